@@ -0,0 +1,99 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newCleanCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "remove cached files",
+		Long: `Clean removes files cached by the cue command.
+
+The --modcache flag removes the module cache, which holds the contents of
+modules downloaded from a registry. With --max-size, only the least
+recently used modules are removed, until the size of the cache is at most
+the given size. Sizes are given in bytes or with one of the suffixes KiB,
+MiB, or GiB.
+
+The module cache is stored in the mod directory of $CUE_CACHE_DIR, which
+defaults to the cue directory in the user's cache directory. If
+$CUE_MODCACHE_MAX_SIZE is set, the module cache is trimmed to that size
+whenever a module is downloaded, which prevents unbounded growth of the
+cache, for instance on CI runners.
+
+Examples:
+
+  # Remove all downloaded modules.
+  $ cue clean --modcache
+
+  # Trim the module cache to 500 MiB.
+  $ cue clean --modcache --max-size 500MiB
+`,
+		Args: cobra.NoArgs,
+		RunE: mkRunE(c, runClean),
+	}
+	cmd.Flags().Bool(string(flagModCache), false,
+		"remove the module cache")
+	cmd.Flags().String(string(flagMaxSize), "",
+		"only remove modules until the cache is at most this size")
+	return cmd
+}
+
+const (
+	flagModCache flagName = "modcache"
+	flagMaxSize  flagName = "max-size"
+)
+
+func runClean(cmd *Command, args []string) error {
+	if !flagModCache.Bool(cmd) {
+		return fmt.Errorf("nothing to clean; use --%s to remove the module cache", flagModCache)
+	}
+	cache, err := modCache()
+	if err != nil {
+		return err
+	}
+	if s := flagMaxSize.String(cmd); s != "" {
+		size, err := parseSize(s)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %v", flagMaxSize, err)
+		}
+		return cache.GC(size)
+	}
+	return cache.Clean()
+}
+
+// parseSize parses a size in bytes, optionally followed by one of the
+// suffixes KiB, MiB, or GiB.
+func parseSize(s string) (int64, error) {
+	mult := int64(1)
+	for i, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if t, ok := strings.CutSuffix(s, suffix); ok {
+			s, mult = t, 1<<(10*(i+1))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a valid size", s)
+	}
+	return n * mult, nil
+}
@@ -19,6 +19,7 @@ import (
 	"os"
 
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/tools/flow"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -27,7 +28,7 @@ import (
 
 func newCmdCmd(c *Command) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "cmd <name> [inputs]",
+		Use:   "cmd <name> [inputs] [-- flags and args]",
 		Short: "run a user-defined shell command",
 		Long: `cmd executes the named command for each of the named instances.
 
@@ -132,6 +133,29 @@ the second to standard output (i.e. it echos it again).
 		}
 	}
 
+A command may declare flags and positional arguments, which are given
+after its name. Inputs must then be separated from these by "--":
+
+	$ cue cmd deploy ./services/... -- --env=prod frontend
+
+By default, all tasks that are ready run concurrently and a task that
+fails fails the command. Use --parallel to limit the number of tasks
+that run concurrently and --retries to retry failed tasks with an
+exponential backoff, starting at --retry-backoff. Individual tasks can
+set these options with a @task attribute, as described in
+"cue help commands".
+
+Tasks marked with @task(cache) are cached if --cache-dir is set. Their
+results are stored keyed by a hash of their inputs, and they are skipped
+when run again with the same inputs. Only tasks without side effects,
+such as fetching a web page, should be cached.
+
+Use --graph to print the tasks of a command and their dependencies in
+the Graphviz DOT or Mermaid format without running them, and --events
+to report the progress of tasks as JSON lines, each with the time of
+the event, the kind of event (start, finish, or error), the path of
+the task, and its duration in seconds.
+
 Run "cue help commands" for more details on tasks and commands.
 `,
 		RunE: mkRunE(c, func(cmd *Command, args []string) error {
@@ -151,7 +175,12 @@ Run "cue help commands" for more details on tasks and commands.
 				fmt.Fprintln(w, "Run 'cue help cmd' for known subcommands.")
 				return ErrPrintedError
 			}
-			tools, err := buildTools(cmd, args[1:])
+			inputs, cmdArgs := splitCommandArgs(args[1:])
+			if n := cmd.Command.ArgsLenAtDash(); n > 0 {
+				// The flag parser of the root command consumes "--".
+				inputs, cmdArgs = args[1:n], args[n:]
+			}
+			tools, err := buildTools(cmd, inputs)
 			if err != nil && !isRootCmd {
 				// `cue cmd` fails immediately if there is no CUE package,
 				// but `cue` does not in order to always show a useful error in `cue typo`.
@@ -175,7 +204,7 @@ Run "cue help commands" for more details on tasks and commands.
 			}
 			// Presumably the *cobra.Command argument should be cmd.Command,
 			// as that is the one which will have the right settings applied.
-			return sub.RunE(cmd.Command, args[1:])
+			return sub.RunE(cmd.Command, cmdArgs)
 		}),
 	}
 
@@ -183,5 +212,21 @@ Run "cue help commands" for more details on tasks and commands.
 
 	addInjectionFlags(cmd.Flags(), true, false)
 
+	cmd.Flags().String(string(flagSummary), "",
+		"print a summary of task durations to stderr after running (text|json)")
+	cmd.Flags().Lookup(string(flagSummary)).NoOptDefVal = "text"
+	cmd.Flags().Int(string(flagParallel), 0,
+		"maximum number of tasks to run concurrently (0 means no limit)")
+	cmd.Flags().Int(string(flagRetries), 0,
+		"number of times to retry a failed task, unless set with @task(retries=n)")
+	cmd.Flags().Duration(string(flagRetryBackoff), flow.DefaultBackoff,
+		"time to wait before the first retry of a task, which doubles with every retry")
+	cmd.Flags().String(string(flagCacheDir), "",
+		"directory in which to cache the results of tasks marked with @task(cache)")
+	cmd.Flags().String(string(flagGraph), "",
+		"print the task graph instead of running the command (dot|mermaid)")
+	cmd.Flags().Bool(string(flagEvents), false,
+		"write the start, finish and error events of tasks to stderr as JSON lines")
+
 	return cmd
 }
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -38,6 +39,7 @@ import (
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/encoding"
 	"cuelang.org/go/internal/filetypes"
+	"cuelang.org/go/internal/mod/modcache"
 	"cuelang.org/go/internal/value"
 )
 
@@ -48,6 +50,21 @@ func defaultConfig() (*config, error) {
 	if err != nil {
 		return nil, err
 	}
+	// TODO document CUE_CACHE_DIR via a new "cue help environment" subcommand.
+	cacheDir := os.Getenv("CUE_CACHE_DIR")
+	if requestedVersion != "" {
+		// The parse results depend on the requested version,
+		// which is not part of the cache key.
+		cacheDir = ""
+	}
+	var cache modcache.Cache
+	if reg != nil {
+		disk, err := modCache()
+		if err != nil {
+			return nil, err
+		}
+		cache = disk
+	}
 	return &config{
 		loadCfg: &load.Config{
 			ParseFile: func(name string, src interface{}) (*ast.File, error) {
@@ -69,6 +86,8 @@ func defaultConfig() (*config, error) {
 				return parser.ParseFile(name, src, options...)
 			},
 			Registry: reg,
+			ModCache: cache,
+			CacheDir: cacheDir,
 		},
 	}, nil
 }
@@ -89,6 +108,15 @@ func exitOnErr(cmd *Command, err error, fatal bool) {
 		return
 	}
 
+	if d := cmd.diags; d != nil {
+		d.addErr(cmd, err)
+		if fatal {
+			d.flush(cmd)
+			exit()
+		}
+		return
+	}
+
 	// Link x/text as our localizer.
 	p := message.NewPrinter(getLang())
 	format := func(w io.Writer, format string, args ...interface{}) {
@@ -111,11 +139,17 @@ func exitOnErr(cmd *Command, err error, fatal bool) {
 	}
 }
 
-func loadFromArgs(args []string, cfg *load.Config) []*build.Instance {
+func loadFromArgs(cmd *Command, args []string, cfg *load.Config) []*build.Instance {
 	binst := load.Instances(args, cfg)
 	if len(binst) == 0 {
 		return nil
 	}
+	for i, b := range binst {
+		cmd.logger.Progress("load", i+1, len(binst), fmt.Sprintf("%s (%d files)", b.DisplayPath, len(b.BuildFiles)))
+	}
+	if cmd.watching() {
+		cmd.watch.files = append(cmd.watch.files, watchedFiles(binst)...)
+	}
 
 	return binst
 }
@@ -154,6 +188,13 @@ type buildPlan struct {
 	path       []ast.Label
 	useContext bool
 
+	// stream, if set, causes imported documents to be written to their
+	// output files one by one, without holding them in memory. The
+	// documents are written to separate files by the value of shard, if
+	// set.
+	stream bool
+	shard  ast.Expr
+
 	// outFile defines the file to output to. Default is CUE stdout.
 	outFile *build.File
 
@@ -306,6 +347,9 @@ func (i *streamingIterator) scan() bool {
 				// Validate should always be non-nil, but just in case.
 				i.e = err
 			}
+			if i.b.cfg.continueOnSchemaErr {
+				i.e = nil
+			}
 		}
 		i.f = nil
 	}
@@ -376,6 +420,11 @@ type config struct {
 
 	noMerge bool // do not merge individual data files.
 
+	// continueOnSchemaErr continues iterating over data values if a value
+	// does not validate against the schema. The error is then reported
+	// through the value.
+	continueOnSchemaErr bool
+
 	loadCfg *load.Config
 }
 
@@ -464,7 +513,8 @@ func (p *buildPlan) getDecoders(b *build.Instance) (schemas, values []*decoderIn
 		}
 		switch f.Encoding {
 		case build.Protobuf, build.YAML, build.JSON, build.JSONL,
-			build.Text, build.Binary:
+			build.TOML, build.XML, build.HCL, build.INI, build.DotEnv,
+			build.GraphQL, build.Text, build.Binary:
 			if f.Interpretation == build.ProtobufJSON {
 				// Need a schema.
 				values = append(values, &decoderInfo{f, nil})
@@ -478,6 +528,14 @@ func (p *buildPlan) getDecoders(b *build.Instance) (schemas, values []*decoderIn
 			// Needs to be decoded after any schema.
 			values = append(values, &decoderInfo{f, nil})
 			continue
+		case build.BinaryProto:
+			if p.importing {
+				return schemas, values, errors.Newf(token.NoPos,
+					"cannot import binary protobuf files")
+			}
+			// Needs to be decoded after any schema.
+			values = append(values, &decoderInfo{f, nil})
+			continue
 		default:
 			return schemas, values, errors.Newf(token.NoPos,
 				"unsupported encoding %q", f.Encoding)
@@ -538,7 +596,7 @@ func parseArgs(cmd *Command, args []string, cfg *config) (p *buildPlan, err erro
 
 	adt.DebugSort, _ = strconv.Atoi(os.Getenv("CUE_DEBUG_SORT_ARCS"))
 
-	builds := loadFromArgs(args, cfg.loadCfg)
+	builds := loadFromArgs(cmd, args, cfg.loadCfg)
 	if builds == nil {
 		return nil, errors.Newf(token.NoPos, "invalid args")
 	}
@@ -726,6 +784,12 @@ func (b *buildPlan) parseFlags() (err error) {
 		Strict:        flagStrict.Bool(b.cmd),
 		InlineImports: flagInlineImports.Bool(b.cmd),
 		EscapeHTML:    flagEscape.Bool(b.cmd),
+		PreserveYAML:  flagPreserveYAML.Bool(b.cmd),
+	}
+	if !b.cmd.watching() {
+		// Files may change while watching, which would invalidate their
+		// mappings.
+		b.encConfig.Mappings = b.cmd.mappings
 	}
 	return nil
 }
@@ -734,6 +798,9 @@ func buildInstances(cmd *Command, binst []*build.Instance, ignoreErrors bool) []
 	// TODO:
 	// If there are no files and User is true, then use those?
 	// Always use all files in user mode?
+	for i, b := range binst {
+		cmd.logger.Progress("build", i+1, len(binst), b.DisplayPath)
+	}
 	instances, err := cmd.ctx.BuildInstances(binst)
 	exitOnErr(cmd, err, true)
 
@@ -784,7 +851,7 @@ func buildTools(cmd *Command, args []string) (*cue.Instance, error) {
 	f := cmd.cmd.Flags()
 	setTags(cfg, f, cmd.cmd.Parent().Flags())
 
-	binst := loadFromArgs(args, cfg)
+	binst := loadFromArgs(cmd, args, cfg)
 	if len(binst) == 0 {
 		return nil, nil
 	}
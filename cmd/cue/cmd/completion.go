@@ -16,8 +16,23 @@ package cmd
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/encoding"
+	"cuelang.org/go/internal/filetypes"
 )
 
 var validCompletionArgs = []string{"bash", "zsh", "fish", "powershell"}
@@ -50,9 +65,18 @@ $ cue completion fish > ~/.config/fish/completions/cue.fish
 
 func newCompletionCmd(c *Command) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:       fmt.Sprintf("completion %s", validCompletionArgs),
-		Short:     "Generate completion script",
-		Long:      ``,
+		Use:   fmt.Sprintf("completion %s", validCompletionArgs),
+		Short: "Generate completion script",
+		Long: `completion generates a script that sets up command line completion for
+the given shell.
+
+Besides commands and flags, the generated completion suggests input files,
+directories and the packages of the current module, the names of custom
+commands, field paths for --expression and --path, and tag names for
+--inject. These are derived from the files of the inputs without evaluating
+them, so fields that are only defined by comprehensions, embeddings or
+references are not suggested.
+`,
 		Example:   completionExample,
 		ValidArgs: validCompletionArgs,
 		Args:      cobra.ExactValidArgs(1),
@@ -77,3 +101,356 @@ func runCompletion(cmd *Command, args []string) error {
 	}
 	return nil
 }
+
+// inputCommands are the commands of which all arguments are inputs.
+var inputCommands = map[string]bool{
+	"def":    true,
+	"eval":   true,
+	"export": true,
+	"fix":    true,
+	"fmt":    true,
+	"lint":   true,
+	"test":   true,
+	"trim":   true,
+	"vet":    true,
+}
+
+// addCompletions sets up the dynamic completion of the arguments and flags
+// of cmd and its subcommands. Completions are computed from a partial load
+// of the inputs: files are parsed, but not evaluated, so that completion is
+// fast. As a consequence, fields defined by comprehensions, embeddings or
+// references are not suggested.
+func addCompletions(cmd *cobra.Command) {
+	switch {
+	case cmd.Name() == "cmd":
+		cmd.ValidArgsFunction = completeCommands
+	case inputCommands[cmd.Name()]:
+		cmd.ValidArgsFunction = completeInputs
+	}
+	for name, f := range map[flagName]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+		flagExpression: completeExpressions,
+		flagInject:     completeTags,
+		flagPath:       completeDataPaths,
+	} {
+		if cmd.Flags().Lookup(string(name)) != nil {
+			cmd.RegisterFlagCompletionFunc(string(name), f)
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		addCompletions(sub)
+	}
+}
+
+// completionInputs returns the inputs among the arguments args of cmd.
+func completionInputs(cmd *cobra.Command, args []string) []string {
+	if cmd.Name() == "cmd" || !cmd.HasParent() {
+		// The first argument is the name of a custom command.
+		if len(args) == 0 {
+			return nil
+		}
+		inputs, _ := splitCommandArgs(args[1:])
+		return inputs
+	}
+	return args
+}
+
+// completeInputs suggests directories, files and the import paths of the
+// packages of the current module.
+func completeInputs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var a []string
+	if !strings.HasPrefix(toComplete, ".") && !filepath.IsAbs(toComplete) {
+		a = append(a, modulePackages(toComplete)...)
+	}
+	a = append(a, completeFiles(toComplete)...)
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if len(a) == 1 && strings.HasSuffix(a[0], "/") {
+		directive |= cobra.ShellCompDirectiveNoSpace
+	}
+	return a, directive
+}
+
+// completeFiles suggests the directories and input files matching
+// toComplete. Directories relative to the current directory start with
+// "./", so that they are not taken to be import paths.
+func completeFiles(toComplete string) []string {
+	dir, base := path.Split(filepath.ToSlash(toComplete))
+	readDir, dirPrefix := dir, dir
+	if dir == "" {
+		readDir, dirPrefix = ".", "./"
+	}
+	entries, err := os.ReadDir(filepath.FromSlash(readDir))
+	if err != nil {
+		return nil
+	}
+	var a []string
+	if base == "" || (dir == "" && base == ".") {
+		if dir == "" {
+			a = append(a, ".", "./...")
+		} else {
+			a = append(a, dir+"...")
+		}
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		switch {
+		case e.IsDir():
+			if name != "cue.mod" {
+				a = append(a, dirPrefix+name+"/")
+			}
+		case isInputFile(name):
+			a = append(a, dir+name)
+		}
+	}
+	return a
+}
+
+func isInputFile(name string) bool {
+	f, err := filetypes.ParseFile(name, filetypes.Input)
+	return err == nil && f.Encoding != ""
+}
+
+// modulePackages returns the import paths of the packages in the current
+// module that start with prefix.
+func modulePackages(prefix string) []string {
+	root, err := findModuleRoot()
+	if err != nil {
+		return nil
+	}
+	mf, err := readModFile(root)
+	if err != nil || mf == nil || mf.Module == "" {
+		return nil
+	}
+	mod, _, _ := strings.Cut(mf.Module, "@")
+	if !strings.HasPrefix(mod, prefix) && !strings.HasPrefix(prefix, mod) {
+		return nil
+	}
+	seen := map[string]bool{}
+	var a []string
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p == root {
+				return nil
+			}
+			name := d.Name()
+			if name == "cue.mod" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, "cue.mod")); err == nil {
+				return filepath.SkipDir // nested module
+			}
+			return nil
+		}
+		dir := filepath.Dir(p)
+		if filepath.Ext(p) != ".cue" || seen[dir] {
+			return nil
+		}
+		seen[dir] = true
+		importPath := mod
+		if rel, _ := filepath.Rel(root, dir); rel != "." {
+			importPath += "/" + filepath.ToSlash(rel)
+		}
+		if strings.HasPrefix(importPath, prefix) {
+			a = append(a, importPath)
+		}
+		return nil
+	})
+	sort.Strings(a)
+	return a
+}
+
+// completeCommands suggests the names of custom commands for the first
+// argument of cue cmd, and inputs for the remaining ones.
+func completeCommands(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		for _, a := range args[1:] {
+			if a == "--" {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		}
+		return completeInputs(cmd, args, toComplete)
+	}
+	var files []*ast.File
+	for _, inst := range load.Instances(nil, &load.Config{Tools: true}) {
+		for _, f := range inst.Files {
+			if strings.HasSuffix(f.Filename, "_tool.cue") {
+				files = append(files, f)
+			}
+		}
+	}
+	var names []string
+	for _, p := range completeFieldPaths(files, commandSection+"."+toComplete) {
+		names = append(names, strings.TrimPrefix(p, commandSection+"."))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeExpressions suggests the field paths of the inputs.
+func completeExpressions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var files []*ast.File
+	for _, inst := range load.Instances(completionInputs(cmd, args), &load.Config{}) {
+		files = append(files, inst.Files...)
+	}
+	return completeFieldPaths(files, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDataPaths suggests the field paths of the first value of each
+// data file among the inputs.
+func completeDataPaths(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var files []*ast.File
+	for _, inst := range load.Instances(completionInputs(cmd, args), &load.Config{DataFiles: true}) {
+		for _, f := range append(inst.OrphanedFiles, inst.BuildFiles...) {
+			if f.Encoding == build.CUE {
+				continue
+			}
+			d := encoding.NewDecoder(f, &encoding.Config{Mode: filetypes.Input})
+			if !d.Done() {
+				files = append(files, d.File())
+			}
+			d.Close()
+		}
+	}
+	return completeFieldPaths(files, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFieldPaths suggests the paths of the fields declared in files
+// that complete toComplete, which is a partial path of labels separated by
+// dots.
+func completeFieldPaths(files []*ast.File, toComplete string) []string {
+	parent, base := "", toComplete
+	var path []string
+	if i := strings.LastIndexByte(toComplete, '.'); i >= 0 {
+		parent, base = toComplete[:i+1], toComplete[i+1:]
+		for _, l := range strings.Split(toComplete[:i], ".") {
+			if s, err := literal.Unquote(l); err == nil {
+				l = s
+			}
+			path = append(path, l)
+		}
+	}
+	var decls []ast.Decl
+	for _, f := range files {
+		decls = append(decls, declsAt(f.Decls, path)...)
+	}
+	seen := map[string]bool{}
+	var a []string
+	for _, d := range decls {
+		f, ok := d.(*ast.Field)
+		if !ok {
+			continue
+		}
+		name, _, err := ast.LabelName(f.Label)
+		if err != nil || strings.HasPrefix(name, "_") {
+			continue
+		}
+		label := name
+		if !ast.IsValidIdent(name) {
+			label = literal.Label.Quote(name)
+		}
+		if strings.HasPrefix(label, base) && !seen[label] {
+			seen[label] = true
+			a = append(a, parent+label)
+		}
+	}
+	sort.Strings(a)
+	return a
+}
+
+// declsAt returns the declarations of the struct literals at path in decls.
+func declsAt(decls []ast.Decl, path []string) []ast.Decl {
+	if len(path) == 0 {
+		return decls
+	}
+	var a []ast.Decl
+	for _, d := range decls {
+		var x ast.Expr
+		switch d := d.(type) {
+		case *ast.Field:
+			if name, _, err := ast.LabelName(d.Label); err != nil || name != path[0] {
+				continue
+			}
+			for _, s := range structLits(d.Value) {
+				a = append(a, declsAt(s.Elts, path[1:])...)
+			}
+			continue
+		case *ast.EmbedDecl:
+			x = d.Expr
+		default:
+			continue
+		}
+		for _, s := range structLits(x) {
+			a = append(a, declsAt(s.Elts, path)...)
+		}
+	}
+	return a
+}
+
+// structLits returns the struct literals that are unified in x.
+func structLits(x ast.Expr) []*ast.StructLit {
+	switch x := x.(type) {
+	case *ast.StructLit:
+		return []*ast.StructLit{x}
+	case *ast.ParenExpr:
+		return structLits(x.X)
+	case *ast.BinaryExpr:
+		if x.Op == token.AND {
+			return append(structLits(x.X), structLits(x.Y)...)
+		}
+	}
+	return nil
+}
+
+// completeTags suggests the names of the tags declared in the inputs, and
+// their short values.
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.Contains(toComplete, "=") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	// Tool files may only declare tags used by custom commands.
+	cfg := &load.Config{Tools: cmd.Name() == "cmd" || !cmd.HasParent()}
+	seen := map[string]bool{}
+	var a []string
+	add := func(s string) {
+		if strings.HasPrefix(s, toComplete) && !seen[s] {
+			seen[s] = true
+			a = append(a, s)
+		}
+	}
+	for _, inst := range load.Instances(completionInputs(cmd, args), cfg) {
+		for _, f := range inst.Files {
+			ast.Walk(f, func(n ast.Node) bool {
+				x, ok := n.(*ast.Attribute)
+				if !ok {
+					return true
+				}
+				key, body := x.Split()
+				if key != "tag" {
+					return true
+				}
+				attr := internal.ParseAttrBody(x.Pos(), body)
+				name, err := attr.String(0)
+				if err != nil || name == "" {
+					return true
+				}
+				add(name + "=")
+				if short, ok, _ := attr.Lookup(1, "short"); ok {
+					for _, s := range strings.Split(short, "|") {
+						add(s)
+					}
+				}
+				return true
+			}, nil)
+		}
+	}
+	sort.Strings(a)
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if len(a) == 1 && strings.HasSuffix(a[0], "=") {
+		directive |= cobra.ShellCompDirectiveNoSpace
+	}
+	return a, directive
+}
@@ -26,6 +26,7 @@ import (
 	"sync"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
@@ -33,10 +34,15 @@ import (
 	itask "cuelang.org/go/internal/task"
 	"cuelang.org/go/internal/value"
 	_ "cuelang.org/go/pkg/tool/cli" // Register tasks
+	_ "cuelang.org/go/pkg/tool/cloud"
 	_ "cuelang.org/go/pkg/tool/exec"
 	_ "cuelang.org/go/pkg/tool/file"
+	_ "cuelang.org/go/pkg/tool/git"
 	_ "cuelang.org/go/pkg/tool/http"
+	_ "cuelang.org/go/pkg/tool/oci"
 	_ "cuelang.org/go/pkg/tool/os"
+	_ "cuelang.org/go/pkg/tool/random"
+	_ "cuelang.org/go/pkg/tool/time"
 	"cuelang.org/go/tools/flow"
 )
 
@@ -124,36 +130,109 @@ outer:
 		usage = lookupString(o, "$usage", usage)
 		long = lookupString(o, "long", txt)
 	}
+	params, err := newCommandParams(o)
+	if err != nil {
+		return nil, err
+	}
 	if !strings.HasPrefix(usage, name+" ") {
-		usage = name
+		usage = params.use(name)
 	}
 	sub := &cobra.Command{
 		Use:   usage,
 		Short: lookupString(o, "$short", short),
 		Long:  lookupString(o, "$long", long),
-		RunE: mkRunE(c, func(cmd *Command, args []string) error {
-			// TODO:
-			// - parse flags and env vars
-			// - constrain current config with config section
-
-			return doTasks(cmd, typ, name, tools)
-		}),
 	}
-
-	// TODO: implement var/flag handling.
+	params.addFlags(sub.Flags())
+	sub.RunE = mkRunE(c, func(cmd *Command, args []string) error {
+		path := cue.MakePath(cue.Str(typ), cue.Str(name))
+		root, err := params.inject(sub.Flags(), tools.Value(), path, args)
+		if err == pflag.ErrHelp {
+			cmd.Command.AddCommand(sub)
+			sub.SetOut(cmd.OutOrStdout())
+			return sub.Help()
+		}
+		if err != nil {
+			return err
+		}
+		return doTasks(cmd, typ, name, root)
+	})
 	return sub, nil
 }
 
-func doTasks(cmd *Command, typ, command string, root *cue.Instance) error {
+func doTasks(cmd *Command, typ, command string, root cue.Value) error {
 	cfg := &flow.Config{
 		Root:           cue.MakePath(cue.Str(commandSection), cue.Str(command)),
 		InferTasks:     true,
 		IgnoreConcrete: true,
+		MaxParallel:    flagParallel.Int(cmd),
+		UpdateFunc: func(c *flow.Controller, t *flow.Task) error {
+			if t == nil || t.State() != flow.Terminated {
+				return nil
+			}
+			tasks := c.Tasks()
+			done := 0
+			for _, t := range tasks {
+				if t.State() == flow.Terminated {
+					done++
+				}
+			}
+			cmd.logger.Progress("task", done, len(tasks), t.Path().String())
+			return nil
+		},
+	}
+
+	if n := flagParallel.Int(cmd); n < 0 {
+		return errors.Newf(token.NoPos, "--%s must not be negative", flagParallel)
+	}
+	if n := flagRetries.Int(cmd); n > 0 {
+		cfg.Retry = &flow.Retry{
+			Retries: n,
+			Backoff: flagRetryBackoff.Duration(cmd),
+		}
+	} else if n < 0 {
+		return errors.Newf(token.NoPos, "--%s must not be negative", flagRetries)
+	}
+
+	if dir := flagCacheDir.String(cmd); dir != "" {
+		cfg.Cache = flow.DirCache{Dir: dir}
+	}
+
+	var summary *taskSummary
+	format := flagSummary.String(cmd)
+	switch format {
+	case "":
+	case "text", "json":
+		summary = newTaskSummary()
+		cfg.Tracer = summary
+	default:
+		return errors.Newf(token.NoPos, "unknown --%s format %q; must be text or json", flagSummary, format)
+	}
+
+	if flagEvents.Bool(cmd) {
+		cfg.Subscriber = newTaskEvents(cmd.OutOrStderr())
 	}
 
 	c := flow.New(cfg, root, newTaskFunc(cmd))
 
+	switch format := flagGraph.String(cmd); format {
+	case "":
+	case "dot":
+		_, err := io.WriteString(cmd.OutOrStdout(), c.Graph().DOT())
+		return err
+	case "mermaid":
+		_, err := io.WriteString(cmd.OutOrStdout(), c.Graph().Mermaid())
+		return err
+	default:
+		return errors.Newf(token.NoPos, "unknown --%s format %q; must be dot or mermaid", flagGraph, format)
+	}
+
 	err := c.Run(context.Background())
+	if summary != nil {
+		// Report the summary before any error, which exits.
+		if err := summary.write(cmd.OutOrStderr(), format, c.Tasks()); err != nil {
+			return err
+		}
+	}
 	exitOnErr(cmd, err, true)
 
 	return err
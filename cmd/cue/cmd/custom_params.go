@@ -0,0 +1,289 @@
+// Copyright 2024 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+// This file contains code for the flags and positional arguments declared
+// by custom commands.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+const (
+	flagsField = "$flags"
+	argsField  = "$args"
+)
+
+// commandParams holds the flags and positional arguments declared by a
+// custom command.
+type commandParams struct {
+	flags []*commandParam
+	args  []*commandParam
+}
+
+// A commandParam is a flag or positional argument of a custom command. It
+// is declared as a field, where the label is the name of the parameter, the
+// kind of the value its type, the default of the value its default, and
+// the first line of the doc comment its usage.
+type commandParam struct {
+	name  string
+	typ   string // bool, int, number, string, or strings
+	usage string
+
+	hasDefault bool
+	def        string // default in the format used by --help
+
+	values []string // values given on the command line
+}
+
+// splitCommandArgs splits the arguments following the name of a custom
+// command into the inputs from which to load the command and the flags and
+// arguments of the command itself. The latter follow "--", or comprise all
+// arguments if the first is a flag.
+func splitCommandArgs(args []string) (inputs, cmdArgs []string) {
+	if len(args) > 0 && args[0] != "--" && strings.HasPrefix(args[0], "-") {
+		return nil, args
+	}
+	for i, a := range args {
+		if a == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+func newCommandParams(o cue.Value) (*commandParams, error) {
+	flags, err := declaredParams(o, flagsField)
+	if err != nil {
+		return nil, err
+	}
+	args, err := declaredParams(o, argsField)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range args {
+		if a.typ == "strings" && i != len(args)-1 {
+			return nil, errors.Newf(o.LookupPath(cue.MakePath(cue.Str(argsField), cue.Str(a.name))).Pos(),
+				"%s.%s: only the last argument may be a list", argsField, a.name)
+		}
+	}
+	return &commandParams{flags: flags, args: args}, nil
+}
+
+func declaredParams(o cue.Value, field string) ([]*commandParam, error) {
+	v := o.LookupPath(cue.MakePath(cue.Str(field)))
+	if !v.Exists() {
+		return nil, nil
+	}
+	iter, err := v.Fields()
+	if err != nil {
+		return nil, err
+	}
+	var params []*commandParam
+	for iter.Next() {
+		x := iter.Value()
+		p := &commandParam{name: iter.Selector().Unquoted()}
+		switch k := x.IncompleteKind(); {
+		case k == cue.BoolKind:
+			p.typ = "bool"
+		case k == cue.IntKind:
+			p.typ = "int"
+		case k == cue.FloatKind, k == cue.NumberKind:
+			p.typ = "number"
+		case k == cue.StringKind:
+			p.typ = "string"
+		case k == cue.ListKind:
+			p.typ = "strings"
+		default:
+			return nil, errors.Newf(x.Pos(),
+				"%s.%s: unsupported type %v; must be bool, int, number, string, or a list of strings",
+				field, p.name, k)
+		}
+		if d, ok := x.Default(); ok && d.IsConcrete() {
+			p.hasDefault = true
+			var list []string
+			switch {
+			case p.typ == "string":
+				p.def, _ = d.String()
+			case p.typ == "strings" && d.Decode(&list) == nil:
+				if len(list) > 0 {
+					p.def = "[" + strings.Join(list, ",") + "]"
+				}
+			default:
+				p.def = fmt.Sprint(d)
+			}
+		}
+		if docs := x.Doc(); len(docs) > 0 {
+			p.usage, _ = splitLine(docs[0].Text())
+			p.usage = strings.TrimSpace(p.usage)
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// addFlags adds the declared flags to f.
+func (p *commandParams) addFlags(f *pflag.FlagSet) {
+	for _, x := range p.flags {
+		fl := f.VarPF(x, x.name, "", x.usage)
+		if x.typ == "bool" {
+			fl.NoOptDefVal = "true"
+		}
+	}
+}
+
+// use returns the usage line of a command with the given name.
+func (p *commandParams) use(name string) string {
+	b := &strings.Builder{}
+	b.WriteString(name)
+	for _, a := range p.args {
+		arg := a.name
+		if a.typ == "strings" {
+			arg += "..."
+		}
+		if a.hasDefault || a.typ == "strings" {
+			arg = "[" + arg + "]"
+		}
+		fmt.Fprintf(b, " %s", arg)
+	}
+	return b.String()
+}
+
+// inject parses the flags and arguments of a command and fills their values
+// in the command at path in v.
+func (p *commandParams) inject(f *pflag.FlagSet, v cue.Value, path cue.Path, args []string) (cue.Value, error) {
+	if err := f.Parse(args); err != nil {
+		return v, err
+	}
+	args = f.Args()
+
+	fill := func(field string, x *commandParam) error {
+		val, err := x.value()
+		if err != nil {
+			return err
+		}
+		sel := append(path.Selectors(), cue.Str(field), cue.Str(x.name))
+		v = v.FillPath(cue.MakePath(sel...), val)
+		return nil
+	}
+
+	for _, x := range p.flags {
+		switch {
+		case len(x.values) == 0 && x.typ == "bool" && !x.hasDefault:
+			// A boolean flag without a default is false if not set.
+			x.values = []string{"false"}
+			fallthrough
+		case len(x.values) > 0:
+			if err := fill(flagsField, x); err != nil {
+				return v, err
+			}
+		case !x.hasDefault:
+			return v, errors.Newf(token.NoPos, "missing value for flag --%s", x.name)
+		}
+	}
+
+	for i, x := range p.args {
+		switch {
+		case x.typ == "strings":
+			if i < len(args) {
+				x.values = args[i:]
+				args = nil
+			} else if x.hasDefault {
+				continue
+			}
+			if err := fill(argsField, x); err != nil {
+				return v, err
+			}
+		case i < len(args):
+			x.values = args[i : i+1]
+			if err := fill(argsField, x); err != nil {
+				return v, err
+			}
+		case !x.hasDefault:
+			return v, errors.Newf(token.NoPos, "missing argument %s", x.name)
+		}
+	}
+	if len(args) > len(p.args) {
+		return v, errors.Newf(token.NoPos, "too many arguments; expected at most %d", len(p.args))
+	}
+
+	for _, field := range []string{flagsField, argsField} {
+		sel := append(path.Selectors(), cue.Str(field))
+		x := v.LookupPath(cue.MakePath(sel...))
+		if !x.Exists() {
+			continue
+		}
+		if err := x.Validate(); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+// value converts the values given on the command line to a value of the
+// declared type.
+func (p *commandParam) value() (interface{}, error) {
+	if p.typ == "strings" {
+		return append([]string{}, p.values...), nil
+	}
+	s := p.values[len(p.values)-1]
+	var x interface{}
+	var err error
+	switch p.typ {
+	case "bool":
+		x, err = strconv.ParseBool(s)
+	case "int":
+		x, err = strconv.ParseInt(s, 0, 64)
+	case "number":
+		if x, err = strconv.ParseInt(s, 0, 64); err != nil {
+			x, err = strconv.ParseFloat(s, 64)
+		}
+	default:
+		x = s
+	}
+	if err != nil {
+		return nil, errors.Newf(token.NoPos, "invalid %s value %q for %s", p.typ, s, p.name)
+	}
+	return x, nil
+}
+
+// String implements pflag.Value. It returns the default, as it is used to
+// display the default in the usage of a command.
+func (p *commandParam) String() string {
+	return p.def
+}
+
+// Set implements pflag.Value.
+func (p *commandParam) Set(s string) error {
+	if p.typ != "strings" {
+		p.values = p.values[:0]
+	}
+	p.values = append(p.values, s)
+	_, err := p.value()
+	return err
+}
+
+// Type implements pflag.Value.
+func (p *commandParam) Type() string {
+	return p.typ
+}
@@ -0,0 +1,273 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+const diagnosticsHelp = `
+Machine-readable errors
+
+The --format flag selects how errors are reported on stderr. With
+--format=json, each error is written as a JSON object on its own line:
+
+  {"severity":"error","path":"a.b","message":"conflicting values 1 and 2",
+   "filename":"x.cue","line":3,"column":5,
+   "constraints":[{"filename":"x.cue","line":2,"column":5}]}
+
+The constraints hold the positions of the values and constraints that
+caused the error. With --format=sarif, all errors are written as a single
+SARIF 2.1.0 log when the command finishes, with the positions of the
+constraints as related locations. The exit status does not depend on the
+format.
+`
+
+func addDiagnosticsFlag(f *pflag.FlagSet) {
+	f.String(string(flagFormat), "text",
+		"format of error messages (text|json|sarif)")
+}
+
+// diagnostics reports errors in a machine-readable format, for use by
+// CI systems and code review tools. A nil *diagnostics reports errors as
+// text.
+type diagnostics struct {
+	sarif bool
+	cwd   string
+
+	// pending reports whether a SARIF log is still to be written for the
+	// current run, holding results.
+	pending bool
+	results []diagnostic
+}
+
+func newDiagnostics(c *Command) (*diagnostics, error) {
+	d := &diagnostics{}
+	switch format := flagFormat.String(c); format {
+	case "", "text":
+		return nil, nil
+	case "json":
+	case "sarif":
+		d.sarif = true
+	default:
+		return nil, fmt.Errorf("unknown --%s %q; must be text, json or sarif", flagFormat, format)
+	}
+	d.cwd, _ = os.Getwd()
+	d.begin()
+	return d, nil
+}
+
+// begin starts a new run of the command, for which a SARIF log is written
+// by the next call to flush.
+func (d *diagnostics) begin() {
+	if d != nil {
+		d.pending = true
+	}
+}
+
+type diagnostic struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+	location
+	Constraints []location `json:"constraints,omitempty"`
+}
+
+type location struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// addErr reports the errors in err.
+func (d *diagnostics) addErr(c *Command, err error) {
+	for _, e := range errors.Errors(errors.Sanitize(errors.Promote(err, ""))) {
+		x := diagnostic{Severity: "error"}
+		x.Path = strings.Join(e.Path(), ".")
+		x.Message = strings.TrimPrefix(errors.String(e), x.Path+": ")
+		// The first position is that of the error itself if it has one.
+		for i, p := range errors.Positions(e) {
+			if i == 0 {
+				x.location = d.location(p)
+			} else {
+				x.Constraints = append(x.Constraints, d.location(p))
+			}
+		}
+		d.add(c, x)
+	}
+}
+
+// addWarning reports a message that does not concern a specific value.
+func (d *diagnostics) addWarning(c *Command, msg string) {
+	d.add(c, diagnostic{Severity: "warning", Message: msg})
+}
+
+func (d *diagnostics) add(c *Command, x diagnostic) {
+	if d.sarif {
+		c.hasErr = true
+		d.pending = true
+		d.results = append(d.results, x)
+		return
+	}
+	c.Stderr().Write(marshal(x, ""))
+}
+
+func (d *diagnostics) location(p token.Pos) location {
+	pos := p.Position()
+	filename := pos.Filename
+	if rel, err := filepath.Rel(d.cwd, filename); err == nil && filename != "" {
+		filename = rel
+	}
+	return location{
+		Filename: filepath.ToSlash(filename),
+		Line:     pos.Line,
+		Column:   pos.Column,
+	}
+}
+
+// flush writes the pending SARIF log. It is written even if there are no
+// errors, so that tools consuming it can tell a clean run from a failed one.
+func (d *diagnostics) flush(c *Command) {
+	if d == nil || !d.sarif || !d.pending {
+		return
+	}
+	d.pending = false
+	results := []sarifResult{}
+	for _, x := range d.results {
+		r := sarifResult{
+			Level:   x.Severity,
+			Message: sarifMessage{Text: x.Message},
+		}
+		if x.Filename != "" {
+			loc := newSarifLocation(x.location)
+			if x.Path != "" {
+				loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: x.Path}}
+			}
+			r.Locations = append(r.Locations, loc)
+		} else if x.Path != "" {
+			r.Locations = append(r.Locations, sarifLocation{
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: x.Path}},
+			})
+		}
+		for _, l := range x.Constraints {
+			r.RelatedLocations = append(r.RelatedLocations, newSarifLocation(l))
+		}
+		results = append(results, r)
+	}
+	d.results = nil
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "cue",
+				InformationURI: "https://cuelang.org",
+			}},
+			Results: results,
+		}},
+	}
+	// Use OutOrStderr directly: the exit status was already set when the
+	// errors were added.
+	c.OutOrStderr().Write(marshal(log, "\t"))
+}
+
+// marshal encodes x as JSON followed by a newline. Unlike json.Marshal, it
+// does not escape characters such as > and &, which are common in messages.
+func marshal(x interface{}, indent string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(x); err != nil {
+		panic(err) // the types used here can always be encoded
+	}
+	return buf.Bytes()
+}
+
+// The following types are the subset of SARIF 2.1.0 that is used to
+// report errors. See https://docs.oasis-open.org/sarif/sarif/v2.1.0.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func newSarifLocation(l location) sarifLocation {
+	p := &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: l.Filename}}
+	if l.Line > 0 {
+		p.Region = &sarifRegion{StartLine: l.Line, StartColumn: l.Column}
+	}
+	return sarifLocation{PhysicalLocation: p}
+}
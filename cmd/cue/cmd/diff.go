@@ -0,0 +1,441 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/filetypes"
+)
+
+const (
+	flagRev            flagName = "rev"
+	flagIgnoreDefaults flagName = "ignore-defaults"
+	flagIgnoreOrder    flagName = "ignore-order"
+	flagIgnorePath     flagName = "ignore"
+)
+
+func newDiffCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <a> <b> | --rev <revision> <a>",
+		Short: "compare configurations by value",
+		Long: `diff reports how the values of two configurations differ
+
+Diff evaluates two packages or data files and compares their values,
+rather than their text. It prints a line for each path that differs:
+
+  + path: value           the path only exists in b
+  - path: value           the path only exists in a
+  ~ path: value -> value  the path has a different value in b
+
+Fields that exist in both configurations are compared recursively. The
+order of fields does not matter, but that of list elements does, unless
+--ignore-order is given, in which case lists are compared as collections.
+
+With --rev, the configuration a at the given git revision is compared to
+the one in the working directory. The revision is anything accepted by
+git, such as a branch, tag, or commit.
+
+The --ignore-defaults flag ignores differences of fields that, on either
+side, are absent or set by a default, so that a change to a default in a
+schema does not show up as a change to every configuration using it.
+
+The --ignore flag excludes the values matching a query, and everything
+below them, from the comparison. It accepts the queries of 'cue query',
+so that, for instance, * matches any field or element.
+
+Diff exits with status 1 if the configurations differ.
+
+Examples:
+
+  # Compare two rendered manifests.
+  cue diff old.yaml new.yaml
+
+  # Review the changes to a package since the main branch.
+  cue diff --rev main ./manifests
+
+  # Ignore generated annotations.
+  cue diff --ignore 'deployment.*.metadata.annotations' ./a ./b
+`,
+		RunE: mkRunE(c, runDiff),
+	}
+
+	addInjectionFlags(cmd.Flags(), false, false)
+
+	cmd.Flags().String(string(flagRev), "",
+		"compare with the configuration at the given git revision")
+	cmd.Flags().Bool(string(flagIgnoreDefaults), false,
+		"ignore fields that are absent or set by a default on either side")
+	cmd.Flags().Bool(string(flagIgnoreOrder), false,
+		"ignore the order of list elements")
+	cmd.Flags().StringArray(string(flagIgnorePath), nil,
+		"ignore differences at and below the paths matching the given query")
+
+	return cmd
+}
+
+func runDiff(cmd *Command, args []string) error {
+	rev := flagRev.String(cmd)
+	switch {
+	case rev != "" && len(args) != 1:
+		return errors.Newf(token.NoPos, "diff with --%s takes one argument", flagRev)
+	case rev == "" && len(args) != 2:
+		return errors.Newf(token.NoPos, "diff takes two arguments")
+	}
+
+	f := &diffFilter{
+		ignoreDefaults: flagIgnoreDefaults.Bool(cmd),
+		ignoreOrder:    flagIgnoreOrder.Bool(cmd),
+	}
+	var ignore []cue.Query
+	for _, s := range flagIgnorePath.StringArray(cmd) {
+		q := cue.ParseQuery(s)
+		exitOnErr(cmd, q.Err(), true)
+		ignore = append(ignore, q)
+	}
+
+	var a, b cue.Value
+	if rev != "" {
+		dir, err := revisionDir(rev)
+		exitOnErr(cmd, err, true)
+		defer os.RemoveAll(dir.root)
+
+		a = loadDiffValue(cmd, args[0], dir.cwd)
+		b = loadDiffValue(cmd, args[0], "")
+	} else {
+		a = loadDiffValue(cmd, args[0], "")
+		b = loadDiffValue(cmd, args[1], "")
+	}
+	for _, q := range ignore {
+		exitOnErr(cmd, f.ignoreMatches(a, q), true)
+		exitOnErr(cmd, f.ignoreMatches(b, q), true)
+	}
+
+	diffs := f.leaves(a, b)
+
+	w := cmd.OutOrStdout()
+	for _, d := range diffs {
+		path := diffPath(d.Path)
+		switch d.Kind {
+		case cue.DiffAdded:
+			fmt.Fprintf(w, "+ %s: %s\n", path, diffString(d.Y))
+		case cue.DiffRemoved:
+			fmt.Fprintf(w, "- %s: %s\n", path, diffString(d.X))
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", path, diffString(d.X), diffString(d.Y))
+		}
+	}
+	if len(diffs) > 0 {
+		return ErrPrintedError
+	}
+	return nil
+}
+
+// loadDiffValue loads the package or data file given by arg. If dir is not
+// empty, arg is interpreted relative to dir instead of the current
+// directory.
+func loadDiffValue(cmd *Command, arg, dir string) cue.Value {
+	cfg := &config{outMode: filetypes.Eval}
+	if dir != "" {
+		defCfg, err := defaultConfig()
+		exitOnErr(cmd, err, true)
+		loadCfg := *defCfg.loadCfg
+		loadCfg.Dir = dir
+		cfg.loadCfg = &loadCfg
+		if !filepath.IsAbs(arg) {
+			if _, err := os.Stat(filepath.Join(dir, arg)); err == nil && !isPackagePattern(arg) {
+				arg = filepath.Join(dir, arg)
+			}
+		}
+	}
+	b, err := parseArgs(cmd, []string{arg}, cfg)
+	exitOnErr(cmd, err, true)
+
+	iter := b.instances()
+	defer iter.close()
+	var v cue.Value
+	n := 0
+	for iter.scan() {
+		v = iter.value()
+		n++
+	}
+	exitOnErr(cmd, iter.err(), true)
+	if n != 1 {
+		exitOnErr(cmd, errors.Newf(token.NoPos, "%s: found %d values to compare; want 1", arg, n), true)
+	}
+	exitOnErr(cmd, v.Err(), true)
+	return v
+}
+
+// isPackagePattern reports whether arg names a package directory rather
+// than a file, which must be loaded relative to the configured directory.
+func isPackagePattern(arg string) bool {
+	return filepath.Ext(arg) == "" || strings.HasSuffix(arg, "...")
+}
+
+type revDir struct {
+	root string // temporary directory holding the revision
+	cwd  string // directory corresponding to the current directory
+}
+
+// revisionDir extracts the git revision rev of the repository containing
+// the current directory into a temporary directory.
+func revisionDir(rev string) (revDir, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return revDir{}, err
+	}
+	out, err := git(cwd, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return revDir{}, err
+	}
+	top := strings.TrimSpace(string(out))
+	rel, err := filepath.Rel(top, cwd)
+	if err != nil {
+		return revDir{}, err
+	}
+	data, err := git(top, "archive", "--format=zip", rev)
+	if err != nil {
+		return revDir{}, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return revDir{}, err
+	}
+	root, err := os.MkdirTemp("", "cue-diff-")
+	if err != nil {
+		return revDir{}, err
+	}
+	for _, f := range zr.File {
+		if err := extractFile(root, f); err != nil {
+			os.RemoveAll(root)
+			return revDir{}, err
+		}
+	}
+	return revDir{root: root, cwd: filepath.Join(root, rel)}, nil
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+func extractFile(root string, f *zip.File) error {
+	name := filepath.Join(root, filepath.FromSlash(f.Name))
+	if !strings.HasPrefix(name, root+string(filepath.Separator)) {
+		return fmt.Errorf("invalid file name %q in archive", f.Name)
+	}
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(name, 0o777)
+	}
+	if !f.Mode().IsRegular() {
+		// Symbolic links and other special files are not needed to
+		// evaluate configurations.
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+		return err
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// A diffFilter selects the differences reported by Value.Diff that are
+// relevant to a comparison.
+type diffFilter struct {
+	ignoreDefaults bool
+	ignoreOrder    bool
+	ignore         map[string]bool // paths of ignored values
+
+	diffs []*cue.Diff
+}
+
+// leaves returns the differences between the regular fields of a and b
+// without children, in the order reported by Value.Diff. A renamed field is
+// reported as a removal and an addition.
+func (f *diffFilter) leaves(a, b cue.Value) []*cue.Diff {
+	// Defaults are kept so that ignoreDefaults can tell them apart; they
+	// are selected when comparing leaf values.
+	f.walk(a.Diff(b, cue.Optional(false), cue.Definitions(false)))
+	return f.diffs
+}
+
+func (f *diffFilter) walk(d *cue.Diff) {
+	if d == nil || f.ignored(d.Path) {
+		return
+	}
+	switch {
+	case d.Kind == cue.DiffRenamed:
+		f.add(cue.DiffRemoved, d.Path, d.X, cue.Value{})
+		f.add(cue.DiffAdded, d.To, cue.Value{}, d.Y)
+
+	case f.ignoreOrder && len(d.Children) > 0 &&
+		d.X.IncompleteKind() == cue.ListKind && d.Y.IncompleteKind() == cue.ListKind:
+		f.unordered(d.Path, d.X, d.Y)
+
+	case len(d.Children) > 0:
+		for _, c := range d.Children {
+			f.walk(c)
+		}
+
+	default:
+		f.add(d.Kind, d.Path, d.X, d.Y)
+	}
+}
+
+func (f *diffFilter) add(kind cue.DiffKind, path cue.Path, x, y cue.Value) {
+	if f.ignored(path) {
+		return
+	}
+	if f.ignoreDefaults && isDefaultOrAbsent(x) && isDefaultOrAbsent(y) {
+		return
+	}
+	// Values that only differ in their defaults are equal once the
+	// defaults are selected.
+	if kind == cue.DiffModified && equalFinal(x, y) {
+		return
+	}
+	f.diffs = append(f.diffs, &cue.Diff{Kind: kind, Path: path, X: x, Y: y})
+}
+
+// unordered adds the elements of the lists x and y at path that do not
+// have an equal counterpart in the other list.
+func (f *diffFilter) unordered(path cue.Path, x, y cue.Value) {
+	xs, ys := listElems(x), listElems(y)
+	index := func(i int) cue.Path {
+		return cue.MakePath(append(path.Selectors(), cue.Index(i))...)
+	}
+	matched := make([]bool, len(ys))
+outer:
+	for i, a := range xs {
+		for j, b := range ys {
+			if !matched[j] && equalFinal(a, b) {
+				matched[j] = true
+				continue outer
+			}
+		}
+		f.add(cue.DiffRemoved, index(i), a, cue.Value{})
+	}
+	for j, b := range ys {
+		if !matched[j] {
+			f.add(cue.DiffAdded, index(j), cue.Value{}, b)
+		}
+	}
+}
+
+// ignoreMatches ignores the values in v matching q and everything below
+// them.
+func (f *diffFilter) ignoreMatches(v cue.Value, q cue.Query) error {
+	values, err := v.LookupAll(q)
+	if err != nil {
+		return err
+	}
+	if f.ignore == nil {
+		f.ignore = map[string]bool{}
+	}
+	for _, v := range values {
+		f.ignore[v.Path().String()] = true
+	}
+	return nil
+}
+
+func (f *diffFilter) ignored(path cue.Path) bool {
+	sels := path.Selectors()
+	for i := 0; i <= len(sels); i++ {
+		if f.ignore[cue.MakePath(sels[:i]...).String()] {
+			return true
+		}
+	}
+	return false
+}
+
+func listElems(v cue.Value) []cue.Value {
+	v, _ = v.Default()
+	var a []cue.Value
+	iter, _ := v.List()
+	for iter.Next() {
+		a = append(a, iter.Value())
+	}
+	return a
+}
+
+// equalFinal reports whether x and y do not differ once their defaults are
+// selected.
+func equalFinal(x, y cue.Value) bool {
+	return x.Exists() && y.Exists() && x.Diff(y, cue.Final()) == nil
+}
+
+// isDefaultOrAbsent reports whether v does not exist or its value is that
+// of a default.
+func isDefaultOrAbsent(v cue.Value) bool {
+	if !v.Exists() {
+		return true
+	}
+	d, ok := v.Default()
+	return ok && d.IsConcrete()
+}
+
+// diffPath formats the path of a difference, using "." for the root.
+func diffPath(p cue.Path) string {
+	if s := p.String(); s != "" {
+		return s
+	}
+	return "."
+}
+
+// diffString formats v on a single line.
+func diffString(v cue.Value) string {
+	v, _ = v.Default()
+	if v.Validate(cue.Concrete(true)) == nil {
+		if b, err := v.MarshalJSON(); err == nil {
+			return string(b)
+		}
+	}
+	return strings.Join(strings.Fields(fmt.Sprint(v)), " ")
+}
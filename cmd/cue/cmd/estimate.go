@@ -0,0 +1,255 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+const (
+	// unknownFanOut is the number of iterations assumed for a comprehension
+	// of which the source cannot be determined without evaluation.
+	unknownFanOut = 10
+
+	// highCost is the estimated cost above which a warning is reported.
+	highCost = 1_000_000
+)
+
+// estimate holds statistics gathered from the syntax of a configuration
+// that are indicative of the cost of evaluating it.
+type estimate struct {
+	packages       int
+	files          int
+	dataFiles      int
+	fields         int
+	disjunctions   int
+	alternatives   int
+	comprehensions int
+	maxNesting     int
+	maxFanOut      int
+
+	// cost is a unitless estimate of the evaluation cost. It is only
+	// meaningful in comparison to the estimated cost of other
+	// configurations.
+	cost int
+}
+
+// estimateCost analyzes the given instances, including the non-builtin
+// packages they import, without evaluating them.
+func estimateCost(insts []*build.Instance) *estimate {
+	e := &estimate{}
+	seen := map[*build.Instance]bool{}
+	var visit func(b *build.Instance)
+	visit = func(b *build.Instance) {
+		if b == nil || seen[b] {
+			return
+		}
+		seen[b] = true
+		if len(b.Files) > 0 {
+			e.packages++
+		}
+		e.dataFiles += len(b.OrphanedFiles)
+		for _, f := range b.Files {
+			e.files++
+			e.addDecls(f.Decls, 1)
+		}
+		for _, imp := range b.Imports {
+			visit(imp)
+		}
+	}
+	for _, b := range insts {
+		visit(b)
+	}
+	return e
+}
+
+// addDecls adds the statistics of decls, which are evaluated mult times.
+func (e *estimate) addDecls(decls []ast.Decl, mult int) {
+	for _, d := range decls {
+		switch x := d.(type) {
+		case *ast.Field:
+			e.fields++
+			e.cost += mult
+			e.addExpr(x.Value, mult)
+		case *ast.EmbedDecl:
+			e.addExpr(x.Expr, mult)
+		case *ast.LetClause:
+			e.addExpr(x.Expr, mult)
+		case *ast.Comprehension:
+			e.addComprehension(x, mult, 1)
+		}
+	}
+}
+
+func (e *estimate) addExpr(x ast.Expr, mult int) {
+	switch x := x.(type) {
+	case *ast.StructLit:
+		e.addDecls(x.Elts, mult)
+
+	case *ast.ListLit:
+		for _, elt := range x.Elts {
+			if c, ok := elt.(*ast.Comprehension); ok {
+				e.addComprehension(c, mult, 1)
+				continue
+			}
+			e.addExpr(elt, mult)
+		}
+
+	case *ast.BinaryExpr:
+		if x.Op == token.OR {
+			// Count a chain of alternatives as a single disjunction.
+			terms := disjuncts(x, nil)
+			e.disjunctions++
+			e.alternatives += len(terms)
+			e.cost += saturatingMul(mult, len(terms))
+			for _, t := range terms {
+				e.addExpr(t, mult)
+			}
+			return
+		}
+		e.addExpr(x.X, mult)
+		e.addExpr(x.Y, mult)
+
+	case *ast.ParenExpr:
+		e.addExpr(x.X, mult)
+
+	case *ast.UnaryExpr:
+		e.addExpr(x.X, mult)
+
+	case *ast.CallExpr:
+		for _, a := range x.Args {
+			e.addExpr(a, mult)
+		}
+	}
+}
+
+// addComprehension adds the statistics of c, which is nested within depth-1
+// other comprehensions that together are evaluated mult times.
+func (e *estimate) addComprehension(c *ast.Comprehension, mult, depth int) {
+	e.comprehensions++
+	if depth > e.maxNesting {
+		e.maxNesting = depth
+	}
+	for _, cl := range c.Clauses {
+		f, ok := cl.(*ast.ForClause)
+		if !ok {
+			continue
+		}
+		n := fanOut(f.Source)
+		if n > e.maxFanOut {
+			e.maxFanOut = n
+		}
+		mult = saturatingMul(mult, n)
+	}
+	e.cost += mult
+
+	// Comprehensions directly nested within the body increase the depth.
+	body, ok := c.Value.(*ast.StructLit)
+	if !ok {
+		return
+	}
+	for _, d := range body.Elts {
+		if x, ok := d.(*ast.Comprehension); ok {
+			e.addComprehension(x, mult, depth+1)
+			continue
+		}
+		e.addDecls([]ast.Decl{d}, mult)
+	}
+}
+
+// fanOut estimates the number of values iterated over by a for clause with
+// the given source.
+func fanOut(x ast.Expr) int {
+	switch x := x.(type) {
+	case *ast.ListLit:
+		return len(x.Elts)
+	case *ast.StructLit:
+		return len(x.Elts)
+	case *ast.ParenExpr:
+		return fanOut(x.X)
+	}
+	return unknownFanOut
+}
+
+func disjuncts(x ast.Expr, a []ast.Expr) []ast.Expr {
+	if b, ok := x.(*ast.BinaryExpr); ok && b.Op == token.OR {
+		a = disjuncts(b.X, a)
+		return disjuncts(b.Y, a)
+	}
+	return append(a, x)
+}
+
+func saturatingMul(a, b int) int {
+	const max = 1 << 50
+	if a != 0 && b > max/a {
+		return max
+	}
+	return a * b
+}
+
+func (e *estimate) level() string {
+	switch {
+	case e.cost >= highCost:
+		return "high"
+	case e.cost >= highCost/100:
+		return "medium"
+	}
+	return "low"
+}
+
+// runEstimate reports the estimated cost of evaluating the instances
+// denoted by args. The instances are loaded, but not evaluated.
+func runEstimate(cmd *Command, args []string, cfg *config) error {
+	p, err := newBuildPlan(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	builds := loadFromArgs(cmd, args, p.cfg.loadCfg)
+	if builds == nil {
+		return errors.Newf(token.NoPos, "invalid args")
+	}
+	for _, b := range builds {
+		if b.Err != nil {
+			return b.Err
+		}
+	}
+	e := estimateCost(builds)
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 1, ' ', 0)
+	fmt.Fprintf(w, "packages:\t%d\n", e.packages)
+	fmt.Fprintf(w, "files:\t%d\n", e.files)
+	if e.dataFiles > 0 {
+		fmt.Fprintf(w, "data files:\t%d\n", e.dataFiles)
+	}
+	fmt.Fprintf(w, "fields:\t%d\n", e.fields)
+	fmt.Fprintf(w, "disjunctions:\t%d (%d alternatives)\n", e.disjunctions, e.alternatives)
+	fmt.Fprintf(w, "comprehensions:\t%d (max nesting %d, max fan-out %d)\n",
+		e.comprehensions, e.maxNesting, e.maxFanOut)
+	fmt.Fprintf(w, "estimated cost:\t%d (%s)\n", e.cost, e.level())
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if e.cost >= highCost {
+		cmd.logger.Infof("warning: evaluation of this configuration may take a long time")
+	}
+	return nil
+}
@@ -48,13 +48,17 @@ Examples:
   $ cue eval foo.cue -e a[0] -e a[2]
   "a"
   "c"
-`,
+` + "\n" + diagnosticsHelp,
 		RunE: mkRunE(c, runEval),
 	}
 
 	addOutFlags(cmd.Flags(), true)
 	addOrphanFlags(cmd.Flags())
 	addInjectionFlags(cmd.Flags(), false, false)
+	addTimeoutFlag(cmd.Flags())
+	addEstimateFlag(cmd.Flags())
+	addDiagnosticsFlag(cmd.Flags())
+	addProfileFlag(cmd.Flags())
 
 	cmd.Flags().StringArrayP(string(flagExpression), "e", nil, "evaluate this expression only")
 
@@ -85,6 +89,12 @@ const (
 )
 
 func runEval(cmd *Command, args []string) error {
+	if flagEstimate.Bool(cmd) {
+		return runEstimate(cmd, args, &config{outMode: filetypes.Eval})
+	}
+
+	defer startTrace(cmd)()
+
 	b, err := parseArgs(cmd, args, &config{outMode: filetypes.Eval})
 	exitOnErr(cmd, err, true)
 
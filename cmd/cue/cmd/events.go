@@ -0,0 +1,65 @@
+// Copyright 2024 The CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"cuelang.org/go/tools/flow"
+)
+
+// taskEvents is a flow.Subscriber that writes the events of a workflow as
+// JSON lines, so that they can be consumed by other tools, such as CI
+// dashboards.
+type taskEvents struct {
+	enc *json.Encoder
+}
+
+type taskEvent struct {
+	Time     string  `json:"time"`
+	Event    string  `json:"event"`
+	Task     string  `json:"task"`
+	Seconds  float64 `json:"seconds,omitempty"`
+	Attempts int     `json:"attempts,omitempty"`
+	Cached   bool    `json:"cached,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func newTaskEvents(w io.Writer) *taskEvents {
+	return &taskEvents{enc: json.NewEncoder(w)}
+}
+
+// Notify implements flow.Subscriber.
+func (s *taskEvents) Notify(e flow.Event) {
+	x := taskEvent{
+		Time:    e.Time.UTC().Format(time.RFC3339Nano),
+		Event:   e.Kind.String(),
+		Task:    e.Task.Path().String(),
+		Seconds: e.Duration.Seconds(),
+	}
+	if e.Kind != flow.TaskStarted {
+		if n := e.Task.Attempts(); n > 1 {
+			x.Attempts = n
+		}
+		x.Cached = e.Task.Cached()
+	}
+	if e.Err != nil {
+		x.Error = e.Err.Error()
+	}
+	// Errors writing events are not fatal to the workflow.
+	_ = s.enc.Encode(x)
+}
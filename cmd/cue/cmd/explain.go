@@ -0,0 +1,376 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/yaml"
+)
+
+func newExplainCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <topic> [arguments]",
+		Short: "explain aspects of a configuration",
+		Long: `Explain prints information that helps to understand a configuration.
+
+The aspect to explain is selected with a subcommand, such as
+"constraint". Run 'cue help explain <topic>' for details.
+`,
+		RunE: mkRunE(c, func(cmd *Command, args []string) error {
+			stderr := cmd.Stderr()
+			if len(args) == 0 {
+				fmt.Fprintln(stderr, "explain must be run as one of its subcommands")
+			} else {
+				fmt.Fprintf(stderr, "explain must be run as one of its subcommands: unknown subcommand %q\n", args[0])
+			}
+			fmt.Fprintln(stderr, "Run 'cue help explain' for known subcommands.")
+			os.Exit(1) // TODO: get rid of this
+			return nil
+		}),
+	}
+	cmd.AddCommand(newExplainConstraintCmd(c))
+	cmd.AddCommand(newExplainTraceCmd(c))
+	return cmd
+}
+
+func newExplainConstraintCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "constraint <path> [packages]",
+		Short: "print the effective constraint at a path",
+		Long: `Constraint prints the effective constraint of the value at the given
+path, that is, the result of unifying all declarations for it. It answers
+the question of which values are allowed at a path without having to
+locate and combine all the places that constrain it.
+
+The output shows the kind of the value, the merged constraint with all its
+bounds, the options of a disjunction, the default value, if any, the
+documentation, and each declaration that contributed to the constraint.
+
+Examples:
+
+  $ cat <<EOF > schema.cue
+  #Spec: {
+  	// Replicas is the number of pods.
+  	replicas: *3 | int & >=1
+  }
+  spec: #Spec
+  spec: replicas: <=10
+  EOF
+
+  $ cue explain constraint spec.replicas schema.cue
+  spec.replicas
+      Replicas is the number of pods.
+
+  kind:        int
+  constraint:  *3 | uint & >=1 & <=10
+  default:     3
+  options:
+      3 (default)
+      uint & >=1 & <=10
+  declared at:
+      ./schema.cue:3:12  *3 | int & >=1
+      ./schema.cue:6:17  <=10
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: mkRunE(c, runExplainConstraint),
+	}
+	addInjectionFlags(cmd.Flags(), false, false)
+	return cmd
+}
+
+func runExplainConstraint(cmd *Command, args []string) error {
+	path := cue.ParsePath(args[0])
+	if err := path.Err(); err != nil {
+		return errors.Newf(token.NoPos, "invalid path %q: %v", args[0], err)
+	}
+
+	b, err := parseArgs(cmd, args[1:], &config{})
+	exitOnErr(cmd, err, true)
+
+	iter := b.instances()
+	defer iter.close()
+	for i := 0; iter.scan(); i++ {
+		w := cmd.OutOrStdout()
+		if len(b.insts) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "// %s\n", iter.id())
+		}
+		v := iter.value().LookupPath(path)
+		if !v.Exists() {
+			exitOnErr(cmd, errors.Newf(token.NoPos, "no value at path %s", path), false)
+			continue
+		}
+		explainConstraint(w, path, v)
+	}
+	exitOnErr(cmd, iter.err(), true)
+	return nil
+}
+
+// explainConstraint writes a description of the constraint of v.
+func explainConstraint(w io.Writer, path cue.Path, v cue.Value) {
+	fmt.Fprintln(w, path)
+	for _, cg := range v.Doc() {
+		writeIndented(w, strings.TrimSpace(cg.Text()))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "kind:        %v\n", v.IncompleteKind())
+	if err := v.Err(); err != nil {
+		fmt.Fprintf(w, "error:       %v\n", err)
+	} else {
+		fmt.Fprintf(w, "constraint:  %s\n", indentTail(fmt.Sprint(v)))
+	}
+	if d, ok := v.Default(); ok {
+		fmt.Fprintf(w, "default:     %s\n", indentTail(fmt.Sprint(d)))
+	}
+
+	if options := disjunctOptions(fmt.Sprint(v)); len(options) > 1 {
+		fmt.Fprintln(w, "options:")
+		for _, o := range options {
+			writeIndented(w, o)
+		}
+	}
+
+	origins := v.Provenance()
+	if len(origins) > 0 {
+		fmt.Fprintln(w, "declared at:")
+	}
+	cwd, _ := os.Getwd()
+	for _, o := range origins {
+		fmt.Fprintf(w, "    %s  %s\n", relPos(cwd, o.Pos), indentTail(syntaxString(o.Value)))
+	}
+}
+
+func newExplainTraceCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trace <path> [packages]",
+		Short: "show which layer determines each field at a path",
+		Long: `Trace shows, for the value at the given path and each of its fields, how
+the value of the configuration, the base layer, combines with the values
+of the files given with --overlay, the overlay layer. For each field it
+shows the final value, the layer that determined it, whether a default
+applied, and the value of the other layer if it lost. It answers the
+question of why a field has its value when an overlay customizes a base
+configuration with defaults.
+
+Overlay files may be CUE, JSON or YAML files. They are unified with each
+other, but evaluated separately from the packages. Use --out json to
+print the trace as JSON, for instance for use in user interfaces. Paths
+in the JSON output are relative to the given path.
+
+Examples:
+
+  $ cat <<EOF > base.cue
+  spec: {
+  	replicas: *3 | int
+  	port:     *8080 | int
+  	image:    string
+  }
+  EOF
+
+  $ cat <<EOF > prod.yaml
+  spec:
+    replicas: 5
+    image: web:1.0
+  EOF
+
+  $ cue explain trace --overlay prod.yaml spec base.cue
+  spec
+      replicas  5          overlay        base: *3 | int
+      port      8080       base, default
+      image     "web:1.0"  overlay        base: string
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: mkRunE(c, runExplainTrace),
+	}
+	addInjectionFlags(cmd.Flags(), false, false)
+	cmd.Flags().StringArray(string(flagOverlay), nil,
+		"CUE, JSON or YAML file with values for the overlay layer")
+	cmd.Flags().String(string(flagOut), "text", "output format (text|json)")
+	return cmd
+}
+
+const flagOverlay flagName = "overlay"
+
+func runExplainTrace(cmd *Command, args []string) error {
+	path := cue.ParsePath(args[0])
+	if err := path.Err(); err != nil {
+		return errors.Newf(token.NoPos, "invalid path %q: %v", args[0], err)
+	}
+	out := flagOut.String(cmd)
+	if out != "text" && out != "json" {
+		return errors.Newf(token.NoPos, "unknown output format %q; want text or json", out)
+	}
+
+	b, err := parseArgs(cmd, args[1:], &config{})
+	exitOnErr(cmd, err, true)
+
+	iter := b.instances()
+	defer iter.close()
+	for i := 0; iter.scan(); i++ {
+		w := cmd.OutOrStdout()
+		if len(b.insts) > 1 && out == "text" {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "// %s\n", iter.id())
+		}
+		overlay, err := readOverlay(iter.value().Context(), cmd.Flags())
+		exitOnErr(cmd, err, true)
+
+		base := iter.value().LookupPath(path)
+		var over cue.Value
+		if overlay.Exists() {
+			over = overlay.LookupPath(path)
+		}
+		if !base.Exists() && !over.Exists() {
+			exitOnErr(cmd, errors.Newf(token.NoPos, "no value at path %s", path), false)
+			continue
+		}
+		t := cue.TraceLayers(base, over)
+		if out == "json" {
+			data, err := json.Marshal(t)
+			exitOnErr(cmd, err, true)
+			fmt.Fprintf(w, "%s\n", data)
+			continue
+		}
+		fmt.Fprintln(w, path)
+		writeIndented(w, strings.TrimSuffix(t.String(), "\n"))
+	}
+	exitOnErr(cmd, iter.err(), true)
+	return nil
+}
+
+// readOverlay returns the unification of the files given with --overlay.
+// The value does not exist if there are none.
+func readOverlay(ctx *cue.Context, f *pflag.FlagSet) (cue.Value, error) {
+	files, _ := f.GetStringArray(string(flagOverlay))
+	var v cue.Value
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		var w cue.Value
+		switch filepath.Ext(file) {
+		case ".yaml", ".yml":
+			f, err := yaml.Extract(file, data)
+			if err != nil {
+				return cue.Value{}, err
+			}
+			w = ctx.BuildFile(f)
+		default:
+			w = ctx.CompileBytes(data, cue.Filename(file))
+		}
+		if err := w.Err(); err != nil {
+			return cue.Value{}, err
+		}
+		v = v.Unify(w)
+	}
+	return v, nil
+}
+
+// disjunctOptions splits the formatted value s into the options of a
+// disjunction. It returns a single option if s is not a disjunction.
+func disjunctOptions(s string) []string {
+	expr, err := parser.ParseExpr("", s)
+	if err != nil {
+		return nil
+	}
+	var options []string
+	var split func(x ast.Expr)
+	split = func(x ast.Expr) {
+		if b, ok := x.(*ast.BinaryExpr); ok && b.Op == token.OR {
+			split(b.X)
+			split(b.Y)
+			return
+		}
+		suffix := ""
+		if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.MUL {
+			x, suffix = u.X, " (default)"
+		}
+		b, err := format.Node(x)
+		if err != nil {
+			return
+		}
+		options = append(options, string(b)+suffix)
+	}
+	split(expr)
+	return options
+}
+
+// syntaxString returns the formatted syntax of v as declared, without
+// evaluating it.
+func syntaxString(v cue.Value) string {
+	var n ast.Node = v.Syntax(cue.Raw())
+	if src, ok := v.Source().(ast.Expr); ok {
+		n = src
+	}
+	b, err := format.Node(n)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// relPos formats pos with its file name relative to the directory cwd,
+// like positions in error messages.
+func relPos(cwd string, pos token.Pos) string {
+	if !pos.IsValid() {
+		return "-"
+	}
+	p := pos.Position()
+	s := p.Filename
+	if rel, err := filepath.Rel(cwd, s); err == nil && cwd != "" {
+		s = rel
+		if !strings.HasPrefix(s, ".") {
+			s = "." + string(filepath.Separator) + s
+		}
+	}
+	if inTest {
+		s = filepath.ToSlash(s)
+	}
+	return fmt.Sprintf("%s:%d:%d", s, p.Line, p.Column)
+}
+
+func writeIndented(w io.Writer, s string) {
+	for _, line := range strings.Split(s, "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// indentTail indents all but the first line of s, so that multi-line values
+// line up with the surrounding output.
+func indentTail(s string) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.ReplaceAll(s, "\n", "\n    ")
+}
@@ -15,10 +15,27 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/encoding"
 	"cuelang.org/go/internal/filetypes"
+	"cuelang.org/go/internal/mod/modfile"
 )
 
 // newExportCmd creates and export command
@@ -94,6 +111,78 @@ The following formats are recognized:
 
  binary  output as raw binary
               The evaluated value must be of type string or bytes.
+
+
+Headers
+
+The --header flag specifies a comment to write at the start of the output,
+for instance to mark it as generated. A default header for all exports of a
+module may be set with the export.header field of its cue.mod/module.cue file;
+an empty --header flag disables it. Headers are only supported for output
+formats with comments: cue, yaml, toml, and textproto.
+
+The header is a Go text/template with the following fields:
+
+	Module   the path of the module
+	Package  the import paths of the exported packages
+	Version  the version of the cue command
+	Time     the current time, or the time given by SOURCE_DATE_EPOCH
+
+For example:
+
+	cue export --out yaml --header 'Code generated by cue {{.Version}}; DO NOT EDIT.'
+
+
+Multiple output files
+
+The --outdir flag writes the exported value to multiple files in the given
+directory, which is created if needed. The value must be a struct of which
+each regular field is written to its own file: the label of the field is
+the path of the file relative to the directory, and the value of the field
+is its contents. The format of each file is derived from its extension,
+unless it is set with --out. With --expression, each expression must
+evaluate to such a struct. Existing files are only replaced with --force.
+
+For example, the file
+
+	// manifests.cue
+	services: {
+		frontend: port: 80
+		backend: port:  8080
+	}
+
+	files: {
+		for n, s in services {
+			"\(n).yaml": {kind: "Service", metadata: name: n, spec: s}
+		}
+		"ports.json": [for s in services {s.port}]
+	}
+
+is split into frontend.yaml, backend.yaml and ports.json with
+
+	cue export manifests.cue -e files --outdir out
+
+
+Streaming
+
+The --stream flag writes each element of the exported list, or each
+regular field of the exported struct, as a separate document: a line of
+JSON for json and jsonl, a document for yaml, and a line for text. Each
+document is written as soon as it is encoded, so that the output is not
+held in memory as a whole and consumers may start processing early. An
+error in an element stops the export after writing the preceding ones.
+For example,
+
+	cue export -e manifests --out yaml --stream | kubectl apply -f -
+
+
+Watching
+
+The --watch flag keeps export running after the first export and exports
+again whenever one of the loaded files changes, including CUE files added
+to the directories of loaded packages. Errors are reported without ending
+the command. An output file given with --outfile is only rewritten if its
+contents change; it may be replaced without --force once written.
 `,
 		// TODO: some formats are missing for sure, like "jsonl" or "textproto" from internal/filetypes/types.cue.
 		RunE: mkRunE(c, runExport),
@@ -102,17 +191,66 @@ The following formats are recognized:
 	addOutFlags(cmd.Flags(), true)
 	addOrphanFlags(cmd.Flags())
 	addInjectionFlags(cmd.Flags(), false, false)
+	addTimeoutFlag(cmd.Flags())
+	addEstimateFlag(cmd.Flags())
+	addProfileFlag(cmd.Flags())
+	addWatchFlag(cmd.Flags())
 
 	cmd.Flags().Bool(string(flagEscape), false, "use HTML escaping")
 	cmd.Flags().StringArrayP(string(flagExpression), "e", nil, "export this expression only")
+	cmd.Flags().String(string(flagHeader), "", "template for a comment written at the start of the output")
+	cmd.Flags().String(string(flagOutDir), "", "write each field of the exported struct to a file in this directory")
+	cmd.Flags().Bool(string(flagStream), false, "write each element of the exported list or struct as a separate document")
 
 	return cmd
 }
 
 func runExport(cmd *Command, args []string) error {
+	if flagEstimate.Bool(cmd) {
+		return runEstimate(cmd, args, &config{outMode: filetypes.Export})
+	}
+
+	return runWatch(cmd, func() error { return exportOnce(cmd, args) })
+}
+
+func exportOnce(cmd *Command, args []string) error {
 	b, err := parseArgs(cmd, args, &config{outMode: filetypes.Export})
 	exitOnErr(cmd, err, true)
 
+	b.encConfig.Header, err = exportHeader(cmd, b)
+	exitOnErr(cmd, err, true)
+
+	if flagStream.Bool(cmd) {
+		switch {
+		case flagOutDir.String(cmd) != "":
+			err = errors.Newf(token.NoPos, "cannot combine --%s with --%s", flagStream, flagOutDir)
+		case cmd.watching():
+			err = errors.Newf(token.NoPos, "cannot combine --%s with --%s", flagStream, flagWatch)
+		default:
+			err = exportStream(cmd, b)
+		}
+		exitOnErr(cmd, err, true)
+		return nil
+	}
+
+	if dir := flagOutDir.String(cmd); dir != "" {
+		if flagOutFile.String(cmd) != "" {
+			exitOnErr(cmd, errors.Newf(token.NoPos,
+				"cannot specify both --outdir and --outfile"), true)
+		}
+		err = exportFiles(cmd, b, dir)
+		exitOnErr(cmd, err, true)
+		return nil
+	}
+
+	// When watching, only rewrite the output file if its contents change,
+	// so that tools watching the output are not triggered needlessly.
+	var buf *bytes.Buffer
+	if cmd.watching() && b.outFile.Filename != "-" {
+		buf = &bytes.Buffer{}
+		b.encConfig.Out = buf
+	}
+
 	enc, err := encoding.NewEncoder(b.outFile, b.encConfig)
 	exitOnErr(cmd, err, true)
 
@@ -128,5 +266,295 @@ func runExport(cmd *Command, args []string) error {
 	err = enc.Close()
 	exitOnErr(cmd, err, true)
 
+	if buf != nil {
+		// Files written by earlier runs may always be replaced.
+		force := b.encConfig.Force || cmd.watch.runs > 0
+		err = writeIfChanged(b.outFile.Filename, buf.Bytes(), force)
+		exitOnErr(cmd, err, true)
+	}
 	return nil
 }
+
+// exportStream writes each element of the exported lists, or each regular
+// field of the exported structs, as a separate document. Each document is
+// written as soon as it is encoded, rather than after encoding all of them.
+func exportStream(cmd *Command, b *buildPlan) error {
+	w := b.encConfig.Stdout
+	if name := b.outFile.Filename; name != "-" {
+		mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if b.encConfig.Force {
+			mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		f, err := os.OpenFile(name, mode, 0o666)
+		if err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return errors.Wrapf(fs.ErrExist, token.NoPos, "error writing %q", name)
+			}
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	var encode func(v cue.Value) error
+	switch enc := b.outFile.Encoding; enc {
+	case build.JSON, build.JSONL:
+		// Write one JSON value per line.
+		e := json.NewEncoder(w)
+		e.SetEscapeHTML(b.encConfig.EscapeHTML)
+		encode = func(v cue.Value) error {
+			if err := v.Validate(cue.Concrete(true)); err != nil {
+				return err
+			}
+			err := e.Encode(v)
+			if x, ok := err.(*json.MarshalerError); ok {
+				err = x.Err
+			}
+			return err
+		}
+	case build.YAML, build.Text:
+		cfg := *b.encConfig
+		cfg.Out = w
+		cfg.Stream = true
+		e, err := encoding.NewEncoder(b.outFile, &cfg)
+		if err != nil {
+			return err
+		}
+		defer e.Close()
+		encode = e.Encode
+	default:
+		return errors.Newf(token.NoPos,
+			"--%s does not support %s output; use json, jsonl, yaml, or text", flagStream, enc)
+	}
+
+	iter := b.instances()
+	defer iter.close()
+	for iter.scan() {
+		v := iter.value()
+		if err := v.Err(); err != nil {
+			return err
+		}
+		switch k := v.IncompleteKind(); k {
+		case cue.ListKind:
+			list, err := v.List()
+			if err != nil {
+				return err
+			}
+			for list.Next() {
+				if err := encode(list.Value()); err != nil {
+					return err
+				}
+			}
+		case cue.StructKind:
+			fields, err := v.Fields()
+			if err != nil {
+				return err
+			}
+			for fields.Next() {
+				if err := encode(fields.Value()); err != nil {
+					return err
+				}
+			}
+		default:
+			return errors.Newf(v.Pos(), "--%s requires a list or struct, found %v", flagStream, k)
+		}
+	}
+	return iter.err()
+}
+
+// An outputFile is a file written by export --outdir.
+type outputFile struct {
+	path  string
+	file  *build.File
+	value cue.Value
+}
+
+// exportFiles writes each regular field of the exported values to its own
+// file in dir, named by the label of the field. No files are written if any
+// of them is invalid.
+func exportFiles(cmd *Command, b *buildPlan, dir string) error {
+	force := b.encConfig.Force || cmd.watching() && cmd.watch.runs > 0
+	var files []outputFile
+	seen := map[string]bool{}
+	iter := b.instances()
+	defer iter.close()
+	for iter.scan() {
+		v := iter.value()
+		if err := v.Err(); err != nil {
+			return err
+		}
+		if k := v.IncompleteKind(); k != cue.StructKind {
+			return errors.Newf(v.Pos(),
+				"--outdir requires a struct of files, found %v", k)
+		}
+		fields, err := v.Fields()
+		if err != nil {
+			return err
+		}
+		for fields.Next() {
+			name := fields.Selector().Unquoted()
+			pos := fields.Value().Pos()
+			if !filepath.IsLocal(filepath.FromSlash(name)) {
+				return errors.Newf(pos,
+					"invalid output file name %q: must be a relative path within the output directory", name)
+			}
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if seen[path] {
+				return errors.Newf(pos, "duplicate output file %q", name)
+			}
+			seen[path] = true
+
+			qualified := path
+			if out := flagOut.String(cmd); out != "" {
+				qualified = out + ":" + path
+			}
+			f, err := filetypes.ParseFile(qualified, filetypes.Export)
+			if err != nil {
+				return err
+			}
+			if !force {
+				if _, err := os.Stat(path); err == nil {
+					return errors.Wrapf(fs.ErrExist, token.NoPos, "error writing %q", path)
+				}
+			}
+			files = append(files, outputFile{path: path, file: f, value: fields.Value()})
+		}
+	}
+	if err := iter.err(); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err := exportFile(cmd, b, f, force); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportFile(cmd *Command, b *buildPlan, f outputFile, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o777); err != nil {
+		return err
+	}
+	cfg := *b.encConfig
+	cfg.Stream = false
+	cfg.Force = force
+	// When watching, only rewrite files of which the contents change.
+	var buf *bytes.Buffer
+	if cmd.watching() {
+		buf = &bytes.Buffer{}
+		cfg.Out = buf
+	}
+	enc, err := encoding.NewEncoder(f.file, &cfg)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(f.value); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if buf != nil {
+		return writeIfChanged(f.path, buf.Bytes(), force)
+	}
+	return nil
+}
+
+// headerData holds the values available to header templates.
+type headerData struct {
+	Module  string
+	Package string
+	Version string
+	Time    time.Time
+}
+
+// exportHeader returns the header to write at the start of the output, as
+// set by the --header flag or the module file.
+func exportHeader(cmd *Command, b *buildPlan) (string, error) {
+	insts := b.insts
+	if len(insts) == 0 && b.orphanInstance != nil {
+		insts = []*build.Instance{b.orphanInstance}
+	}
+	text := flagHeader.String(cmd)
+	if !cmd.Flags().Changed(string(flagHeader)) {
+		for _, inst := range insts {
+			if inst.Root == "" {
+				continue
+			}
+			mf, err := readModFile(inst.Root)
+			if err != nil {
+				return "", err
+			}
+			if mf != nil {
+				text = mf.Export.Header
+			}
+			break
+		}
+	}
+	if text == "" {
+		return "", nil
+	}
+	t, err := template.New("header").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid header: %v", err)
+	}
+
+	data := headerData{Version: defaultVersion}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		data.Version = cueVersion(bi)
+	}
+	data.Time, err = headerTime()
+	if err != nil {
+		return "", err
+	}
+	var pkgs []string
+	for _, inst := range insts {
+		if data.Module == "" {
+			data.Module = inst.Module
+		}
+		p := inst.ImportPath
+		if p == "" {
+			p = inst.DisplayPath
+		}
+		if p != "" {
+			pkgs = append(pkgs, p)
+		}
+	}
+	data.Package = strings.Join(pkgs, ", ")
+
+	buf := &strings.Builder{}
+	if err := t.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("invalid header: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// readModFile reads the module file of the module rooted at dir. It returns
+// nil if there is no such file.
+func readModFile(dir string) (*modfile.File, error) {
+	filename := filepath.Join(dir, "cue.mod", "module.cue")
+	if info, err := os.Stat(filename); err != nil || !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return modfile.ParseLegacy(data, filename)
+}
+
+// headerTime returns the time for headers. The SOURCE_DATE_EPOCH environment
+// variable may be set to a number of seconds since the Unix epoch for
+// reproducible output.
+func headerTime() (time.Time, error) {
+	s := os.Getenv("SOURCE_DATE_EPOCH")
+	if s == "" {
+		return time.Now().UTC(), nil
+	}
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %v", s, err)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
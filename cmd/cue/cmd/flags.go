@@ -15,6 +15,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/pflag"
 )
 
@@ -23,6 +25,8 @@ const (
 	flagAll           flagName = "all"
 	flagDryrun        flagName = "dryrun"
 	flagVerbose       flagName = "verbose"
+	flagQuiet         flagName = "quiet"
+	flagLogFormat     flagName = "log-format"
 	flagAllErrors     flagName = "all-errors"
 	flagTrace         flagName = "trace"
 	flagForce         flagName = "force"
@@ -30,25 +34,43 @@ const (
 	flagStrict        flagName = "strict"
 	flagSimplify      flagName = "simplify"
 	flagInlineImports flagName = "inline-imports"
+	flagPreserveYAML  flagName = "preserve-yaml"
 	flagPackage       flagName = "package"
 	flagInject        flagName = "inject"
 	flagInjectVars    flagName = "inject-vars"
+	flagTimeout       flagName = "timeout"
+	flagEstimate      flagName = "estimate"
+	flagSummary       flagName = "summary"
+	flagWatch         flagName = "watch"
+	flagFormat        flagName = "format"
+	flagProfile       flagName = "profile"
+	flagParallel      flagName = "parallel"
+	flagRetries       flagName = "retries"
+	flagRetryBackoff  flagName = "retry-backoff"
+	flagCacheDir      flagName = "cache-dir"
+	flagGraph         flagName = "graph"
+	flagEvents        flagName = "events"
+	flagSignKey       flagName = "sign-key"
 
 	flagExpression  flagName = "expression"
 	flagSchema      flagName = "schema"
 	flagEscape      flagName = "escape"
+	flagHeader      flagName = "header"
 	flagGlob        flagName = "name"
 	flagRecursive   flagName = "recursive"
 	flagMerge       flagName = "merge"
 	flagList        flagName = "list"
 	flagPath        flagName = "path"
 	flagFiles       flagName = "files"
+	flagStream      flagName = "stream"
+	flagShard       flagName = "shard"
 	flagProtoPath   flagName = "proto_path"
 	flagProtoEnum   flagName = "proto_enum"
 	flagExt         flagName = "ext"
 	flagWithContext flagName = "with-context"
 	flagOut         flagName = "out"
 	flagOutFile     flagName = "outfile"
+	flagOutDir      flagName = "outdir"
 )
 
 func addOutFlags(f *pflag.FlagSet, allowNonCUE bool) {
@@ -72,6 +94,10 @@ func addGlobalFlags(f *pflag.FlagSet) {
 		"report errors for lossy mappings")
 	f.BoolP(string(flagVerbose), "v", false,
 		"print information about progress")
+	f.BoolP(string(flagQuiet), "q", false,
+		"only print errors")
+	f.String(string(flagLogFormat), "text",
+		"format of diagnostic messages (text|json)")
 	f.BoolP(string(flagAllErrors), "E", false, "print all available errors")
 }
 
@@ -86,6 +112,7 @@ func addOrphanFlags(f *pflag.FlagSet) {
 	f.String(string(flagProtoEnum), "int", "mode for rendering enums (int|json)")
 	f.StringP(string(flagGlob), "n", "", "glob filter for non-CUE file names in directories")
 	f.Bool(string(flagMerge), true, "merge non-CUE files")
+	f.Bool(string(flagPreserveYAML), false, "record YAML anchors and aliases as attributes and restore them, with comments, in YAML output")
 }
 
 func addInjectionFlags(f *pflag.FlagSet, auto, hidden bool) {
@@ -99,6 +126,21 @@ func addInjectionFlags(f *pflag.FlagSet, auto, hidden bool) {
 	}
 }
 
+func addTimeoutFlag(f *pflag.FlagSet) {
+	f.Duration(string(flagTimeout), 0,
+		"abort evaluation after the given duration, such as 30s")
+}
+
+func addWatchFlag(f *pflag.FlagSet) {
+	f.Bool(string(flagWatch), false,
+		"keep running and run again whenever input files change")
+}
+
+func addEstimateFlag(f *pflag.FlagSet) {
+	f.Bool(string(flagEstimate), false,
+		"report the estimated cost of evaluation instead of evaluating")
+}
+
 type flagName string
 
 func (f flagName) Bool(cmd *Command) bool {
@@ -106,6 +148,16 @@ func (f flagName) Bool(cmd *Command) bool {
 	return v
 }
 
+func (f flagName) Duration(cmd *Command) time.Duration {
+	v, _ := cmd.Flags().GetDuration(string(f))
+	return v
+}
+
+func (f flagName) Int(cmd *Command) int {
+	v, _ := cmd.Flags().GetInt(string(f))
+	return v
+}
+
 func (f flagName) String(cmd *Command) string {
 	v, _ := cmd.Flags().GetString(string(f))
 	return v
@@ -24,6 +24,7 @@ import (
 	"cuelang.org/go/cue/load"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/encoding"
+	"cuelang.org/go/internal/mod/modfile"
 	"cuelang.org/go/tools/fix"
 )
 
@@ -32,6 +33,40 @@ func newFmtCmd(c *Command) *cobra.Command {
 		Use:   "fmt [-s] [inputs]",
 		Short: "formats CUE configuration files",
 		Long: `Fmt formats the given files or the files for the given packages in place
+
+The formatting style may be adjusted with the flags below. The defaults for
+all files of a module may be set in the fmt field of its cue.mod/module.cue
+file, which the flags override. For example:
+
+	module: "example.com"
+	fmt: {
+		indent:        2    // indent with two spaces instead of tabs
+		simplify:      1    // only remove unnecessary quotes from labels
+		maxBlankLines: 0    // remove all blank lines
+		trailingComma: true // write {a: 1, b: 2,} for single-line structs
+		lineWidth:     100  // wrap long disjunctions and call arguments
+		sort:          true // sort imports and top-level fields
+		fieldOrder:    ["apiVersion", "kind"]
+	}
+
+The simplification levels are:
+
+	0  no simplification (the default)
+	1  remove unnecessary quotes from labels
+	2  also collapse structs with a single field, such as a: {b: 1} to
+	   a: b: 1, and move ellipses to the end of structs (as with -s)
+
+A line width, set with --line-width or lineWidth, makes fmt write each
+disjunct of disjunctions and each argument of calls on its own line if they
+would otherwise extend beyond that column. Tabs count as the indent width,
+or as 8 columns when indenting with tabs.
+
+Sorting, enabled with --sort or sort, puts the imports of each parenthesized
+import declaration in order, with the standard library first and other
+imports after a blank line. It also sorts runs of consecutive top-level
+fields by name, after the fields listed in fieldOrder, which come first in
+the given order. Other declarations, such as embeddings and comprehensions,
+are not moved. Fields keep their comments.
 `,
 		RunE: mkRunE(c, func(cmd *Command, args []string) error {
 			plan, err := newBuildPlan(cmd, &config{loadCfg: &load.Config{
@@ -42,21 +77,19 @@ func newFmtCmd(c *Command) *cobra.Command {
 			}})
 			exitOnErr(cmd, err, true)
 
-			builds := loadFromArgs(args, plan.cfg.loadCfg)
+			builds := loadFromArgs(cmd, args, plan.cfg.loadCfg)
 			if builds == nil {
 				exitOnErr(cmd, errors.Newf(token.NoPos, "invalid args"), true)
 			}
 
-			opts := []format.Option{}
-			if flagSimplify.Bool(cmd) {
-				opts = append(opts, format.Simplify())
-			}
-
 			cfg := *plan.encConfig
-			cfg.Format = opts
 			cfg.Force = true
 
 			for _, inst := range builds {
+				opts, err := fmtOptions(cmd, inst)
+				exitOnErr(cmd, err, true)
+				cfg.Format = opts
+
 				if inst.Err != nil {
 					var p *load.PackageError
 					switch {
@@ -101,5 +134,88 @@ func newFmtCmd(c *Command) *cobra.Command {
 			return nil
 		}),
 	}
+	cmd.Flags().Int(string(flagIndent), 0,
+		"number of spaces to indent with, or 0 to indent with tabs")
+	cmd.Flags().Int(string(flagSimplifyLevel), 0,
+		"level of simplification (0-2); -s is the same as 2")
+	cmd.Flags().Int(string(flagMaxBlankLines), 1,
+		"maximum number of consecutive blank lines")
+	cmd.Flags().Bool(string(flagTrailingComma), false,
+		"write a comma after the last field of single-line structs")
+	cmd.Flags().Int(string(flagLineWidth), 0,
+		"wrap disjunctions and call arguments extending beyond this column, or 0 to not wrap")
+	cmd.Flags().Bool(string(flagSort), false,
+		"sort imports and top-level fields")
 	return cmd
 }
+
+const (
+	flagIndent        flagName = "indent"
+	flagSimplifyLevel flagName = "simplify-level"
+	flagMaxBlankLines flagName = "max-blank-lines"
+	flagTrailingComma flagName = "trailing-comma"
+	flagLineWidth     flagName = "line-width"
+	flagSort          flagName = "sort"
+)
+
+// fmtOptions returns the formatting options for the files of inst, as set
+// by the module file and overridden by flags.
+func fmtOptions(cmd *Command, inst *build.Instance) ([]format.Option, error) {
+	var settings modfile.Fmt
+	if inst.Root != "" {
+		mf, err := readModFile(inst.Root)
+		if err != nil {
+			return nil, err
+		}
+		if mf != nil {
+			settings = mf.Fmt
+		}
+	}
+	flags := cmd.Flags()
+	if flags.Changed(string(flagIndent)) {
+		settings.Indent, _ = flags.GetInt(string(flagIndent))
+	}
+	if flags.Changed(string(flagSimplifyLevel)) {
+		settings.Simplify, _ = flags.GetInt(string(flagSimplifyLevel))
+	}
+	if flagSimplify.Bool(cmd) {
+		settings.Simplify = 2
+	}
+	if flags.Changed(string(flagMaxBlankLines)) {
+		n, _ := flags.GetInt(string(flagMaxBlankLines))
+		settings.MaxBlankLines = &n
+	}
+	if flags.Changed(string(flagTrailingComma)) {
+		settings.TrailingComma, _ = flags.GetBool(string(flagTrailingComma))
+	}
+	if flags.Changed(string(flagLineWidth)) {
+		settings.LineWidth, _ = flags.GetInt(string(flagLineWidth))
+	}
+	if flags.Changed(string(flagSort)) {
+		settings.Sort, _ = flags.GetBool(string(flagSort))
+	}
+
+	if settings.Indent < 0 {
+		return nil, errors.Newf(token.NoPos, "invalid indent %d", settings.Indent)
+	}
+	if settings.Simplify < 0 || settings.Simplify > 2 {
+		return nil, errors.Newf(token.NoPos, "invalid simplification level %d; want 0, 1 or 2", settings.Simplify)
+	}
+	opts := []format.Option{format.SimplifyLevel(settings.Simplify)}
+	if settings.Indent > 0 {
+		opts = append(opts, format.UseSpaces(settings.Indent), format.TabIndent(false))
+	}
+	if n := settings.MaxBlankLines; n != nil {
+		opts = append(opts, format.MaxBlankLines(*n))
+	}
+	if settings.TrailingComma {
+		opts = append(opts, format.TrailingComma(true))
+	}
+	if settings.LineWidth > 0 {
+		opts = append(opts, format.LineWidth(settings.LineWidth))
+	}
+	if settings.Sort {
+		opts = append(opts, format.GroupImports(), format.SortFields(settings.FieldOrder...))
+	}
+	return opts, nil
+}
@@ -115,6 +115,17 @@ Go structs are converted to cue structs adhering to the following conventions:
 	- Pointers translate to a sum type with the default value of null and
 	  the Go type as an alternative value.
 
+	- Generic types are converted with each type parameter replaced by its
+	  constraint. An instantiation of a generic type, like Pair[string, int],
+	  is expanded in place using its type arguments, except where this would
+	  recurse, in which case the generic definition is used.
+
+	- Interfaces used as type constraints translate to the set of types they
+	  allow: the terms of a union become a disjunction and embedded
+	  constraints are unified. Other interfaces translate to top (_).
+
+	- Type aliases translate to a definition with the aliased type.
+
 	- Field tags are translated to CUE's field attributes. In some cases,
 	  the contents are rewritten to reflect the corresponding types in CUE.
 	  The @go attribute is added if the field name or type definition differs
@@ -254,6 +265,10 @@ type extractor struct {
 	orig     map[types.Type]*ast.StructType
 	usedPkgs map[string]bool
 
+	// expanding holds the instantiations of generic types that are being
+	// expanded, to detect recursive instantiations.
+	expanding []*types.Named
+
 	// per file
 	cmap     ast.CommentMap
 	pkg      *packages.Package
@@ -270,9 +285,7 @@ type pkgInfo struct {
 }
 
 func (e *extractor) logf(format string, args ...interface{}) {
-	if flagVerbose.Bool(e.cmd) {
-		fmt.Fprintf(e.stderr, format+"\n", args...)
-	}
+	e.cmd.logger.Debugf(format, args...)
 }
 
 func (e *extractor) usedPkg(pkg string) {
@@ -367,7 +380,7 @@ func extract(cmd *Command, args []string) error {
 	// command specifies a Go package(s) that belong to the main module
 	// and where for some reason the
 	// determine module root:
-	binst := loadFromArgs([]string{"."}, nil)[0]
+	binst := loadFromArgs(cmd, []string{"."}, nil)[0]
 
 	// TODO: require explicitly set root.
 	root := binst.Root
@@ -808,13 +821,55 @@ func (e *extractor) reportDecl(x *ast.GenDecl) (a []cueast.Decl) {
 }
 
 func shortTypeName(t types.Type) string {
+	t = unalias(t)
 	if n, ok := t.(*types.Named); ok {
 		return n.Obj().String() // fully qualified, e.g. "foo/bar.Baz"
 	}
 	return t.String() // anonymous, e.g. "interface{Method() []byte}"
 }
 
+// resolveAliases returns t with the type aliases within it resolved, so that
+// it prints as the types it denotes. Aliases declared in the standard library
+// are kept, as the types they denote may differ between Go versions.
+func resolveAliases(t types.Type) types.Type {
+	if isStdAlias(t) {
+		return t
+	}
+	switch x := unalias(t).(type) {
+	case *types.Pointer:
+		return types.NewPointer(resolveAliases(x.Elem()))
+	case *types.Slice:
+		return types.NewSlice(resolveAliases(x.Elem()))
+	case *types.Array:
+		return types.NewArray(resolveAliases(x.Elem()), x.Len())
+	case *types.Map:
+		return types.NewMap(resolveAliases(x.Key()), resolveAliases(x.Elem()))
+	case *types.Chan:
+		return types.NewChan(x.Dir(), resolveAliases(x.Elem()))
+	case *types.Named:
+		targs := x.TypeArgs()
+		if targs.Len() == 0 {
+			return x
+		}
+		args := make([]types.Type, targs.Len())
+		for i := range args {
+			args[i] = resolveAliases(targs.At(i))
+		}
+		if inst, err := types.Instantiate(nil, x.Origin(), args, false); err == nil {
+			return inst
+		}
+		return x
+	default:
+		return x
+	}
+}
+
 func (e *extractor) altType(typ types.Type) cueast.Expr {
+	if isConstraint(typ) {
+		// Constraint interfaces are not implemented by values, but describe
+		// sets of types.
+		return nil
+	}
 	// We need to check whether T or *T implement each interface I.
 	// Typically we would just need to check whether *T implements I,
 	// as the method set of *T includes the method set of T,
@@ -915,12 +970,13 @@ func makeDoc(g *ast.CommentGroup, isDoc bool) *cueast.CommentGroup {
 func supportedType(stack []types.Type, t types.Type) (ok bool) {
 	// handle recursive types
 	for _, t0 := range stack {
-		if t0 == t {
+		if types.Identical(t0, t) {
 			return true
 		}
 	}
 	stack = append(stack, t)
 
+	t = unalias(t)
 	if named, ok := t.(*types.Named); ok {
 		obj := named.Obj()
 
@@ -1023,6 +1079,7 @@ func (e *extractor) makeField(name string, kind fieldKind, expr types.Type, doc
 }
 
 func (e *extractor) makeType(expr types.Type) (result cueast.Expr) {
+	expr = unalias(expr)
 	if x, ok := expr.(*types.Named); ok {
 		obj := x.Obj()
 		if obj.Pkg() == nil {
@@ -1054,6 +1111,10 @@ func (e *extractor) makeType(expr types.Type) (result cueast.Expr) {
 			}
 		}
 
+		if x.TypeArgs().Len() > 0 {
+			return e.makeInstance(x)
+		}
+
 		result = e.ident(obj.Name(), true)
 		if pkg := obj.Pkg(); pkg != nil && pkg != e.pkg.Types {
 			info := e.pkgNames[pkg.Path()]
@@ -1149,14 +1210,93 @@ func (e *extractor) makeType(expr types.Type) (result cueast.Expr) {
 		}
 
 	case *types.Interface:
+		if !x.IsMethodSet() {
+			return e.makeTypeSet(x)
+		}
 		return e.ident("_", false)
 
+	case *types.TypeParam:
+		// Outside of an instantiation, a type parameter may be any type
+		// allowed by its constraint.
+		return e.makeType(x.Constraint())
+
 	default:
 		// record error
 		panic(fmt.Sprintf("unsupported type %T", x))
 	}
 }
 
+// makeInstance expands an instantiation of a generic type, as CUE has no
+// type parameters. A recursive instantiation refers to the generic type
+// instead, for which type parameters are mapped to their constraints.
+func (e *extractor) makeInstance(x *types.Named) cueast.Expr {
+	if isIdentity(x) {
+		// The generic type instantiated with its own type parameters, as
+		// in a recursive generic type.
+		return e.makeType(x.Origin())
+	}
+	for _, t := range e.expanding {
+		if types.Identical(t, x) {
+			e.logf("    Recursive instantiation %v; using %v", x, x.Origin())
+			return e.makeType(x.Origin())
+		}
+	}
+	e.expanding = append(e.expanding, x)
+	defer func() { e.expanding = e.expanding[:len(e.expanding)-1] }()
+
+	// Use the documentation of the fields of the generic type.
+	if st, ok := x.Underlying().(*types.Struct); ok {
+		if s := e.orig[x.Origin().Underlying()]; s != nil {
+			e.orig[st] = s
+		}
+	}
+	return e.makeType(x.Underlying())
+}
+
+// isIdentity reports whether x instantiates a generic type with the type
+// parameters of that type.
+func isIdentity(x *types.Named) bool {
+	params := x.Origin().TypeParams()
+	args := x.TypeArgs()
+	for i := 0; i < args.Len(); i++ {
+		if args.At(i) != params.At(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// makeTypeSet converts the type set of a constraint interface. The embedded
+// elements of an interface are intersected, whereas the terms of a union
+// are not. Methods and approximations (~T) cannot be expressed in CUE and
+// are ignored.
+func (e *extractor) makeTypeSet(x *types.Interface) cueast.Expr {
+	var conjuncts []cueast.Expr
+	for i := 0; i < x.NumEmbeddeds(); i++ {
+		switch t := x.EmbeddedType(i).(type) {
+		case *types.Union:
+			var terms []cueast.Expr
+			for j := 0; j < t.Len(); j++ {
+				terms = append(terms, e.makeType(t.Term(j).Type()))
+			}
+			conjuncts = append(conjuncts, cueast.NewBinExpr(cuetoken.OR, terms...))
+		default:
+			conjuncts = append(conjuncts, e.makeType(t))
+		}
+	}
+	if len(conjuncts) == 0 {
+		return e.ident("_", false)
+	}
+	return cueast.NewBinExpr(cuetoken.AND, conjuncts...)
+}
+
+// isConstraint reports whether t is an interface that can only be used as
+// a constraint of a type parameter.
+func isConstraint(t types.Type) bool {
+	x, ok := t.Underlying().(*types.Interface)
+	return ok && !x.IsMethodSet()
+}
+
 func (e *extractor) addAttr(f *cueast.Field, tag, body string) {
 	s := fmt.Sprintf("@%s(%s)", tag, body)
 	f.Attrs = append(f.Attrs, &cueast.Attribute{Text: s})
@@ -1167,14 +1307,16 @@ func (e *extractor) addFields(x *types.Struct, st *cueast.StructLit) {
 		st.Elts = append(st.Elts, x)
 	}
 
-	s := e.orig[x]
-	docs := []*ast.CommentGroup{}
-	for _, f := range s.Fields.List {
-		if len(f.Names) == 0 {
-			docs = append(docs, f.Doc)
-		} else {
-			for range f.Names {
+	docs := make([]*ast.CommentGroup, x.NumFields())
+	if s := e.orig[x]; s != nil {
+		docs = docs[:0]
+		for _, f := range s.Fields.List {
+			if len(f.Names) == 0 {
 				docs = append(docs, f.Doc)
+			} else {
+				for range f.Names {
+					docs = append(docs, f.Doc)
+				}
 			}
 		}
 	}
@@ -1189,7 +1331,7 @@ func (e *extractor) addFields(x *types.Struct, st *cueast.StructLit) {
 			continue
 		}
 		if f.Anonymous() && e.isInline(x.Tag(i)) {
-			typ := f.Type()
+			typ := unalias(f.Type())
 			for {
 				p, ok := typ.(*types.Pointer)
 				if !ok {
@@ -1232,13 +1374,13 @@ func (e *extractor) addFields(x *types.Struct, st *cueast.StructLit) {
 		if s := reflect.StructTag(tag).Get("cue"); s != "" {
 			expr, err := parser.ParseExpr("get go", s)
 			if err != nil {
-				e.logf("error parsing struct tag %q:", s, err)
+				e.logf("error parsing struct tag %q: %v", s, err)
 			}
 			field.Value = cueast.NewBinExpr(cuetoken.AND, field.Value, expr)
 		}
 
 		// Add field tag to convert back to Go.
-		typeName := f.Type().String()
+		typeName := resolveAliases(f.Type()).String()
 		// simplify type names:
 		for path, info := range e.pkgNames {
 			typeName = strings.Replace(typeName, path+".", info.name+".", -1)
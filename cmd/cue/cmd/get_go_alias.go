@@ -0,0 +1,39 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.22
+
+package cmd
+
+import (
+	"go/types"
+	"strings"
+)
+
+// unalias returns t with any type aliases resolved. As of Go 1.23, go/types
+// represents aliases as *types.Alias rather than the type they denote.
+func unalias(t types.Type) types.Type {
+	return types.Unalias(t)
+}
+
+// isStdAlias reports whether t is a type alias declared in the standard
+// library.
+func isStdAlias(t types.Type) bool {
+	a, ok := t.(*types.Alias)
+	if !ok {
+		return false
+	}
+	pkg := a.Obj().Pkg()
+	return pkg != nil && !strings.ContainsAny(pkg.Path(), ".")
+}
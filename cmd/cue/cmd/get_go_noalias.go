@@ -0,0 +1,30 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !go1.22
+
+package cmd
+
+import "go/types"
+
+// unalias returns t. Before Go 1.22, go/types resolves type aliases to the
+// type they denote.
+func unalias(t types.Type) types.Type {
+	return t
+}
+
+// isStdAlias reports false: type aliases are always resolved before Go 1.22.
+func isStdAlias(t types.Type) bool {
+	return false
+}
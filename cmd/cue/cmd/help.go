@@ -229,11 +229,22 @@ var filetypeHelp = &cobra.Command{
     cue         .cue            CUE source files.
     json        .json           JSON files.
     yaml        .yaml/.yml      YAML files.
-    jsonl       .jsonl/.ldjson  Line-separated JSON values.
+    jsonl       .jsonl/.ndjson/ Newline-delimited JSON values. The
+                .ldjson         ndjson and ldjson tags are aliases.
+    toml        .toml           TOML files.
+    xml         .xml            XML files.
+    hcl         .hcl/.tf        HCL files, such as Terraform configurations.
+    ini         .ini            INI files.
+    dotenv      .env            Environment files holding KEY=value lines.
     jsonschema                  JSON Schema.
     openapi                     OpenAPI schema.
+    avro        .avsc           Avro schema.
+    graphql     .graphql/.gql   GraphQL schema definition language.
+                .graphqls
+    tfschema                    Terraform provider schemas.
 	pb                          Use Protobuf mappings (e.g. json+pb)
     textproto    .textproto     Text-based protocol buffers.
+    binpb       .binpb          Binary protocol buffers.
     proto        .proto         Protocol Buffer definitions.
     go           .go            Go source files.
     text         .txt           Raw text file; the evaluated value
@@ -241,11 +252,16 @@ var filetypeHelp = &cobra.Command{
     binary                      Raw binary file; the evaluated value
                                 must be of type string or bytes.
 
-OpenAPI, JSON Schema and Protocol Buffer definitions are
+OpenAPI, JSON Schema, Avro, GraphQL and Protocol Buffer definitions are
 always interpreted as schema. YAML and JSON are always
 interpreted as data. CUE and Go are interpreted as schema by
 default, but may be selected to operate in data mode.
 
+Newline-delimited JSON is imported as a list of its values,
+unless the --path or --files flags specify otherwise. When
+exporting to it, each element of a list is written on its
+own line; other values are written on a single line.
+
 The cue tool will infer a file's type from its extension by
 default. The user my override this behavior by using qualifiers.
 A qualifier takes the form
@@ -297,6 +313,12 @@ $ cue def openapi: bar.cue foo.yaml
 # an alternate file extension.
 $ cue def -o openapi+yaml:foo.openapi
 
+# Write the definitions of a CUE package as JSON Schema.
+$ cue def --out jsonschema
+
+# Write the definitions of a CUE package as an Avro schema.
+$ cue def --out avro
+
 # Print the data for the current package as YAML.
 $ cue export --out=yaml
 
@@ -379,6 +401,13 @@ field. For instance
 
 ensures the user may only specify "prod" or "staging".
 
+A tag may be marked as required:
+
+   environment: string @tag(env,required)
+
+It is then an error not to set the tag, either with --inject/-t
+or through a tag variable.
+
 
 Tag variables
 
@@ -519,6 +548,68 @@ the second to standard output (i.e. it echos it again).
 		}
 	}
 
+Flags and arguments
+
+A command may declare flags in a $flags field and positional arguments
+in an $args field. Each field declares a flag or argument of the same
+name, where the type of the field determines its type, a default for the
+field its default, and the first line of its doc comment its usage.
+Supported types are bool, int, number, string, and lists of strings.
+
+	command: deploy: {
+		$flags: {
+			// environment to deploy to
+			env: *"staging" | "prod"
+			// only show what would be deployed
+			dryrun: *false | bool
+		}
+		$args: {
+			// service to deploy
+			service: string
+		}
+
+		print: cli.Print & {
+			text: "deploying \($args.service) to \($flags.env)"
+		}
+	}
+
+The values given on the command line are unified with the fields:
+
+	$ cue cmd deploy --env=prod frontend
+	deploying frontend to prod
+
+A flag or argument without a default must be set, except for boolean
+flags, which are false if not set. A list of strings as a flag may be
+set multiple times; as the last argument it receives all remaining
+arguments. If the first argument after the name of a command is not a
+flag, inputs are read until "--". When using the short form "cue
+<name>", flags must follow "--".
+
+Tasks that are ready run concurrently. A task may limit this, or ask
+to be retried if it fails, with a @task attribute:
+
+	command: fetch: {
+		for name in ["a", "b", "c", "d"] {
+			(name): http.Get & {
+				url: "https://example.com/\(name)"
+			} @task(group=example, limit=2, retries=3, backoff=500ms)
+		}
+	}
+
+The arguments of the attribute are:
+
+	retries     the maximum number of times the task is retried
+	backoff     the time to wait before the first retry (default 1s),
+	            which doubles with every retry
+	maxBackoff  the maximum time to wait between retries
+	group       the name of a group of tasks to which limit applies
+	limit       the maximum number of tasks of the group that run
+	            concurrently
+	cache       cache the results of the task if --cache-dir is set
+
+The --parallel and --retries flags of "cue cmd" limit the number of
+tasks that run concurrently and retry all tasks that fail.
+
 The types of the commands and tasks are defined in CUE itself at
 cuelang.org/go/pkg/tool/tool.cue.
 
@@ -547,6 +638,12 @@ cuelang.org/go/pkg/tool/tool.cue.
 		// $long is a longer description that spans multiple lines and
 		// likely contain examples of usage of the command.
 		$long?: string
+
+		// $flags declares the flags of the command.
+		$flags?: [string]: bool | number | string | [...string]
+
+		// $args declares the positional arguments of the command, in order.
+		$args?: [string]: bool | number | string | [...string]
 	}
 
 	// Tasks defines a hierarchy of tasks. A command completes if all
@@ -578,7 +675,4 @@ cuelang.org/go/pkg/tool/tool.cue.
 // - attr/noattr
 // - id=<url>
 
-// TODO: filetypes:
-// - binpb
-
 // TODO: cue.mod help topic
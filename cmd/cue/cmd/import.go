@@ -15,8 +15,10 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -24,6 +26,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/ast/astutil"
 	"cuelang.org/go/cue/build"
@@ -36,6 +39,7 @@ import (
 	"cuelang.org/go/encoding/json"
 	"cuelang.org/go/encoding/protobuf"
 	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/astinternal"
 	"cuelang.org/go/internal/third_party/yaml"
 )
 
@@ -59,6 +63,10 @@ the following modes:
               and interpret them as binary.
    jsonschema Interpret JSON, YAML or CUE files as JSON Schema.
    openapi    Interpret JSON, YAML or CUE files as OpenAPI.
+   avro       Convert Avro schema files (.avsc).
+   graphql    Convert GraphQL schema files (.graphql .graphqls .gql).
+   tfschema   Convert Terraform provider schemas, as printed by
+              terraform providers schema -json.
    auto       Look for JSON or YAML files and interpret them as
               data, JSON Schema, or OpenAPI, depending on
               existing fields.
@@ -81,6 +89,28 @@ OpenAPI is identified by the existence of a top-level field
 the info.title and info.version fields.
 
 
+tfschema mode
+
+Tfschema mode converts the schemas of Terraform providers to
+definitions for Terraform configurations in the JSON syntax:
+#Provider, #Resource and #Data hold the schemas of providers,
+resources and data sources and #Config describes a complete
+configuration. The schemas are obtained by running
+
+   terraform providers schema -json > providers.json
+
+in an initialized Terraform working directory. For instance,
+
+   cue import tfschema -p tf providers.json
+
+writes the definitions to providers.cue. A configuration such as
+
+   config: #Config & {resource: aws_instance: web: {...}}
+
+in the same package is then validated and written with
+
+   cue export -e config --out json -o main.tf.json
+
 proto mode
 
 Proto mode converts .proto files containing Prototcol Buffer
@@ -221,6 +251,30 @@ Examples:
   }]
 
 
+Streaming large files
+
+By default, all entries of a file are read before they are placed. The
+--stream flag instead writes the entries of each file to the output file as
+the elements of a list, one entry at a time, allowing files with a very large
+number of entries to be imported with bounded memory.
+
+The --shard flag implies --stream and writes the entries to a separate file
+for each value of the given expression, which is evaluated for each entry as
+with the --path flag. The files are named after the input file and the value
+of the expression. The --stream and --shard flags cannot be combined with
+the --files, --path, --with-context or --recursive flags.
+
+Example:
+  $ cue import --shard 'strings.ToLower(kind)' foo.yaml
+  $ cat foo.service.cue
+  [
+      {
+          kind: "Service"
+          name: "booster"
+      },
+  ]
+
+
 Embedded data files
 
 The --recursive or -R flag enables the parsing of fields that are string
@@ -256,6 +310,8 @@ Example:
 	addOrphanFlags(cmd.Flags())
 
 	cmd.Flags().Bool(string(flagFiles), false, "split multiple entries into different files")
+	cmd.Flags().Bool(string(flagStream), false, "write entries to the output as a list one at a time")
+	cmd.Flags().String(string(flagShard), "", "split entries into different files by the value of this expression (implies --stream)")
 	cmd.Flags().Bool(string(flagDryrun), false, "only run simulation")
 	cmd.Flags().BoolP(string(flagRecursive), "R", false, "recursively parse string values")
 	cmd.Flags().StringArray(string(flagExt), nil, "match files with these extensions")
@@ -292,6 +348,8 @@ func runImport(cmd *Command, args []string) (err error) {
 			c.fileFilter = `\.(yaml|yml)$`
 		case "text":
 			c.fileFilter = `\.txt$`
+		case "graphql":
+			c.fileFilter = `\.(graphql|graphqls|gql)$`
 		case "binary":
 			if len(extensions) == 0 {
 				return errors.Newf(token.NoPos,
@@ -300,6 +358,17 @@ func runImport(cmd *Command, args []string) (err error) {
 		case "auto", "openapi", "jsonschema":
 			c.interpretation = build.Interpretation(mode)
 			c.encoding = "yaml"
+		case "avro":
+			c.interpretation = build.Avro
+			c.encoding = "json"
+			c.fileFilter = `\.avsc$`
+		case "tfschema":
+			c.interpretation = build.TFSchema
+			c.encoding = "json"
+			c.fileFilter = `\.json$`
+			// Provider schemas have no distinct extension, so interpret
+			// all matched files as such.
+			c.overrideDefault = true
 		case "data":
 			// default mode for encoding/ no interpretation.
 			c.encoding = ""
@@ -445,16 +514,15 @@ func getFilename(b *buildPlan, f *ast.File, root string, force bool) (filename s
 			if !force {
 				// TODO: mimic old behavior: write to stderr, but do not exit
 				// with error code. Consider what is best to do here.
-				stderr := b.cmd.Command.OutOrStderr()
 				if root != "" {
 					cueFile, _ = filepath.Rel(root, cueFile)
 				}
-				fmt.Fprintf(stderr, "Skipping file %q: already exists.\n",
+				b.cmd.logger.Infof("Skipping file %q: already exists.",
 					filepath.ToSlash(cueFile))
 				if strings.HasPrefix(cueFile, "cue.mod") {
-					fmt.Fprintln(stderr, "Use -Rf to override.")
+					b.cmd.logger.Infof("Use -Rf to override.")
 				} else {
-					fmt.Fprintln(stderr, "Use -f to override.")
+					b.cmd.logger.Infof("Use -f to override.")
 				}
 				return "", nil
 			}
@@ -494,6 +562,169 @@ func writeFile(p *buildPlan, f *ast.File, cueFile string) error {
 	return os.WriteFile(cueFile, b, 0644)
 }
 
+// streamOrphans writes the documents of the data file di as the elements of
+// a list to the output file, or to one file per shard key, one document at a
+// time. Unlike placeOrphans, it does not hold the documents in memory, which
+// allows importing files with a large number of documents.
+func (b *buildPlan) streamOrphans(di *decoderInfo, pkg string) (err error) {
+	d := di.dec(b)
+	defer d.Close()
+
+	writers := map[string]*listWriter{}
+	defer func() {
+		for _, w := range writers {
+			if cerr := w.close(); err == nil {
+				err = cerr
+			}
+		}
+	}()
+
+	for ; !d.Done(); d.Next() {
+		f := d.File()
+		if f == nil {
+			continue
+		}
+		name := newName(d.Filename(), 0)
+		if b.shard != nil {
+			key, err := b.shardKey(f)
+			if err != nil {
+				return err
+			}
+			name = shardName(d.Filename(), key)
+		}
+
+		w, ok := writers[name]
+		if !ok {
+			w, err = b.newListWriter(name, pkg)
+			if err != nil {
+				return err
+			}
+			writers[name] = w
+		}
+		if err := w.write(internal.ToExpr(f)); err != nil {
+			return err
+		}
+	}
+	return d.Err()
+}
+
+// shardKey evaluates the --shard expression for the document f.
+func (b *buildPlan) shardKey(f *ast.File) (string, error) {
+	ctx := b.cmd.ctx
+	inst := ctx.BuildFile(f)
+	if err := inst.Err(); err != nil {
+		return "", err
+	}
+	v := ctx.BuildExpr(b.shard, cue.InferBuiltins(true), cue.Scope(inst))
+
+	var key string
+	switch v.Kind() {
+	case cue.StringKind:
+		key, _ = v.String()
+	case cue.IntKind:
+		key = fmt.Sprint(v)
+	default:
+		var arg interface{} = v
+		if err := v.Err(); err != nil {
+			arg = err
+		}
+		return "", fmt.Errorf("error evaluating shard expression %v: %v",
+			astinternal.DebugStr(b.shard), arg)
+	}
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		return "", fmt.Errorf("invalid shard key %q", key)
+	}
+	return key, nil
+}
+
+// shardName returns the name of the CUE file holding the documents of
+// filename with the given shard key.
+func shardName(filename, key string) string {
+	if filename == "-" {
+		filename = "stdin"
+	}
+	ext := filepath.Ext(filename)
+	return filename[:len(filename)-len(ext)] + "." + key + ".cue"
+}
+
+// A listWriter writes the elements of a CUE list to a file as they are
+// added.
+type listWriter struct {
+	w *bufio.Writer
+	c io.Closer // nil if the output is not to be closed
+	n int
+}
+
+// newListWriter returns a writer for the CUE file cueFile, which declares
+// package pkg, if set. It returns a writer discarding all elements if the
+// file already exists and the --force flag is not set.
+func (b *buildPlan) newListWriter(cueFile, pkg string) (*listWriter, error) {
+	cueFile, err := getFilename(b, &ast.File{Filename: cueFile}, "", flagForce.Bool(b.cmd))
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.Writer
+	var c io.Closer
+	switch cueFile {
+	case "":
+		w = io.Discard
+	case "-":
+		w = b.cmd.OutOrStdout()
+	default:
+		_ = os.MkdirAll(filepath.Dir(cueFile), 0755)
+		f, err := os.Create(cueFile)
+		if err != nil {
+			return nil, err
+		}
+		w, c = f, f
+	}
+
+	lw := &listWriter{w: bufio.NewWriter(w), c: c}
+	if pkg != "" {
+		fmt.Fprintf(lw.w, "package %s\n\n", pkg)
+	}
+	return lw, nil
+}
+
+func (w *listWriter) write(x ast.Expr) error {
+	b, err := format.Node(x, format.Simplify())
+	if err != nil {
+		return fmt.Errorf("error formatting file: %v", err)
+	}
+	if w.n == 0 {
+		w.w.WriteString("[\n")
+	} else {
+		w.w.WriteString(",\n")
+	}
+	w.n++
+	for i, line := range bytes.Split(bytes.TrimSpace(b), []byte("\n")) {
+		if i > 0 {
+			w.w.WriteByte('\n')
+		}
+		if len(line) > 0 {
+			w.w.WriteByte('\t')
+			w.w.Write(line)
+		}
+	}
+	return nil
+}
+
+func (w *listWriter) close() error {
+	if w.n == 0 {
+		w.w.WriteString("[]\n")
+	} else {
+		w.w.WriteString(",\n]\n")
+	}
+	err := w.w.Flush()
+	if w.c != nil {
+		if cerr := w.c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 type hoister struct {
 	fields map[string]bool
 }
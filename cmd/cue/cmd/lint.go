@@ -0,0 +1,103 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/tools/lint"
+)
+
+const flagRules flagName = "rules"
+
+func newLintCmd(c *Command) *cobra.Command {
+	var rules strings.Builder
+	for _, r := range lint.Rules() {
+		fmt.Fprintf(&rules, "  %-25s %s\n", r.Name, r.Doc)
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lint [packages]",
+		Short: "report suspicious constructs",
+		Long: `lint reports suspicious constructs in CUE packages
+
+Lint checks packages with a set of rules, each of which reports a class
+of constructs that are valid CUE but likely mistakes, and exits with a
+non-zero status if any rule reports a finding. Each finding is followed by
+the name of the rule that reported it.
+
+The following rules are available:
+
+` + rules.String() + `
+By default all rules are run. The --rules flag selects a comma-separated
+list of rules instead.
+
+Examples:
+
+  # Check the package in the current directory with all rules.
+  cue lint
+
+  # Check all packages of a module for unused imports only.
+  cue lint --rules unused-import ./...
+
+Rules may be added by tools built on the Go API in package
+cuelang.org/go/tools/lint.
+`,
+		RunE: mkRunE(c, runLint),
+	}
+
+	cmd.Flags().String(string(flagRules), "",
+		"comma-separated list of rules to run")
+
+	return cmd
+}
+
+func runLint(cmd *Command, args []string) error {
+	var rules []*lint.Rule
+	if s := flagRules.String(cmd); s != "" {
+		for _, name := range strings.Split(s, ",") {
+			name = strings.TrimSpace(name)
+			r := lint.Lookup(name)
+			if r == nil {
+				return errors.Newf(token.NoPos, "unknown lint rule %q", name)
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	binst := loadFromArgs(cmd, args, nil)
+	if binst == nil {
+		return nil
+	}
+	for _, inst := range binst {
+		exitOnErr(cmd, inst.Err, true)
+
+		// The value may be an error, for instance because of unused
+		// imports, which rules that check the syntax still report.
+		v := cmd.ctx.BuildInstance(inst)
+
+		var errs errors.Error
+		for _, d := range lint.Run(inst, v, rules) {
+			errs = errors.Append(errs, errors.Newf(d.Pos, "%s (%s)", d.Message, d.Rule))
+		}
+		exitOnErr(cmd, errs, false)
+	}
+	return nil
+}
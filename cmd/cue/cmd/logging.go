@@ -0,0 +1,131 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type logLevel int
+
+const (
+	logQuiet   logLevel = iota // only errors
+	logNormal                  // also informational messages and warnings
+	logVerbose                 // also progress and debug messages
+)
+
+// A logger writes diagnostic messages to stderr on behalf of all commands.
+// It honors the --quiet, --verbose and --log-format flags, so that humans
+// get feedback on long operations while automation can capture structured
+// logs.
+//
+// Errors are not reported through a logger: they are always printed.
+type logger struct {
+	mu      sync.Mutex
+	w       io.Writer
+	level   logLevel
+	json    bool
+	command string
+}
+
+func newLogger(c *Command) (*logger, error) {
+	l := &logger{
+		w:       c.OutOrStderr(),
+		level:   logNormal,
+		command: c.Command.Name(),
+	}
+	switch {
+	case flagQuiet.Bool(c) && flagVerbose.Bool(c):
+		return nil, fmt.Errorf("cannot combine --%s and --%s", flagQuiet, flagVerbose)
+	case flagQuiet.Bool(c):
+		l.level = logQuiet
+	case flagVerbose.Bool(c):
+		l.level = logVerbose
+	}
+	switch format := flagLogFormat.String(c); format {
+	case "", "text":
+	case "json":
+		l.json = true
+	default:
+		return nil, fmt.Errorf("unknown log format %q; must be text or json", format)
+	}
+	return l, nil
+}
+
+// Infof logs an informational message or warning, which is shown unless
+// --quiet is given.
+func (l *logger) Infof(format string, args ...interface{}) {
+	l.log(logNormal, "info", fmt.Sprintf(format, args...), nil)
+}
+
+// Debugf logs a message that is only shown with --verbose.
+func (l *logger) Debugf(format string, args ...interface{}) {
+	l.log(logVerbose, "debug", fmt.Sprintf(format, args...), nil)
+}
+
+// Progress logs that done out of total steps of the named operation have
+// completed. Progress is only shown with --verbose.
+func (l *logger) Progress(op string, done, total int, item string) {
+	l.log(logVerbose, "progress", fmt.Sprintf("[%d/%d] %s %s", done, total, op, item), map[string]interface{}{
+		"op":    op,
+		"done":  done,
+		"total": total,
+		"item":  item,
+	})
+}
+
+// Enabled reports whether messages of the given level are shown. It allows
+// callers to avoid computing expensive messages.
+func (l *logger) Enabled(level logLevel) bool {
+	return l != nil && l.level >= level
+}
+
+func (l *logger) log(level logLevel, name, msg string, attrs map[string]interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	var buf bytes.Buffer
+	if l.json {
+		entry := map[string]interface{}{
+			"level":   name,
+			"msg":     msg,
+			"command": l.command,
+		}
+		if !inTest {
+			entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+		}
+		for k, v := range attrs {
+			entry[k] = v
+		}
+		// Map keys are sorted by encoding/json, which keeps output stable.
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		buf.Write(b)
+	} else {
+		buf.WriteString(msg)
+	}
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(buf.Bytes())
+}
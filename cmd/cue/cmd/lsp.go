@@ -0,0 +1,50 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/internal/lsp"
+)
+
+func newLSPCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "run the CUE language server",
+		Long: `lsp runs a language server for CUE, communicating with an
+editor over standard input and output using the Language Server
+Protocol.
+
+The server supports:
+
+  - diagnostics for the errors of the packages of open files,
+  - go to definition of fields, aliases, let clauses and imports,
+  - hover, showing the evaluated value and documentation of a field,
+  - completion of field names and import paths, and
+  - renaming of fields, aliases, let clauses and imports.
+
+Files are evaluated as part of their package, using the contents of
+open files that have not yet been saved.
+`,
+		Args: cobra.NoArgs,
+		RunE: mkRunE(c, runLSP),
+	}
+	return cmd
+}
+
+func runLSP(cmd *Command, args []string) error {
+	return lsp.Serve(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+}
@@ -15,6 +15,8 @@
 package cmd
 
 import (
+	"context"
+	cryptorand "crypto/rand"
 	"fmt"
 	"math/rand"
 	"net/url"
@@ -22,7 +24,12 @@ import (
 	"path/filepath"
 	"strings"
 
+	"cuelabs.dev/go/oci/ociregistry"
 	"github.com/spf13/cobra"
+
+	"cuelang.org/go/internal/mod/modcache"
+	"cuelang.org/go/internal/mod/modload"
+	"cuelang.org/go/internal/mod/modsign"
 )
 
 func newModCmd(c *Command) *cobra.Command {
@@ -45,6 +52,11 @@ func newModCmd(c *Command) *cobra.Command {
 	}
 
 	cmd.AddCommand(newModInitCmd(c))
+	cmd.AddCommand(newModKeygenCmd(c))
+	cmd.AddCommand(newModOutdatedCmd(c))
+	cmd.AddCommand(newModPublishCmd(c))
+	cmd.AddCommand(newModTidyCmd(c))
+	cmd.AddCommand(newModVendorCmd(c))
 	return cmd
 }
 
@@ -109,6 +121,7 @@ func runModInit(cmd *Command, args []string) (err error) {
 		if !flagForce.Bool(cmd) {
 			return fmt.Errorf("detected old-style config file; use --force to upgrade")
 		}
+		cmd.logger.Debugf("upgrading old-style cue.mod file")
 		return backport(mod, cwd)
 	}
 
@@ -129,6 +142,7 @@ func runModInit(cmd *Command, args []string) (err error) {
 
 	// Set module even if it is empty, making it easier for users to fill it in.
 	_, err = fmt.Fprintf(f, "module: %q\n", module)
+	cmd.logger.Debugf("created %s", filepath.Join("cue.mod", "module.cue"))
 
 	if err = os.Mkdir(filepath.Join(mod, "usr"), 0755); err != nil {
 		return err
@@ -173,3 +187,269 @@ func backport(mod, cwd string) error {
 
 	return nil
 }
+
+func newModTidyCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tidy",
+		Short: "reconcile module dependencies with imports",
+		Long: `Tidy makes the dependencies recorded in cue.mod/module.cue
+match the packages imported by the current module.
+
+It adds the latest version of a module for each imported package
+not provided by any dependency, and removes dependencies that are
+no longer needed. The resulting module file lists every module in
+the build, directly or indirectly required.
+
+Tidy also writes cue.mod/module.lock, which records the hash of the
+contents of each dependency. Later builds check the contents of
+downloaded and vendored modules against these hashes.
+
+Note: this requires the modules experiment to be enabled
+with CUE_EXPERIMENT=modules.
+`,
+		RunE: mkRunE(c, runModTidy),
+	}
+	return cmd
+}
+
+func runModTidy(cmd *Command, args []string) error {
+	return runModLoad(cmd, args, modload.Tidy)
+}
+
+func newModVendorCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vendor",
+		Short: "copy module dependencies into cue.mod/vendor",
+		Long: `Vendor copies the contents of all dependencies of the current
+module into its cue.mod/vendor directory, replacing anything stored
+there before.
+
+The module must be tidy, as with "cue mod tidy", and the contents of
+each dependency are checked against cue.mod/module.lock before they
+are copied.
+
+When cue.mod/vendor exists, packages are loaded from the vendored
+modules rather than from the registry, and their contents are again
+checked against cue.mod/module.lock.
+
+Note: this requires the modules experiment to be enabled
+with CUE_EXPERIMENT=modules.
+`,
+		RunE: mkRunE(c, runModVendor),
+	}
+	return cmd
+}
+
+func runModVendor(cmd *Command, args []string) error {
+	return runModLoad(cmd, args, modload.Vendor)
+}
+
+func newModOutdatedCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "list newer versions of module dependencies",
+		Long: `Outdated lists the dependencies of the current module for which
+the registry holds a newer version with the same major version.
+Pre-releases are only listed for dependencies that use a pre-release.
+
+For each newer version, the schemas of all packages of the version
+in use are checked for subsumption by those of the newer version,
+as with Value.Subsume, to classify the risk of upgrading:
+
+	compatible  every value accepted by the version in use
+	            is also accepted by the newer version
+	breaking    the newer version removes a package or rejects
+	            values accepted by the version in use
+	unknown     the versions could not be compared, for instance
+	            because a package fails to build
+
+The reasons for a breaking or unknown upgrade are listed below it.
+
+Note: this requires the modules experiment to be enabled
+with CUE_EXPERIMENT=modules.
+`,
+		RunE: mkRunE(c, runModOutdated),
+	}
+	return cmd
+}
+
+func runModOutdated(cmd *Command, args []string) error {
+	root, reg, cache, err := modLoadEnv(args)
+	if err != nil {
+		return err
+	}
+	updates, err := modload.Outdated(context.Background(), root, reg, cache)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	for _, u := range updates {
+		fmt.Fprintf(w, "%s %s => %s: %v\n", u.Current.Path(), u.Current.Version(), u.Latest.Version(), u.Risk)
+		for _, err := range u.Errors {
+			fmt.Fprintf(w, "\t%v\n", err)
+		}
+	}
+	return nil
+}
+
+func newModPublishCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "publish <version>",
+		Short: "publish the current module to a registry",
+		Long: `Publish uploads the current module to the registry as the given
+version, so that other modules can depend on it.
+
+The version must be a canonical semantic version, such as v1.2.3,
+whose major version matches the major version suffix of the module
+path in cue.mod/module.cue. A version that is already published is
+not replaced.
+
+The module must be tidy, as with "cue mod tidy". The archive
+uploaded holds the files of the module, except for those in nested
+modules, in cue.mod/vendor and in version control directories. Its
+digest is printed once it is published.
+
+With --sign-key, the archive is signed with the private key in the
+given file, as created by "cue mod keygen". The signature covers the
+module path, the version and the digest of the archive, and is stored
+in the registry alongside the module.
+
+Modules that depend on a signed module can require its signature
+with a policy in their cue.mod/module.cue file:
+
+	signatures: {
+		keys: ["ed25519:..."]
+		required: true
+	}
+
+The signatures of modules downloaded from a registry are then
+checked against the trusted public keys listed in keys. A module
+that is signed must have a valid signature by one of these keys.
+If required is true, modules without a signature are rejected as
+well. Only Ed25519 keys are supported.
+
+Note: this requires the modules experiment to be enabled
+with CUE_EXPERIMENT=modules.
+`,
+		RunE: mkRunE(c, runModPublish),
+	}
+	cmd.Flags().String(string(flagSignKey), "", "file holding the private key to sign the module with")
+	return cmd
+}
+
+func runModPublish(cmd *Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("publish requires exactly one version argument")
+	}
+	var key string
+	if file := flagSignKey.String(cmd); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		key = string(data)
+	}
+	root, reg, cache, err := modLoadEnv(nil)
+	if err != nil {
+		return err
+	}
+	mv, digest, err := modload.Publish(context.Background(), root, args[0], reg, cache, key)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "published %v %s\n", mv, digest)
+	return nil
+}
+
+func newModKeygenCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keygen <file>",
+		Short: "generate a key pair to sign modules with",
+		Long: `Keygen generates an Ed25519 key pair for signing modules. It writes
+the private key to the given file, which must not exist yet, and
+prints the public key.
+
+The private key is used with "cue mod publish --sign-key". The public
+key is listed in the signatures policy of modules that trust it. See
+"cue help mod publish" for details.
+`,
+		RunE: mkRunE(c, runModKeygen),
+	}
+	return cmd
+}
+
+func runModKeygen(cmd *Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("keygen requires exactly one file argument")
+	}
+	public, private, err := modsign.GenerateKey(cryptorand.Reader)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, private); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), public)
+	return nil
+}
+
+// runModLoad runs f on the module containing the current directory.
+func runModLoad(cmd *Command, args []string, f func(context.Context, string, ociregistry.Interface, modcache.Cache) error) error {
+	root, reg, cache, err := modLoadEnv(args)
+	if err != nil {
+		return err
+	}
+	return f(context.Background(), root, reg, cache)
+}
+
+// modLoadEnv returns the root of the module containing the current
+// directory, the registry and the module cache, as needed to maintain the
+// dependencies of the module.
+func modLoadEnv(args []string) (string, ociregistry.Interface, modcache.Cache, error) {
+	if len(args) > 0 {
+		return "", nil, nil, fmt.Errorf("no arguments expected")
+	}
+	reg, err := getRegistry()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if reg == nil {
+		return "", nil, nil, fmt.Errorf("modules experiment not enabled (enable with CUE_EXPERIMENT=modules)")
+	}
+	cache, err := modCache()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	root, err := findModuleRoot()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return root, reg, cache, nil
+}
+
+// findModuleRoot returns the root of the module containing the current
+// directory.
+func findModuleRoot() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for dir := cwd; ; {
+		if info, err := os.Stat(filepath.Join(dir, "cue.mod")); err == nil && info.IsDir() {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("cannot find cue.mod directory in %s or any parent directory", cwd)
+		}
+		dir = parent
+	}
+}
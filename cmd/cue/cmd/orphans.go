@@ -59,6 +59,30 @@ func (b *buildPlan) parsePlacementFlags() error {
 		b.path = append(b.path, &ast.ParenExpr{X: l})
 	}
 
+	// The --stream flag of export concerns the output, not data files.
+	b.stream = b.importing && flagStream.Bool(cmd)
+	if str := flagShard.String(cmd); str != "" {
+		x, err := parser.ParseExpr("--shard", str)
+		if err != nil {
+			return fmt.Errorf("invalid shard expression: %v", err)
+		}
+		b.shard = x
+		b.stream = true
+	}
+	if b.stream {
+		switch {
+		case b.perFile, len(b.path) > 0, b.useContext, flagRecursive.Bool(cmd):
+			return fmt.Errorf(
+				"cannot combine --%s or --%s with flag %q, %q, %q, or %q",
+				flagStream, flagShard,
+				flagFiles, flagPath, flagWithContext, flagRecursive,
+			)
+		case b.shard != nil && flagOutFile.String(cmd) != "":
+			return fmt.Errorf("cannot combine --%s with flag %q",
+				flagShard, flagOutFile)
+		}
+	}
+
 	if !b.importing && !b.perFile && !b.useList && len(b.path) == 0 {
 		if b.useContext {
 			return fmt.Errorf(
@@ -93,6 +117,13 @@ func (b *buildPlan) placeOrphans(i *build.Instance, a []*decoderInfo) error {
 			continue
 		}
 
+		if b.stream {
+			if err := b.streamOrphans(di, pkg); err != nil {
+				return err
+			}
+			continue
+		}
+
 		d := di.dec(b)
 
 		var objs []*ast.File
@@ -109,9 +140,14 @@ func (b *buildPlan) placeOrphans(i *build.Instance, a []*decoderInfo) error {
 		}
 		d.Close()
 
+		// Newline-delimited JSON holds a sequence of records, which are
+		// imported as a list unless placed otherwise.
+		useList := b.useList ||
+			b.importing && di.file.Encoding == build.JSONL && len(b.path) == 0
+
 		if b.perFile {
 			for i, obj := range objs {
-				f, err := placeOrphans(b, d, pkg, obj)
+				f, err := placeOrphans(b, d, pkg, b.useList, obj)
 				if err != nil {
 					return err
 				}
@@ -123,13 +159,13 @@ func (b *buildPlan) placeOrphans(i *build.Instance, a []*decoderInfo) error {
 		// TODO: consider getting rid of this requirement. It is important that
 		// import will catch conflicts ahead of time then, though, and report
 		// this messages as a possible solution if there are conflicts.
-		if b.importing && len(objs) > 1 && len(b.path) == 0 && !b.useList {
+		if b.importing && len(objs) > 1 && len(b.path) == 0 && !useList {
 			return fmt.Errorf(
 				"%s, %s, or %s flag needed to handle multiple objects in file %s",
 				flagPath, flagList, flagFiles, shortFile(i.Root, di.file))
 		}
 
-		if !b.useList && len(b.path) == 0 && !b.useContext {
+		if !useList && len(b.path) == 0 && !b.useContext {
 			for _, f := range objs {
 				if pkg := b.encConfig.PkgName; pkg != "" {
 					internal.SetPackage(f, pkg, false)
@@ -138,7 +174,7 @@ func (b *buildPlan) placeOrphans(i *build.Instance, a []*decoderInfo) error {
 			}
 		} else {
 			// TODO: handle imports correctly, i.e. for proto.
-			f, err := placeOrphans(b, d, pkg, objs...)
+			f, err := placeOrphans(b, d, pkg, useList, objs...)
 			if err != nil {
 				return err
 			}
@@ -156,7 +192,7 @@ func (b *buildPlan) placeOrphans(i *build.Instance, a []*decoderInfo) error {
 	return nil
 }
 
-func placeOrphans(b *buildPlan, d *encoding.Decoder, pkg string, objs ...*ast.File) (*ast.File, error) {
+func placeOrphans(b *buildPlan, d *encoding.Decoder, pkg string, useList bool, objs ...*ast.File) (*ast.File, error) {
 	f := &ast.File{}
 	filename := d.Filename()
 
@@ -236,7 +272,7 @@ func placeOrphans(b *buildPlan, d *encoding.Decoder, pkg string, objs ...*ast.Fi
 		switch d.Interpretation() {
 		case build.ProtobufJSON:
 			v := b.instance.Value().LookupPath(path)
-			if b.useList {
+			if useList {
 				v, _ = v.Elem()
 			}
 			if !v.Exists() {
@@ -247,7 +283,7 @@ func placeOrphans(b *buildPlan, d *encoding.Decoder, pkg string, objs ...*ast.Fi
 			}
 		}
 
-		if b.useList {
+		if useList {
 			idx := index
 			for _, e := range labels {
 				idx = idx.label(e)
@@ -289,7 +325,7 @@ func placeOrphans(b *buildPlan, d *encoding.Decoder, pkg string, objs ...*ast.Fi
 		internal.SetPackage(f, pkg, false)
 	}
 
-	if b.useList {
+	if useList {
 		switch x := index.field.Value.(type) {
 		case *ast.StructLit:
 			f.Decls = append(f.Decls, x.Elts...)
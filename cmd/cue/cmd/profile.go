@@ -0,0 +1,381 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// profileTop is the number of paths and disjunctions listed in a text
+// profile.
+const profileTop = 20
+
+func addProfileFlag(f *pflag.FlagSet) {
+	f.String(string(flagProfile), "",
+		"write an evaluation profile to the given file, in pprof format if it ends in .pprof or .pb.gz, or as text to stderr for -")
+}
+
+// startProfile enables profiling of evaluation if the --profile flag is
+// given. The returned function writes the profile.
+func startProfile(c *Command) func() error {
+	name := flagProfile.String(c)
+	if name == "" {
+		return func() error { return nil }
+	}
+	p := &adt.Profile{}
+	rt := (*runtime.Runtime)(c.ctx)
+	rt.Hooks().Profile = p
+	start := time.Now()
+	return func() error {
+		rt.Hooks().Profile = nil
+		prof := newEvalProfile(rt, p, time.Since(start))
+		if name == "-" {
+			return prof.writeText(c.OutOrStderr())
+		}
+		var buf bytes.Buffer
+		var err error
+		if strings.HasSuffix(name, ".pprof") || strings.HasSuffix(name, ".pb.gz") {
+			err = prof.writePprof(&buf)
+		} else {
+			err = prof.writeText(&buf)
+		}
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, buf.Bytes(), 0o666)
+	}
+}
+
+// evalProfile is the profile of a single command, with the costs of paths
+// aggregated per package.
+type evalProfile struct {
+	duration     time.Duration
+	packages     []*adt.ProfileEntry
+	fields       []*adt.ProfileEntry
+	disjunctions []*adt.ProfileEntry
+	pkgOf        map[*adt.Vertex]string
+	total        adt.ProfileEntry
+}
+
+func newEvalProfile(rt *runtime.Runtime, p *adt.Profile, d time.Duration) *evalProfile {
+	prof := &evalProfile{
+		duration:     d,
+		fields:       p.Fields(),
+		disjunctions: p.Disjunctions(),
+		pkgOf:        map[*adt.Vertex]string{},
+	}
+	pkgs := map[string]*adt.ProfileEntry{}
+	for _, e := range prof.fields {
+		pkg, ok := prof.pkgOf[e.Root]
+		if !ok {
+			if inst := rt.GetInstanceFromNode(e.Root); inst != nil {
+				pkg = inst.ImportPath
+			}
+			prof.pkgOf[e.Root] = pkg
+		}
+		x := pkgs[pkg]
+		if x == nil {
+			x = &adt.ProfileEntry{Path: pkg}
+			pkgs[pkg] = x
+			prof.packages = append(prof.packages, x)
+		}
+		addEntry(x, e)
+		addEntry(&prof.total, e)
+	}
+	sort.SliceStable(prof.packages, func(i, j int) bool {
+		return prof.packages[i].Time > prof.packages[j].Time
+	})
+	return prof
+}
+
+func addEntry(x, y *adt.ProfileEntry) {
+	x.Unifications += y.Unifications
+	x.Disjuncts += y.Disjuncts
+	x.Time += y.Time
+	x.AllocBytes += y.AllocBytes
+	x.AllocObjects += y.AllocObjects
+}
+
+// path returns the path of e qualified with its package.
+func (p *evalProfile) path(e *adt.ProfileEntry) string {
+	return qualifiedPath(p.pkgOf[e.Root], e.Path)
+}
+
+// qualifiedPath qualifies path with the import path of its package, if
+// known. The root of a package is denoted by the import path alone.
+func qualifiedPath(pkg, path string) string {
+	switch {
+	case pkg == "" && path == "":
+		return "<root>"
+	case pkg == "":
+		return path
+	case path == "":
+		return pkg
+	}
+	return pkg + ":" + path
+}
+
+// pathPrefixes returns the qualified paths enclosing path, from the innermost
+// to the root of the package.
+func pathPrefixes(pkg, path string) []string {
+	var a []string
+	quoted := false
+	for i := len(path) - 1; i > 0; i-- {
+		switch path[i] {
+		case '"':
+			quoted = !quoted
+		case '.':
+			if !quoted {
+				a = append(a, qualifiedPath(pkg, path[:i]))
+			}
+		}
+	}
+	return append(a, qualifiedPath(pkg, ""))
+}
+
+func (p *evalProfile) writeText(w io.Writer) error {
+	cwd, _ := os.Getwd()
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "evaluation took %v: %d unifications, %d disjuncts, %s allocated\n",
+		p.duration.Round(time.Microsecond), p.total.Unifications, p.total.Disjuncts,
+		formatBytes(p.total.AllocBytes))
+
+	fmt.Fprintln(w, "\npackages:")
+	fmt.Fprintf(tw, "time\talloc\tunifications\tdisjuncts\t\n")
+	for _, e := range p.packages {
+		writeEntry(tw, e)
+		name := e.Path
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\n", name)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nslowest paths, excluding time spent on other paths:\n")
+	fmt.Fprintf(tw, "time\talloc\tunifications\tdisjuncts\t\n")
+	for i, e := range p.fields {
+		if i == profileTop {
+			break
+		}
+		writeEntry(tw, e)
+		fmt.Fprintf(tw, "%s", p.path(e))
+		if e.Pos.IsValid() {
+			fmt.Fprintf(tw, " (%s)", relPos(cwd, e.Pos))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	if len(p.disjunctions) > 0 {
+		fmt.Fprintf(w, "\nslowest disjunctions, including nested evaluation:\n")
+		fmt.Fprintf(tw, "time\talloc\tunifications\tdisjuncts\t\n")
+		for i, e := range p.disjunctions {
+			if i == profileTop {
+				break
+			}
+			writeEntry(tw, e)
+			fmt.Fprintf(tw, "%s\n", relPos(cwd, e.Pos))
+		}
+		tw.Flush()
+	}
+	return nil
+}
+
+func writeEntry(w io.Writer, e *adt.ProfileEntry) {
+	fmt.Fprintf(w, "%v\t%s\t%d\t%d\t  ",
+		e.Time.Round(time.Microsecond), formatBytes(e.AllocBytes), e.Unifications, e.Disjuncts)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// writePprof writes p as a gzip-compressed protocol buffer in the format
+// read by the pprof tool. Each path is a function, nested within the
+// functions of its parent paths, so that the cost of a path and all paths
+// below it can be inspected as cumulative values.
+func (p *evalProfile) writePprof(w io.Writer) error {
+	var b pprofBuilder
+	b.strings = map[string]int64{"": 0}
+	b.stringTable = []string{""}
+	b.locations = map[string]uint64{}
+
+	for _, x := range [][2]string{
+		{"unifications", "count"},
+		{"disjuncts", "count"},
+		{"time", "nanoseconds"},
+		{"alloc_space", "bytes"},
+		{"alloc_objects", "count"},
+	} {
+		var vt protoBuf
+		vt.int(1, b.str(x[0]))
+		vt.int(2, b.str(x[1]))
+		b.buf.bytes(1, vt.Bytes())
+	}
+
+	byPath := map[string]*adt.ProfileEntry{}
+	for _, e := range p.fields {
+		byPath[p.path(e)] = e
+	}
+	for _, e := range p.fields {
+		// Locations are listed from the leaf to the root.
+		locs := []uint64{b.location(p.path(e), e)}
+		if e.Path != "" {
+			for _, prefix := range pathPrefixes(p.pkgOf[e.Root], e.Path) {
+				locs = append(locs, b.location(prefix, byPath[prefix]))
+			}
+		}
+		var s protoBuf
+		s.packed(1, locs)
+		s.packedInt(2, []int64{
+			e.Unifications, e.Disjuncts, int64(e.Time), e.AllocBytes, e.AllocObjects,
+		})
+		b.buf.bytes(2, s.Bytes())
+	}
+
+	b.buf.int(10, int64(p.duration))
+	b.buf.int(14, b.str("time"))
+	b.buf.Write(b.locationsBuf.Bytes())
+	b.buf.Write(b.functionsBuf.Bytes())
+	for _, s := range b.stringTable {
+		b.buf.bytes(6, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.buf.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type pprofBuilder struct {
+	buf          protoBuf
+	locationsBuf protoBuf
+	functionsBuf protoBuf
+	strings      map[string]int64
+	stringTable  []string
+	locations    map[string]uint64
+}
+
+func (b *pprofBuilder) str(s string) int64 {
+	if i, ok := b.strings[s]; ok {
+		return i
+	}
+	i := int64(len(b.stringTable))
+	b.strings[s] = i
+	b.stringTable = append(b.stringTable, s)
+	return i
+}
+
+// location returns the location for the given path, adding it along with
+// a function of the same name if needed. The entry e of the path, if any,
+// determines the source position.
+func (b *pprofBuilder) location(path string, e *adt.ProfileEntry) uint64 {
+	if id, ok := b.locations[path]; ok {
+		return id
+	}
+	id := uint64(len(b.locations) + 1)
+	b.locations[path] = id
+
+	var fn protoBuf
+	fn.int(1, int64(id))
+	fn.int(2, b.str(path))
+	var line int64
+	if pos := entryPos(e); pos.IsValid() {
+		fn.int(4, b.str(pos.Filename))
+		line = int64(pos.Line)
+	}
+	b.functionsBuf.bytes(5, fn.Bytes())
+
+	var ln protoBuf
+	ln.int(1, int64(id))
+	ln.int(2, line)
+	var loc protoBuf
+	loc.int(1, int64(id))
+	loc.bytes(4, ln.Bytes())
+	b.locationsBuf.bytes(4, loc.Bytes())
+	return id
+}
+
+// protoBuf encodes the protocol buffer wire format.
+type protoBuf struct {
+	bytes.Buffer
+}
+
+func (b *protoBuf) varint(x uint64) {
+	b.Write(binary.AppendUvarint(nil, x))
+}
+
+func (b *protoBuf) int(field int, x int64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(field) << 3)
+	b.varint(uint64(x))
+}
+
+func (b *protoBuf) bytes(field int, x []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(x)))
+	b.Write(x)
+}
+
+func (b *protoBuf) packed(field int, a []uint64) {
+	var p protoBuf
+	for _, x := range a {
+		p.varint(x)
+	}
+	b.bytes(field, p.Bytes())
+}
+
+func (b *protoBuf) packedInt(field int, a []int64) {
+	u := make([]uint64, len(a))
+	for i, x := range a {
+		u[i] = uint64(x)
+	}
+	b.packed(field, u)
+}
+
+func entryPos(e *adt.ProfileEntry) token.Position {
+	if e == nil {
+		return token.Position{}
+	}
+	return e.Pos.Position()
+}
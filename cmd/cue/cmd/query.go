@@ -0,0 +1,96 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/encoding"
+	"cuelang.org/go/internal/filetypes"
+)
+
+func newQueryCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query <query> [packages | files]",
+		Short: "print the values matching a path query",
+		Long: `query prints the values selected by a path query
+
+Query evaluates the given packages or data files and prints each value
+selected by the query, in the format selected with --out, which defaults
+to JSON. It exits successfully if no values match.
+
+A query is a CUE path, such as a.b[2]."c-d", which may also contain
+the following steps:
+
+  [*]         all regular fields of a struct, or all elements of a
+              list; may also be written as *, as in a.*.b
+  ..x         the field x at any depth, including the value itself
+  [?expr]     the fields or elements that are structs for which the
+              CUE expression expr evaluates to true, where expr may
+              refer to the fields of that struct
+
+Each step selects values from the values selected by the previous
+steps, starting with the root value.
+
+Examples:
+
+  # Print the names of all containers of all deployments.
+  cue query 'deployment.*.spec.template.spec.containers[*].name' ./...
+
+  # Print the services of kind LoadBalancer as YAML.
+  cue query --out yaml 'service[?spec.type == "LoadBalancer"]' ./...
+
+  # Print the images of all containers at any depth.
+  cue query '..containers[*].image' ./...
+
+  # Query a data file.
+  cue query --out text 'items[0].metadata.name' pods.json
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: mkRunE(c, runQuery),
+	}
+
+	addOutFlags(cmd.Flags(), true)
+	addOrphanFlags(cmd.Flags())
+	addInjectionFlags(cmd.Flags(), false, false)
+
+	return cmd
+}
+
+func runQuery(cmd *Command, args []string) error {
+	q := cue.ParseQuery(args[0])
+	exitOnErr(cmd, q.Err(), true)
+
+	b, err := parseArgs(cmd, args[1:], &config{outMode: filetypes.Export})
+	exitOnErr(cmd, err, true)
+
+	enc, err := encoding.NewEncoder(b.outFile, b.encConfig)
+	exitOnErr(cmd, err, true)
+
+	iter := b.instances()
+	defer iter.close()
+	for iter.scan() {
+		values, err := iter.value().LookupAll(q)
+		exitOnErr(cmd, err, true)
+		for _, v := range values {
+			exitOnErr(cmd, enc.Encode(v), true)
+		}
+	}
+	exitOnErr(cmd, iter.err(), true)
+
+	exitOnErr(cmd, enc.Close(), true)
+	return nil
+}
@@ -4,16 +4,43 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"cuelabs.dev/go/oci/ociregistry"
-	"cuelabs.dev/go/oci/ociregistry/ociclient"
 	"cuelabs.dev/go/oci/ociregistry/ocifilter"
 	"cuelabs.dev/go/oci/ociregistry/ociref"
 
+	"cuelang.org/go/cue/load"
 	"cuelang.org/go/internal/cueexperiment"
+	"cuelang.org/go/internal/mod/modcache"
 )
 
+// modCache returns the cache for modules downloaded from a registry, which
+// is stored in $CUE_CACHE_DIR/mod. $CUE_CACHE_DIR defaults to a directory
+// within the user's cache directory.
+func modCache() (*modcache.Disk, error) {
+	// TODO document CUE_CACHE_DIR and CUE_MODCACHE_MAX_SIZE via a new
+	// "cue help environment" subcommand.
+	dir := os.Getenv("CUE_CACHE_DIR")
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine cache directory: %v", err)
+		}
+		dir = filepath.Join(userDir, "cue")
+	}
+	cache := modcache.NewDisk(filepath.Join(dir, "mod"))
+	if env := os.Getenv("CUE_MODCACHE_MAX_SIZE"); env != "" {
+		size, err := parseSize(env)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $CUE_MODCACHE_MAX_SIZE: %v", err)
+		}
+		cache.MaxSize = size
+	}
+	return cache, nil
+}
+
 func getRegistry() (ociregistry.Interface, error) {
 	// TODO document CUE_REGISTRY via a new "cue help environment" subcommand.
 	env := os.Getenv("CUE_REGISTRY")
@@ -31,11 +58,9 @@ func getRegistry() (ociregistry.Interface, error) {
 	if err != nil {
 		return nil, err
 	}
-	r, err := ociclient.New(host, &ociclient.Options{
-		Insecure: insecure,
-	})
+	r, err := load.NewRegistry(host, insecure)
 	if err != nil {
-		return nil, fmt.Errorf("cannot make OCI client: %v", err)
+		return nil, err
 	}
 	if prefix != "" {
 		r = ocifilter.Sub(r, prefix)
@@ -20,6 +20,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -32,6 +33,7 @@ import (
 	"cuelang.org/go/internal/cueexperiment"
 	"cuelang.org/go/internal/encoding"
 	"cuelang.org/go/internal/filetypes"
+	"cuelang.org/go/internal/source"
 )
 
 // TODO: commands
@@ -41,7 +43,6 @@ import (
 //   get:      convert cue from other languages, like proto and go.
 //   gen:      generate files for other languages
 //   generate  like go generate (also convert cue to go doc)
-//   test      load and fully evaluate test files.
 //
 // TODO: documentation of concepts
 //   tasks     the key element for cmd, serve, and fix
@@ -89,7 +90,36 @@ func mkRunE(c *Command, f runFunction) func(*cobra.Command, []string) error {
 			return err
 		}
 
-		err := f(c, args)
+		logger, err := newLogger(c)
+		if err != nil {
+			return err
+		}
+		c.logger = logger
+
+		diags, err := newDiagnostics(c)
+		if err != nil {
+			return err
+		}
+		c.diags = diags
+
+		if d := flagTimeout.Duration(c); d > 0 {
+			c.ctx = newContext(cuecontext.WithDeadline(time.Now().Add(d)))
+		}
+
+		writeProfile := startProfile(c)
+		defer func() {
+			if perr := writeProfile(); err == nil {
+				err = perr
+			}
+		}()
+
+		// Binary files read by the command may be mapped into memory, as
+		// the values referring to them are no longer used once it is done.
+		c.mappings = &source.Mappings{}
+		defer c.mappings.Close()
+
+		err = f(c, args)
+		c.diags.flush(c)
 
 		if statsEnc != nil {
 			var stats Stats
@@ -169,7 +199,7 @@ For more information on writing CUE configuration files see cuelang.org.`,
 	c := &Command{
 		Command: cmd,
 		root:    cmd,
-		ctx:     cuecontext.New(cuecontext.Interpreter(wasm.New())),
+		ctx:     newContext(),
 	}
 
 	cmdCmd := newCmdCmd(c)
@@ -177,15 +207,22 @@ For more information on writing CUE configuration files see cuelang.org.`,
 
 	subCommands := []*cobra.Command{
 		cmdCmd,
+		newCleanCmd(c),
 		newCompletionCmd(c),
 		newEvalCmd(c),
 		newDefCmd(c),
+		newDiffCmd(c),
+		newExplainCmd(c),
 		newExportCmd(c),
 		newFixCmd(c),
 		newFmtCmd(c),
 		newGetCmd(c),
 		newImportCmd(c),
+		newLintCmd(c),
+		newLSPCmd(c),
 		newModCmd(c),
+		newQueryCmd(c),
+		newTestCmd(c),
 		newTrimCmd(c),
 		newVersionCmd(c),
 		newVetCmd(c),
@@ -203,6 +240,7 @@ For more information on writing CUE configuration files see cuelang.org.`,
 	for _, sub := range subCommands {
 		cmd.AddCommand(sub)
 	}
+	addCompletions(cmd)
 
 	// Cobra's --help flag shows up in help text by default, which is unnecessary.
 	cmd.InitDefaultHelpFlag()
@@ -245,6 +283,12 @@ func Main() int {
 	return 0
 }
 
+// newContext returns the context used for evaluation by all commands.
+func newContext(opts ...cuecontext.Option) *cue.Context {
+	opts = append([]cuecontext.Option{cuecontext.Interpreter(wasm.New())}, opts...)
+	return cuecontext.New(opts...)
+}
+
 type Command struct {
 	// The currently active command.
 	*cobra.Command
@@ -256,7 +300,21 @@ type Command struct {
 
 	ctx *cue.Context
 
+	// logger reports diagnostics for the currently active command.
+	logger *logger
+
+	// diags reports errors in a machine-readable format if --format is
+	// given.
+	diags *diagnostics
+
 	hasErr bool
+
+	// watch holds the state of a command run with --watch.
+	watch *watchState
+
+	// mappings holds the files mapped into memory by the currently active
+	// command.
+	mappings *source.Mappings
 }
 
 type errWriter Command
@@ -305,7 +363,7 @@ func (c *Command) Run(ctx context.Context) (err error) {
 	// For the latter two, we need to use the default loading.
 	defer recoverError(&err)
 
-	if err := c.root.Execute(); err != nil {
+	if err := c.root.ExecuteContext(ctx); err != nil {
 		return err
 	}
 	if c.hasErr {
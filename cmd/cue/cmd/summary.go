@@ -0,0 +1,155 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"cuelang.org/go/tools/flow"
+)
+
+// Statuses of tasks reported in a task summary.
+const (
+	taskOK      = "ok"
+	taskFailed  = "failed"
+	taskSkipped = "skipped" // the task did not run
+	taskCached  = "cached"  // the results of the task were cached
+)
+
+// A taskSummary is a flow.Tracer that records the duration and outcome of
+// each task run by a workflow, to be reported after the run.
+type taskSummary struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	tasks map[string]*taskTiming
+}
+
+type taskTiming struct {
+	Task    string  `json:"task"`
+	Status  string  `json:"status"`
+	Seconds float64 `json:"seconds"`
+
+	// Attempts is set if the task was retried.
+	Attempts int `json:"attempts,omitempty"`
+
+	start time.Time
+	dur   time.Duration
+}
+
+func newTaskSummary() *taskSummary {
+	return &taskSummary{now: time.Now, tasks: map[string]*taskTiming{}}
+}
+
+// Start implements flow.Tracer.
+func (s *taskSummary) Start(ctx context.Context, name string, attrs ...flow.Attribute) (context.Context, flow.Span) {
+	if name != flow.TaskSpan {
+		return ctx, nopSpan{}
+	}
+	t := &taskTiming{start: s.now()}
+	for _, a := range attrs {
+		if a.Key == flow.AttrTaskPath {
+			t.Task, _ = a.Value.(string)
+		}
+	}
+	return ctx, &taskSpan{s, t}
+}
+
+type taskSpan struct {
+	s *taskSummary
+	t *taskTiming
+}
+
+func (x *taskSpan) End(err error, attrs ...flow.Attribute) {
+	s, t := x.s, x.t
+	t.dur = s.now().Sub(t.start)
+	t.Status = taskOK
+	if err != nil {
+		t.Status = taskFailed
+	}
+	for _, a := range attrs {
+		switch a.Key {
+		case flow.AttrTaskAttempts:
+			if n, ok := a.Value.(int); ok && n > 1 {
+				t.Attempts = n
+			}
+		case flow.AttrTaskCached:
+			t.Status = taskCached
+		}
+	}
+	s.mu.Lock()
+	s.tasks[t.Task] = t
+	s.mu.Unlock()
+}
+
+type nopSpan struct{}
+
+func (nopSpan) End(err error, attrs ...flow.Attribute) {}
+
+// write writes the summary of the given tasks to w, in the given format,
+// which is either "text" or "json". Tasks are sorted by decreasing duration;
+// tasks that did not run are listed last.
+func (s *taskSummary) write(w io.Writer, format string, tasks []*flow.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]*taskTiming, 0, len(tasks))
+	for _, t := range tasks {
+		path := t.Path().String()
+		x := s.tasks[path]
+		if x == nil {
+			x = &taskTiming{Task: path, Status: taskSkipped}
+		}
+		x.Seconds = x.dur.Seconds()
+		list = append(list, x)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if (a.Status == taskSkipped) != (b.Status == taskSkipped) {
+			return b.Status == taskSkipped
+		}
+		return a.dur > b.dur
+	})
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return enc.Encode(list)
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "TASK\tSTATUS\tDURATION")
+		for _, t := range list {
+			d := "-"
+			if t.Status != taskSkipped {
+				d = t.dur.Round(time.Microsecond).String()
+			}
+			status := t.Status
+			if t.Attempts > 1 {
+				status += fmt.Sprintf(" (%d attempts)", t.Attempts)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Task, status, d)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown summary format %q; must be text or json", format)
+}
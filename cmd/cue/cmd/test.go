@@ -0,0 +1,376 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/text/message"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/encoding"
+	"cuelang.org/go/internal/filetypes"
+)
+
+func newTestCmd(c *Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [packages]",
+		Short: "run the tests of CUE packages",
+		Long: `test runs the tests defined in the _test.cue files of packages
+
+Files ending in _test.cue are part of their package only when running
+test. A test is a regular field declared at the top level of such a file
+whose name starts with Test, followed by an upper case letter, digit, or
+underscore, such as TestDefaults or Test_x. Tests are evaluated within
+their package, so they may refer to all of its declarations.
+
+A test fails if its value has errors or is not concrete, which allows
+writing assertions as constraints:
+
+	TestPort: port & >=1024
+
+In addition, a test fails if
+
+  - it has fields got and want whose values differ, or
+  - it has a @test(golden=file) attribute and its value, or that of its
+    field got, differs from the contents of the file, which is
+    interpreted relative to the package directory.
+
+Golden files may be in any format supported by 'cue export', selected by
+their extension. The --update flag writes the golden files of the tests
+that are run instead of comparing them.
+
+For example:
+
+	package config
+
+	#Port: int & >=1024 | *8080
+
+	TestPortDefault: {
+		got:  #Port
+		want: 8080
+	}
+
+	TestService: service & {port: 443} @test(golden="testdata/service.json")
+
+Test prints a line for each package, followed by the failures of its
+tests. It exits with a non-zero status if any test fails.
+`,
+		RunE: mkRunE(c, runTest),
+	}
+
+	addInjectionFlags(cmd.Flags(), false, false)
+
+	cmd.Flags().String(string(flagRun), "",
+		"only run tests whose name matches the regular expression")
+	cmd.Flags().Bool(string(flagUpdate), false,
+		"update golden files instead of comparing them")
+
+	return cmd
+}
+
+const (
+	flagRun    flagName = "run"
+	flagUpdate flagName = "update"
+)
+
+func runTest(cmd *Command, args []string) error {
+	var match *regexp.Regexp
+	if s := flagRun.String(cmd); s != "" {
+		var err error
+		match, err = regexp.Compile(s)
+		if err != nil {
+			return errors.Newf(token.NoPos, "invalid --%s: %v", flagRun, err)
+		}
+	}
+
+	cfg := &load.Config{Tests: true}
+	setTags(cfg, cmd.Flags())
+	binst := loadFromArgs(cmd, args, cfg)
+	if binst == nil {
+		return nil
+	}
+
+	r := &testRunner{
+		cmd:    cmd,
+		w:      cmd.OutOrStdout(),
+		match:  match,
+		update: flagUpdate.Bool(cmd),
+	}
+	failed := false
+	for _, inst := range binst {
+		if !r.runPackage(inst) {
+			failed = true
+		}
+	}
+	if failed {
+		return ErrPrintedError
+	}
+	return nil
+}
+
+type testRunner struct {
+	cmd    *Command
+	w      io.Writer
+	match  *regexp.Regexp
+	update bool
+}
+
+// runPackage runs the tests of inst and reports whether they passed.
+func (r *testRunner) runPackage(inst *build.Instance) bool {
+	pkg := inst.ImportPath
+	if pkg == "" {
+		pkg = inst.DisplayPath
+	}
+	tests := testNames(inst)
+	var names []string
+	for _, name := range tests {
+		if r.match == nil || r.match.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 && inst.Err == nil {
+		fmt.Fprintf(r.w, "?   \t%s\t[no test files]\n", pkg)
+		return true
+	}
+
+	var v cue.Value
+	var err error
+	if inst.Err != nil {
+		err = inst.Err
+	} else {
+		v = r.cmd.ctx.BuildInstance(inst)
+		err = validatePackage(v, tests)
+	}
+	if err != nil {
+		r.printErr(err)
+		fmt.Fprintf(r.w, "FAIL\t%s\t[build failed]\n", pkg)
+		return false
+	}
+
+	ok := true
+	for _, name := range names {
+		if err := r.runTest(inst, v.LookupPath(cue.MakePath(cue.Str(name)))); err != nil {
+			fmt.Fprintf(r.w, "--- FAIL: %s\n", name)
+			r.printErr(err)
+			ok = false
+		} else if flagVerbose.Bool(r.cmd) {
+			fmt.Fprintf(r.w, "--- PASS: %s\n", name)
+		}
+	}
+	if !ok {
+		fmt.Fprintf(r.w, "FAIL\t%s\n", pkg)
+		return false
+	}
+	fmt.Fprintf(r.w, "ok  \t%s\n", pkg)
+	return true
+}
+
+// validatePackage reports the errors of v outside of the given tests. The
+// errors of failing tests are also errors of v as a whole, so v.Err cannot
+// be used.
+func validatePackage(v cue.Value, tests []string) error {
+	isTest := map[string]bool{}
+	for _, name := range tests {
+		isTest[name] = true
+	}
+	iter, err := v.Fields(cue.All())
+	if err != nil {
+		return err
+	}
+	var errs errors.Error
+	for iter.Next() {
+		if sel := iter.Selector(); sel.IsString() && isTest[sel.Unquoted()] {
+			continue
+		}
+		if err := iter.Value().Validate(); err != nil {
+			errs = errors.Append(errs, errors.Promote(err, ""))
+		}
+	}
+	return errs
+}
+
+// runTest evaluates a single test and returns its failures.
+func (r *testRunner) runTest(inst *build.Instance, t cue.Value) error {
+	if err := t.Validate(cue.Concrete(true)); err != nil {
+		return err
+	}
+
+	got := t.LookupPath(cue.ParsePath("got"))
+	if want := t.LookupPath(cue.ParsePath("want")); got.Exists() && want.Exists() {
+		if err := testDiff(want.Pos(), want, got); err != nil {
+			return err
+		}
+	}
+
+	attr := t.Attribute("test")
+	golden, found, err := attr.Lookup(0, "golden")
+	if err != nil || !found {
+		return nil
+	}
+	if !got.Exists() {
+		got = t
+	}
+	path := filepath.Join(inst.Dir, filepath.FromSlash(golden))
+	if r.update {
+		return writeGolden(path, got)
+	}
+	want, err := readGolden(path, t.Context())
+	if errors.Is(err, fs.ErrNotExist) {
+		return errors.Newf(t.Pos(), "golden file %s does not exist; run cue test --%s to create it", golden, flagUpdate)
+	}
+	if err != nil {
+		return err
+	}
+	return testDiff(t.Pos(), want, got)
+}
+
+// testDiff reports the differences between want and got as errors. Errors
+// are positioned at the differing values, or at pos if these have no
+// position.
+func testDiff(pos token.Pos, want, got cue.Value) error {
+	f := &diffFilter{}
+	var errs errors.Error
+	for _, d := range f.leaves(want, got) {
+		var msg string
+		p := d.Y.Pos()
+		switch d.Kind {
+		case cue.DiffAdded:
+			msg = fmt.Sprintf("got unexpected %s", diffString(d.Y))
+		case cue.DiffRemoved:
+			msg = fmt.Sprintf("missing, want %s", diffString(d.X))
+			p = d.X.Pos()
+		default:
+			msg = fmt.Sprintf("got %s, want %s", diffString(d.Y), diffString(d.X))
+		}
+		if !p.IsValid() {
+			p = pos
+		}
+		errs = errors.Append(errs, errors.Newf(p, "%s: %s", diffPath(d.Path), msg))
+	}
+	return errs
+}
+
+func readGolden(path string, ctx *cue.Context) (cue.Value, error) {
+	if _, err := os.Stat(path); err != nil {
+		return cue.Value{}, err
+	}
+	f, err := filetypes.ParseFile(path, filetypes.Input)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	d := encoding.NewDecoder(f, &encoding.Config{Mode: filetypes.Input})
+	defer d.Close()
+	if err := d.Err(); err != nil {
+		return cue.Value{}, err
+	}
+	v := ctx.BuildFile(d.File())
+	return v, v.Err()
+}
+
+func writeGolden(path string, v cue.Value) error {
+	f, err := filetypes.ParseFile(path, filetypes.Export)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc, err := encoding.NewEncoder(f, &encoding.Config{
+		Mode: filetypes.Export,
+		Out:  &buf,
+	})
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o666)
+}
+
+func (r *testRunner) printErr(err error) {
+	p := message.NewPrinter(getLang())
+	cwd, _ := os.Getwd()
+	var buf bytes.Buffer
+	errors.Print(&buf, err, &errors.Config{
+		Format: func(w io.Writer, format string, args ...interface{}) {
+			p.Fprintf(w, format, args...)
+		},
+		Cwd:     cwd,
+		ToSlash: inTest,
+	})
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		fmt.Fprintf(r.w, "    %s\n", line)
+	}
+}
+
+// testNames returns the names of the tests declared in the _test.cue files
+// of inst, in order of declaration.
+func testNames(inst *build.Instance) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, f := range inst.Files {
+		if !strings.HasSuffix(f.Filename, "_test.cue") {
+			continue
+		}
+		for _, d := range f.Decls {
+			field, ok := d.(*ast.Field)
+			if !ok {
+				continue
+			}
+			name, isIdent, err := ast.LabelName(field.Label)
+			if err != nil || !isIdent || !isTestName(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isTestName reports whether name is the name of a test: Test followed by
+// nothing or a character other than a lower case letter.
+func isTestName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "Test")
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(r)
+}
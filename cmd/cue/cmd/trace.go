@@ -0,0 +1,67 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// startTrace writes the events of evaluation to stderr if the --trace flag
+// is given. The returned function stops tracing.
+func startTrace(c *Command) func() {
+	if !flagTrace.Bool(c) {
+		return func() {}
+	}
+	cwd, _ := os.Getwd()
+	hooks := (*runtime.Runtime)(c.ctx).Hooks()
+	hooks.Tracer = &textTracer{w: c.OutOrStderr(), cwd: cwd}
+	return func() { hooks.Tracer = nil }
+}
+
+// textTracer writes trace events as lines of text, indented by their depth.
+type textTracer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	cwd string
+}
+
+func (t *textTracer) Trace(e adt.TraceEvent) {
+	var b strings.Builder
+	b.WriteString(strings.Repeat("  ", e.Depth))
+	path := e.Path
+	if path == "" {
+		path = "-"
+	}
+	fmt.Fprintf(&b, "%s %s", e.Kind, path)
+	if e.Pos.IsValid() {
+		fmt.Fprintf(&b, " (%s)", relPos(t.cwd, e.Pos))
+	}
+	if e.Err != nil {
+		format, args := e.Err.Msg()
+		fmt.Fprintf(&b, ": %s", fmt.Sprintf(format, args...))
+	}
+	b.WriteByte('\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.w, b.String())
+}
@@ -15,21 +15,23 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/diff"
 	"cuelang.org/go/tools/trim"
 )
 
 // TODO:
 // - remove the limitations mentioned in the documentation
-// - implement verification post-processing as extra safety
 
 // newTrimCmd creates a trim command
 func newTrimCmd(c *Command) *cobra.Command {
@@ -40,7 +42,22 @@ func newTrimCmd(c *Command) *cobra.Command {
 
 A field, struct, or list is removed if it is implied by a constraint, such
 as from an optional field matching a required field, a list type value,
-a comprehension or any other implied content. It will modify the files in place.
+a comprehension or any other implied content. Values equal to the default of
+such a constraint are removed as well, as are structs that were left empty
+by trimming if a comprehension generates them anyway. It will modify the
+files in place.
+
+The --report flag prints each removed field with the reason for its removal
+and the position of the value implying it. Combined with --dryrun, it shows
+what would be removed without modifying any files. The --diff flag instead
+prints the changes trim would make as a unified diff, without modifying any
+files.
+
+Before writing, trim checks that the trimmed files evaluate to the same
+output. The --verify flag additionally checks that all definitions of the
+trimmed packages are unchanged, so that the packages can be used as before
+as schemas by other packages. Regular fields matching a default are still
+removed, as that does not change the output.
 
 
 Limitations
@@ -50,7 +67,8 @@ Removal is on a best effort basis. Some caveats:
   they are included, but are only resolved on a best-effort basis.
 - Disjunctions that contain structs in implied content cannot be used to
   remove fields.
-- There is currently no verification step: manual verification is required.
+- A struct left empty by trimming is only removed if its existence is implied
+  by a comprehension.
 
 Examples:
 
@@ -88,11 +106,26 @@ removal.
 
 	addOutFlags(cmd.Flags(), false)
 
+	cmd.Flags().BoolP(string(flagDryrun), "n", false,
+		"only run simulation")
+	cmd.Flags().Bool(string(flagReport), false,
+		"print the removed fields and why they were removed")
+	cmd.Flags().Bool(string(flagVerify), false,
+		"also check that the definitions are unchanged by trimming")
+	cmd.Flags().Bool(string(flagDiff), false,
+		"print the changes as a unified diff instead of modifying any files")
+
 	return cmd
 }
 
+const (
+	flagReport flagName = "report"
+	flagVerify flagName = "verify"
+	flagDiff   flagName = "diff"
+)
+
 func runTrim(cmd *Command, args []string) error {
-	binst := loadFromArgs(args, nil)
+	binst := loadFromArgs(cmd, args, nil)
 	if binst == nil {
 		return nil
 	}
@@ -109,12 +142,36 @@ func runTrim(cmd *Command, args []string) error {
 
 	overlay := map[string]load.Source{}
 
+	trimCfg := &trim.Config{
+		Trace: flagTrace.Bool(cmd),
+	}
+	if flagReport.Bool(cmd) {
+		cwd, _ := os.Getwd()
+		w := cmd.OutOrStderr()
+		trimCfg.Report = func(r trim.Removal) {
+			fmt.Fprintf(w, "%s: removed %s: %s", relPos(cwd, r.Field.Pos()), r.Path, r.Reason)
+			if r.By.IsValid() {
+				fmt.Fprintf(w, " at %s", relPos(cwd, r.By))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	formatOpts := []format.Option{}
+	if flagSimplify.Bool(cmd) {
+		formatOpts = append(formatOpts, format.Simplify())
+	}
+
+	var edits []trim.FileEdit
 	for i, inst := range binst {
 		root := instances[i]
-		err := trim.Files(inst.Files, root.Value(), &trim.Config{
-			Trace: flagTrace.Bool(cmd),
-		})
-		if err != nil {
+		if flagDiff.Bool(cmd) {
+			e, err := trim.Edits(inst.Files, root.Value(), trimCfg, formatOpts...)
+			if err != nil {
+				return err
+			}
+			edits = append(edits, e...)
+		} else if err := trim.Files(inst.Files, root.Value(), trimCfg); err != nil {
 			return err
 		}
 
@@ -143,9 +200,27 @@ func runTrim(cmd *Command, args []string) error {
 				fmt.Println("You can file a bug here: https://cuelang.org/issues/new?assignees=&labels=NeedsInvestigation&template=bug_report.md&title=")
 				os.Exit(1)
 			}
+			if flagVerify.Bool(cmd) {
+				if err := verifyTrim(cmd, p.Value(), tinsts[i].Value()); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
+	if flagDiff.Bool(cmd) {
+		cwd, _ := os.Getwd()
+		for _, e := range edits {
+			if rel, err := filepath.Rel(cwd, e.Filename); err == nil {
+				e.Filename = rel
+			}
+			if _, err := cmd.OutOrStdout().Write(e.Diff()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	if flagDryrun.Bool(cmd) {
 		return nil
 	}
@@ -154,12 +229,7 @@ func runTrim(cmd *Command, args []string) error {
 		for _, f := range inst.Files {
 			filename := f.Filename
 
-			opts := []format.Option{}
-			if flagSimplify.Bool(cmd) {
-				opts = append(opts, format.Simplify())
-			}
-
-			b, err := format.Node(f, opts...)
+			b, err := format.Node(f, formatOpts...)
 			if err != nil {
 				return fmt.Errorf("error formatting file: %v", err)
 			}
@@ -182,3 +252,26 @@ func runTrim(cmd *Command, args []string) error {
 	}
 	return nil
 }
+
+// verifyTrim checks that the definitions of the trimmed value are those of
+// the original value.
+func verifyTrim(cmd *Command, orig, trimmed cue.Value) error {
+	iter, err := orig.Fields(cue.Definitions(true))
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		if !sel.IsDefinition() {
+			continue
+		}
+		path := cue.MakePath(sel)
+		k, script := diff.Schema.Diff(iter.Value(), trimmed.LookupPath(path))
+		if k != diff.Identity {
+			diff.Print(cmd.OutOrStderr(), script)
+			return errors.Newf(token.NoPos,
+				"aborting trim, definition %v differs after trimming; use -i to force trim", path)
+		}
+	}
+	return nil
+}
@@ -66,39 +66,7 @@ func runVersion(cmd *Command, args []string) error {
 		bi.Settings = append(bi.Settings, extra...)
 	}
 
-	// prefer ldflags `version` override
-	if version == defaultVersion {
-		// no version provided via ldflags, try buildinfo
-		if bi.Main.Version != "" && bi.Main.Version != defaultVersion {
-			version = bi.Main.Version
-		}
-	}
-
-	if version == defaultVersion {
-		// a specific version was not provided by ldflags or buildInfo
-		// attempt to make our own
-		var vcsTime time.Time
-		var vcsRevision string
-		for _, s := range bi.Settings {
-			switch s.Key {
-			case "vcs.time":
-				// If the format is invalid, we'll print a zero timestamp.
-				vcsTime, _ = time.Parse(time.RFC3339Nano, s.Value)
-			case "vcs.revision":
-				vcsRevision = s.Value
-				// module.PseudoVersion recommends the revision to be a 12-byte
-				// commit hash prefix, which is what cmd/go uses as well.
-				if len(vcsRevision) > 12 {
-					vcsRevision = vcsRevision[:12]
-				}
-			}
-		}
-		if vcsRevision != "" {
-			version = module.PseudoVersion("", "", vcsTime, vcsRevision)
-		}
-	}
-
-	fmt.Fprintf(w, "cue version %s\n\n", version)
+	fmt.Fprintf(w, "cue version %s\n\n", cueVersion(bi))
 	fmt.Fprintf(w, "go version %s\n", runtime.Version())
 	for _, s := range bi.Settings {
 		if s.Value == "" {
@@ -115,3 +83,39 @@ func runVersion(cmd *Command, args []string) error {
 	}
 	return nil
 }
+
+// cueVersion returns the version of the cue command as reported by
+// "cue version", using the build information in bi.
+func cueVersion(bi *debug.BuildInfo) string {
+	// prefer ldflags `version` override
+	if version != defaultVersion {
+		return version
+	}
+	// no version provided via ldflags, try buildinfo
+	if bi.Main.Version != "" && bi.Main.Version != defaultVersion {
+		return bi.Main.Version
+	}
+
+	// a specific version was not provided by ldflags or buildInfo
+	// attempt to make our own
+	var vcsTime time.Time
+	var vcsRevision string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.time":
+			// If the format is invalid, we'll print a zero timestamp.
+			vcsTime, _ = time.Parse(time.RFC3339Nano, s.Value)
+		case "vcs.revision":
+			vcsRevision = s.Value
+			// module.PseudoVersion recommends the revision to be a 12-byte
+			// commit hash prefix, which is what cmd/go uses as well.
+			if len(vcsRevision) > 12 {
+				vcsRevision = vcsRevision[:12]
+			}
+		}
+	}
+	if vcsRevision != "" {
+		return module.PseudoVersion("", "", vcsTime, vcsRevision)
+	}
+	return defaultVersion
+}
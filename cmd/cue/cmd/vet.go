@@ -15,6 +15,15 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"golang.org/x/text/message"
 
@@ -59,25 +68,68 @@ Examples:
   cue vet translations/*.yaml foo.cue -d '#Translation'
 
 If more than one expression is given, all must match all values.
+
+
+Adopting vet incrementally
+
+The --baseline flag names a JSON file recording known violations. Vet
+only fails for violations that are not in the baseline, which allows
+adopting stricter schemas for existing configurations without fixing all
+violations first. Violations are identified by their path, message, and
+the files involved, so that they remain known as files are edited.
+
+The --update-baseline flag records all current violations in the baseline
+file instead of reporting them. Run it to create the baseline and
+whenever violations are fixed, so that they cannot be reintroduced.
+
+Examples:
+
+  # Record the current violations.
+  cue vet --baseline baseline.json --update-baseline ./...
+
+  # Fail only for violations not recorded in baseline.json.
+  cue vet --baseline baseline.json ./...
+
+
+Watching
+
+The --watch flag keeps vet running and validates again whenever one of
+the loaded files changes, printing the violations found by each run.
 `
 
 func newVetCmd(c *Command) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "vet",
 		Short: "validate data",
-		Long:  vetDoc,
-		RunE:  mkRunE(c, doVet),
+		Long:  vetDoc + "\n" + diagnosticsHelp,
+		RunE:  mkRunE(c, runVet),
 	}
 
 	addOrphanFlags(cmd.Flags())
 	addInjectionFlags(cmd.Flags(), false, false)
+	addWatchFlag(cmd.Flags())
+	addDiagnosticsFlag(cmd.Flags())
 
 	cmd.Flags().BoolP(string(flagConcrete), "c", false,
 		"require the evaluation to be concrete")
 
+	cmd.Flags().String(string(flagBaseline), "",
+		"only report violations not recorded in the given JSON file")
+	cmd.Flags().Bool(string(flagUpdateBaseline), false,
+		"record current violations in the --baseline file instead of reporting them")
+
 	return cmd
 }
 
+const (
+	flagBaseline       flagName = "baseline"
+	flagUpdateBaseline flagName = "update-baseline"
+)
+
+func runVet(cmd *Command, args []string) error {
+	return runWatch(cmd, func() error { return doVet(cmd, args) })
+}
+
 // doVet validates instances. There are two modes:
 // - Only packages: vet all these packages
 // - Data files: compare each data instance against a single package.
@@ -87,8 +139,14 @@ func newVetCmd(c *Command) *cobra.Command {
 // TODO: allow unrooted schema, such as JSON schema to compare against
 // other values.
 func doVet(cmd *Command, args []string) error {
+	bl, err := loadBaseline(cmd)
+	exitOnErr(cmd, err, true)
+
 	b, err := parseArgs(cmd, args, &config{
 		noMerge: true,
+
+		// Ensure that known violations do not hide any that follow.
+		continueOnSchemaErr: bl != nil,
 	})
 	exitOnErr(cmd, err, true)
 
@@ -96,7 +154,7 @@ func doVet(cmd *Command, args []string) error {
 	// files on the command line.
 	// TODO: unify these two modes.
 	if len(b.orphaned) > 0 {
-		vetFiles(cmd, b)
+		vetFiles(cmd, b, bl)
 		return nil
 	}
 
@@ -127,18 +185,23 @@ func doVet(cmd *Command, args []string) error {
 			err = v.Validate(append(opt, cue.Concrete(false))...)
 			if !shown && err == nil {
 				shown = true
-				p := message.NewPrinter(getLang())
-				_, _ = p.Fprintln(w,
-					"some instances are incomplete; use the -c flag to show errors or suppress this message")
+				const msg = "some instances are incomplete; use the -c flag to show errors or suppress this message"
+				if d := cmd.diags; d != nil {
+					d.addWarning(cmd, msg)
+				} else {
+					p := message.NewPrinter(getLang())
+					_, _ = p.Fprintln(w, msg)
+				}
 			}
 		}
-		exitOnErr(cmd, err, false)
+		exitOnErr(cmd, bl.filter(err), false)
 	}
 	exitOnErr(cmd, iter.err(), true)
+	exitOnErr(cmd, bl.save(), true)
 	return nil
 }
 
-func vetFiles(cmd *Command, b *buildPlan) {
+func vetFiles(cmd *Command, b *buildPlan, bl *baseline) {
 	// Use -r type root, instead of -e
 
 	if !b.encConfig.Schema.Exists() {
@@ -152,7 +215,140 @@ func vetFiles(cmd *Command, b *buildPlan) {
 
 		// Always concrete when checking against concrete files.
 		err := v.Validate(cue.Concrete(true))
-		exitOnErr(cmd, err, false)
+		exitOnErr(cmd, bl.filter(err), false)
 	}
 	exitOnErr(cmd, iter.err(), false)
+	exitOnErr(cmd, bl.save(), true)
+}
+
+// A baseline records known violations, as configured with the --baseline
+// flag. A nil baseline reports all violations.
+type baseline struct {
+	file   string
+	update bool
+	cwd    string
+
+	known map[baselineEntry]int // number of remaining occurrences
+	found []baselineEntry
+}
+
+// A baselineEntry identifies a violation. It deliberately excludes line and
+// column numbers, which change as unrelated parts of a file are edited.
+type baselineEntry struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Files   string `json:"files,omitempty"` // comma-separated
+}
+
+type baselineFile struct {
+	Violations []baselineEntry `json:"violations"`
+}
+
+func loadBaseline(cmd *Command) (*baseline, error) {
+	file := flagBaseline.String(cmd)
+	update := flagUpdateBaseline.Bool(cmd)
+	if file == "" {
+		if update {
+			return nil, fmt.Errorf("--%s requires --%s", flagUpdateBaseline, flagBaseline)
+		}
+		return nil, nil
+	}
+	cwd, _ := os.Getwd()
+	bl := &baseline{
+		file:   file,
+		update: update,
+		cwd:    cwd,
+		known:  map[baselineEntry]int{},
+	}
+	if update {
+		return bl, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return bl, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f baselineFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid baseline file %s: %v", file, err)
+	}
+	for _, e := range f.Violations {
+		bl.known[e]++
+	}
+	return bl, nil
+}
+
+// filter returns the errors of err that are not known violations.
+func (bl *baseline) filter(err error) error {
+	if bl == nil || err == nil {
+		return err
+	}
+	var errs errors.Error
+	for _, e := range errors.Errors(err) {
+		entry := bl.entry(e)
+		bl.found = append(bl.found, entry)
+		switch {
+		case bl.update:
+		case bl.known[entry] > 0:
+			bl.known[entry]--
+		default:
+			errs = errors.Append(errs, e)
+		}
+	}
+	if errs == nil {
+		return nil
+	}
+	return errs
+}
+
+func (bl *baseline) entry(err errors.Error) baselineEntry {
+	format, args := err.Msg()
+	var files []string
+	seen := map[string]bool{}
+	for _, pos := range errors.Positions(err) {
+		file := pos.Filename()
+		if rel, err := filepath.Rel(bl.cwd, file); err == nil && bl.cwd != "" {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+		if file != "" && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return baselineEntry{
+		Path:    strings.Join(err.Path(), "."),
+		Message: fmt.Sprintf(format, args...),
+		Files:   strings.Join(files, ","),
+	}
+}
+
+// save writes the violations found to the baseline file if it is to be
+// updated.
+func (bl *baseline) save() error {
+	if bl == nil || !bl.update {
+		return nil
+	}
+	found := append([]baselineEntry{}, bl.found...)
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Files != b.Files {
+			return a.Files < b.Files
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Message < b.Message
+	})
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(baselineFile{Violations: found}); err != nil {
+		return err
+	}
+	return os.WriteFile(bl.file, buf.Bytes(), 0o666)
 }
@@ -0,0 +1,196 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// watchInterval is the time between checks for changed files.
+var watchInterval = 500 * time.Millisecond
+
+// A watchState holds the state of a command run with --watch.
+type watchState struct {
+	// runs counts the completed runs.
+	runs int
+
+	// files holds the files that determine the result of the current run,
+	// as returned by watchedFiles. They are recorded when instances are
+	// loaded, as later processing may remove files from the instances.
+	files []string
+}
+
+// watching reports whether the command runs with --watch.
+func (c *Command) watching() bool {
+	return c.watch != nil
+}
+
+// runWatch calls run and, if the --watch flag is set, calls it again each
+// time the files it loaded change, until the command's context is done.
+// Errors of a run are reported, but do not end watching. The returned
+// error is that of the last run.
+func runWatch(c *Command, run func() error) error {
+	if !flagWatch.Bool(c) {
+		return run()
+	}
+	c.watch = &watchState{}
+	defer func() { c.watch = nil }()
+
+	ctx := c.Context()
+	for {
+		c.hasErr = false
+		c.watch.files = nil
+		c.diags.begin()
+		err := runOnce(run)
+		if err != nil && err != ErrPrintedError {
+			exitOnErr(c, err, false)
+		}
+		c.diags.flush(c)
+		c.watch.runs++
+
+		files := c.watch.files
+		before := snapshot(files)
+		for {
+			select {
+			case <-ctx.Done():
+				if c.hasErr {
+					return ErrPrintedError
+				}
+				return nil
+			case <-time.After(watchInterval):
+			}
+			if !snapshotEqual(before, snapshot(files)) {
+				break
+			}
+		}
+	}
+}
+
+// runOnce calls run, recovering from fatal errors reported with
+// exitOnErr.
+func runOnce(run func() error) (err error) {
+	defer recoverError(&err)
+	return run()
+}
+
+// watchedFiles returns the files that determine the given instances and
+// their imports: their CUE files, including those that may be added to
+// their directories, data files, and module files. Directories are
+// returned with a trailing separator and stand for the CUE files they
+// hold.
+func watchedFiles(insts []*build.Instance) []string {
+	seen := map[string]bool{}
+	var files []string
+	add := func(name string) {
+		if name == "" || name == "-" || seen[name] {
+			return
+		}
+		seen[name] = true
+		files = append(files, name)
+	}
+	done := map[*build.Instance]bool{}
+	var walk func(inst *build.Instance)
+	walk = func(inst *build.Instance) {
+		if inst == nil || done[inst] {
+			return
+		}
+		done[inst] = true
+		if inst.Dir != "" && !inst.User {
+			add(filepath.Clean(inst.Dir) + string(filepath.Separator))
+		}
+		for _, f := range inst.BuildFiles {
+			add(f.Filename)
+		}
+		for _, f := range inst.OrphanedFiles {
+			add(f.Filename)
+		}
+		if inst.Root != "" {
+			add(filepath.Join(inst.Root, "cue.mod", "module.cue"))
+		}
+		for _, imp := range inst.Imports {
+			walk(imp)
+		}
+	}
+	for _, inst := range insts {
+		walk(inst)
+	}
+	return files
+}
+
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshot returns the size and modification time of the given files. For
+// directories, it returns those of the CUE files they hold.
+func snapshot(files []string) map[string]watchedFile {
+	m := map[string]watchedFile{}
+	for _, name := range files {
+		if dir := strings.TrimSuffix(name, string(filepath.Separator)); dir != name {
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				if e.IsDir() || !strings.HasSuffix(e.Name(), ".cue") {
+					continue
+				}
+				if fi, err := e.Info(); err == nil {
+					m[filepath.Join(dir, e.Name())] = watchedFile{fi.Size(), fi.ModTime()}
+				}
+			}
+			continue
+		}
+		if fi, err := os.Stat(name); err == nil {
+			m[name] = watchedFile{fi.Size(), fi.ModTime()}
+		}
+	}
+	return m
+}
+
+func snapshotEqual(a, b map[string]watchedFile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, x := range a {
+		if y, ok := b[name]; !ok || !x.modTime.Equal(y.modTime) || x.size != y.size {
+			return false
+		}
+	}
+	return true
+}
+
+// writeIfChanged writes data to the file with the given name, unless the
+// file already holds data. An existing file is only replaced if force is
+// true.
+func writeIfChanged(name string, data []byte, force bool) error {
+	old, err := os.ReadFile(name)
+	switch {
+	case err == nil && bytes.Equal(old, data):
+		return nil
+	case err == nil && !force:
+		return errors.Wrapf(fs.ErrExist, token.NoPos, "error writing %q", name)
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestExportWatch(t *testing.T) {
+	defer func(d time.Duration) { watchInterval = d }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.cue")
+	out := filepath.Join(dir, "out.json")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(in, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor := func(desc string, cond func() bool) {
+		t.Helper()
+		for start := time.Now(); !cond(); time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > 10*time.Second {
+				t.Fatalf("timed out waiting for %s", desc)
+			}
+		}
+	}
+	output := func(want string) func() bool {
+		return func() bool {
+			b, _ := os.ReadFile(out)
+			return string(b) == want
+		}
+	}
+
+	write("a: 1\n")
+	cmd, err := New([]string{"export", "--watch", "--out", "json", "-o", out, in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log syncBuffer
+	cmd.SetOutput(&log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- cmd.Run(ctx) }()
+
+	waitFor("first export", output("{\n    \"a\": 1\n}\n"))
+
+	write("a: 1\nb: \"two\"\n")
+	waitFor("second export", output("{\n    \"a\": 1,\n    \"b\": \"two\"\n}\n"))
+
+	write("a: 1 & 2\n")
+	waitFor("error", func() bool { return strings.Contains(log.String(), "conflicting values") })
+
+	write("a: 3\n")
+	waitFor("export after error", output("{\n    \"a\": 3\n}\n"))
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
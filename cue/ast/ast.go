@@ -413,17 +413,22 @@ type Ident struct {
 }
 
 // A BasicLit node represents a literal of basic type.
+//
+// A bytes literal created by NewBytes holds its contents in Raw rather than
+// in quoted form in Value, which is then empty. Code inspecting Value of
+// literals it did not create itself should check Raw first.
 type BasicLit struct {
 	ValuePos token.Pos   // literal position
 	Kind     token.Token // INT, FLOAT, DURATION, or STRING
 	Value    string      // literal string; e.g. 42, 0x7f, 3.14, 1_234_567, 1e-9, 2.4i, 'a', '\x7f', "foo", or '\m\n\o'
+	Raw      []byte      // if not nil, the unquoted contents of a bytes literal; Value is empty
 
 	comments
 	expr
 	label
 }
 
-// TODO: introduce and use NewLabel and NewBytes and perhaps NewText (in the
+// TODO: introduce and use NewLabel and perhaps NewText (in the
 // later case NewString would return a string or bytes type) to distinguish from
 // NewString. Consider how to pass indentation information.
 
@@ -435,6 +440,17 @@ func NewString(str string) *BasicLit {
 	return &BasicLit{Kind: token.STRING, ValuePos: token.NoPos, Value: str}
 }
 
+// NewBytes creates a new BasicLit with a bytes value without position.
+// The literal refers to b in Raw instead of holding its quoted form in Value,
+// which avoids copying large data. b must not be modified afterwards.
+// Useful for ASTs generated by code other than the CUE parser.
+func NewBytes(b []byte) *BasicLit {
+	if b == nil {
+		b = []byte{}
+	}
+	return &BasicLit{Kind: token.STRING, Raw: b}
+}
+
 // NewNull creates a new BasicLit configured to be a null value.
 // Useful for ASTs generated by code other than the CUE parser.
 func NewNull() *BasicLit {
@@ -464,7 +480,6 @@ func NewBool(b bool) *BasicLit {
 
 // TODO:
 // - use CUE-specific quoting (hoist functionality in export)
-// - NewBytes
 
 // A Interpolation node represents a string or bytes interpolation.
 type Interpolation struct {
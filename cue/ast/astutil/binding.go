@@ -0,0 +1,183 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astutil
+
+import (
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// A BindingKind indicates the kind of declaration that introduces a Binding.
+type BindingKind int
+
+const (
+	// LetBinding is a let clause, as in let X = expr.
+	LetBinding BindingKind = iota + 1
+
+	// FieldAlias is an alias for a field, as in X=label: value or
+	// X=[pattern]: value.
+	FieldAlias
+
+	// ValueAlias is an alias for the value of a field, as in label: X=value.
+	ValueAlias
+
+	// LabelAlias is an alias for the label matched by a pattern constraint,
+	// as in [X=pattern]: value.
+	LabelAlias
+)
+
+func (k BindingKind) String() string {
+	switch k {
+	case LetBinding:
+		return "let"
+	case FieldAlias:
+		return "field alias"
+	case ValueAlias:
+		return "value alias"
+	case LabelAlias:
+		return "label alias"
+	}
+	return "unknown"
+}
+
+// A Binding describes a name introduced by a let clause or an alias, along
+// with the identifiers referring to it.
+type Binding struct {
+	Kind BindingKind
+
+	// Name is the identifier that declares the binding.
+	Name *ast.Ident
+
+	// Decl is the node declaring the binding: a *ast.LetClause for let
+	// clauses and the *ast.Alias otherwise.
+	Decl ast.Node
+
+	// Expr is the expression the binding refers to. This is the expression
+	// of a let clause or value alias, the value of the field of a field
+	// alias, and the pattern of a label alias.
+	Expr ast.Expr
+
+	// Scope is the node in which the name is visible. This is the enclosing
+	// *ast.File, *ast.StructLit or *ast.Comprehension for let clauses and
+	// aliases of regular fields, and the *ast.Field for all other aliases.
+	Scope ast.Node
+
+	// References lists the identifiers that refer to the binding, in the
+	// order in which they appear.
+	References []*ast.Ident
+}
+
+// Bindings returns the let clauses and aliases declared in f, in the order in
+// which they appear, along with the identifiers referring to them.
+//
+// Identifiers of f that have not yet been resolved are resolved as by Resolve.
+func Bindings(f *ast.File) []*Binding {
+	Resolve(f, func(pos token.Pos, msg string, args ...interface{}) {})
+
+	var bindings []*Binding
+	// byTarget maps the node to which a resolved identifier refers to the
+	// binding it represents.
+	byTarget := map[ast.Node]*Binding{}
+	add := func(target ast.Node, b *Binding) {
+		if b.Name == nil {
+			return
+		}
+		bindings = append(bindings, b)
+		byTarget[target] = b
+	}
+
+	var scopes []ast.Node
+	ast.Walk(f, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.File, *ast.StructLit, *ast.Comprehension:
+			scopes = append(scopes, n)
+
+		case *ast.LetClause:
+			b := &Binding{
+				Kind:  LetBinding,
+				Name:  x.Ident,
+				Decl:  x,
+				Expr:  x.Expr,
+				Scope: scopes[len(scopes)-1],
+			}
+			add(x, b)
+			// References to let clauses of comprehensions resolve to the
+			// identifier of the clause.
+			byTarget[x.Ident] = b
+
+		case *ast.Field:
+			switch l := x.Label.(type) {
+			case *ast.Alias:
+				scope := scopes[len(scopes)-1]
+				if _, ok := l.Expr.(*ast.ListLit); ok {
+					scope = x
+				}
+				add(x, &Binding{
+					Kind:  FieldAlias,
+					Name:  aliasIdent(l),
+					Decl:  l,
+					Expr:  x.Value,
+					Scope: scope,
+				})
+
+			case *ast.ListLit:
+				if len(l.Elts) == 1 {
+					if a, ok := l.Elts[0].(*ast.Alias); ok {
+						add(a.Expr, &Binding{
+							Kind:  LabelAlias,
+							Name:  aliasIdent(a),
+							Decl:  a,
+							Expr:  a.Expr,
+							Scope: x,
+						})
+					}
+				}
+			}
+			if a, ok := x.Value.(*ast.Alias); ok {
+				add(a, &Binding{
+					Kind:  ValueAlias,
+					Name:  aliasIdent(a),
+					Decl:  a,
+					Expr:  a.Expr,
+					Scope: x,
+				})
+			}
+		}
+		return true
+	}, func(n ast.Node) {
+		switch n.(type) {
+		case *ast.File, *ast.StructLit, *ast.Comprehension:
+			scopes = scopes[:len(scopes)-1]
+		}
+	})
+
+	ast.Walk(f, func(n ast.Node) bool {
+		if x, ok := n.(*ast.Ident); ok && x.Node != nil {
+			if b := byTarget[x.Node]; b != nil && x != b.Name {
+				b.References = append(b.References, x)
+			}
+		}
+		return true
+	}, nil)
+
+	return bindings
+}
+
+func aliasIdent(a *ast.Alias) *ast.Ident {
+	if a.Ident == nil || a.Ident.Name == "_" {
+		return nil
+	}
+	return a.Ident
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astutil_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/internal/astinternal"
+	"cuelang.org/go/internal/cuetxtar"
+)
+
+func TestBindings(t *testing.T) {
+	test := cuetxtar.TxTarTest{
+		Root: "./testdata/bindings",
+		Name: "bindings",
+	}
+
+	test.Run(t, func(t *cuetxtar.Test) {
+		a := t.Instance()
+
+		for _, f := range a.Files {
+			if filepath.Ext(f.Filename) != ".cue" {
+				continue
+			}
+			base := filepath.Base(f.Filename)
+			w := t.Writer(base[:len(base)-len(".cue")])
+			for _, b := range astutil.Bindings(f) {
+				var refs []string
+				for _, r := range b.References {
+					refs = append(refs, fmt.Sprint(r.Pos().Position().Line, ":", r.Pos().Position().Column))
+				}
+				fmt.Fprintf(w, "%s %s at %v\n", b.Kind, b.Name.Name, b.Name.Pos().Position().Line)
+				fmt.Fprintf(w, "\texpr:  %s\n", astinternal.DebugStr(b.Expr))
+				fmt.Fprintf(w, "\tscope: %T\n", b.Scope)
+				fmt.Fprintf(w, "\trefs:  [%s]\n", strings.Join(refs, " "))
+			}
+		}
+	})
+}
@@ -0,0 +1,329 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// A Rewriter makes structured changes to the fields of a file.
+//
+// Fields are selected by patterns: labels separated by dots, where each
+// label is an identifier, a double-quoted string, or *, which matches any
+// regular, definition, or hidden label. For instance, "a.*.b" selects the
+// fields b of all fields of a. Fields nested in the struct literal values of
+// fields can be selected; fields defined in any other way, such as by
+// embedded structs, comprehensions, or pattern constraints, are not.
+//
+// Changes preserve the comments and attributes of the fields they affect
+// and the relative positions of all other nodes. Printing a formatted file
+// after rewriting it therefore only changes the affected parts of the file.
+type Rewriter struct {
+	file *ast.File
+}
+
+// NewRewriter returns a Rewriter that modifies f.
+func NewRewriter(f *ast.File) *Rewriter {
+	return &Rewriter{file: f}
+}
+
+// Fields returns the fields matching pattern in the order in which they
+// appear in the file.
+func (r *Rewriter) Fields(pattern string) ([]*ast.Field, error) {
+	matches, err := r.match(pattern)
+	if err != nil {
+		return nil, err
+	}
+	a := make([]*ast.Field, len(matches))
+	for i, m := range matches {
+		a[i] = m.field
+	}
+	return a, nil
+}
+
+// Replace replaces the value of each field matching pattern with the value
+// returned by fn for that field, unless fn returns nil. It returns the number
+// of fields that were changed.
+//
+// If a new value has no comments, it takes the comments of the value it
+// replaces. Likewise, a new value without a relative position takes that of
+// the old value.
+func (r *Rewriter) Replace(pattern string, fn func(f *ast.Field) ast.Expr) (int, error) {
+	matches, err := r.match(pattern)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, m := range matches {
+		if x := fn(m.field); x != nil {
+			replaceValue(m.field, x)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Delete deletes the fields matching pattern, along with their comments. It
+// returns the number of deleted fields.
+//
+// A declaration following a deleted field takes its relative position if it
+// starts a new line, so that blank lines separating sections of a struct
+// are retained.
+func (r *Rewriter) Delete(pattern string) (int, error) {
+	matches, err := r.match(pattern)
+	if err != nil {
+		return 0, err
+	}
+	// Delete in reverse order to keep the indices of the remaining
+	// matches valid.
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		decls := *m.decls
+		if next := m.index + 1; next < len(decls) {
+			rel := declRelPos(m.field)
+			if nextRel := declRelPos(decls[next]); nextRel >= token.Newline && rel > nextRel {
+				setDeclRelPos(decls[next], rel)
+			}
+		}
+		*m.decls = append(decls[:m.index], decls[m.index+1:]...)
+	}
+	return len(matches), nil
+}
+
+// Set sets the value of the field at path to x. The path may not contain
+// wildcards. If the field does not exist, it is added at the end of the
+// struct literal that would contain it, along with any fields for the
+// intermediate labels of path. If there are several fields for a label,
+// the first one is used.
+//
+// An existing value is replaced as by Replace.
+func (r *Rewriter) Set(path string, x ast.Expr) error {
+	labels, err := parsePattern(path)
+	if err != nil {
+		return err
+	}
+	decls := &r.file.Decls
+	var parent *ast.StructLit
+	for i, l := range labels {
+		if l.wildcard {
+			return fmt.Errorf("invalid path %q: wildcards not allowed", path)
+		}
+		var f *ast.Field
+		for _, d := range *decls {
+			if x, ok := d.(*ast.Field); ok && l.matches(x.Label) {
+				f = x
+				break
+			}
+		}
+		last := i == len(labels)-1
+		switch {
+		case f == nil && last:
+			appendDecl(parent, decls, &ast.Field{Label: l.label(), Value: x})
+			return nil
+
+		case f == nil:
+			f = &ast.Field{Label: l.label(), Value: ast.NewStruct()}
+			appendDecl(parent, decls, f)
+
+		case last:
+			replaceValue(f, x)
+			return nil
+		}
+		s, ok := f.Value.(*ast.StructLit)
+		if !ok {
+			return fmt.Errorf("cannot set %q: value of %s is not a struct literal",
+				path, strings.Join(labelNames(labels[:i+1]), "."))
+		}
+		decls, parent = &s.Elts, s
+	}
+	return nil
+}
+
+// replaceValue sets the value of f to x, retaining the comments and relative
+// position of the old value if x does not specify any.
+func replaceValue(f *ast.Field, x ast.Expr) {
+	old := f.Value
+	if len(ast.Comments(x)) == 0 {
+		CopyComments(x, old)
+	}
+	if !x.Pos().HasRelPos() {
+		ast.SetRelPos(x, old.Pos().RelPos())
+	}
+	f.Value = x
+}
+
+// appendDecl adds d to the end of decls, the declarations of s or of the
+// file if s is nil, using the same line layout as the existing declarations.
+func appendDecl(s *ast.StructLit, decls *[]ast.Decl, d ast.Decl) {
+	rel := token.Newline
+	switch n := len(*decls); {
+	case s != nil && !s.Lbrace.IsValid() && n > 0:
+		// The struct of a field with multiple labels, as in a: b: c. Such
+		// structs are printed with braces once they have multiple fields.
+		for _, d := range *decls {
+			ast.SetRelPos(d, token.Newline)
+		}
+	case n > 0:
+		if last := (*decls)[n-1].Pos().RelPos(); last > token.NoRelPos && last < token.Newline {
+			rel = token.Blank
+		}
+	}
+	ast.SetRelPos(d, rel)
+	*decls = append(*decls, d)
+}
+
+// declRelPos returns the relative position of d, including its doc
+// comments.
+func declRelPos(d ast.Decl) token.RelPos {
+	if cgs := ast.Comments(d); len(cgs) > 0 && cgs[0].Position == 0 {
+		return cgs[0].Pos().RelPos()
+	}
+	return d.Pos().RelPos()
+}
+
+// setDeclRelPos sets the relative position of d, including its doc
+// comments.
+func setDeclRelPos(d ast.Decl, rel token.RelPos) {
+	if cgs := ast.Comments(d); len(cgs) > 0 && cgs[0].Position == 0 {
+		ast.SetRelPos(cgs[0], rel)
+		return
+	}
+	ast.SetRelPos(d, rel)
+}
+
+// A fieldMatch is a field matching a pattern.
+type fieldMatch struct {
+	field *ast.Field
+	decls *[]ast.Decl // the declarations containing field
+	index int         // the index of field in decls
+}
+
+func (r *Rewriter) match(pattern string) ([]fieldMatch, error) {
+	labels, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var matches []fieldMatch
+	var walk func(decls *[]ast.Decl, labels []patternLabel)
+	walk = func(decls *[]ast.Decl, labels []patternLabel) {
+		for i, d := range *decls {
+			f, ok := d.(*ast.Field)
+			if !ok || !labels[0].matches(f.Label) {
+				continue
+			}
+			if len(labels) == 1 {
+				matches = append(matches, fieldMatch{field: f, decls: decls, index: i})
+				continue
+			}
+			if s, ok := f.Value.(*ast.StructLit); ok {
+				walk(&s.Elts, labels[1:])
+			}
+		}
+	}
+	walk(&r.file.Decls, labels)
+	return matches, nil
+}
+
+// A patternLabel is a label of a pattern.
+type patternLabel struct {
+	name     string
+	quoted   bool // the label was a double-quoted string
+	wildcard bool // the label was *
+}
+
+func (p patternLabel) matches(l ast.Label) bool {
+	name, isIdent, err := ast.LabelName(l)
+	if err != nil {
+		return false
+	}
+	if p.wildcard {
+		return true
+	}
+	if name != p.name {
+		return false
+	}
+	// Quoted labels starting with # or _ are regular fields, which differ
+	// from definitions and hidden fields with the same name.
+	if strings.HasPrefix(name, "#") || strings.HasPrefix(name, "_") {
+		return isIdent != p.quoted
+	}
+	return true
+}
+
+// label returns a label for creating a field matching p.
+func (p patternLabel) label() ast.Label {
+	if !p.quoted && ast.IsValidIdent(p.name) {
+		return ast.NewIdent(p.name)
+	}
+	return ast.NewString(p.name)
+}
+
+func labelNames(labels []patternLabel) []string {
+	a := make([]string, len(labels))
+	for i, l := range labels {
+		switch {
+		case l.wildcard:
+			a[i] = "*"
+		case l.quoted:
+			a[i] = strconv.Quote(l.name)
+		default:
+			a[i] = l.name
+		}
+	}
+	return a
+}
+
+func parsePattern(s string) ([]patternLabel, error) {
+	var labels []patternLabel
+	for rest := s; ; {
+		var l patternLabel
+		switch {
+		case strings.HasPrefix(rest, `"`):
+			q, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %v", s, err)
+			}
+			l.name, _ = strconv.Unquote(q)
+			l.quoted = true
+			rest = rest[len(q):]
+
+		default:
+			i := strings.IndexByte(rest, '.')
+			if i < 0 {
+				i = len(rest)
+			}
+			l.name, rest = rest[:i], rest[i:]
+			switch {
+			case l.name == "*":
+				l.wildcard = true
+			case !ast.IsValidIdent(l.name):
+				return nil, fmt.Errorf("invalid pattern %q: invalid label %q", s, l.name)
+			}
+		}
+		labels = append(labels, l)
+		if rest == "" {
+			return labels, nil
+		}
+		if rest[0] != '.' {
+			return nil, fmt.Errorf("invalid pattern %q: expected '.' after label", s)
+		}
+		rest = rest[1:]
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astutil_test
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+)
+
+const rewriteInput = `package foo
+
+// a is a.
+a: {
+	// b is b.
+	b: 1   // trailing b
+	c: "x" @go(C)
+
+	// Section.
+	d: 3
+	e: f: g: 3
+}
+
+#D: x:   int
+"#D": x: 1
+list: [1, 2]
+inline: {a: 1, b: 2}
+`
+
+func TestRewriter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rewrite func(r *astutil.Rewriter) error
+		out     string
+		err     string
+	}{{
+		name: "replace",
+		rewrite: func(r *astutil.Rewriter) error {
+			_, err := r.Replace("a.*", func(f *ast.Field) ast.Expr {
+				if _, ok := f.Value.(*ast.BasicLit); !ok {
+					return nil
+				}
+				return ast.NewString("new")
+			})
+			return err
+		},
+		out: `package foo
+
+// a is a.
+a: {
+	// b is b.
+	b: "new" // trailing b
+	c: "new" @go(C)
+
+	// Section.
+	d: "new"
+	e: f: g: 3
+}
+
+#D: x:   int
+"#D": x: 1
+list: [1, 2]
+inline: {a: 1, b: 2}
+`,
+	}, {
+		name: "delete",
+		rewrite: func(r *astutil.Rewriter) error {
+			if _, err := r.Delete("a.d"); err != nil {
+				return err
+			}
+			_, err := r.Delete(`"#D"`)
+			return err
+		},
+		out: `package foo
+
+// a is a.
+a: {
+	// b is b.
+	b: 1   // trailing b
+	c: "x" @go(C)
+
+	e: f: g: 3
+}
+
+#D: x: int
+list: [1, 2]
+inline: {a: 1, b: 2}
+`,
+	}, {
+		name: "set",
+		rewrite: func(r *astutil.Rewriter) error {
+			for _, p := range []string{"a.e.f.h", "#D.y", "inline.c", "new.x"} {
+				if err := r.Set(p, ast.NewBool(true)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		out: `package foo
+
+// a is a.
+a: {
+	// b is b.
+	b: 1   // trailing b
+	c: "x" @go(C)
+
+	// Section.
+	d: 3
+	e: f: {
+		g: 3
+		h: true
+	}
+}
+
+#D: {
+	x: int
+	y: true
+}
+"#D": x: 1
+list: [1, 2]
+inline: {a: 1, b: 2, c: true}
+new: {
+	x: true
+}
+`,
+	}, {
+		name: "set existing",
+		rewrite: func(r *astutil.Rewriter) error {
+			return r.Set("a.b", ast.NewString("x"))
+		},
+		out: `package foo
+
+// a is a.
+a: {
+	// b is b.
+	b: "x" // trailing b
+	c: "x" @go(C)
+
+	// Section.
+	d: 3
+	e: f: g: 3
+}
+
+#D: x:   int
+"#D": x: 1
+list: [1, 2]
+inline: {a: 1, b: 2}
+`,
+	}, {
+		name: "set in list",
+		rewrite: func(r *astutil.Rewriter) error {
+			return r.Set("list.a", ast.NewNull())
+		},
+		err: `cannot set "list.a": value of list is not a struct literal`,
+	}, {
+		name: "set wildcard",
+		rewrite: func(r *astutil.Rewriter) error {
+			return r.Set("a.*", ast.NewNull())
+		},
+		err: `invalid path "a.*": wildcards not allowed`,
+	}, {
+		name: "invalid pattern",
+		rewrite: func(r *astutil.Rewriter) error {
+			_, err := r.Delete("a..b")
+			return err
+		},
+		err: `invalid pattern "a..b": invalid label ""`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := parser.ParseFile("in.cue", rewriteInput, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tc.rewrite(astutil.NewRewriter(f))
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("got error %v; want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := format.Node(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
+
+func TestRewriterFields(t *testing.T) {
+	f, err := parser.ParseFile("in.cue", rewriteInput, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := astutil.NewRewriter(f)
+	testCases := []struct {
+		pattern string
+		want    string
+	}{
+		{"a.*", "b c d e"},
+		{"a.e.f.g", "g"},
+		{"*.x", "x x"},
+		{"#D.x", "x"},
+		{`"#D"`, "#D"},
+		{`"a".b`, "b"},
+		{"a.x", ""},
+	}
+	for _, tc := range testCases {
+		fields, err := r.Fields(tc.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range fields {
+			name, _, _ := ast.LabelName(f.Label)
+			names = append(names, name)
+		}
+		if got := strings.Join(names, " "); got != tc.want {
+			t.Errorf("Fields(%q): got %q; want %q", tc.pattern, got, tc.want)
+		}
+	}
+}
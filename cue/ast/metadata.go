@@ -0,0 +1,228 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Metadata records the comments and attributes of a CUE syntax tree that
+// cannot be represented in data-only encodings such as JSON or YAML.
+//
+// Metadata is intended to be used as a side-channel: a tool converting CUE
+// to JSON extracts the metadata with ExtractMetadata and stores it alongside
+// the JSON output, for instance in a separate file. After the JSON has been
+// converted back to CUE, ApplyMetadata reattaches the comments and attributes
+// to the resulting syntax tree.
+//
+// The metadata itself is designed to be marshaled as JSON. It maps the path
+// of each field to the metadata of that field. Paths use CUE path syntax:
+// labels are separated by dots, labels that are not valid identifiers are
+// quoted, and list elements are denoted by their index in square brackets,
+// as in
+//
+//	a.b."x-y"[2].c
+//
+// The empty path refers to the file itself and holds attributes declared at
+// the top level of the file. Fields with labels that are not concrete, such
+// as pattern constraints or interpolations, are not recorded.
+type Metadata map[string]*FieldMetadata
+
+// FieldMetadata holds the metadata of a single field.
+type FieldMetadata struct {
+	// Doc holds the text of the doc comments associated with the field,
+	// without comment markers.
+	Doc string `json:"doc,omitempty"`
+
+	// Line holds the text of the comment on the same line as the field,
+	// without comment markers.
+	Line string `json:"line,omitempty"`
+
+	// Attrs holds the attributes of the field, including the leading '@'.
+	Attrs []string `json:"attrs,omitempty"`
+}
+
+// ExtractMetadata returns the comments and attributes of all fields in n.
+// It returns an empty, non-nil Metadata if n does not have any.
+func ExtractMetadata(n Node) Metadata {
+	m := Metadata{}
+	if f, ok := n.(*File); ok {
+		var fm FieldMetadata
+		for _, d := range f.Decls {
+			if a, ok := d.(*Attribute); ok {
+				fm.Attrs = append(fm.Attrs, a.Text)
+			}
+		}
+		if fm.Attrs != nil {
+			m[""] = &fm
+		}
+		extractDecls(m, "", f.Decls)
+		return m
+	}
+	if e, ok := n.(Expr); ok {
+		extractExpr(m, "", e)
+	}
+	return m
+}
+
+func extractDecls(m Metadata, path string, decls []Decl) {
+	for _, d := range decls {
+		switch x := d.(type) {
+		case *Field:
+			p, ok := appendLabel(path, x.Label)
+			if !ok {
+				continue
+			}
+			var fm FieldMetadata
+			for _, a := range x.Attrs {
+				fm.Attrs = append(fm.Attrs, a.Text)
+			}
+			for _, cg := range Comments(x) {
+				switch {
+				case cg.Doc:
+					fm.Doc += cg.Text()
+				case cg.Line:
+					fm.Line += cg.Text()
+				}
+			}
+			if fm.Doc != "" || fm.Line != "" || fm.Attrs != nil {
+				m[p] = &fm
+			}
+			extractExpr(m, p, x.Value)
+
+		case *EmbedDecl:
+			extractExpr(m, path, x.Expr)
+		}
+	}
+}
+
+func extractExpr(m Metadata, path string, e Expr) {
+	switch x := e.(type) {
+	case *StructLit:
+		extractDecls(m, path, x.Elts)
+
+	case *ListLit:
+		for i, e := range x.Elts {
+			extractExpr(m, path+"["+strconv.Itoa(i)+"]", e)
+		}
+	}
+}
+
+func appendLabel(path string, l Label) (string, bool) {
+	name, isIdent, err := LabelName(l)
+	if err != nil {
+		return "", false
+	}
+	// Quoted labels starting with '#' or '_' denote regular fields and must
+	// remain quoted to be distinguished from definitions and hidden fields.
+	if !IsValidIdent(name) || !isIdent && strings.ContainsAny(name[:1], "#_") {
+		name = strconv.Quote(name)
+	}
+	if path == "" {
+		return name, true
+	}
+	return path + "." + name, true
+}
+
+// ApplyMetadata attaches the comments and attributes recorded in m to the
+// corresponding fields in n. Comments and attributes already present in n
+// are retained. Entries of m for which n has no corresponding field are
+// ignored. File-level attributes are only applied if n is a *File.
+func ApplyMetadata(n Node, m Metadata) {
+	if len(m) == 0 {
+		return
+	}
+	if f, ok := n.(*File); ok {
+		if fm := m[""]; fm != nil && len(fm.Attrs) > 0 {
+			// Insert attributes after the package clause and imports, if any.
+			i := 0
+			for ; i < len(f.Decls); i++ {
+				switch f.Decls[i].(type) {
+				case *Package, *ImportDecl, *CommentGroup, *Attribute:
+					continue
+				}
+				break
+			}
+			decls := make([]Decl, 0, len(f.Decls)+len(fm.Attrs))
+			decls = append(decls, f.Decls[:i]...)
+			for _, a := range fm.Attrs {
+				decls = append(decls, &Attribute{Text: a})
+			}
+			f.Decls = append(decls, f.Decls[i:]...)
+		}
+		applyDecls(m, "", f.Decls)
+		return
+	}
+	if e, ok := n.(Expr); ok {
+		applyExpr(m, "", e)
+	}
+}
+
+func applyDecls(m Metadata, path string, decls []Decl) {
+	for _, d := range decls {
+		switch x := d.(type) {
+		case *Field:
+			p, ok := appendLabel(path, x.Label)
+			if !ok {
+				continue
+			}
+			if fm := m[p]; fm != nil {
+				for _, a := range fm.Attrs {
+					x.Attrs = append(x.Attrs, &Attribute{Text: a})
+				}
+				if fm.Doc != "" {
+					cg := commentGroup(fm.Doc)
+					cg.Doc = true
+					AddComment(x, cg)
+				}
+				if fm.Line != "" {
+					cg := commentGroup(fm.Line)
+					cg.Line = true
+					cg.Position = 4
+					AddComment(x, cg)
+				}
+			}
+			applyExpr(m, p, x.Value)
+
+		case *EmbedDecl:
+			applyExpr(m, path, x.Expr)
+		}
+	}
+}
+
+func applyExpr(m Metadata, path string, e Expr) {
+	switch x := e.(type) {
+	case *StructLit:
+		applyDecls(m, path, x.Elts)
+
+	case *ListLit:
+		for i, e := range x.Elts {
+			applyExpr(m, path+"["+strconv.Itoa(i)+"]", e)
+		}
+	}
+}
+
+func commentGroup(text string) *CommentGroup {
+	cg := &CommentGroup{}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if line == "" {
+			cg.List = append(cg.List, &Comment{Text: "//"})
+		} else {
+			cg.List = append(cg.List, &Comment{Text: "// " + line})
+		}
+	}
+	return cg
+}
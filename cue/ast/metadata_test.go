@@ -0,0 +1,91 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	cuejson "cuelang.org/go/encoding/json"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	const src = `@schema(v1)
+
+// The name of the service.
+name: "svc" @go(Name)
+ports: [{
+	// The port number.
+	port: 80 @go(Port,type=int)
+}]
+"x-y": {
+	a: true // line comment
+}
+"#def": 1 @tag()
+`
+	f, err := parser.ParseFile("in.cue", src, parser.ParseComments)
+	qt.Assert(t, qt.IsNil(err))
+
+	// Convert the metadata and the data to JSON separately.
+	meta, err := json.Marshal(ast.ExtractMetadata(f))
+	qt.Assert(t, qt.IsNil(err))
+	data, err := cuecontext.New().BuildFile(f).MarshalJSON()
+	qt.Assert(t, qt.IsNil(err))
+
+	// And convert back.
+	var m ast.Metadata
+	qt.Assert(t, qt.IsNil(json.Unmarshal(meta, &m)))
+	expr, err := cuejson.Extract("in.json", data)
+	qt.Assert(t, qt.IsNil(err))
+	out := &ast.File{Decls: expr.(*ast.StructLit).Elts}
+	ast.ApplyMetadata(out, m)
+
+	b, err := format.Node(out)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.Equals(string(b), `@schema(v1)
+
+// The name of the service.
+name: "svc" @go(Name)
+ports: [{
+	// The port number.
+	port: 80 @go(Port,type=int)
+}]
+"x-y": {
+	a: true // line comment
+}
+"#def": 1 @tag()
+`))
+}
+
+func TestExtractMetadata(t *testing.T) {
+	f, err := parser.ParseFile("in.cue", `
+a: {
+	// doc
+	b: [1, {c: 2 @x()}]
+}
+[string]: int @ignored()
+`, parser.ParseComments)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.DeepEquals(ast.ExtractMetadata(f), ast.Metadata{
+		"a.b":      {Doc: "doc\n"},
+		"a.b[1].c": {Attrs: []string{"@x()"}},
+	}))
+}
@@ -41,10 +41,13 @@ const (
 	Binary      Encoding = "binary"
 	Protobuf    Encoding = "proto"
 	TextProto   Encoding = "textproto"
-	BinaryProto Encoding = "pb"
-
-	// TODO:
-	// TOML
+	BinaryProto Encoding = "binarypb"
+	TOML        Encoding = "toml"
+	XML         Encoding = "xml"
+	HCL         Encoding = "hcl"
+	GraphQL     Encoding = "graphql"
+	INI         Encoding = "ini"
+	DotEnv      Encoding = "dotenv"
 
 	Code Encoding = "code" // Programming languages
 )
@@ -70,6 +73,8 @@ const (
 	JSONSchema   Interpretation = "jsonschema"
 	OpenAPI      Interpretation = "openapi"
 	ProtobufJSON Interpretation = "pb"
+	Avro         Interpretation = "avro"
+	TFSchema     Interpretation = "tfschema"
 )
 
 // A Form specifies the form in which a program should be represented.
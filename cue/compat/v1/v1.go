@@ -0,0 +1,313 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1 provides a frozen subset of the cue API for programs that embed
+// the evaluator and need to upgrade it without changing their code.
+//
+// The API of this package is stable: no identifier is removed or changes its
+// signature, and the documented behavior of each function is retained across
+// releases. New functionality is only added in a new major version of this
+// package, such as v2, which may coexist with this one in the same program.
+//
+// The package deliberately exposes only a small part of the cue API. Values
+// are looked up by path strings, kinds are represented by the Kind type of
+// this package, and errors are returned as plain error values that may be
+// inspected with Details. Programs that need more functionality can convert
+// a Value to a cue.Value with Value.Unwrap, but lose the compatibility
+// guarantees of this package for any code using the result.
+//
+// The evaluation results, such as the precise text of error messages, may
+// still change between releases as the evaluator is improved. Only the
+// classification of results, such as whether a value is concrete or whether
+// evaluating it fails, is guaranteed.
+package v1
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+)
+
+// A Context creates and holds values. Values created by different contexts
+// may not be combined.
+type Context struct {
+	ctx *cue.Context
+}
+
+// NewContext returns a new Context.
+func NewContext() *Context {
+	return &Context{ctx: cuecontext.New()}
+}
+
+// CompileString parses and evaluates the CUE source src. The filename is
+// used in error messages and may be empty.
+//
+// Any error is reported by the returned value; see Value.Err.
+func (c *Context) CompileString(filename, src string) Value {
+	return Value{c.ctx.CompileString(src, cue.Filename(filename))}
+}
+
+// CompileBytes is like CompileString, but takes the source as a byte slice.
+func (c *Context) CompileBytes(filename string, src []byte) Value {
+	return Value{c.ctx.CompileBytes(src, cue.Filename(filename))}
+}
+
+// Encode converts the Go value x to a Value. Go values are converted as by
+// encoding/json, including the use of json struct tags.
+//
+// Any error is reported by the returned value; see Value.Err.
+func (c *Context) Encode(x interface{}) Value {
+	return Value{c.ctx.Encode(x)}
+}
+
+// A Value is the result of evaluating CUE. The zero Value does not exist.
+type Value struct {
+	v cue.Value
+}
+
+// Unwrap returns the cue.Value underlying v. The compatibility guarantees of
+// this package do not extend to the use of the returned value.
+func (v Value) Unwrap() cue.Value {
+	return v.v
+}
+
+// Exists reports whether v exists. A value does not exist if it is the
+// result of looking up a field that is not defined.
+func (v Value) Exists() bool {
+	return v.v.Exists()
+}
+
+// Err returns the error of v, if any, including errors for values that are
+// incomplete.
+func (v Value) Err() error {
+	return v.v.Err()
+}
+
+// Kind returns the kind of v if v is concrete, or BottomKind otherwise.
+func (v Value) Kind() Kind {
+	return toKind(v.v.Kind())
+}
+
+// IsConcrete reports whether v is a concrete value, such as a number, string
+// or struct, rather than a type or constraint. Structs and lists are concrete
+// even if their elements are not.
+func (v Value) IsConcrete() bool {
+	return v.v.IsConcrete()
+}
+
+// Lookup returns the value at the given path relative to v, using the
+// syntax of CUE selectors, such as
+//
+//	a.b[2]."c-d"
+//
+// The result does not exist if there is no such value. Any error in path
+// is reported by the returned value.
+func (v Value) Lookup(path string) Value {
+	p := cue.ParsePath(path)
+	if err := p.Err(); err != nil {
+		return pathError(v, err)
+	}
+	return Value{v.v.LookupPath(p)}
+}
+
+// pathError returns a value in error for the invalid path of a call to a
+// method of v.
+func pathError(v Value, err error) Value {
+	return Value{v.v.Context().Encode(errors.Promote(err, "invalid path"))}
+}
+
+// Unify returns the unification of v and w.
+func (v Value) Unify(w Value) Value {
+	return Value{v.v.Unify(w.v)}
+}
+
+// Fill returns the result of unifying v with the Go value x placed at the
+// given path relative to v. The Go value is converted as by Context.Encode.
+func (v Value) Fill(path string, x interface{}) Value {
+	p := cue.ParsePath(path)
+	if err := p.Err(); err != nil {
+		return pathError(v, err)
+	}
+	if w, ok := x.(Value); ok {
+		x = w.v
+	}
+	return Value{v.v.FillPath(p, x)}
+}
+
+// Validate reports any errors in v, including its descendants. If concrete
+// is true, it also reports an error for any regular field that does not
+// have a concrete value.
+func (v Value) Validate(concrete bool) error {
+	return v.v.Validate(cue.Concrete(concrete))
+}
+
+// Subsumes reports whether every value that is an instance of w is also an
+// instance of v.
+func (v Value) Subsumes(w Value) bool {
+	return v.v.Subsumes(w.v)
+}
+
+// Decode initializes the Go value x with v, where x must be a pointer. The
+// conversion follows the rules of encoding/json.
+func (v Value) Decode(x interface{}) error {
+	return v.v.Decode(x)
+}
+
+// String returns the string value of v, or an error if v is not a concrete
+// string.
+func (v Value) String() (string, error) {
+	return v.v.String()
+}
+
+// Bytes returns the bytes value of v, or an error if v is not a concrete
+// bytes or string value.
+func (v Value) Bytes() ([]byte, error) {
+	return v.v.Bytes()
+}
+
+// Bool returns the boolean value of v, or an error if v is not a concrete
+// boolean.
+func (v Value) Bool() (bool, error) {
+	return v.v.Bool()
+}
+
+// Int64 returns the integer value of v, or an error if v is not a concrete
+// integer or does not fit in an int64.
+func (v Value) Int64() (int64, error) {
+	return v.v.Int64()
+}
+
+// Float64 returns the numeric value of v as a float64, or an error if v is
+// not a concrete number.
+func (v Value) Float64() (float64, error) {
+	return v.v.Float64()
+}
+
+// Fields returns the regular fields of v in the order in which they are
+// defined, or an error if v is not a struct.
+func (v Value) Fields() ([]Field, error) {
+	iter, err := v.v.Fields()
+	if err != nil {
+		return nil, err
+	}
+	var a []Field
+	for iter.Next() {
+		a = append(a, Field{
+			Label: iter.Selector().Unquoted(),
+			Value: Value{iter.Value()},
+		})
+	}
+	return a, nil
+}
+
+// Elems returns the elements of v, or an error if v is not a list.
+func (v Value) Elems() ([]Value, error) {
+	iter, err := v.v.List()
+	if err != nil {
+		return nil, err
+	}
+	var a []Value
+	for iter.Next() {
+		a = append(a, Value{iter.Value()})
+	}
+	return a, nil
+}
+
+// MarshalJSON returns the JSON encoding of v, or an error if v is not
+// concrete.
+func (v Value) MarshalJSON() ([]byte, error) {
+	return v.v.MarshalJSON()
+}
+
+// Source returns v formatted as CUE source. If final is true, defaults are
+// resolved and optional fields and definitions are omitted.
+func (v Value) Source(final bool) ([]byte, error) {
+	var opts []cue.Option
+	if final {
+		opts = append(opts, cue.Final())
+	}
+	return format.Node(v.v.Syntax(opts...))
+}
+
+// A Field is a regular field of a struct.
+type Field struct {
+	Label string
+	Value Value
+}
+
+// A Kind is the kind of a concrete value.
+type Kind int
+
+const (
+	// BottomKind is the kind of values that are not concrete or are in
+	// error.
+	BottomKind Kind = iota
+	NullKind
+	BoolKind
+	IntKind
+	FloatKind
+	StringKind
+	BytesKind
+	StructKind
+	ListKind
+)
+
+var kindStrings = [...]string{
+	BottomKind: "_|_",
+	NullKind:   "null",
+	BoolKind:   "bool",
+	IntKind:    "int",
+	FloatKind:  "float",
+	StringKind: "string",
+	BytesKind:  "bytes",
+	StructKind: "struct",
+	ListKind:   "list",
+}
+
+// String returns the CUE type name of k.
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindStrings) {
+		return "_|_"
+	}
+	return kindStrings[k]
+}
+
+func toKind(k cue.Kind) Kind {
+	switch k {
+	case cue.NullKind:
+		return NullKind
+	case cue.BoolKind:
+		return BoolKind
+	case cue.IntKind:
+		return IntKind
+	case cue.FloatKind:
+		return FloatKind
+	case cue.StringKind:
+		return StringKind
+	case cue.BytesKind:
+		return BytesKind
+	case cue.StructKind:
+		return StructKind
+	case cue.ListKind:
+		return ListKind
+	}
+	return BottomKind
+}
+
+// Details returns a detailed description of err, including the positions
+// of the CUE source involved, with one error per line.
+func Details(err error) string {
+	return errors.Details(err, nil)
+}
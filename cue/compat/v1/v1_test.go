@@ -0,0 +1,131 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "cuelang.org/go/cue/compat/v1"
+)
+
+func TestValue(t *testing.T) {
+	ctx := v1.NewContext()
+	v := ctx.CompileString("in.cue", `
+	#Schema: {
+		name:     string
+		replicas: int | *1
+	}
+	a: #Schema & {name: "foo"}
+	b: #Schema
+	"c-d": [1, 2.5, "x", null, true, 'y', {}]
+	`)
+	if err := v.Err(); err != nil {
+		t.Fatal(v1.Details(err))
+	}
+
+	var kinds []string
+	elems, err := v.Lookup(`"c-d"`).Elems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range elems {
+		kinds = append(kinds, e.Kind().String())
+	}
+	if got, want := strings.Join(kinds, " "), "int float string null bool bytes struct"; got != want {
+		t.Errorf("got kinds %q; want %q", got, want)
+	}
+
+	if n, err := v.Lookup("a.replicas").Int64(); err != nil || n != 1 {
+		t.Errorf("got a.replicas %v, %v; want 1", n, err)
+	}
+	if err := v.Lookup("a").Validate(true); err != nil {
+		t.Errorf("unexpected error validating a: %v", err)
+	}
+	if err := v.Lookup("b").Validate(true); err == nil {
+		t.Errorf("expected error validating b")
+	}
+	if got := v.Lookup("b.name").Kind(); got != v1.BottomKind {
+		t.Errorf("got kind %v for b.name; want %v", got, v1.BottomKind)
+	}
+	if v.Lookup("x").Exists() {
+		t.Errorf("x should not exist")
+	}
+	if err := v.Lookup("a.").Err(); err == nil {
+		t.Errorf("expected error for invalid path")
+	}
+
+	b := v.Fill("b.name", "bar")
+	s, err := b.Lookup("b.name").String()
+	if err != nil || s != "bar" {
+		t.Errorf("got b.name %q, %v; want bar", s, err)
+	}
+	if !v.Lookup("#Schema").Subsumes(b.Lookup("b")) {
+		t.Errorf("#Schema does not subsume b")
+	}
+	if err := v.Fill("a.replicas", "x").Lookup("a.replicas").Err(); err == nil {
+		t.Errorf("expected conflict filling a.replicas")
+	}
+
+	var x struct {
+		Name     string `json:"name"`
+		Replicas int    `json:"replicas"`
+	}
+	if err := b.Lookup("b").Decode(&x); err != nil {
+		t.Fatal(err)
+	}
+	if x.Name != "bar" || x.Replicas != 1 {
+		t.Errorf("got decoded value %+v", x)
+	}
+
+	fields, err := b.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, f := range fields {
+		labels = append(labels, f.Label)
+	}
+	if got, want := strings.Join(labels, " "), "a b c-d"; got != want {
+		t.Errorf("got labels %q; want %q", got, want)
+	}
+}
+
+func ExampleValue_Unify() {
+	ctx := v1.NewContext()
+	schema := ctx.CompileString("schema.cue", `{
+		name:  string
+		port?: int & >0
+	}`)
+	data := ctx.Encode(map[string]interface{}{"name": "web", "port": 8080})
+
+	v := schema.Unify(data)
+	if err := v.Validate(true); err != nil {
+		fmt.Println(v1.Details(err))
+		return
+	}
+	b, _ := v.MarshalJSON()
+	fmt.Println(string(b))
+
+	src, _ := v.Source(true)
+	fmt.Println(string(src))
+	// Output:
+	// {"name":"web","port":8080}
+	// {
+	// 	name: "web"
+	// 	port: 8080
+	// }
+}
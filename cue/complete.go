@@ -0,0 +1,115 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A Completion describes a field that may be defined in a struct, as reported
+// by [Value.Completions].
+type Completion struct {
+	// Selector is the label of the field. Its constraint type reports
+	// whether the field is optional or required. It is AnyString for a
+	// pattern constraint.
+	Selector Selector
+
+	// Pattern is the value that labels must match for a pattern constraint,
+	// such as =~"^x-". It does not exist for named fields.
+	Pattern Value
+
+	// Value is the value of the field as constrained by the struct.
+	Value Value
+
+	// Kind is the set of kinds the value of the field may have.
+	Kind Kind
+
+	// Default is the default value of the field. It does not exist if the
+	// field has no default.
+	Default Value
+
+	// Doc holds the doc comments of the field.
+	Doc []*ast.CommentGroup
+}
+
+// Completions reports the fields that may be defined in the struct at path p
+// of v, such as a cursor position in an editor. It reports the fields of the
+// struct, including optional and required fields, followed by its pattern
+// constraints. By default, definitions and hidden fields are omitted; the
+// options select the fields to report, as for [Value.Fields].
+//
+// The path may refer to fields that are not defined in v, as long as they
+// are allowed by optional fields or pattern constraints. No other fields may
+// be added to the struct if it is closed, which can be checked with
+// [Value.Allows] and [AnyString].
+//
+// Completions returns an error if p does not refer to a field that v allows.
+// It returns no completions if the value at p cannot be a struct.
+func (v Value) Completions(p Path, opts ...Option) ([]Completion, error) {
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	w := v
+	sels := p.Selectors()
+	for i, sel := range sels {
+		if sel.LabelType() == StringLabel && sel.ConstraintType() == 0 {
+			// Also look up optional and required fields and fields that
+			// only exist as a pattern constraint.
+			sel = sel.Optional()
+		}
+		x := w.LookupPath(MakePath(sel))
+		if !x.Exists() && sel.LabelType() == IndexLabel {
+			// Elements beyond the end of an open list are constrained by
+			// its ellipsis.
+			x = w.LookupPath(MakePath(AnyIndex))
+		}
+		if !x.Exists() {
+			return nil, errors.Newf(token.NoPos, "%v: field not allowed", MakePath(sels[:i+1]...))
+		}
+		w = x
+	}
+	if w.IncompleteKind()&StructKind == 0 {
+		return nil, nil
+	}
+
+	iter, err := w.Fields(append([]Option{Optional(true)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	var a []Completion
+	for iter.Next() {
+		a = append(a, newCompletion(iter.Selector(), Value{}, iter.Value()))
+	}
+	for _, p := range w.Patterns() {
+		a = append(a, newCompletion(AnyString, p.Pattern, p.Value))
+	}
+	return a, nil
+}
+
+func newCompletion(sel Selector, pattern, v Value) Completion {
+	c := Completion{
+		Selector: sel,
+		Pattern:  pattern,
+		Value:    v,
+		Kind:     v.IncompleteKind(),
+		Doc:      v.Doc(),
+	}
+	if d, ok := v.Default(); ok {
+		c.Default = d
+	}
+	return c
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestCompletions(t *testing.T) {
+	ctx := cuecontext.New()
+	v := ctx.CompileString(`
+	#Service: {
+		// name is the name of the service.
+		name!: string
+		// kind is the protocol.
+		kind:  *"http" | "grpc"
+		port?: {number: int, tls?: bool}
+		// Extensions.
+		[=~"^x-"]: {value: string}
+		#Internal: int
+	}
+	service: #Service & {name: "web"}
+	list: [...#Service]
+	`)
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		path string
+		opts []cue.Option
+		want string
+		err  string
+	}{{
+		path: "service",
+		want: `
+name string doc="name is the name of the service."
+kind string default="http" doc="kind is the protocol."
+port? struct
+[=~"^x-"] struct doc="Extensions."`,
+	}, {
+		path: "service",
+		opts: []cue.Option{cue.Definitions(true)},
+		want: `
+name string doc="name is the name of the service."
+kind string default="http" doc="kind is the protocol."
+port? struct
+#Internal int
+[=~"^x-"] struct doc="Extensions."`,
+	}, {
+		// Optional fields that are not yet defined may be completed.
+		path: "service.port",
+		want: `
+number int
+tls? bool`,
+	}, {
+		// As may fields that only exist as a pattern constraint.
+		path: `service."x-ext"`,
+		want: `
+value string`,
+	}, {
+		// And elements of open lists.
+		path: "list[2].port",
+		want: `
+number int
+tls? bool`,
+	}, {
+		path: "service.kind",
+		want: ``,
+	}, {
+		path: "service.other",
+		err:  "service.other: field not allowed",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			a, err := v.Completions(cue.ParsePath(tc.path), tc.opts...)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("got error %v; want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := &strings.Builder{}
+			for _, c := range a {
+				if c.Pattern.Exists() {
+					fmt.Fprintf(b, "\n[%v] %v", c.Pattern, c.Kind)
+				} else {
+					fmt.Fprintf(b, "\n%v %v", c.Selector, c.Kind)
+				}
+				if c.Default.Exists() {
+					fmt.Fprintf(b, " default=%v", c.Default)
+				}
+				if len(c.Doc) > 0 {
+					fmt.Fprintf(b, " doc=%q", strings.TrimSpace(c.Doc[0].Text()))
+				}
+			}
+			if got := b.String(); got != tc.want {
+				t.Errorf("got:%s\nwant:%s", got, tc.want)
+			}
+		})
+	}
+}
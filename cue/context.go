@@ -15,6 +15,9 @@
 package cue
 
 import (
+	goruntime "runtime"
+	"sync"
+
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/ast/astutil"
 	"cuelang.org/go/cue/build"
@@ -39,6 +42,14 @@ import (
 //	ctx := cuecontext.New()
 //
 // to create a new Context.
+//
+// Values created by a Context are fully evaluated and may be used by multiple
+// goroutines simultaneously: operations such as LookupPath, Unify, FillPath,
+// Validate, and Decode create new values rather than modifying existing ones.
+// This allows, for instance, a schema to be compiled once and used to
+// validate many requests concurrently. Building instances that import the
+// same packages should not be done concurrently using BuildInstance, as the
+// imported packages are evaluated lazily; use BuildInstancesParallel instead.
 type Context runtime.Runtime
 
 func (c *Context) runtime() *runtime.Runtime {
@@ -109,6 +120,24 @@ func InferBuiltins(elide bool) BuildOption {
 	}
 }
 
+// Parallel evaluates the independent top-level fields of a built value on up
+// to n goroutines, or GOMAXPROCS goroutines if n is zero or less.
+//
+// Fields are independent if they do not refer to each other, directly or
+// indirectly. Values with top-level embeddings, comprehensions, pattern
+// constraints or dynamic fields are evaluated sequentially. A budget set
+// for the Context applies to each goroutine separately.
+//
+// Use BuildInstancesParallel to build several instances in parallel as well.
+func Parallel(n int) BuildOption {
+	return func(o *runtime.Config) {
+		if n <= 0 {
+			n = goruntime.GOMAXPROCS(0)
+		}
+		o.Parallel = n
+	}
+}
+
 func (c *Context) parseOptions(options []BuildOption) (cfg runtime.Config) {
 	cfg.Runtime = (*runtime.Runtime)(c)
 	for _, f := range options {
@@ -127,7 +156,7 @@ func (c *Context) BuildInstance(i *build.Instance, options ...BuildOption) Value
 	if err != nil {
 		return c.makeError(err)
 	}
-	return c.make(v)
+	return c.makeWith(&cfg, v)
 }
 
 func (c *Context) makeError(err errors.Error) Value {
@@ -155,20 +184,115 @@ func (c *Context) BuildInstances(instances []*build.Instance) ([]Value, error) {
 	return a, errs
 }
 
+// BuildInstancesParallel is like BuildInstances, but builds the instances
+// concurrently, using at most n goroutines, or GOMAXPROCS goroutines if n is
+// zero or less. Packages imported by the instances are built and evaluated
+// first, so that the instances importing them can be evaluated in parallel.
+// The options apply to each of the instances.
+func (c *Context) BuildInstancesParallel(instances []*build.Instance, n int, options ...BuildOption) ([]Value, error) {
+	if n <= 0 {
+		n = goruntime.GOMAXPROCS(0)
+	}
+	cfg := c.parseOptions(options)
+
+	done := map[*build.Instance]bool{}
+	for _, b := range instances {
+		c.buildImports(b, done)
+	}
+	imported := map[string]bool{}
+	for b := range done {
+		imported[b.ImportPath] = true
+	}
+
+	a := make([]Value, len(instances))
+	errs := make([]errors.Error, len(instances))
+	buildOne := func(i int) {
+		v, err := c.runtime().Build(&cfg, instances[i])
+		if err != nil {
+			errs[i] = err
+			a[i] = c.makeError(err)
+		} else {
+			a[i] = c.makeWith(&cfg, v)
+		}
+	}
+
+	first := map[*build.Instance]int{}
+	var parallel []int
+	for i, b := range instances {
+		if _, ok := first[b]; ok {
+			continue // Copied from the first occurrence below.
+		}
+		first[b] = i
+		if imported[b.ImportPath] {
+			// Building b registers it under its import path, after which
+			// other instances importing this path refer to it. So build it
+			// before any of those.
+			buildOne(i)
+		} else {
+			parallel = append(parallel, i)
+		}
+	}
+
+	// The instances share the vertices of the packages they import.
+	lock := c.runtime().CacheLock()
+	lock.Begin()
+	defer lock.End()
+
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for _, i := range parallel {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			buildOne(i)
+		}(i)
+	}
+	wg.Wait()
+
+	var err errors.Error
+	for i, b := range instances {
+		if j := first[b]; j != i {
+			a[i], errs[i] = a[j], errs[j]
+		}
+		err = errors.Append(err, errs[i])
+	}
+	return a, err
+}
+
+// buildImports builds and evaluates the transitive imports of b that are not
+// in done, dependencies first.
+func (c *Context) buildImports(b *build.Instance, done map[*build.Instance]bool) {
+	for _, d := range b.Imports {
+		if done[d] {
+			continue
+		}
+		done[d] = true
+		c.buildImports(d, done)
+		if v, err := c.runtime().Build(nil, d); err == nil {
+			c.make(v)
+		}
+	}
+}
+
 // BuildFile creates a Value from f.
 //
 // The returned Value will represent an error, accessible through Err, if any
 // error occurred.
 func (c *Context) BuildFile(f *ast.File, options ...BuildOption) Value {
 	cfg := c.parseOptions(options)
-	return c.compile(c.runtime().CompileFile(&cfg, f))
+	v, p := c.runtime().CompileFile(&cfg, f)
+	return c.compile(&cfg, v, p)
 }
 
-func (c *Context) compile(v *adt.Vertex, p *build.Instance) Value {
+func (c *Context) compile(cfg *runtime.Config, v *adt.Vertex, p *build.Instance) Value {
 	if p.Err != nil {
 		return c.makeError(p.Err)
 	}
-	return c.make(v)
+	return c.makeWith(cfg, v)
 }
 
 // BuildExpr creates a Value from x.
@@ -223,7 +347,8 @@ const anonymousPkg = "_"
 // error occurred.
 func (c *Context) CompileString(src string, options ...BuildOption) Value {
 	cfg := c.parseOptions(options)
-	return c.compile(c.runtime().Compile(&cfg, src))
+	v, p := c.runtime().Compile(&cfg, src)
+	return c.compile(&cfg, v, p)
 }
 
 // CompileBytes parses and build a Value from the given source bytes.
@@ -232,7 +357,8 @@ func (c *Context) CompileString(src string, options ...BuildOption) Value {
 // error occurred.
 func (c *Context) CompileBytes(b []byte, options ...BuildOption) Value {
 	cfg := c.parseOptions(options)
-	return c.compile(c.runtime().Compile(&cfg, b))
+	v, p := c.runtime().Compile(&cfg, b)
+	return c.compile(&cfg, v, p)
 }
 
 // TODO: fs.FS or custom wrapper?
@@ -255,6 +381,16 @@ func (c *Context) make(v *adt.Vertex) Value {
 	return x
 }
 
+// makeWith is like make, but evaluates v as configured by cfg.
+func (c *Context) makeWith(cfg *runtime.Config, v *adt.Vertex) Value {
+	if cfg.Parallel > 1 {
+		opCtx := newContext(c.runtime())
+		v.FinalizeParallel(opCtx, cfg.Parallel)
+		adt.AddStats(opCtx)
+	}
+	return c.make(v)
+}
+
 // An EncodeOption defines options for the various encoding-related methods of
 // Context.
 type EncodeOption func(*encodeOptions)
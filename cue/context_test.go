@@ -16,6 +16,8 @@ package cue_test
 
 import (
 	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"cuelang.org/go/cue"
@@ -138,9 +140,288 @@ bar: [
 	}
 }
 
+func TestBuildInstancesParallel(t *testing.T) {
+	in := `
+-- cue.mod/module.cue --
+module: "mod.test"
+-- a/a.cue --
+package a
+
+import "mod.test/shared"
+
+x: shared.#T & {n: 1}
+-- b/b.cue --
+package b
+
+import "mod.test/shared"
+
+x: shared.#T & {n: 2}
+-- c/c.cue --
+package c
+
+import "mod.test/a"
+
+x: a.x.m + 1
+-- bad/bad.cue --
+package bad
+
+x: y
+-- shared/shared.cue --
+package shared
+
+#T: {
+	n: int
+	m: n * 2
+	l: [for i in [1, 2, 3] {i + n}]
+}
+`
+	a := txtar.Parse([]byte(in))
+	insts := cuetxtar.Load(a, t.TempDir(), "./a", "./b", "./c", "./a", "./bad")
+	vs, err := cuecontext.New().BuildInstancesParallel(insts, 2)
+	qt.Assert(t, qt.ErrorMatches(err, `reference "y" not found`))
+
+	paths := []string{"x.m", "x.m", "x", "x.m", ""}
+	var got []string
+	for i, v := range vs {
+		got = append(got, fmt.Sprint(v.LookupPath(cue.ParsePath(paths[i]))))
+	}
+	qt.Assert(t, qt.DeepEquals(got, []string{
+		"2",
+		"4",
+		"3",
+		"2",
+		`_|_ // reference "y" not found`,
+	}))
+}
+
+// TestParallel checks that evaluating top-level fields in parallel gives the
+// same results as evaluating them sequentially. It is most useful when run
+// with the race detector.
+func TestParallel(t *testing.T) {
+	in := `
+-- cue.mod/module.cue --
+module: "mod.test"
+-- shared/shared.cue --
+package shared
+
+#T: {
+	n:    int
+	kind: "a" | "b" | *"c"
+	m:    n * 2
+	if n > 2 {
+		big: {at: n}
+	}
+	labels: [string]: string
+}
+-- a/a.cue --
+package a
+
+import (
+	"strings"
+	"mod.test/shared"
+)
+
+let prefix = "svc"
+
+#Local: {name: string, id: "\(prefix)-\(name)"}
+
+s1: shared.#T & {n: 1, labels: app: "one"}
+s2: shared.#T & {n: 3, kind: "a"}
+s3: shared.#T & {n: s2.n + 1}
+s4: #Local & {name: strings.ToUpper("four")}
+s5: {x: [for i in [1, 2, 3] {i * 10}], y: len(x)}
+s6: X={a: 1, b: X.a + 1}
+-- b/b.cue --
+package b
+
+s1: {a: 1}
+s2: s1.a + 1
+{c: 3}
+`
+	a := txtar.Parse([]byte(in))
+	for _, dir := range []string{"./a", "./b"} {
+		t.Run(dir, func(t *testing.T) {
+			build := func(opts ...cue.BuildOption) string {
+				insts := cuetxtar.Load(a, t.TempDir(), dir)
+				v := cuecontext.New().BuildInstance(insts[0], opts...)
+				qt.Assert(t, qt.IsNil(v.Validate()))
+				return fmt.Sprint(v)
+			}
+			want := build()
+			qt.Assert(t, qt.Equals(build(cue.Parallel(4)), want))
+		})
+	}
+
+	vs, err := cuecontext.New().BuildInstancesParallel(
+		cuetxtar.Load(a, t.TempDir(), "./a", "./b"), 2, cue.Parallel(2))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.Equals(fmt.Sprint(vs[0].LookupPath(cue.ParsePath("s3.big.at"))), "4"))
+	qt.Assert(t, qt.Equals(fmt.Sprint(vs[1].LookupPath(cue.ParsePath("s2"))), "2"))
+
+	v := cuecontext.New().CompileString("a: 1, b: a + 1, c: {d: 3}", cue.Parallel(2))
+	qt.Assert(t, qt.Equals(fmt.Sprint(v), "{\n\ta: 1\n\tb: 2\n\tc: {\n\t\td: 3\n\t}\n}"))
+}
+
+// TestParallelSharedImport checks that concurrent parallel evaluations of
+// instances importing the same package can share its environments. It is
+// most useful when run with the race detector.
+func TestParallelSharedImport(t *testing.T) {
+	in := `
+-- cue.mod/module.cue --
+module: "mod.test"
+-- shared/shared.cue --
+package shared
+
+#T: {
+	n: int
+	m: n * 2
+	l: [for i in [1, 2, 3] {i + n}]
+	s: "\(n)-\(m)"
+	[=~"^x"]: int
+	let k = "k\(n)"
+	(k): n
+}
+#S: [=~"^l"]: string
+labels: #S
+-- a/a.cue --
+package a
+
+import "mod.test/shared"
+
+x1: shared.#T & {n: 1}
+x2: shared.#T & {n: 2}
+x3: shared.#T & {n: 3, xa: 1}
+l: shared.labels & {la: "a", lb: "b"}
+-- b/b.cue --
+package b
+
+import "mod.test/shared"
+
+y1: shared.#T & {n: 1}
+y2: shared.#T & {n: 2}
+y3: shared.#T & {n: 3, xb: 1}
+l: shared.labels & {la: "a", lc: "c"}
+`
+	a := txtar.Parse([]byte(in))
+	for i := 0; i < 10; i++ {
+		vs, err := cuecontext.New().BuildInstancesParallel(
+			cuetxtar.Load(a, t.TempDir(), "./a", "./b"), 2, cue.Parallel(2))
+		qt.Assert(t, qt.IsNil(err))
+		qt.Assert(t, qt.Equals(fmt.Sprint(vs[0].LookupPath(cue.ParsePath("x3.s"))), `"3-6"`))
+		qt.Assert(t, qt.Equals(fmt.Sprint(vs[1].LookupPath(cue.ParsePath("y2.l[2]"))), "5"))
+	}
+}
+
+// TestConcurrentUse checks that values created from the same Context can be
+// used concurrently. It is most useful when run with the race detector.
+func TestConcurrentUse(t *testing.T) {
+	ctx := cuecontext.New()
+	schema := ctx.CompileString(`
+	import "strings"
+
+	#Request: {
+		name: string & strings.MinRunes(1)
+		port: int & >0 & <65536 | *8080
+		kind: "a" | "b" | *"c"
+		meta: [string]: string
+		out: {n: strings.ToUpper(name), p: port + 1}
+	}
+	`).LookupPath(cue.ParsePath("#Request"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				data := ctx.CompileString(fmt.Sprintf(`{name: "r%d", meta: k: "v"}`, j))
+				v := schema.Unify(data)
+				if err := v.Validate(cue.Concrete(true)); err != nil {
+					t.Error(err)
+				}
+				var out struct {
+					Out struct{ N string }
+				}
+				if err := v.Decode(&out); err != nil {
+					t.Error(err)
+				}
+				if want := fmt.Sprintf("R%d", j); out.Out.N != want {
+					t.Errorf("got %q; want %q", out.Out.N, want)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
 func TestContextCheck(t *testing.T) {
 	qt.Assert(t, qt.PanicMatches(func() {
 		var c cue.Context
 		c.CompileString("1")
 	}, `.*use cuecontext\.New.*`))
 }
+
+func TestMarshalInstances(t *testing.T) {
+	in := `
+-- cue.mod/module.cue --
+module: "mod.test"
+-- schema/schema.cue --
+package schema
+
+#Service: {
+	name: string @go(Name)
+	port: *80 | int
+}
+-- app/app.cue --
+package app
+
+import (
+	"strings"
+	"mod.test/schema"
+)
+
+web: schema.#Service & {name: strings.ToUpper("web")}
+-- db/db.cue --
+package db
+
+import "mod.test/schema"
+
+db: schema.#Service & {name: "db", port: 5432}
+-- db/port.cue --
+package db
+
+db: port: int
+`
+	a := txtar.Parse([]byte(in))
+	insts := cuetxtar.Load(a, t.TempDir(), "./app", "./db", "./schema")
+	ctx := cuecontext.New()
+	want, err := ctx.BuildInstances(insts)
+	qt.Assert(t, qt.IsNil(err))
+
+	b, err := ctx.MarshalInstances(insts)
+	qt.Assert(t, qt.IsNil(err))
+	got, err := cuecontext.New().UnmarshalInstances(b)
+	qt.Assert(t, qt.IsNil(err))
+
+	qt.Assert(t, qt.HasLen(got, len(want)))
+	for i := range got {
+		qt.Check(t, qt.Equals(fmt.Sprint(got[i]), fmt.Sprint(want[i])))
+	}
+
+	name := got[0].LookupPath(cue.ParsePath("web.name"))
+	attr := name.Attribute("go")
+	qt.Assert(t, qt.IsNil(attr.Err()))
+	qt.Assert(t, qt.Equals(attr.Contents(), "Name"))
+
+	var positions []string
+	for _, o := range got[1].LookupPath(cue.ParsePath("db.port")).Provenance() {
+		positions = append(positions, fmt.Sprintf("%s:%d:%d",
+			filepath.Base(o.Pos.Filename()), o.Pos.Line(), o.Pos.Column()))
+	}
+	qt.Assert(t, qt.DeepEquals(positions, []string{
+		"schema.cue:5:8", "db.cue:5:42", "port.cue:3:11",
+	}))
+
+	_, err = cuecontext.New().UnmarshalInstances(b[:len(b)/2])
+	qt.Assert(t, qt.ErrorMatches(err, "unmarshal failed: .*"))
+}
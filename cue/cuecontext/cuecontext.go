@@ -15,7 +15,10 @@
 package cuecontext
 
 import (
+	"time"
+
 	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/runtime"
 
 	_ "cuelang.org/go/pkg"
@@ -46,3 +49,106 @@ func Interpreter(i ExternInterpreter) Option {
 		r.SetInterpreter(i)
 	}}
 }
+
+// ErrBudgetExceeded is the cause of errors resulting from evaluation that
+// exceeds the budget set with WithBudget. Use errors.Is to check for it.
+var ErrBudgetExceeded = adt.ErrBudgetExceeded
+
+// WithBudget limits the work done by each evaluation within a context, so that
+// untrusted configurations cannot make evaluation run indefinitely. An
+// evaluation that exceeds maxSteps evaluation steps or takes longer than
+// maxDuration results in an error wrapping ErrBudgetExceeded. A zero value
+// means there is no limit for the respective measure.
+//
+// A budget applies to each operation on a value, such as a call to
+// Value.Validate, separately.
+func WithBudget(maxSteps int64, maxDuration time.Duration) Option {
+	return Option{func(r *runtime.Runtime) {
+		b := budget(r)
+		b.MaxSteps = maxSteps
+		b.MaxDuration = maxDuration
+		r.Hooks().Budget = b
+	}}
+}
+
+// WithDeadline makes all evaluation within a context fail with an error
+// wrapping ErrBudgetExceeded once the deadline has passed. Unlike the duration
+// passed to WithBudget, the deadline applies to all operations together, which
+// allows bounding the total time spent on evaluating a configuration.
+func WithDeadline(deadline time.Time) Option {
+	return Option{func(r *runtime.Runtime) {
+		b := budget(r)
+		b.Deadline = deadline
+		r.Hooks().Budget = b
+	}}
+}
+
+// ErrLimitExceeded is the cause of errors resulting from evaluation that
+// exceeds the limits set with WithLimits. Use errors.Is to check for it.
+var ErrLimitExceeded = adt.ErrLimitExceeded
+
+// Limits bound the resources used by each evaluation within a context. A zero
+// value for a field means there is no limit for the respective measure.
+type Limits = adt.Limits
+
+// A LimitError describes which limit was exceeded and at which path. Use
+// errors.As to obtain it from an error resulting from evaluation.
+type LimitError = adt.LimitError
+
+// A LimitKind identifies one of the fields of Limits.
+type LimitKind = adt.LimitKind
+
+const (
+	DepthLimit     = adt.DepthLimit
+	IterationLimit = adt.IterationLimit
+	SizeLimit      = adt.SizeLimit
+)
+
+// WithLimits bounds the nesting depth, the number of comprehension iterations
+// and the size of computed strings and bytes of each evaluation within a
+// context, so that untrusted configurations cannot exhaust the stack or
+// memory. An evaluation that exceeds a limit results in an error wrapping a
+// *LimitError, rather than a crash.
+func WithLimits(l Limits) Option {
+	return Option{func(r *runtime.Runtime) {
+		r.Hooks().Limits = &l
+	}}
+}
+
+// A Tracer receives structured events during evaluation, such as the start
+// and end of the evaluation of a value, the conjuncts added to it and the
+// disjuncts selected or discarded, which helps to find out why a value
+// evaluates to an error. Events may be reported concurrently.
+type Tracer = adt.Tracer
+
+// A TraceEvent describes a single step in the evaluation of a value.
+type TraceEvent = adt.TraceEvent
+
+// A TraceKind identifies the kind of a TraceEvent.
+type TraceKind = adt.TraceKind
+
+const (
+	VertexStart       = adt.VertexStart
+	VertexDone        = adt.VertexDone
+	ConjunctAdded     = adt.ConjunctAdded
+	DisjunctSelected  = adt.DisjunctSelected
+	DisjunctDiscarded = adt.DisjunctDiscarded
+	CycleDetected     = adt.CycleDetected
+)
+
+// WithTracer reports the events of all evaluation within a context to t.
+func WithTracer(t Tracer) Option {
+	return Option{func(r *runtime.Runtime) {
+		r.Hooks().Tracer = t
+	}}
+}
+
+// budget returns a copy of the budget of r, so that options setting different
+// limits can be combined.
+func budget(r *runtime.Runtime) *adt.Budget {
+	b := &adt.Budget{}
+	if old := r.Hooks().Budget; old != nil {
+		*b = *old
+	}
+	return b
+}
@@ -16,10 +16,13 @@ package cuecontext
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
 )
 
 func TestAPI(t *testing.T) {
@@ -72,3 +75,229 @@ func TestConcurrency(t *testing.T) {
 		`)
 	}()
 }
+
+func TestBudget(t *testing.T) {
+	testCases := []struct {
+		name     string
+		in       string
+		steps    int64
+		duration time.Duration
+		exceeded bool
+	}{{
+		name:  "withinBudget",
+		in:    `a: b: c: 1, d: [1, 2, 3]`,
+		steps: 1000,
+	}, {
+		name: "comprehension",
+		in: `
+		l: [0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19]
+		a: [for x in l for y in l for z in l for w in l { x + y + z + w }]
+		`,
+		steps:    1000,
+		exceeded: true,
+	}, {
+		name: "disjunctions",
+		in: `
+		#A: 1 | 2 | 3 | 4 | 5 | 6 | 7 | 8
+		a: [#A, #A, #A, #A, #A, #A, #A, #A]
+		b: a[0] & a[1] & a[2] & a[3] & a[4] & a[5] & a[6] & a[7]
+		c: {x: #A, y: #A, z: #A} & ({x: 1} | {y: 2} | {z: 3} | {x: 4} | {y: 5})
+		`,
+		steps:    20,
+		exceeded: true,
+	}, {
+		name: "duration",
+		in: `
+		l: [0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19]
+		a: [for x in l for y in l for z in l for w in l { x + y + z + w }]
+		`,
+		duration: time.Nanosecond,
+		exceeded: true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := New(WithBudget(tc.steps, tc.duration))
+			v := ctx.CompileString(tc.in)
+			err := v.Err()
+			if err == nil {
+				err = v.Validate()
+			}
+			if got := errors.Is(err, ErrBudgetExceeded); got != tc.exceeded {
+				t.Errorf("budget exceeded: got %v (%v); want %v", got, err, tc.exceeded)
+			}
+		})
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	ctx := New(WithDeadline(time.Now().Add(-time.Second)))
+	v := ctx.CompileString(`a: b: [for x in [1, 2, 3] { x + 1 }]`)
+	err := v.Validate()
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("got %v; want error wrapping ErrBudgetExceeded", err)
+	}
+	if got, want := err.Error(), "deadline exceeded"; !strings.Contains(got, want) {
+		t.Errorf("got %q; want it to contain %q", got, want)
+	}
+}
+
+func TestBudgetPath(t *testing.T) {
+	ctx := New(WithBudget(3, 0))
+	v := ctx.CompileString(`a: b: c: d: e: 1`)
+	err := v.Validate()
+	const want = "more than 3 steps while evaluating a"
+	if got := fmt.Sprint(err); !strings.Contains(got, want) {
+		t.Errorf("got %q; want it to contain %q", got, want)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	testCases := []struct {
+		name   string
+		in     string
+		limits Limits
+		kind   LimitKind // zero if no limit is exceeded
+		path   string
+	}{{
+		name:   "withinLimits",
+		in:     `a: b: c: "x" * 10, d: [for x in [1, 2, 3] {x}]`,
+		limits: Limits{MaxDepth: 10, MaxIterations: 10, MaxSize: 100},
+	}, {
+		name: "depth",
+		in: `
+		#F: {n: int, out: {next: (#F & {n: n + 1}).out}}
+		a: (#F & {n: 0}).out
+		`,
+		limits: Limits{MaxDepth: 50},
+		kind:   DepthLimit,
+	}, {
+		name:   "nesting",
+		in:     `a: b: c: d: e: f: 1`,
+		limits: Limits{MaxDepth: 4},
+		kind:   DepthLimit,
+		path:   "a.b.c.d",
+	}, {
+		name: "iterations",
+		in: `
+		l: [0, 1, 2, 3, 4, 5, 6, 7, 8, 9]
+		a: [for x in l for y in l for z in l { x + y + z }]
+		`,
+		limits: Limits{MaxIterations: 100},
+		kind:   IterationLimit,
+		path:   "a",
+	}, {
+		name:   "listMultiplication",
+		in:     `a: [1] * 1000000000`,
+		limits: Limits{MaxIterations: 100},
+		kind:   IterationLimit,
+	}, {
+		name:   "stringMultiplication",
+		in:     `a: b: "abc" * 1000000000000`,
+		limits: Limits{MaxSize: 1000},
+		kind:   SizeLimit,
+		path:   "a.b",
+	}, {
+		name: "concatenation",
+		in: `
+		s: "x" * 600
+		a: s + s
+		`,
+		limits: Limits{MaxSize: 1000},
+		kind:   SizeLimit,
+		path:   "a",
+	}, {
+		name:   "interpolation",
+		in:     `s: "x" * 600, a: "\(s)\(s)"`,
+		limits: Limits{MaxSize: 1000},
+		kind:   SizeLimit,
+		path:   "a",
+	}, {
+		name: "builtin",
+		in: `
+		import "strings"
+		a: strings.Repeat("x", 2000)
+		`,
+		limits: Limits{MaxSize: 1000},
+		kind:   SizeLimit,
+		path:   "a",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := New(WithLimits(tc.limits))
+			v := ctx.CompileString(tc.in)
+			err := v.Err()
+			if err == nil {
+				err = v.Validate()
+			}
+			var lerr *LimitError
+			if !errors.As(err, &lerr) {
+				if tc.kind != 0 {
+					t.Fatalf("got %v; want error wrapping a *LimitError", err)
+				}
+				return
+			}
+			if tc.kind == 0 {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("got %v; want error wrapping ErrLimitExceeded", err)
+			}
+			if lerr.Kind != tc.kind {
+				t.Errorf("got kind %v; want %v", lerr.Kind, tc.kind)
+			}
+			if tc.path != "" && lerr.Path != tc.path {
+				t.Errorf("got path %q; want %q", lerr.Path, tc.path)
+			}
+		})
+	}
+}
+
+type eventRecorder []TraceEvent
+
+func (r *eventRecorder) Trace(e TraceEvent) { *r = append(*r, e) }
+
+func TestTracer(t *testing.T) {
+	var events eventRecorder
+	ctx := New(WithTracer(&events))
+	v := ctx.CompileString(`
+	#A: {kind: "a", v: int} | {kind: "b", w: string}
+	x: #A & {kind: "b", w: "s"}
+	s: {a: s}
+	`)
+	v.Validate()
+
+	has := func(kind TraceKind, path, msg string) bool {
+		for _, e := range events {
+			if e.Kind != kind || e.Path != path {
+				continue
+			}
+			if msg == "" || e.Err != nil && strings.Contains(e.Err.Error(), msg) {
+				return true
+			}
+		}
+		return false
+	}
+	testCases := []struct {
+		kind TraceKind
+		path string
+		msg  string
+	}{
+		{VertexStart, "x", ""},
+		{ConjunctAdded, "x.kind", ""},
+		{DisjunctDiscarded, "x", "conflicting values"},
+		{DisjunctSelected, "x", ""},
+		{VertexDone, "x", ""},
+		{CycleDetected, "s.a", ""},
+		{VertexDone, "s.a", "structural cycle"},
+	}
+	for _, tc := range testCases {
+		if !has(tc.kind, tc.path, tc.msg) {
+			t.Errorf("no %v event for %s with error %q", tc.kind, tc.path, tc.msg)
+		}
+	}
+	for _, e := range events {
+		if e.Kind == VertexStart && e.Path == "x" && !e.Pos.IsValid() {
+			t.Errorf("start event for x has no position")
+		}
+	}
+}
@@ -34,8 +34,16 @@ import (
 // An error is returned if x is nil or not a pointer.
 //
 // If x is a struct, Decode will validate the constraints specified in the field tags.
-func (v Value) Decode(x interface{}) error {
-	var d decoder
+//
+// Values of types implementing [Unmarshaler] are decoded by calling their
+// UnmarshalCUE method. Otherwise, types implementing [json.Unmarshaler]
+// are passed the JSON encoding of the value and types implementing
+// [encoding.TextUnmarshaler] are passed the string or bytes value.
+// The [DecodeHook] option can be used to customize the decoding of other
+// types, such as [time.Duration].
+func (v Value) Decode(x interface{}, opts ...Option) error {
+	o := getOptions(opts)
+	d := decoder{hooks: o.decodeHooks}
 	w := reflect.ValueOf(x)
 	if w.Kind() != reflect.Pointer || w.IsNil() {
 		d.addErr(errors.Newf(v.Pos(), "cannot decode into unsettable value"))
@@ -45,8 +53,71 @@ func (v Value) Decode(x interface{}) error {
 	return d.errs
 }
 
+// Unmarshaler is the interface implemented by types that can decode a CUE
+// value into themselves.
+type Unmarshaler interface {
+	UnmarshalCUE(v Value) error
+}
+
+// A DecodeHookFunc is called by [Value.Decode] before it decodes v into a Go
+// value of type t. If ok is true, Decode uses x, which must be nil or
+// assignable to t, instead of decoding v itself. A non-nil error is reported
+// as a decoding error.
+type DecodeHookFunc func(v Value, t reflect.Type) (x interface{}, ok bool, err error)
+
+// DecodeHook registers hooks to be called by [Value.Decode] for each value it
+// decodes, including nested values. Hooks are called in order until one
+// reports it has handled the value, and take precedence over [Unmarshaler]
+// and the standard library unmarshal interfaces.
+//
+// For instance, the following hook decodes strings like "1m30s" into a
+// [time.Duration]:
+//
+//	durationType := reflect.TypeOf(time.Duration(0))
+//	hook := func(v cue.Value, t reflect.Type) (any, bool, error) {
+//		if t != durationType || v.Kind() != cue.StringKind {
+//			return nil, false, nil
+//		}
+//		s, _ := v.String()
+//		d, err := time.ParseDuration(s)
+//		return d, true, err
+//	}
+//	err := v.Decode(&x, cue.DecodeHook(hook))
+func DecodeHook(hooks ...DecodeHookFunc) Option {
+	return func(o *options) { o.decodeHooks = append(o.decodeHooks, hooks...) }
+}
+
 type decoder struct {
-	errs errors.Error
+	errs  errors.Error
+	hooks []DecodeHookFunc
+}
+
+// callHooks reports whether any of the decode hooks handled v.
+func (d *decoder) callHooks(x reflect.Value, v Value) bool {
+	for _, h := range d.hooks {
+		r, ok, err := h(v, x.Type())
+		if err != nil {
+			d.addErr(errors.Wrapf(err, v.Pos(), "Decode"))
+			return true
+		}
+		if !ok {
+			continue
+		}
+		if r == nil {
+			d.clear(x)
+			return true
+		}
+		rv := reflect.ValueOf(r)
+		if !rv.Type().AssignableTo(x.Type()) {
+			d.addErr(errors.Newf(v.Pos(),
+				"decode hook returned value of type %v, which is not assignable to %v",
+				rv.Type(), x.Type()))
+			return true
+		}
+		x.Set(rv)
+		return true
+	}
+	return false
 }
 
 func (d *decoder) addErr(err error) {
@@ -88,6 +159,10 @@ func (d *decoder) decode(x reflect.Value, v Value, isPtr bool) {
 		return
 	}
 
+	if d.hooks != nil && d.callHooks(x, v) {
+		return
+	}
+
 	switch x.Kind() {
 	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
 		// nullable types
@@ -104,7 +179,12 @@ func (d *decoder) decode(x reflect.Value, v Value, isPtr bool) {
 		}
 	}
 
-	ij, it, x := indirect(x, v.Null() == nil)
+	ic, ij, it, x := indirect(x, v.Null() == nil)
+
+	if ic != nil {
+		d.addErr(ic.UnmarshalCUE(v))
+		return
+	}
 
 	if ij != nil {
 		b, err := v.marshalJSON()
@@ -854,10 +934,11 @@ func simpleLetterEqualFold(s, t []byte) bool {
 
 // indirect walks down v allocating pointers as needed,
 // until it gets to a non-pointer.
-// If it encounters an Unmarshaler, indirect stops and returns that.
+// If it encounters an Unmarshaler, or a json or text unmarshaler,
+// indirect stops and returns that.
 // If decodingNull is true, indirect stops at the first settable pointer so it
 // can be set to nil.
-func indirect(v reflect.Value, decodingNull bool) (json.Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
+func indirect(v reflect.Value, decodingNull bool) (Unmarshaler, json.Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
 	// Issue #24153 indicates that it is generally not a guaranteed property
 	// that you may round-trip a reflect.Value by calling Value.Addr().Elem()
 	// and expect the value to still be settable for values derived from
@@ -910,12 +991,15 @@ func indirect(v reflect.Value, decodingNull bool) (json.Unmarshaler, encoding.Te
 			v.Set(reflect.New(v.Type().Elem()))
 		}
 		if v.Type().NumMethod() > 0 && v.CanInterface() {
+			if u, ok := v.Interface().(Unmarshaler); ok {
+				return u, nil, nil, reflect.Value{}
+			}
 			if u, ok := v.Interface().(json.Unmarshaler); ok {
-				return u, nil, reflect.Value{}
+				return nil, u, nil, reflect.Value{}
 			}
 			if !decodingNull {
 				if u, ok := v.Interface().(encoding.TextUnmarshaler); ok {
-					return nil, u, reflect.Value{}
+					return nil, nil, u, reflect.Value{}
 				}
 			}
 		}
@@ -927,5 +1011,5 @@ func indirect(v reflect.Value, decodingNull bool) (json.Unmarshaler, encoding.Te
 			v = v.Elem()
 		}
 	}
-	return nil, nil, v
+	return nil, nil, nil, v
 }
@@ -15,7 +15,10 @@
 package cue
 
 import (
+	"fmt"
+	"net/netip"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -269,3 +272,128 @@ func (d *Duration) UnmarshalText(data []byte) error {
 func (d *Duration) MarshalText() ([]byte, error) {
 	return []byte(d.D.String()), nil
 }
+
+type color int
+
+const (
+	red color = iota
+	green
+)
+
+// span decodes from a struct with fields lo and hi.
+type span struct {
+	lo, hi int
+}
+
+func (s *span) UnmarshalCUE(v Value) error {
+	lo, err := v.LookupPath(ParsePath("lo")).Int64()
+	if err != nil {
+		return err
+	}
+	hi, err := v.LookupPath(ParsePath("hi")).Int64()
+	if err != nil {
+		return err
+	}
+	s.lo, s.hi = int(lo), int(hi)
+	return nil
+}
+
+func TestDecodeHook(t *testing.T) {
+	durationType := reflect.TypeOf(time.Duration(0))
+	durationHook := func(v Value, t reflect.Type) (interface{}, bool, error) {
+		if t != durationType || v.Kind() != StringKind {
+			return nil, false, nil
+		}
+		s, _ := v.String()
+		d, err := time.ParseDuration(s)
+		return d, true, err
+	}
+	colorHook := func(v Value, t reflect.Type) (interface{}, bool, error) {
+		if t != reflect.TypeOf(color(0)) {
+			return nil, false, nil
+		}
+		switch s, _ := v.String(); s {
+		case "red":
+			return red, true, nil
+		case "green":
+			return green, true, nil
+		default:
+			return nil, true, fmt.Errorf("unknown color %q", s)
+		}
+	}
+	badHook := func(v Value, t reflect.Type) (interface{}, bool, error) {
+		return "foo", true, nil
+	}
+
+	type T struct {
+		Timeout   time.Duration   `json:"timeout"`
+		Retries   []time.Duration `json:"retries"`
+		Color     color           `json:"color"`
+		Addr      netip.Addr      `json:"addr"`
+		Span      span            `json:"span"`
+		SpanPtr   *span           `json:"spanPtr"`
+		Untouched int             `json:"untouched"`
+	}
+
+	testCases := []struct {
+		name  string
+		value string
+		hooks []DecodeHookFunc
+		want  T
+		err   string
+	}{{
+		name: "hooks",
+		value: `
+			timeout: "1m30s"
+			retries: ["1s", "2s"]
+			color: "green"
+			addr: "192.168.0.1"
+			span: {lo: 1, hi: 3}
+			spanPtr: {lo: 4, hi: 5}
+			untouched: 3
+		`,
+		hooks: []DecodeHookFunc{durationHook, colorHook},
+		want: T{
+			Timeout:   90 * time.Second,
+			Retries:   []time.Duration{time.Second, 2 * time.Second},
+			Color:     green,
+			Addr:      netip.MustParseAddr("192.168.0.1"),
+			Span:      span{1, 3},
+			SpanPtr:   &span{4, 5},
+			Untouched: 3,
+		},
+	}, {
+		name:  "integer durations are not handled by the hook",
+		value: `timeout: 1000`,
+		hooks: []DecodeHookFunc{durationHook},
+		want:  T{Timeout: 1000},
+	}, {
+		name:  "hook error",
+		value: `color: "blue"`,
+		hooks: []DecodeHookFunc{colorHook},
+		err:   `unknown color "blue"`,
+	}, {
+		name:  "not assignable",
+		value: `untouched: 1`,
+		hooks: []DecodeHookFunc{badHook},
+		err:   "decode hook returned value of type string, which is not assignable to cue.T",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got T
+			err := getInstance(t, tc.value).Value().Decode(&got, DecodeHook(tc.hooks...))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v; want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(span{}), cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A DiffKind indicates how a value differs between two values.
+type DiffKind int
+
+const (
+	// DiffAdded indicates a value only exists in the second value.
+	DiffAdded DiffKind = iota + 1
+
+	// DiffRemoved indicates a value only exists in the first value.
+	DiffRemoved
+
+	// DiffModified indicates a value exists in both values, but differs.
+	DiffModified
+
+	// DiffRenamed indicates a field was moved to a different label, as
+	// identified by its @id attribute. The field may differ in other ways
+	// as well.
+	DiffRenamed
+)
+
+var diffKindStrings = map[DiffKind]string{
+	DiffAdded:    "added",
+	DiffRemoved:  "removed",
+	DiffModified: "modified",
+	DiffRenamed:  "renamed",
+}
+
+func (k DiffKind) String() string {
+	if s, ok := diffKindStrings[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("DiffKind(%d)", int(k))
+}
+
+// A Diff describes how a value differs between two values.
+//
+// If both values are structs or lists, Children describes the differences
+// between their fields or elements, ordered as they appear in the first value
+// followed by those only appearing in the second value. Otherwise, Children
+// is empty.
+type Diff struct {
+	Kind DiffKind
+
+	// Path is the path of the value relative to the values being compared.
+	// Paths of values within a renamed field are relative to the first
+	// value.
+	Path Path
+
+	// To is the path of a renamed field in the second value.
+	To Path
+
+	// X and Y are the values being compared. X does not exist for an added
+	// value and Y does not exist for a removed value.
+	X, Y Value
+
+	Children []*Diff
+}
+
+// Diff reports the structural differences between v and w, or nil if there
+// are none.
+//
+// By default, regular fields, optional fields and definitions are compared and
+// disjunctions are compared as is, including their defaults. Options can be
+// used to change this:
+//
+//   - Optional(false) ignores optional fields,
+//   - Definitions(false) ignores definitions,
+//   - Hidden(true) includes hidden fields, and
+//   - Final() selects defaults before comparing and ignores optional fields
+//     and definitions.
+//
+// Values that are not structs or lists are compared with Equals.
+//
+// Fields, typically definitions, can be given a stable identity with an
+// @id attribute, as in
+//
+//	#Person: {name: string} @id("person")
+//
+// A field that only exists in v is paired with a field that only exists in w
+// that has the same @id within the same struct. Such a pair is reported as a
+// DiffRenamed, rather than a removal and an addition.
+func (v Value) Diff(w Value, opts ...Option) *Diff {
+	opts = append([]Option{Optional(true), Definitions(true)}, opts...)
+	d := differ{opts: opts, final: getOptions(opts).final}
+	return d.diff(nil, v, w)
+}
+
+type differ struct {
+	opts  []Option
+	final bool
+}
+
+func (d *differ) diff(path []Selector, x, y Value) *Diff {
+	if d.final {
+		x, _ = x.Default()
+		y, _ = y.Default()
+	}
+
+	var children []*Diff
+	switch xk, yk := x.IncompleteKind(), y.IncompleteKind(); {
+	case xk != yk:
+		return d.newDiff(DiffModified, path, x, y)
+
+	case xk == StructKind:
+		children = d.diffStruct(path, x, y)
+
+	case xk == ListKind && x.IsConcrete() && y.IsConcrete():
+		children = d.diffList(path, x, y)
+
+	default:
+		if x.Equals(y) {
+			return nil
+		}
+		return d.newDiff(DiffModified, path, x, y)
+	}
+
+	if len(children) == 0 {
+		return nil
+	}
+	m := d.newDiff(DiffModified, path, x, y)
+	m.Children = children
+	return m
+}
+
+type diffField struct {
+	sel Selector
+	v   Value
+}
+
+func (d *differ) fields(v Value) (fields []diffField, index map[Selector]int) {
+	index = map[Selector]int{}
+	iter, err := v.Fields(d.opts...)
+	if err != nil {
+		return nil, index
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		index[diffKey(sel)] = len(fields)
+		fields = append(fields, diffField{sel, iter.Value()})
+	}
+	return fields, index
+}
+
+// diffKey returns a key for sel that ignores whether a field is regular,
+// optional or required, so that changing this results in a modification
+// rather than a removal and addition.
+func diffKey(sel Selector) Selector {
+	return sel.Optional()
+}
+
+func (d *differ) diffStruct(path []Selector, x, y Value) (children []*Diff) {
+	xf, _ := d.fields(x)
+	yf, yIndex := d.fields(y)
+
+	seen := make([]bool, len(yf))
+	// Mark fields that exist in both values first, so that they are not
+	// considered to be the target of a rename.
+	for _, f := range xf {
+		if i, ok := yIndex[diffKey(f.sel)]; ok {
+			seen[i] = true
+		}
+	}
+	for _, f := range xf {
+		p := withSelector(path, f.sel)
+		i, ok := yIndex[diffKey(f.sel)]
+		if !ok {
+			if i, ok := renamed(f, yf, seen); ok {
+				seen[i] = true
+				r := d.newDiff(DiffRenamed, p, f.v, yf[i].v)
+				r.To = MakePath(withSelector(path, yf[i].sel)...)
+				if c := d.diff(p, f.v, yf[i].v); c != nil {
+					r.Children = c.Children
+					if len(c.Children) == 0 {
+						r.Children = []*Diff{c}
+					}
+				}
+				children = append(children, r)
+				continue
+			}
+			children = append(children, d.newDiff(DiffRemoved, p, f.v, Value{}))
+			continue
+		}
+		if yf[i].sel.ConstraintType() != f.sel.ConstraintType() {
+			children = append(children, d.newDiff(DiffModified, p, f.v, yf[i].v))
+			continue
+		}
+		if c := d.diff(p, f.v, yf[i].v); c != nil {
+			children = append(children, c)
+		}
+	}
+	for i, f := range yf {
+		if !seen[i] {
+			p := withSelector(path, f.sel)
+			children = append(children, d.newDiff(DiffAdded, p, Value{}, f.v))
+		}
+	}
+	return children
+}
+
+// renamed reports the index of the field in fields that has not been seen
+// and has the same @id attribute as f, if any.
+func renamed(f diffField, fields []diffField, seen []bool) (int, bool) {
+	id, ok := stableID(f.v)
+	if !ok {
+		return 0, false
+	}
+	for i, g := range fields {
+		if seen[i] {
+			continue
+		}
+		if gid, ok := stableID(g.v); ok && gid == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// stableID reports the argument of the @id attribute of v, if any.
+func stableID(v Value) (string, bool) {
+	for _, a := range v.Attributes(ValueAttr) {
+		if a.Name() != "id" {
+			continue
+		}
+		if id, err := a.String(0); err == nil && id != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+func (d *differ) diffList(path []Selector, x, y Value) (children []*Diff) {
+	xi, err := x.List()
+	if err != nil {
+		return []*Diff{d.newDiff(DiffModified, path, x, y)}
+	}
+	yi, err := y.List()
+	if err != nil {
+		return []*Diff{d.newDiff(DiffModified, path, x, y)}
+	}
+	for i := 0; ; i++ {
+		xok, yok := xi.Next(), yi.Next()
+		p := withSelector(path, Index(i))
+		switch {
+		case xok && yok:
+			if c := d.diff(p, xi.Value(), yi.Value()); c != nil {
+				children = append(children, c)
+			}
+		case xok:
+			children = append(children, d.newDiff(DiffRemoved, p, xi.Value(), Value{}))
+		case yok:
+			children = append(children, d.newDiff(DiffAdded, p, Value{}, yi.Value()))
+		default:
+			return children
+		}
+	}
+}
+
+func (d *differ) newDiff(k DiffKind, path []Selector, x, y Value) *Diff {
+	return &Diff{Kind: k, Path: MakePath(path...), X: x, Y: y}
+}
+
+func withSelector(path []Selector, sel Selector) []Selector {
+	p := make([]Selector, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, sel)
+}
+
+// String returns a line-based representation of the differences described by
+// d, listing only leaf values. Each line starts with "+" for an added value,
+// "-" for a removed value, or "~" for a modified value, followed by the path
+// and the value, or "old -> new" for modifications. A renamed field is listed
+// as a line starting with ">" followed by "old -> new" paths, followed by the
+// differences within the field, if any.
+func (d *Diff) String() string {
+	var b strings.Builder
+	d.write(&b)
+	return b.String()
+}
+
+func (d *Diff) write(b *strings.Builder) {
+	if d == nil {
+		return
+	}
+	if d.Kind == DiffRenamed {
+		fmt.Fprintf(b, "> %v -> %v\n", d.Path, d.To)
+	}
+	if len(d.Children) > 0 {
+		for _, c := range d.Children {
+			c.write(b)
+		}
+		return
+	}
+	switch d.Kind {
+	case DiffRenamed:
+	case DiffAdded:
+		fmt.Fprintf(b, "+ %v: %v\n", d.Path, d.Y)
+	case DiffRemoved:
+		fmt.Fprintf(b, "- %v: %v\n", d.Path, d.X)
+	default:
+		fmt.Fprintf(b, "~ %v: %v -> %v\n", d.Path, d.X, d.Y)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name string
+		x, y string
+		opts []cue.Option
+		out  string
+	}{{
+		name: "equal",
+		x:    `a: 1, b: [1, 2]`,
+		y:    `b: [1, 2], a: 1`,
+		out:  "",
+	}, {
+		name: "fields",
+		x:    `a: 1, b: 2, c: {d: 3}`,
+		y:    `a: 1, c: {d: 4}, e: 5`,
+		out: `- b: 2
+~ c.d: 3 -> 4
++ e: 5
+`,
+	}, {
+		name: "lists",
+		x:    `a: [1, 2, 3]`,
+		y:    `a: [1, 4]`,
+		out: `~ a[1]: 2 -> 4
+- a[2]: 3
+`,
+	}, {
+		name: "kinds",
+		x:    `a: {b: 1}`,
+		y:    `a: [1]`,
+		out: `~ a: {
+	b: 1
+} -> [1]
+`,
+	}, {
+		name: "schema",
+		x:    `#D: {a: int, b?: string}`,
+		y:    `#D: {a: >0, b: string}`,
+		out: `~ #D.a: int -> >0
+~ #D.b?: string -> string
+`,
+	}, {
+		name: "ignoreOptional",
+		x:    `a?: int, b: 1`,
+		y:    `a?: string, b: 1`,
+		opts: []cue.Option{cue.Optional(false)},
+		out:  "",
+	}, {
+		name: "renamed",
+		x: `
+			#Person: {name: string} @id("person")
+			#Pet: {name: string} @id("pet")
+			#Old: int @id("old")
+			`,
+		y: `
+			#Human: {name: string, age?: int} @id("person")
+			#Pet: {name: string} @id("pet")
+			#New: int @id("new")
+			`,
+		out: `> #Person -> #Human
++ #Person.age?: int
+- #Old: int
++ #New: int
+`,
+	}, {
+		name: "renamedUnchanged",
+		x:    `#A: {a: 1} @id("a")`,
+		y:    `#B: {a: 1} @id("a")`,
+		out:  "> #A -> #B\n",
+	}, {
+		name: "defaults",
+		x:    `a: *1 | int`,
+		y:    `a: 1`,
+		out:  "~ a: *1 | int -> 1\n",
+	}, {
+		name: "ignoreDefaults",
+		x:    `a: *1 | int`,
+		y:    `a: 1`,
+		opts: []cue.Option{cue.Final()},
+		out:  "",
+	}}
+	ctx := cuecontext.New()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			x := ctx.CompileString(tc.x)
+			y := ctx.CompileString(tc.y)
+			d := x.Diff(y, tc.opts...)
+			if got := d.String(); got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+			if (d == nil) != (tc.out == "") {
+				t.Errorf("got diff %v; want nil: %v", d, tc.out == "")
+			}
+		})
+	}
+}
+
+func TestDiffTree(t *testing.T) {
+	ctx := cuecontext.New()
+	x := ctx.CompileString(`a: b: 1`)
+	y := ctx.CompileString(`a: b: 2, c: 3`)
+
+	d := x.Diff(y)
+	if d.Kind != cue.DiffModified || len(d.Children) != 2 {
+		t.Fatalf("unexpected root diff %v with %d children", d.Kind, len(d.Children))
+	}
+	a := d.Children[0]
+	if got := a.Path.String(); got != "a" {
+		t.Errorf("got path %q; want a", got)
+	}
+	b := a.Children[0]
+	if got := b.Path.String(); got != "a.b" || b.Kind != cue.DiffModified {
+		t.Errorf("got %v at %q; want modified at a.b", b.Kind, got)
+	}
+	c := d.Children[1]
+	if c.Kind != cue.DiffAdded || c.X.Exists() || !c.Y.Exists() {
+		t.Errorf("got %v; want added value c", c.Kind)
+	}
+}
@@ -46,7 +46,12 @@ import (
 //	#    print as schema and include definitions.
 //	     The result is printed as a self-contained file, instead of an the
 //	     expression format.
-//	+    evaluate: resolve defaults and error on incomplete errors
+//	+    evaluate: resolve defaults and error on incomplete errors, but
+//	     include doc comments and optional fields
+//
+// So %v prints a concise representation of the value, %+v prints its
+// evaluated form annotated with documentation and optional fields, and
+// %#v prints it as CUE syntax that can be parsed back.
 //
 // Indentation can be controlled as follows:
 //
@@ -102,8 +107,7 @@ func (v Value) Format(state fmt.State, verb rune) {
 		if b, err := v.Bytes(); err == nil {
 			fmt.Fprintf(state, msg, b)
 		} else {
-			s := fmt.Sprintf("%+v", v)
-			fmt.Fprintf(state, msg, s)
+			fmt.Fprintf(state, msg, finalString(v))
 		}
 
 	case 'x', 'X':
@@ -153,6 +157,8 @@ func formatCUE(state fmt.State, v Value, showDocs, showAll bool) {
 
 	case state.Flag('+'):
 		p = *export.Final
+		p.ShowOptional = true
+		showDocs = true
 		fallthrough
 
 	default:
@@ -172,6 +178,14 @@ func formatCUE(state fmt.State, v Value, showDocs, showAll bool) {
 	formatExpr(state, n)
 }
 
+// finalString returns the evaluated form of v, without the doc comments
+// and optional fields that are included by %+v.
+func finalString(v Value) string {
+	n, _ := export.Final.Value(v.idx, v.instance().ID(), v.v)
+	b, _ := format.Node(n)
+	return string(bytes.Trim(b, "\n\r"))
+}
+
 func formatExpr(state fmt.State, n ast.Node) {
 	opts := make([]format.Option, 0, 3)
 	if state.Flag('-') {
@@ -37,9 +37,22 @@ import (
 type Option func(c *config)
 
 // Simplify allows the formatter to simplify output, such as removing
-// unnecessary quotes.
+// unnecessary quotes. It is equivalent to SimplifyLevel(2).
 func Simplify() Option {
-	return func(c *config) { c.simplify = true }
+	return SimplifyLevel(2)
+}
+
+// SimplifyLevel sets how much the formatter simplifies output:
+//
+//	0  no simplification
+//	1  remove unnecessary quotes from labels
+//	2  also collapse structs with a single field, such as a: {b: 1} to
+//	   a: b: 1, and move ellipses to the end of structs
+func SimplifyLevel(level int) Option {
+	return func(c *config) {
+		c.simplifyLabels = level >= 1
+		c.simplify = level >= 2
+	}
 }
 
 // UseSpaces specifies that tabs should be converted to spaces and sets the
@@ -63,12 +76,65 @@ func IndentPrefix(n int) Option {
 	return func(c *config) { c.Indent = n }
 }
 
-// TODO: make public
-// sortImportsOption causes import declarations to be sorted.
-func sortImportsOption() Option {
+// MaxBlankLines sets the maximum number of consecutive blank lines. The
+// formatter keeps at most one blank line between declarations, so values
+// larger than 1 have the same effect as 1, which is the default. A value of
+// 0 removes all blank lines.
+func MaxBlankLines(n int) Option {
+	return func(c *config) { c.noBlankLines = n <= 0 }
+}
+
+// TrailingComma specifies whether to write a comma after the last field of
+// structs written on a single line, as in {a: 1, b: 2,}.
+func TrailingComma(b bool) Option {
+	return func(c *config) { c.trailingComma = b }
+}
+
+// LineWidth sets the column width beyond which the formatter wraps
+// disjunctions and the arguments of calls, including those within
+// interpolations, by writing each disjunct or argument on its own line.
+// Tabs count as the tab width, which is set with UseSpaces. A width of 0,
+// the default, disables wrapping.
+func LineWidth(n int) Option {
+	return func(c *config) { c.lineWidth = n }
+}
+
+// SortImports sorts runs of consecutive imports of parenthesized import
+// declarations. Imports separated by a blank line or a doc comment are
+// sorted separately. Duplicate imports are removed when this does not lose
+// any information.
+func SortImports() Option {
 	return func(c *config) { c.sortImports = true }
 }
 
+// GroupImports is like SortImports, but sorts all imports of a
+// parenthesized import declaration together, grouping the imports of the
+// standard library before all other imports, separated by a blank line.
+func GroupImports() Option {
+	return func(c *config) {
+		c.sortImports = true
+		c.groupImports = true
+	}
+}
+
+// SortFields sorts the top-level fields of a file. The fields of nested
+// structs are not sorted. Fields named in order come first, in the given
+// order, followed by all other fields in alphabetical order. Only runs of
+// consecutive fields are sorted: other declarations, such as embeddings,
+// comprehensions and fields with dynamic labels, stay in place. Fields keep
+// their comments.
+func SortFields(order ...string) Option {
+	return func(c *config) {
+		c.sortFields = true
+		c.fieldOrder = make(map[string]int, len(order))
+		for i, name := range order {
+			if _, ok := c.fieldOrder[name]; !ok {
+				c.fieldOrder[name] = i
+			}
+		}
+	}
+}
+
 // TODO: other options:
 //
 // const (
@@ -122,8 +188,15 @@ type config struct {
 	Tabwidth  int // default: 4
 	Indent    int // default: 0 (all code is indented at least by this much)
 
-	simplify    bool
-	sortImports bool
+	simplify       bool
+	simplifyLabels bool
+	sortImports    bool
+	groupImports   bool
+	sortFields     bool
+	fieldOrder     map[string]int
+	noBlankLines   bool
+	trailingComma  bool
+	lineWidth      int
 }
 
 func newConfig(opt []Option) *config {
@@ -184,6 +257,14 @@ type formatter struct {
 	stack    []frame
 	current  frame
 	nestExpr int
+
+	// multiline reports whether the value of the last field printed
+	// spans multiple lines.
+	multiline bool
+
+	// wrapDisjuncts records whether to write the disjuncts of
+	// disjunctions on separate lines.
+	wrapDisjuncts map[*ast.BinaryExpr]bool
 }
 
 func newFormatter(p *printer) *formatter {
@@ -195,6 +276,7 @@ func newFormatter(p *printer) *formatter {
 				parentSep: newline,
 			},
 		},
+		wrapDisjuncts: map[*ast.BinaryExpr]bool{},
 	}
 	return f
 }
@@ -48,6 +48,8 @@ const (
 	idempotent
 	simplify
 	sortImps
+	groupImps
+	sortFlds
 )
 
 // format parses src, prints the corresponding AST, verifies the resulting
@@ -60,7 +62,13 @@ func format(src []byte, mode checkMode) ([]byte, error) {
 		opts = append(opts, Simplify())
 	}
 	if mode&sortImps != 0 {
-		opts = append(opts, sortImportsOption())
+		opts = append(opts, SortImports())
+	}
+	if mode&groupImps != 0 {
+		opts = append(opts, GroupImports())
+	}
+	if mode&sortFlds != 0 {
+		opts = append(opts, SortFields("name", "version"))
 	}
 
 	res, err := Source(src, opts...)
@@ -197,7 +205,9 @@ var data = []entry{
 	{"simplify.input", "simplify.golden", simplify},
 	{"expressions.input", "expressions.golden", 0},
 	{"values.input", "values.golden", 0},
-	{"imports.input", "imports.golden", sortImps},
+	{"imports.input", "imports.golden", 0},
+	{"imports.input", "imports_sorted.golden", sortImps},
+	{"sort.input", "sort.golden", groupImps | sortFlds},
 }
 
 func TestFiles(t *testing.T) {
@@ -469,6 +479,185 @@ func TestIncorrectIdent(t *testing.T) {
 	}
 }
 
+func TestOptions(t *testing.T) {
+	const src = `package p
+
+"a": {b: 1, c: 2}
+
+// doc
+x: {
+	z: {e: 1}
+
+	w: {
+		a: "s"
+	}
+}
+`
+	testCases := []struct {
+		name string
+		opts []Option
+		want string
+	}{{
+		name: "IndentWidth",
+		opts: []Option{UseSpaces(2), TabIndent(false)},
+		want: `package p
+
+"a": {b: 1, c: 2}
+
+// doc
+x: {
+  z: {e: 1}
+
+  w: {
+    a: "s"
+  }
+}
+`,
+	}, {
+		name: "SimplifyLevel1",
+		opts: []Option{SimplifyLevel(1)},
+		want: `package p
+
+a: {b: 1, c: 2}
+
+// doc
+x: {
+	z: {e: 1}
+
+	w: {
+		a: "s"
+	}
+}
+`,
+	}, {
+		name: "SimplifyLevel2",
+		opts: []Option{SimplifyLevel(2)},
+		want: `package p
+
+a: {b: 1, c: 2}
+
+// doc
+x: {
+	z: e: 1
+
+	w: a: "s"
+}
+`,
+	}, {
+		name: "NoBlankLines",
+		opts: []Option{MaxBlankLines(0)},
+		want: `package p
+"a": {b: 1, c: 2}
+// doc
+x: {
+	z: {e: 1}
+	w: {
+		a: "s"
+	}
+}
+`,
+	}, {
+		name: "TrailingComma",
+		opts: []Option{TrailingComma(true)},
+		want: `package p
+
+"a": {b: 1, c: 2,}
+
+// doc
+x: {
+	z: {e: 1,}
+
+	w: {
+		a: "s"
+	}
+}
+`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := Source([]byte(src), tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+			// The output is stable.
+			b, err = Source(b, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("reformatted:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLineWidth(t *testing.T) {
+	const src = `package p
+
+x:     strings.Join(["aaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbb", "cccccccccccccccccc"], ", ")
+#Kind: "Deployment" | "StatefulSet" | "DaemonSet" | "Job" | "CronJob" | "ReplicaSet"
+short: "a" | "b"
+s: {
+	a: ["\(strings.Join(["aaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbb"], ", ")) and more"]
+	c: f(g("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"), "c")
+}
+`
+	const want = `package p
+
+x: strings.Join(
+	["aaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbb", "cccccccccccccccccc"],
+	", ",
+)
+#Kind: "Deployment" |
+	"StatefulSet" |
+	"DaemonSet" |
+	"Job" |
+	"CronJob" |
+	"ReplicaSet"
+short: "a" | "b"
+s: {
+	a: ["\(strings.Join(
+		["aaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbb"],
+		", ",
+	)) and more"]
+	c: f(
+		g(
+			"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		),
+		"c",
+	)
+}
+`
+	b, err := Source([]byte(src), UseSpaces(4), LineWidth(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	// The output is stable.
+	b, err = Source(b, UseSpaces(4), LineWidth(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("reformatted:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Without a line width, nothing is wrapped.
+	b, err = Source([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != src {
+		t.Errorf("got:\n%s\nwant:\n%s", got, src)
+	}
+}
+
 // TextX is a skeleton test that can be filled in for debugging one-off cases.
 // Do not remove.
 func TestX(t *testing.T) {
@@ -17,35 +17,90 @@ package format
 import (
 	"sort"
 	"strconv"
+	"strings"
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/token"
 )
 
-// sortImports sorts runs of consecutive import lines in import blocks in f.
-// It also removes duplicate imports when it is possible to do so without data
-// loss.
-func sortImports(d *ast.ImportDecl) {
+// sortImports returns a copy of d with runs of consecutive import lines
+// sorted. It also removes duplicate imports when it is possible to do so
+// without data loss. It does not modify d.
+func sortImports(d *ast.ImportDecl) *ast.ImportDecl {
 	if !d.Lparen.IsValid() || len(d.Specs) == 0 {
 		// Not a block: sorted by default.
-		return
+		return d
 	}
 
+	c := *d
+	c.Specs = nil
+
 	// Identify and sort runs of specs on successive lines.
-	i := 0
-	specs := d.Specs[:0]
+	var run []*ast.ImportSpec
 	for j, s := range d.Specs {
-		if j > i && (s.Pos().RelPos() >= token.NewSection || hasDoc(s)) {
+		s = copySpec(s)
+		if j > 0 && (s.Pos().RelPos() >= token.NewSection || hasDoc(s)) {
 			setRelativePos(s, token.Newline)
 			// j begins a new run. End this one.
-			block := sortSpecs(d.Specs[i:j])
-			specs = append(specs, block...)
-			i = j
+			c.Specs = append(c.Specs, sortSpecs(run)...)
+			run = nil
+		}
+		run = append(run, s)
+	}
+	c.Specs = append(c.Specs, sortSpecs(run)...)
+	setRelativePos(c.Specs[0], token.Newline)
+	return &c
+}
+
+// groupImports returns a copy of d with its imports sorted. Imports of the
+// standard library come first, followed by a blank line and all other
+// imports. It also removes duplicate imports when it is possible to do so
+// without data loss. It does not modify d.
+func groupImports(d *ast.ImportDecl) *ast.ImportDecl {
+	if !d.Lparen.IsValid() || len(d.Specs) == 0 {
+		// Not a block: sorted by default.
+		return d
+	}
+
+	var std, other []*ast.ImportSpec
+	for _, s := range d.Specs {
+		s = copySpec(s)
+		setRelativePos(s, token.Newline)
+		if isStdlib(importPath(s)) {
+			std = append(std, s)
+		} else {
+			other = append(other, s)
 		}
 	}
-	specs = append(specs, sortSpecs(d.Specs[i:])...)
-	setRelativePos(specs[0], token.Newline)
-	d.Specs = specs
+
+	c := *d
+	c.Specs = nil
+	for _, group := range [][]*ast.ImportSpec{std, other} {
+		if len(group) > 0 {
+			c.Specs = append(c.Specs, sortSpecs(group)...)
+		}
+	}
+	setRelativePos(c.Specs[0], token.Newline)
+	return &c
+}
+
+// copySpec returns a copy of s that can be repositioned without modifying s.
+func copySpec(s *ast.ImportSpec) *ast.ImportSpec {
+	c := *s
+	if s.Name != nil {
+		name := *s.Name
+		c.Name = &name
+	}
+	path := *s.Path
+	c.Path = &path
+	return &c
+}
+
+// isStdlib reports whether path is the import path of a package of the
+// standard library, whose first path element never contains a dot.
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
 }
 
 func setRelativePos(s *ast.ImportSpec, r token.RelPos) {
@@ -60,8 +115,8 @@ func setRelativePos(s *ast.ImportSpec, r token.RelPos) {
 	}
 }
 
-func hasDoc(s *ast.ImportSpec) bool {
-	for _, doc := range s.Comments() {
+func hasDoc(n ast.Node) bool {
+	for _, doc := range n.Comments() {
 		if doc.Doc {
 			return true
 		}
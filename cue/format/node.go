@@ -15,8 +15,10 @@
 package format
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/literal"
@@ -33,24 +35,24 @@ func printNode(node interface{}, f *printer) error {
 	f.allowed = nooverride // gobble initial whitespace.
 	switch x := node.(type) {
 	case *ast.File:
-		if f.cfg.simplify {
+		if f.cfg.simplifyLabels {
 			ls.markReferences(x)
 		}
-		s.file(x)
+		s.file(f.sortFile(x))
 	case ast.Expr:
-		if f.cfg.simplify {
+		if f.cfg.simplifyLabels {
 			ls.markReferences(x)
 		}
 		s.expr(x)
 	case ast.Decl:
-		if f.cfg.simplify {
+		if f.cfg.simplifyLabels {
 			ls.markReferences(x)
 		}
 		s.decl(x)
 	// case ast.Node: // TODO: do we need this?
 	// 	s.walk(x)
 	case []ast.Decl:
-		if f.cfg.simplify {
+		if f.cfg.simplifyLabels {
 			ls.processDecls(x)
 		}
 		s.walkDeclList(x)
@@ -105,6 +107,7 @@ func (f *formatter) walkDeclList(list []ast.Decl) {
 	f.before(nil)
 	d := 0
 	hasEllipsis := false
+	multiline := false
 	for i, x := range list {
 		if i > 0 {
 			f.print(declcomma)
@@ -112,7 +115,9 @@ func (f *formatter) walkDeclList(list []ast.Decl) {
 			if f, ok := x.(*ast.Field); ok {
 				nd = nestDepth(f)
 			}
-			if f.current.parentSep == newline && (d == 0 || nd != d) {
+			// Do not align declarations following one that spans multiple
+			// lines, as its continuation lines would be aligned as well.
+			if f.current.parentSep == newline && (d == 0 || nd != d || multiline) {
 				f.print(f.formfeed())
 			}
 			if hasDocComments(x) {
@@ -125,13 +130,19 @@ func (f *formatter) walkDeclList(list []ast.Decl) {
 				default:
 					f.print(newsection)
 				}
+				if f.sections[x] {
+					// The declaration was moved by sorting.
+					f.print(newsection)
+				}
 			}
 		}
 		if f.printer.cfg.simplify && internal.IsEllipsis(x) {
 			hasEllipsis = true
 			continue
 		}
+		f.multiline = false
 		f.decl(x)
+		multiline = f.multiline
 		d = 0
 		if f, ok := x.(*ast.Field); ok {
 			d = nestDepth(f)
@@ -213,10 +224,13 @@ func (f *formatter) walkListElems(list []ast.Expr) {
 	f.after(nil)
 }
 
-func (f *formatter) walkArgsList(list []ast.Expr, depth int) {
+func (f *formatter) walkArgsList(list []ast.Expr, depth int, wrap bool) {
 	f.before(nil)
 	for _, x := range list {
 		f.before(x)
+		if wrap {
+			f.print(formfeed, nooverride)
+		}
 		f.exprRaw(x, token.LowestPrec, depth)
 		f.print(comma, blank)
 		f.after(x)
@@ -277,6 +291,8 @@ func (f *formatter) decl(decl ast.Decl) {
 	case *ast.Field:
 		constraint, _ := internal.ConstraintToken(n)
 		f.label(n.Label, constraint)
+		start := f.lineout
+		defer func() { f.multiline = f.lineout != start }()
 
 		regular := isRegularField(n.Token)
 		if regular {
@@ -606,9 +622,18 @@ func (f *formatter) exprRaw(expr ast.Expr, prec1, depth int) {
 		if len(x.Args) > 1 {
 			depth++
 		}
+		wrap := len(x.Args) > 0 && !f.fits(x)
 		wasIndented := f.possibleSelectorExpr(x.Fun, token.HighestPrec, depth)
 		f.print(x.Lparen, token.LPAREN)
-		f.walkArgsList(x.Args, depth)
+		if wrap {
+			// Write each argument on its own line.
+			f.print(indent)
+		}
+		f.walkArgsList(x.Args, depth, wrap)
+		if wrap {
+			f.matchUnindent()
+			f.print(newline, nooverride)
+		}
 		f.print(trailcomma, noblank, x.Rparen, token.RPAREN)
 		if wasIndented {
 			f.print(unindent)
@@ -637,6 +662,11 @@ func (f *formatter) exprRaw(expr ast.Expr, prec1, depth int) {
 		f.print(x.Lbrace, token.LBRACE, &l, ws, ff, indent)
 
 		f.walkDeclList(x.Elts)
+		if f.cfg.trailingComma && len(x.Elts) > 0 && f.lineout == l {
+			// Use the position of the closing brace to keep the comma on
+			// the same line.
+			f.print(noblank, x.Rbrace, token.COMMA)
+		}
 		f.matchUnindent()
 
 		ws = noblank
@@ -850,13 +880,30 @@ func (f *formatter) binaryExpr(x *ast.BinaryExpr, prec1, cutoff, depth int) {
 
 	printBlank := prec < cutoff
 
+	wrap := false
+	if x.Op == token.OR {
+		// Either all or none of the disjuncts of a chain of disjunctions
+		// are written on separate lines. The decision is made for the
+		// outermost disjunction and applies to the disjunctions on its left.
+		var ok bool
+		if wrap, ok = f.wrapDisjuncts[x]; !ok {
+			wrap = !f.fits(x)
+			for y := x; y != nil && y.Op == token.OR; y, _ = y.X.(*ast.BinaryExpr) {
+				f.wrapDisjuncts[y] = wrap
+			}
+		}
+	}
+
 	f.expr1(x.X, prec, depth+diffPrec(x.X, prec))
 	f.print(nooverride)
 	if printBlank {
 		f.print(blank)
 	}
 	f.print(x.OpPos, x.Op)
-	if x.Y.Pos().IsNewline() {
+	if wrap {
+		f.print(formfeed, nooverride)
+		printBlank = false
+	} else if x.Y.Pos().IsNewline() {
 		// at least one line break, but respect an extra empty line
 		// in the source
 		f.print(formfeed)
@@ -870,6 +917,23 @@ func (f *formatter) binaryExpr(x *ast.BinaryExpr, prec1, cutoff, depth int) {
 	f.expr1(x.Y, prec+1, depth+1)
 }
 
+// fits reports whether x fits within the configured line width when
+// written on a single line starting at the current column.
+func (f *formatter) fits(x ast.Expr) bool {
+	if f.cfg.lineWidth <= 0 {
+		return true
+	}
+	cfg := *f.cfg
+	cfg.lineWidth = 0
+	cfg.Indent = 0
+	b, err := cfg.fprint(x)
+	if err != nil {
+		return true
+	}
+	n := utf8.RuneCount(bytes.Join(bytes.Fields(b), []byte(" ")))
+	return f.column()+n <= f.cfg.lineWidth
+}
+
 func isBinary(expr ast.Expr) bool {
 	_, ok := expr.(*ast.BinaryExpr)
 	return ok
@@ -15,10 +15,12 @@
 package format
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/errors"
@@ -46,6 +48,11 @@ type printer struct {
 	spaceBefore bool
 
 	errs errors.Error
+
+	// sections holds the declarations moved by sorting that need to be
+	// printed as a new section to keep their doc comments apart from the
+	// preceding declaration.
+	sections map[ast.Decl]bool
 }
 
 type line int
@@ -116,6 +123,9 @@ func (p *printer) Print(v interface{}) {
 
 	case *ast.BasicLit:
 		data = x.Value
+		if x.Raw != nil {
+			data = literal.Bytes.WithTabIndent(1).Quote(string(x.Raw))
+		}
 		switch x.Kind {
 		case token.STRING:
 			// TODO: only do this when simplifying. Right now this does not
@@ -266,12 +276,12 @@ func (p *printer) writeWhitespace(ws whiteSpace) {
 		p.markUnindentLine()
 	}
 	switch {
-	case ws&newsection != 0:
+	case ws&newsection != 0 && !p.cfg.noBlankLines:
 		p.maybeIndentLine(ws)
 		p.writeByte('\f', 2)
 		p.lineout += 2
 		p.spaceBefore = true
-	case ws&formfeed != 0:
+	case ws&(newsection|formfeed) != 0:
 		p.maybeIndentLine(ws)
 		p.writeByte('\f', 1)
 		p.lineout++
@@ -382,6 +392,29 @@ func (p *printer) writeString(s string, isLit bool) {
 	}
 }
 
+// column returns the width of the current output line, counting tabs as the
+// tab width, including pending blanks.
+func (p *printer) column() int {
+	line := p.output
+	if i := bytes.LastIndexAny(line, "\n\f"); i >= 0 {
+		line = line[i+1:]
+	}
+	n := 0
+	for _, r := range string(line) {
+		switch r {
+		case utf8.RuneError: // tabwriter.Escape
+		case '\t':
+			n += p.cfg.Tabwidth
+		default:
+			n++
+		}
+	}
+	if p.allowed&(blank|vtab) != 0 {
+		n++
+	}
+	return n
+}
+
 func (p *printer) writeByte(ch byte, n int) {
 	for i := 0; i < n; i++ {
 		p.output = append(p.output, ch)
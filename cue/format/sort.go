@@ -0,0 +1,142 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"sort"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// sortFile returns a copy of f with its imports and top-level fields sorted
+// as configured. It does not modify f.
+func (p *printer) sortFile(f *ast.File) *ast.File {
+	if !p.cfg.sortImports && !p.cfg.sortFields {
+		return f
+	}
+	c := *f
+	c.Decls = append([]ast.Decl(nil), f.Decls...)
+	for i, d := range c.Decls {
+		x, ok := d.(*ast.ImportDecl)
+		switch {
+		case !ok:
+		case p.cfg.groupImports:
+			c.Decls[i] = groupImports(x)
+		case p.cfg.sortImports:
+			c.Decls[i] = sortImports(x)
+		}
+	}
+	if p.cfg.sortFields {
+		p.sortFields(c.Decls)
+	}
+	return &c
+}
+
+// sortFields sorts the runs of consecutive fields with sortable labels in
+// decls.
+func (p *printer) sortFields(decls []ast.Decl) {
+	start := 0
+	for i := 0; i <= len(decls); i++ {
+		if i < len(decls) {
+			if _, ok := fieldName(decls[i]); ok {
+				continue
+			}
+		}
+		if i-start > 1 {
+			p.sortRun(decls[start:i])
+		}
+		start = i + 1
+	}
+}
+
+func (p *printer) sortRun(run []ast.Decl) {
+	// Blank lines separating the fields of the run are dropped, as they
+	// would end up in arbitrary places.
+	rel := make([]token.RelPos, len(run))
+	for i, d := range run {
+		rel[i] = d.Pos().RelPos()
+		if i > 0 && rel[i] > token.Newline {
+			rel[i] = token.Newline
+		}
+	}
+
+	order := p.cfg.fieldOrder
+	sort.SliceStable(run, func(i, j int) bool {
+		a, _ := fieldName(run[i])
+		b, _ := fieldName(run[j])
+		ia, oka := order[a]
+		ib, okb := order[b]
+		switch {
+		case oka && okb:
+			return ia < ib
+		case oka || okb:
+			return oka
+		}
+		return a < b
+	})
+
+	for i, d := range run {
+		if hasDoc(d) {
+			// The position of a field with a doc comment is that of its
+			// comment, which is shared with the input. Instead, ensure the
+			// comment is not attached to the end of the preceding field.
+			if i > 0 {
+				if p.sections == nil {
+					p.sections = map[ast.Decl]bool{}
+				}
+				p.sections[d] = true
+			}
+			continue
+		}
+		run[i] = withRelPos(d.(*ast.Field), rel[i])
+	}
+}
+
+// withRelPos returns a copy of f with the relative position rel, or f itself
+// if it already has that position or its position cannot be changed without
+// modifying f.
+func withRelPos(f *ast.Field, rel token.RelPos) *ast.Field {
+	if f.Pos().RelPos() == rel {
+		return f
+	}
+	var label ast.Label
+	switch x := f.Label.(type) {
+	case *ast.Ident:
+		c := *x
+		c.NamePos = c.NamePos.WithRel(rel)
+		label = &c
+	case *ast.BasicLit:
+		c := *x
+		c.ValuePos = c.ValuePos.WithRel(rel)
+		label = &c
+	default:
+		return f
+	}
+	c := *f
+	c.Label = label
+	return &c
+}
+
+// fieldName returns the name of the label of d if d is a field that can be
+// sorted.
+func fieldName(d ast.Decl) (string, bool) {
+	f, ok := d.(*ast.Field)
+	if !ok {
+		return "", false
+	}
+	name, _, err := ast.LabelName(f.Label)
+	return name, err == nil
+}
@@ -224,6 +224,23 @@ x: *1 | int`,
 		b: "hello world"
 	}
 	x: 1
+}`,
+		),
+	}, {
+		desc: "docs and optional fields",
+		in: `
+		// a is a field.
+		a: *1 | int
+		b?: string
+		`,
+		out: tests(
+			"%v", `{
+	a: *1 | int
+}`,
+			"%+v", `{
+	// a is a field.
+	a:  1
+	b?: string
 }`,
 		),
 	}, {
@@ -0,0 +1,7 @@
+list: [1, 2, 3]
+squares: [for x in list if x > 1 {x * x}]
+byName: {
+	for i, x in list {
+		"item\(i)": x
+	}
+}
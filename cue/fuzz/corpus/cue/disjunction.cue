@@ -0,0 +1,10 @@
+#Kind: "a" | "b" | *"c"
+x:     #Kind
+y:     int | string
+y:     "foo"
+z?:    >10 & <20
+s:     """
+	multi
+	line
+	"""
+b: '\x00\xff'
@@ -0,0 +1,9 @@
+let base = {a: 1}
+x: base & {b: x.a + 1}
+_hidden: 3
+y: {
+	z: _hidden
+	w: y.z * 2
+	...
+}
+[string]: _
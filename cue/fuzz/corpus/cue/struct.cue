@@ -0,0 +1,10 @@
+package example
+
+// A is a struct with a default.
+#A: {
+	name:  string
+	count: *1 | int & >=0
+	tags: [...string]
+}
+
+a: #A & {name: "foo"}
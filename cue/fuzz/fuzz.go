@@ -0,0 +1,268 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fuzz provides entry points for fuzzing the CUE parser, evaluator
+// and encoders, along with seed corpora for each of them.
+//
+// Each entry point processes an arbitrary input and returns a *Crash error
+// only if doing so reveals a bug, such as a panic or a formatted file that
+// can no longer be parsed. Invalid inputs are not reported. Entry points never
+// panic, so they can be used directly with native Go fuzzing:
+//
+//	func FuzzEval(f *testing.F) {
+//		for _, seed := range fuzz.Seeds(fuzz.Eval) {
+//			f.Add(seed)
+//		}
+//		f.Fuzz(func(t *testing.T, data []byte) {
+//			if err := fuzz.Run(fuzz.Eval, data); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+//
+// To bound the time and memory spent on a single input, inputs larger than
+// MaxInputSize are skipped, and the evaluator is not run on inputs that
+// import packages, as builtins may allocate arbitrary amounts of memory.
+package fuzz
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"runtime/debug"
+	"strconv"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/encoding/json"
+	"cuelang.org/go/encoding/yaml"
+)
+
+// MaxInputSize is the size in bytes of the largest input that is processed.
+const MaxInputSize = 16 << 10
+
+// A Target identifies a fuzzing entry point.
+type Target int
+
+const (
+	// Parse parses the input as a CUE file.
+	Parse Target = iota
+
+	// Format parses the input as a CUE file and formats it. It reports a
+	// crash if the formatted file cannot be parsed or formatting it again
+	// yields a different result.
+	Format
+
+	// Eval compiles and evaluates the input as a CUE file, and exports the
+	// result to JSON if it is concrete.
+	Eval
+
+	// JSON decodes the input as JSON and encodes the result as JSON. It
+	// reports a crash if the encoded JSON cannot be decoded.
+	JSON
+
+	// YAML decodes the input as YAML and encodes the result as YAML. It
+	// reports a crash if the encoded YAML cannot be decoded.
+	YAML
+)
+
+var targetNames = []string{
+	Parse:  "parse",
+	Format: "format",
+	Eval:   "eval",
+	JSON:   "json",
+	YAML:   "yaml",
+}
+
+func (t Target) String() string {
+	if t < 0 || int(t) >= len(targetNames) {
+		return "Target(" + strconv.Itoa(int(t)) + ")"
+	}
+	return targetNames[t]
+}
+
+// Targets lists all fuzzing entry points.
+var Targets = []Target{Parse, Format, Eval, JSON, YAML}
+
+// A Crash describes a bug revealed by processing an input.
+type Crash struct {
+	Target Target
+	Input  []byte
+
+	// Reason describes the bug. For a panic, it holds the panic value.
+	Reason string
+
+	// Stack holds the stack trace of a panic.
+	Stack []byte
+}
+
+func (c *Crash) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s: %s\ninput: %q", c.Target, c.Reason, c.Input)
+	if len(c.Stack) > 0 {
+		fmt.Fprintf(&buf, "\n%s", c.Stack)
+	}
+	return buf.String()
+}
+
+// Run runs the entry point t on data. It returns a *Crash if a bug was
+// found, and nil otherwise.
+func Run(t Target, data []byte) (err error) {
+	if len(data) > MaxInputSize {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &Crash{
+				Target: t,
+				Input:  data,
+				Reason: fmt.Sprint("panic: ", r),
+				Stack:  debug.Stack(),
+			}
+		}
+	}()
+
+	var reason string
+	switch t {
+	case Parse:
+		_, _ = parser.ParseFile("fuzz.cue", data, parser.ParseComments)
+	case Format:
+		reason = formatCUE(data)
+	case Eval:
+		reason = eval(data)
+	case JSON:
+		reason = roundTripJSON(data)
+	case YAML:
+		reason = roundTripYAML(data)
+	default:
+		return fmt.Errorf("unknown fuzz target %v", t)
+	}
+	if reason != "" {
+		return &Crash{Target: t, Input: data, Reason: reason}
+	}
+	return nil
+}
+
+func formatCUE(data []byte) string {
+	f, err := parser.ParseFile("fuzz.cue", data, parser.ParseComments)
+	if err != nil {
+		return ""
+	}
+	b1, err := format.Node(f)
+	if err != nil {
+		return ""
+	}
+	f, err = parser.ParseFile("fuzz.cue", b1, parser.ParseComments)
+	if err != nil {
+		return fmt.Sprintf("formatted file does not parse: %v\nformatted:\n%s", err, b1)
+	}
+	b2, err := format.Node(f)
+	if err != nil {
+		return fmt.Sprintf("formatted file cannot be formatted: %v\nformatted:\n%s", err, b1)
+	}
+	if !bytes.Equal(b1, b2) {
+		return fmt.Sprintf("formatting is not idempotent\nfirst:\n%s\nsecond:\n%s", b1, b2)
+	}
+	return ""
+}
+
+func eval(data []byte) string {
+	f, err := parser.ParseFile("fuzz.cue", data)
+	if err != nil || len(f.Imports) > 0 {
+		return ""
+	}
+	v := cuecontext.New().BuildFile(f)
+	if v.Err() != nil {
+		return ""
+	}
+	_ = v.Validate()
+	_ = v.Syntax(cue.Final())
+	if v.Validate(cue.Concrete(true)) == nil {
+		_, _ = v.MarshalJSON()
+	}
+	return ""
+}
+
+func roundTripJSON(data []byte) string {
+	expr, err := json.Extract("fuzz.json", data)
+	if err != nil {
+		return ""
+	}
+	v := cuecontext.New().BuildExpr(expr)
+	if v.Err() != nil {
+		return ""
+	}
+	b, err := v.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+	if _, err := json.Extract("fuzz.json", b); err != nil {
+		return fmt.Sprintf("encoded JSON does not decode: %v\nencoded:\n%s", err, b)
+	}
+	return ""
+}
+
+func roundTripYAML(data []byte) string {
+	f, err := yaml.Extract("fuzz.yaml", data)
+	if err != nil {
+		return ""
+	}
+	v := cuecontext.New().BuildFile(f)
+	if v.Err() != nil {
+		return ""
+	}
+	b, err := yaml.Encode(v)
+	if err != nil {
+		return ""
+	}
+	if _, err := yaml.Extract("fuzz.yaml", b); err != nil {
+		return fmt.Sprintf("encoded YAML does not decode: %v\nencoded:\n%s", err, b)
+	}
+	return ""
+}
+
+//go:embed corpus
+var corpus embed.FS
+
+// corpora maps targets to the directory holding their seed corpus.
+var corpora = []string{
+	Parse:  "cue",
+	Format: "cue",
+	Eval:   "cue",
+	JSON:   "json",
+	YAML:   "yaml",
+}
+
+// Seeds returns the seed corpus for the entry point t.
+func Seeds(t Target) [][]byte {
+	if t < 0 || int(t) >= len(corpora) {
+		return nil
+	}
+	dir := path.Join("corpus", corpora[t])
+	entries, err := corpus.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var seeds [][]byte
+	for _, e := range entries {
+		b, err := corpus.ReadFile(path.Join(dir, e.Name()))
+		if err == nil {
+			seeds = append(seeds, b)
+		}
+	}
+	return seeds
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz_test
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/fuzz"
+)
+
+func fuzzTarget(f *testing.F, target fuzz.Target) {
+	seeds := fuzz.Seeds(target)
+	if len(seeds) == 0 {
+		f.Fatalf("no seeds for %v", target)
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := fuzz.Run(target, data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzParse(f *testing.F)  { fuzzTarget(f, fuzz.Parse) }
+func FuzzFormat(f *testing.F) { fuzzTarget(f, fuzz.Format) }
+func FuzzEval(f *testing.F)   { fuzzTarget(f, fuzz.Eval) }
+func FuzzJSON(f *testing.F)   { fuzzTarget(f, fuzz.JSON) }
+func FuzzYAML(f *testing.F)   { fuzzTarget(f, fuzz.YAML) }
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		target fuzz.Target
+		input  string
+	}{
+		{fuzz.Parse, "a: {"},
+		{fuzz.Format, "a: b: c"},
+		{fuzz.Eval, "a: 1, a: 2"},
+		{fuzz.Eval, `import "strings", a: strings.Repeat("x", 1e12)`},
+		{fuzz.JSON, "{"},
+		{fuzz.YAML, "a: [b"},
+		{fuzz.Eval, strings.Repeat("a: ", fuzz.MaxInputSize)},
+	}
+	for _, tc := range testCases {
+		if err := fuzz.Run(tc.target, []byte(tc.input)); err != nil {
+			t.Errorf("%v: unexpected crash: %v", tc.target, err)
+		}
+	}
+
+	if err := fuzz.Run(fuzz.Target(100), nil); err == nil {
+		t.Error("expected error for unknown target")
+	}
+}
+
+func TestTargets(t *testing.T) {
+	for _, target := range fuzz.Targets {
+		if strings.HasPrefix(target.String(), "Target(") {
+			t.Errorf("target %d has no name", int(target))
+		}
+		if len(fuzz.Seeds(target)) == 0 {
+			t.Errorf("no seeds for %v", target)
+		}
+	}
+}
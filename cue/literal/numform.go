@@ -0,0 +1,105 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package literal
+
+import (
+	"github.com/cockroachdb/apd/v3"
+)
+
+// String returns the suffix of m as it is written in a number literal, such
+// as "Ki", or the empty string if m is not a valid multiplier.
+func (m Multiplier) String() string {
+	i := int(m&^(mulBin|mulDec)) - 1
+	if i < 0 || i >= len(mulChars) || m&(mulBin|mulDec) == 0 {
+		return ""
+	}
+	if m&mulBin != 0 {
+		return mulChars[i:i+1] + "i"
+	}
+	return mulChars[i : i+1]
+}
+
+const mulChars = "KMGTPEZY"
+
+// NumForm defines how to format a number, optionally using the multipliers
+// that may be used in number literals, such as 512Mi.
+type NumForm struct {
+	muls      []Multiplier // in order of preference
+	precision int
+}
+
+var (
+	// Number formats numbers without multipliers.
+	Number NumForm
+
+	// SI formats integers using the decimal multipliers K, M, G, T and P.
+	SI = NumForm{muls: []Multiplier{P, T, G, M, K}}
+
+	// IEC formats integers using the binary multipliers Ki, Mi, Gi, Ti and Pi.
+	IEC = NumForm{muls: []Multiplier{Pi, Ti, Gi, Mi, Ki}}
+
+	// Units formats integers using both the decimal and the binary
+	// multipliers.
+	Units = NumForm{muls: []Multiplier{Pi, P, Ti, T, Gi, G, Mi, M, Ki, K}}
+)
+
+// WithPrecision returns a new NumForm that allows up to n digits after the
+// decimal point of a number with a multiplier, as in 1.5Gi. By default, a
+// multiplier is only used if it divides the number.
+func (f NumForm) WithPrecision(n int) NumForm {
+	f.precision = n
+	return f
+}
+
+// Format returns a CUE number literal representing d.
+//
+// An integer is written with the multiplier of f that yields the shortest
+// literal, if that literal is shorter than the integer written without a
+// multiplier. The literal always represents d exactly: the digits of the
+// number before the multiplier are never rounded. Numbers that are not
+// integers are never written with a multiplier.
+func (f NumForm) Format(d *apd.Decimal) string {
+	if d.Form != apd.Finite {
+		return d.String()
+	}
+	var x apd.Decimal
+	x.Reduce(d)
+	if x.Exponent < 0 {
+		return d.String()
+	}
+	s := x.Text('f')
+	for _, m := range f.muls {
+		var q apd.Decimal
+		cond, err := baseContext.Quo(&q, &x, mulToRat[m])
+		if err != nil || cond.Inexact() {
+			continue
+		}
+		q.Reduce(&q)
+		if -q.Exponent > int32(f.precision) {
+			continue
+		}
+		var abs apd.Decimal
+		abs.Abs(&q)
+		if abs.Cmp(one) < 0 {
+			continue
+		}
+		if t := q.Text('f') + m.String(); len(t) < len(s) {
+			s = t
+		}
+	}
+	return s
+}
+
+var one = apd.New(1, 0)
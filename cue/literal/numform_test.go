@@ -0,0 +1,100 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package literal
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+)
+
+func TestMultiplierString(t *testing.T) {
+	testCases := []struct {
+		m    Multiplier
+		want string
+	}{
+		{0, ""},
+		{K, "K"},
+		{P, "P"},
+		{Y, "Y"},
+		{Ki, "Ki"},
+		{Mi, "Mi"},
+		{Yi, "Yi"},
+		{mul1, ""},
+	}
+	for _, tc := range testCases {
+		if got := tc.m.String(); got != tc.want {
+			t.Errorf("%#x: got %q; want %q", byte(tc.m), got, tc.want)
+		}
+	}
+}
+
+func TestNumFormFormat(t *testing.T) {
+	testCases := []struct {
+		form NumForm
+		in   string
+		want string
+	}{
+		{Number, "536870912", "536870912"},
+		{Number, "1.5", "1.5"},
+		{IEC, "536870912", "512Mi"},
+		{IEC, "-536870912", "-512Mi"},
+		{IEC, "1024", "1Ki"},
+		{IEC, "1000", "1000"},
+		{IEC, "0", "0"},
+		{IEC, "1610612736", "1536Mi"},
+		{IEC.WithPrecision(1), "1610612736", "1.5Gi"},
+		{IEC.WithPrecision(1), "512", "512"},
+		{IEC, "1125899906842624000", "1000Pi"},
+		{IEC, "1.5", "1.5"},
+		{IEC, "1.5E+3", "1500"},
+		{IEC, "2048.0", "2Ki"},
+		{SI, "1000", "1K"},
+		{SI, "1024", "1024"},
+		{SI, "1500", "1500"},
+		{SI.WithPrecision(1), "1500000", "1.5M"},
+		{SI, "2E+9", "2G"},
+		{SI, "1E+30", "1000000000000000P"},
+		{Units, "1024000", "1024K"},
+		{Units, "2000000", "2M"},
+		{Units, "1073741824", "1Gi"},
+		{Units.WithPrecision(1), "1572864", "1.5Mi"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			d, _, err := apd.NewFromString(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tc.form.Format(d)
+			if got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+
+			// The result must represent the same number.
+			var n NumInfo
+			if err := ParseNum(got, &n); err != nil {
+				t.Fatal(err)
+			}
+			var x apd.Decimal
+			if err := n.Decimal(&x); err != nil {
+				t.Fatal(err)
+			}
+			if x.Cmp(d) != 0 {
+				t.Errorf("%s parses as %s; want %s", got, &x, d)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/astcodec"
+	"cuelang.org/go/internal/source"
+)
+
+// parseCache stores parsed files in a directory, keyed by a hash of their
+// contents, so that they can be reused by later loads, including those in
+// other processes.
+//
+// Entries are immutable and are written to a temporary file that is
+// atomically renamed into place, so concurrent processes never observe
+// partially written entries. Any problem reading or writing the cache
+// results in the file being parsed as usual.
+type parseCache struct {
+	dir   string
+	parse func(name string, src interface{}) (*ast.File, error)
+}
+
+func newParseCache(dir string, parse func(name string, src interface{}) (*ast.File, error)) *parseCache {
+	if parse == nil {
+		parse = func(name string, src interface{}) (*ast.File, error) {
+			return parser.ParseFile(name, src, parser.ParseComments)
+		}
+	}
+	return &parseCache{dir: filepath.Join(dir, "parse"), parse: parse}
+}
+
+// parseFile implements the signature of Config.ParseFile.
+func (c *parseCache) parseFile(name string, src interface{}) (*ast.File, error) {
+	data, err := source.Read(name, src)
+	if err != nil {
+		return nil, err
+	}
+	// //line comments alter position information in a way that is not
+	// recorded in the cache.
+	if bytes.Contains(data, []byte("//line ")) {
+		return c.parse(name, data)
+	}
+	file := c.path(data)
+	if b, err := os.ReadFile(file); err == nil {
+		if f, err := astcodec.Decode(name, data, b); err == nil {
+			return f, nil
+		}
+	}
+	f, err := c.parse(name, data)
+	if err != nil {
+		return f, err
+	}
+	if b, err := astcodec.Encode(f); err == nil {
+		c.put(file, b)
+	}
+	return f, nil
+}
+
+// path reports the location of the cache entry for the given file contents.
+func (c *parseCache) path(data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(astcodec.Version))
+	h.Write([]byte{0})
+	h.Write(data)
+	sum := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(c.dir, sum[:2], sum)
+}
+
+func (c *parseCache) put(file string, b []byte) {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
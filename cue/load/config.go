@@ -16,6 +16,7 @@ package load
 
 import (
 	"io"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/mod/modcache"
 	"cuelang.org/go/internal/mod/modfile"
 )
 
@@ -33,6 +35,7 @@ const (
 	cueSuffix  = ".cue"
 	modDir     = "cue.mod"
 	moduleFile = "module.cue"
+	vendorDir  = "vendor"
 	pkgDir     = "pkg"
 )
 
@@ -217,8 +220,24 @@ type Config struct {
 	//    environment: "prod" | "staging" @tag(env,short=prod|staging)
 	//
 	// ensures the user may only specify "prod" or "staging".
+	//
+	// A @tag attribute with the "required" option, as in
+	//
+	//    environment: string @tag(env,required)
+	//
+	// makes it an error for the tag not to be set by Tags, TagValues or
+	// TagVars.
 	Tags []string
 
+	// TagValues defines values, by tag name, to inject into fields with a
+	// @tag attribute, like the key-value pairs in Tags. Unlike those, which
+	// are parsed from strings, values may be of any Go type, including
+	// structs, maps and slices, and are converted to CUE as with
+	// cue.Context.Encode. A value may also be an ast.Expr or a cue.Value.
+	//
+	// If the @tag attribute defines a type, the value must be of that type.
+	TagValues map[string]interface{}
+
 	// TagVars defines a set of key value pair the values of which may be
 	// referenced by tags.
 	//
@@ -266,16 +285,34 @@ type Config struct {
 	// the syntax tree.
 	ParseFile func(name string, src interface{}) (*ast.File, error)
 
+	// CacheDir, if non-empty, names a directory in which the results of
+	// parsing files are cached, keyed by a hash of their contents, so that
+	// they can be reused by later loads. The directory may be shared by
+	// concurrent processes.
+	//
+	// When both CacheDir and ParseFile are set, ParseFile must produce the
+	// same result for the same file contents.
+	CacheDir string
+
 	// Overlay provides a mapping of absolute file paths to file contents.  If
 	// the file with the given path already exists, the parser will use the
 	// alternative file contents provided by the map.
 	Overlay map[string]Source
 
+	// FS, if non-nil, is used to read directories and files in place of the
+	// operating system's file system. Absolute paths, such as those of Dir
+	// and ModuleRoot, map to slash-separated paths in FS by dropping the
+	// leading separator, so that the root of FS corresponds to the root
+	// directory. If Dir is empty, it defaults to that root directory. The
+	// Overlay still takes precedence over the files in FS.
+	FS iofs.FS
+
 	// Stdin defines an alternative for os.Stdin for the file "-". When used,
 	// the corresponding build.File will be associated with the full buffer.
 	Stdin io.Reader
 
-	// Registry is used to fetch CUE module dependencies.
+	// Registry is used to fetch CUE module dependencies. Use NewRegistry
+	// to create a client for a registry requiring authentication.
 	//
 	// When nil, dependencies will be resolved in legacy mode:
 	// reading from cue.mod/pkg, cue.mod/usr, and cue.mod/gen.
@@ -283,7 +320,17 @@ type Config struct {
 	// THIS IS EXPERIMENTAL FOR NOW. DO NOT USE.
 	Registry ociregistry.Interface
 
+	// ModCache stores the contents of modules fetched from Registry.
+	// When nil, modules are stored in a new temporary directory.
+	//
+	// THIS IS EXPERIMENTAL FOR NOW. DO NOT USE.
+	ModCache modcache.Cache
+
 	fileSystem fileSystem
+
+	// skipRequiredTags disables the check for required tags, which
+	// NewTagCache performs for each evaluation instead.
+	skipRequiredTags bool
 }
 
 func (c *Config) stdin() io.Reader {
@@ -341,13 +388,19 @@ func (c Config) complete() (cfg *Config, err error) {
 	// (perhaps it is the stub to use in that case) should say "+build !cue1.x".
 	c.releaseTags = []string{"cue0.1"}
 
-	if c.Dir == "" {
+	switch {
+	case c.FS != nil:
+		// Paths are not relative to the working directory of the process.
+		c.Dir = filepath.Join(string(filepath.Separator), c.Dir)
+	case c.Dir == "":
 		c.Dir, err = os.Getwd()
 		if err != nil {
 			return nil, err
 		}
-	} else if c.Dir, err = filepath.Abs(c.Dir); err != nil {
-		return nil, err
+	default:
+		if c.Dir, err = filepath.Abs(c.Dir); err != nil {
+			return nil, err
+		}
 	}
 
 	// TODO: we could populate this already with absolute file paths,
@@ -371,6 +424,9 @@ func (c Config) complete() (cfg *Config, err error) {
 	if err := c.loadModule(); err != nil {
 		return nil, err
 	}
+	if c.CacheDir != "" {
+		c.ParseFile = newParseCache(c.CacheDir, c.ParseFile).parseFile
+	}
 	return &c, nil
 }
 
@@ -0,0 +1,121 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// An Evaluator evaluates the packages loaded by a Loader and, after files
+// change, evaluates again only the packages affected by the change.
+//
+// Once files are reported as changed, with Changed or any of the methods of
+// the Loader or its Watcher, the packages containing the files and the
+// packages importing them, directly or indirectly, are compiled and
+// evaluated anew, each in full. The evaluated values of all other packages,
+// including imports shared with the affected packages, are reused as is.
+// Results are not reused within a package: evaluation is incremental at the
+// granularity of packages only.
+//
+// The results of evaluating affected packages are discarded from the
+// cue.Context on the next call to Values or Changed. Values obtained
+// before a change should not be used after it. The same holds for packages
+// with errors, which the Loader loads anew each time.
+//
+// An Evaluator is not safe for concurrent use, nor for use concurrently
+// with its Loader.
+type Evaluator struct {
+	ctx *cue.Context
+	l   *Loader
+
+	// built holds the instances built by the Evaluator, including the
+	// imports built along with them.
+	built map[*build.Instance]bool
+}
+
+// NewEvaluator returns an Evaluator that evaluates the packages loaded by
+// l with ctx. The Evaluator should be the only user of l.
+func NewEvaluator(ctx *cue.Context, l *Loader) *Evaluator {
+	return &Evaluator{
+		ctx:   ctx,
+		l:     l,
+		built: map[*build.Instance]bool{},
+	}
+}
+
+// Values returns the values of the instances of the package named by arg,
+// as loaded by Loader.Load, and reports the combined errors of building
+// them, as with cue.Context.BuildInstances.
+//
+// The values of packages not affected by changes since an earlier call are
+// returned as is, without being evaluated again.
+func (e *Evaluator) Values(arg string) ([]cue.Value, error) {
+	e.release()
+	a := e.l.Load(arg)
+	v, err := e.ctx.BuildInstances(a)
+	e.track(a)
+	return v, err
+}
+
+// Changed reports that the files with the given names, relative to
+// Config.Dir, were changed, added or removed on disk, as with
+// Loader.Invalidate, and discards the values of the packages affected.
+func (e *Evaluator) Changed(filenames ...string) {
+	e.l.invalidate(filenames)
+	e.release()
+}
+
+// track records the instances in a and their transitive imports as built.
+func (e *Evaluator) track(a []*build.Instance) {
+	for _, p := range a {
+		if !e.built[p] {
+			e.built[p] = true
+			e.track(p.Imports)
+		}
+	}
+}
+
+// release discards the results of building instances that the Loader no
+// longer holds, directly or as imports.
+func (e *Evaluator) release() {
+	live := map[*build.Instance]bool{}
+	var mark func(a []*build.Instance)
+	mark = func(a []*build.Instance) {
+		for _, p := range a {
+			if !live[p] {
+				live[p] = true
+				mark(p.Imports)
+			}
+		}
+	}
+	for _, a := range e.l.pkgs {
+		mark(a)
+	}
+	for _, p := range e.l.imports {
+		mark([]*build.Instance{p})
+	}
+	var stale []*build.Instance
+	for p := range e.built {
+		if !live[p] {
+			stale = append(stale, p)
+			delete(e.built, p)
+		}
+	}
+	if len(stale) > 0 {
+		(*runtime.Runtime)(e.ctx).RemoveInst(stale...)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"io"
 	iofs "io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -48,6 +49,9 @@ func (f *overlayFile) Sys() interface{}   { return nil }
 type fileSystem struct {
 	overlayDirs map[string]map[string]*overlayFile
 	cwd         string
+
+	// fs, if non-nil, is used instead of the operating system's file system.
+	fs iofs.FS
 }
 
 func (fs *fileSystem) getDir(dir string, create bool) map[string]*overlayFile {
@@ -62,41 +66,51 @@ func (fs *fileSystem) getDir(dir string, create bool) map[string]*overlayFile {
 
 func (fs *fileSystem) init(c *Config) error {
 	fs.cwd = c.Dir
+	fs.fs = c.FS
 
 	overlay := c.Overlay
 	fs.overlayDirs = map[string]map[string]*overlayFile{}
 
 	// Organize overlay
 	for filename, src := range overlay {
-		// TODO: do we need to further clean the path or check that the
-		// specified files are within the root/ absolute files?
-		dir, base := filepath.Split(filename)
-		m := fs.getDir(dir, true)
-
-		b, file, err := src.contents()
-		if err != nil {
+		if err := fs.setOverlay(filename, src); err != nil {
 			return err
 		}
-		m[base] = &overlayFile{
-			basename: base,
-			contents: b,
-			file:     file,
-			modtime:  time.Now(),
-		}
+	}
+	return nil
+}
 
-		for {
-			prevdir := dir
-			dir, base = filepath.Split(filepath.Dir(dir))
-			if dir == prevdir || dir == "" {
-				break
-			}
-			m := fs.getDir(dir, true)
-			if m[base] == nil {
-				m[base] = &overlayFile{
-					basename: base,
-					modtime:  time.Now(),
-					isDir:    true,
-				}
+// setOverlay sets the contents of the given file, overriding any file on
+// disk.
+func (fs *fileSystem) setOverlay(filename string, src Source) error {
+	// TODO: do we need to further clean the path or check that the
+	// specified files are within the root/ absolute files?
+	dir, base := filepath.Split(filename)
+	m := fs.getDir(dir, true)
+
+	b, file, err := src.contents()
+	if err != nil {
+		return err
+	}
+	m[base] = &overlayFile{
+		basename: base,
+		contents: b,
+		file:     file,
+		modtime:  time.Now(),
+	}
+
+	for {
+		prevdir := dir
+		dir, base = filepath.Split(filepath.Dir(dir))
+		if dir == prevdir || dir == "" {
+			break
+		}
+		m := fs.getDir(dir, true)
+		if m[base] == nil {
+			m[base] = &overlayFile{
+				basename: base,
+				modtime:  time.Now(),
+				isDir:    true,
 			}
 		}
 	}
@@ -122,18 +136,40 @@ func (fs *fileSystem) makeAbs(path string) string {
 	return filepath.Clean(filepath.Join(fs.cwd, path))
 }
 
+// ioPath returns the path in fs.fs of the absolute path.
+func (fs *fileSystem) ioPath(path string) string {
+	path = filepath.ToSlash(path[len(filepath.VolumeName(path)):])
+	path = strings.TrimPrefix(pathpkg.Clean(path), "/")
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// osStat calls os.Stat or, if set, its equivalent on fs.fs. As symbolic
+// links are not exposed by an fs.FS, it is also used in place of os.Lstat.
+func (fs *fileSystem) osStat(path string, lstat bool) (os.FileInfo, error) {
+	switch {
+	case fs.fs != nil:
+		return iofs.Stat(fs.fs, fs.ioPath(path))
+	case lstat:
+		return os.Lstat(path)
+	}
+	return os.Stat(path)
+}
+
 func (fs *fileSystem) isDir(path string) bool {
 	path = fs.makeAbs(path)
 	if fs.getDir(path, false) != nil {
 		return true
 	}
-	fi, err := os.Stat(path)
+	fi, err := fs.osStat(path, false)
 	return err == nil && fi.IsDir()
 }
 
 func (fs *fileSystem) hasSubdir(root, dir string) (rel string, ok bool) {
 	// Try using paths we received.
-	if rel, ok = hasSubdir(root, dir); ok {
+	if rel, ok = hasSubdir(root, dir); ok || fs.fs != nil {
 		return
 	}
 
@@ -168,7 +204,13 @@ func hasSubdir(root, dir string) (rel string, ok bool) {
 func (fs *fileSystem) readDir(path string) ([]iofs.DirEntry, errors.Error) {
 	path = fs.makeAbs(path)
 	m := fs.getDir(path, false)
-	items, err := os.ReadDir(path)
+	var items []iofs.DirEntry
+	var err error
+	if fs.fs != nil {
+		items, err = iofs.ReadDir(fs.fs, fs.ioPath(path))
+	} else {
+		items, err = os.ReadDir(path)
+	}
 	if err != nil {
 		if !os.IsNotExist(err) || m == nil {
 			return nil, errors.Wrapf(err, token.NoPos, "readDir")
@@ -207,7 +249,7 @@ func (fs *fileSystem) stat(path string) (os.FileInfo, errors.Error) {
 	if fi := fs.getOverlay(path); fi != nil {
 		return fi, nil
 	}
-	fi, err := os.Stat(path)
+	fi, err := fs.osStat(path, false)
 	if err != nil {
 		return nil, errors.Wrapf(err, token.NoPos, "stat")
 	}
@@ -219,7 +261,7 @@ func (fs *fileSystem) lstat(path string) (os.FileInfo, errors.Error) {
 	if fi := fs.getOverlay(path); fi != nil {
 		return fi, nil
 	}
-	fi, err := os.Lstat(path)
+	fi, err := fs.osStat(path, true)
 	if err != nil {
 		return nil, errors.Wrapf(err, token.NoPos, "stat")
 	}
@@ -232,13 +274,33 @@ func (fs *fileSystem) openFile(path string) (io.ReadCloser, errors.Error) {
 		return io.NopCloser(bytes.NewReader(fi.contents)), nil
 	}
 
-	f, err := os.Open(path)
+	var f io.ReadCloser
+	var err error
+	if fs.fs != nil {
+		f, err = fs.fs.Open(fs.ioPath(path))
+	} else {
+		f, err = os.Open(path)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, token.NoPos, "load")
 	}
 	return f, nil
 }
 
+// readFile returns the contents of the file at path.
+func (fs *fileSystem) readFile(path string) ([]byte, errors.Error) {
+	f, err := fs.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, err1 := io.ReadAll(f)
+	if err1 != nil {
+		return nil, errors.Wrapf(err1, token.NoPos, "load")
+	}
+	return b, nil
+}
+
 var skipDir = errors.Newf(token.NoPos, "skip directory")
 
 type walkFunc func(path string, entry iofs.DirEntry, err errors.Error) errors.Error
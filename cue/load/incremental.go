@@ -0,0 +1,263 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/filetypes"
+)
+
+// A Loader loads packages on demand and keeps them for later loads.
+//
+// Where Instances does all of its work up front for the packages named by
+// its arguments, a Loader loads a single package and only its transitive
+// imports, and reuses the instances of packages loaded before, including
+// those loaded as imports, as long as their files have not changed. This
+// suits tools such as language servers, which reload a package after each
+// edit.
+//
+// Changes to files must be reported with SetOverlay or Invalidate, or be
+// detected with a Watcher. Changes to the module file, cue.mod/module.cue,
+// require a new Loader.
+//
+// Reused instances are identical, so building them again with the same
+// cue.Context reuses the results of earlier builds of their imports.
+//
+// A Loader is not safe for concurrent use.
+type Loader struct {
+	cfg    *Config
+	loader *loader
+
+	// pkgs holds the instances loaded for each package argument, by
+	// absolute directory or import path.
+	pkgs map[string][]*build.Instance
+
+	// imports holds the instances loaded for each import path.
+	imports map[string]*build.Instance
+
+	// watcher, if not nil, tracks the files of the instances loaded.
+	watcher *Watcher
+}
+
+// NewLoader returns a Loader that loads packages as configured by c. Tags
+// are not supported, as injecting them modifies the instances that the
+// Loader reuses.
+func NewLoader(c *Config) (*Loader, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if len(c.Tags) > 0 || len(c.TagValues) > 0 {
+		return nil, errors.Newf(token.NoPos, "load: tags are not supported by Loader")
+	}
+	c, err := c.complete()
+	if err != nil {
+		return nil, err
+	}
+	deps, regClient, err := c.resolveModules()
+	if err != nil {
+		return nil, err
+	}
+	l := &Loader{
+		cfg:     c,
+		loader:  newLoader(c, newTagger(c), deps, regClient),
+		pkgs:    map[string][]*build.Instance{},
+		imports: map[string]*build.Instance{},
+	}
+	l.loader.loadFunc = l.loadImport
+	return l, nil
+}
+
+// Load returns the instances of the package named by arg, which is an
+// import path or a directory relative to Config.Dir as accepted by
+// Instances, but not a pattern. There may be several instances if the
+// package name is "*".
+//
+// Load only loads the package and its transitive imports, and returns the
+// same instances as earlier calls for packages whose files did not change
+// since.
+func (l *Loader) Load(arg string) []*build.Instance {
+	if strings.Contains(arg, "...") || !filetypes.IsPackage(arg) {
+		return []*build.Instance{l.cfg.newErrInstance(
+			errors.Newf(token.NoPos, "%q is not a package", arg))}
+	}
+	key := arg
+	if isLocalImport(arg) {
+		key = filepath.Join(l.cfg.Dir, arg)
+	} else if p, ok := l.imports[arg]; ok {
+		return []*build.Instance{p}
+	}
+	if a, ok := l.pkgs[key]; ok {
+		return a
+	}
+
+	// Imports are shared through l.imports, rather than through the build
+	// context, so that they outlive a single load.
+	l.cfg.Context = build.NewContext(
+		build.Loader(l.loader.loadFunc),
+		build.ParseFile(l.cfg.ParseFile),
+	)
+	var a []*build.Instance
+	for _, m := range l.loader.importPaths([]string{arg}) {
+		if m.Err != nil {
+			a = append(a, l.cfg.newErrInstance(m.Err))
+			continue
+		}
+		a = append(a, m.Pkgs...)
+	}
+	if cacheable(a...) {
+		l.pkgs[key] = a
+		if l.watcher != nil {
+			l.watcher.track(a...)
+		}
+	}
+	return a
+}
+
+// loadImport is used as the load function of the instances loaded by l.
+func (l *Loader) loadImport(pos token.Pos, path string) *build.Instance {
+	if p, ok := l.imports[path]; ok {
+		return p
+	}
+	p := l.loader._loadFunc(pos, path)
+	if p != nil && cacheable(p) {
+		l.imports[path] = p
+		if l.watcher != nil {
+			l.watcher.track(p)
+		}
+	}
+	return p
+}
+
+// cacheable reports whether the instances may be reused. Instances with
+// errors are loaded anew each time, as it is not known which files need to
+// change to fix them.
+func cacheable(a ...*build.Instance) bool {
+	for _, p := range a {
+		if p.Err != nil || p.Incomplete {
+			return false
+		}
+	}
+	return true
+}
+
+// SetOverlay sets the contents of the file with the given absolute name,
+// overriding the file on disk, as with Config.Overlay. A nil src removes
+// the override. Packages that may contain the file are loaded anew by
+// later calls to Load.
+func (l *Loader) SetOverlay(filename string, src Source) error {
+	fs := &l.cfg.fileSystem
+	if src == nil {
+		dir, base := filepath.Split(filename)
+		delete(fs.getDir(dir, false), base)
+	} else if err := fs.setOverlay(filename, src); err != nil {
+		return err
+	}
+	l.Invalidate(filename)
+	return nil
+}
+
+// Invalidate reports that the files with the given names, relative to
+// Config.Dir, were changed, added or removed on disk. Packages that may
+// contain any of the files, and the packages importing them, directly or
+// indirectly, are loaded anew by later calls to Load.
+//
+// As a package may include files from parent directories, a file affects
+// all packages in its directory and the directories below it.
+func (l *Loader) Invalidate(filenames ...string) {
+	l.invalidate(filenames)
+}
+
+// invalidate implements Invalidate and returns the instances it removed.
+func (l *Loader) invalidate(filenames []string) []*build.Instance {
+	var dirs []string
+	for _, f := range filenames {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(l.cfg.Dir, f)
+		}
+		dirs = append(dirs, filepath.Dir(f))
+	}
+	stale := func(p *build.Instance) bool {
+		for _, dir := range dirs {
+			if p.Dir == dir || strings.HasPrefix(p.Dir, dir+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Remove the instances of the affected packages and then, until none
+	// is left, those importing removed instances.
+	removed := map[*build.Instance]bool{}
+	live := map[*build.Instance]bool{}
+	for path, p := range l.imports {
+		if stale(p) {
+			delete(l.imports, path)
+			removed[p] = true
+		} else {
+			live[p] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for path, p := range l.imports {
+			if !importsLive(p, live) {
+				delete(l.imports, path)
+				delete(live, p)
+				removed[p] = true
+				changed = true
+			}
+		}
+	}
+	for key, a := range l.pkgs {
+		for _, p := range a {
+			if stale(p) || !importsLive(p, live) {
+				delete(l.pkgs, key)
+				for _, p := range a {
+					removed[p] = true
+				}
+				break
+			}
+		}
+	}
+
+	a := make([]*build.Instance, 0, len(removed))
+	for p := range removed {
+		a = append(a, p)
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].Dir != a[j].Dir {
+			return a[i].Dir < a[j].Dir
+		}
+		return a[i].PkgName < a[j].PkgName
+	})
+	return a
+}
+
+// importsLive reports whether all imports of p, other than builtin
+// packages, are in live.
+func importsLive(p *build.Instance, live map[*build.Instance]bool) bool {
+	for _, imp := range p.Imports {
+		if !live[imp] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+	"cuelang.org/go/internal/value"
+)
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cue.mod/module.cue": `module: "mod.test"`,
+		"a/a.cue":            "package a\nimport \"mod.test/b\"\nx: b.y",
+		"b/b.cue":            "package b\ny: 1",
+		"c/c.cue":            "package c\nz: 2",
+	})
+	l, err := load.NewLoader(&load.Config{Dir: dir})
+	qt.Assert(t, qt.IsNil(err))
+
+	ctx := cuecontext.New()
+	a1 := loadOne(t, l, "./a")
+	qt.Assert(t, qt.Equals(lookup(t, ctx, a1, "x"), "1"))
+	b1 := a1.Imports[0]
+	qt.Assert(t, qt.Equals(b1.ImportPath, "mod.test/b"))
+	c1 := loadOne(t, l, "./c")
+
+	// Packages are reused, including those loaded as imports.
+	qt.Assert(t, qt.Equals(loadOne(t, l, "./a"), a1))
+	qt.Assert(t, qt.Equals(loadOne(t, l, "mod.test/b"), b1))
+
+	// Changing a package reloads it and the packages importing it.
+	err = l.SetOverlay(filepath.Join(dir, "b", "b.cue"), load.FromString("package b\ny: 3"))
+	qt.Assert(t, qt.IsNil(err))
+	a2 := loadOne(t, l, "./a")
+	qt.Assert(t, qt.Not(qt.Equals(a2, a1)))
+	qt.Assert(t, qt.Not(qt.Equals(a2.Imports[0], b1)))
+	qt.Assert(t, qt.Equals(lookup(t, ctx, a2, "x"), "3"))
+	qt.Assert(t, qt.Equals(loadOne(t, l, "./c"), c1))
+
+	// Removing the overlay restores the file on disk.
+	qt.Assert(t, qt.IsNil(l.SetOverlay(filepath.Join(dir, "b", "b.cue"), nil)))
+	qt.Assert(t, qt.Equals(lookup(t, ctx, loadOne(t, l, "./a"), "x"), "1"))
+
+	// Files added on disk are picked up once reported.
+	writeFiles(t, dir, map[string]string{"c/d.cue": "package c\nw: 4"})
+	qt.Assert(t, qt.Equals(loadOne(t, l, "./c"), c1))
+	l.Invalidate("c/d.cue")
+	c2 := loadOne(t, l, "./c")
+	qt.Assert(t, qt.Not(qt.Equals(c2, c1)))
+	qt.Assert(t, qt.Equals(lookup(t, ctx, c2, "w"), "4"))
+
+	// Files in a parent directory affect the packages below it.
+	l.Invalidate("x.cue")
+	qt.Assert(t, qt.Not(qt.Equals(loadOne(t, l, "./c"), c2)))
+
+	// Packages with errors are not reused.
+	bad := l.Load("./missing")
+	qt.Assert(t, qt.Not(qt.IsNil(bad[0].Err)))
+	qt.Assert(t, qt.Not(qt.Equals(l.Load("./missing")[0], bad[0])))
+
+	qt.Assert(t, qt.ErrorMatches(l.Load("./...")[0].Err, `"./..." is not a package`))
+}
+
+func TestLoaderTags(t *testing.T) {
+	_, err := load.NewLoader(&load.Config{Tags: []string{"prod"}})
+	qt.Assert(t, qt.ErrorMatches(err, `load: tags are not supported by Loader`))
+}
+
+func loadOne(t *testing.T, l *load.Loader, arg string) *build.Instance {
+	a := l.Load(arg)
+	qt.Assert(t, qt.HasLen(a, 1))
+	qt.Assert(t, qt.IsNil(a[0].Err))
+	return a[0]
+}
+
+func lookup(t *testing.T, ctx *cue.Context, inst *build.Instance, path string) string {
+	v := ctx.BuildInstance(inst)
+	qt.Assert(t, qt.IsNil(v.Err()))
+	return fmt.Sprint(v.LookupPath(cue.ParsePath(path)))
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		qt.Assert(t, qt.IsNil(os.MkdirAll(filepath.Dir(name), 0o777)))
+		qt.Assert(t, qt.IsNil(os.WriteFile(name, []byte(data), 0o666)))
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cue.mod/module.cue": `module: "mod.test"`,
+		"a/a.cue":            "package a\nimport \"mod.test/b\"\nx: b.y",
+		"b/b.cue":            "package b\ny: 1",
+		"c/c.cue":            "package c\nz: 2",
+	})
+	l, err := load.NewLoader(&load.Config{Dir: dir})
+	qt.Assert(t, qt.IsNil(err))
+	a1 := loadOne(t, l, "./a")
+	w := load.NewWatcher(l)
+	c1 := loadOne(t, l, "./c")
+
+	check := func() []string {
+		a, err := w.Check()
+		qt.Assert(t, qt.IsNil(err))
+		var dirs []string
+		for _, p := range a {
+			rel, err := filepath.Rel(dir, p.Dir)
+			qt.Assert(t, qt.IsNil(err))
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+		return dirs
+	}
+	qt.Assert(t, qt.IsNil(check()))
+
+	// Changing an imported package invalidates its importers.
+	writeFiles(t, dir, map[string]string{"b/b.cue": "package b\ny: 10"})
+	qt.Assert(t, qt.DeepEquals(check(), []string{"a", "b"}))
+	qt.Assert(t, qt.IsNil(check()))
+	a2 := loadOne(t, l, "./a")
+	qt.Assert(t, qt.Not(qt.Equals(a2, a1)))
+	qt.Assert(t, qt.Equals(loadOne(t, l, "./c"), c1))
+
+	// Added and removed files are reported, including those in parent
+	// directories, which may belong to any package.
+	writeFiles(t, dir, map[string]string{"c/d.cue": "package c\nw: 4"})
+	qt.Assert(t, qt.DeepEquals(check(), []string{"c"}))
+	loadOne(t, l, "./c")
+	qt.Assert(t, qt.IsNil(os.Remove(filepath.Join(dir, "c", "d.cue"))))
+	qt.Assert(t, qt.DeepEquals(check(), []string{"c"}))
+	loadOne(t, l, "./c")
+	writeFiles(t, dir, map[string]string{"x.cue": "package c"})
+	qt.Assert(t, qt.DeepEquals(check(), []string{"a", "b", "c"}))
+
+	// Files of other directories and other file types are ignored.
+	writeFiles(t, dir, map[string]string{"e/e.cue": "package e", "a/a.json": "{}"})
+	qt.Assert(t, qt.IsNil(check()))
+
+	writeFiles(t, dir, map[string]string{"cue.mod/module.cue": `module: "mod.test/other"`})
+	_, err = w.Check()
+	qt.Assert(t, qt.ErrorMatches(err, `load: module file .* changed; a new Loader is needed`))
+}
+
+func TestEvaluator(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cue.mod/module.cue": `module: "mod.test"`,
+		"a/a.cue":            "package a\nimport \"mod.test/b\"\nx: b.y",
+		"b/b.cue":            "package b\ny: 1",
+		"c/c.cue":            "package c\nimport \"mod.test/b\"\nz: b.y + 1",
+	})
+	l, err := load.NewLoader(&load.Config{Dir: dir})
+	qt.Assert(t, qt.IsNil(err))
+	ctx := cuecontext.New()
+	e := load.NewEvaluator(ctx, l)
+
+	eval := func(arg, path string) (*adt.Vertex, string) {
+		a, err := e.Values(arg)
+		qt.Assert(t, qt.IsNil(err))
+		qt.Assert(t, qt.HasLen(a, 1))
+		_, v := value.ToInternal(a[0])
+		return v, fmt.Sprint(a[0].LookupPath(cue.ParsePath(path)))
+	}
+	rt := (*runtime.Runtime)(ctx)
+
+	a1, x := eval("./a", "x")
+	qt.Assert(t, qt.Equals(x, "1"))
+	c1, z := eval("./c", "z")
+	qt.Assert(t, qt.Equals(z, "2"))
+	b1, _ := eval("mod.test/b", "y")
+
+	// Unchanged packages are not evaluated again.
+	a, _ := eval("./a", "x")
+	qt.Assert(t, qt.Equals(a, a1))
+
+	// Changing a package only affects the package and its importers.
+	writeFiles(t, dir, map[string]string{"a/a.cue": "package a\nimport \"mod.test/b\"\nx: b.y + 10"})
+	e.Changed("a/a.cue")
+	qt.Assert(t, qt.IsNil(rt.GetInstanceFromNode(a1)))
+	a2, x := eval("./a", "x")
+	qt.Assert(t, qt.Equals(x, "11"))
+	qt.Assert(t, qt.Not(qt.Equals(a2, a1)))
+	b, _ := eval("mod.test/b", "y")
+	qt.Assert(t, qt.Equals(b, b1))
+	c, _ := eval("./c", "z")
+	qt.Assert(t, qt.Equals(c, c1))
+
+	err = l.SetOverlay(filepath.Join(dir, "b", "b.cue"), load.FromString("package b\ny: 5"))
+	qt.Assert(t, qt.IsNil(err))
+	_, z = eval("./c", "z")
+	qt.Assert(t, qt.Equals(z, "6"))
+	_, x = eval("./a", "x")
+	qt.Assert(t, qt.Equals(x, "15"))
+	for _, v := range []*adt.Vertex{a2, b1, c1} {
+		qt.Assert(t, qt.IsNil(rt.GetInstanceFromNode(v)))
+	}
+
+	// Packages with errors are discarded once evaluated anew.
+	_, err = e.Values("./missing")
+	qt.Assert(t, qt.Not(qt.IsNil(err)))
+}
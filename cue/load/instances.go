@@ -26,6 +26,8 @@ import (
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/internal/filetypes"
+	"cuelang.org/go/internal/mod/modcache"
+	"cuelang.org/go/internal/mod/modsign"
 
 	// Trigger the unconditional loading of all core builtin packages if load
 	// is used. This was deemed the simplest way to avoid having to import
@@ -48,25 +50,9 @@ func Instances(args []string, c *Config) []*build.Instance {
 		return []*build.Instance{c.newErrInstance(err)}
 	}
 	c = newC
-	// TODO use predictable location
-	var deps *dependencies
-	var regClient *registryClient
-	if c.Registry != nil {
-		// TODO use configured cache directory.
-		tmpDir, err := os.MkdirTemp("", "cue-load-")
-		if err != nil {
-			return []*build.Instance{c.newErrInstance(err)}
-		}
-		regClient, err = newRegistryClient(c.Registry, tmpDir)
-		if err != nil {
-			return []*build.Instance{c.newErrInstance(fmt.Errorf("cannot make registry client: %v", err))}
-		}
-		deps1, err := resolveDependencies(c.modFile, regClient)
-		if err != nil {
-			return []*build.Instance{c.newErrInstance(fmt.Errorf("cannot resolve dependencies: %v", err))}
-		}
-		deps = deps1
-
+	deps, regClient, err := c.resolveModules()
+	if err != nil {
+		return []*build.Instance{c.newErrInstance(err)}
 	}
 	tg := newTagger(c)
 	l := newLoader(c, tg, deps, regClient)
@@ -112,6 +98,7 @@ func Instances(args []string, c *Config) []*build.Instance {
 		}
 		tg.tags = append(tg.tags, tags...)
 	}
+	shareImports(a)
 
 	// TODO(api): have API call that returns an error which is the aggregate
 	// of all build errors. Certain errors, like these, hold across builds.
@@ -140,3 +127,93 @@ func Instances(args []string, c *Config) []*build.Instance {
 
 	return a
 }
+
+// resolveModules returns the dependencies of the main module and a client
+// to fetch them when modules are loaded from a registry.
+func (c *Config) resolveModules() (*dependencies, *registryClient, error) {
+	if c.Registry == nil {
+		return nil, nil, nil
+	}
+	// TODO use predictable location
+	cache := c.ModCache
+	if cache == nil {
+		tmpDir, err := os.MkdirTemp("", "cue-load-")
+		if err != nil {
+			return nil, nil, err
+		}
+		cache = modcache.NewDisk(tmpDir)
+	}
+	regClient, err := newRegistryClient(c.Registry, cache)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot make registry client: %v", err)
+	}
+	regClient.policy = &modsign.Policy{
+		Keys:     c.modFile.Signatures.Keys,
+		Required: c.modFile.Signatures.Required,
+	}
+	if err := c.loadLock(regClient); err != nil {
+		return nil, nil, err
+	}
+	if regClient.vendorDir != "" {
+		// Vendored dependencies are tidy, so the module file
+		// already lists the whole build list.
+		return &dependencies{
+			mainModule: c.modFile,
+			versions:   c.modFile.DepVersions(),
+		}, regClient, nil
+	}
+	deps, err := resolveDependencies(c.modFile, regClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot resolve dependencies: %v", err)
+	}
+	return deps, regClient, nil
+}
+
+// shareImports replaces the imports of the instances in a, and of their
+// transitive imports, with the equivalent top-level instance in a, if any.
+// This ensures that a package that is both imported and loaded as a top-level
+// instance is represented by a single instance, and thus only built once.
+// Importers thereby also observe the tags injected in the top-level instance.
+func shareImports(a []*build.Instance) {
+	byPath := map[string]*build.Instance{}
+	for _, p := range a {
+		if p.ImportPath != "" && p.Err == nil && !p.User {
+			byPath[p.ImportPath] = p
+		}
+	}
+	if len(byPath) == 0 {
+		return
+	}
+
+	seen := map[*build.Instance]bool{}
+	var visit func(p *build.Instance)
+	visit = func(p *build.Instance) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		for i, imp := range p.Imports {
+			if q := byPath[imp.ImportPath]; q != nil && sameInstance(q, imp) {
+				p.Imports[i] = q
+			}
+			visit(p.Imports[i])
+		}
+	}
+	for _, p := range a {
+		visit(p)
+	}
+}
+
+// sameInstance reports whether p and q were loaded from the same files.
+func sameInstance(p, q *build.Instance) bool {
+	if p.Dir != q.Dir || p.PkgName != q.PkgName ||
+		len(p.BuildFiles) != len(q.BuildFiles) || q.Err != nil {
+		return false
+	}
+	for i, f := range p.BuildFiles {
+		if f.Filename != q.BuildFiles[i].Filename {
+			return false
+		}
+	}
+	return true
+}
@@ -25,7 +25,6 @@ import (
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/token"
-	"cuelang.org/go/internal/encoding"
 
 	// Trigger the unconditional loading of all core builtin packages if load
 	// is used. This was deemed the simplest way to avoid having to import
@@ -41,6 +40,8 @@ type loader struct {
 	loadFunc  build.LoadFunc
 	deps      *dependencies
 	regClient *registryClient
+
+	prefetcher *prefetcher
 }
 
 func newLoader(c *Config, tg *tagger, deps *dependencies, regClient *registryClient) *loader {
@@ -49,6 +50,8 @@ func newLoader(c *Config, tg *tagger, deps *dependencies, regClient *registryCli
 		tagger:    tg,
 		deps:      deps,
 		regClient: regClient,
+
+		prefetcher: newPrefetcher(c),
 	}
 	l.loadFunc = l._loadFunc
 	return l
@@ -135,16 +138,15 @@ func (l *loader) cueFilesPackage(files []*build.File) *build.Instance {
 
 func (l *loader) addFiles(dir string, p *build.Instance) {
 	for _, f := range p.BuildFiles {
-		d := encoding.NewDecoder(f, &encoding.Config{
-			Stdin:     l.cfg.stdin(),
-			ParseFile: l.cfg.ParseFile,
-		})
-		for ; !d.Done(); d.Next() {
-			_ = p.AddSyntax(d.File())
+		l.prefetcher.prefetch(f)
+	}
+	for _, f := range p.BuildFiles {
+		files, err := l.prefetcher.decode(f)
+		for _, file := range files {
+			_ = p.AddSyntax(file)
 		}
-		if err := d.Err(); err != nil {
-			p.ReportError(errors.Promote(err, "load"))
+		if err != nil {
+			p.ReportError(err)
 		}
-		d.Close()
 	}
 }
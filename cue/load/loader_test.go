@@ -16,17 +16,21 @@ package load
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"text/template"
 	"unicode"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/internal/str"
 	"cuelang.org/go/internal/tdtest"
 )
@@ -404,6 +408,192 @@ func TestOverlays(t *testing.T) {
 	}
 }
 
+func TestFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mod/cue.mod/module.cue": {Data: []byte(`module: "mod.test"`)},
+		"mod/root.cue":           {Data: []byte("package root\n")},
+		"mod/dir/top.cue": {Data: []byte(`
+			package top
+
+			import "mod.test/dir/b"
+
+			msg: "Hello"
+			a:   b.a
+		`)},
+		"mod/dir/b/foo.cue":   {Data: []byte("package b\na: <=5\n")},
+		"mod/dir/b/bar.cue":   {Data: []byte("package b\na: >=6\n")},
+		"mod/dir/b/data.json": {Data: []byte(`{"x": 1}`)},
+	}
+	c := &Config{
+		Dir: "mod/dir",
+		FS:  fsys,
+		// The Overlay takes precedence over FS.
+		Overlay: map[string]Source{
+			filepath.FromSlash("/mod/dir/b/bar.cue"): FromString("package b\na: >=5\n"),
+		},
+		DataFiles: true,
+	}
+	want := []string{
+		`{msg:"Hello"a:5}`,
+		`{a:5}`,
+	}
+	rmSpace := func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}
+	insts := Instances([]string{"./..."}, c)
+	if len(insts) != len(want) {
+		t.Fatalf("got %d instances; want %d", len(insts), len(want))
+	}
+	if got, want := insts[0].Module, "mod.test"; got != want {
+		t.Errorf("got module %q; want %q", got, want)
+	}
+	for i, inst := range cue.Build(insts) {
+		if inst.Err != nil {
+			t.Fatal(inst.Err)
+		}
+		b, err := format.Node(inst.Value().Syntax(cue.Final()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(bytes.Map(rmSpace, b)); got != want[i] {
+			t.Errorf("%s: got %s; want %s", inst.Dir, got, want[i])
+		}
+	}
+	if f := insts[1].OrphanedFiles; len(f) != 1 || string(f[0].Source.([]byte)) != `{"x": 1}` {
+		t.Errorf("data file not read from FS: %v", f)
+	}
+}
+
+func TestParseCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	var parsed []string
+	load := func() string {
+		var mu sync.Mutex
+		parsed = nil
+		cfg := &Config{
+			Dir:      testMod("testmod"),
+			CacheDir: cacheDir,
+			ParseFile: func(name string, src interface{}) (*ast.File, error) {
+				mu.Lock()
+				parsed = append(parsed, filepath.Base(name))
+				mu.Unlock()
+				return parser.ParseFile(name, src, parser.ParseComments)
+			},
+		}
+		insts := cue.Build(Instances([]string{"."}, cfg))
+		if err := insts[0].Err; err != nil {
+			t.Fatal(err)
+		}
+		b, err := format.Node(insts[0].Value().Syntax(cue.Final()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	want := load()
+	if len(parsed) == 0 {
+		t.Fatal("no files parsed on first load")
+	}
+	got := load()
+	if len(parsed) != 0 {
+		t.Errorf("files parsed despite cache: %v", parsed)
+	}
+	if got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mod/cue.mod/module.cue": {Data: []byte(`module: "mod.test"`)},
+	}
+	const n = 20
+	for i := 0; i < n; i++ {
+		dir := fmt.Sprintf("mod/p%02d/", i)
+		fsys[dir+"a.cue"] = &fstest.MapFile{Data: []byte(fmt.Sprintf("package p\na: %d\n", i))}
+		fsys[dir+"b.cue"] = &fstest.MapFile{Data: []byte("package p\nb: a + 1\n")}
+		fsys[dir+"_c.cue"] = &fstest.MapFile{Data: []byte("package p\nc: 1\n")}
+	}
+
+	var mu sync.Mutex
+	parsed := map[string]int{}
+	insts := Instances([]string{"./..."}, &Config{
+		Dir: "mod",
+		FS:  fsys,
+		ParseFile: func(name string, src interface{}) (*ast.File, error) {
+			mu.Lock()
+			parsed[filepath.ToSlash(name)]++
+			mu.Unlock()
+			return parser.ParseFile(name, src, parser.ParseComments)
+		},
+	})
+	if len(insts) != n {
+		t.Fatalf("got %d instances; want %d", len(insts), n)
+	}
+	for i, v := range cue.Build(insts) {
+		if err := v.Err; err != nil {
+			t.Fatal(err)
+		}
+		got, _ := v.Value().LookupPath(cue.ParsePath("b")).Int64()
+		if want := int64(i + 1); got != want {
+			t.Errorf("%s: got b: %d; want %d", v.Dir, got, want)
+		}
+	}
+	if len(parsed) != 2*n {
+		t.Errorf("parsed %d files; want %d", len(parsed), 2*n)
+	}
+	for name, count := range parsed {
+		if count != 1 {
+			t.Errorf("%s parsed %d times", name, count)
+		}
+	}
+}
+
+func TestSharedImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"cue.mod/module.cue": `module: "mod.test"`,
+		"a/a.cue":            "package a\n\nimport (\n\t\"mod.test/b\"\n\t\"mod.test/c\"\n)\n\nx: b.x + c.x",
+		"b/b.cue":            "package b\n\nx: 1",
+		"c/c.cue":            "package c\n\nx: *2 | int @tag(x,type=int)",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insts := Instances([]string{"./a", "./b", "./c"}, &Config{
+		Dir:  dir,
+		Tags: []string{"x=3"},
+	})
+	for _, p := range insts {
+		if p.Err != nil {
+			t.Fatal(p.Err)
+		}
+	}
+	a, b, c := insts[0], insts[1], insts[2]
+	if got := a.LookupImport("mod.test/b"); got != b {
+		t.Errorf("import of b not shared with top-level instance")
+	}
+	if got := a.LookupImport("mod.test/c"); got != c {
+		t.Errorf("import of c not shared with top-level instance")
+	}
+
+	// The import of c observes the injected tag.
+	v := cue.Build(insts)[0].Value()
+	if got, _ := v.LookupPath(cue.ParsePath("x")).Int64(); got != 4 {
+		t.Errorf("a.x = %v; want 4", got)
+	}
+}
+
 func TestLoadInstancesConcurrent(t *testing.T) {
 	// This test is designed to fail when run with the race detector
 	// if there's an underlying race condition.
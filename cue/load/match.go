@@ -51,12 +51,8 @@ func (e excludeError) Is(err error) bool { return err == errExclude }
 // If allTags is non-nil, matchFile records any encountered build tag
 // by setting allTags[tag] = true.
 func matchFile(cfg *Config, file *build.File, returnImports, allFiles bool, allTags map[string]bool) (match bool, data []byte, err errors.Error) {
-	if fi := cfg.fileSystem.getOverlay(file.Filename); fi != nil {
-		if fi.file != nil {
-			file.Source = fi.file
-		} else {
-			file.Source = fi.contents
-		}
+	if err := setSource(cfg, file); err != nil {
+		return false, nil, err
 	}
 
 	if file.Encoding != build.CUE {
@@ -99,6 +95,26 @@ func matchFile(cfg *Config, file *build.File, returnImports, allFiles bool, allT
 	return true, data, nil
 }
 
+// setSource sets the source of file to its overlay, if any, or to its
+// contents if these cannot later be read from its name.
+func setSource(cfg *Config, file *build.File) errors.Error {
+	if fi := cfg.fileSystem.getOverlay(file.Filename); fi != nil {
+		if fi.file != nil {
+			file.Source = fi.file
+		} else {
+			file.Source = fi.contents
+		}
+	} else if cfg.FS != nil && file.Source == nil && file.Filename != "-" {
+		// Files in an FS cannot be read by their name later on.
+		b, err := cfg.fileSystem.readFile(file.Filename)
+		if err != nil {
+			return err
+		}
+		file.Source = b
+	}
+	return nil
+}
+
 // treeCanMatchPattern(pattern)(name) reports whether
 // name or children of name can possibly match pattern.
 // Pattern is the same limited glob accepted by matchPattern.
@@ -10,6 +10,7 @@ import (
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/mod/modfile"
+	"cuelang.org/go/internal/mod/modlock"
 	"cuelang.org/go/internal/mod/module"
 	"cuelang.org/go/internal/mod/mvs"
 	"cuelang.org/go/internal/mod/semver"
@@ -60,6 +61,36 @@ func (c *Config) loadModule() error {
 	return nil
 }
 
+// loadLock reads the lock file of the main module, if any, into regClient,
+// and switches regClient to use vendored dependencies if the module has a
+// cue.mod/vendor directory.
+func (c *Config) loadLock(regClient *registryClient) error {
+	if c.modFile == nil || c.ModuleRoot == "" {
+		return nil
+	}
+	lockFile := filepath.Join(c.ModuleRoot, modDir, modlock.FileName)
+	if _, err := c.fileSystem.stat(lockFile); err == nil {
+		data, err := c.fileSystem.readFile(lockFile)
+		if err != nil {
+			return err
+		}
+		lock, err1 := modlock.Parse(data, lockFile)
+		if err1 != nil {
+			return err1
+		}
+		regClient.lock = lock
+	}
+	vendor := filepath.Join(c.ModuleRoot, modDir, vendorDir)
+	if !c.fileSystem.isDir(vendor) {
+		return nil
+	}
+	if regClient.lock == nil {
+		return errors.Newf(token.NoPos, "vendored module has no %s file; run 'cue mod tidy'", modlock.FileName)
+	}
+	regClient.vendorDir = vendor
+	return nil
+}
+
 type dependencies struct {
 	mainModule *modfile.File
 	versions   []module.Version
@@ -0,0 +1,137 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/internal/encoding"
+	"cuelang.org/go/internal/filetypes"
+)
+
+// A prefetcher reads and parses files concurrently, ahead of the loader
+// needing them.
+//
+// The loader itself remains sequential, which keeps its results
+// deterministic, but the bulk of its work, reading and parsing files, is
+// spread over all available processors. Each prefetched file is handed out
+// at most once, as the loader may modify the syntax trees it is given; files
+// that are requested again are decoded anew.
+type prefetcher struct {
+	cfg *Config
+	sem chan struct{} // limits the number of concurrent decodes
+
+	mu      sync.Mutex
+	pending map[string]*prefetched // by absolute file name
+}
+
+type prefetched struct {
+	file build.File // copy of the file as passed to prefetch
+	done chan struct{}
+
+	files []*ast.File
+	err   errors.Error
+}
+
+func newPrefetcher(c *Config) *prefetcher {
+	return &prefetcher{
+		cfg:     c,
+		sem:     make(chan struct{}, runtime.GOMAXPROCS(0)),
+		pending: map[string]*prefetched{},
+	}
+}
+
+// prefetch starts decoding f in the background if it is a CUE file that is
+// not already being decoded.
+func (p *prefetcher) prefetch(f *build.File) {
+	if f.Filename == "-" || f.Encoding != build.CUE {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending[f.Filename] != nil {
+		return
+	}
+	x := &prefetched{file: *f, done: make(chan struct{})}
+	p.pending[f.Filename] = x
+	go func() {
+		defer close(x.done)
+		p.sem <- struct{}{}
+		defer func() { <-p.sem }()
+
+		if x.err = setSource(p.cfg, &x.file); x.err == nil {
+			x.files, x.err = p.decodeFile(&x.file)
+		}
+	}()
+}
+
+// prefetchDir starts decoding the CUE files in dir that may be part of a
+// package.
+func (p *prefetcher) prefetchDir(dir string) {
+	entries, err := p.cfg.fileSystem.readDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == "-" ||
+			strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			continue
+		}
+		f, err := filetypes.ParseFile(name, filetypes.Input)
+		if err != nil {
+			continue
+		}
+		f.Filename = filepath.Join(dir, name)
+		p.prefetch(f)
+	}
+}
+
+// decode returns the syntax trees of f, using the result of an earlier call
+// to prefetch if possible.
+func (p *prefetcher) decode(f *build.File) ([]*ast.File, errors.Error) {
+	p.mu.Lock()
+	x := p.pending[f.Filename]
+	delete(p.pending, f.Filename)
+	p.mu.Unlock()
+
+	if x != nil && x.file.Encoding == f.Encoding &&
+		x.file.Interpretation == f.Interpretation && x.file.Form == f.Form {
+		<-x.done
+		return x.files, x.err
+	}
+	return p.decodeFile(f)
+}
+
+func (p *prefetcher) decodeFile(f *build.File) (files []*ast.File, err errors.Error) {
+	d := encoding.NewDecoder(f, &encoding.Config{
+		Stdin:     p.cfg.stdin(),
+		ParseFile: p.cfg.ParseFile,
+	})
+	defer d.Close()
+	for ; !d.Done(); d.Next() {
+		files = append(files, d.File())
+	}
+	if err := d.Err(); err != nil {
+		return files, errors.Promote(err, "load")
+	}
+	return files, nil
+}
@@ -3,39 +3,88 @@ package load
 import (
 	"context"
 	"fmt"
-	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"sync"
 
 	"cuelabs.dev/go/oci/ociregistry"
+	"cuelabs.dev/go/oci/ociregistry/ociclient"
+
+	"cuelang.org/go/internal/mod/modauth"
+	"cuelang.org/go/internal/mod/modcache"
 	"cuelang.org/go/internal/mod/modfile"
+	"cuelang.org/go/internal/mod/modlock"
 	"cuelang.org/go/internal/mod/modregistry"
+	"cuelang.org/go/internal/mod/modsign"
 	"cuelang.org/go/internal/mod/module"
-	"cuelang.org/go/internal/mod/zip"
 )
 
+// NewRegistry returns a client for the OCI registry at the given host, which
+// may include a port, for use as Config.Registry. If insecure is true, the
+// registry is contacted over HTTP rather than HTTPS.
+//
+// Requests are authenticated with the credentials configured for the host
+// in the Docker configuration file, $DOCKER_CONFIG/config.json or
+// ~/.docker/config.json, including those obtained from credential helpers.
+// The contents of modules fetched from the registry are checked against the
+// digests recorded in their manifests.
+//
+// THIS IS EXPERIMENTAL FOR NOW. DO NOT USE.
+func NewRegistry(host string, insecure bool) (ociregistry.Interface, error) {
+	cfg, err := modauth.Load(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ociclient.New(host, &ociclient.Options{
+		Client:   modauth.NewClient(nil, cfg.Credentials),
+		Insecure: insecure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot make OCI client: %v", err)
+	}
+	return r, nil
+}
+
 // registryClient implements the protocol for talking to
 // the registry server.
 type registryClient struct {
-	// TODO caching
-	client   *modregistry.Client
-	cacheDir string
+	client *modregistry.Client
+	cache  modcache.Cache
+
+	// lock, if non-nil, holds the lock file of the main module, against
+	// which the contents of all modules are verified.
+	lock *modlock.File
+
+	// vendorDir, if non-empty, holds the directory with the vendored
+	// contents of the dependencies, which are used instead of the cache.
+	vendorDir string
+
+	// policy determines the signatures accepted for modules fetched
+	// from the registry.
+	policy *modsign.Policy
+
+	mu       sync.Mutex
+	verified map[module.Version]bool
+	signed   map[module.Version]bool
 }
 
 // newRegistryClient returns a registry client that talks to
 // the given base URL and stores downloaded module information
-// in the given cache directory. It assumes that information
+// in the given cache. It assumes that information
 // in the registry is immutable, so if it's in the cache, a module
 // will not be downloaded again.
-func newRegistryClient(registry ociregistry.Interface, cacheDir string) (*registryClient, error) {
+func newRegistryClient(registry ociregistry.Interface, cache modcache.Cache) (*registryClient, error) {
 	client, err := modregistry.NewClient(registry)
 	if err != nil {
 		return nil, err
 	}
 	return &registryClient{
 		client:   client,
-		cacheDir: cacheDir,
+		cache:    cache,
+		policy:   &modsign.Policy{},
+		verified: map[module.Version]bool{},
+		signed:   map[module.Version]bool{},
 	}, nil
 }
 
@@ -64,36 +113,84 @@ func (c *registryClient) fetchRawModFile(ctx context.Context, mv module.Version)
 }
 
 // getModContents downloads the module with the given version
-// and returns the directory where it's stored.
+// and returns the directory where it's stored. When vendoring,
+// it returns the directory holding the vendored module instead.
+// If there is a lock file, the contents are checked against it.
 func (c *registryClient) getModContents(ctx context.Context, mv module.Version) (string, error) {
-	modPath := filepath.Join(c.cacheDir, mv.String())
-	if _, err := os.Stat(modPath); err == nil {
-		return modPath, nil
+	dir, err := c.modContents(ctx, mv)
+	if err != nil {
+		return "", err
+	}
+	if err := c.verify(mv, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func (c *registryClient) modContents(ctx context.Context, mv module.Version) (string, error) {
+	if c.vendorDir != "" {
+		dir := filepath.Join(c.vendorDir, filepath.FromSlash(mv.String()))
+		if _, err := os.Stat(dir); err != nil {
+			return "", fmt.Errorf("module %v is not vendored; run 'cue mod vendor'", mv)
+		}
+		return dir, nil
+	}
+	// The signatures are checked even if the module is in the cache, as
+	// the cache may be shared with modules that have a different policy.
+	c.mu.Lock()
+	signed := c.signed[mv] || c.policy.AcceptsAll()
+	c.mu.Unlock()
+	dir, ok := c.cache.Dir(mv)
+	if ok && signed {
+		return dir, nil
 	}
 	m, err := c.client.GetModule(ctx, mv)
 	if err != nil {
 		return "", err
 	}
+	if !signed {
+		if err := m.VerifySignatures(ctx, c.policy); err != nil {
+			return "", err
+		}
+		if ok {
+			// The signatures only cover the digest of the module, so
+			// check that the cached module has that digest.
+			if err := c.cache.Verify(mv, m.ZipDigest()); err != nil {
+				return "", err
+			}
+		}
+		c.mu.Lock()
+		c.signed[mv] = true
+		c.mu.Unlock()
+	}
+	if ok {
+		return dir, nil
+	}
 	r, err := m.GetZip(ctx)
 	if err != nil {
 		return "", err
 	}
 	defer r.Close()
-	zipfile := filepath.Join(c.cacheDir, mv.String()+".zip")
-	if err := os.MkdirAll(filepath.Dir(zipfile), 0o777); err != nil {
-		return "", fmt.Errorf("cannot create parent directory for zip file: %v", err)
+	return c.cache.Put(mv, r)
+}
+
+// verify checks the contents of module mv in dir against the lock file,
+// once for each module.
+func (c *registryClient) verify(mv module.Version, dir string) error {
+	if c.lock == nil {
+		return nil
 	}
-	f, err := os.Create(zipfile)
-	if err != nil {
-		return "", fmt.Errorf("cannot create zipfile: %v", err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.verified[mv] {
+		return nil
 	}
-
-	defer f.Close() // TODO check error on close
-	if _, err := io.Copy(f, r); err != nil {
-		return "", fmt.Errorf("cannot copy data to zip file %q: %v", zipfile, err)
+	if _, ok := c.lock.Hash(mv); !ok {
+		return fmt.Errorf("%v is missing from %s; run 'cue mod tidy'", mv, modlock.FileName)
 	}
-	if err := zip.Unzip(modPath, mv, zipfile); err != nil {
-		return "", fmt.Errorf("cannot unzip %v: %v", mv, err)
+	if err := c.lock.Verify(mv, os.DirFS(dir)); err != nil {
+		return err
 	}
-	return modPath, nil
+	c.verified[mv] = true
+	return nil
 }
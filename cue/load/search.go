@@ -153,6 +153,7 @@ func (l *loader) matchPackagesInFS(pattern, pkgName string) *match {
 	// TODO(legacy): remove
 	pkgDir2 := filepath.Join(root, "pkg")
 
+	var dirs []string
 	_ = c.fileSystem.walk(root, func(path string, entry fs.DirEntry, err errors.Error) errors.Error {
 		if err != nil || !entry.IsDir() {
 			return nil
@@ -182,6 +183,17 @@ func (l *loader) matchPackagesInFS(pattern, pkgName string) *match {
 		// 	return nil
 		// }
 
+		dirs = append(dirs, path)
+		return nil
+	})
+
+	// Decode the files of all packages concurrently while they are loaded.
+	for _, dir := range dirs {
+		l.prefetcher.prefetchDir(dir)
+	}
+
+nextDir:
+	for _, path := range dirs {
 		// We keep the directory if we can import it, or if we can't import it
 		// due to invalid CUE source files. This means that directories
 		// containing parse errors will be built (and fail) instead of being
@@ -203,7 +215,7 @@ func (l *loader) matchPackagesInFS(pattern, pkgName string) *match {
 					if c.DataFiles && len(p.OrphanedFiles) > 0 {
 						break
 					}
-					return nil
+					continue nextDir
 				default:
 					m.Err = errors.Append(m.Err, err)
 				}
@@ -211,8 +223,7 @@ func (l *loader) matchPackagesInFS(pattern, pkgName string) *match {
 		}
 
 		m.Pkgs = append(m.Pkgs, pkgs...)
-		return nil
-	})
+	}
 	return m
 }
 
@@ -0,0 +1,394 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/value"
+)
+
+// A TagCache evaluates an instance repeatedly with different tags, as set
+// with Config.Tags and Config.TagValues, such as when rendering the same
+// configuration for many tenants.
+//
+// The instance is loaded and its imports are evaluated only once. The
+// top-level fields of the instance are evaluated once without tags, and
+// for each set of tags only the top-level fields that depend on tags, and
+// the fields these refer to, are evaluated again. The results are cached
+// by tag set.
+//
+// Top-level fields are independent of tags if neither they nor the fields
+// they refer to have tags. If the instance has top-level embeddings,
+// comprehensions, pattern constraints or dynamic fields, which may affect
+// any field, the whole instance is evaluated for each tag set.
+//
+// Tag variables, as set by Config.TagVars, are not supported, as their
+// values may differ across evaluations.
+//
+// A TagCache is safe for concurrent use. Results are kept until Reset is
+// called.
+type TagCache struct {
+	ctx  *cue.Context
+	inst *build.Instance
+
+	// base is the instance evaluated without tags.
+	base cue.Value
+
+	// decls holds, for each file of inst, the declarations that need to
+	// be evaluated again for each tag set. It is nil if all declarations
+	// need to be.
+	decls [][]ast.Decl
+
+	mu     sync.Mutex
+	values map[string]cue.Value
+}
+
+// NewTagCache returns a TagCache for the instance named by arg, which is
+// loaded as with Instances using the configuration c. Tags are not allowed
+// in c. The values the TagCache returns are created by ctx.
+func NewTagCache(ctx *cue.Context, arg string, c *Config) (*TagCache, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if len(c.Tags) > 0 || len(c.TagValues) > 0 || c.TagVars != nil {
+		return nil, errors.Newf(token.NoPos, "load: tags are not supported by TagCache")
+	}
+	cfg := *c
+	cfg.skipRequiredTags = true
+	a := Instances([]string{arg}, &cfg)
+	if len(a) != 1 {
+		return nil, errors.Newf(token.NoPos, "load: %q names %d instances, want 1", arg, len(a))
+	}
+	inst := a[0]
+	if inst.Err != nil {
+		return nil, inst.Err
+	}
+	base := ctx.BuildInstance(inst)
+	if err := base.Err(); err != nil {
+		return nil, err
+	}
+	tc := &TagCache{
+		ctx:    ctx,
+		inst:   inst,
+		base:   base,
+		values: map[string]cue.Value{},
+	}
+	if deps := topLevelDeps(inst.Files); deps != nil {
+		tc.decls = deps.taggedDecls(inst.Files)
+	}
+	return tc, nil
+}
+
+// Value returns the instance evaluated with the given tags and tag values,
+// which are interpreted as Config.Tags and Config.TagValues. An error is
+// returned if the tags cannot be injected. Errors in the configuration are
+// reported in the returned value.
+func (c *TagCache) Value(tags []string, values map[string]interface{}) (cue.Value, error) {
+	exprs := make(map[string]interface{}, len(values))
+	for key, val := range values {
+		x, _, err := tagValue(c.ctx, key, val)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		exprs[key] = x
+	}
+	key, err := tagSetKey(tags, exprs)
+	if err != nil {
+		return cue.Value{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.values[key]; ok {
+		return v, nil
+	}
+	v, err := c.build(tags, exprs)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	c.values[key] = v
+	return v, nil
+}
+
+// Reset removes all cached results.
+func (c *TagCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = map[string]cue.Value{}
+}
+
+// tagSetKey returns a key that is identical for tag sets that result in
+// the same configuration.
+func tagSetKey(tags []string, values map[string]interface{}) (string, error) {
+	a := append([]string(nil), tags...)
+	sort.Strings(a)
+	for key, x := range values {
+		b, err := format.Node(x.(ast.Expr))
+		if err != nil {
+			return "", err
+		}
+		a = append(a, "\x00"+key+"="+string(b))
+	}
+	sort.Strings(a[len(tags):])
+	return strings.Join(a, "\x00"), nil
+}
+
+// build evaluates the instance with the given tags. The declarations to
+// evaluate are selected and the tags are injected by modifying the syntax of
+// the instance, which is shared by all evaluations and therefore restored
+// once the instance is built. The instance itself is copied, as the runtime
+// builds an instance only once.
+func (c *TagCache) build(tags []string, values map[string]interface{}) (cue.Value, error) {
+	p := *c.inst
+	if c.decls != nil {
+		decls := make([][]ast.Decl, len(p.Files))
+		for i, f := range p.Files {
+			decls[i], f.Decls = f.Decls, c.decls[i]
+		}
+		defer func() {
+			for i, f := range p.Files {
+				f.Decls = decls[i]
+			}
+		}()
+	}
+
+	tg := newTagger(&Config{TagValues: values})
+	var errs errors.Error
+	tg.tags, errs = findTags(&p)
+	if errs != nil {
+		return cue.Value{}, errs
+	}
+	fieldValues := map[*ast.Field]ast.Expr{}
+	for _, t := range tg.tags {
+		if _, ok := fieldValues[t.field]; !ok {
+			fieldValues[t.field] = t.field.Value
+		}
+	}
+	defer func() {
+		for f, x := range fieldValues {
+			f.Value = x
+		}
+	}()
+	if err := tg.injectTags(tags); err != nil {
+		return cue.Value{}, err
+	}
+	if tg.replacements != nil {
+		idents := map[*ast.Ident]ast.Node{}
+		for _, f := range p.Files {
+			ast.Walk(f, nil, func(n ast.Node) {
+				if ident, ok := n.(*ast.Ident); ok {
+					if v, ok := tg.replacements[ident.Node]; ok {
+						idents[ident] = ident.Node
+						ident.Node = v
+					}
+				}
+			})
+		}
+		defer func() {
+			for ident, n := range idents {
+				ident.Node = n
+			}
+		}()
+	}
+
+	v := c.ctx.BuildInstance(&p)
+	if c.decls == nil || v.Err() != nil {
+		return v, nil
+	}
+	return c.merge(v), nil
+}
+
+// merge returns the result of adding the top-level fields of the base
+// evaluation that are absent from v, the evaluation of the declarations
+// that depend on tags, to v.
+func (c *TagCache) merge(v cue.Value) cue.Value {
+	_, base := value.ToInternal(c.base)
+	_, tagged := value.ToInternal(v)
+
+	n := &adt.Vertex{
+		Label:     tagged.Label,
+		ArcType:   tagged.ArcType,
+		BaseValue: tagged.BaseValue,
+		Closed:    tagged.Closed,
+		// The conjuncts of the base evaluation, combined with those of
+		// the tagged fields, are equivalent to the conjuncts of the
+		// instance with tags, so that further operations on the result,
+		// such as unification, see the complete configuration.
+		Conjuncts: append(append([]adt.Conjunct(nil), base.Conjuncts...), tagged.Conjuncts...),
+	}
+	done := map[adt.Feature]bool{}
+	for _, a := range base.Arcs {
+		if t := tagged.Lookup(a.Label); t != nil {
+			a = t
+		}
+		done[a.Label] = true
+		n.Arcs = append(n.Arcs, a)
+	}
+	for _, a := range tagged.Arcs {
+		if !done[a.Label] {
+			n.Arcs = append(n.Arcs, a)
+		}
+	}
+	switch {
+	case tagged.ChildErrors == nil:
+		n.ChildErrors = base.ChildErrors
+	case base.ChildErrors == nil:
+		n.ChildErrors = tagged.ChildErrors
+	default:
+		n.ChildErrors = adt.CombineErrors(nil, tagged.ChildErrors, base.ChildErrors)
+	}
+	n.ForceDone()
+	return c.ctx.Encode(n)
+}
+
+// declDeps holds the dependencies between the top-level declarations of
+// the files of a package. Declarations are grouped by the name by which
+// they are referred to, which is the label of a field, the name of a let
+// clause or an alias of a field label.
+type declDeps struct {
+	decls map[ast.Decl]string // group of a declaration
+	names map[string]string   // group referred to by an identifier
+	refs  map[string][]string // groups referred to by a group
+	tags  map[string]bool     // groups with tags
+	keep  map[string]bool     // groups to evaluate for each tag set
+	users map[string][]string // groups referring to a group
+}
+
+// topLevelDeps computes the dependencies between the top-level declarations
+// of files. It returns nil if any declaration may affect fields other than
+// those it declares.
+func topLevelDeps(files []*ast.File) *declDeps {
+	d := &declDeps{
+		decls: map[ast.Decl]string{},
+		names: map[string]string{},
+		refs:  map[string][]string{},
+		tags:  map[string]bool{},
+		users: map[string][]string{},
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch x := decl.(type) {
+			case *ast.Package, *ast.ImportDecl, *ast.Attribute, *ast.CommentGroup:
+			case *ast.LetClause:
+				d.decls[x] = x.Ident.Name
+				d.names[x.Ident.Name] = x.Ident.Name
+			case *ast.Field:
+				label := x.Label
+				alias, _ := label.(*ast.Alias)
+				if alias != nil {
+					label, _ = alias.Expr.(ast.Label)
+				}
+				if _, ok := label.(*ast.ListLit); ok {
+					return nil // pattern constraint
+				}
+				name, isIdent, err := ast.LabelName(label)
+				if err != nil {
+					return nil // dynamic field
+				}
+				d.decls[x] = name
+				if isIdent {
+					d.names[name] = name
+				}
+				if alias != nil {
+					d.names[alias.Ident.Name] = name
+				}
+			default:
+				// Embeddings, comprehensions, ellipses and the like.
+				return nil
+			}
+		}
+	}
+
+	// Identifiers are matched by name, rather than by what they resolve
+	// to, as references to fields in other files are not resolved in the
+	// syntax. Shadowed names only cause more fields to be evaluated again.
+	for decl, group := range d.decls {
+		seen := map[string]bool{}
+		ast.Walk(decl, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.Ident:
+				if g, ok := d.names[x.Name]; ok && g != group && !seen[g] {
+					seen[g] = true
+					d.refs[group] = append(d.refs[group], g)
+					d.users[g] = append(d.users[g], group)
+				}
+			case *ast.Field:
+				for _, a := range x.Attrs {
+					if key, _ := a.Split(); key == "tag" {
+						d.tags[group] = true
+					}
+				}
+			}
+			return true
+		}, nil)
+	}
+
+	// Fields that refer to fields with tags depend on the tags as well.
+	dependent := map[string]bool{}
+	var markUsers func(g string)
+	markUsers = func(g string) {
+		if dependent[g] {
+			return
+		}
+		dependent[g] = true
+		for _, u := range d.users[g] {
+			markUsers(u)
+		}
+	}
+	for g := range d.tags {
+		markUsers(g)
+	}
+
+	// Evaluating these requires the fields they refer to.
+	d.keep = map[string]bool{}
+	var markRefs func(g string)
+	markRefs = func(g string) {
+		if d.keep[g] {
+			return
+		}
+		d.keep[g] = true
+		for _, r := range d.refs[g] {
+			markRefs(r)
+		}
+	}
+	for g := range dependent {
+		markRefs(g)
+	}
+	return d
+}
+
+// taggedDecls returns, for each file, the declarations that need to be
+// evaluated for each tag set.
+func (d *declDeps) taggedDecls(files []*ast.File) [][]ast.Decl {
+	a := make([][]ast.Decl, len(files))
+	for i, f := range files {
+		for _, decl := range f.Decls {
+			if group, ok := d.decls[decl]; !ok || d.keep[group] {
+				a[i] = append(a[i], decl)
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/value"
+)
+
+func TestTagCache(t *testing.T) {
+	dir := t.TempDir()
+
+	type tagSet struct {
+		tags   []string
+		values map[string]interface{}
+	}
+	testCases := []struct {
+		in      string
+		partial bool
+		// kept holds the top-level fields evaluated for each tag set.
+		kept []string
+		sets []tagSet
+		err  string
+	}{{
+		in: `
+		env:      string @tag(env)
+		replicas: int    @tag(replicas,type=int)
+		name:     "web-\(env)"
+		X=labels: {app: "web"}
+		spec: {
+			labels: X
+			count:  replicas
+		}
+		let base = {image: "web:1.0"}
+		image:  base.image
+		static: [for i in [1, 2, 3] {i * 2}]
+		`,
+		partial: true,
+		kept:    []string{"X", "env", "labels", "name", "replicas", "spec"},
+		sets: []tagSet{
+			{tags: []string{"env=prod", "replicas=3"}},
+			{tags: []string{"replicas=3", "env=prod"}},
+			{tags: []string{"env=dev"}, values: map[string]interface{}{"replicas": 1}},
+			{},
+		},
+	}, {
+		in: `
+		env: string @tag(env)
+		for k, v in {a: env} {
+			(k): v
+		}
+		`,
+		sets: []tagSet{
+			{tags: []string{"env=prod"}},
+		},
+	}, {
+		in: `
+		env:  string @tag(env)
+		zone: string @tag(zone,required)
+		`,
+		partial: true,
+		kept:    []string{"env", "zone"},
+		sets: []tagSet{
+			{tags: []string{"env=prod"}},
+		},
+		err: `required tag "zone" not set`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			filename := filepath.Join(dir, "foo.cue")
+			cfg := &Config{
+				Dir:     dir,
+				Overlay: map[string]Source{filename: FromString(tc.in)},
+			}
+			ctx := cuecontext.New()
+			tc := tc
+			c, err := NewTagCache(ctx, "foo.cue", cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.decls != nil; got != tc.partial {
+				t.Fatalf("partial evaluation: got %v; want %v", got, tc.partial)
+			}
+			if tc.partial {
+				deps := topLevelDeps(c.inst.Files)
+				var kept []string
+				for name, g := range deps.names {
+					if deps.keep[g] {
+						kept = append(kept, name)
+					}
+				}
+				sort.Strings(kept)
+				if !equalStrings(kept, tc.kept) {
+					t.Errorf("kept: got %v; want %v", kept, tc.kept)
+				}
+			}
+
+			for _, set := range tc.sets {
+				got, err := c.Value(set.tags, set.values)
+				if tc.err != "" {
+					if err == nil || err.Error() != tc.err {
+						t.Fatalf("error: got %v; want %v", err, tc.err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				again, _ := c.Value(set.tags, set.values)
+				if _, v := value.ToInternal(again); v != mustVertex(got) {
+					t.Error("result not cached")
+				}
+
+				cfg := &Config{
+					Dir:       dir,
+					Overlay:   map[string]Source{filename: FromString(tc.in)},
+					Tags:      set.tags,
+					TagValues: set.values,
+				}
+				want := ctx.BuildInstance(Instances([]string{"foo.cue"}, cfg)[0])
+				// Let clauses are not compared with Equals, as their labels
+				// differ for each build.
+				if g, w := fmt.Sprint(got), fmt.Sprint(want); g != w {
+					t.Errorf("got %s\nwant %s", g, w)
+				}
+
+				// Unifying the result sees the fields of the base evaluation.
+				u := got.Unify(ctx.CompileString(`static: [2, 4, 6]`))
+				if err := u.Validate(); err != nil {
+					t.Error(err)
+				}
+				if tc.partial {
+					if v := got.LookupPath(cue.ParsePath("static")); mustVertex(v) != mustVertex(c.base.LookupPath(cue.ParsePath("static"))) {
+						t.Error("field independent of tags was evaluated again")
+					}
+				}
+			}
+
+			// The shared syntax is restored.
+			ast.Walk(c.inst.Files[0], nil, func(n ast.Node) {
+				if x, ok := n.(*ast.BinaryExpr); ok && x.Op == token.AND {
+					t.Errorf("tag value left in syntax: %v", x.Y)
+				}
+			})
+		})
+	}
+}
+
+func mustVertex(v cue.Value) interface{} {
+	_, x := value.ToInternal(v)
+	return x
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
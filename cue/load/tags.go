@@ -20,12 +20,14 @@ import (
 	"os"
 	"os/user"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/cue/token"
@@ -122,7 +124,7 @@ func varToString(s string, err error) (ast.Expr, error) {
 //
 // A tag is of the form
 //
-//	@tag(<name>,[type=(string|int|number|bool)][,short=<shorthand>+])
+//	@tag(<name>,[type=(string|int|number|bool)][,short=<shorthand>+][,required])
 //
 // The name is mandatory and type defaults to string. Tags are set using the -t
 // option on the command line. -t name=value will parse value for the type
@@ -136,11 +138,15 @@ func varToString(s string, err error) (ast.Expr, error) {
 // shorthands than the shorthand name itself. Doing so would create a powerful
 // mechanism that would assign different values to different fields based on the
 // same shorthand, duplicating functionality that is already available in CUE.
+//
+// A required tag must be set, either directly or through a tag variable.
 type tag struct {
 	key            string
 	kind           cue.Kind
+	typed          bool // kind was set explicitly
 	shorthands     []string
 	vars           string // -T flag
+	required       bool
 	hasReplacement bool
 
 	field *ast.Field
@@ -158,6 +164,7 @@ func parseTag(pos token.Pos, body string) (t *tag, err errors.Error) {
 	}
 
 	if s, ok, _ := a.Lookup(1, "type"); ok {
+		t.typed = true
 		switch s {
 		case "string":
 		case "int":
@@ -184,6 +191,8 @@ func parseTag(pos token.Pos, body string) (t *tag, err errors.Error) {
 		t.vars = s
 	}
 
+	t.required, _ = a.Flag(1, "required")
+
 	return t, nil
 }
 
@@ -293,6 +302,10 @@ func (tg *tagger) injectTags(tags []string) errors.Error {
 		}
 	}
 
+	if err := tg.injectTagValues(tg.cfg.TagValues); err != nil {
+		return err
+	}
+
 	if tg.cfg.TagVars != nil {
 		vars := map[string]ast.Expr{}
 
@@ -321,9 +334,79 @@ func (tg *tagger) injectTags(tags []string) errors.Error {
 			}
 		}
 	}
+
+	if tg.cfg.skipRequiredTags {
+		return nil
+	}
+	var errs errors.Error
+	for _, t := range tg.tags {
+		if t.required && !t.hasReplacement {
+			errs = errors.Append(errs, errors.Newf(t.field.Pos(),
+				"required tag %q not set", t.key))
+		}
+	}
+	return errs
+}
+
+// injectTagValues injects the values of Config.TagValues.
+func (tg *tagger) injectTagValues(values map[string]interface{}) errors.Error {
+	if len(values) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ctx := cuecontext.New()
+	for _, key := range keys {
+		x, v, err := tagValue(ctx, key, values[key])
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, t := range tg.tags {
+			if t.key != key {
+				continue
+			}
+			found = true
+			if t.typed && v.IncompleteKind()&^t.kind != 0 {
+				return errors.Newf(t.field.Pos(),
+					"value for tag %q is of kind %v, want %v", key, v.IncompleteKind(), t.kind)
+			}
+			t.injectValue(x, tg)
+		}
+		if !found {
+			return errors.Newf(token.NoPos, "no tag for %q", key)
+		}
+	}
 	return nil
 }
 
+// tagValue converts a value of Config.TagValues for the tag key to both
+// an expression to inject and its evaluated value.
+func tagValue(ctx *cue.Context, key string, val interface{}) (ast.Expr, cue.Value, errors.Error) {
+	var x ast.Expr
+	var v cue.Value
+	switch val := val.(type) {
+	case ast.Expr:
+		x = val
+		v = ctx.BuildExpr(val)
+	case cue.Value:
+		v = val
+		x = internal.ToExpr(val.Syntax(cue.Final()))
+	default:
+		v = ctx.Encode(val)
+		x = internal.ToExpr(v.Syntax(cue.Final()))
+	}
+	if err := v.Err(); err != nil {
+		return nil, v, errors.Wrapf(err, token.NoPos, "invalid value for tag %q", key)
+	}
+	return x, v, nil
+}
+
 // shouldBuildFile determines whether a File should be included based on its
 // attributes.
 func shouldBuildFile(f *ast.File, fp *fileProcessor) errors.Error {
@@ -121,3 +121,111 @@ func TestTags(t *testing.T) {
 		})
 	}
 }
+
+func TestTagValues(t *testing.T) {
+	dir := t.TempDir()
+
+	type config struct {
+		Name     string   `json:"name"`
+		Replicas int      `json:"replicas"`
+		Zones    []string `json:"zones"`
+	}
+
+	testCases := []struct {
+		in     string
+		values map[string]interface{}
+		out    string
+		err    string
+	}{{
+		in: `
+		cfg: {name: string, replicas: >0} @tag(cfg)
+		port: int                          @tag(port,type=int)
+		ratio: number                      @tag(ratio,type=number)
+		list: [...int]                     @tag(list)
+		`,
+		values: map[string]interface{}{
+			"cfg":   config{Name: "web", Replicas: 3, Zones: []string{"a", "b"}},
+			"port":  8080,
+			"ratio": 0.5,
+			"list":  []int{1, 2},
+		},
+		out: `{
+			cfg: {name: "web", replicas: 3, zones: ["a", "b"]}
+			port: 8080
+			ratio: 0.5
+			list: [1, 2]
+		}`,
+	}, {
+		in: `
+		a: string @tag(a)
+		b: int    @tag(b)
+		`,
+		values: map[string]interface{}{
+			"a": ast.NewString("x"),
+			"b": cuecontext.New().CompileString("1 + 2"),
+		},
+		out: `{a: "x", b: 3}`,
+	}, {
+		in: `
+		cfg: {replicas: >0} @tag(cfg)
+		`,
+		values: map[string]interface{}{"cfg": map[string]int{"replicas": 0}},
+		err:    `cfg.replicas: invalid value 0 (out of bound >0)`,
+	}, {
+		in: `
+		port: int @tag(port,type=int)
+		`,
+		values: map[string]interface{}{"port": "8080"},
+		err:    `value for tag "port" is of kind string, want int`,
+	}, {
+		in: `
+		port: int @tag(port)
+		`,
+		values: map[string]interface{}{"host": "x"},
+		err:    `no tag for "host"`,
+	}, {
+		in: `
+		env:  string @tag(env,required)
+		zone: string @tag(zone,required)
+		user: string @tag(user,required,var=username)
+		`,
+		values: map[string]interface{}{"env": "prod"},
+		err:    `required tag "zone" not set`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			cfg := &Config{
+				Dir: dir,
+				Overlay: map[string]Source{
+					filepath.Join(dir, "foo.cue"): FromString(tc.in),
+				},
+				TagValues: tc.values,
+				TagVars:   testTagVars,
+			}
+			b := Instances([]string{"foo.cue"}, cfg)[0]
+
+			c := cuecontext.New()
+			got := c.BuildInstance(b)
+			switch err := got.Err(); {
+			case (err == nil) != (tc.err == ""):
+				t.Fatalf("error: got %v; want %v", err, tc.err)
+
+			case err != nil:
+				got := err.Error()
+				if got != tc.err {
+					t.Fatalf("error: got %v; want %v", got, tc.err)
+				}
+
+			default:
+				want := c.CompileString(tc.out)
+				if !got.Equals(want) {
+					_, es := diff.Diff(got, want)
+					b := &bytes.Buffer{}
+					diff.Print(b, es)
+					t.Error(b)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A Watcher reports the instances of a Loader that are invalidated by
+// changes to files on disk, so that they can be rebuilt.
+//
+// A Watcher only inspects the directories that may hold files of the
+// instances loaded by the Loader: the directory of each instance and its
+// parent directories up to the module root. Files are compared by size and
+// modification time.
+//
+// A Watcher is not safe for concurrent use, nor for use concurrently with
+// its Loader.
+type Watcher struct {
+	l *Loader
+
+	// dirs holds the state of the CUE files in each watched directory,
+	// by absolute directory and then file name.
+	dirs map[string]map[string]fileState
+
+	// modFile holds the state of the module file, if any.
+	modFile     string
+	modFileStat fileState
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewWatcher returns a Watcher for the instances loaded by l, including
+// those loaded before the call. A Loader has at most one Watcher.
+func NewWatcher(l *Loader) *Watcher {
+	w := &Watcher{
+		l:    l,
+		dirs: map[string]map[string]fileState{},
+	}
+	if l.cfg.modFile != nil {
+		w.modFile = filepath.Join(l.cfg.ModuleRoot, modDir, moduleFile)
+		w.modFileStat, _ = w.stat(w.modFile)
+	}
+	l.watcher = w
+	for _, a := range l.pkgs {
+		w.track(a...)
+	}
+	for _, p := range l.imports {
+		w.track(p)
+	}
+	return w
+}
+
+// Check reports the instances loaded by the Loader whose files changed
+// since the instances were loaded or since the previous call to Check,
+// together with the instances importing them, directly or indirectly.
+// These instances are invalidated as with Loader.Invalidate, so that the
+// next call to Load loads them anew. The instances are sorted by
+// directory.
+//
+// Check returns an error if the module file changed, as this requires a
+// new Loader.
+func (w *Watcher) Check() ([]*build.Instance, error) {
+	if w.modFile != "" {
+		s, err := w.stat(w.modFile)
+		if err != nil || s != w.modFileStat {
+			return nil, errors.Newf(token.NoPos,
+				"load: module file %s changed; a new Loader is needed", w.modFile)
+		}
+	}
+
+	var changed []string
+	dirs := make([]string, 0, len(w.dirs))
+	for dir := range w.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		old := w.dirs[dir]
+		cur := w.readDir(dir)
+		for name, s := range cur {
+			if o, ok := old[name]; !ok || o != s {
+				changed = append(changed, filepath.Join(dir, name))
+			}
+		}
+		for name := range old {
+			if _, ok := cur[name]; !ok {
+				changed = append(changed, filepath.Join(dir, name))
+			}
+		}
+		w.dirs[dir] = cur
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	return w.l.invalidate(changed), nil
+}
+
+// track starts watching the directories holding the files of the given
+// instances, if not watched already.
+func (w *Watcher) track(a ...*build.Instance) {
+	for _, p := range a {
+		for dir := p.Dir; dir != ""; {
+			if _, ok := w.dirs[dir]; !ok {
+				w.dirs[dir] = w.readDir(dir)
+			}
+			if dir == p.Root || p.Root == "" || !strings.HasPrefix(dir, p.Root) {
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+}
+
+// readDir returns the state of the CUE files in dir. A directory that
+// cannot be read is reported as empty, so that files in a removed
+// directory are reported as removed.
+func (w *Watcher) readDir(dir string) map[string]fileState {
+	m := map[string]fileState{}
+	items, err := w.l.cfg.fileSystem.readDir(dir)
+	if err != nil {
+		return m
+	}
+	for _, item := range items {
+		if item.IsDir() || !strings.HasSuffix(item.Name(), cueSuffix) {
+			continue
+		}
+		fi, err := item.Info()
+		if err != nil {
+			continue
+		}
+		m[item.Name()] = fileState{fi.Size(), fi.ModTime()}
+	}
+	return m
+}
+
+func (w *Watcher) stat(filename string) (fileState, error) {
+	fi, err := w.l.cfg.fileSystem.stat(filename)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{fi.Size(), fi.ModTime()}, nil
+}
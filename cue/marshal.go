@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/gob"
+	"fmt"
 	"path/filepath"
 	"strings"
 
@@ -28,6 +29,7 @@ import (
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/astcodec"
 	"cuelang.org/go/internal/core/export"
 )
 
@@ -219,3 +221,184 @@ func (r *Runtime) Marshal(instances ...*Instance) (b []byte, err error) {
 	return buf.Bytes(), nil
 
 }
+
+// instanceCacheVersion identifies the format written by
+// Context.MarshalInstances.
+const instanceCacheVersion = "cue-instances-v1/" + astcodec.Version
+
+// cachedInstance holds a build instance as stored by MarshalInstances.
+type cachedInstance struct {
+	ImportPath  string
+	DisplayPath string
+	PkgName     string
+	Module      string
+	ModuleRoot  string
+	Dir         string
+	Files       []cachedFile
+}
+
+// cachedFile holds either the encoded syntax of a file or, if the syntax
+// could not be encoded, its formatted source.
+type cachedFile struct {
+	Filename string
+	Syntax   []byte
+	Source   []byte
+}
+
+// MarshalInstances encodes the given build instances, along with all
+// non-builtin packages they import, into a byte slice. The result can be
+// passed to UnmarshalInstances, possibly in another process, to build the
+// same values without having to locate, read and parse the files of the
+// instances again.
+//
+// The encoding stores the parsed syntax, including comments, attributes and
+// position information. It is not stable across versions of CUE:
+// UnmarshalInstances reports an error for data that was written by a
+// different version, in which case the data should be regenerated.
+//
+// It is an error to marshal instances with errors.
+func (c *Context) MarshalInstances(instances []*build.Instance) ([]byte, error) {
+	var staged []*cachedInstance
+	done := map[*build.Instance]*cachedInstance{}
+	index := map[*cachedInstance]int{}
+
+	var stage func(i *build.Instance) (*cachedInstance, error)
+	stage = func(i *build.Instance) (*cachedInstance, error) {
+		if ci := done[i]; ci != nil {
+			return ci, nil
+		}
+		if i.Err != nil {
+			return nil, i.Err
+		}
+		ci := &cachedInstance{
+			ImportPath:  i.ImportPath,
+			DisplayPath: i.DisplayPath,
+			PkgName:     i.PkgName,
+			Module:      i.Module,
+			ModuleRoot:  i.Root,
+			Dir:         i.Dir,
+		}
+		done[i] = ci
+		for _, f := range i.Files {
+			cf := cachedFile{Filename: f.Filename}
+			if b, err := astcodec.EncodeStandalone(f); err == nil {
+				cf.Syntax = b
+			} else if cf.Source, err = format.Node(f); err != nil {
+				return nil, errors.Promote(err, "marshal")
+			}
+			ci.Files = append(ci.Files, cf)
+		}
+		// Imported instances precede the instances that import them.
+		for _, imp := range i.Imports {
+			if len(imp.Files) == 0 {
+				continue // a builtin package.
+			}
+			if _, err := stage(imp); err != nil {
+				return nil, err
+			}
+		}
+		index[ci] = len(staged)
+		staged = append(staged, ci)
+		return ci, nil
+	}
+
+	var roots []int
+	for _, i := range instances {
+		ci, err := stage(i)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, index[ci])
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	enc := gob.NewEncoder(zw)
+	if err := enc.Encode(instanceCacheVersion); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(staged); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(roots); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalInstances reconstructs the build instances encoded by
+// MarshalInstances and builds them in c. It returns the values for the
+// instances that were passed to MarshalInstances, in the same order.
+//
+// The syntax of the instances is restored without parsing, but it is
+// compiled and evaluated in c as with BuildInstances.
+func (c *Context) UnmarshalInstances(b []byte) ([]Value, error) {
+	fail := func(err error) ([]Value, error) {
+		return nil, errors.Newf(token.NoPos, "unmarshal failed: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return fail(err)
+	}
+	dec := gob.NewDecoder(zr)
+	var v string
+	if err := dec.Decode(&v); err != nil {
+		return fail(err)
+	}
+	if v != instanceCacheVersion {
+		return nil, errors.Newf(token.NoPos,
+			"unmarshal failed: unsupported version %q, regenerate data", v)
+	}
+	var data []*cachedInstance
+	var rootIndices []int
+	if err := dec.Decode(&data); err != nil {
+		return fail(err)
+	}
+	if err := dec.Decode(&rootIndices); err != nil {
+		return fail(err)
+	}
+
+	ctxt := build.NewContext()
+	byPath := map[string]*build.Instance{}
+	load := func(pos token.Pos, path string) *build.Instance {
+		return byPath[path]
+	}
+	built := make([]*build.Instance, len(data))
+	for i, ci := range data {
+		p := ctxt.NewInstance(ci.Dir, load)
+		p.ImportPath = ci.ImportPath
+		p.DisplayPath = ci.DisplayPath
+		p.PkgName = ci.PkgName
+		p.Module = ci.Module
+		p.Root = ci.ModuleRoot
+		for _, cf := range ci.Files {
+			if cf.Syntax == nil {
+				_ = p.AddFile(cf.Filename, cf.Source)
+				continue
+			}
+			f, err := astcodec.DecodeStandalone(cf.Filename, cf.Syntax)
+			if err != nil {
+				return fail(err)
+			}
+			_ = p.AddSyntax(f)
+		}
+		// Imports were staged before the instances that import them, so
+		// they are available when completing p.
+		_ = p.Complete()
+		if ci.ImportPath != "" {
+			byPath[ci.ImportPath] = p
+		}
+		built[i] = p
+	}
+	roots := make([]*build.Instance, len(rootIndices))
+	for i, x := range rootIndices {
+		if x < 0 || x >= len(built) {
+			return fail(fmt.Errorf("invalid instance index %d", x))
+		}
+		roots[i] = built[x]
+	}
+	return c.BuildInstances(roots)
+}
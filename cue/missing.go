@@ -0,0 +1,210 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/astinternal"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/walk"
+)
+
+// A MissingKind indicates why a value is not concrete.
+type MissingKind int
+
+const (
+	// MissingValue indicates a regular field that does not have a concrete
+	// value, such as a field that is only constrained by a type.
+	MissingValue MissingKind = iota + 1
+
+	// UnresolvedReference indicates a reference that cannot be resolved,
+	// such as a reference that selects a field that does not exist in an
+	// open struct.
+	UnresolvedReference
+)
+
+func (k MissingKind) String() string {
+	switch k {
+	case MissingValue:
+		return "missing value"
+	case UnresolvedReference:
+		return "unresolved reference"
+	}
+	return "unknown"
+}
+
+// A Missing describes a part of a value that prevents it from being
+// concrete.
+type Missing struct {
+	Kind MissingKind
+
+	// Path is the path of the field that is not concrete, relative to the
+	// root of its package.
+	Path Path
+
+	// Pos is the position of the field for a MissingValue and the position
+	// of the reference for an UnresolvedReference.
+	Pos token.Pos
+
+	// Reference is the reference as written in the source, for an
+	// UnresolvedReference.
+	Reference string
+
+	// Value is the value of the field, which describes the values that are
+	// allowed for a MissingValue.
+	Value Value
+}
+
+// Missing reports the inputs that are needed to make v concrete, in the
+// order of its fields. Only regular fields need to be concrete; optional
+// fields, definitions and hidden fields are not reported.
+//
+// A field that is not concrete only because it refers to other fields of v
+// that are not concrete, such as x in
+//
+//	x: y + 1
+//	y: int
+//
+// is not reported, as providing values for the fields it refers to makes it
+// concrete. Errors other than incomplete values are not reported; use
+// Validate to detect these.
+func (v Value) Missing() []Missing {
+	if v.v == nil {
+		return nil
+	}
+	m := &missing{
+		ctx:  v.ctx(),
+		root: v,
+	}
+	m.rootVertex, m.rootPath = mkPath(v.idx, nil, v.v)
+	m.visit(v)
+	return m.a
+}
+
+type missing struct {
+	ctx        *adt.OpContext
+	root       Value
+	rootVertex *adt.Vertex
+	rootPath   []Selector
+	a          []Missing
+}
+
+func (m *missing) visit(v Value) {
+	d, _ := v.Default()
+	if b, ok := d.v.BaseValue.(*adt.Bottom); ok {
+		if b.IsIncomplete() {
+			m.field(v)
+		}
+		return
+	}
+	if !isConcrete(d) {
+		m.field(v)
+		return
+	}
+	switch d.IncompleteKind() {
+	case StructKind:
+		iter, err := d.Fields()
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			m.visit(iter.Value())
+		}
+	case ListKind:
+		iter, err := d.List()
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			m.visit(iter.Value())
+		}
+	}
+}
+
+// field reports the non-concrete field v. It reports the references of v
+// that cannot be resolved or, if there are no such references, reports v
+// itself unless it refers to other fields that are reported.
+func (m *missing) field(v Value) {
+	_, path := mkPath(v.idx, nil, v.v)
+	n := len(m.a)
+	derived := false
+	for _, c := range v.v.Conjuncts {
+		env := c.Env
+		w := walk.Visitor{Before: func(n adt.Node) bool {
+			switch x := n.(type) {
+			case *adt.StructLit, *adt.ListLit, *adt.Comprehension:
+				// These have their own scope, which is not tracked here.
+				return false
+
+			case adt.Resolver:
+				m.ctx.Err() // clear errors
+				ref, _ := m.ctx.Resolve(adt.MakeConjunct(env, x, adt.CloseInfo{}), x)
+				m.ctx.Err()
+				if ref != nil {
+					if m.reported(ref) && !isConcrete(makeValue(m.root.idx, ref, nil)) {
+						derived = true
+					}
+					return false
+				}
+				m.a = append(m.a, Missing{
+					Kind:      UnresolvedReference,
+					Path:      Path{path: path},
+					Pos:       pos(x),
+					Reference: astinternal.DebugStr(x.Source()),
+					Value:     v,
+				})
+				return false
+			}
+			return true
+		}}
+		w.Elem(c.Elem())
+	}
+	if len(m.a) == n && !derived {
+		m.a = append(m.a, Missing{
+			Kind:  MissingValue,
+			Path:  Path{path: path},
+			Pos:   v.Pos(),
+			Value: v,
+		})
+	}
+}
+
+// reported reports whether x is a regular field within the inspected value
+// that is reported as missing itself.
+func (m *missing) reported(x *adt.Vertex) bool {
+	root, path := mkPath(m.root.idx, nil, x)
+	if root != m.rootVertex || len(path) <= len(m.rootPath) ||
+		!hasSelectorPrefix(path, m.rootPath) {
+		return false
+	}
+	for _, sel := range path[len(m.rootPath):] {
+		switch sel.Type() {
+		case StringLabel, IndexLabel:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isConcrete reports whether v or its default is concrete, where open lists
+// are considered to be concrete.
+func isConcrete(v Value) bool {
+	v, _ = v.Default()
+	if _, ok := v.v.BaseValue.(*adt.Bottom); ok {
+		return false
+	}
+	return v.IsConcrete() || v.IncompleteKind() == ListKind
+}
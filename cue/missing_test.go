@@ -0,0 +1,89 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestMissing(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+#Port: int & >0
+config: {
+	name:  string
+	port:  #Port
+	url:   "https://\(name):\(port)"
+	mode:  *"dev" | "prod"
+	level: "low" | "high"
+	tags: [...string]
+	opt?:   string
+	#def:   string
+	_hide:  int
+	limits: [{max: int}, {max: 3}]
+}
+env: {}
+region: env.region
+size:   other + 1
+other:  int
+copy:   config.name
+label:  "\(unknown)"
+unknown: _
+`, cue.Filename("in.cue"))
+	qt.Assert(t, qt.IsNil(v.Err()))
+
+	str := func(a []cue.Missing) (s []string) {
+		for _, m := range a {
+			x := fmt.Sprintf("%d:%d %v: %v", m.Pos.Line(), m.Pos.Column(), m.Path, m.Kind)
+			if m.Reference != "" {
+				x += " " + m.Reference
+			} else {
+				x += fmt.Sprintf(" (%v)", m.Value)
+			}
+			s = append(s, x)
+		}
+		return s
+	}
+
+	qt.Assert(t, qt.DeepEquals(str(v.Missing()), []string{
+		"4:2 config.name: missing value (string)",
+		"5:2 config.port: missing value (>0 & int)",
+		`8:2 config.level: missing value ("low" | "high")`,
+		"13:12 config.limits[0].max: missing value (int)",
+		"16:9 region: unresolved reference env.region",
+		"18:1 other: missing value (int)",
+		"21:1 unknown: missing value (_)",
+	}))
+
+	config := v.LookupPath(cue.ParsePath("config"))
+	qt.Assert(t, qt.DeepEquals(str(config.Missing()), []string{
+		"4:2 config.name: missing value (string)",
+		"5:2 config.port: missing value (>0 & int)",
+		`8:2 config.level: missing value ("low" | "high")`,
+		"13:12 config.limits[0].max: missing value (int)",
+	}))
+
+	copy := v.LookupPath(cue.ParsePath("copy"))
+	qt.Assert(t, qt.DeepEquals(str(copy.Missing()), []string{
+		"19:1 copy: missing value (string)",
+	}))
+
+	qt.Assert(t, qt.HasLen(v.LookupPath(cue.ParsePath("config.mode")).Missing(), 0))
+}
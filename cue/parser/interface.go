@@ -74,6 +74,17 @@ var (
 		p.mode |= allErrorsMode
 	}
 
+	// ErrorRecovery causes the parser to recover from syntax errors and
+	// return a best-effort AST for the entire source, along with all errors.
+	// Erroneous source fragments are represented by ast.BadExpr and
+	// ast.BadDecl nodes, and parsing continues with the next declaration,
+	// which allows tools such as editors to work with source that is being
+	// edited. It implies AllErrors.
+	ErrorRecovery Option = errorRecovery
+	errorRecovery        = func(p *parser) {
+		p.mode |= errorRecoveryMode | allErrorsMode
+	}
+
 	// AllowPartial allows the parser to be used on a prefix buffer.
 	AllowPartial Option = allowPartial
 	allowPartial        = func(p *parser) {
@@ -136,6 +147,7 @@ const (
 	traceMode             // print a trace of parsed productions
 	declarationErrorsMode // report declaration errors
 	allErrorsMode         // report all errors (not just the first 10 on different lines)
+	errorRecoveryMode     // return a partial AST for erroneous source
 )
 
 // ParseFile parses the source code of a single CUE source file and returns
@@ -155,7 +167,8 @@ const (
 // indicates the specific failure. If the source was read but syntax
 // errors were found, the result is a partial AST (with Bad* nodes
 // representing the fragments of erroneous source code). Multiple errors
-// are returned via a ErrorList which is sorted by file position.
+// are returned via a ErrorList which is sorted by file position. With the
+// ErrorRecovery option, the partial AST covers the entire source.
 func ParseFile(filename string, src interface{}, mode ...Option) (f *ast.File, err error) {
 
 	// get source
@@ -231,7 +244,7 @@ func ParseExpr(filename string, src interface{}, mode ...Option) (ast.Expr, erro
 		p.expect(token.EOF)
 	}
 
-	if p.errors != nil {
+	if p.errors != nil && p.mode&errorRecoveryMode == 0 {
 		return nil, p.errors
 	}
 	astutil.ResolveExpr(e, p.errf)
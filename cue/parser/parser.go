@@ -451,6 +451,9 @@ func (p *parser) expect(tok token.Token) token.Pos {
 	pos := p.pos
 	if p.tok != tok {
 		p.errorExpected(pos, "'"+tok.String()+"'")
+		if p.leaveClosingBrace() {
+			return pos
+		}
 	}
 	p.next() // make progress
 	return pos
@@ -488,6 +491,9 @@ func (p *parser) atComma(context string, follow ...token.Token) bool {
 			return false
 		}
 	}
+	if p.tok == token.RBRACE && p.mode&errorRecoveryMode != 0 {
+		return false // unclosed list; reported by the caller
+	}
 	// TODO: find a way to detect crossing lines now we don't have a semi.
 	if p.lit == "\n" {
 		p.errf(p.pos, "missing ',' before newline")
@@ -497,11 +503,49 @@ func (p *parser) atComma(context string, follow ...token.Token) bool {
 	return true // "insert" comma and continue
 }
 
+// recoverAt reports whether the parser recovers from errors and the current
+// token may follow a complete expression, in which case it should not be
+// skipped so that the remainder of the enclosing construct can be parsed.
+func (p *parser) recoverAt() bool {
+	if p.mode&errorRecoveryMode == 0 {
+		return false
+	}
+	switch p.tok {
+	case token.COMMA, token.COLON, token.RBRACE, token.RBRACK, token.RPAREN, token.EOF:
+		return true
+	}
+	return false
+}
+
+// leaveClosingBrace reports whether the parser recovers from errors and the
+// closing brace at the current position should be left to the enclosing
+// struct. Like syncExpr, it gives up after a number of calls without
+// progress to guarantee that parsing terminates.
+func (p *parser) leaveClosingBrace() bool {
+	if p.mode&errorRecoveryMode == 0 || p.tok != token.RBRACE {
+		return false
+	}
+	if p.pos == p.syncPos && p.syncCnt < 10 {
+		p.syncCnt++
+		return true
+	}
+	if !p.syncPos.IsValid() || p.syncPos.Before(p.pos) {
+		p.syncPos = p.pos
+		p.syncCnt = 0
+		return true
+	}
+	return false
+}
+
 // syncExpr advances to the next field in a field list.
 // Used for synchronization after an error.
 func syncExpr(p *parser) {
 	for {
 		switch p.tok {
+		case token.RBRACE:
+			if p.leaveClosingBrace() {
+				return
+			}
 		case token.COMMA:
 			// Return only if parser made some progress since last
 			// sync or if it has not reached 10 sync calls without
@@ -514,7 +558,8 @@ func syncExpr(p *parser) {
 				p.syncCnt++
 				return
 			}
-			if p.syncPos.Before(p.pos) {
+			if p.syncPos.Before(p.pos) ||
+				p.mode&errorRecoveryMode != 0 && !p.syncPos.IsValid() {
 				p.syncPos = p.pos
 				p.syncCnt = 0
 				return
@@ -711,6 +756,9 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) (expr *ast.CallExpr) {
 	p.exprLev++
 	var list []ast.Expr
 	for p.tok != token.RPAREN && p.tok != token.EOF {
+		if p.tok == token.RBRACE && p.mode&errorRecoveryMode != 0 {
+			break // unclosed argument list
+		}
 		list = append(list, p.parseRHS()) // builtins may expect a type: make(some type, ...)
 		if !p.atComma("argument list", token.RPAREN) {
 			break
@@ -1262,6 +1310,9 @@ func (p *parser) parseListElements() (list []ast.Expr) {
 	defer p.closeList()
 
 	for p.tok != token.RBRACK && p.tok != token.ELLIPSIS && p.tok != token.EOF {
+		if p.tok == token.RBRACE && p.mode&errorRecoveryMode != 0 {
+			break // unclosed list
+		}
 		expr, ok := p.parseListElement()
 		list = append(list, expr)
 		if !ok {
@@ -1429,7 +1480,9 @@ L:
 			default:
 				pos := p.pos
 				p.errorExpected(pos, "selector")
-				p.next() // make progress
+				if !p.recoverAt() {
+					p.next() // make progress
+				}
 				x = &ast.SelectorExpr{X: x, Sel: &ast.Ident{NamePos: pos, Name: "_"}}
 			}
 			c.closeNode(p, x)
@@ -1673,7 +1726,7 @@ func (p *parser) parseFile() *ast.File {
 
 	// Don't bother parsing the rest if we had errors scanning the first
 	// Likely not a Go source file at all.
-	if p.errors != nil {
+	if p.errors != nil && p.mode&errorRecoveryMode == 0 {
 		return nil
 	}
 	p.openList()
@@ -1722,6 +1775,17 @@ func (p *parser) parseFile() *ast.File {
 			// rest of package decls
 			// TODO: loop and allow multiple expressions.
 			decls = append(decls, p.parseFieldList()...)
+			for p.mode&errorRecoveryMode != 0 && p.tok == token.RBRACE {
+				// Skip unmatched closing braces and continue with the
+				// declarations that follow them.
+				pos := p.pos
+				p.errorExpected(pos, "'EOF'")
+				for p.tok == token.RBRACE || p.tok == token.COMMA {
+					p.next()
+				}
+				decls = append(decls, &ast.BadDecl{From: pos, To: p.pos})
+				decls = append(decls, p.parseFieldList()...)
+			}
 			p.expect(token.EOF)
 		}
 	}
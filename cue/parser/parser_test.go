@@ -868,6 +868,80 @@ func TestIncompleteSelection(t *testing.T) {
 	}
 }
 
+func TestErrorRecovery(t *testing.T) {
+	testCases := []struct{ desc, in, out, err string }{{
+		"unmatched closing braces",
+		"a: 1\n}}\nb: 2",
+		"a: 1, <*ast.BadDecl>, b: 2",
+		"expected 'EOF', found '}'",
+	}, {
+		"missing value in struct",
+		"a: {b: }\nc: 2",
+		"a: {b: <*ast.BadExpr>}, c: 2",
+		"expected operand, found '}'",
+	}, {
+		"invalid value in struct",
+		"a: {b: )}\nc: 2",
+		"a: {b: <*ast.BadExpr>}, c: 2",
+		"expected operand, found ')'",
+	}, {
+		"unclosed list",
+		"a: {b: [1, 2 }\nc: 2",
+		"a: {b: [1, 2]}, c: 2",
+		"expected ']', found '}'",
+	}, {
+		"unclosed argument list",
+		"a: {b: len(1 }\nc: 2",
+		"a: {b: len(1)}, c: 2",
+		"expected ')', found '}'",
+	}, {
+		"missing selector",
+		"a: b.: 1\nc: 2",
+		"a: b._, 1, c: 2",
+		"expected selector, found ':'",
+	}, {
+		"unclosed struct",
+		"a: {\n\tb: 1\n\tc: x.\n",
+		"a: {b: 1, c: x._}",
+		"expected '}', found 'EOF'",
+	}, {
+		"errors in many fields",
+		"a: )\nb: )\nc: )\nd: )\ne: )\nf: )\ng: )\nh: )\ni: )\nj: )\nk: )\nl: )\nm: 1",
+		"a: <*ast.BadExpr>, b: <*ast.BadExpr>, c: <*ast.BadExpr>, d: <*ast.BadExpr>, e: <*ast.BadExpr>, f: <*ast.BadExpr>, g: <*ast.BadExpr>, h: <*ast.BadExpr>, i: <*ast.BadExpr>, j: <*ast.BadExpr>, k: <*ast.BadExpr>, l: <*ast.BadExpr>, m: 1",
+		"expected operand, found ')' (and 11 more errors)",
+	}, {
+		"scan error at start",
+		"\"\\z\": 1\nb: 2",
+		"\"\\z\": 1, b: 2",
+		"unknown escape sequence",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f, err := ParseFile("input", tc.in, ErrorRecovery)
+			if err == nil {
+				t.Fatalf("unexpected success: %v", tc.in)
+			}
+			if got := err.Error(); !strings.Contains(got, tc.err) {
+				t.Errorf("got error %q; want %q", got, tc.err)
+			}
+			if got := debugStr(f); got != tc.out {
+				t.Errorf("\ngot  %q;\nwant %q", got, tc.out)
+			}
+		})
+	}
+}
+
+func TestErrorRecoveryExpr(t *testing.T) {
+	x, err := ParseExpr("input", "{a: ), b: 1}", ErrorRecovery)
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	const want = "{a: <*ast.BadExpr>, b: 1}"
+	if got := debugStr(x); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
 // For debugging, do not delete.
 func TestX(t *testing.T) {
 	t.Skip()
@@ -0,0 +1,110 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"cuelang.org/go/internal/core/adt"
+)
+
+// A Pattern is a pattern constraint of the form
+//
+//	[pattern]: value
+//
+// that applies to all fields of a struct with a label matching pattern.
+type Pattern struct {
+	// Pattern is the value against which labels are matched, such as
+	// string or =~"^env_".
+	Pattern Value
+
+	// Value is the constraint that applies to matching fields.
+	Value Value
+}
+
+// Patterns reports the pattern constraints of struct v in the order in
+// which they were declared. Each pattern constraint is reported
+// once, even if it was unified into v multiple times.
+//
+// A pattern constraint may refer to the label of the field it applies to,
+// as in [X=string]: {name: X}. As the label is not known, such references
+// evaluate to string. Use MatchPatterns to obtain the constraints for a
+// specific label.
+func (v Value) Patterns() []Pattern {
+	return v.patterns(adt.AnyString)
+}
+
+// MatchPatterns reports the pattern constraints of struct v that apply to a
+// field with the label denoted by sel, regardless of whether v has such a
+// field. The Value of each constraint is evaluated for this label. Only
+// regular fields can match pattern constraints.
+func (v Value) MatchPatterns(sel Selector) []Pattern {
+	if v.v == nil {
+		return nil
+	}
+	f := sel.sel.feature(v.idx)
+	if !f.IsRegular() {
+		return nil
+	}
+	return v.patterns(f)
+}
+
+// patterns returns the pattern constraints of v that match f, or all pattern
+// constraints if f is adt.AnyString.
+func (v Value) patterns(f adt.Feature) []Pattern {
+	if v.v == nil {
+		return nil
+	}
+	ctx := v.ctx()
+
+	var a []Pattern
+	seen := map[*adt.BulkOptionalField]bool{}
+	for _, s := range v.v.Structs {
+		if s.Disable {
+			continue
+		}
+		for _, b := range s.Bulk {
+			if seen[b] {
+				continue
+			}
+			if f != adt.AnyString && !adt.MatchBulk(ctx, s.Env, b, f) {
+				continue
+			}
+			seen[b] = true
+
+			env := *s.Env
+			if f != adt.AnyString {
+				env.DynamicLabel = f
+			}
+
+			closeInfo := s.CloseInfo
+			closeInfo.IsClosed = false
+			info := closeInfo.SpawnSpan(b.Value, adt.ConstraintSpan)
+
+			x := &adt.Vertex{Parent: v.v, Label: f}
+			if f == adt.AnyString {
+				// A field with this label is not allowed in closed structs.
+				// Evaluate the constraint on its own instead.
+				x.Parent = nil
+			}
+			x.AddConjunct(adt.MakeConjunct(&env, b, info))
+			x.Finalize(ctx)
+
+			a = append(a, Pattern{
+				Pattern: remakeValue(v, s.Env, b.Filter),
+				Value:   makeChildValue(v, x),
+			})
+		}
+	}
+	return a
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestPatterns(t *testing.T) {
+	ctx := cuecontext.New()
+	v := ctx.CompileString(`
+	#Env: {
+		[=~"^env_"]: string
+		[X=string]: {name: X} | string
+		a: "x"
+	}
+	x: #Env
+	x: #Env
+	`)
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+	x := v.LookupPath(cue.ParsePath("x"))
+
+	str := func(a []cue.Pattern) string {
+		var b []string
+		for _, p := range a {
+			b = append(b, fmt.Sprintf("[%v]: %v", p.Pattern, p.Value))
+		}
+		return strings.Join(b, "\n")
+	}
+
+	// The pattern constraints of #Env are reported only once.
+	if got, want := str(x.Patterns()), `[=~"^env_"]: string
+[string]: {
+	name: string
+} | string`; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	testCases := []struct {
+		sel  cue.Selector
+		want string
+	}{{
+		sel: cue.Str("env_home"),
+		want: `[=~"^env_"]: string
+[string]: {
+	name: "env_home"
+} | string`,
+	}, {
+		sel: cue.Str("foo"),
+		want: `[string]: {
+	name: "foo"
+} | string`,
+	}, {
+		sel:  cue.Def("#foo"),
+		want: ``,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.sel.String(), func(t *testing.T) {
+			got := str(x.MatchPatterns(tc.sel))
+			if got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
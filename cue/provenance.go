@@ -0,0 +1,67 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"cuelang.org/go/cue/token"
+)
+
+// An Origin describes a single declaration that contributed to a value.
+type Origin struct {
+	// Pos is the position of the contributing expression. It is
+	// token.NoPos for values that were not derived from source, for instance
+	// those added with FillPath.
+	Pos token.Pos
+
+	// Value is the value of the contributing expression on its own, that is,
+	// without unifying it with the other declarations for the same field.
+	Value Value
+
+	// Final reports whether this declaration by itself evaluates to the
+	// final concrete value of the field. More than one declaration may
+	// supply the final value. Final is always false for structs and lists,
+	// as their values are composed from the declarations of their fields.
+	Final bool
+}
+
+// Provenance reports the declarations that were unified to obtain v, in the
+// order in which they were unified. It is intended to answer the question of
+// where a value came from when debugging layered configurations.
+//
+// Each field declaration, embedding, and value filled in programmatically
+// counts as a separate declaration. A declaration that is a reference is
+// reported at the position of the reference; use ReferencePath on the
+// reported Value to find the referenced value and its provenance in turn.
+func (v Value) Provenance() []Origin {
+	if v.v == nil {
+		return nil
+	}
+	final, _ := v.Default()
+	isScalar := final.IsConcrete() && final.Kind()&(StructKind|ListKind) == 0
+
+	var a []Origin
+	for _, c := range v.v.Conjuncts {
+		o := Origin{
+			Pos:   pos(c.Elem()),
+			Value: remakeValue(v, c.Env, c.Expr()),
+		}
+		if isScalar {
+			w, _ := o.Value.Default()
+			o.Final = w.IsConcrete() && w.Equals(final)
+		}
+		a = append(a, o)
+	}
+	return a
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestProvenance(t *testing.T) {
+	const src = `
+#Base: {
+	replicas: int & >0
+	port:     *80 | int
+	name:     string
+}
+defaults: replicas: <10
+app: #Base & defaults
+app: replicas: 3
+app: name: "web"
+ref: app.replicas
+`
+	testCases := []struct {
+		path string
+		want string
+	}{{
+		path: "app.replicas",
+		want: `
+3:12 >0 & int final=false
+7:21 <10 final=false
+9:16 3 final=true`,
+	}, {
+		path: "app.port",
+		want: `
+4:12 *80 | int final=true`,
+	}, {
+		path: "app.name",
+		want: `
+5:12 string final=false
+10:12 "web" final=true`,
+	}, {
+		path: "ref",
+		want: `
+11:6 3 final=true`,
+	}, {
+		path: "app",
+		want: `
+8:6 { replicas: uint & >0 & <10 port: *80 | int name: string } final=false
+9:6 { replicas: 3 } final=false
+10:6 { name: "web" } final=false`,
+	}}
+	v := cuecontext.New().CompileString(src, cue.Filename("in.cue"))
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			w := v.LookupPath(cue.ParsePath(tc.path))
+			if err := w.Err(); err != nil {
+				t.Fatal(err)
+			}
+			b := &strings.Builder{}
+			for _, o := range w.Provenance() {
+				str := strings.Join(strings.Fields(fmt.Sprint(o.Value)), " ")
+				fmt.Fprintf(b, "\n%d:%d %s final=%v",
+					o.Pos.Line(), o.Pos.Column(), str, o.Final)
+			}
+			if got := b.String(); got != tc.want {
+				t.Errorf("got:%s\nwant:%s", got, tc.want)
+			}
+		})
+	}
+}
@@ -15,6 +15,11 @@
 package cue
 
 import (
+	"strings"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/core/adt"
 )
 
@@ -85,3 +90,259 @@ outer:
 	}
 	return makeValue(v.idx, n, parent)
 }
+
+// A Query selects values that match a path pattern. In addition to the
+// selectors allowed in a Path, a Query may contain
+//
+//	[*]      any regular field or list element; may also be written as *
+//	..x      x at any depth, including the value itself
+//	[?expr]  any regular field or list element that is a struct for which
+//	         the CUE expression expr evaluates to true; identifiers in expr
+//	         refer to the fields of that struct
+//
+// For example, `spec.containers[*].image` selects the image of each container
+// and `..containers[?name == "app"].image` selects the image of each container
+// named app at any depth.
+type Query struct {
+	src   string
+	elems []queryElem
+	err   errors.Error
+}
+
+type queryKind int
+
+const (
+	querySelector queryKind = iota
+	queryWildcard
+	queryFilter
+)
+
+type queryElem struct {
+	kind      queryKind
+	recursive bool // match at any depth
+
+	sel    Selector // for querySelector
+	filter string   // for queryFilter
+}
+
+// ParseQuery parses a query. Any errors are reported by the Err method of
+// the result.
+func ParseQuery(s string) Query {
+	q := Query{src: s}
+	for i := 0; i < len(s); {
+		e := queryElem{}
+		switch {
+		case strings.HasPrefix(s[i:], ".."):
+			e.recursive = true
+			i += 2
+		case i > 0 && s[i] == '.':
+			i++
+		case i == 0 || s[i] == '[':
+		default:
+			return q.fail("unexpected %q at offset %d", s[i], i)
+		}
+
+		if i < len(s) && s[i] == '[' {
+			j := queryBracketEnd(s, i)
+			if j < 0 {
+				return q.fail("unterminated '[' at offset %d", i)
+			}
+			if err := e.parseBracket(s[i+1 : j]); err != nil {
+				return q.fail("%v", err)
+			}
+			i = j + 1
+		} else {
+			j := queryLabelEnd(s, i)
+			if j == i {
+				return q.fail("missing label at offset %d", i)
+			}
+			if err := e.parseLabel(s[i:j]); err != nil {
+				return q.fail("%v", err)
+			}
+			i = j
+		}
+		q.elems = append(q.elems, e)
+	}
+	return q
+}
+
+func (q Query) fail(format string, args ...interface{}) Query {
+	q.elems = nil
+	q.err = errors.Newf(token.NoPos, "invalid query %q: "+format,
+		append([]interface{}{q.src}, args...)...)
+	return q
+}
+
+// Err reports errors that occurred when parsing the query.
+func (q Query) Err() error {
+	if q.err == nil {
+		return nil
+	}
+	return q.err
+}
+
+// String reports the query as it was passed to ParseQuery.
+func (q Query) String() string {
+	return q.src
+}
+
+func (e *queryElem) parseLabel(s string) error {
+	if s == "*" {
+		e.kind = queryWildcard
+		return nil
+	}
+	p := ParsePath(s)
+	if err := p.Err(); err != nil {
+		return err
+	}
+	if len(p.path) != 1 {
+		return errors.Newf(token.NoPos, "invalid label %s", s)
+	}
+	e.sel = p.path[0]
+	return nil
+}
+
+func (e *queryElem) parseBracket(s string) error {
+	switch {
+	case s == "*":
+		e.kind = queryWildcard
+
+	case strings.HasPrefix(s, "?"):
+		e.kind = queryFilter
+		e.filter = strings.TrimSpace(s[1:])
+		if _, err := parser.ParseExpr("filter", e.filter); err != nil {
+			return err
+		}
+
+	default:
+		// Parse as an index expression and use its only selector.
+		p := ParsePath("x[" + s + "]")
+		if err := p.Err(); err != nil {
+			return err
+		}
+		if len(p.path) != 2 {
+			return errors.Newf(token.NoPos, "invalid index [%s]", s)
+		}
+		e.sel = p.path[1]
+	}
+	return nil
+}
+
+// queryLabelEnd returns the offset of the first '.' or '[' at or after i
+// that is not part of a quoted string.
+func queryLabelEnd(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '.', '[':
+			return i
+		case '"':
+			i = queryQuoteEnd(s, i)
+		}
+	}
+	return len(s)
+}
+
+// queryBracketEnd returns the offset of the ']' matching the '[' at i, or -1
+// if there is none.
+func queryBracketEnd(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '"':
+			i = queryQuoteEnd(s, i)
+		}
+	}
+	return -1
+}
+
+// queryQuoteEnd returns the offset of the closing quote for the quote at i.
+func queryQuoteEnd(s string, i int) int {
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// LookupAll reports all values relative to v that match q, in the order in
+// which they appear. The Path method of each result reports its concrete
+// path.
+func (v Value) LookupAll(q Query) ([]Value, error) {
+	if err := q.Err(); err != nil {
+		return nil, err
+	}
+	a := []Value{v}
+	for _, e := range q.elems {
+		var next []Value
+		for _, x := range a {
+			if e.recursive {
+				next = e.matchAll(x, next)
+			} else {
+				next = e.match(x, next)
+			}
+		}
+		a = next
+	}
+	return a, nil
+}
+
+// matchAll appends the matches of e for x and all its descendants to a.
+func (e *queryElem) matchAll(x Value, a []Value) []Value {
+	a = e.match(x, a)
+	for _, c := range queryChildren(x) {
+		a = e.matchAll(c, a)
+	}
+	return a
+}
+
+// match appends the matches of e for x to a.
+func (e *queryElem) match(x Value, a []Value) []Value {
+	switch e.kind {
+	case querySelector:
+		if y := x.LookupPath(MakePath(e.sel)); y.Exists() {
+			a = append(a, y)
+		}
+
+	case queryWildcard:
+		a = append(a, queryChildren(x)...)
+
+	case queryFilter:
+		for _, c := range queryChildren(x) {
+			if c.IncompleteKind() != StructKind {
+				continue
+			}
+			r := c.Context().CompileString(e.filter, Scope(c), InferBuiltins(true))
+			if b, err := r.Bool(); err == nil && b {
+				a = append(a, c)
+			}
+		}
+	}
+	return a
+}
+
+// queryChildren returns the regular fields or list elements of x.
+func queryChildren(x Value) (a []Value) {
+	var iter *Iterator
+	switch x.IncompleteKind() {
+	case StructKind:
+		iter, _ = x.Fields()
+	case ListKind:
+		i, _ := x.List()
+		iter = &i
+	}
+	for iter != nil && iter.Next() {
+		a = append(a, iter.Value())
+	}
+	return a
+}
@@ -16,6 +16,8 @@ package cue_test
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 
 	"cuelang.org/go/cue"
@@ -134,6 +136,87 @@ func TestLookupPath(t *testing.T) {
 	}
 }
 
+func TestLookupAll(t *testing.T) {
+	const in = `
+	spec: {
+		containers: [{
+			name:  "app"
+			image: "app:v1"
+			port:  8080
+		}, {
+			name:  "sidecar"
+			image: "proxy:v2"
+		}]
+		init: containers: [{name: "setup", image: "busybox"}]
+	}
+	"a.b": {image: "quoted"}
+	#Def: image: string
+	`
+	testCases := []struct {
+		query string
+		out   string
+		err   string
+	}{{
+		query: "spec.containers[*].image",
+		out:   `spec.containers[0].image: "app:v1"; spec.containers[1].image: "proxy:v2"`,
+	}, {
+		query: "spec.containers.*.name",
+		out:   `spec.containers[0].name: "app"; spec.containers[1].name: "sidecar"`,
+	}, {
+		query: "spec.containers[1].image",
+		out:   `spec.containers[1].image: "proxy:v2"`,
+	}, {
+		query: "..image",
+		out: `spec.containers[0].image: "app:v1"; spec.containers[1].image: "proxy:v2"; ` +
+			`spec.init.containers[0].image: "busybox"; "a.b".image: "quoted"`,
+	}, {
+		query: `..containers[?name == "app" || port > 0].image`,
+		out:   `spec.containers[0].image: "app:v1"`,
+	}, {
+		query: `spec..[?strings.HasPrefix(image, "b")].name`,
+		out:   `spec.init.containers[0].name: "setup"`,
+	}, {
+		query: `"a.b".image`,
+		out:   `"a.b".image: "quoted"`,
+	}, {
+		query: `["a.b"].image`,
+		out:   `"a.b".image: "quoted"`,
+	}, {
+		query: "#Def.image",
+		out:   `#Def.image: string`,
+	}, {
+		query: "spec.missing[*]",
+	}, {
+		query: "spec.containers[",
+		err:   `invalid query "spec.containers[": unterminated '[' at offset 15`,
+	}, {
+		query: "spec.",
+		err:   `invalid query "spec.": missing label at offset 5`,
+	}, {
+		query: "spec[?name ==]",
+		err:   `invalid query "spec[?name ==]": expected operand, found 'EOF'`,
+	}}
+	v := cuecontext.New().CompileString(in)
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			a, err := v.LookupAll(cue.ParseQuery(tc.query))
+			if err != nil || tc.err != "" {
+				if got := fmt.Sprint(err); got != tc.err {
+					t.Fatalf("error: got %v; want %v", got, tc.err)
+				}
+				return
+			}
+			var out []string
+			for _, x := range a {
+				out = append(out, fmt.Sprintf("%v: %v", x.Path(), x))
+			}
+			if got := strings.Join(out, "; "); got != tc.out {
+				t.Errorf("got  %s\nwant %s", got, tc.out)
+			}
+		})
+	}
+}
+
 func compileT(t *testing.T, r *cue.Runtime, s string) cue.Value {
 	t.Helper()
 	inst, err := r.Compile("", s)
@@ -0,0 +1,382 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quick
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+)
+
+// maxAttempts is the number of values that are generated for a schema
+// before giving up on finding one that is an instance of it.
+const maxAttempts = 100
+
+type generator struct {
+	ctx      *cue.Context
+	r        *rand.Rand
+	maxSize  int
+	maxDepth int
+
+	top cue.Value // _
+}
+
+func newGenerator(schema cue.Value, c *Config, r *rand.Rand) *generator {
+	return &generator{
+		ctx:      schema.Context(),
+		r:        r,
+		maxSize:  c.maxSize(),
+		maxDepth: c.maxDepth(),
+		top:      schema.Context().CompileString("_"),
+	}
+}
+
+// generate returns a concrete value that is an instance of schema. The value
+// holds data only, without any of the constraints of schema.
+func (g *generator) generate(schema cue.Value) (cue.Value, error) {
+	if err := schema.Err(); err != nil {
+		return cue.Value{}, err
+	}
+	var err error
+	for i := 0; i < maxAttempts; i++ {
+		var v cue.Value
+		v, err = data(g.value(schema, 0))
+		if err != nil {
+			continue
+		}
+		if err = instanceOf(schema, v); err == nil {
+			return v, nil
+		}
+	}
+	return cue.Value{}, fmt.Errorf("cannot generate instance of schema: %v", err)
+}
+
+// data returns the data of a concrete value v, dropping all its constraints.
+func data(v cue.Value) (cue.Value, error) {
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return cue.Value{}, err
+	}
+	expr, ok := v.Syntax(cue.Final(), cue.Concrete(true)).(ast.Expr)
+	if !ok {
+		return cue.Value{}, fmt.Errorf("unexpected syntax for %v", v)
+	}
+	d := v.Context().BuildExpr(expr)
+	return d, d.Err()
+}
+
+// instanceOf reports why the data v is not an instance of schema, if it is
+// not. The schema may not add any data to v, which would make v a different
+// value than the one that consumers of the schema see.
+func instanceOf(schema, v cue.Value) error {
+	d, err := data(schema.Unify(v))
+	if err != nil {
+		return err
+	}
+	if !d.Equals(v) {
+		return fmt.Errorf("value %v is incomplete", v)
+	}
+	return nil
+}
+
+// value returns v unified with random values for all its non-concrete parts.
+// The result may not be concrete or may be an error if that failed.
+func (g *generator) value(v cue.Value, depth int) cue.Value {
+	if v.Err() != nil {
+		return v
+	}
+	if op, args := v.Expr(); op == cue.OrOp {
+		if d, ok := v.Default(); ok && g.r.Intn(2) == 0 {
+			return g.value(d, depth)
+		}
+		// The chosen disjunct is unified with v later, when the value as a
+		// whole is checked against the schema.
+		return g.value(args[g.r.Intn(len(args))], depth)
+	}
+
+	switch kind := g.kind(v.IncompleteKind(), depth); kind {
+	case cue.NullKind:
+		return v.Unify(g.ctx.Encode(nil))
+	case cue.BoolKind:
+		return v.Unify(g.ctx.Encode(g.r.Intn(2) == 0))
+	case cue.IntKind:
+		if v.IsConcrete() {
+			return v
+		}
+		return v.Unify(g.ctx.Encode(g.int(v)))
+	case cue.FloatKind:
+		if v.IsConcrete() {
+			return v
+		}
+		return v.Unify(g.ctx.Encode(g.float(v)))
+	case cue.StringKind:
+		if v.IsConcrete() {
+			return v
+		}
+		return v.Unify(g.ctx.Encode(g.string(v)))
+	case cue.BytesKind:
+		if v.IsConcrete() {
+			return v
+		}
+		b := make([]byte, g.r.Intn(g.maxSize+1))
+		g.r.Read(b)
+		return v.Unify(g.ctx.Encode(b))
+	case cue.ListKind:
+		return g.list(v, depth)
+	case cue.StructKind:
+		return g.structValue(v, depth)
+	}
+	return v
+}
+
+// kind chooses a random kind among those of k, avoiding lists and structs
+// beyond the maximum depth if possible.
+func (g *generator) kind(k cue.Kind, depth int) cue.Kind {
+	if k == cue.TopKind {
+		// Any value will do: restrict ourselves to scalars.
+		k = cue.NullKind | cue.BoolKind | cue.NumberKind | cue.StringKind
+	}
+	if depth >= g.maxDepth && k&^(cue.ListKind|cue.StructKind) != 0 {
+		k &^= cue.ListKind | cue.StructKind
+	}
+	var kinds []cue.Kind
+	for _, x := range []cue.Kind{
+		cue.NullKind, cue.BoolKind, cue.IntKind, cue.FloatKind,
+		cue.StringKind, cue.BytesKind, cue.ListKind, cue.StructKind,
+	} {
+		if k&x != 0 {
+			kinds = append(kinds, x)
+		}
+	}
+	if len(kinds) == 0 {
+		return cue.BottomKind
+	}
+	return kinds[g.r.Intn(len(kinds))]
+}
+
+// constraints calls f for all conjuncts of v.
+func constraints(v cue.Value, f func(op cue.Op, arg cue.Value)) {
+	op, args := v.Expr()
+	switch {
+	case op == cue.AndOp:
+		for _, a := range args {
+			constraints(a, f)
+		}
+	case len(args) == 1:
+		f(op, args[0])
+	}
+}
+
+// int returns a random integer within the bounds of v.
+func (g *generator) int(v cue.Value) *big.Int {
+	var lo, hi *big.Int
+	constraints(v, func(op cue.Op, arg cue.Value) {
+		var b *big.Int
+		switch arg.Kind() {
+		case cue.IntKind:
+			b, _ = arg.Int(nil)
+		case cue.FloatKind:
+			f, _ := arg.Float64()
+			switch op {
+			case cue.GreaterThanOp, cue.GreaterThanEqualOp:
+				f = math.Ceil(f)
+			default:
+				f = math.Floor(f)
+			}
+			b, _ = big.NewFloat(f).Int(nil)
+		default:
+			return
+		}
+		switch op {
+		case cue.GreaterThanOp:
+			b.Add(b, big.NewInt(1))
+			fallthrough
+		case cue.GreaterThanEqualOp:
+			if lo == nil || b.Cmp(lo) > 0 {
+				lo = b
+			}
+		case cue.LessThanOp:
+			b.Sub(b, big.NewInt(1))
+			fallthrough
+		case cue.LessThanEqualOp:
+			if hi == nil || b.Cmp(hi) < 0 {
+				hi = b
+			}
+		}
+	})
+
+	switch {
+	case lo != nil && hi != nil:
+		if lo.Cmp(hi) > 0 {
+			return lo
+		}
+		span := new(big.Int).Sub(hi, lo)
+		n := new(big.Int).Rand(g.r, span.Add(span, big.NewInt(1)))
+		return n.Add(n, lo)
+	}
+	n := g.magnitude()
+	switch {
+	case lo != nil:
+		return n.Add(lo, n)
+	case hi != nil:
+		return n.Sub(hi, n)
+	case g.r.Intn(2) == 0:
+		n.Neg(n)
+	}
+	return n
+}
+
+// magnitude returns a random non-negative integer that is small more often
+// than large.
+func (g *generator) magnitude() *big.Int {
+	max := new(big.Int).Lsh(big.NewInt(1), uint(g.r.Intn(64)))
+	return new(big.Int).Rand(g.r, max)
+}
+
+// float returns a random floating-point number within the bounds of v.
+func (g *generator) float(v cue.Value) float64 {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	constraints(v, func(op cue.Op, arg cue.Value) {
+		if arg.Kind()&cue.NumberKind == 0 {
+			return
+		}
+		f, _ := arg.Float64()
+		switch op {
+		case cue.GreaterThanOp, cue.GreaterThanEqualOp:
+			lo = math.Max(lo, f)
+		case cue.LessThanOp, cue.LessThanEqualOp:
+			hi = math.Min(hi, f)
+		}
+	})
+
+	// Choose a magnitude across many orders of magnitude.
+	x := math.Abs(g.r.NormFloat64()) * math.Pow(10, float64(g.r.Intn(7)-2))
+	switch {
+	case !math.IsInf(lo, 0) && !math.IsInf(hi, 0):
+		return lo + g.r.Float64()*(hi-lo)
+	case !math.IsInf(lo, 0):
+		return lo + x
+	case !math.IsInf(hi, 0):
+		return hi - x
+	}
+	if g.r.Intn(2) == 0 {
+		return -x
+	}
+	return x
+}
+
+// string returns a random string, which matches a regular expression that
+// v must match, if any.
+func (g *generator) string(v cue.Value) string {
+	var re string
+	constraints(v, func(op cue.Op, arg cue.Value) {
+		if op == cue.RegexMatchOp && re == "" {
+			re, _ = arg.String()
+		}
+	})
+	if re != "" {
+		if s, err := g.matchingString(re); err == nil {
+			return s
+		}
+	}
+	r := make([]rune, g.r.Intn(g.maxSize+1))
+	for i := range r {
+		r[i] = g.rune()
+	}
+	return string(r)
+}
+
+// list returns a list with random elements for list v.
+func (g *generator) list(v cue.Value, depth int) cue.Value {
+	var elems []cue.Value
+	iter, err := v.List()
+	if err != nil {
+		return v
+	}
+	for iter.Next() {
+		elems = append(elems, g.value(iter.Value(), depth+1))
+	}
+	if rest := v.LookupPath(cue.MakePath(cue.AnyIndex)); rest.Exists() && depth < g.maxDepth {
+		for n := g.r.Intn(g.maxSize + 1); n > 0; n-- {
+			elems = append(elems, g.value(rest, depth+1))
+		}
+	}
+	for _, e := range elems {
+		if e.Err() != nil {
+			return e
+		}
+	}
+	return v.Unify(g.ctx.NewList(elems...))
+}
+
+// structValue fills struct v with random values for its fields, some of its
+// optional fields and fields matching its pattern constraints.
+func (g *generator) structValue(v cue.Value, depth int) cue.Value {
+	var pending []cue.Path
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return v
+	}
+	for iter.Next() {
+		if iter.IsOptional() && (depth >= g.maxDepth || g.r.Intn(2) == 0) {
+			continue
+		}
+		pending = append(pending, cue.MakePath(cue.Str(iter.Selector().Unquoted())))
+	}
+
+	if depth < g.maxDepth {
+		for _, p := range v.Patterns() {
+			if p.Pattern.IncompleteKind()&cue.StringKind == 0 {
+				continue
+			}
+			for n := g.r.Intn(g.maxSize/2 + 1); n > 0; n-- {
+				label, err := data(g.value(p.Pattern, depth+1))
+				if err != nil {
+					continue
+				}
+				s, _ := label.String()
+				pending = append(pending, cue.MakePath(cue.Str(s)))
+			}
+		}
+	}
+
+	// Fields may depend on the values of other fields, in which case their
+	// kind is unknown until those have been filled in.
+	for len(pending) > 0 {
+		var next []cue.Path
+		for _, path := range pending {
+			// Filling in top turns optional fields and fields for pattern
+			// constraints into regular fields with all their constraints.
+			f := v.FillPath(path, g.top).LookupPath(path)
+			if f.IncompleteKind() == cue.BottomKind && f.Err() != nil {
+				next = append(next, path)
+				continue
+			}
+			x := g.value(f, depth+1)
+			if x.Err() != nil {
+				return x
+			}
+			v = v.FillPath(path, x)
+		}
+		if len(next) == len(pending) {
+			break
+		}
+		pending = next
+	}
+	return v
+}
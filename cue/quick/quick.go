@@ -0,0 +1,191 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quick implements property-based testing of code that consumes
+// values described by a CUE schema, in the style of testing/quick.
+//
+// Value generates random concrete values that are instances of a schema, and
+// Check tests a property against many such values, shrinking any value for
+// which the property fails to a simpler one before reporting it:
+//
+//	schema := ctx.CompileString(`{
+//		name:     =~"^[a-z]+$"
+//		replicas: int & >=1 & <=10
+//		labels?: [string]: string
+//	}`)
+//	err := quick.Check(schema, func(v cue.Value) bool {
+//		var cfg Config
+//		return v.Decode(&cfg) == nil && deploy(cfg) == nil
+//	}, nil)
+//
+// Values of basic types are generated within the bounds that a schema places
+// on them, strings are generated to match regular expressions, and one of the
+// disjuncts of a disjunction is chosen at random. Other constraints, such as
+// those imposed by validators, are honored by discarding values that violate
+// them, which may cause generation to fail for schemas that few values
+// satisfy.
+package quick
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"cuelang.org/go/cue"
+)
+
+// A Config configures the generation of values and the checking of
+// properties. A nil *Config is equivalent to the zero Config, which uses
+// the defaults described for each of its fields.
+type Config struct {
+	// MaxCount is the maximum number of values that Check tests.
+	// The default is 100.
+	MaxCount int
+
+	// Rand is the source of random numbers. If it is nil, a source seeded
+	// with the current time is used.
+	Rand *rand.Rand
+
+	// MaxSize is the maximum length of generated strings, bytes and lists,
+	// not counting elements required by the schema, and the maximum number
+	// of fields generated for a pattern constraint. The default is 10.
+	MaxSize int
+
+	// MaxDepth is the depth of nesting beyond which optional fields, list
+	// elements and fields for pattern constraints are no longer generated,
+	// which ensures that values of recursive schemas are finite.
+	// The default is 5.
+	MaxDepth int
+
+	// MaxShrinks is the maximum number of times that Check tests the
+	// property while shrinking a failing value. The default is 1000.
+	MaxShrinks int
+}
+
+func (c *Config) maxCount() int {
+	if c == nil || c.MaxCount <= 0 {
+		return 100
+	}
+	return c.MaxCount
+}
+
+func (c *Config) rand() *rand.Rand {
+	if c == nil || c.Rand == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return c.Rand
+}
+
+func (c *Config) maxSize() int {
+	if c == nil || c.MaxSize <= 0 {
+		return 10
+	}
+	return c.MaxSize
+}
+
+func (c *Config) maxDepth() int {
+	if c == nil || c.MaxDepth <= 0 {
+		return 5
+	}
+	return c.MaxDepth
+}
+
+func (c *Config) maxShrinks() int {
+	if c == nil || c.MaxShrinks <= 0 {
+		return 1000
+	}
+	return c.MaxShrinks
+}
+
+// Value returns a random concrete value that is an instance of schema.
+func Value(schema cue.Value, c *Config) (cue.Value, error) {
+	return newGenerator(schema, c, c.rand()).generate(schema)
+}
+
+// A CheckError is the result of Check finding a value for which a property
+// does not hold.
+type CheckError struct {
+	// Count is the number of the value, counting from 1, for which the
+	// property failed.
+	Count int
+
+	// In is the shrunk value for which the property fails.
+	In cue.Value
+
+	// Original is the value for which the property failed originally.
+	Original cue.Value
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("#%d: failed on input %v", e.Count, e.In)
+}
+
+// Check calls f with random instances of schema and reports whether it
+// returns true for all of them. If f returns false for a value, Check
+// shrinks the value to a simpler one for which f still returns false and
+// returns a *CheckError holding it. Any other error indicates that no values
+// could be generated for schema.
+func Check(schema cue.Value, f func(v cue.Value) bool, c *Config) error {
+	g := newGenerator(schema, c, c.rand())
+	for i := 1; i <= c.maxCount(); i++ {
+		v, err := g.generate(schema)
+		if err != nil {
+			return err
+		}
+		if f(v) {
+			continue
+		}
+		fails := func(v cue.Value) bool { return !f(v) }
+		return &CheckError{
+			Count:    i,
+			In:       Shrink(schema, v, fails, c),
+			Original: v,
+		}
+	}
+	return nil
+}
+
+// Shrink returns the simplest instance of schema that it can find, by
+// successively simplifying v, for which fails returns true. It assumes that
+// fails(v) holds.
+//
+// Values are simplified by removing struct fields and list elements, by
+// moving numbers towards zero, by shortening strings and bytes, and by
+// replacing true with false, each time keeping only simplifications that
+// are instances of schema.
+func Shrink(schema, v cue.Value, fails func(v cue.Value) bool, c *Config) cue.Value {
+	return shrink(schema, v, fails, c.maxShrinks())
+}
+
+// Values returns a function for use as the Values field of a
+// testing/quick.Config, which sets each argument of the tested function
+// to an instance of the corresponding schema. The arguments of the tested
+// function must be of type cue.Value.
+//
+// The returned function panics if it cannot generate a value.
+func Values(c *Config, schemas ...cue.Value) func(args []reflect.Value, r *rand.Rand) {
+	return func(args []reflect.Value, r *rand.Rand) {
+		if len(args) != len(schemas) {
+			panic(fmt.Sprintf("quick: function has %d arguments but %d schemas were given", len(args), len(schemas)))
+		}
+		for i, schema := range schemas {
+			v, err := newGenerator(schema, c, r).generate(schema)
+			if err != nil {
+				panic(fmt.Sprintf("quick: %v", err))
+			}
+			args[i] = reflect.ValueOf(v)
+		}
+	}
+}
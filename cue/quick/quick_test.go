@@ -0,0 +1,184 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quick_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	tquick "testing/quick"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/quick"
+)
+
+// schemas holds CUE files each of which defines a schema x.
+var schemas = []string{
+	`x: null`,
+	`x: bool`,
+	`x: uint8`,
+	`x: int & >=3 & <10 & !=5`,
+	`x: int & >2.5 & <=4.5`,
+	`x: number`,
+	`x: float & >1 & <2`,
+	`x: bytes`,
+	`x: =~"^[a-z]+[0-9]?$"`,
+	`x: *"x" | "y" | int`,
+	`x: _`,
+	`x: [...int]`,
+	`x: [int, string]`,
+	`x: [int, ...string]`,
+	`x: {a: int, b?: string, c: [string]: bool}`,
+	`x: {h: a + 1, a: int & <10}`,
+	`x: {#a: int, _h: #a, b: #a}`,
+	`x: close({[=~"^x"]: int})`,
+	`x: {kind: "a", a: int} | {kind: "b", b: string}`,
+	`x: #T, #T: {v: int, next?: #T}`,
+	`import "strings"
+	x: strings.MinRunes(2)`,
+}
+
+func TestValue(t *testing.T) {
+	ctx := cuecontext.New()
+	for _, src := range schemas {
+		t.Run(src, func(t *testing.T) {
+			v := compile(t, ctx, src)
+			schema := v.LookupPath(cue.ParsePath("x"))
+			c := &quick.Config{Rand: rand.New(rand.NewSource(1))}
+			for i := 0; i < 50; i++ {
+				v, err := quick.Value(schema, c)
+				qt.Assert(t, qt.IsNil(err))
+				qt.Assert(t, qt.IsNil(v.Validate(cue.Concrete(true))))
+				qt.Assert(t, qt.IsNil(schema.Unify(v).Validate(cue.Concrete(true))), qt.Commentf("%v", v))
+			}
+		})
+	}
+}
+
+func TestValueOptional(t *testing.T) {
+	ctx := cuecontext.New()
+	schema := compile(t, ctx, `#T, #T: {labels?: [string]: string, next?: #T}`)
+	c := &quick.Config{Rand: rand.New(rand.NewSource(1))}
+	var next, labels bool
+	for i := 0; i < 50; i++ {
+		v, err := quick.Value(schema, c)
+		qt.Assert(t, qt.IsNil(err))
+		next = next || v.LookupPath(cue.ParsePath("next")).Exists()
+		iter, _ := v.LookupPath(cue.ParsePath("labels")).Fields()
+		labels = labels || iter != nil && iter.Next()
+	}
+	qt.Assert(t, qt.IsTrue(next))
+	qt.Assert(t, qt.IsTrue(labels))
+}
+
+func TestValueError(t *testing.T) {
+	ctx := cuecontext.New()
+	schema := compile(t, ctx, `=~"^a" & =~"^b"`)
+	_, err := quick.Value(schema, nil)
+	qt.Assert(t, qt.ErrorMatches(err, `cannot generate instance of schema: .*`))
+}
+
+func TestCheck(t *testing.T) {
+	ctx := cuecontext.New()
+	schema := compile(t, ctx, `{n: int, s?: string, l: [...bool]}`)
+	err := quick.Check(schema, func(v cue.Value) bool {
+		n, _ := v.LookupPath(cue.ParsePath("n")).Int64()
+		return n <= 100
+	}, &quick.Config{Rand: rand.New(rand.NewSource(1))})
+
+	var cerr *quick.CheckError
+	qt.Assert(t, qt.IsTrue(errors.As(err, &cerr)))
+	qt.Assert(t, qt.Equals(fmt.Sprint(cerr.In), "{\n\tn: 101\n\tl: []\n}"))
+	qt.Assert(t, qt.IsNil(schema.Unify(cerr.Original).Validate(cue.Concrete(true))))
+
+	err = quick.Check(schema, func(v cue.Value) bool { return true }, nil)
+	qt.Assert(t, qt.IsNil(err))
+}
+
+func TestShrink(t *testing.T) {
+	ctx := cuecontext.New()
+	tests := []struct {
+		schema string
+		v      string
+		fails  func(v cue.Value) bool
+		want   string
+	}{{
+		schema: `=~"^[a-z]+$"`,
+		v:      `"hello"`,
+		fails: func(v cue.Value) bool {
+			s, _ := v.String()
+			return len(s) >= 3
+		},
+		want: `"llo"`,
+	}, {
+		schema: `[...number]`,
+		v:      `[1, -2.5, 7]`,
+		fails: func(v cue.Value) bool {
+			n, _ := v.Len().Int64()
+			return n > 1
+		},
+		want: `[0.0, 0]`,
+	}, {
+		schema: `{a?: bool, b: bool, c: "x"}`,
+		v:      `{a: true, b: true, c: "x"}`,
+		fails:  func(v cue.Value) bool { return true },
+		want:   "{\n\tb: false\n\tc: \"x\"\n}",
+	}, {
+		schema: `int & >=-50`,
+		v:      `-40`,
+		fails: func(v cue.Value) bool {
+			n, _ := v.Int64()
+			return n < -20
+		},
+		want: `-21`,
+	}}
+	for _, test := range tests {
+		t.Run(test.schema, func(t *testing.T) {
+			schema := compile(t, ctx, test.schema)
+			v := compile(t, ctx, test.v)
+			got := quick.Shrink(schema, v, test.fails, nil)
+			qt.Assert(t, qt.Equals(fmt.Sprint(got), test.want))
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	ctx := cuecontext.New()
+	port := compile(t, ctx, `int & >=1 & <=65535`)
+	host := compile(t, ctx, `=~"^[a-z]+(\\.[a-z]+)*$"`)
+	f := func(port, host cue.Value) bool {
+		p, err := port.Int64()
+		if err != nil || p < 1 || p > 65535 {
+			return false
+		}
+		_, err = host.String()
+		return err == nil
+	}
+	err := tquick.Check(f, &tquick.Config{
+		Rand:   rand.New(rand.NewSource(1)),
+		Values: quick.Values(nil, port, host),
+	})
+	qt.Assert(t, qt.IsNil(err))
+}
+
+func compile(t *testing.T, ctx *cue.Context, src string) cue.Value {
+	v := ctx.CompileString(src)
+	qt.Assert(t, qt.IsNil(v.Err()))
+	return v
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quick
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// matchingString returns a random string that matches re. As the regular
+// expressions of CUE match anywhere in a string, assertions such as ^ and \b
+// are ignored, which may result in a string that does not match.
+func (g *generator) matchingString(re string) (string, error) {
+	x, err := syntax.Parse(re, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	g.writeMatch(&b, x.Simplify())
+	return b.String(), nil
+}
+
+func (g *generator) writeMatch(b *strings.Builder, x *syntax.Regexp) {
+	switch x.Op {
+	case syntax.OpLiteral:
+		for _, r := range x.Rune {
+			if x.Flags&syntax.FoldCase != 0 && g.r.Intn(2) == 0 {
+				r = unicode.SimpleFold(r)
+			}
+			b.WriteRune(r)
+		}
+
+	case syntax.OpCharClass:
+		b.WriteRune(g.classRune(x.Rune))
+
+	case syntax.OpAnyCharNotNL:
+		r := g.rune()
+		for r == '\n' {
+			r = g.rune()
+		}
+		b.WriteRune(r)
+
+	case syntax.OpAnyChar:
+		b.WriteRune(g.rune())
+
+	case syntax.OpCapture:
+		g.writeMatch(b, x.Sub[0])
+
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := x.Min, x.Max
+		switch x.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + g.maxSize/2
+		}
+		n := min + g.r.Intn(max-min+1)
+		for i := 0; i < n; i++ {
+			g.writeMatch(b, x.Sub[0])
+		}
+
+	case syntax.OpConcat:
+		for _, sub := range x.Sub {
+			g.writeMatch(b, sub)
+		}
+
+	case syntax.OpAlternate:
+		g.writeMatch(b, x.Sub[g.r.Intn(len(x.Sub))])
+	}
+}
+
+// classRune returns a random rune from a character class, given as pairs of
+// inclusive bounds. Printable ASCII characters are preferred.
+func (g *generator) classRune(ranges []rune) rune {
+	if len(ranges) == 0 {
+		return g.rune()
+	}
+	if g.r.Intn(10) != 0 {
+		var ascii []rune
+		for i := 0; i < len(ranges); i += 2 {
+			lo, hi := ranges[i], ranges[i+1]
+			if lo < ' ' {
+				lo = ' '
+			}
+			if hi > '~' {
+				hi = '~'
+			}
+			if lo <= hi {
+				ascii = append(ascii, lo, hi)
+			}
+		}
+		if len(ascii) > 0 {
+			ranges = ascii
+		}
+	}
+	i := 2 * g.r.Intn(len(ranges)/2)
+	lo, hi := ranges[i], ranges[i+1]
+	for {
+		r := lo + rune(g.r.Int63n(int64(hi-lo)+1))
+		if utf8.ValidRune(r) {
+			return r
+		}
+	}
+}
+
+// rune returns a random rune, which is usually a printable ASCII character.
+func (g *generator) rune() rune {
+	if g.r.Intn(10) != 0 {
+		return ' ' + rune(g.r.Intn('~'-' '+1))
+	}
+	for {
+		r := rune(g.r.Intn(unicode.MaxRune + 1))
+		if utf8.ValidRune(r) {
+			return r
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quick
+
+import (
+	"math"
+	"math/big"
+
+	"cuelang.org/go/cue"
+)
+
+// shrink implements Shrink, testing the property at most max times.
+func shrink(schema, v cue.Value, fails func(v cue.Value) bool, max int) cue.Value {
+	ctx := schema.Context()
+	n := newNode(v)
+	for max > 0 {
+		var next *node
+		n.candidates(ctx, func(c *node) bool {
+			x := c.value(ctx)
+			if instanceOf(schema, x) != nil {
+				return true
+			}
+			max--
+			if fails(x) {
+				next = c
+				return false
+			}
+			return max > 0
+		})
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return n.value(ctx)
+}
+
+// A node is a concrete value that is taken apart for shrinking.
+type node struct {
+	kind   cue.Kind
+	scalar cue.Value      // the value of a scalar
+	labels []cue.Selector // the labels of the fields of a struct
+	elems  []*node        // the fields of a struct or elements of a list
+}
+
+func newNode(v cue.Value) *node {
+	n := &node{kind: v.Kind()}
+	switch n.kind {
+	case cue.StructKind:
+		iter, _ := v.Fields()
+		for iter.Next() {
+			n.labels = append(n.labels, iter.Selector())
+			n.elems = append(n.elems, newNode(iter.Value()))
+		}
+	case cue.ListKind:
+		iter, _ := v.List()
+		for iter.Next() {
+			n.elems = append(n.elems, newNode(iter.Value()))
+		}
+	default:
+		n.scalar = v
+	}
+	return n
+}
+
+// value returns the value that n represents.
+func (n *node) value(ctx *cue.Context) cue.Value {
+	switch n.kind {
+	case cue.StructKind:
+		v := ctx.CompileString("{}")
+		for i, sel := range n.labels {
+			v = v.FillPath(cue.MakePath(sel), n.elems[i].value(ctx))
+		}
+		return v
+	case cue.ListKind:
+		elems := make([]cue.Value, len(n.elems))
+		for i, e := range n.elems {
+			elems[i] = e.value(ctx)
+		}
+		return ctx.NewList(elems...)
+	}
+	return n.scalar
+}
+
+// without returns a copy of struct or list n without its i'th element.
+func (n *node) without(i int) *node {
+	c := *n
+	c.elems = append(append([]*node(nil), n.elems[:i]...), n.elems[i+1:]...)
+	if n.labels != nil {
+		c.labels = append(append([]cue.Selector(nil), n.labels[:i]...), n.labels[i+1:]...)
+	}
+	return &c
+}
+
+// with returns a copy of struct or list n with its i'th element replaced
+// by e.
+func (n *node) with(i int, e *node) *node {
+	c := *n
+	c.elems = append([]*node(nil), n.elems...)
+	c.elems[i] = e
+	return &c
+}
+
+// candidates calls yield with simpler versions of n, simplest first, until
+// yield returns false. It reports whether all candidates were yielded.
+func (n *node) candidates(ctx *cue.Context, yield func(*node) bool) bool {
+	switch n.kind {
+	case cue.StructKind, cue.ListKind:
+		for i := range n.elems {
+			if !yield(n.without(i)) {
+				return false
+			}
+		}
+		for i, e := range n.elems {
+			ok := e.candidates(ctx, func(c *node) bool {
+				return yield(n.with(i, c))
+			})
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	for _, x := range shrinkScalar(ctx, n.scalar) {
+		if !yield(&node{kind: n.kind, scalar: x}) {
+			return false
+		}
+	}
+	return true
+}
+
+// shrinkScalar returns simpler versions of the scalar v.
+func shrinkScalar(ctx *cue.Context, v cue.Value) []cue.Value {
+	var a []cue.Value
+	switch v.Kind() {
+	case cue.BoolKind:
+		if b, _ := v.Bool(); b {
+			a = append(a, ctx.Encode(false))
+		}
+
+	case cue.IntKind:
+		x, _ := v.Int(nil)
+		if x.Sign() == 0 {
+			break
+		}
+		half := new(big.Int).Quo(x, big.NewInt(2))
+		toZero := new(big.Int).Sub(x, big.NewInt(int64(x.Sign())))
+		a = append(a, ctx.Encode(0))
+		if half.Sign() != 0 {
+			a = append(a, ctx.Encode(half))
+		}
+		if toZero.Sign() != 0 && toZero.Cmp(half) != 0 {
+			a = append(a, ctx.Encode(toZero))
+		}
+
+	case cue.FloatKind:
+		f, _ := v.Float64()
+		if f == 0 {
+			break
+		}
+		a = append(a, ctx.Encode(0.0))
+		if t := math.Trunc(f); t != f {
+			a = append(a, ctx.Encode(t))
+		} else if h := math.Trunc(f / 2); h != 0 {
+			a = append(a, ctx.Encode(h))
+		}
+
+	case cue.StringKind:
+		s, _ := v.String()
+		for _, r := range shrinkSlice([]rune(s)) {
+			a = append(a, ctx.Encode(string(r)))
+		}
+
+	case cue.BytesKind:
+		b, _ := v.Bytes()
+		for _, b := range shrinkSlice(b) {
+			a = append(a, ctx.Encode(b))
+		}
+	}
+	return a
+}
+
+// shrinkSlice returns shorter versions of s: the empty slice, its halves and
+// s with a single element removed.
+func shrinkSlice[T any](s []T) [][]T {
+	if len(s) == 0 {
+		return nil
+	}
+	a := [][]T{{}}
+	if len(s) > 2 {
+		a = append(a, s[:len(s)/2], s[len(s)/2:])
+	}
+	if len(s) > 1 {
+		for i := range s {
+			a = append(a, append(append([]T{}, s[:i]...), s[i+1:]...))
+		}
+	}
+	return a
+}
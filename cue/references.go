@@ -0,0 +1,119 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/dep"
+)
+
+// A Reference describes a reference from one field to another value.
+type Reference struct {
+	// Pos is the position of the referring expression.
+	Pos token.Pos
+
+	// From is the path of the field containing the reference, relative to
+	// the root of its package.
+	From Path
+
+	// To is the path of the referenced value, relative to the root of the
+	// package that defines it. If a reference selects a field that does not
+	// exist, such as x.y where x has no field y, To is the path of the
+	// closest existing value.
+	To Path
+
+	// ImportPath is the import path of the package defining the referenced
+	// value if this differs from the package containing the reference.
+	ImportPath string
+}
+
+// References reports the references made by v and, recursively, by all of
+// its fields, including definitions and hidden and optional fields.
+// References to values that do not have a path from the root of a package,
+// such as let declarations and comprehension variables, are not reported,
+// but references made by their expressions are attributed to the field in
+// which they are used. A field that is unified with a struct, for instance
+// through a reference, reports the references within that struct relative to
+// its own path.
+func (v Value) References() []Reference {
+	if v.v == nil {
+		return nil
+	}
+	ctx := v.ctx()
+	var a []Reference
+	var visit func(x *adt.Vertex)
+	visit = func(x *adt.Vertex) {
+		_, from := mkPath(v.idx, nil, x)
+		_ = dep.Visit(nil, ctx, x, func(d dep.Dependency) error {
+			_, to := mkPath(v.idx, nil, d.Node)
+			r := Reference{
+				Pos:  pos(d.Reference),
+				From: Path{path: from},
+				To:   Path{path: to},
+			}
+			if imp := d.Import(); imp != nil {
+				r.ImportPath = imp.ImportPath.StringValue(ctx)
+			}
+			a = append(a, r)
+			return nil
+		})
+		for _, arc := range x.Arcs {
+			// References made by let expressions are reported where they
+			// are used.
+			if !arc.Label.IsLet() {
+				visit(arc)
+			}
+		}
+	}
+	visit(v.v)
+	return a
+}
+
+// Dependents reports the references within v, as reported by References,
+// to the value at path p relative to v. This includes references to fields
+// of that value and to its ancestors within v, as the values of these
+// change if the value at p changes.
+func (v Value) Dependents(p Path) []Reference {
+	if v.v == nil || p.Err() != nil {
+		return nil
+	}
+	target := append(v.Path().Selectors(), p.Selectors()...)
+	var a []Reference
+	for _, r := range v.References() {
+		if r.ImportPath != "" {
+			continue
+		}
+		to := r.To.Selectors()
+		if hasSelectorPrefix(to, target) ||
+			len(to) > len(v.Path().Selectors()) && hasSelectorPrefix(target, to) {
+			a = append(a, r)
+		}
+	}
+	return a
+}
+
+// hasSelectorPrefix reports whether prefix is a prefix of a.
+func hasSelectorPrefix(a, prefix []Selector) bool {
+	if len(prefix) > len(a) {
+		return false
+	}
+	for i, s := range prefix {
+		if s.String() != a[i].String() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestReferences(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+import "strings"
+
+#Port: int & >0
+config: {
+	host: "example.com"
+	port: #Port & 8080
+	url:  "https://\(host):\(port)"
+}
+let cfg = config
+upper:  strings.ToUpper(cfg.host)
+all:    config
+if config.port > 0 {enabled: true}
+`, cue.Filename("in.cue"))
+	qt.Assert(t, qt.IsNil(v.Err()))
+
+	str := func(refs []cue.Reference) (a []string) {
+		for _, r := range refs {
+			s := fmt.Sprintf("%d:%d %v -> %v", r.Pos.Line(), r.Pos.Column(), r.From, r.To)
+			if r.ImportPath != "" {
+				s += " in " + r.ImportPath
+			}
+			a = append(a, s)
+		}
+		return a
+	}
+
+	qt.Assert(t, qt.DeepEquals(str(v.References()), []string{
+		"7:8 config.port -> #Port",
+		"8:19 config.url -> config.host",
+		"8:27 config.url -> config.port",
+		"11:9 upper -> ToUpper in strings",
+		"11:25 upper -> config.host",
+		"12:9 all -> config",
+		"7:8 all.port -> #Port",
+		"8:19 all.url -> all.host",
+		"8:27 all.url -> all.port",
+		"13:4 enabled -> config.port",
+	}))
+
+	qt.Assert(t, qt.DeepEquals(str(v.Dependents(cue.ParsePath("config.port"))), []string{
+		"8:27 config.url -> config.port",
+		"12:9 all -> config",
+		"13:4 enabled -> config.port",
+	}))
+
+	config := v.LookupPath(cue.ParsePath("config"))
+	qt.Assert(t, qt.DeepEquals(str(config.Dependents(cue.ParsePath("host"))), []string{
+		"8:19 config.url -> config.host",
+	}))
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// Sources returns the syntax of all declarations that were unified to obtain
+// v, in the order in which they were unified. Unlike [Value.Source] and
+// [Value.Pos], which report a single node, it includes the declarations from
+// all files. The range of source text of each node is given by its Pos and
+// End methods.
+//
+// As for [Value.Provenance], a declaration that is a reference is reported
+// as the reference itself. Declarations that were not derived from source,
+// such as values filled in programmatically, are omitted.
+func (v Value) Sources() []ast.Node {
+	if v.v == nil {
+		return nil
+	}
+	var a []ast.Node
+outer:
+	for _, c := range v.v.Conjuncts {
+		n := c.Source()
+		if n == nil || !n.Pos().IsValid() {
+			continue
+		}
+		for _, x := range a {
+			if x == n {
+				continue outer
+			}
+		}
+		a = append(a, n)
+	}
+	return a
+}
+
+// A SourceMap maps the paths of a value and the values nested within it to
+// the syntax that produced them.
+type SourceMap struct {
+	paths   []Path
+	sources map[string][]ast.Node
+}
+
+// SourceMap returns a SourceMap for v and the values nested within it. The
+// paths in the map are relative to v, which has the empty path. The options
+// select the fields that are included, as for [Value.Fields].
+func (v Value) SourceMap(opts ...Option) *SourceMap {
+	m := &SourceMap{sources: map[string][]ast.Node{}}
+	m.add(nil, v, opts)
+	return m
+}
+
+func (m *SourceMap) add(sels []Selector, v Value, opts []Option) {
+	p := MakePath(sels...)
+	m.paths = append(m.paths, p)
+	m.sources[p.String()] = v.Sources()
+
+	switch v.IncompleteKind() {
+	case StructKind:
+		iter, err := v.Fields(opts...)
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			m.add(append(sels[:len(sels):len(sels)], iter.Selector()), iter.Value(), opts)
+		}
+	case ListKind:
+		list, err := v.List()
+		if err != nil {
+			return
+		}
+		for i := 0; list.Next(); i++ {
+			m.add(append(sels[:len(sels):len(sels)], Index(i)), list.Value(), opts)
+		}
+	}
+}
+
+// Paths returns all paths in m. A path precedes the paths of the values
+// nested within it, which are ordered as for [Value.Fields] and
+// [Value.List].
+func (m *SourceMap) Paths() []Path {
+	return m.paths
+}
+
+// Sources returns the syntax that produced the value at path p, as reported
+// by [Value.Sources], or nil if there is no such value in m.
+func (m *SourceMap) Sources(p Path) []ast.Node {
+	return m.sources[p.String()]
+}
+
+// PathsAt returns the paths of the values in m that have a source containing
+// pos, in the order of [SourceMap.Paths]. An editor can use it to find the
+// values produced by the text at a cursor position.
+func (m *SourceMap) PathsAt(pos token.Pos) []Path {
+	var a []Path
+	for _, p := range m.paths {
+		for _, n := range m.sources[p.String()] {
+			if contains(n, pos) {
+				a = append(a, p)
+				break
+			}
+		}
+	}
+	return a
+}
+
+func contains(n ast.Node, pos token.Pos) bool {
+	start, end := n.Pos(), n.End()
+	return start.Filename() == pos.Filename() &&
+		start.Offset() <= pos.Offset() && pos.Offset() < end.Offset()
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/parser"
+)
+
+func TestSourceMap(t *testing.T) {
+	files := []struct{ name, src string }{{
+		name: "a.cue",
+		src: `package p
+
+#Def: {name: string, port: *80 | int}
+x: #Def & {name: "a"}
+x: port: 8080
+l: [1, y]
+y: 2
+`,
+	}, {
+		name: "b.cue",
+		src: `package p
+
+x: name: "a"
+y: int
+`,
+	}}
+	inst := build.NewContext().NewInstance("", nil)
+	for _, f := range files {
+		f, err := parser.ParseFile(f.name, f.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := inst.AddSyntax(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v := cuecontext.New().BuildInstance(inst)
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := v.SourceMap()
+	b := &strings.Builder{}
+	for _, p := range m.Paths() {
+		fmt.Fprintf(b, "\n%q:", p)
+		for _, n := range m.Sources(p) {
+			fmt.Fprintf(b, " %s", sourceRange(n))
+		}
+	}
+	want := `
+"": a.cue:1:1-7:5 b.cue:1:1-4:7
+"x": a.cue:4:1-4:22 a.cue:5:1-5:14 b.cue:3:1-3:13
+"x.name": a.cue:3:8-3:20 a.cue:4:12-4:21 b.cue:3:4-3:13
+"x.port": a.cue:3:22-3:37 a.cue:5:4-5:14
+"l": a.cue:6:1-6:10
+"l[0]": a.cue:6:5-6:6
+"l[1]": a.cue:6:8-6:9
+"y": a.cue:7:1-7:5 b.cue:4:1-4:7`
+	if got := b.String(); got != want {
+		t.Errorf("got:%s\nwant:%s", got, want)
+	}
+
+	// The sources of a value are those of its entry in the map.
+	x := v.LookupPath(cue.ParsePath("x.name"))
+	if got, want := len(x.Sources()), 3; got != want {
+		t.Errorf("got %d sources for x.name; want %d", got, want)
+	}
+
+	// The value of y in b.cue contributes to y and to the file.
+	y := m.Sources(cue.ParsePath("y"))[1]
+	if got, want := fmt.Sprint(m.PathsAt(y.Pos())), `[ y]`; got != want {
+		t.Errorf("got paths %s; want %s", got, want)
+	}
+
+	if got := m.Sources(cue.ParsePath("z")); got != nil {
+		t.Errorf("got sources %v for missing path; want none", got)
+	}
+}
+
+func TestSourcesFillPath(t *testing.T) {
+	ctx := cuecontext.New()
+	v := ctx.CompileString("a: int", cue.Filename("in.cue"))
+	v = v.FillPath(cue.ParsePath("a"), 1)
+	a := v.LookupPath(cue.ParsePath("a"))
+	var got []string
+	for _, n := range a.Sources() {
+		got = append(got, sourceRange(n))
+	}
+	// The filled in value is not derived from source.
+	if want := []string{"in.cue:1:1-1:7"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func sourceRange(n ast.Node) string {
+	start, end := n.Pos().Position(), n.End().Position()
+	return fmt.Sprintf("%s:%d:%d-%d:%d",
+		start.Filename, start.Line, start.Column, end.Line, end.Column)
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statstest provides utilities for checking evaluation statistics in
+// tests, so that performance regressions in CUE schemas surface as test
+// failures.
+//
+// This is an experimental package and may change without notice.
+package statstest
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/stats"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+// NewContext returns a new Context, created with the given options, that
+// records statistics of all evaluations done with it. When t completes, the
+// statistics are logged and t fails if any of the non-zero counters in limits
+// is exceeded.
+//
+// Only the operation counters, Unifications, Disjuncts and Conjuncts, and
+// Allocs are checked.
+func NewContext(t testing.TB, limits stats.Counts, opts ...cuecontext.Option) *cue.Context {
+	t.Helper()
+	ctx := cuecontext.New(opts...)
+	r := (*runtime.Runtime)(ctx)
+	r.RecordStats()
+	t.Cleanup(func() {
+		counts := r.Stats()
+		t.Logf("evaluation stats:%s", counts)
+		check(t, "Unifications", counts.Unifications, limits.Unifications)
+		check(t, "Disjuncts", counts.Disjuncts, limits.Disjuncts)
+		check(t, "Conjuncts", counts.Conjuncts, limits.Conjuncts)
+		check(t, "Allocs", counts.Allocs, limits.Allocs)
+	})
+	return ctx
+}
+
+// Stats reports the statistics recorded so far for a context created with
+// NewContext. It should not be called while evaluation is in progress.
+func Stats(ctx *cue.Context) stats.Counts {
+	return (*runtime.Runtime)(ctx).Stats()
+}
+
+func check(t testing.TB, name string, got, limit int64) {
+	t.Helper()
+	if limit > 0 && got > limit {
+		t.Errorf("%s: got %d; exceeds limit of %d", name, got, limit)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statstest_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/stats"
+	"cuelang.org/go/cue/stats/statstest"
+)
+
+// recorder records failures without failing the enclosing test.
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper()                         {}
+func (r *recorder) Logf(format string, args ...any) {}
+func (r *recorder) Cleanup(f func())                { r.cleanups = append(r.cleanups, f) }
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+const src = `
+#A: {a: int, b: string | *"x"}
+x: #A & {a: 1}
+y: [for i in [1, 2, 3] {#A & {a: i}}]
+`
+
+func TestNewContext(t *testing.T) {
+	r := &recorder{TB: t}
+	ctx := statstest.NewContext(r, stats.Counts{Unifications: 1000})
+	v := ctx.CompileString(src)
+	if err := v.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	counts := statstest.Stats(ctx)
+	if counts.Unifications == 0 || counts.Disjuncts == 0 {
+		t.Errorf("expected statistics to be recorded, got %+v", counts)
+	}
+	r.finish()
+	if len(r.errors) > 0 {
+		t.Errorf("unexpected failures: %v", r.errors)
+	}
+
+	r = &recorder{TB: t}
+	ctx = statstest.NewContext(r, stats.Counts{Unifications: 2})
+	ctx.CompileString(src).Validate()
+	r.finish()
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "Unifications: got ") {
+		t.Errorf("got failures %q; want one for Unifications", r.errors)
+	}
+}
+
+func TestReleasedContexts(t *testing.T) {
+	ctx := statstest.NewContext(t, stats.Counts{})
+	if err := ctx.CompileString(src).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want := statstest.Stats(ctx)
+
+	// The counts of contexts that are released must still be reported.
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+	}
+	if got := statstest.Stats(ctx); got != want {
+		t.Errorf("got %+v after releasing contexts; want %+v", got, want)
+	}
+}
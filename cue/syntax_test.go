@@ -19,8 +19,12 @@ import (
 	"testing"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/internal/cuetxtar"
+	"golang.org/x/tools/txtar"
 )
 
 func TestSyntax(t *testing.T) {
@@ -213,6 +217,21 @@ if true {
 	out: {
 		"\(s)": 3
 	}
+}
+	`,
+	}, {
+		name: "number form",
+		in: `
+mem:   512 * 1024 * 1024
+limit: <=2048
+ratio: 1024.0
+	`,
+		options: o(cue.NumberForm(literal.IEC)),
+		out: `
+{
+	mem:   512Mi
+	limit: <=2Ki
+	ratio: 1024.0
 }
 	`,
 	}}
@@ -236,3 +255,69 @@ if true {
 		})
 	}
 }
+
+func TestSyntaxInlineImports(t *testing.T) {
+	a := txtar.Parse([]byte(`
+-- cue.mod/module.cue --
+module: "mod.test/x"
+-- pkg/b/b.cue --
+package b
+
+#Name: =~"^[a-z]+$"
+#Person: {
+	name:    #Name
+	age?:    int & >=0
+	_secret: 3
+}
+-- main.cue --
+package main
+
+import (
+	"strings"
+
+	"mod.test/x/pkg/b"
+)
+
+_secret: 4
+p: b.#Person & {name: "bob"}
+q: b.#Person
+u: strings.ToUpper("x")
+`))
+	inst := cuetxtar.Load(a, t.TempDir())[0]
+	if inst.Err != nil {
+		t.Fatal(inst.Err)
+	}
+	v := cuecontext.New().BuildInstance(inst)
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	f := v.Syntax(cue.InlineImports(true)).(*ast.File)
+	b, err := format.Node(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The result must not depend on the imported package and should evaluate
+	// to the same value when compiled on its own.
+	for _, spec := range f.Imports {
+		if spec.Path.Value != `"strings"` {
+			t.Errorf("unexpected import %s:\n%s", spec.Path.Value, b)
+		}
+	}
+	w := cuecontext.New().CompileBytes(b)
+	if err := w.Err(); err != nil {
+		t.Fatalf("compiling result: %v\n%s", err, b)
+	}
+	for _, p := range []string{"p.name", "u"} {
+		got := w.LookupPath(cue.ParsePath(p))
+		want := v.LookupPath(cue.ParsePath(p))
+		if !got.Equals(want) {
+			t.Errorf("%s: got %v; want %v", p, got, want)
+		}
+	}
+	q := w.LookupPath(cue.ParsePath("q"))
+	if err := q.FillPath(cue.ParsePath("name"), "Bob").Validate(); err == nil {
+		t.Errorf("q.name: expected constraint of #Name to be retained:\n%s", b)
+	}
+}
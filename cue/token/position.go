@@ -278,6 +278,18 @@ func (f *File) MergeLine(line int) {
 	f.lines = f.lines[:len(f.lines)-1]
 }
 
+// Lines returns the effective line offset table of the form described by
+// SetLines. Callers must not mutate the result.
+func (f *File) Lines() []int {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	lines := make([]int, len(f.lines))
+	for i, l := range f.lines {
+		lines[i] = int(l)
+	}
+	return lines
+}
+
 // SetLines sets the line offsets for a file and reports whether it succeeded.
 // The line offsets are the offsets of the first character of each line;
 // for instance for the content "ab\nc\n" the line offsets are {0, 3}.
@@ -0,0 +1,322 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// A Layer identifies which of the values combined by TraceLayers
+// determined a field.
+type Layer int
+
+const (
+	// BaseLayer indicates the field is determined by the base value.
+	BaseLayer Layer = iota + 1
+
+	// OverlayLayer indicates the field is determined by the overlay.
+	OverlayLayer
+
+	// BothLayers indicates the field is determined by the base value and the
+	// overlay together, either because they agree on its value or because
+	// neither alone determines it.
+	BothLayers
+)
+
+func (l Layer) String() string {
+	switch l {
+	case BaseLayer:
+		return "base"
+	case OverlayLayer:
+		return "overlay"
+	case BothLayers:
+		return "both"
+	}
+	return fmt.Sprintf("Layer(%d)", int(l))
+}
+
+// A LayerTrace describes how a field of the unification of a base value
+// and an overlay is obtained from these two layers.
+type LayerTrace struct {
+	// Path is the path of the field relative to the traced values.
+	Path Path
+
+	// Base and Overlay are the values of the field in each layer. A value
+	// does not exist if the field is absent from the respective layer.
+	Base    Value
+	Overlay Value
+
+	// Final is the value of the field after unifying both layers.
+	Final Value
+
+	// Layer reports which layer determined the final value. For structs and
+	// lists it only reports which layers define the field; see Fields for
+	// how each of their fields is determined.
+	Layer Layer
+
+	// Default reports whether the final value is a default value, that is,
+	// whether it is not set explicitly by either layer.
+	Default bool
+
+	// Fields holds the traces of the regular fields or elements of structs
+	// and lists, in order.
+	Fields []*LayerTrace
+}
+
+// TraceLayers reports, for base and overlay and each of their fields, which
+// of the two determined the value of the unification of base and overlay,
+// and whether a default applied. It is intended to explain the outcome of
+// layered configurations, where an overlay sets values for a base
+// configuration with defaults.
+//
+// A field whose final value is a default is attributed to the layer that
+// declared the default. A field set to the same value by both layers is
+// attributed to both.
+func TraceLayers(base, overlay Value) *LayerTrace {
+	return traceLayers(Path{}, base, overlay, base.Unify(overlay))
+}
+
+func traceLayers(p Path, base, overlay, final Value) *LayerTrace {
+	t := &LayerTrace{
+		Path:    p,
+		Base:    base,
+		Overlay: overlay,
+		Final:   final,
+	}
+	switch {
+	case !overlay.Exists():
+		t.Layer = BaseLayer
+	case !base.Exists():
+		t.Layer = OverlayLayer
+	default:
+		t.Layer = BothLayers
+	}
+
+	kind := final.IncompleteKind()
+	if kind == BottomKind {
+		// Errors in fields make the enclosing struct or list an error as
+		// well, while the layers still show its fields.
+		kind = layerKind(base, overlay)
+	}
+	switch kind {
+	case StructKind, ListKind:
+	default:
+		d, ok := final.Default()
+		t.Default = ok && d.IsConcrete()
+		if t.Layer == BothLayers && d.IsConcrete() {
+			inBase := hasFinalValue(base, d)
+			inOverlay := hasFinalValue(overlay, d)
+			switch {
+			case inBase && !inOverlay:
+				t.Layer = BaseLayer
+			case inOverlay && !inBase:
+				t.Layer = OverlayLayer
+			}
+		}
+		return t
+	}
+
+	var sels []Selector
+	if final.Err() == nil {
+		sels = selectors(final)
+	} else {
+		seen := map[string]bool{}
+		for _, v := range []Value{base, overlay} {
+			for _, sel := range selectors(v) {
+				if !seen[sel.String()] {
+					seen[sel.String()] = true
+					sels = append(sels, sel)
+				}
+			}
+		}
+	}
+	for _, sel := range sels {
+		path := MakePath(sel)
+		t.Fields = append(t.Fields, traceLayers(
+			MakePath(append(p.Selectors(), sel)...),
+			lookupLayer(base, path),
+			lookupLayer(overlay, path),
+			final.LookupPath(path)))
+	}
+	return t
+}
+
+// layerKind returns the kind of the layers that exist if it is a struct or
+// list for all of them, or BottomKind otherwise.
+func layerKind(base, overlay Value) Kind {
+	kind := BottomKind
+	for _, v := range []Value{base, overlay} {
+		if !v.Exists() {
+			continue
+		}
+		k := v.IncompleteKind()
+		if k != StructKind && k != ListKind || kind != BottomKind && k != kind {
+			return BottomKind
+		}
+		kind = k
+	}
+	return kind
+}
+
+// selectors returns the selectors of the regular fields or elements of v.
+func selectors(v Value) []Selector {
+	var iter *Iterator
+	switch v.IncompleteKind() {
+	case StructKind:
+		iter, _ = v.Fields()
+	case ListKind:
+		it, _ := v.List()
+		iter = &it
+	default:
+		return nil
+	}
+	var a []Selector
+	for iter.Next() {
+		a = append(a, iter.Selector())
+	}
+	return a
+}
+
+// hasFinalValue reports whether v evaluates to final by itself, possibly
+// as a default.
+func hasFinalValue(v, final Value) bool {
+	d, _ := v.Default()
+	return d.IsConcrete() && d.Equals(final)
+}
+
+func lookupLayer(v Value, p Path) Value {
+	if !v.Exists() {
+		return Value{}
+	}
+	return v.LookupPath(p)
+}
+
+// String renders t as an indented tree with a line per field. Each line
+// shows the final value of the field, unless it is a struct or list, and
+// the layer that determined it. For fields determined by a single layer,
+// the value of the other layer, if any, is shown as well.
+func (t *LayerTrace) String() string {
+	b := &strings.Builder{}
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	for _, f := range t.Fields {
+		f.write(w, "")
+	}
+	if len(t.Fields) == 0 {
+		t.write(w, "")
+	}
+	w.Flush()
+
+	// Remove the padding of empty trailing cells.
+	lines := strings.SplitAfter(b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \n")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *LayerTrace) write(w *tabwriter.Writer, indent string) {
+	label := "."
+	if sels := t.Path.Selectors(); len(sels) > 0 {
+		label = sels[len(sels)-1].String()
+	}
+	layer := t.Layer.String()
+	if t.Default {
+		layer += ", default"
+	}
+	note := ""
+	if err := t.Final.Err(); err != nil {
+		note = "error: " + oneLine(err.Error())
+	} else if t.Fields == nil {
+		switch t.Layer {
+		case BaseLayer:
+			note = otherLayer("overlay", t.Overlay)
+		case OverlayLayer:
+			note = otherLayer("base", t.Base)
+		}
+	}
+	fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\n", indent, label, t.finalString(), layer, note)
+	for _, f := range t.Fields {
+		f.write(w, indent+"    ")
+	}
+}
+
+// finalString returns the final value of a field that is not a struct or
+// list, resolving defaults.
+func (t *LayerTrace) finalString() string {
+	if t.Fields != nil {
+		return ""
+	}
+	if err := t.Final.Err(); err != nil {
+		return "_|_"
+	}
+	if t.Final.IncompleteKind()&(StructKind|ListKind) != 0 {
+		return ""
+	}
+	v := t.Final
+	if t.Default {
+		v, _ = v.Default()
+	}
+	return oneLine(fmt.Sprint(v))
+}
+
+func otherLayer(name string, v Value) string {
+	if !v.Exists() {
+		return ""
+	}
+	return name + ": " + oneLine(fmt.Sprint(v))
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type layerTraceJSON struct {
+	Path    string        `json:"path"`
+	Base    string        `json:"base,omitempty"`
+	Overlay string        `json:"overlay,omitempty"`
+	Final   string        `json:"final,omitempty"`
+	Layer   string        `json:"layer"`
+	Default bool          `json:"default,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Fields  []*LayerTrace `json:"fields,omitempty"`
+}
+
+// MarshalJSON encodes t as a JSON object for use in user interfaces.
+// Values are encoded as strings in CUE syntax. The values of structs and
+// lists are omitted, as they are given by their fields.
+func (t *LayerTrace) MarshalJSON() ([]byte, error) {
+	x := layerTraceJSON{
+		Path:    t.Path.String(),
+		Layer:   t.Layer.String(),
+		Default: t.Default,
+		Fields:  t.Fields,
+	}
+	if err := t.Final.Err(); err != nil {
+		x.Error = err.Error()
+	}
+	if t.Fields == nil {
+		x.Final = t.finalString()
+		if t.Base.Exists() {
+			x.Base = oneLine(fmt.Sprint(t.Base))
+		}
+		if t.Overlay.Exists() {
+			x.Overlay = oneLine(fmt.Sprint(t.Overlay))
+		}
+	}
+	return json.Marshal(x)
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestTraceLayers(t *testing.T) {
+	testCases := []struct {
+		base    string
+		overlay string
+		want    string
+	}{{
+		base: `
+		name:     "web"
+		replicas: *3 | int
+		port:     *8080 | int
+		mode:     "fast" | "safe"
+		labels: app: "web"
+		args: ["--verbose"]
+		env: string
+		`,
+		overlay: `
+		name:     "web"
+		replicas: 5
+		port:     int
+		mode:     "safe"
+		labels: tier: "frontend"
+		env:   "prod"
+		debug: *false | bool
+		`,
+		want: `
+name      "web"        both
+replicas  5            overlay           base: *3 | int
+port      8080         base, default     overlay: int
+mode      "safe"       overlay           base: "fast" | "safe"
+labels                 both
+    app   "web"        base
+    tier  "frontend"   overlay
+args                   base
+    0     "--verbose"  base
+env       "prod"       overlay           base: string
+debug     false        overlay, default
+`,
+	}, {
+		base:    `a: 1, b: int`,
+		overlay: `a: 2`,
+		want: `
+a  _|_  both  error: a: conflicting values 2 and 1
+b  int  base
+`,
+	}, {
+		base:    `*1 | int`,
+		overlay: `int`,
+		want: `
+.  1  base, default  overlay: int
+`,
+	}}
+	ctx := cuecontext.New()
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := cue.TraceLayers(ctx.CompileString(tc.base), ctx.CompileString(tc.overlay))
+			if got, want := tr.String(), strings.TrimPrefix(tc.want, "\n"); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestTraceLayersJSON(t *testing.T) {
+	ctx := cuecontext.New()
+	base := ctx.CompileString(`spec: {replicas: *3 | int, labels: app: "web"}`)
+	overlay := ctx.CompileString(`spec: replicas: 5`)
+	tr := cue.TraceLayers(base, overlay)
+	b, err := json.Marshal(tr.Fields[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"path":"spec","layer":"both","fields":[` +
+		`{"path":"spec.replicas","base":"*3 | int","overlay":"5","final":"5","layer":"overlay"},` +
+		`{"path":"spec.labels","layer":"base","fields":[` +
+		`{"path":"spec.labels.app","base":"\"web\"","final":"\"web\"","layer":"base"}]}]}`
+	if got := string(b); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -15,6 +15,7 @@
 package cue
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/core/adt"
@@ -954,6 +956,84 @@ func (v Value) marshalJSON() (b []byte, err error) {
 	}
 }
 
+// EncodeJSON writes the JSON encoding of v to w. The output is identical to
+// that of MarshalJSON, but structs and lists are written field by field and
+// element by element, so that the encoding of v as a whole is never held in
+// memory.
+//
+// If an error is returned, w may have received part of the encoding.
+func (v Value) EncodeJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := v.encodeJSON(bw); err != nil {
+		return unwrapJSONError(err)
+	}
+	return bw.Flush()
+}
+
+func (v Value) encodeJSON(w *bufio.Writer) error {
+	v, _ = v.Default()
+	if v.v != nil {
+		ctx := newContext(v.idx)
+		x := v.eval(ctx)
+		if _, ok := x.(adt.Resolver); !ok && adt.IsConcrete(x) {
+			switch x.Kind() {
+			case adt.ListKind:
+				return v.encodeJSONList(w)
+			case adt.StructKind:
+				obj, err := v.structValData(ctx)
+				if err != nil {
+					return toMarshalErr(v, err)
+				}
+				return obj.encodeJSON(w)
+			}
+		}
+	}
+	b, err := v.marshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (o *structValue) encodeJSON(w *bufio.Writer) error {
+	w.WriteByte('{')
+	n := o.Len()
+	for i := 0; i < n; i++ {
+		k, v := o.At(i)
+		s, err := internaljson.Marshal(k)
+		if err != nil {
+			return err
+		}
+		w.Write(s)
+		w.WriteByte(':')
+		if err := v.encodeJSON(w); err != nil {
+			return err
+		}
+		if i < n-1 {
+			w.WriteByte(',')
+		}
+	}
+	return w.WriteByte('}')
+}
+
+func (v Value) encodeJSONList(w *bufio.Writer) error {
+	l, err := v.List()
+	if err != nil {
+		return err
+	}
+	w.WriteByte('[')
+	for i := 0; l.Next(); i++ {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if err := l.Value().encodeJSON(w); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte(']')
+}
+
 // Syntax converts the possibly partially evaluated value into syntax. This
 // can use used to print the value with package format.
 func (v Value) Syntax(opts ...Option) ast.Node {
@@ -976,6 +1056,7 @@ func (v Value) Syntax(opts ...Option) ast.Node {
 		ShowDocs:        o.docs,
 		ShowErrors:      o.showErrors,
 		InlineImports:   o.inlineImports,
+		NumForm:         o.numForm,
 	}
 
 	pkgID := v.instance().ID()
@@ -1793,6 +1874,11 @@ func (v hiddenValue) Template() func(label string) Value {
 // Use the [Raw] option to do a low-level subsumption, taking defaults into
 // account.
 //
+// Use the [AllErrors] option to report each field that prevents subsumption,
+// instead of only the first one found. Each error reports the path of the
+// offending field, which can be obtained with [errors.Errors] and the Path
+// method of each error.
+//
 // Value v and w must be obtained from the same build. TODO: remove this
 // requirement.
 func (v Value) Subsume(w Value, opts ...Option) error {
@@ -1809,8 +1895,21 @@ func (v Value) Subsume(w Value, opts ...Option) error {
 	if !o.raw {
 		p.Defaults = true
 	}
+	p.Explain = o.allErrors
 	ctx := v.ctx()
-	return p.Value(ctx, v.v, w.v)
+	err := p.Value(ctx, v.v, w.v)
+	if err == nil || !o.allErrors {
+		return err
+	}
+	a := errors.Errors(errors.Sanitize(err))
+	if o.maxErrors > 0 && len(a) > o.maxErrors {
+		a = a[:o.maxErrors]
+	}
+	err = nil
+	for _, e := range a {
+		err = errors.Append(err, e)
+	}
+	return err
 }
 
 // Deprecated: use [Value.Subsume].
@@ -2053,12 +2152,17 @@ type options struct {
 	omitOptional      bool
 	omitAttrs         bool
 	inlineImports     bool
+	numForm           *literal.NumForm
 	resolveReferences bool
 	showErrors        bool
 	final             bool
 	ignoreClosedness  bool // used for comparing APIs
 	docs              bool
 	disallowCycles    bool // implied by concrete
+	allErrors         bool
+	maxErrors         int
+	lateBound         []Path
+	decodeHooks       []DecodeHookFunc
 }
 
 // An Option defines modes of evaluation.
@@ -2103,10 +2207,24 @@ func Concrete(concrete bool) Option {
 
 // InlineImports causes references to values within imported packages to be
 // inlined. References to builtin packages are not inlined.
+//
+// The inlined values are declared as let clauses at the end of the file.
+// Hidden fields originating from an imported package are renamed to avoid
+// clashes with hidden fields of the same name in the value being exported.
+// As a result, the syntax returned for a root value of an instance is a
+// self-contained file that can be evaluated outside of its module, which
+// makes it suitable for sharing a snapshot of a schema.
 func InlineImports(expand bool) Option {
 	return func(p *options) { p.inlineImports = expand }
 }
 
+// NumberForm sets the form used by Syntax to write integers. For instance,
+// NumberForm(literal.IEC) writes 536870912 as 512Mi. By default, integers
+// are written as in the source or without a multiplier.
+func NumberForm(f literal.NumForm) Option {
+	return func(p *options) { p.numForm = &f }
+}
+
 // DisallowCycles forces validation in the presence of cycles, even if
 // non-concrete values are allowed. This is implied by [Concrete].
 func DisallowCycles(disallow bool) Option {
@@ -2190,6 +2308,37 @@ func Optional(include bool) Option {
 	return func(p *options) { p.omitOptional = !include }
 }
 
+// AllErrors indicates that Validate should traverse the entire value and
+// report every error it finds, each with its path, sorted by position and
+// without duplicates. At most limit errors are reported, or all if limit is
+// zero or less. For Subsume, it reports every field that prevents
+// subsumption.
+func AllErrors(limit int) Option {
+	return func(o *options) {
+		o.allErrors = true
+		o.maxErrors = limit
+	}
+}
+
+// LateBound marks the values at the given paths, relative to the value being
+// validated, as late bound: values that are supplied only just before export,
+// typically using FillPath. When combined with Concrete(true), Validate does
+// not require these values to be concrete, but still reports any errors in
+// the values declared for them. It is an error for a late-bound path to not
+// exist. A required field must be selected with a Required selector.
+//
+// This allows a configuration to be compiled and validated once, and then
+// parameterized cheaply, for instance per tenant:
+//
+//	base := ctx.CompileString(`tenant: string, host: "\(tenant).example.com"`)
+//	err := base.Validate(cue.Concrete(true), cue.LateBound(cue.ParsePath("tenant")))
+//	...
+//	v := base.FillPath(cue.ParsePath("tenant"), "acme")
+//	err = v.Validate(cue.Concrete(true))
+func LateBound(paths ...Path) Option {
+	return func(o *options) { o.lateBound = append(o.lateBound, paths...) }
+}
+
 // Attributes indicates that attributes should be included.
 func Attributes(include bool) Option {
 	return func(p *options) { p.omitAttrs = !include }
@@ -2212,7 +2361,7 @@ func (o *options) updateOptions(opts []Option) {
 //
 // Note that by default not all errors are reported, unless options like
 // [Concrete] are used. The [Final] option can be used to check for missing
-// required fields.
+// required fields. The [AllErrors] option can be used to report all errors.
 func (v Value) Validate(opts ...Option) error {
 	o := options{}
 	o.updateOptions(opts)
@@ -2222,13 +2371,30 @@ func (v Value) Validate(opts ...Option) error {
 		Final:          o.final,
 		DisallowCycles: o.disallowCycles,
 		AllErrors:      true,
+		Exhaustive:     o.allErrors,
+		MaxErrors:      o.maxErrors,
+	}
+
+	for _, p := range o.lateBound {
+		w := v.LookupPath(p)
+		if !w.Exists() {
+			return errors.Newf(v.Pos(), "late-bound path %v does not exist", p)
+		}
+		if cfg.LateBound == nil {
+			cfg.LateBound = map[*adt.Vertex]bool{}
+		}
+		cfg.LateBound[w.v] = true
 	}
 
 	b := validate.Validate(v.ctx(), v.v, cfg)
-	if b != nil {
-		return v.toErr(b)
+	if b == nil {
+		return nil
 	}
-	return nil
+	err := v.toErr(b)
+	if o.allErrors {
+		err = errors.Sanitize(err)
+	}
+	return err
 }
 
 // Walk descends into all values of v, calling f. If f returns false, Walk
@@ -28,6 +28,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/internal/astinternal"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/debug"
@@ -2058,6 +2059,75 @@ func TestSubsume(t *testing.T) {
 	}
 }
 
+func TestSubsumeAllErrors(t *testing.T) {
+	const in = `
+	v1: {
+		name:  string
+		port:  int
+		tags?: [...string]
+		opts: {debug: bool, level: int}
+		zone: string
+	}
+	v2: {
+		name:  string
+		port:  string
+		tags!: [...string]
+		opts: {debug: bool, level: string}
+	}
+	`
+	testCases := []struct {
+		opts []Option
+		want []string
+	}{{
+		want: []string{
+			`: field port not present in {name:string,port:string,tags!:[],opts:{debug:bool,level:string}}`,
+			`: missing field "port"`,
+		},
+	}, {
+		opts: []Option{AllErrors(0)},
+		want: []string{
+			"v2.opts.level: int does not subsume string",
+			"v2.port: int does not subsume string",
+			"v2.zone: required field is optional in subsumed value: zone",
+		},
+	}, {
+		opts: []Option{AllErrors(1)},
+		want: []string{"v2.opts.level: int does not subsume string"},
+	}}
+	for _, tc := range testCases {
+		v := getInstance(t, in).Value()
+		v1 := v.LookupPath(ParsePath("v1"))
+		v2 := v.LookupPath(ParsePath("v2"))
+		var got []string
+		for _, err := range errors.Errors(v1.Subsume(v2, tc.opts...)) {
+			format, args := err.Msg()
+			got = append(got, strings.Join(err.Path(), ".")+": "+fmt.Sprintf(format, args...))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got errors\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+		}
+	}
+}
+
+// TestErrorMsgArgs checks that values are passed as strings to the format of
+// error messages.
+func TestErrorMsgArgs(t *testing.T) {
+	v := getInstance(t, `a: 1 & 2`).Value()
+	errs := errors.Errors(v.Validate())
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors; want 1", len(errs))
+	}
+	format, args := errs[0].Msg()
+	if len(args) < 2 {
+		t.Fatalf("%q: got %d arguments; want at least 2", format, len(args))
+	}
+	for i, arg := range args[:2] {
+		if _, ok := arg.(string); !ok {
+			t.Errorf("%q: argument %d is %T; want string", format, i, arg)
+		}
+	}
+}
+
 func TestSubsumes(t *testing.T) {
 	a := []string{"a"}
 	b := []string{"b"}
@@ -2444,6 +2514,50 @@ func TestValidate(t *testing.T) {
 			"variables"?: #variables
 		}
 		`,
+	}, {
+		desc: "late bound",
+		in: `
+		tenant: string
+		db: {name: string, port: int}
+		host: "\(tenant).example.com"
+		`,
+		opts: []Option{Concrete(true), LateBound(ParsePath("tenant"), ParsePath("db"))},
+	}, {
+		desc: "late bound required",
+		in: `
+		tenant!: string
+		`,
+		opts: []Option{Concrete(true), Final(), LateBound(MakePath(Str("tenant").Required()))},
+	}, {
+		desc: "late bound indirect",
+		in: `
+		tenant: string
+		_name: "\(tenant)-db"
+		db: name: _name
+		`,
+		opts: []Option{Concrete(true), LateBound(ParsePath("tenant"))},
+	}, {
+		desc: "late bound with error",
+		in: `
+		tenant: string & 1
+		`,
+		opts: []Option{Concrete(true), LateBound(ParsePath("tenant"))},
+		err:  true,
+	}, {
+		desc: "late bound missing other",
+		in: `
+		tenant: string
+		region: string
+		`,
+		opts: []Option{Concrete(true), LateBound(ParsePath("tenant"))},
+		err:  true,
+	}, {
+		desc: "late bound path does not exist",
+		in: `
+		tenant: string
+		`,
+		opts: []Option{LateBound(ParsePath("region"))},
+		err:  true,
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -2459,6 +2573,36 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateAllErrors(t *testing.T) {
+	const in = `
+	x: {a: 1 & 2, b: 3 & 4, if a == 1 {c: 1}}
+	y: [5 & 6, {d: int}]
+	`
+	testCases := []struct {
+		opts []Option
+		want []string
+	}{{
+		opts: []Option{AllErrors(0)},
+		want: []string{"x.a", "x.b", "y.0"},
+	}, {
+		opts: []Option{AllErrors(0), Concrete(true)},
+		want: []string{"x.a", "x.b", "y.0", "y.1.d"},
+	}, {
+		opts: []Option{AllErrors(2), Concrete(true)},
+		want: []string{"x.a", "x.b"},
+	}}
+	for _, tc := range testCases {
+		v := getInstance(t, in).Value()
+		var got []string
+		for _, err := range errors.Errors(v.Validate(tc.opts...)) {
+			got = append(got, strings.Join(err.Path(), "."))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got error paths %v; want %v", got, tc.want)
+		}
+	}
+}
+
 func TestPath(t *testing.T) {
 	config := `
 	a: b: c: 5
@@ -2920,6 +3064,13 @@ func TestMarshalJSON(t *testing.T) {
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d/%v", i, tc.value), func(t *testing.T) {
 			inst := getInstance(t, tc.value)
+
+			var buf bytes.Buffer
+			err := inst.Value().EncodeJSON(&buf)
+			if checkFailed(t, err, tc.err, "encode") && buf.String() != tc.json {
+				t.Errorf("encode:\n got %v;\nwant %v", buf.String(), tc.json)
+			}
+
 			b, err := inst.Value().MarshalJSON()
 			checkFatal(t, err, tc.err, "init")
 
@@ -0,0 +1,855 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validator compiles CUE schemas into validators that check concrete
+// data, such as decoded JSON or YAML, without running the general evaluator
+// for each input:
+//
+//	v, err := validator.Compile(ctx.CompileString(`{
+//		name:     =~"^[a-z]+$"
+//		replicas: int & >=1 & <=10
+//		labels?: [string]: string
+//	}`))
+//	...
+//	err = v.ValidateJSON(body)
+//
+// A validator checks types, bounds, regular expressions, disjunctions,
+// required, optional and closed fields, pattern constraints of the form
+// [string]: T, and list elements directly. Parts of a schema that use other
+// constraints, such as builtin validators or other pattern constraints, are
+// checked by unifying the schema with the corresponding part of the data,
+// which is correct but slower.
+//
+// Validation reports the first violation found. A value is considered to
+// match a disjunction if it matches any of its disjuncts, even if unifying
+// the schema with the value would result in an ambiguous value because
+// several disjuncts match and add different defaults.
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	cuejson "cuelang.org/go/encoding/json"
+)
+
+// maxDepth and maxNodes are the depth of nesting and number of nodes beyond
+// which schemas are checked by unification, which ensures that compiling
+// recursive schemas terminates in reasonable time.
+const (
+	maxDepth = 64
+	maxNodes = 10000
+)
+
+// A Validator checks data against a compiled schema. It is safe for
+// concurrent use.
+type Validator struct {
+	root *node
+}
+
+// Compile compiles schema into a Validator. It returns an error if the
+// schema itself is invalid.
+func Compile(schema cue.Value) (*Validator, error) {
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	c := &compiler{}
+	root, err := c.compile(schema, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{root: root}, nil
+}
+
+// Validate reports whether x is an instance of the schema. The value x
+// must consist of the values produced by decoding JSON or YAML into an
+// interface value: nil, booleans, numbers, strings, []any and
+// map[string]any. Numbers may be of any of Go's integer or floating-point
+// types or json.Number. Floating-point numbers with an integral value are
+// treated as integers, as encoding/json decodes all numbers as float64.
+//
+// The returned error, if any, is an *Error.
+func (v *Validator) Validate(x any) error {
+	return v.root.check(x, nil)
+}
+
+// ValidateJSON reports whether the JSON value data is an instance of the
+// schema. Unlike Validate, it distinguishes between integers and
+// floating-point numbers as CUE does: a number in data is a float if it
+// contains a decimal point or exponent.
+func (v *Validator) ValidateJSON(data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var x any
+	if err := d.Decode(&x); err != nil {
+		return err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return fmt.Errorf("invalid JSON: data after top-level value")
+	}
+	return v.Validate(x)
+}
+
+// An Error describes data that is not an instance of a schema.
+type Error struct {
+	// Path is the path of the offending value within the data.
+	Path []string
+
+	// Msg describes the violation.
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Msg
+	}
+	return strings.Join(e.Path, ".") + ": " + e.Msg
+}
+
+func newError(path []string, format string, args ...any) *Error {
+	return &Error{
+		Path: append([]string(nil), path...),
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+// A kind is a set of kinds of data values.
+type kind uint8
+
+const (
+	nullKind kind = 1 << iota
+	boolKind
+	intKind
+	floatKind
+	stringKind
+	listKind
+	structKind
+
+	numberKind = intKind | floatKind
+	anyKind    = nullKind | boolKind | numberKind | stringKind | listKind | structKind
+)
+
+func (k kind) String() string {
+	if k == anyKind {
+		return "_"
+	}
+	var a []string
+	for _, x := range []struct {
+		k    kind
+		name string
+	}{
+		{nullKind, "null"},
+		{boolKind, "bool"},
+		{numberKind, "number"},
+		{intKind, "int"},
+		{floatKind, "float"},
+		{stringKind, "string"},
+		{listKind, "list"},
+		{structKind, "struct"},
+	} {
+		if k&x.k == x.k {
+			a = append(a, x.name)
+			k &^= x.k
+		}
+	}
+	return strings.Join(a, "|")
+}
+
+func fromCUEKind(k cue.Kind) kind {
+	var x kind
+	if k&cue.NullKind != 0 {
+		x |= nullKind
+	}
+	if k&cue.BoolKind != 0 {
+		x |= boolKind
+	}
+	if k&cue.IntKind != 0 {
+		x |= intKind
+	}
+	if k&cue.FloatKind != 0 {
+		x |= floatKind
+	}
+	if k&cue.StringKind != 0 {
+		x |= stringKind
+	}
+	if k&cue.ListKind != 0 {
+		x |= listKind
+	}
+	if k&cue.StructKind != 0 {
+		x |= structKind
+	}
+	return x
+}
+
+// A number is a numeric data value.
+type number struct {
+	isInt bool
+	exact bool // i holds the value of an integer
+	i     int64
+	f     float64
+}
+
+func (x number) cmp(y number) int {
+	if x.exact && y.exact {
+		switch {
+		case x.i < y.i:
+			return -1
+		case x.i > y.i:
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case x.f < y.f:
+		return -1
+	case x.f > y.f:
+		return 1
+	}
+	return 0
+}
+
+func (x number) String() string {
+	if x.exact {
+		return strconv.FormatInt(x.i, 10)
+	}
+	return strconv.FormatFloat(x.f, 'g', -1, 64)
+}
+
+func intNumber(i int64) number {
+	return number{isInt: true, exact: true, i: i, f: float64(i)}
+}
+
+func floatNumber(f float64) number {
+	if f == math.Trunc(f) && !math.IsInf(f, 0) {
+		n := number{isInt: true, f: f}
+		if f >= math.MinInt64 && f < math.MaxInt64 {
+			n.exact, n.i = true, int64(f)
+		}
+		return n
+	}
+	return number{f: f}
+}
+
+func parseNumber(s string) (number, bool) {
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return intNumber(i), true
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return number{isInt: true, f: f}, err == nil || isRangeErr(err)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return number{f: f}, err == nil || isRangeErr(err)
+}
+
+func isRangeErr(err error) bool {
+	e, ok := err.(*strconv.NumError)
+	return ok && e.Err == strconv.ErrRange
+}
+
+// kindOf returns the kind of the data value x and its number, if it is one.
+func kindOf(x any) (kind, number, bool) {
+	switch x := x.(type) {
+	case nil:
+		return nullKind, number{}, true
+	case bool:
+		return boolKind, number{}, true
+	case string:
+		return stringKind, number{}, true
+	case []any:
+		return listKind, number{}, true
+	case map[string]any:
+		return structKind, number{}, true
+	case json.Number:
+		n, ok := parseNumber(string(x))
+		return numKind(n), n, ok
+	case float64:
+		n := floatNumber(x)
+		return numKind(n), n, true
+	case float32:
+		n := floatNumber(float64(x))
+		return numKind(n), n, true
+	case int:
+		return intKind, intNumber(int64(x)), true
+	case int8:
+		return intKind, intNumber(int64(x)), true
+	case int16:
+		return intKind, intNumber(int64(x)), true
+	case int32:
+		return intKind, intNumber(int64(x)), true
+	case int64:
+		return intKind, intNumber(x), true
+	case uint:
+		return intKind, uintNumber(uint64(x)), true
+	case uint8:
+		return intKind, intNumber(int64(x)), true
+	case uint16:
+		return intKind, intNumber(int64(x)), true
+	case uint32:
+		return intKind, intNumber(int64(x)), true
+	case uint64:
+		return intKind, uintNumber(x), true
+	}
+	return 0, number{}, false
+}
+
+func numKind(n number) kind {
+	if n.isInt {
+		return intKind
+	}
+	return floatKind
+}
+
+func uintNumber(u uint64) number {
+	if u <= math.MaxInt64 {
+		return intNumber(int64(u))
+	}
+	return number{isInt: true, f: float64(u)}
+}
+
+// A node checks a data value against a part of a schema.
+type node struct {
+	// kind is the set of kinds the value may have.
+	kind kind
+
+	// consts, if not nil, holds the values of which the value must be one.
+	consts []constant
+
+	// bounds, notEqual and regexps constrain scalar values.
+	bounds   []bound
+	notEqual []constant
+	regexps  []*regexp.Regexp
+	notMatch []*regexp.Regexp
+
+	// allOf and anyOf hold the nodes that the value must all match and of
+	// which it must match at least one, respectively.
+	allOf []*node
+	anyOf []*node
+
+	// fields, rest and open constrain struct values. If rest is nil and
+	// open is false, fields not in fields are checked by allows.
+	fields map[string]*field
+	names  []string // names of fields, sorted
+	rest   *node
+	open   bool
+	schema cue.Value
+
+	// elems and elemRest constrain list values if isList is set. If
+	// elemRest is nil, the list may not have more than len(elems) elements.
+	isList   bool
+	elems    []*node
+	elemRest *node
+
+	// fallback is set if the value is checked by unifying it with schema.
+	fallback bool
+}
+
+type field struct {
+	node     *node
+	required bool
+
+	// invalid is set if the value of the field in the schema is an error.
+	// This is also the case for fields of recursive schemas that are
+	// evaluated in isolation, so data with such a field is checked by
+	// unifying the enclosing struct.
+	invalid bool
+}
+
+// A constant is a concrete scalar value.
+type constant struct {
+	kind kind
+	b    bool
+	n    number
+	s    string
+}
+
+func (c constant) equals(k kind, x any, n number) bool {
+	if c.kind != k {
+		return false
+	}
+	switch k {
+	case nullKind:
+		return true
+	case boolKind:
+		return c.b == x.(bool)
+	case intKind, floatKind:
+		return c.n.cmp(n) == 0
+	case stringKind:
+		return c.s == x.(string)
+	}
+	return false
+}
+
+func (c constant) String() string {
+	switch c.kind {
+	case nullKind:
+		return "null"
+	case boolKind:
+		return strconv.FormatBool(c.b)
+	case stringKind:
+		return strconv.Quote(c.s)
+	}
+	return c.n.String()
+}
+
+// A bound is a bound on numbers or strings.
+type bound struct {
+	op   cue.Op
+	kind kind // numberKind or stringKind
+	n    number
+	s    string
+}
+
+func (b bound) holds(k kind, x any, n number) bool {
+	var c int
+	switch {
+	case b.kind == numberKind && k&numberKind != 0:
+		c = n.cmp(b.n)
+	case b.kind == stringKind && k == stringKind:
+		c = strings.Compare(x.(string), b.s)
+	default:
+		return false
+	}
+	switch b.op {
+	case cue.LessThanOp:
+		return c < 0
+	case cue.LessThanEqualOp:
+		return c <= 0
+	case cue.GreaterThanOp:
+		return c > 0
+	case cue.GreaterThanEqualOp:
+		return c >= 0
+	}
+	return false
+}
+
+func (b bound) String() string {
+	if b.kind == stringKind {
+		return fmt.Sprintf("%v%q", b.op, b.s)
+	}
+	return fmt.Sprintf("%v%v", b.op, b.n)
+}
+
+type compiler struct {
+	nodes int
+}
+
+// compile returns the node checking values against v.
+func (c *compiler) compile(v cue.Value, depth int) (*node, error) {
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	c.nodes++
+	if depth > maxDepth || c.nodes > maxNodes {
+		return &node{kind: anyKind, fallback: true, schema: v}, nil
+	}
+	// The expression of the evaluated value has references resolved.
+	op, args := v.Eval().Expr()
+	if op == cue.NoOp && len(args) == 1 {
+		// A disjunction of which all defaults are subsumed by other disjuncts
+		// is reported as its single remaining disjunct.
+		if xop, xargs := args[0].Eval().Expr(); xop != cue.NoOp {
+			op, args = xop, xargs
+		}
+	}
+	if op == cue.OrOp {
+		n := &node{kind: anyKind}
+		var first error
+		for _, a := range args {
+			x, err := c.compile(a, depth)
+			if err != nil {
+				// Disjuncts that are errors never match.
+				if first == nil {
+					first = err
+				}
+				continue
+			}
+			n.anyOf = append(n.anyOf, x)
+		}
+		if len(n.anyOf) == 0 {
+			return nil, first
+		}
+		return n, nil
+	}
+
+	k := v.IncompleteKind()
+	switch {
+	case k == cue.StructKind:
+		return c.structNode(v, depth)
+	case k == cue.ListKind:
+		return c.listNode(v, depth)
+	case v.IsConcrete():
+		x, ok := constantOf(v)
+		if !ok {
+			break
+		}
+		return &node{kind: x.kind, consts: []constant{x}}, nil
+	}
+
+	switch op {
+	case cue.AndOp:
+		n := &node{kind: fromCUEKind(k)}
+		for _, a := range args {
+			x, err := c.compile(a, depth)
+			if err != nil {
+				return nil, err
+			}
+			n.allOf = append(n.allOf, x)
+		}
+		return n, nil
+
+	case cue.NoOp:
+		if k&^(cue.NullKind|cue.BoolKind|cue.NumberKind|cue.StringKind) == 0 ||
+			k == cue.TopKind {
+			return &node{kind: fromCUEKind(k)}, nil
+		}
+
+	case cue.LessThanOp, cue.LessThanEqualOp, cue.GreaterThanOp, cue.GreaterThanEqualOp:
+		if b, ok := boundOf(op, args[0]); ok {
+			return &node{kind: b.kind, bounds: []bound{b}}, nil
+		}
+
+	case cue.NotEqualOp:
+		if x, ok := constantOf(args[0]); ok {
+			return &node{kind: anyKind, notEqual: []constant{x}}, nil
+		}
+
+	case cue.RegexMatchOp, cue.NotRegexMatchOp:
+		s, err := args[0].String()
+		if err != nil {
+			break
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		if op == cue.RegexMatchOp {
+			return &node{kind: stringKind, regexps: []*regexp.Regexp{re}}, nil
+		}
+		return &node{kind: stringKind, notMatch: []*regexp.Regexp{re}}, nil
+	}
+	return &node{kind: anyKind, fallback: true, schema: v}, nil
+}
+
+func (c *compiler) structNode(v cue.Value, depth int) (*node, error) {
+	n := &node{
+		kind:   structKind,
+		fields: map[string]*field{},
+		schema: v,
+	}
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		if !sel.IsString() {
+			continue
+		}
+		fv := iter.Value()
+		x, err := c.compile(fv, depth+1)
+		if err != nil && sel.ConstraintType() != cue.OptionalConstraint {
+			return nil, err
+		}
+		f := &field{node: x, invalid: err != nil}
+		switch sel.ConstraintType() {
+		case cue.RequiredConstraint:
+			f.required = true
+		case cue.OptionalConstraint:
+		default:
+			// A regular field may be omitted from the data if the schema
+			// provides a concrete value for it.
+			f.required = fv.Validate(cue.Concrete(true)) != nil
+		}
+		name := sel.Unquoted()
+		n.fields[name] = f
+		n.names = append(n.names, name)
+	}
+	sort.Strings(n.names)
+
+	if elem := v.LookupPath(cue.MakePath(cue.AnyString)); elem.Exists() {
+		x, err := c.compile(elem, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n.rest = x
+	} else {
+		n.open = v.Allows(cue.AnyString)
+	}
+	return n, nil
+}
+
+func (c *compiler) listNode(v cue.Value, depth int) (*node, error) {
+	n := &node{kind: listKind, isList: true}
+	iter, err := v.List()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		x, err := c.compile(iter.Value(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n.elems = append(n.elems, x)
+	}
+	if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+		x, err := c.compile(elem, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n.elemRest = x
+	}
+	return n, nil
+}
+
+func constantOf(v cue.Value) (constant, bool) {
+	switch v.Kind() {
+	case cue.NullKind:
+		return constant{kind: nullKind}, true
+	case cue.BoolKind:
+		b, err := v.Bool()
+		return constant{kind: boolKind, b: b}, err == nil
+	case cue.IntKind, cue.FloatKind:
+		n, ok := numberOf(v)
+		return constant{kind: numKind(n), n: n}, ok
+	case cue.StringKind:
+		s, err := v.String()
+		return constant{kind: stringKind, s: s}, err == nil
+	}
+	return constant{}, false
+}
+
+func numberOf(v cue.Value) (number, bool) {
+	if v.Kind() == cue.IntKind {
+		if i, err := v.Int64(); err == nil {
+			return intNumber(i), true
+		}
+		f, err := v.Float64()
+		return number{isInt: true, f: f}, err == nil || isRangeErr(err)
+	}
+	f, err := v.Float64()
+	return number{f: f}, err == nil || isRangeErr(err)
+}
+
+func boundOf(op cue.Op, v cue.Value) (bound, bool) {
+	switch v.Kind() {
+	case cue.IntKind, cue.FloatKind:
+		n, ok := numberOf(v)
+		return bound{op: op, kind: numberKind, n: n}, ok
+	case cue.StringKind:
+		s, err := v.String()
+		return bound{op: op, kind: stringKind, s: s}, err == nil
+	}
+	return bound{}, false
+}
+
+// check reports whether x matches n. The path of x is path.
+func (n *node) check(x any, path []string) error {
+	if n.fallback {
+		return unify(n.schema, x, path)
+	}
+	k, num, ok := kindOf(x)
+	if !ok {
+		return newError(path, "unsupported value of type %T", x)
+	}
+	if k&n.kind == 0 {
+		return newError(path, "conflicting values %s and %v (mismatched types %v and %v)",
+			formatValue(x), n.kind, k, n.kind)
+	}
+	if n.consts != nil {
+		found := false
+		for _, c := range n.consts {
+			if c.equals(k, x, num) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return newError(path, "conflicting values %s and %v", formatValue(x), n.consts[0])
+		}
+	}
+	for _, b := range n.bounds {
+		if !b.holds(k, x, num) {
+			return newError(path, "invalid value %s (out of bound %v)", formatValue(x), b)
+		}
+	}
+	for _, c := range n.notEqual {
+		if c.equals(k, x, num) {
+			return newError(path, "invalid value %s (out of bound !=%v)", formatValue(x), c)
+		}
+	}
+	for _, re := range n.regexps {
+		if !re.MatchString(x.(string)) {
+			return newError(path, "invalid value %s (out of bound =~%q)", formatValue(x), re)
+		}
+	}
+	for _, re := range n.notMatch {
+		if re.MatchString(x.(string)) {
+			return newError(path, "invalid value %s (out of bound !~%q)", formatValue(x), re)
+		}
+	}
+	for _, a := range n.allOf {
+		if err := a.check(x, path); err != nil {
+			return err
+		}
+	}
+	if n.anyOf != nil {
+		if err := n.checkAnyOf(x, path); err != nil {
+			return err
+		}
+	}
+	switch k {
+	case structKind:
+		if n.fields != nil {
+			return n.checkStruct(x.(map[string]any), path)
+		}
+	case listKind:
+		if n.isList {
+			return n.checkList(x.([]any), path)
+		}
+	}
+	return nil
+}
+
+func (n *node) checkAnyOf(x any, path []string) error {
+	var first error
+	for _, a := range n.anyOf {
+		err := a.check(x, path)
+		if err == nil {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if len(n.anyOf) == 1 {
+		return first
+	}
+	return newError(path, "%s does not match any of %d disjuncts (first error: %v)",
+		formatValue(x), len(n.anyOf), first)
+}
+
+func (n *node) checkStruct(m map[string]any, path []string) error {
+	for _, name := range n.names {
+		f := n.fields[name]
+		x, ok := m[name]
+		if !ok {
+			if f.required {
+				return newError(append(path, name), "field is required but not present")
+			}
+			continue
+		}
+		if f.invalid {
+			return unify(n.schema, m, path)
+		}
+		if err := f.node.check(x, append(path, name)); err != nil {
+			return err
+		}
+	}
+	var extra []string
+	for name := range m {
+		if _, ok := n.fields[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	// Check fields in a deterministic order to report errors reliably.
+	sort.Strings(extra)
+	for _, name := range extra {
+		switch {
+		case n.rest != nil:
+			if err := n.rest.check(m[name], append(path, name)); err != nil {
+				return err
+			}
+		case n.open:
+		case n.schema.Allows(cue.Str(name)):
+			// The field matches a pattern constraint that cannot be
+			// checked directly.
+			return unify(n.schema, m, path)
+		default:
+			return newError(append(path, name), "field not allowed")
+		}
+	}
+	return nil
+}
+
+func (n *node) checkList(a []any, path []string) error {
+	if n.elemRest == nil && len(a) > len(n.elems) {
+		return newError(path, "incompatible list lengths (%d and %d)", len(n.elems), len(a))
+	}
+	if len(a) < len(n.elems) {
+		return newError(path, "incompatible list lengths (%d and %d)", len(n.elems), len(a))
+	}
+	for i, x := range a {
+		e := n.elemRest
+		if i < len(n.elems) {
+			e = n.elems[i]
+		}
+		if err := e.check(x, append(path, strconv.Itoa(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unify checks x by unifying it with schema.
+func unify(schema cue.Value, x any, path []string) error {
+	b, err := json.Marshal(x)
+	if err != nil {
+		return newError(path, "%v", err)
+	}
+	expr, err := cuejson.Extract("", b)
+	if err != nil {
+		return newError(path, "%v", err)
+	}
+	d := schema.Context().BuildExpr(expr)
+	err = schema.Unify(d).Validate(cue.Concrete(true))
+	if err == nil {
+		return nil
+	}
+	// Report the first error relative to the path of x.
+	e := errors.Errors(err)[0]
+	p := append([]string(nil), path...)
+	if ep, n := e.Path(), len(schema.Path().Selectors()); len(ep) >= n {
+		p = append(p, ep[n:]...)
+	}
+	format, args := e.Msg()
+	return newError(p, format, args...)
+}
+
+// formatValue formats the data value x for use in error messages.
+func formatValue(x any) string {
+	switch x := x.(type) {
+	case []any:
+		return "list"
+	case map[string]any:
+		return "struct"
+	case float64, float32:
+		_, n, _ := kindOf(x)
+		return n.String()
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		return fmt.Sprint(x)
+	}
+	return string(b)
+}
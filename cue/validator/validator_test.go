@@ -0,0 +1,266 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/validator"
+)
+
+const schema = `
+import "strings"
+
+#Port: int & >0 & <65536
+
+#Service: {
+	name!:    =~"^[a-z][a-z0-9-]*$" & !="default"
+	kind:     *"http" | "grpc" | "tcp"
+	version:  "v1"
+	replicas: uint8 | *1
+	weight?:  number & >=0 & <=1
+	ports: [...#Port]
+	pair?:   [string, int]
+	labels?: [string]: string
+	owner?:  strings.MinRunes(3)
+	tls?:    null | {cert: string, key: string}
+	backend?: {host: string, port: #Port} | {socket: string}
+	annotations?: {[=~"^x-"]: string, team?: string}
+	open?: {a?: int, ...}
+}
+
+#Service
+`
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		data string
+		err  string // empty if the data is valid
+	}{{
+		data: `{"name": "web", "ports": [80, 443]}`,
+	}, {
+		data: `{"name": "web", "kind": "grpc", "version": "v1", "replicas": 3, "ports": []}`,
+	}, {
+		data: `{"name": "web", "ports": [], "weight": 0.5, "pair": ["a", 1], "labels": {"app": "web"}}`,
+	}, {
+		data: `{"name": "web", "ports": [], "owner": "team", "tls": null, "backend": {"socket": "/s"}}`,
+	}, {
+		data: `{"name": "web", "ports": [], "tls": {"cert": "c", "key": "k"}, "annotations": {"x-a": "b", "team": "t"}}`,
+	}, {
+		data: `{"name": "web", "ports": [], "open": {"a": 1, "b": true}}`,
+	}, {
+		data: `{"ports": []}`,
+		err:  "name: field is required but not present",
+	}, {
+		data: `{"name": "Web", "ports": []}`,
+		err:  "name: invalid value \"Web\"",
+	}, {
+		data: `{"name": "default", "ports": []}`,
+		err:  "name: invalid value \"default\" (out of bound !=\"default\")",
+	}, {
+		data: `{"name": "web"}`,
+	}, {
+		data: `{"name": "web", "ports": [80, 0]}`,
+		err:  "ports.1: invalid value 0 (out of bound >0)",
+	}, {
+		data: `{"name": "web", "ports": [80.5]}`,
+		err:  "ports.0: conflicting values 80.5 and int",
+	}, {
+		data: `{"name": "web", "ports": [], "kind": "udp"}`,
+		err:  "kind: \"udp\" does not match any of 3 disjuncts",
+	}, {
+		data: `{"name": "web", "ports": [], "version": "v2"}`,
+		err:  "version: conflicting values \"v2\" and \"v1\"",
+	}, {
+		data: `{"name": "web", "ports": [], "replicas": 256}`,
+		err:  "replicas: invalid value 256 (out of bound <=255)",
+	}, {
+		data: `{"name": "web", "ports": [], "weight": 2}`,
+		err:  "weight: invalid value 2 (out of bound <=1)",
+	}, {
+		data: `{"name": "web", "ports": [], "pair": ["a"]}`,
+		err:  "pair: incompatible list lengths (2 and 1)",
+	}, {
+		data: `{"name": "web", "ports": [], "pair": ["a", 1, 2]}`,
+		err:  "pair: incompatible list lengths (2 and 3)",
+	}, {
+		data: `{"name": "web", "ports": [], "labels": {"app": 1}}`,
+		err:  "labels.app: conflicting values 1 and string",
+	}, {
+		data: `{"name": "web", "ports": [], "owner": "me"}`,
+		err:  "owner: ",
+	}, {
+		data: `{"name": "web", "ports": [], "tls": {"cert": "c"}}`,
+		err:  "tls: struct does not match any of 2 disjuncts",
+	}, {
+		data: `{"name": "web", "ports": [], "backend": {"host": "h", "port": 1, "socket": "s"}}`,
+		err:  "backend: struct does not match any of 2 disjuncts",
+	}, {
+		data: `{"name": "web", "ports": [], "annotations": {"y-a": "b"}}`,
+		err:  "annotations.y-a: field not allowed",
+	}, {
+		data: `{"name": "web", "ports": [], "annotations": {"x-a": 1}}`,
+		err:  `annotations."x-a": conflicting values 1 and string`,
+	}, {
+		data: `{"name": "web", "ports": [], "other": 1}`,
+		err:  "other: field not allowed",
+	}, {
+		data: `{"name": "web", "ports": [], "open": {"a": "x"}}`,
+		err:  "open.a: conflicting values \"x\" and int",
+	}}
+	ctx := cuecontext.New()
+	s := ctx.CompileString(schema)
+	v, err := validator.Compile(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			err := v.ValidateJSON([]byte(tc.data))
+			switch {
+			case tc.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.err != "" && err == nil:
+				t.Errorf("got no error; want %q", tc.err)
+			case tc.err != "" && !strings.HasPrefix(err.Error(), tc.err):
+				t.Errorf("got error %q; want %q", err, tc.err)
+			}
+
+			// The validator must agree with unification.
+			d := ctx.CompileString(tc.data)
+			uerr := s.Unify(d).Validate(cue.Concrete(true))
+			if (err == nil) != (uerr == nil) {
+				t.Errorf("validator error %v differs from unification error %v", err, uerr)
+			}
+		})
+	}
+}
+
+func TestValidateGoValues(t *testing.T) {
+	ctx := cuecontext.New()
+	v, err := validator.Compile(ctx.CompileString(`{
+		n: int & <10
+		f: float
+		l: [...bool]
+		s: {[string]: null | string}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := map[string]any{
+		"n": float64(3), // as decoded by encoding/json
+		"f": 1.5,
+		"l": []any{true, false},
+		"s": map[string]any{"a": nil, "b": "c"},
+	}
+	if err := v.Validate(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	invalid := map[string]any{
+		"n": uint64(12),
+		"f": 1.5,
+		"l": []any{},
+		"s": map[string]any{},
+	}
+	err = v.Validate(invalid)
+	want := "n: invalid value 12 (out of bound <10)"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %q", err, want)
+	}
+	if _, ok := err.(*validator.Error); !ok {
+		t.Errorf("got error of type %T; want *validator.Error", err)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	ctx := cuecontext.New()
+	_, err := validator.Compile(ctx.CompileString(`a: int & string`))
+	if err == nil {
+		t.Errorf("got no error for invalid schema")
+	}
+}
+
+func TestRecursive(t *testing.T) {
+	ctx := cuecontext.New()
+	v, err := validator.Compile(ctx.CompileString(`
+	#Tree: {value: int, left?: #Tree, right?: #Tree}
+	#Tree
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `{"value": 1}`
+	for i := 0; i < 100; i++ {
+		data = fmt.Sprintf(`{"value": %d, "left": %s}`, i, data)
+	}
+	if err := v.ValidateJSON([]byte(data)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	data = strings.Replace(data, `"value": 1}`, `"value": "x"}`, 1)
+	if err := v.ValidateJSON([]byte(data)); err == nil {
+		t.Errorf("got no error for invalid data")
+	}
+}
+
+func TestConcurrentValidate(t *testing.T) {
+	ctx := cuecontext.New()
+	v, err := validator.Compile(ctx.CompileString(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := v.ValidateJSON([]byte(`{"name": "a", "ports": [1], "owner": "abc"}`)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkValidate(b *testing.B) {
+	ctx := cuecontext.New()
+	s := ctx.CompileString(schema)
+	data := []byte(`{"name": "web", "kind": "grpc", "ports": [80, 443], "labels": {"app": "web", "tier": "front"}, "tls": {"cert": "c", "key": "k"}}`)
+	b.Run("validator", func(b *testing.B) {
+		v, err := validator.Compile(s)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < b.N; i++ {
+			if err := v.ValidateJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("unify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			d := ctx.CompileBytes(data)
+			if err := s.Unify(d).Validate(cue.Concrete(true)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -52,6 +52,18 @@
 // is considered undefined if it is a nil pointer type or if it is a zero
 // value and there is a JSON field tag with the omitempty flag.
 // A Complete will implicitly validate a struct.
+//
+// # Generating Field Tags
+//
+// GenerateTags derives cue field tags for existing Go types from a CUE
+// schema, which helps to keep both in sync when migrating from one to the
+// other.
+//
+// # Generating Validators
+//
+// Context.GenerateCode generates Go code with validation methods for the
+// types of a package from the constraints collected by a Context, using
+// package gocode. This avoids analyzing the Go types at run time.
 package cuego // import "cuelang.org/go/cuego"
 
 // The first goal of this packages is to get the semantics right. After that,
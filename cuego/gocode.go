@@ -0,0 +1,83 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuego
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/gocode"
+)
+
+// GenerateCode generates Go source code with validation methods for the
+// types of the Go package with import path pkgPath that have constraints in
+// c. The constraints are those derived from field tags and those added with
+// Constrain, for any type that was passed to Constrain, Validate, or
+// Complete before the call. Constraints for a type T and *T are combined.
+//
+// The generated code, typically written to a file in the package at
+// pkgPath, embeds the constraints in a precompiled form, so that the Go
+// types need not be analyzed at run time. See gocode.Generate for the
+// generated declarations and the options in config. As the constraints are
+// derived from the Go types, field tags remain the source of truth; the code
+// needs to be regenerated if they change.
+//
+// GenerateCode is typically called from a program run with go:generate that
+// imports the package at pkgPath to register its constraints.
+func (c *Context) GenerateCode(pkgPath string, config *gocode.Config) ([]byte, error) {
+	values := map[string]cue.Value{}
+	c.typeCache.Range(func(key, value interface{}) bool {
+		t := key.(reflect.Type)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.PkgPath() != pkgPath || t.Name() == "" {
+			return true
+		}
+		v := value.(cue.Value)
+		if w, ok := values[t.Name()]; ok {
+			v = w.Unify(v)
+		}
+		values[t.Name()] = v
+		return true
+	})
+	if len(values) == 0 {
+		return nil, fmt.Errorf("cuego: no constraints for types in package %q", pkgPath)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mutex.Lock()
+	inst := instance
+	for _, name := range names {
+		var err error
+		inst, err = inst.Fill(values[name], name)
+		if err != nil {
+			mutex.Unlock()
+			return nil, err
+		}
+	}
+	mutex.Unlock()
+	inst.PkgName = path.Base(pkgPath)
+
+	return gocode.Generate(pkgPath, inst, config)
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuego
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"cuelang.org/go/cuego/testdata/gen"
+	"cuelang.org/go/internal/cuetest"
+)
+
+func TestGenerateCode(t *testing.T) {
+	c := &Context{}
+	if err := c.Constrain(&gen.Request{}, `{replicas: <=5}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Constrain(gen.Port(0), `>0 & <65536`); err != nil {
+		t.Fatal(err)
+	}
+	c.load(Sum{}) // ignored: not in package gen
+
+	b, err := c.GenerateCode("cuelang.org/go/cuego/testdata/gen", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const filename = "testdata/gen/cue_gen.go"
+	if cuetest.UpdateGoldenFiles {
+		if err := os.WriteFile(filename, b, 0o666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The compressed instance data depends on the version of compress/gzip,
+	// so compare the code and the decompressed data separately.
+	gotCode, gotData := splitInstanceData(t, b)
+	wantCode, wantData := splitInstanceData(t, want)
+	if gotCode != wantCode || !bytes.Equal(gotData, wantData) {
+		t.Errorf("generated code differs from %s; run with CUE_UPDATE=1 to update:\n%s", filename, b)
+	}
+}
+
+var instanceDataRE = regexp.MustCompile(`(?m)^// Data size: \d+ bytes.\nvar cuegenInstanceData = \[\]byte\((".*")\)$`)
+
+// splitInstanceData returns the generated code b without the instance data
+// and the data decompressed.
+func splitInstanceData(t *testing.T, b []byte) (code string, data []byte) {
+	m := instanceDataRE.FindSubmatchIndex(b)
+	if m == nil {
+		t.Fatalf("no instance data in generated code:\n%s", b)
+	}
+	s, err := strconv.Unquote(string(b[m[2]:m[3]]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first byte holds the version of the format.
+	zr, err := gzip.NewReader(bytes.NewReader([]byte(s[1:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code = string(b[:m[0]]) + s[:1] + string(b[m[1]:])
+	return code, data
+}
+
+func TestGeneratedValidators(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value interface{ Validate() error }
+		ok    bool
+	}{{
+		name:  "valid",
+		value: &gen.Request{Name: "web", Replicas: 3, Port: 8080},
+		ok:    true,
+	}, {
+		name:  "invalid name from tag",
+		value: &gen.Request{Name: "Web", Replicas: 3, Port: 8080},
+	}, {
+		name:  "too many replicas from Constrain",
+		value: &gen.Request{Name: "web", Replicas: 6, Port: 8080},
+	}, {
+		name:  "invalid port",
+		value: gen.Port(0),
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.value.Validate()
+			if ok := err == nil; ok != tc.ok {
+				t.Errorf("got error %v; want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuego
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	goformat "go/format"
+	goparser "go/parser"
+	gotoken "go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/token"
+)
+
+// GenerateTags annotates the Go struct types declared in the package in
+// directory pkgDir with `cue:` field tags derived from schema. This keeps Go
+// types in sync with a CUE schema when migrating from one representation to
+// the other. It is typically run from a go:generate directive.
+//
+// A Go struct type T is matched with the definition #T in schema, or with
+// the field T if there is no such definition. A Go struct field is matched
+// with the CUE field named by its json tag, or by its Go name if there is no
+// such tag. The cue tag of a matched field is set to the constraints of the
+// CUE field, leaving out basic types, like int, that are already implied by
+// the Go type.
+//
+// Fields that are structs or lists, or whose constraints cannot be written as
+// a tag, for instance because they refer to an imported package, are left
+// unchanged. Source files are only rewritten if they change.
+func GenerateTags(schema cue.Value, pkgDir string) error {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if err := generateFileTags(schema, filepath.Join(pkgDir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generateFileTags(schema cue.Value, filename string) error {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	fset := gotoken.NewFileSet()
+	f, err := goparser.ParseFile(fset, filename, src, goparser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	goast.Inspect(f, func(n goast.Node) bool {
+		spec, ok := n.(*goast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*goast.StructType)
+		if !ok {
+			return false
+		}
+		v := lookupField(schema, spec.Name.Name, true)
+		if !v.Exists() {
+			return false
+		}
+		for _, field := range st.Fields.List {
+			if len(field.Names) == 0 {
+				continue // embedded
+			}
+			if setTag(v, field) {
+				changed = true
+			}
+		}
+		return false
+	})
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := goformat.Node(&buf, fset, f); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0o666)
+}
+
+// lookupField looks up the field or, if def is true, preferably the
+// definition with the given name in v.
+func lookupField(v cue.Value, name string, def bool) cue.Value {
+	if def {
+		if w := v.LookupPath(cue.MakePath(cue.Def(name))); w.Exists() {
+			return w
+		}
+	}
+	if w := v.LookupPath(cue.MakePath(cue.Str(name))); w.Exists() {
+		return w
+	}
+	return v.LookupPath(cue.MakePath(cue.Str(name).Optional()))
+}
+
+// setTag sets the cue tag of field to the constraints of its counterpart in
+// the struct v and reports whether this changed the tag.
+func setTag(v cue.Value, field *goast.Field) bool {
+	var tag string
+	if field.Tag != nil {
+		var err error
+		tag, err = strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return false
+		}
+	}
+	if !field.Names[0].IsExported() {
+		return false
+	}
+	name := field.Names[0].Name
+	if s, ok := lookupTag(tag, "json"); ok {
+		s, _, _ = strings.Cut(s, ",")
+		switch s {
+		case "-":
+			return false
+		case "":
+		default:
+			name = s
+		}
+	}
+
+	w := lookupField(v, name, false)
+	if !w.Exists() {
+		return false
+	}
+	switch w.IncompleteKind() {
+	case cue.StructKind, cue.ListKind:
+		return false
+	}
+	expr, ok := constraintExpr(w)
+	if !ok {
+		return false
+	}
+
+	newTag := replaceTag(tag, "cue", expr)
+	if newTag == tag || strings.Contains(newTag, "`") {
+		return false
+	}
+	if field.Tag == nil {
+		field.Tag = &goast.BasicLit{ValuePos: field.Type.End(), Kind: gotoken.STRING}
+	}
+	field.Tag.Value = "`" + newTag + "`"
+	return true
+}
+
+var basicTypes = map[string]bool{
+	"_":      true,
+	"null":   true,
+	"bool":   true,
+	"int":    true,
+	"float":  true,
+	"number": true,
+	"string": true,
+	"bytes":  true,
+}
+
+// constraintExpr returns the constraints of v, as a single line of CUE, that
+// are not implied by a Go type.
+func constraintExpr(v cue.Value) (string, bool) {
+	x, ok := v.Syntax(cue.Raw()).(ast.Expr)
+	if !ok {
+		return "", false
+	}
+
+	var conjuncts []ast.Expr
+	var split func(x ast.Expr)
+	split = func(x ast.Expr) {
+		if b, ok := x.(*ast.BinaryExpr); ok && b.Op == token.AND {
+			split(b.X)
+			split(b.Y)
+			return
+		}
+		if id, ok := x.(*ast.Ident); ok && basicTypes[id.Name] {
+			return
+		}
+		conjuncts = append(conjuncts, x)
+	}
+	split(x)
+	if len(conjuncts) == 0 {
+		return "", false
+	}
+
+	b, err := format.Node(ast.NewBinExpr(token.AND, conjuncts...))
+	if err != nil || bytes.ContainsRune(b, '\n') {
+		return "", false
+	}
+	return string(b), true
+}
+
+// lookupTag is like reflect.StructTag.Lookup.
+func lookupTag(tag, key string) (string, bool) {
+	for _, kv := range parseTag(tag) {
+		if kv.key == key {
+			return kv.value, true
+		}
+	}
+	return "", false
+}
+
+// replaceTag sets the value of key in tag, adding it at the end if it does
+// not exist yet.
+func replaceTag(tag, key, value string) string {
+	kvs := parseTag(tag)
+	found := false
+	for i := range kvs {
+		if kvs[i].key == key {
+			kvs[i].value = value
+			found = true
+		}
+	}
+	if !found {
+		kvs = append(kvs, tagPair{key, value})
+	}
+	var parts []string
+	for _, kv := range kvs {
+		parts = append(parts, fmt.Sprintf("%s:%s", kv.key, strconv.Quote(kv.value)))
+	}
+	return strings.Join(parts, " ")
+}
+
+type tagPair struct {
+	key, value string
+}
+
+// parseTag parses a struct tag following the conventions of reflect.StructTag.
+func parseTag(tag string) (kvs []tagPair) {
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		tag = tag[i+1:]
+		kvs = append(kvs, tagPair{key, value})
+	}
+	return kvs
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cuego
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestGenerateTags(t *testing.T) {
+	const schema = `
+import "strings"
+
+#Person: {
+	name:     string & strings.MinRunes(1)
+	age?:     int & >=0 & <150
+	email:    =~"@"
+	nickname: string
+	address:  {street: string}
+	tags:     [...string]
+	Score:    *0 | >=0
+}
+
+Config: {
+	level: "debug" | "info"
+}
+`
+	const in = `package p
+
+type Person struct {
+	Name     string
+	Age      int    ` + "`json:\"age,omitempty\"`" + `
+	Email    string ` + "`json:\"email\" cue:\"string\"`" + `
+	Nickname string ` + "`json:\"nickname\"`" + `
+	Address  struct{ Street string }
+	Tags     []string ` + "`json:\"tags\"`" + `
+	Score    int
+	Ignored  int ` + "`json:\"-\"`" + `
+	internal int
+}
+
+type Config struct {
+	Level string ` + "`json:\"level\"`" + `
+}
+
+type Unknown struct {
+	Level string
+}
+`
+	const want = `package p
+
+type Person struct {
+	Name     string
+	Age      int    ` + "`json:\"age,omitempty\" cue:\">=0 & <150\"`" + `
+	Email    string ` + "`json:\"email\" cue:\"=~\\\"@\\\"\"`" + `
+	Nickname string ` + "`json:\"nickname\"`" + `
+	Address  struct{ Street string }
+	Tags     []string ` + "`json:\"tags\"`" + `
+	Score    int      ` + "`cue:\"*0 | >=0\"`" + `
+	Ignored  int      ` + "`json:\"-\"`" + `
+	internal int
+}
+
+type Config struct {
+	Level string ` + "`json:\"level\" cue:\"\\\"debug\\\" | \\\"info\\\"\"`" + `
+}
+
+type Unknown struct {
+	Level string
+}
+`
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "p.go")
+	if err := os.WriteFile(filename, []byte(in), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	v := cuecontext.New().CompileString(schema)
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateTags(v, dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Running it again should not change anything.
+	if err := GenerateTags(v, dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err = os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("second run changed output:\n%s", got)
+	}
+}
@@ -0,0 +1,62 @@
+// Code generated by gocode.Generate; DO NOT EDIT.
+
+package gen
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/gocode/gocodec"
+	_ "cuelang.org/go/pkg"
+)
+
+var cuegenvalPort = cuegenMake("Port", nil)
+
+// Validate validates x.
+func (x Port) Validate() error {
+	return cuegenCodec.Validate(cuegenvalPort, x)
+}
+
+var cuegenvalRequest = cuegenMake("Request", &Request{})
+
+// Validate validates x.
+func (x *Request) Validate() error {
+	return cuegenCodec.Validate(cuegenvalRequest, x)
+}
+
+var cuegenCodec, cuegenInstance_, cuegenValue = func() (*gocodec.Codec, *cue.Instance, cue.Value) {
+	var r *cue.Runtime
+	r = &cue.Runtime{}
+	instances, err := r.Unmarshal(cuegenInstanceData)
+	if err != nil {
+		panic(err)
+	}
+	if len(instances) != 1 {
+		panic("expected encoding of exactly one instance")
+	}
+	return gocodec.New(r, nil), instances[0], instances[0].Value()
+}()
+
+// Deprecated: cue.Instance is deprecated. Use cuegenValue instead.
+var cuegenInstance = cuegenInstance_
+
+// cuegenMake is called in the init phase to initialize CUE values for
+// validation functions.
+func cuegenMake(name string, x interface{}) cue.Value {
+	f, err := cuegenValue.FieldByName(name, true)
+	if err != nil {
+		panic(fmt.Errorf("could not find type %q in instance", name))
+	}
+	v := f.Value
+	if x != nil {
+		w, err := cuegenCodec.ExtractType(x)
+		if err != nil {
+			panic(err)
+		}
+		v = v.Unify(w)
+	}
+	return v
+}
+
+// Data size: 278 bytes.
+var cuegenInstanceData = []byte("\x01\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xffD\x8e\xcfJ\xf3@\x14G\xef\x9d\xe6\x83/C\xf5\r\x84K\x91R\x15\xa5E\x9bEh\\\x89K)\u0756\n\x970\xb6\x83\x93IL&\x1b\xb5\xfe\xa9\u0557\xf01|\xc0\x91\x14\xb5\xdb\x03\xe7\xfc~;\xfe]\xa0\xf0\x1f\x80\xfe\x05 zn!\xb6\xb5\xad\x1c\xdbT]\xb0\xe3\x06c\v\x83I\x9e;\x14\x80\xc1\x98\xdd\x02\u06c0\xff.\xb5Q\x15\xfa5\x00\xec\xf97\x81\xb8;\x9d\xa5\xb5:\xb9\xd1\xe6\xc7\\\x03\xfa\x15@\u03ff\xb6\x10\xffo\xf9\nP`p\u0159jB\xc1\x06J\x00\xf0_\xcd\x0fD\x81\x18\xd66\xe3\xb2Z\xb0A\xffYpz\xcbsEse\xa5\x1c\u794b\x89\x88jm\x1du\xe9\xbcO]\x1aE\xc3\xe1i$'\xea\xaeV\x95\x8b\xa9whkc\xe8\x91\x1edh9S\x1b\x81\x92\xa7\xce\xf5\x94\x8f\xefgG\xfb\x1d\x19\x96\xaa0:\xe5*\xfe+%\x83&\x95\f\xfa2,~WH[\x17\x9d\xc9\xe5\x01u\x9b\xd8V\x1a%C\xb9\x94\x00\xdf\x01\x00\x00\xff\xff\xa1\u01a0\xabA\x01\x00\x00")
@@ -0,0 +1,110 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package avro converts Apache Avro schemas to CUE and back.
+//
+// Avro schemas are presented in CUE, so any of the supported encodings that
+// can represent JSON, typically the JSON of an .avsc file, can be used as a
+// source.
+//
+// The named types of a schema, records, enums and fixed, are mapped to CUE
+// definitions named after the type, without its namespace:
+//
+//	Avro                      CUE
+//	null                      null
+//	boolean                   bool
+//	int, long                 int32, int64
+//	float, double             float32, float64
+//	bytes, string             bytes, string
+//	record                    #Name: {fields}
+//	enum                      #Name: "A" | "B"
+//	fixed                     #Name: bytes @avro(fixed,size=n)
+//	array                     [...T]
+//	map                       {[string]: T}
+//	union                     T1 | T2
+//
+// A field default is mapped to a CUE default. Information that is not
+// represented in the CUE types, such as the namespace of a type or the logical
+// type of a field, is recorded in @avro attributes, so that the original
+// schema can be recreated. For instance,
+//
+//	{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+//
+// is converted to
+//
+//	created: int64 @avro(logicalType="timestamp-millis")
+package avro
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+)
+
+// Config configures the conversion of Avro schemas.
+type Config struct {
+	// PkgName is the package name of the generated CUE file. If it is empty,
+	// no package clause is generated.
+	PkgName string
+
+	// The following fields only apply to Generate.
+
+	// Root is the path of the definition that is converted to the schema,
+	// such as "#User". If it is empty, the schema is generated from the only
+	// definition of the value or, if it has several, as a union of its
+	// definitions that map to named types and are not already defined as
+	// part of a preceding type.
+	Root string
+
+	// Namespace is the namespace of the named types that do not specify one
+	// with an @avro(namespace=x) attribute.
+	Namespace string
+}
+
+// Extract converts the Avro schema data, typically the contents of an .avsc
+// file, to a CUE file with a definition for each of its named types.
+//
+// A schema that is not a named type, or a union of named types, is also
+// included as an embedded value of the file.
+func Extract(data cue.InstanceOrValue, cfg *Config) (*ast.File, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	d := &decoder{
+		cfg:    cfg,
+		labels: map[string]string{},
+		names:  map[string]string{},
+	}
+	f := d.decode(data.Value())
+	return f, d.errs
+}
+
+// Generate converts the definitions of v to an Avro schema. See Config.Root
+// for which definitions are converted.
+//
+// The value of a definition must map to an Avro type. Constraints that cannot
+// be represented in Avro, such as bounds other than the range of int32 or
+// float32, are omitted.
+func Generate(v cue.Value, cfg *Config) (*ast.File, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	g := &generator{
+		cfg:     cfg,
+		root:    v,
+		emitted: map[string]bool{},
+		names:   map[string]bool{},
+	}
+	f := g.generate()
+	return f, g.errs
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/encoding/avro"
+)
+
+const userSchema = `{
+	"type": "record",
+	"name": "User",
+	"namespace": "com.example",
+	"doc": "A User is a registered user.",
+	"fields": [
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+		{"name": "age", "type": ["null", "int"], "default": null},
+		{"name": "score", "type": "double", "default": 1.5},
+		{"name": "kind", "type": {
+			"type": "enum",
+			"name": "Kind",
+			"symbols": ["ADMIN", "GUEST"],
+			"default": "GUEST"
+		}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "long"}, "doc": "Arbitrary attributes."},
+		{"name": "hash", "type": {"type": "fixed", "name": "MD5", "size": 16}},
+		{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "friend", "type": ["null", "User"], "default": null}
+	]
+}`
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema string
+		want   string
+	}{{
+		name:   "record",
+		schema: userSchema,
+		want: `package example
+
+// A User is a registered user.
+#User: {
+	id:    string @avro(logicalType=uuid)
+	age:   *null | int32
+	score: *1.5 | float64
+	kind:  #Kind
+	tags: [...string]
+	// Arbitrary attributes.
+	attrs: {
+		[string]: int64
+	}
+	hash:    #MD5
+	created: int64 @avro(logicalType="timestamp-millis")
+	friend:  *null | #User
+} @avro(namespace="com.example")
+#Kind: "ADMIN" | *"GUEST" @avro(namespace="com.example")
+#MD5:  bytes              @avro(namespace="com.example",fixed,size=16)
+`,
+	}, {
+		name:   "union",
+		schema: `[{"type": "record", "name": "A", "fields": []}, {"type": "enum", "name": "B", "symbols": ["X"]}]`,
+		want: `package example
+
+#A: {}
+#B: "X"
+`,
+	}, {
+		name:   "unnamed",
+		schema: `{"type": "array", "items": ["string", "bytes"]}`,
+		want: `package example
+
+[...string | bytes]
+`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := cuecontext.New().CompileString(tc.schema)
+			f, err := avro.Extract(v, &avro.Config{PkgName: "example"})
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	testCases := []struct {
+		schema string
+		err    string
+	}{{
+		schema: `"Unknown"`,
+		err:    `undefined type "Unknown"`,
+	}, {
+		schema: `{"type": "array"}`,
+		err:    `missing items`,
+	}, {
+		schema: `[{"type": "fixed", "name": "a.X", "size": 1}, {"type": "fixed", "name": "b.X", "size": 1}]`,
+		err:    `type "b.X" has the same name as type "a.X"`,
+	}}
+	for _, tc := range testCases {
+		v := cuecontext.New().CompileString(tc.schema)
+		_, err := avro.Extract(v, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %v; want %q", tc.schema, err, tc.err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := cuecontext.New()
+	f, err := avro.Extract(ctx.CompileString(userSchema), nil)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	v := ctx.BuildFile(f)
+	if err := v.Err(); err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	out, err := avro.Generate(v, &avro.Config{Root: "#User"})
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	got := toJSON(t, ctx.BuildFile(out))
+	want := toJSON(t, ctx.CompileString(userSchema))
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name string
+		cue  string
+		cfg  *avro.Config
+		want string
+	}{{
+		name: "optional",
+		cue: `
+		// A Point in space.
+		#Point: {
+			x:      int
+			y?:     float32
+			label?: string | null
+			mode:   *"fast" | "slow"
+			data:   *'\x01' | bytes
+			n:      *2 | int & <10
+		}`,
+		want: `{
+  "type": "record",
+  "name": "Point",
+  "doc": "A Point in space.",
+  "fields": [
+    {"name": "x", "type": "long"},
+    {"name": "y", "type": ["null", "float"], "default": null},
+    {"name": "label", "type": ["null", "string"], "default": null},
+    {"name": "mode", "type": {"type": "enum", "name": "Mode", "symbols": ["fast", "slow"], "default": "fast"}, "default": "fast"},
+    {"name": "data", "type": "bytes", "default": "\u0001"},
+    {"name": "n", "type": "long", "default": 2}
+  ]
+}`,
+	}, {
+		name: "nested",
+		cue: `
+		#A: {b: #B}
+		#B: {n: int32}
+		#C: string
+		`,
+		cfg: &avro.Config{Namespace: "x"},
+		want: `{"type": "record", "name": "A", "namespace": "x", "fields": [
+  {"name": "b", "type": {"type": "record", "name": "B", "fields": [
+    {"name": "n", "type": "int"}
+  ]}}
+]}`,
+	}, {
+		name: "union",
+		cue: `
+		#B: {n: int32}
+		#A: {b: #B}
+		#C: "x" | "y"
+		`,
+		want: `[
+  {"type": "record", "name": "B", "fields": [{"name": "n", "type": "int"}]},
+  {"type": "record", "name": "A", "fields": [{"name": "b", "type": "B"}]},
+  {"type": "enum", "name": "C", "symbols": ["x", "y"]}
+]`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := cuecontext.New()
+			v := ctx.CompileString(tc.cue)
+			f, err := avro.Generate(v, tc.cfg)
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			got := toJSON(t, ctx.BuildFile(f))
+			if want := toJSON(t, ctx.CompileString(tc.want)); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func toJSON(t *testing.T, v cue.Value) string {
+	t.Helper()
+	b, err := v.MarshalJSON()
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,367 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+)
+
+// primitives maps the Avro primitive types other than null to their CUE
+// types.
+var primitives = map[string]string{
+	"boolean": "bool",
+	"int":     "int32",
+	"long":    "int64",
+	"float":   "float32",
+	"double":  "float64",
+	"bytes":   "bytes",
+	"string":  "string",
+}
+
+// A decoder converts an Avro schema to CUE.
+type decoder struct {
+	cfg  *Config
+	errs errors.Error
+
+	decls []ast.Decl
+
+	// labels maps the full names of named types to the labels of their
+	// definitions, and names maps these labels back to the full names.
+	labels map[string]string
+	names  map[string]string
+}
+
+func (d *decoder) errf(v cue.Value, format string, args ...interface{}) ast.Expr {
+	d.errs = errors.Append(d.errs, errors.Newf(v.Pos(), format, args...))
+	return &ast.BottomLit{}
+}
+
+func (d *decoder) decode(v cue.Value) *ast.File {
+	f := &ast.File{}
+	if pkgName := d.cfg.PkgName; pkgName != "" {
+		f.Decls = append(f.Decls, &ast.Package{Name: ast.NewIdent(pkgName)})
+	}
+
+	x, _ := d.schema(v, "")
+	if !d.isNamed(x) {
+		f.Decls = append(f.Decls, &ast.EmbedDecl{Expr: x})
+	}
+	f.Decls = append(f.Decls, d.decls...)
+
+	_ = astutil.Sanitize(f)
+	return f
+}
+
+// isNamed reports whether x refers to a named type or is a union of such
+// references.
+func (d *decoder) isNamed(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return d.names[x.Name] != ""
+	case *ast.BinaryExpr:
+		return x.Op == token.OR && d.isNamed(x.X) && d.isNamed(x.Y)
+	}
+	return false
+}
+
+// schema converts the Avro schema v, which is defined within namespace ns,
+// to a CUE expression. It also returns the attribute arguments that describe
+// v, if it is a primitive type with a logical type.
+func (d *decoder) schema(v cue.Value, ns string) (ast.Expr, []string) {
+	switch v.Kind() {
+	case cue.StringKind:
+		s, _ := v.String()
+		if s == "null" {
+			return ast.NewNull(), nil
+		}
+		if t, ok := primitives[s]; ok {
+			return ast.NewIdent(t), nil
+		}
+		return d.reference(v, s, ns), nil
+
+	case cue.ListKind:
+		var a []ast.Expr
+		for iter, _ := v.List(); iter.Next(); {
+			x, _ := d.schema(iter.Value(), ns)
+			a = append(a, x)
+		}
+		if len(a) == 0 {
+			return d.errf(v, "empty union"), nil
+		}
+		return ast.NewBinExpr(token.OR, a...), nil
+
+	case cue.StructKind:
+		t := v.LookupPath(cue.MakePath(cue.Str("type")))
+		s, err := t.String()
+		if err != nil {
+			// The type is itself a schema, as in {"type": {"type": "array", ...}}.
+			if !t.Exists() {
+				return d.errf(v, "missing type"), nil
+			}
+			return d.schema(t, ns)
+		}
+		switch s {
+		case "record", "error", "enum", "fixed":
+			return d.named(v, s, ns), nil
+		case "array":
+			x, _ := d.schema(d.lookup(v, "items"), ns)
+			return ast.NewList(&ast.Ellipsis{Type: x}), nil
+		case "map":
+			x, _ := d.schema(d.lookup(v, "values"), ns)
+			return ast.NewStruct(&ast.Field{
+				Label: ast.NewList(ast.NewIdent("string")),
+				Value: x,
+			}), nil
+		}
+		x, _ := d.schema(t, ns)
+		return x, logicalType(v)
+	}
+	return d.errf(v, "invalid schema %v", v), nil
+}
+
+// logicalType returns the attribute arguments for the logical type of v.
+func logicalType(v cue.Value) []string {
+	var args []string
+	for _, key := range []string{"logicalType", "precision", "scale"} {
+		if x := v.LookupPath(cue.MakePath(cue.Str(key))); x.Exists() {
+			args = append(args, attrArg(key, x))
+		}
+	}
+	return args
+}
+
+func (d *decoder) lookup(v cue.Value, key string) cue.Value {
+	x := v.LookupPath(cue.MakePath(cue.Str(key)))
+	if !x.Exists() {
+		d.errf(v, "missing %s", key)
+	}
+	return x
+}
+
+// reference returns a reference to the named type with the given name.
+func (d *decoder) reference(v cue.Value, name, ns string) ast.Expr {
+	full := fullName(name, ns)
+	if label, ok := d.labels[full]; ok {
+		return ast.NewIdent(label)
+	}
+	if label, ok := d.labels[name]; ok {
+		return ast.NewIdent(label)
+	}
+	return d.errf(v, "undefined type %q", name)
+}
+
+// named adds a definition for the named type v of the given kind and returns
+// a reference to it.
+func (d *decoder) named(v cue.Value, kind, ns string) ast.Expr {
+	name, err := d.lookup(v, "name").String()
+	if err != nil {
+		return d.errf(v, "invalid name: %v", err)
+	}
+	if s, err := v.LookupPath(cue.MakePath(cue.Str("namespace"))).String(); err == nil {
+		ns = s
+	}
+	full := fullName(name, ns)
+	if i := strings.LastIndexByte(full, '.'); i >= 0 {
+		name, ns = full[i+1:], full[:i]
+	}
+
+	label := "#" + name
+	if other, ok := d.names[label]; ok {
+		return d.errf(v, "type %q has the same name as type %q", full, other)
+	}
+	d.labels[full] = label
+	d.names[label] = full
+
+	// Add the field before converting the type, so that definitions appear
+	// in the order in which the types are defined.
+	f := &ast.Field{Label: ast.NewIdent(label)}
+	d.decls = append(d.decls, f)
+	addDoc(f, v)
+
+	var args []string
+	if ns != "" {
+		args = append(args, attrArg("namespace", ns))
+	}
+	switch kind {
+	case "record", "error":
+		if kind == "error" {
+			args = append(args, "error")
+		}
+		f.Value = d.record(v, ns)
+
+	case "enum":
+		var a []ast.Expr
+		def, _ := v.LookupPath(cue.MakePath(cue.Str("default"))).String()
+		for iter, _ := d.lookup(v, "symbols").List(); iter.Next(); {
+			s, err := iter.Value().String()
+			if err != nil {
+				d.errf(iter.Value(), "invalid symbol: %v", err)
+				continue
+			}
+			var x ast.Expr = ast.NewString(s)
+			if s == def {
+				x = &ast.UnaryExpr{Op: token.MUL, X: x}
+			}
+			a = append(a, x)
+		}
+		if len(a) == 0 {
+			f.Value = d.errf(v, "enum %q has no symbols", full)
+		} else {
+			f.Value = ast.NewBinExpr(token.OR, a...)
+		}
+
+	case "fixed":
+		size, err := d.lookup(v, "size").Int64()
+		if err != nil {
+			d.errf(v, "invalid size: %v", err)
+		}
+		args = append(args, "fixed", attrArg("size", size))
+		args = append(args, logicalType(v)...)
+		f.Value = ast.NewIdent("bytes")
+	}
+	if len(args) > 0 {
+		f.Attrs = append(f.Attrs, newAttr(args))
+	}
+	return ast.NewIdent(label)
+}
+
+// record converts the fields of the record v.
+func (d *decoder) record(v cue.Value, ns string) ast.Expr {
+	s := &ast.StructLit{}
+	for iter, _ := d.lookup(v, "fields").List(); iter.Next(); {
+		fv := iter.Value()
+		name, err := d.lookup(fv, "name").String()
+		if err != nil {
+			d.errf(fv, "invalid field name: %v", err)
+			continue
+		}
+		x, args := d.schema(d.lookup(fv, "type"), ns)
+		if def := fv.LookupPath(cue.MakePath(cue.Str("default"))); def.Exists() {
+			x = d.withDefault(x, def)
+		}
+		f := &ast.Field{Label: label(name), Value: x}
+		addDoc(f, fv)
+		if len(args) > 0 {
+			f.Attrs = append(f.Attrs, newAttr(args))
+		}
+		s.Elts = append(s.Elts, f)
+	}
+	return s
+}
+
+// withDefault marks def as the default value of x. By the Avro specification,
+// the default of a union corresponds to its first type.
+func (d *decoder) withDefault(x ast.Expr, def cue.Value) ast.Expr {
+	first := x
+	for {
+		b, ok := first.(*ast.BinaryExpr)
+		if !ok || b.Op != token.OR {
+			break
+		}
+		first = b.X
+	}
+	if lit, ok := first.(*ast.BasicLit); ok && lit.Kind == token.NULL && def.Kind() == cue.NullKind {
+		// Avoid the redundant *null | null | T.
+		return replaceFirst(x, &ast.UnaryExpr{Op: token.MUL, X: lit})
+	}
+
+	var lit ast.Expr
+	if id, ok := first.(*ast.Ident); ok && id.Name == "bytes" {
+		// Bytes are encoded as strings in JSON, mapping code points 0-255 to
+		// the corresponding bytes.
+		s, _ := def.String()
+		b := make([]byte, 0, len(s))
+		for _, r := range s {
+			b = append(b, byte(r))
+		}
+		lit = ast.NewLit(token.STRING, literal.Bytes.Quote(string(b)))
+	} else {
+		expr, ok := def.Syntax(cue.Final()).(ast.Expr)
+		if !ok {
+			return x
+		}
+		lit = expr
+	}
+	return ast.NewBinExpr(token.OR, &ast.UnaryExpr{Op: token.MUL, X: lit}, x)
+}
+
+// replaceFirst replaces the first element of the disjunction x with y.
+func replaceFirst(x, y ast.Expr) ast.Expr {
+	b, ok := x.(*ast.BinaryExpr)
+	if !ok || b.Op != token.OR {
+		return y
+	}
+	return &ast.BinaryExpr{Op: token.OR, X: replaceFirst(b.X, y), Y: b.Y}
+}
+
+// fullName returns the full name of the named type name in namespace ns.
+func fullName(name, ns string) string {
+	if strings.Contains(name, ".") || ns == "" {
+		return name
+	}
+	return ns + "." + name
+}
+
+// label returns the label for the Avro field name.
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !internal.IsDefOrHidden(name) {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+func addDoc(n ast.Node, v cue.Value) {
+	doc, err := v.LookupPath(cue.MakePath(cue.Str("doc"))).String()
+	if err != nil || strings.TrimSpace(doc) == "" {
+		return
+	}
+	ast.SetComments(n, []*ast.CommentGroup{internal.NewComment(true, doc)})
+}
+
+// attrArg returns the attribute argument key=value, quoting the value if
+// needed.
+func attrArg(key string, value interface{}) string {
+	var s string
+	switch x := value.(type) {
+	case cue.Value:
+		if str, err := x.String(); err == nil {
+			s = str
+		} else {
+			s = fmt.Sprint(x)
+		}
+	default:
+		s = fmt.Sprint(x)
+	}
+	if !ast.IsValidIdent(s) || strings.HasPrefix(s, "#") {
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			s = literal.String.Quote(s)
+		}
+	}
+	return key + "=" + s
+}
+
+func newAttr(args []string) *ast.Attribute {
+	return &ast.Attribute{Text: "@avro(" + strings.Join(args, ",") + ")"}
+}
@@ -0,0 +1,542 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A generator converts CUE definitions to an Avro schema.
+type generator struct {
+	cfg  *Config
+	root cue.Value
+	errs errors.Error
+
+	// emitted records the definitions that were converted to a named type,
+	// which are referred to by name thereafter.
+	emitted map[string]bool
+
+	// names records the names of the named types.
+	names map[string]bool
+
+	// ns is the namespace of the enclosing named type.
+	ns string
+}
+
+func (g *generator) errf(v cue.Value, format string, args ...interface{}) ast.Expr {
+	g.errs = errors.Append(g.errs, errors.Newf(v.Pos(), format, args...))
+	return ast.NewNull()
+}
+
+func (g *generator) generate() *ast.File {
+	var x ast.Expr
+	if g.cfg.Root != "" {
+		p := cue.ParsePath(g.cfg.Root)
+		if err := p.Err(); err != nil {
+			g.errs = errors.Append(g.errs, errors.Promote(err, "invalid root"))
+			return nil
+		}
+		x = g.path(p)
+	} else {
+		var defs []cue.Path
+		iter, err := g.root.Fields(cue.Definitions(true))
+		if err != nil {
+			g.errs = errors.Append(g.errs, errors.Promote(err, "avro"))
+			return nil
+		}
+		for iter.Next() {
+			if sel := iter.Selector(); sel.IsDefinition() {
+				defs = append(defs, cue.MakePath(sel))
+			}
+		}
+		switch len(defs) {
+		case 0:
+			g.errf(g.root, "no definitions to convert to Avro")
+			return nil
+		case 1:
+			x = g.path(defs[0])
+		default:
+			var a []ast.Expr
+			for _, p := range defs {
+				if g.emitted[p.String()] || namedKind(g.root.LookupPath(p)) == "" {
+					continue
+				}
+				a = append(a, g.path(p))
+			}
+			if len(a) == 1 {
+				x = a[0]
+			} else {
+				x = ast.NewList(a...)
+			}
+		}
+	}
+
+	f := &ast.File{}
+	if s, ok := x.(*ast.StructLit); ok {
+		f.Decls = s.Elts
+	} else {
+		f.Decls = append(f.Decls, &ast.EmbedDecl{Expr: x})
+	}
+	return f
+}
+
+// path converts the definition at path p, which is a single selector.
+func (g *generator) path(p cue.Path) ast.Expr {
+	v := g.root.LookupPath(p)
+	if !v.Exists() {
+		return g.errf(g.root, "definition %v not found", p)
+	}
+	sels := p.Selectors()
+	if len(sels) != 1 || !sels[0].IsDefinition() {
+		return g.schema(v, "")
+	}
+	label := sels[0].String()
+	name := strings.TrimPrefix(label, "#")
+	if g.emitted[label] {
+		if ns := g.namespace(v); ns != g.ns {
+			name = ns + "." + name
+		}
+		return ast.NewString(name)
+	}
+	kind := namedKind(v)
+	if kind == "" {
+		return g.schema(v, name)
+	}
+	g.emitted[label] = true
+	return g.named(v, kind, name)
+}
+
+func (g *generator) namespace(v cue.Value) string {
+	attr := v.Attribute("avro")
+	if ns, ok, _ := attr.Lookup(0, "namespace"); ok {
+		return ns
+	}
+	return g.cfg.Namespace
+}
+
+// namedKind returns the kind of named type for v, or "" if v does not map to
+// a named type.
+func namedKind(v cue.Value) string {
+	attr := v.Attribute("avro")
+	switch {
+	case hasFlag(attr, "fixed"):
+		return "fixed"
+	case stringEnum(v) != nil:
+		return "enum"
+	case isRecord(v) && hasFlag(attr, "error"):
+		return "error"
+	case isRecord(v):
+		return "record"
+	}
+	return ""
+}
+
+// named converts v to a named type of the given kind.
+func (g *generator) named(v cue.Value, kind, name string) ast.Expr {
+	if g.names[name] {
+		return g.errf(v, "duplicate Avro type name %q", name)
+	}
+	g.names[name] = true
+
+	fields := []interface{}{"type", ast.NewString(kind), "name", ast.NewString(name)}
+	ns := g.namespace(v)
+	if ns != g.ns {
+		fields = append(fields, "namespace", ast.NewString(ns))
+	}
+	// Nested types inherit the namespace of the enclosing type.
+	defer func(ns string) { g.ns = ns }(g.ns)
+	g.ns = ns
+	if doc := docString(v); doc != "" {
+		fields = append(fields, "doc", ast.NewString(doc))
+	}
+
+	switch kind {
+	case "record", "error":
+		fields = append(fields, "fields", g.fields(v))
+
+	case "enum":
+		var symbols []ast.Expr
+		for _, s := range stringEnum(v) {
+			symbols = append(symbols, ast.NewString(s))
+		}
+		fields = append(fields, "symbols", ast.NewList(symbols...))
+		if d, ok := v.Default(); ok {
+			if s, err := d.String(); err == nil {
+				fields = append(fields, "default", ast.NewString(s))
+			}
+		}
+
+	case "fixed":
+		attr := v.Attribute("avro")
+		s, _, _ := attr.Lookup(0, "size")
+		size, err := strconv.Atoi(s)
+		if err != nil {
+			return g.errf(v, "invalid size %q for fixed type %q", s, name)
+		}
+		fields = append(fields, "size", ast.NewLit(token.INT, strconv.Itoa(size)))
+		fields = append(fields, logicalFields(attr)...)
+	}
+	return ast.NewStruct(fields...)
+}
+
+// fields converts the regular fields of the struct v to the fields of a
+// record.
+func (g *generator) fields(v cue.Value) ast.Expr {
+	var a []ast.Expr
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return g.errf(v, "invalid record: %v", err)
+	}
+	for iter.Next() {
+		label := iter.Selector().Unquoted()
+		f := iter.Value()
+
+		typ := g.schema(f, label)
+		if lt := logicalFields(f.Attribute("avro")); lt != nil {
+			typ = ast.NewStruct(append([]interface{}{"type", typ}, lt...)...)
+		}
+
+		var def ast.Expr
+		if iter.IsOptional() {
+			typ = withNull(typ)
+			def = ast.NewNull()
+		} else if d, ok := f.Default(); ok && d.IsConcrete() && !isTypeDefault(f, d) {
+			def = g.value(d)
+			typ = defaultFirst(typ, d.Kind())
+		} else if op, _ := f.Expr(); op == cue.OrOp && f.Kind() == cue.NullKind {
+			// The other types of a union with a recursive type, as in
+			// *null | #Self, are eliminated by evaluation.
+			def = ast.NewNull()
+			typ = defaultFirst(typ, cue.NullKind)
+		}
+
+		fields := []interface{}{"name", ast.NewString(label), "type", typ}
+		if doc := docString(f); doc != "" {
+			fields = append(fields, "doc", ast.NewString(doc))
+		}
+		if def != nil {
+			fields = append(fields, "default", def)
+		}
+		a = append(a, ast.NewStruct(fields...))
+	}
+	return ast.NewList(a...)
+}
+
+// isTypeDefault reports whether the default d of the field f is implied by
+// its type, rather than specified for the field. This is the case for the
+// defaults of references to enums and for the empty default of lists.
+func isTypeDefault(f, d cue.Value) bool {
+	if _, p := f.ReferencePath(); len(p.Selectors()) > 0 {
+		return true
+	}
+	n, _ := d.Len().Int64()
+	return d.Kind() == cue.ListKind && n == 0
+}
+
+// schema converts v to an Avro schema. The hint is used to name anonymous
+// records and enums.
+func (g *generator) schema(v cue.Value, hint string) ast.Expr {
+	if root, p := v.ReferencePath(); root.BuildInstance() == g.root.BuildInstance() {
+		if sels := p.Selectors(); len(sels) == 1 && sels[0].IsDefinition() &&
+			g.root.LookupPath(p).Exists() {
+			return g.path(p)
+		}
+	}
+
+	if stringEnum(v) != nil {
+		return g.named(v, "enum", g.anonName(hint))
+	}
+	if op, args := v.Expr(); op == cue.OrOp {
+		var a []ast.Expr
+		for _, arg := range args {
+			switch x := g.schema(arg, hint).(type) {
+			case *ast.ListLit:
+				a = append(a, x.Elts...)
+			default:
+				a = append(a, x)
+			}
+		}
+		return ast.NewList(dedup(a)...)
+	}
+
+	k := v.IncompleteKind()
+	if k&cue.NullKind != 0 && k != cue.NullKind {
+		// TODO: this is only reached for values such as `null | _` that are
+		// not represented as disjunctions.
+		return withNull(g.schema(v, hint))
+	}
+	switch k {
+	case cue.NullKind:
+		return ast.NewString("null")
+	case cue.BoolKind:
+		return ast.NewString("boolean")
+	case cue.IntKind:
+		if subsumes("int32", v) {
+			return ast.NewString("int")
+		}
+		return ast.NewString("long")
+	case cue.FloatKind, cue.NumberKind:
+		if subsumes("float32", v) {
+			return ast.NewString("float")
+		}
+		return ast.NewString("double")
+	case cue.StringKind:
+		return ast.NewString("string")
+	case cue.BytesKind:
+		return ast.NewString("bytes")
+	case cue.ListKind:
+		elem := v.LookupPath(cue.MakePath(cue.AnyIndex))
+		if !elem.Exists() {
+			return g.errf(v, "cannot convert list without element type to Avro")
+		}
+		return ast.NewStruct("type", ast.NewString("array"), "items", g.schema(elem, hint))
+	case cue.StructKind:
+		if !isRecord(v) {
+			elem := v.LookupPath(cue.MakePath(cue.AnyString))
+			return ast.NewStruct("type", ast.NewString("map"), "values", g.schema(elem, hint))
+		}
+		return g.named(v, "record", g.anonName(hint))
+	}
+	return g.errf(v, "cannot convert %v to Avro", v)
+}
+
+// anonName returns an unused name for an anonymous record or enum, based on
+// the label of the field that defines it.
+func (g *generator) anonName(hint string) string {
+	var b strings.Builder
+	up := true
+	for _, c := range hint {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			up = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(c) {
+			b.WriteByte('T')
+		}
+		if up {
+			c = unicode.ToUpper(c)
+			up = false
+		}
+		b.WriteRune(c)
+	}
+	name := b.String()
+	if name == "" {
+		name = "Type"
+	}
+	for i := 1; g.names[name]; i++ {
+		name = fmt.Sprintf("%s%d", b.String(), i)
+	}
+	return name
+}
+
+// value converts the concrete value v to its Avro JSON representation.
+func (g *generator) value(v cue.Value) ast.Expr {
+	if b, err := v.Bytes(); err == nil && v.Kind() == cue.BytesKind {
+		// Bytes are encoded as strings of code points 0-255.
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		return ast.NewString(string(r))
+	}
+	x, ok := v.Syntax(cue.Final()).(ast.Expr)
+	if !ok {
+		return g.errf(v, "invalid default value %v", v)
+	}
+	return x
+}
+
+// withNull returns the union of "null" and typ, with "null" first, as is
+// required for a default of null.
+func withNull(typ ast.Expr) ast.Expr {
+	a := []ast.Expr{typ}
+	if l, ok := typ.(*ast.ListLit); ok {
+		a = l.Elts
+	}
+	return defaultFirst(ast.NewList(dedup(append([]ast.Expr{ast.NewString("null")}, a...))...), cue.NullKind)
+}
+
+// defaultFirst moves the type of the union typ that holds values of kind k
+// to the front, as Avro requires the default of a union to correspond to its
+// first type.
+func defaultFirst(typ ast.Expr, k cue.Kind) ast.Expr {
+	l, ok := typ.(*ast.ListLit)
+	if !ok {
+		return typ
+	}
+	for i, x := range l.Elts {
+		if typeKind(x)&k != 0 {
+			a := append([]ast.Expr{x}, l.Elts[:i]...)
+			l.Elts = append(a, l.Elts[i+1:]...)
+			break
+		}
+	}
+	return l
+}
+
+// typeKind returns the kinds of the values of the Avro schema x.
+func typeKind(x ast.Expr) cue.Kind {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		switch s, _ := strconv.Unquote(x.Value); s {
+		case "null":
+			return cue.NullKind
+		case "boolean":
+			return cue.BoolKind
+		case "int", "long":
+			return cue.IntKind
+		case "float", "double":
+			return cue.NumberKind
+		case "string":
+			return cue.StringKind
+		case "bytes":
+			return cue.BytesKind
+		}
+	case *ast.StructLit:
+		for _, e := range x.Elts {
+			if f, ok := e.(*ast.Field); ok {
+				if l, _, _ := ast.LabelName(f.Label); l == "type" {
+					switch t := typeKind(f.Value); {
+					case t != 0:
+						return t
+					case isString(f.Value, "array"):
+						return cue.ListKind
+					case isString(f.Value, "enum"):
+						return cue.StringKind
+					case isString(f.Value, "fixed"):
+						return cue.BytesKind
+					}
+					return cue.StructKind
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func isString(x ast.Expr, s string) bool {
+	lit, ok := x.(*ast.BasicLit)
+	if !ok {
+		return false
+	}
+	str, _ := strconv.Unquote(lit.Value)
+	return str == s
+}
+
+// dedup removes duplicate primitive types from a union.
+func dedup(a []ast.Expr) []ast.Expr {
+	seen := map[string]bool{}
+	k := 0
+	for _, x := range a {
+		if lit, ok := x.(*ast.BasicLit); ok {
+			if seen[lit.Value] {
+				continue
+			}
+			seen[lit.Value] = true
+		}
+		a[k] = x
+		k++
+	}
+	return a[:k]
+}
+
+// logicalFields returns the fields describing a logical type recorded in
+// attr.
+func logicalFields(attr cue.Attribute) []interface{} {
+	var a []interface{}
+	lt, ok, _ := attr.Lookup(0, "logicalType")
+	if !ok {
+		return nil
+	}
+	a = append(a, "logicalType", ast.NewString(lt))
+	for _, key := range []string{"precision", "scale"} {
+		if s, ok, _ := attr.Lookup(0, key); ok {
+			if _, err := strconv.Atoi(s); err == nil {
+				a = append(a, key, ast.NewLit(token.INT, s))
+			}
+		}
+	}
+	return a
+}
+
+func hasFlag(attr cue.Attribute, key string) bool {
+	ok, _ := attr.Flag(0, key)
+	return ok
+}
+
+// subsumes reports whether the predeclared CUE type t subsumes v, ignoring
+// any default value of v.
+func subsumes(t string, v cue.Value) bool {
+	switch op, args := v.Expr(); op {
+	case cue.NoOp:
+		if len(args) == 1 {
+			v = args[0]
+		}
+	case cue.AndOp:
+		v = args[0]
+		for _, a := range args[1:] {
+			v = v.Unify(a)
+		}
+	}
+	v = v.Context().CompileString("_").Unify(v)
+	return v.Context().CompileString(t).Subsumes(v)
+}
+
+// stringEnum returns the values of v if v is a disjunction of concrete
+// strings, or nil otherwise.
+func stringEnum(v cue.Value) []string {
+	op, args := v.Expr()
+	if op != cue.OrOp {
+		return nil
+	}
+	var values []string
+	for _, a := range args {
+		s, err := a.String()
+		if err != nil {
+			return nil
+		}
+		values = append(values, s)
+	}
+	return values
+}
+
+// isRecord reports whether v maps to a record, rather than a map. Structs
+// with only a pattern constraint for all string labels map to a map.
+func isRecord(v cue.Value) bool {
+	if v.IncompleteKind() != cue.StructKind {
+		return false
+	}
+	if !v.LookupPath(cue.MakePath(cue.AnyString)).Exists() {
+		return true
+	}
+	iter, _ := v.Fields(cue.Optional(true))
+	return iter.Next()
+}
+
+func docString(v cue.Value) string {
+	var a []string
+	for _, c := range v.Doc() {
+		a = append(a, strings.TrimSpace(c.Text()))
+	}
+	return strings.Join(a, "\n\n")
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dotenv converts environment files, commonly named .env, to CUE.
+//
+// Each line of an environment file assigns a value to a variable, as in
+//
+//	export DATABASE_URL="postgres://localhost/db" # optional export prefix
+//
+// which maps to the field
+//
+//	DATABASE_URL: "postgres://localhost/db"
+//
+// All values are strings. Unquoted values extend to the end of the line or
+// to a "#" preceded by white space, which starts a comment. Values enclosed
+// in single quotes are taken literally. Values enclosed in double quotes may
+// span multiple lines and may contain the escape sequences \n, \t, \", \\ and
+// \$. Variable references like $HOME are not expanded.
+//
+// Lines starting with "#" are comments; comments preceding a variable are
+// retained as doc comments. A variable that is assigned more than once takes
+// the last value, as it would when the file is sourced by a shell.
+package dotenv
+
+import (
+	"io"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+)
+
+// A Decoder reads an environment file and converts it to CUE.
+type Decoder struct {
+	filename string
+	r        io.Reader
+	done     bool
+}
+
+// NewDecoder returns a decoder that reads an environment file from r. The
+// filename is used for position information.
+func NewDecoder(filename string, r io.Reader) *Decoder {
+	return &Decoder{filename: filename, r: r}
+}
+
+// Decode parses the environment file and returns it as a CUE struct literal.
+// An environment file holds a single value, so any subsequent call returns
+// io.EOF.
+func (d *Decoder) Decode() (ast.Expr, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	d.done = true
+	src, err := io.ReadAll(d.r)
+	if err != nil {
+		return nil, err
+	}
+	f := token.NewFile(d.filename, -1, len(src))
+	f.SetLinesForContent(src)
+	p := &parser{file: f, src: string(src)}
+	return p.parse()
+}
+
+type parser struct {
+	file *token.File
+	src  string
+	off  int
+}
+
+func (p *parser) parse() (ast.Expr, error) {
+	s := ast.NewStruct()
+	var doc []string
+	for p.off < len(p.src) {
+		p.skipSpace()
+		start := p.off
+		line := p.line()
+		switch {
+		case line == "":
+			doc = nil
+			continue
+		case line[0] == '#':
+			doc = append(doc, strings.TrimSpace(line[1:]))
+			continue
+		}
+		p.off = start
+
+		pos := p.file.Pos(start, token.NoRelPos)
+		name, value, valPos, err := p.assignment()
+		if err != nil {
+			return nil, errors.Newf(pos, "%v", err)
+		}
+
+		f := &ast.Field{Label: label(name), Value: ast.NewString(value)}
+		ast.SetPos(f.Label, pos)
+		ast.SetPos(f.Value, valPos)
+		if len(doc) > 0 {
+			ast.SetComments(f, []*ast.CommentGroup{
+				internal.NewComment(true, strings.Join(doc, "\n")),
+			})
+			doc = nil
+		}
+		// A later assignment overrides an earlier one.
+		for i, e := range s.Elts {
+			if g, ok := e.(*ast.Field); ok {
+				if l, _, _ := ast.LabelName(g.Label); l == name {
+					s.Elts = append(s.Elts[:i], s.Elts[i+1:]...)
+					break
+				}
+			}
+		}
+		s.Elts = append(s.Elts, f)
+	}
+	return s, nil
+}
+
+// assignment parses a variable assignment and returns the name and value of
+// the variable, along with the position of the value.
+func (p *parser) assignment() (name, value string, pos token.Pos, err error) {
+	if strings.HasPrefix(p.src[p.off:], "export") {
+		if rest := p.src[p.off+len("export"):]; rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			p.off += len("export")
+			p.skipSpace()
+		}
+	}
+	start := p.off
+	for p.off < len(p.src) && isNameChar(p.src[p.off], p.off == start) {
+		p.off++
+	}
+	name = p.src[start:p.off]
+	if name == "" {
+		return "", "", pos, errors.New("invalid variable name")
+	}
+	p.skipSpace()
+	if p.off == len(p.src) || p.src[p.off] != '=' {
+		return "", "", pos, errors.Newf(token.NoPos, "missing = after variable %s", name)
+	}
+	p.off++
+	p.skipSpace()
+	pos = p.file.Pos(p.off, token.NoRelPos)
+
+	if p.off < len(p.src) && (p.src[p.off] == '"' || p.src[p.off] == '\'') {
+		value, err = p.quoted(p.src[p.off])
+		if err != nil {
+			return "", "", pos, err
+		}
+		rest := strings.TrimSpace(p.line())
+		if rest != "" && rest[0] != '#' {
+			return "", "", pos, errors.New("unexpected text after quoted value")
+		}
+		return name, value, pos, nil
+	}
+
+	value = p.line()
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+	return name, strings.TrimSpace(value), pos, nil
+}
+
+// quoted parses a value enclosed in the quote character q, which may span
+// multiple lines.
+func (p *parser) quoted(q byte) (string, error) {
+	p.off++
+	var b strings.Builder
+	for ; p.off < len(p.src); p.off++ {
+		c := p.src[p.off]
+		switch {
+		case c == q:
+			p.off++
+			return b.String(), nil
+		case c == '\\' && q == '"':
+			p.off++
+			if p.off == len(p.src) {
+				return "", errors.New("unterminated quoted value")
+			}
+			switch c := p.src[p.off]; c {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(c)
+			default:
+				return "", errors.Newf(token.NoPos, "invalid escape sequence \\%c", c)
+			}
+		case c == '\r' && strings.HasPrefix(p.src[p.off:], "\r\n"):
+			// Normalize line endings within multi-line values.
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", errors.New("unterminated quoted value")
+}
+
+// line returns the remainder of the current line and advances past its end.
+func (p *parser) line() string {
+	end := strings.IndexByte(p.src[p.off:], '\n')
+	var s string
+	if end < 0 {
+		s, p.off = p.src[p.off:], len(p.src)
+	} else {
+		s, p.off = p.src[p.off:p.off+end], p.off+end+1
+	}
+	return strings.TrimRight(s, "\r")
+}
+
+func (p *parser) skipSpace() {
+	for p.off < len(p.src) && (p.src[p.off] == ' ' || p.src[p.off] == '\t') {
+		p.off++
+	}
+}
+
+func isNameChar(c byte, first bool) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_':
+		return true
+	case '0' <= c && c <= '9', c == '.':
+		return !first
+	}
+	return false
+}
+
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !internal.IsDefOrHidden(name) {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
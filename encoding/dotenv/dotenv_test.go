@@ -0,0 +1,125 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotenv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/internal"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "empty",
+		in:   "",
+		out:  "",
+	}, {
+		name: "values",
+		in: `
+# Connection settings.
+export DATABASE_URL="postgres://localhost/db" # trailing comment
+PORT=8080
+  HOST = 0.0.0.0
+EMPTY=
+HASH=a#b # comment
+LITERAL='$HOME \n # not a comment'
+ESCAPED="tab\there \"quoted\" \$HOME"
+MULTI="first
+second"
+app.name=demo
+_private=1
+PORT=9090
+`,
+		out: `
+// Connection settings.
+DATABASE_URL: "postgres://localhost/db"
+HOST:         "0.0.0.0"
+EMPTY:        ""
+HASH:         "a#b"
+LITERAL:      "$HOME \\n # not a comment"
+ESCAPED:      "tab\there \"quoted\" $HOME"
+MULTI:        "first\nsecond"
+"app.name":   "demo"
+"_private":   "1"
+PORT:         "9090"
+`,
+	}, {
+		name: "crlf",
+		in:   "A=1\r\nB=\"x\r\ny\"\r\n",
+		out: `
+A: "1"
+B: "x\ny"
+`,
+	}, {
+		name: "invalid name",
+		in:   "1A=b\n",
+		err:  `test.env:1:1: invalid variable name`,
+	}, {
+		name: "missing equals",
+		in:   "A=1\n  B\n",
+		err:  `test.env:2:3: missing = after variable B`,
+	}, {
+		name: "unterminated quote",
+		in:   "A='abc\n",
+		err:  `test.env:1:1: unterminated quoted value`,
+	}, {
+		name: "invalid escape",
+		in:   `A="\x"`,
+		err:  `test.env:1:1: invalid escape sequence \x`,
+	}, {
+		name: "text after quote",
+		in:   `A="a" b`,
+		err:  `test.env:1:1: unexpected text after quoted value`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDecoder("test.env", strings.NewReader(tc.in))
+			expr, err := d.Decode()
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				got := fmt.Sprintf("%v: %v", err.(errors.Error).Position(), err)
+				if got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(internal.ToFile(expr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSpace(string(b)), strings.TrimSpace(tc.out); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := d.Decode(); err != io.EOF {
+				t.Errorf("second Decode: got %v; want io.EOF", err)
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/encoding/gocode/testdata/pkg1"
 	"cuelang.org/go/encoding/gocode/testdata/pkg2"
+	"cuelang.org/go/encoding/gocode/testdata/pkg3"
 )
 
 type validator interface {
@@ -99,6 +100,53 @@ O.P: invalid value 4 (out of bound >5):
 			I: &pkg2.ImportMe{A: 1000, B: "a"},
 		},
 		want: "nil",
+	}, {
+		name: "generated struct",
+		value: &pkg3.Widget{
+			Name:  "widget",
+			Color: pkg3.ColorRed,
+			Tags:  []string{"a"},
+			Level: pkg3.WidgetLevel200,
+			Owner: &pkg3.Owner{Email: "a@b"},
+		},
+		want: "nil",
+	}, {
+		name: "generated struct with invalid field",
+		value: &pkg3.Widget{
+			Name:  "Widget",
+			Color: pkg3.ColorRed,
+			Tags:  []string{},
+			Level: pkg3.WidgetLevel100,
+		},
+		want: `
+2 errors in empty disjunction:
+conflicting values null and {name!:=~"^[a-z]+$",color:#Color,size?:(int & >=-2147483648 & <=2147483647 & >0),weight?:number,tags:[...string],labels?:{[string]:string},spec?:{replicas:(int & >=0 & <=10),policy:("Always"|"on-failure"|*"Never")},priority?:#Priority,mode?:("fast"|"slow"|null),level:(100|200|300),owner?:(#Owner|null),internal?:string,"api-version"?:string} (mismatched types null and struct):
+    pkg3/instance.cue:x:x
+name: invalid value "Widget" (out of bound =~"^[a-z]+$"):
+    pkg3/instance.cue:x:x
+`,
+	}, {
+		name:  "generated enum",
+		value: pkg3.Color("purple"),
+		want: `
+3 errors in empty disjunction:
+conflicting values "blue" and "purple":
+    pkg3/instance.cue:x:x
+conflicting values "green" and "purple":
+    pkg3/instance.cue:x:x
+conflicting values "red" and "purple":
+    pkg3/instance.cue:x:x
+`,
+	}, {
+		name:  "generated nested struct",
+		value: &pkg3.Owner{Email: "none"},
+		want: `
+2 errors in empty disjunction:
+conflicting values null and {email:=~"@",since?:Time} (mismatched types null and struct):
+    pkg3/instance.cue:x:x
+email: invalid value "none" (out of bound =~"@"):
+    pkg3/instance.cue:x:x
+`,
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -111,6 +159,31 @@ O.P: invalid value 4 (out of bound >5):
 	}
 }
 
+type enum interface {
+	IsValid() bool
+}
+
+func TestEnums(t *testing.T) {
+	testCases := []struct {
+		value enum
+		want  bool
+	}{
+		{pkg3.ColorRed, true},
+		{pkg3.Color("purple"), false},
+		{pkg3.PriorityNeg1, true},
+		{pkg3.Priority(2), false},
+		{pkg3.WidgetModeSlow, true},
+		{pkg3.WidgetMode(""), false},
+		{pkg3.WidgetSpecPolicyOnFailure, true},
+		{pkg3.WidgetLevel(150), false},
+	}
+	for _, tc := range testCases {
+		if got := tc.value.IsValid(); got != tc.want {
+			t.Errorf("%T(%v).IsValid() = %v; want %v", tc.value, tc.value, got, tc.want)
+		}
+	}
+}
+
 func errStr(err error) string {
 	if err == nil {
 		return "nil"
@@ -20,6 +20,7 @@ import (
 	"go/ast"
 	"go/format"
 	"go/types"
+	"strings"
 	"text/template"
 
 	"golang.org/x/tools/go/packages"
@@ -47,6 +48,10 @@ type Config struct {
 	// The cue.Runtime variable name to use for initializing Codecs.
 	// A new Runtime is created by default.
 	RuntimeVar string
+
+	// Types enables generating Go types for the selected top-level
+	// declarations that have no namesake Go type in the package.
+	Types bool
 }
 
 const defaultPrefix = "cuegen"
@@ -89,9 +94,31 @@ const defaultPrefix = "cuegen"
 // methods by default. If not, it will be generated as a function. The default
 // function name is the default operation name with the Go name as a suffix.
 //
+// Definitions are selected and named as other fields, with the leading '#'
+// removed from their name.
+//
+// # Generating Types
+//
+// If Config.Types is set, Generate also generates Go types for selected
+// declarations that have no namesake Go type and no type option. Types
+// generated by a previous run in the package are ignored, so that the output
+// can be regenerated in place. Structs map to Go struct types with json tags,
+// where optional fields are omitted when empty. Disjunctions of string or
+// integer literals map to a string or integer type with a typed constant for
+// each value and an IsValid method, which allows exhaustive switches. For such
+// disjunctions in fields, a type is generated that is named after the
+// declaration and the field, such as WidgetMode for the field mode of Widget.
+// Other values map to the corresponding Go type: lists map to slices, structs
+// with only a pattern constraint map to maps, integers map to the narrowest
+// sized integer type that holds all allowed values, and references to other
+// generated declarations map to their type. Fields may be renamed or dropped
+// with the go attribute.
+//
+// The generated validation methods embed all constraints of the CUE value,
+// including those that cannot be expressed in the Go types.
+//
 // Caveats
 // Currently not supported:
-//   - option to generate Go structs (or automatically generate if undefined)
 //   - for type option to refer to types outside the package.
 func Generate(pkgPath string, inst *cue.Instance, c *Config) (b []byte, err error) {
 	// TODO: if inst is nil, the instance is loaded from CUE files in the same
@@ -104,8 +131,13 @@ func Generate(pkgPath string, inst *cue.Instance, c *Config) (b []byte, err erro
 	}
 	g := &generator{
 		Config: *c,
+		inst:   inst,
 
-		typeMap: map[string]types.Type{},
+		typeMap:  map[string]types.Type{},
+		names:    map[string]string{},
+		structs:  map[string]bool{},
+		generate: map[string]bool{},
+		declared: map[string]bool{},
 	}
 
 	pkgName := inst.PkgName
@@ -135,10 +167,21 @@ func Generate(pkgPath string, inst *cue.Instance, c *Config) (b []byte, err erro
 
 		pkgName = g.pkg.Name
 
+		generated := map[string]bool{}
+		for _, f := range g.pkg.Syntax {
+			if isGenerated(f) {
+				generated[g.pkg.Fset.File(f.Pos()).Name()] = true
+			}
+		}
+
 		for _, obj := range g.pkg.TypesInfo.Defs {
 			if obj == nil || obj.Pkg() != g.pkg.Types || obj.Parent() == nil {
 				continue
 			}
+			// Skip the types of a previous run, as these are regenerated.
+			if generated[g.pkg.Fset.File(obj.Pos()).Name()] {
+				continue
+			}
 			g.typeMap[obj.Name()] = obj.Type()
 		}
 	}
@@ -152,8 +195,15 @@ func Generate(pkgPath string, inst *cue.Instance, c *Config) (b []byte, err erro
 	iter, err := inst.Value().Fields(cue.Definitions(true))
 	g.addErr(err)
 
+	var decls []decl
 	for iter.Next() {
-		g.decl(iter.Label(), iter.Value())
+		if d, ok := g.selectDecl(iter.Label(), iter.Value()); ok {
+			decls = append(decls, d)
+		}
+	}
+	g.selectTypes(decls)
+	for _, d := range decls {
+		g.decl(d)
 	}
 
 	r := value.ConvertToRuntime(inst.Value().Context())
@@ -181,9 +231,21 @@ func Generate(pkgPath string, inst *cue.Instance, c *Config) (b []byte, err erro
 
 type generator struct {
 	Config
+	inst    *cue.Instance
 	pkg     *packages.Package
 	typeMap map[string]types.Type
 
+	names    map[string]string // CUE label to Go type name
+	structs  map[string]bool   // Go type names of struct types
+	generate map[string]bool   // CUE labels for which to generate a type
+	declared map[string]bool   // Go type names of generated field enums
+
+	// typePrefix is the name of the Go type for the value being generated,
+	// which is used to name the types of enums in its fields.
+	typePrefix string
+	// enums holds the declarations of field enums of the current declaration.
+	enums bytes.Buffer
+
 	w   bytes.Buffer
 	err errors.Error
 }
@@ -198,38 +260,90 @@ func (g *generator) exec(t *template.Template, data interface{}) {
 	g.addErr(t.Execute(&g.w, data))
 }
 
-func (g *generator) decl(name string, v cue.Value) {
+// A decl is a top-level declaration selected for generation.
+type decl struct {
+	name     string // the CUE label
+	goName   string
+	typeName string // the Go type name
+	goType   string // the Go type from the type option, if any
+	v        cue.Value
+	attr     cue.Attribute
+}
+
+// selectDecl reports whether code should be generated for the top-level
+// field with the given name and value.
+func (g *generator) selectDecl(name string, v cue.Value) (d decl, ok bool) {
 	attr := v.Attribute("go")
 
-	if !ast.IsExported(name) && attr.Err() != nil {
-		return
+	goName := strings.TrimPrefix(name, "#")
+	if !ast.IsExported(goName) && attr.Err() != nil {
+		return d, false
 	}
 
-	goName := name
 	switch s, _ := attr.String(0); s {
 	case "":
 	case "-":
-		return
+		return d, false
 	default:
 		goName = s
 	}
 
-	goTypeName := goName
-	goType := ""
+	d = decl{
+		name:     name,
+		goName:   goName,
+		typeName: goName,
+		v:        v,
+		attr:     attr,
+	}
 	if str, ok, _ := attr.Lookup(1, "type"); ok {
-		goType = str
-		goTypeName = str
+		d.goType = str
+		d.typeName = str
+	}
+	return d, true
+}
+
+// selectTypes determines the Go types for the selected declarations and
+// which of these need to be generated.
+func (g *generator) selectTypes(decls []decl) {
+	for _, d := range decls {
+		if typ, ok := g.typeMap[d.typeName]; ok {
+			g.names[d.name] = d.typeName
+			if _, ok := typ.Underlying().(*types.Struct); ok {
+				g.structs[d.typeName] = true
+			}
+			continue
+		}
+		if !g.Types || d.goType != "" || mappedGoTypes(d.typeName) {
+			continue
+		}
+		g.names[d.name] = d.typeName
+		g.generate[d.name] = true
+		if isStructValue(d.v) {
+			g.structs[d.typeName] = true
+		}
 	}
+}
+
+func (g *generator) decl(d decl) {
+	goTypeName := d.typeName
+	goType := d.goType
 
-	isFunc, _ := attr.Flag(1, "func")
-	if goTypeName != goName {
+	isFunc, _ := d.attr.Flag(1, "func")
+	if goTypeName != d.goName {
 		isFunc = true
 	}
 
 	zero := "nil"
 
 	typ, ok := g.typeMap[goTypeName]
-	if !ok && !mappedGoTypes(goTypeName) {
+	switch {
+	case g.generate[d.name]:
+		g.typeDecl(goTypeName, d.v)
+		if g.structs[goTypeName] {
+			goType = "*" + goTypeName
+			zero = fmt.Sprintf("&%s{}", goTypeName)
+		}
+	case !ok && !mappedGoTypes(goTypeName):
 		return
 	}
 	if goType == "" {
@@ -248,15 +362,25 @@ func (g *generator) decl(name string, v cue.Value) {
 
 	g.exec(stubCode, map[string]interface{}{
 		"prefix":  strValue(g.Prefix, defaultPrefix),
-		"cueName": name,   // the field name of the CUE type
-		"goType":  goType, // the receiver or argument type
-		"zero":    zero,   // the zero value of the underlying type
+		"cueName": d.name,                          // the field name of the CUE type
+		"name":    strings.TrimPrefix(d.name, "#"), // the CUE name as a Go identifier
+		"goType":  goType,                          // the receiver or argument type
+		"zero":    zero,                            // the zero value of the underlying type
 
 		// @go attribute options
 		"func":     isFunc,
-		"validate": lookupName(attr, "validate", strValue(g.ValidateName, "Validate")),
-		"complete": lookupName(attr, "complete", g.CompleteName),
+		"validate": lookupName(d.attr, "validate", strValue(g.ValidateName, "Validate")),
+		"complete": lookupName(d.attr, "complete", g.CompleteName),
 	})
+
+	g.w.Write(g.enums.Bytes())
+	g.enums.Reset()
+}
+
+// isGenerated reports whether f was generated by Generate.
+func isGenerated(f *ast.File) bool {
+	return len(f.Comments) > 0 && f.Comments[0].Pos() < f.Package &&
+		strings.HasPrefix(f.Comments[0].Text(), "Code generated by gocode.Generate")
 }
 
 func lookupName(attr cue.Attribute, option, config string) string {
@@ -38,26 +38,27 @@ import (
 // Inputs:
 // .prefix 	  prefix to all generated variable names
 // .cueName   name of the top-level CUE value
+// .name      name of the top-level CUE value as a Go identifier
 // .goType    Go type of the receiver or argument
 // .zero      zero value of the Go type; nil indicates no value
 // .validate  name of the validate function; "" means no validate
 // .complete  name of the complete function; "" means no complete
 var stubCode = template.Must(template.New("type").Parse(`
-var {{.prefix}}val{{.cueName}} = {{.prefix}}Make("{{.cueName}}", {{.zero}})
+var {{.prefix}}val{{.name}} = {{.prefix}}Make("{{.cueName}}", {{.zero}})
 
 {{ $sig := .goType | printf "(x %s)" -}}
 {{if .validate}}
-// {{.validate}}{{if .func}}{{.cueName}}{{end}} validates x.
-func {{if .func}}{{.validate}}{{.cueName}}{{$sig}}
+// {{.validate}}{{if .func}}{{.name}}{{end}} validates x.
+func {{if .func}}{{.validate}}{{.name}}{{$sig}}
      {{- else -}}{{$sig}} {{.validate}}(){{end}} error {
-	return {{.prefix}}Codec.Validate({{.prefix}}val{{.cueName}}, x)
+	return {{.prefix}}Codec.Validate({{.prefix}}val{{.name}}, x)
 }
 {{end}}
 {{if .complete}}
-// {{.complete}}{{if .func}}{{.cueName}}{{end}} completes x.
-func {{if .func}}{{.complete}}{{.cueName}}{{$sig}}
+// {{.complete}}{{if .func}}{{.name}}{{end}} completes x.
+func {{if .func}}{{.complete}}{{.name}}{{$sig}}
      {{- else -}}{{$sig}} {{.complete}}(){{end}} error {
-	return {{.prefix}}Codec.Complete({{.prefix}}val{{.cueName}}, x)
+	return {{.prefix}}Codec.Complete({{.prefix}}val{{.name}}, x)
 }
 {{end}}
 `))
@@ -53,7 +53,9 @@ func main() {
 		}
 
 		goPkg := "./testdata/" + d.Name()
-		b, err := gocode.Generate(goPkg, inst, nil)
+		// Go types are only generated for pkg3.
+		cfg := &gocode.Config{Types: d.Name() == "pkg3"}
+		b, err := gocode.Generate(goPkg, inst, cfg)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -0,0 +1,204 @@
+// Code generated by gocode.Generate; DO NOT EDIT.
+
+package pkg3
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/gocode/gocodec"
+	_ "cuelang.org/go/pkg"
+)
+
+// Color is the color of a widget.
+type Color string
+
+const (
+	ColorRed   Color = "red"
+	ColorGreen Color = "green"
+	ColorBlue  Color = "blue"
+)
+
+// IsValid reports whether x is one of the values allowed by the schema.
+func (x Color) IsValid() bool {
+	switch x {
+	case "red", "green", "blue":
+		return true
+	}
+	return false
+}
+
+var cuegenvalColor = cuegenMake("#Color", nil)
+
+// Validate validates x.
+func (x Color) Validate() error {
+	return cuegenCodec.Validate(cuegenvalColor, x)
+}
+
+// Priority is the scheduling priority of a widget.
+type Priority int8
+
+const (
+	PriorityNeg1 Priority = -1
+	Priority0    Priority = 0
+	Priority1    Priority = 1
+)
+
+// IsValid reports whether x is one of the values allowed by the schema.
+func (x Priority) IsValid() bool {
+	switch x {
+	case -1, 0, 1:
+		return true
+	}
+	return false
+}
+
+var cuegenvalPriority = cuegenMake("#Priority", nil)
+
+// Validate validates x.
+func (x Priority) Validate() error {
+	return cuegenCodec.Validate(cuegenvalPriority, x)
+}
+
+// Widget describes a widget.
+type Widget struct {
+	// Name identifies the widget.
+	Name   string            `json:"name"`
+	Color  Color             `json:"color"`
+	Size   int32             `json:"size,omitempty"`
+	Weight float64           `json:"weight,omitempty"`
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Spec   *struct {
+		Replicas int8 `json:"replicas"`
+		// Policy determines when to restart.
+		Policy WidgetSpecPolicy `json:"policy"`
+	} `json:"spec,omitempty"`
+	Priority   Priority    `json:"priority,omitempty"`
+	Mode       *WidgetMode `json:"mode,omitempty"`
+	Level      WidgetLevel `json:"level"`
+	Owner      *Owner      `json:"owner,omitempty"`
+	ApiVersion string      `json:"api-version,omitempty"`
+}
+
+var cuegenvalWidget = cuegenMake("#Widget", &Widget{})
+
+// Validate validates x.
+func (x *Widget) Validate() error {
+	return cuegenCodec.Validate(cuegenvalWidget, x)
+}
+
+type WidgetSpecPolicy string
+
+const (
+	WidgetSpecPolicyAlways    WidgetSpecPolicy = "Always"
+	WidgetSpecPolicyOnFailure WidgetSpecPolicy = "on-failure"
+	WidgetSpecPolicyNever     WidgetSpecPolicy = "Never"
+)
+
+// IsValid reports whether x is one of the values allowed by the schema.
+func (x WidgetSpecPolicy) IsValid() bool {
+	switch x {
+	case "Always", "on-failure", "Never":
+		return true
+	}
+	return false
+}
+
+type WidgetMode string
+
+const (
+	WidgetModeFast WidgetMode = "fast"
+	WidgetModeSlow WidgetMode = "slow"
+)
+
+// IsValid reports whether x is one of the values allowed by the schema.
+func (x WidgetMode) IsValid() bool {
+	switch x {
+	case "fast", "slow":
+		return true
+	}
+	return false
+}
+
+type WidgetLevel int16
+
+const (
+	WidgetLevel100 WidgetLevel = 100
+	WidgetLevel200 WidgetLevel = 200
+	WidgetLevel300 WidgetLevel = 300
+)
+
+// IsValid reports whether x is one of the values allowed by the schema.
+func (x WidgetLevel) IsValid() bool {
+	switch x {
+	case 100, 200, 300:
+		return true
+	}
+	return false
+}
+
+type Owner struct {
+	Email string `json:"email"`
+	Since Time   `json:"since,omitempty"`
+}
+
+var cuegenvalOwner = cuegenMake("#Owner", &Owner{})
+
+// Validate validates x.
+func (x *Owner) Validate() error {
+	return cuegenCodec.Validate(cuegenvalOwner, x)
+}
+
+type Time int
+
+var cuegenvalTime = cuegenMake("Time", nil)
+
+// Validate validates x.
+func (x Time) Validate() error {
+	return cuegenCodec.Validate(cuegenvalTime, x)
+}
+
+var cuegenvalExisting = cuegenMake("#Existing", &Existing{})
+
+// Validate validates x.
+func (x *Existing) Validate() error {
+	return cuegenCodec.Validate(cuegenvalExisting, x)
+}
+
+var cuegenCodec, cuegenInstance_, cuegenValue = func() (*gocodec.Codec, *cue.Instance, cue.Value) {
+	var r *cue.Runtime
+	r = &cue.Runtime{}
+	instances, err := r.Unmarshal(cuegenInstanceData)
+	if err != nil {
+		panic(err)
+	}
+	if len(instances) != 1 {
+		panic("expected encoding of exactly one instance")
+	}
+	return gocodec.New(r, nil), instances[0], instances[0].Value()
+}()
+
+// Deprecated: cue.Instance is deprecated. Use cuegenValue instead.
+var cuegenInstance = cuegenInstance_
+
+// cuegenMake is called in the init phase to initialize CUE values for
+// validation functions.
+func cuegenMake(name string, x interface{}) cue.Value {
+	f, err := cuegenValue.FieldByName(name, true)
+	if err != nil {
+		panic(fmt.Errorf("could not find type %q in instance", name))
+	}
+	v := f.Value
+	if x != nil {
+		w, err := cuegenCodec.ExtractType(x)
+		if err != nil {
+			panic(err)
+		}
+		v = v.Unify(w)
+	}
+	return v
+}
+
+// Data size: 669 bytes.
+var cuegenInstanceData = []byte("\x01\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xffTR\xdfo\xdb6\x10&m\x0f\x18\x89l\xc0\xfe\x80\x017e\b\xb2\x1f\x96\xec\x04\u0603\xb0\xc4\x19\xf6\xeb-\v\x86\x01{0R\x80\x96\xce2\x11\x8a\x14H*\xae\xd3$m\u04f4\x7fv\\\x90\x8a\xd2T\x0f\xe2\xf1\xbb\xbb\xef\xee\xbe\xe3W\xdb\xf7\x03:\xd8~ t\xfb\x86\x90_^\x0f)\u0751\xday\xa1\v\xfcCx\x11`:\xa4\xa3\x7f\x8d\xf1t@\xe8\xe8L\xf8\x15\xdd!\U0010bfe4BG\xb7\xf7\x84\x90o\xb7\xef\x06\x94~=?/ZL\x97R=f\xde\x13\xba\xbd#d\x7f\xfbvH\u95df\xf0;B\att*j\fD\xa3\brB\xc8\xc3\xf064B)\xfd\xb9hQ\t]\xa5\xc6VYe2\u0505)\xa5\x0evaJ\xcc<:_\n/\xb2\xe6\xa2:\xa4\x94~\x13\u03ac\xef;-Z\xa4\x0f\u00ec\x11\u0145\xa8\x10\x82\x93\xf3,\x83\u07cd2\x16\xa4\x03\xbfB(\xe2\xc5,A\xc0Z\x96\x15\xfa\x94\xef\u0180\x1c\x12\x8be\x02\u05d0T\x16Q\a\xeb\xc7d\xa1ZL\"\u02d9\x95\xc6J\xbf\xe9\x89\\\xb1\u00b2URW\xd0\xf4\xae\xcfi\xfb\x8c\x1c\xc6S\xb8\x86\t\\\xc34R\xfd\x1f#\xa0DWX\xb9@\xf7<\xa9\xf3\xe5\xf0\x8a\xb3,\x83\xa0\x16\xc8\x12\xb5\x97K\x89]\xe1>\x94iQ\xe3w9\x00\x1c\xdd&/\xe6b|u\xfe\xd3\xf7\tgE7\r@7\x17gN^\xe1,\x00R\xfb\xc3\x03\u0603\xe3\tgk\x94\xd5\xca\xcfr\xd0m\xbd@\u02d9\x17\x95\xcba\x9e\xa6\xa9\xf3V\xea\xea\x9c3%\x16\xa8\xdc,6\xc3\xe6\x8fp\x0e\x9d\xc1\xd9\rg\xae\xc1\xe2\xd1o\xb1Q\xb2\x10.\x87Vj\x0f{\xf0\xeb\xd1t\xc2Y\x98\xe2\xcc(Yl\xa0D\x8f\xb6\x96\x1a\x1d\xacW\xa8\xc1\x1b\xb0\u8f30a\x18\xd6\u0120\x1c\x92\xdf\xd4Zl\\\\\x84\xd1\u3950\xaa\xb5\xd8m\xe3\x14/\xd1&\xb1r\xafy\x1c,\f\u06eb\xcdYmJ\xec\xe1\xf0%K\xe1|\xe4s\u02ac\x83\xa1[\xa58Sx\x89\xeaY\xdct\x12Vt\x10\xff\x87\x93\tgf\xad\xd1>c\xda\xfd'\x00O\xf9R{\xb4Z\xa8>\xa2\xd3\x05N*\xb3?\xfe\x81\xb3D4r|\x89\xd6I\xa3\x93\u0653l7\xbc\xa3\x89\xaaa-dh\xe1\xe869I\u00a6t\x11Z\xffO\xd6\xc8ox8\xf2\xb0\xb5\xf8j\xfe|)\x9d\x0f\x05\xa4\x83Z4\r\x96A\xc1\xf0\"VB\x97\u3d55\u07a3\x86\xbf\r\xf8M\x83)\xdf\xed\x13b%\x91\xc3\xf1t\x12\xaa\xafdY\xa2~\ua1d0\x8f\x01\x00\x00\xff\xff.\x90~\x8d\r\x04\x00\x00")
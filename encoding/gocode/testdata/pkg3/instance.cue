@@ -0,0 +1,57 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg3
+
+// Color is the color of a widget.
+#Color: "red" | "green" | *"blue"
+
+// Priority is the scheduling priority of a widget.
+#Priority: -1 | 0 | 1
+
+// Widget describes a widget.
+#Widget: {
+	// Name identifies the widget.
+	name!: =~"^[a-z]+$"
+	color: #Color
+	size?: int32 & >0
+	weight?: number
+	tags: [...string]
+	labels?: [string]: string
+	spec?: {
+		replicas: uint8 & <=10
+		// Policy determines when to restart.
+		policy: "Always" | "on-failure" | *"Never"
+	}
+	priority?: #Priority
+	mode?: "fast" | "slow" | null
+	level: 100 | 200 | 300
+	owner?: #Owner | null
+	internal?: string @go(-)
+	"api-version"?: string
+}
+
+#Owner: {
+	email: =~"@"
+	since?: Time
+}
+
+Time: int
+
+// Existing is mapped to the hand-written Go type.
+#Existing: {
+	a: >10
+}
+
+#hidden: string
@@ -0,0 +1,349 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gocode
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+)
+
+// intTypes lists the sized Go integer types from narrowest to widest.
+var intTypes = []string{
+	"int8", "uint8",
+	"int16", "uint16",
+	"int32", "uint32",
+	"int64", "uint64",
+}
+
+// typeDecl generates the declaration of the Go type goName for the top-level
+// CUE value v.
+func (g *generator) typeDecl(goName string, v cue.Value) {
+	w := &bytes.Buffer{}
+	writeDoc(w, v)
+
+	if values, nullable := enumValues(v); values != nil && !nullable {
+		g.enumDecl(w, goName, v, values)
+	} else {
+		g.typePrefix = goName
+		fmt.Fprintf(w, "type %s %s\n", goName, g.goType(v, false))
+		g.typePrefix = ""
+	}
+
+	g.w.WriteString("\n")
+	g.w.Write(w.Bytes())
+}
+
+// enumDecl writes the declaration of the Go type goName for the enum v with
+// the given values, consisting of a typed constant for each value and an
+// IsValid method.
+func (g *generator) enumDecl(w *bytes.Buffer, goName string, v cue.Value, values []cue.Value) {
+	base := "string"
+	if values[0].Kind() == cue.IntKind {
+		base = g.intType(v)
+	}
+	fmt.Fprintf(w, "type %s %s\n\n", goName, base)
+
+	var lits []string
+	seen := map[string]bool{}
+	fmt.Fprintf(w, "const (\n")
+	for _, x := range values {
+		lit, name := enumConst(x)
+		lits = append(lits, lit)
+		// Skip values that cannot be named and names that collide.
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		fmt.Fprintf(w, "%s%s %s = %s\n", goName, name, goName, lit)
+	}
+	fmt.Fprintf(w, ")\n\n")
+
+	fmt.Fprintf(w, "// IsValid reports whether x is one of the values allowed by the schema.\n")
+	fmt.Fprintf(w, "func (x %s) IsValid() bool {\n", goName)
+	fmt.Fprintf(w, "switch x {\ncase %s:\nreturn true\n}\n", strings.Join(lits, ", "))
+	fmt.Fprintf(w, "return false\n}\n")
+}
+
+// enumType returns the name of a Go type generated for the enum v, which is
+// a field of the type currently being generated, or "" if no such type can
+// be generated. The type is named after the path of the field.
+func (g *generator) enumType(v cue.Value, values []cue.Value) string {
+	name := g.typePrefix
+	if _, ok := g.typeMap[name]; ok || g.declared[name] || mappedGoTypes(name) {
+		return ""
+	}
+	for _, n := range g.names {
+		if n == name {
+			return ""
+		}
+	}
+	g.declared[name] = true
+
+	w := &bytes.Buffer{}
+	w.WriteString("\n")
+	g.enumDecl(w, name, v, values)
+	g.enums.Write(w.Bytes())
+	return name
+}
+
+// enumConst returns the Go literal of the enum value v and the suffix of the
+// name of its constant, or "" if it has no valid name.
+func enumConst(v cue.Value) (lit, name string) {
+	if s, err := v.String(); err == nil {
+		return strconv.Quote(s), identifier(s)
+	}
+	n, _ := v.Int64()
+	lit = strconv.FormatInt(n, 10)
+	if n < 0 {
+		return lit, "Neg" + lit[1:]
+	}
+	return lit, lit
+}
+
+// goType returns the Go type for the values of v. If nullable is true, or if
+// v allows null, scalar types are mapped to a pointer type.
+func (g *generator) goType(v cue.Value, nullable bool) string {
+	if op, args := v.Expr(); op == cue.OrOp {
+		var nonNull []cue.Value
+		for _, a := range args {
+			if a.IncompleteKind() == cue.NullKind {
+				nullable = true
+				continue
+			}
+			nonNull = append(nonNull, a)
+		}
+		if len(nonNull) == 1 {
+			return g.goType(nonNull[0], nullable)
+		}
+	}
+
+	ptr := ""
+	if nullable {
+		ptr = "*"
+	}
+
+	if name := g.reference(v); name != "" {
+		return ptr + name
+	}
+
+	if values, nullable := enumValues(v); values != nil && g.typePrefix != "" {
+		if nullable {
+			ptr = "*"
+		}
+		if name := g.enumType(v, values); name != "" {
+			return ptr + name
+		}
+	}
+
+	k := v.IncompleteKind()
+	if k&cue.NullKind != 0 && k != cue.NullKind {
+		ptr = "*"
+		k &^= cue.NullKind
+	}
+	switch k {
+	case cue.StringKind:
+		return ptr + "string"
+	case cue.BoolKind:
+		return ptr + "bool"
+	case cue.BytesKind:
+		return "[]byte"
+	case cue.IntKind:
+		return ptr + g.intType(v)
+	case cue.FloatKind, cue.NumberKind:
+		if subsumes("float32", v) {
+			return ptr + "float32"
+		}
+		return ptr + "float64"
+	case cue.ListKind:
+		elem := v.LookupPath(cue.MakePath(cue.AnyIndex))
+		if !elem.Exists() {
+			return "[]interface{}"
+		}
+		return "[]" + g.goType(elem, false)
+	case cue.StructKind:
+		if !isStructValue(v) {
+			elem := v.LookupPath(cue.MakePath(cue.AnyString))
+			return "map[string]" + g.goType(elem, false)
+		}
+		return ptr + g.structType(v)
+	}
+	return "interface{}"
+}
+
+// structType returns a Go struct type for the fields of v.
+func (g *generator) structType(v cue.Value) string {
+	w := &bytes.Buffer{}
+	w.WriteString("struct {\n")
+	iter, err := v.Fields(cue.Optional(true))
+	g.addErr(err)
+	for iter.Next() {
+		label := iter.Selector().Unquoted()
+		f := iter.Value()
+
+		name := identifier(label)
+		attr := f.Attribute("go")
+		switch s, _ := attr.String(0); s {
+		case "":
+		case "-":
+			continue
+		default:
+			name = s
+		}
+		if name == "" {
+			continue
+		}
+
+		optional := iter.IsOptional()
+		prefix := g.typePrefix
+		g.typePrefix += name
+		typ := g.goType(f, false)
+		g.typePrefix = prefix
+		if optional && g.isStruct(typ) {
+			typ = "*" + typ
+		}
+		tag := label
+		if optional {
+			tag += ",omitempty"
+		}
+
+		writeDoc(w, f)
+		fmt.Fprintf(w, "%s %s `json:%s`\n", name, typ, strconv.Quote(tag))
+	}
+	w.WriteString("}")
+	return w.String()
+}
+
+// reference returns the name of the Go type generated for the top-level
+// declaration of the instance referred to by v, or "" if v does not refer
+// to such a declaration.
+func (g *generator) reference(v cue.Value) string {
+	root, p := v.ReferencePath()
+	sels := p.Selectors()
+	if len(sels) != 1 || root.BuildInstance() != g.inst.Value().BuildInstance() {
+		return ""
+	}
+	return g.names[sels[0].String()]
+}
+
+// isStruct reports whether typ is a struct type or a struct type declared by
+// the generated code.
+func (g *generator) isStruct(typ string) bool {
+	return strings.HasPrefix(typ, "struct") || g.structs[typ]
+}
+
+// intType returns the narrowest sized Go integer type that holds all values
+// of v, or int if v is not bounded.
+func (g *generator) intType(v cue.Value) string {
+	for _, t := range intTypes {
+		if subsumes(t, v) {
+			return t
+		}
+	}
+	return "int"
+}
+
+// subsumes reports whether the predeclared CUE type t subsumes v.
+func subsumes(t string, v cue.Value) bool {
+	// Unify with top to allow subsumption checks on the values of optional
+	// fields.
+	v = v.Context().CompileString("_").Unify(v)
+	return v.Context().CompileString(t).Subsumes(v)
+}
+
+// enumValues returns the values of v if v is a disjunction of two or more
+// concrete strings or of two or more concrete integers, optionally including
+// null, or nil otherwise. It reports whether v allows null.
+func enumValues(v cue.Value) (values []cue.Value, nullable bool) {
+	op, args := v.Expr()
+	if op != cue.OrOp {
+		return nil, false
+	}
+	for _, a := range args {
+		switch k := a.Kind(); {
+		case k == cue.NullKind:
+			nullable = true
+			continue
+		case k != cue.StringKind && k != cue.IntKind,
+			len(values) > 0 && k != values[0].Kind():
+			return nil, false
+		}
+		if a.IncompleteKind() != a.Kind() {
+			return nil, false
+		}
+		values = append(values, a)
+	}
+	if len(values) < 2 {
+		return nil, false
+	}
+	return values, nullable
+}
+
+// isStructValue reports whether v maps to a Go struct, rather than a map
+// or another type. Structs with only a pattern constraint for all string
+// labels map to a Go map.
+func isStructValue(v cue.Value) bool {
+	if v.IncompleteKind() != cue.StructKind {
+		return false
+	}
+	if !v.LookupPath(cue.MakePath(cue.AnyString)).Exists() {
+		return true
+	}
+	iter, _ := v.Fields(cue.Optional(true))
+	return iter.Next()
+}
+
+// writeDoc writes the documentation of v as a Go comment.
+func writeDoc(w *bytes.Buffer, v cue.Value) {
+	for _, c := range v.Doc() {
+		for _, line := range strings.Split(strings.TrimSuffix(c.Text(), "\n"), "\n") {
+			if line == "" {
+				w.WriteString("//\n")
+			} else {
+				fmt.Fprintf(w, "// %s\n", line)
+			}
+		}
+	}
+}
+
+// identifier converts s to an exported Go identifier by capitalizing its
+// words, or returns "" if s has no letters or digits.
+func identifier(s string) string {
+	var b strings.Builder
+	up := true
+	for _, c := range s {
+		switch {
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			up = true
+			continue
+		case b.Len() == 0 && unicode.IsDigit(c):
+			b.WriteByte('X')
+		}
+		if up {
+			c = unicode.ToUpper(c)
+			up = false
+		}
+		b.WriteRune(c)
+	}
+	if name := b.String(); ast.IsExported(name) {
+		return name
+	}
+	return ""
+}
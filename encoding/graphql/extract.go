@@ -0,0 +1,262 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+	"cuelang.org/go/internal/astinternal"
+)
+
+// scalars maps the built-in scalar types of GraphQL to CUE.
+var scalars = map[string]string{
+	"Int":     "int32",
+	"Float":   "float64",
+	"String":  "string",
+	"ID":      "string",
+	"Boolean": "bool",
+}
+
+type extractor struct {
+	types map[string]*definition
+	errs  errors.Error
+}
+
+func extract(filename string, defs []*definition, cfg *Config) (*ast.File, error) {
+	x := &extractor{types: map[string]*definition{}}
+
+	// Merge extensions into the definitions they extend.
+	var types []*definition
+	for _, d := range defs {
+		if _, ok := scalars[d.name]; ok {
+			if d.kind != "scalar" || !d.extend {
+				x.addErrf(d.pos, "cannot redefine built-in type %s", d.name)
+			}
+			continue
+		}
+		t, ok := x.types[d.name]
+		switch {
+		case !ok:
+			x.types[d.name] = d
+			types = append(types, d)
+			continue
+		case t.kind != d.kind:
+			x.addErrf(d.pos, "%s %s conflicts with %s of the same name",
+				d.kind, d.name, t.kind)
+			continue
+		case !t.extend && !d.extend:
+			x.addErrf(d.pos, "duplicate definition of %s", d.name)
+			continue
+		case !d.extend:
+			t.extend = false
+			t.desc = d.desc
+		}
+		t.interfaces = append(t.interfaces, d.interfaces...)
+		t.fields = append(t.fields, d.fields...)
+		t.values = append(t.values, d.values...)
+		t.members = append(t.members, d.members...)
+		t.directives = append(t.directives, d.directives...)
+	}
+
+	f := &ast.File{Filename: filename}
+	if cfg.PkgName != "" {
+		f.Decls = append(f.Decls, &ast.Package{Name: ast.NewIdent(cfg.PkgName)})
+	}
+	for _, d := range types {
+		field := &ast.Field{
+			Label: ast.NewIdent("#" + d.name),
+			Value: x.typeDef(d),
+			Attrs: attrs(d.directives),
+		}
+		addDoc(field, d.desc)
+		f.Decls = append(f.Decls, field)
+	}
+	if x.errs != nil {
+		return nil, x.errs
+	}
+	return f, nil
+}
+
+func (x *extractor) addErrf(pos token.Pos, format string, args ...interface{}) {
+	x.errs = errors.Append(x.errs, errors.Newf(pos, format, args...))
+}
+
+// typeDef returns the CUE type for the definition d.
+func (x *extractor) typeDef(d *definition) ast.Expr {
+	switch d.kind {
+	case "scalar":
+		return ast.NewIdent("_")
+
+	case "enum":
+		if len(d.values) == 0 {
+			x.addErrf(d.pos, "enum %s must define one or more values", d.name)
+			return ast.NewIdent("_")
+		}
+		var a []ast.Expr
+		for _, v := range d.values {
+			a = append(a, ast.NewString(v.name))
+		}
+		return ast.NewBinExpr(token.OR, a...)
+
+	case "union":
+		if len(d.members) == 0 {
+			x.addErrf(d.pos, "union %s must define one or more member types", d.name)
+			return ast.NewIdent("_")
+		}
+		var a []ast.Expr
+		for _, m := range d.members {
+			if t := x.types[m]; t == nil || t.kind != "type" {
+				x.addErrf(d.pos, "member %s of union %s is not an object type", m, d.name)
+			}
+			a = append(a, ast.NewIdent("#"+m))
+		}
+		return ast.NewBinExpr(token.OR, a...)
+	}
+
+	s := ast.NewStruct()
+	if len(d.interfaces) > 0 {
+		var args []string
+		for _, name := range d.interfaces {
+			if t := x.types[name]; t == nil || t.kind != "interface" {
+				x.addErrf(d.pos, "%s implements %s, which is not an interface", d.name, name)
+			}
+			args = append(args, "implements="+name)
+		}
+		s.Elts = append(s.Elts, internal.NewAttr("graphql", strings.Join(args, ",")))
+	}
+	if d.kind == "type" {
+		s.Elts = append(s.Elts, &ast.Field{
+			Label:      ast.NewString("__typename"),
+			Constraint: token.OPTION,
+			Value:      ast.NewString(d.name),
+		})
+	}
+	for _, f := range d.fields {
+		s.Elts = append(s.Elts, x.field(d, f))
+	}
+	if d.kind == "interface" {
+		s.Elts = append(s.Elts, &ast.Ellipsis{})
+	}
+	return s
+}
+
+// field returns the CUE field for the field f of definition d.
+func (x *extractor) field(d *definition, f *field) *ast.Field {
+	out := &ast.Field{
+		Label: label(f.name),
+		Value: x.typ(d, f.typ),
+		Attrs: attrs(f.directives),
+	}
+	if !f.typ.nonNull || f.value != nil {
+		out.Constraint = token.OPTION
+	}
+	if f.value != nil {
+		out.Value = withDefault(out.Value, f.value)
+	}
+	addDoc(out, f.desc)
+	return out
+}
+
+// typ returns the CUE type for the type t referred to by definition d.
+func (x *extractor) typ(d *definition, t *typeRef) ast.Expr {
+	var e ast.Expr
+	if t.elem != nil {
+		elem := x.typ(d, t.elem)
+		if _, ok := elem.(*ast.BinaryExpr); ok {
+			elem = &ast.ParenExpr{X: elem}
+		}
+		e = ast.NewList(&ast.Ellipsis{Type: elem})
+	} else if s, ok := scalars[t.name]; ok {
+		e = ast.NewIdent(s)
+	} else {
+		if x.types[t.name] == nil {
+			x.addErrf(d.pos, "undefined type %s referred to by %s", t.name, d.name)
+		}
+		e = ast.NewIdent("#" + t.name)
+	}
+	if !t.nonNull {
+		e = ast.NewBinExpr(token.OR, ast.NewNull(), e)
+	}
+	return e
+}
+
+// withDefault marks the value v as the default of the type typ.
+func withDefault(typ, v ast.Expr) ast.Expr {
+	// Mark the null of a nullable type instead of adding another null.
+	if lit, ok := v.(*ast.BasicLit); ok && lit.Kind == token.NULL {
+		if b, ok := typ.(*ast.BinaryExpr); ok {
+			b.X = &ast.UnaryExpr{Op: token.MUL, X: b.X}
+			return b
+		}
+	}
+	def := &ast.UnaryExpr{Op: token.MUL, X: v}
+	if b, ok := typ.(*ast.BinaryExpr); ok {
+		return ast.NewBinExpr(token.OR, def, b.X, b.Y)
+	}
+	return ast.NewBinExpr(token.OR, def, typ)
+}
+
+// attrs returns the @graphql attributes for the directives a that are
+// recorded in CUE.
+func attrs(a []*directive) []*ast.Attribute {
+	var args []string
+	for _, d := range a {
+		switch d.name {
+		case "deprecated":
+			arg := "deprecated"
+			if v := d.lookup("reason"); v != nil {
+				arg += "=" + attrValue(v)
+			}
+			args = append(args, arg)
+		case "specifiedBy":
+			if v := d.lookup("url"); v != nil {
+				args = append(args, "specifiedBy="+attrValue(v))
+			}
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return []*ast.Attribute{internal.NewAttr("graphql", strings.Join(args, ","))}
+}
+
+// attrValue returns the attribute value for the string literal v.
+func attrValue(v ast.Expr) string {
+	lit, ok := v.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return literal.String.Quote(astinternal.DebugStr(v))
+	}
+	return lit.Value
+}
+
+// label returns the label for the GraphQL field name.
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !internal.IsDefOrHidden(name) {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+func addDoc(n ast.Node, doc string) {
+	if strings.TrimSpace(doc) == "" {
+		return
+	}
+	ast.SetComments(n, []*ast.CommentGroup{internal.NewComment(true, doc)})
+}
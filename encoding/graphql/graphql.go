@@ -0,0 +1,91 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graphql converts GraphQL schemas, written in the GraphQL schema
+// definition language (SDL), to CUE.
+//
+// The named types of a schema are mapped to CUE definitions named after the
+// type. The resulting definitions describe the JSON representation of the
+// values of these types, such as the data returned by a resolver:
+//
+//	GraphQL                   CUE
+//	Int                       int32
+//	Float                     float64
+//	String, ID                string
+//	Boolean                   bool
+//	scalar Name               #Name: _
+//	type Name {fields}        #Name: {fields}
+//	interface Name {fields}   #Name: {fields, ...}
+//	input Name {fields}       #Name: {fields}
+//	enum Name {A B}           #Name: "A" | "B"
+//	union Name = A | B        #Name: #A | #B
+//	T                         null | T
+//	T!                        T
+//	[T]                       [...T]
+//
+// A field of a non-null type is a required field, whereas a field of a
+// nullable type is optional. For instance,
+//
+//	type User {
+//	  id: ID!
+//	  name: String
+//	}
+//
+// is converted to
+//
+//	#User: {
+//		__typename?: "User"
+//		id:          string
+//		name?:       null | string
+//	}
+//
+// Interfaces are open, so that they can be used to validate the values of any
+// type implementing them. The default value of a field of an input type is
+// mapped to a CUE default. Descriptions are mapped to doc comments, and the
+// @deprecated and @specifiedBy directives to @graphql attributes.
+//
+// Extensions of a type are merged into the definition of the extended type.
+// Field arguments, directive definitions and schema definitions are ignored.
+package graphql
+
+import (
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/internal/source"
+)
+
+// Config configures the conversion of GraphQL schemas.
+type Config struct {
+	// PkgName is the package name of the generated CUE file. If it is empty,
+	// no package clause is generated.
+	PkgName string
+}
+
+// Extract parses a GraphQL schema and returns its types translated to a CUE
+// file. If src is not nil, it will use this as the contents of the file. It
+// may be a string, []byte or io.Reader. Otherwise Extract will open the given
+// file name.
+func Extract(filename string, src interface{}, cfg *Config) (*ast.File, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	b, err := source.Read(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := parse(filename, b)
+	if err != nil {
+		return nil, err
+	}
+	return extract(filename, defs, cfg)
+}
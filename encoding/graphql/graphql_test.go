@@ -0,0 +1,296 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/encoding/graphql"
+)
+
+const schema = `
+"""
+A Node is an object with a global identifier.
+"""
+interface Node {
+  id: ID!
+}
+
+"A registered user."
+type User implements Node {
+  id: ID!
+  name: String
+  "The age in years."
+  age: Int
+  score: Float!
+  roles: [Role!]!
+  friends(first: Int = 10): [User]
+  created: DateTime @deprecated(reason: "Use createdAt.")
+  _internal: Boolean
+}
+
+enum Role {
+  ADMIN
+  GUEST @deprecated
+}
+
+type Bot implements Node {
+  id: ID!
+}
+
+union Actor = | User | Bot
+
+input UserFilter {
+  name: String = null
+  roles: [Role!] = [GUEST]
+  limit: Int! = 10
+  query: String!
+}
+
+scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+
+schema {
+  query: Query
+}
+
+directive @auth(requires: Role = ADMIN) on OBJECT | FIELD_DEFINITION
+
+type Query {
+  node(id: ID!): Node
+  actors: [Actor!]!
+}
+
+extend type Query {
+  users(filter: UserFilter): [User!]!
+}
+
+extend enum Role {
+  OWNER
+}
+`
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema string
+		pkg    string
+		want   string
+	}{{
+		name:   "schema",
+		schema: schema,
+		pkg:    "api",
+		want: `package api
+
+// A Node is an object with a global identifier.
+#Node: {
+	id: string
+	...
+}
+
+// A registered user.
+#User: {
+	@graphql(implements=Node)
+	"__typename"?: "User"
+	id:            string
+	name?:         null | string
+	// The age in years.
+	age?:  null | int32
+	score: float64
+	roles: [...#Role]
+	friends?:     null | [...(null | #User)]
+	created?:     null | #DateTime @graphql(deprecated="Use createdAt.")
+	"_internal"?: null | bool
+}
+#Role: "ADMIN" | "GUEST" | "OWNER"
+#Bot: {
+	@graphql(implements=Node)
+	"__typename"?: "Bot"
+	id:            string
+}
+#Actor: #User | #Bot
+#UserFilter: {
+	name?:  *null | string
+	roles?: *["GUEST"] | null | [...#Role]
+	limit?: *10 | int32
+	query:  string
+}
+#DateTime: _ @graphql(specifiedBy="https://tools.ietf.org/html/rfc3339")
+#Query: {
+	"__typename"?: "Query"
+	node?:         null | #Node
+	actors: [...#Actor]
+	users: [...#User]
+}
+`,
+	}, {
+		name: "strings",
+		schema: `
+			"Line \"one\"!\nLine two\/"
+			scalar A
+
+			"""
+			  Indented
+
+			    block \""" string
+			"""
+			scalar B
+		`,
+		want: `// Line "one"!
+// Line two/
+#A: _
+
+// Indented
+//
+// block """ string
+#B: _
+`,
+	}, {
+		name:   "extension before definition",
+		schema: `extend type A { b: Int } "Doc." type A { a: Int }`,
+		want: `// Doc.
+#A: {
+	"__typename"?: "A"
+	b?:            null | int32
+	a?:            null | int32
+}
+`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := graphql.Extract("schema.graphql", tc.schema, &graphql.Config{
+				PkgName: tc.pkg,
+			})
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	testCases := []struct {
+		schema string
+		err    string
+	}{{
+		schema: `type A { a: B }`,
+		err:    "schema.graphql:1:1: undefined type B referred to by A",
+	}, {
+		schema: `type A { a: Int`,
+		err:    "schema.graphql:1:16: expected name, found end of file",
+	}, {
+		schema: `type A { a: [Int }`,
+		err:    `schema.graphql:1:18: expected "]", found "}"`,
+	}, {
+		schema: `query { a }`,
+		err:    `schema.graphql:1:1: executable definition "query" not allowed in schema`,
+	}, {
+		schema: `type A { a: Int } type A { b: Int }`,
+		err:    "schema.graphql:1:19: duplicate definition of A",
+	}, {
+		schema: `type A { a: Int } enum A { B }`,
+		err:    "schema.graphql:1:19: enum A conflicts with type of the same name",
+	}, {
+		schema: `type Int { a: Int }`,
+		err:    "schema.graphql:1:1: cannot redefine built-in type Int",
+	}, {
+		schema: `union U = A type A { a: Int } input B { b: Int } union V = B`,
+		err:    "schema.graphql:1:50: member B of union V is not an object type",
+	}, {
+		schema: `input A { a: Int = $x }`,
+		err:    "schema.graphql:1:20: variables not allowed in constant values",
+	}, {
+		schema: `scalar A "unterminated`,
+		err:    "schema.graphql:1:23: unterminated string",
+	}, {
+		schema: `input A { a: Int = 01 }`,
+		err:    "schema.graphql:1:20: invalid number",
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			_, err := graphql.Extract("schema.graphql", tc.schema, nil)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			e := errors.Errors(err)[0]
+			if got := fmt.Sprintf("%v: %v", e.Position(), e); got != tc.err {
+				t.Errorf("got %q; want %q", got, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f, err := graphql.Extract("schema.graphql", schema, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := cuecontext.New()
+	v := ctx.BuildFile(f)
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		def  string
+		data string
+		ok   bool
+	}{{
+		def:  "#User",
+		data: `{"__typename": "User", "id": "1", "score": 1.5, "roles": ["ADMIN"], "friends": [null, {"id": "2", "score": 2, "roles": []}]}`,
+		ok:   true,
+	}, {
+		def:  "#User",
+		data: `{"id": "1", "score": 1, "roles": ["ROOT"]}`,
+	}, {
+		def:  "#User",
+		data: `{"id": "1", "roles": []}`,
+	}, {
+		def:  "#User",
+		data: `{"id": "1", "score": 1, "roles": [], "unknown": 1}`,
+	}, {
+		def:  "#Node",
+		data: `{"id": "1", "name": "any field"}`,
+		ok:   true,
+	}, {
+		def:  "#Actor",
+		data: `{"__typename": "Bot", "id": "1"}`,
+		ok:   true,
+	}, {
+		def:  "#UserFilter",
+		data: `{"query": "x", "limit": 3000000000}`,
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			data := ctx.CompileString(tc.data)
+			w := v.LookupPath(cue.ParsePath(tc.def)).Unify(data)
+			err := w.Validate(cue.Concrete(true))
+			if ok := err == nil; ok != tc.ok {
+				t.Errorf("%s: got error %v; want ok %v", tc.def, err, tc.ok)
+			}
+		})
+	}
+}
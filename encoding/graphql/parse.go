@@ -0,0 +1,622 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// This file contains a parser for the type system definitions of the GraphQL
+// schema definition language, as defined in
+// https://spec.graphql.org/October2021/#sec-Type-System.
+
+// A definition is a type definition or extension of a schema.
+type definition struct {
+	pos        token.Pos
+	kind       string // type, interface, input, enum, union or scalar
+	extend     bool
+	name       string
+	desc       string
+	interfaces []string
+	fields     []*field
+	values     []*enumValue
+	members    []string
+	directives []*directive
+}
+
+// A field is a field of an object or interface type, or of an input type.
+type field struct {
+	name       string
+	desc       string
+	typ        *typeRef
+	value      ast.Expr // default value, or nil
+	directives []*directive
+}
+
+type enumValue struct {
+	name       string
+	desc       string
+	directives []*directive
+}
+
+// A typeRef refers to a named type, or to a list of elem if name is empty.
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+type directive struct {
+	name string
+	args []*argument
+}
+
+type argument struct {
+	name  string
+	value ast.Expr
+}
+
+// lookup returns the value of the argument name of d, or nil if it does not
+// exist.
+func (d *directive) lookup(name string) ast.Expr {
+	for _, a := range d.args {
+		if a.name == name {
+			return a.value
+		}
+	}
+	return nil
+}
+
+type tokenKind int
+
+const (
+	eof tokenKind = iota
+	punct
+	name
+	intLit
+	floatLit
+	stringLit
+)
+
+type parser struct {
+	file *token.File
+	src  []byte
+
+	// current token
+	kind tokenKind
+	lit  string // decoded value for strings
+	off  int
+
+	next int // offset after current token
+}
+
+// parse parses the GraphQL type system document src.
+func parse(filename string, src []byte) (defs []*definition, err error) {
+	p := &parser{
+		file: token.NewFile(filename, -1, len(src)),
+		src:  src,
+	}
+	p.file.SetLinesForContent(src)
+
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(errors.Error)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+
+	p.scan()
+	for p.kind != eof {
+		if d := p.definition(); d != nil {
+			defs = append(defs, d)
+		}
+	}
+	return defs, nil
+}
+
+func (p *parser) pos() token.Pos {
+	return p.file.Pos(p.off, token.NoRelPos)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) {
+	panic(errors.Newf(p.pos(), format, args...))
+}
+
+// tok returns a description of the current token for use in error messages.
+func (p *parser) tok() string {
+	switch p.kind {
+	case eof:
+		return "end of file"
+	case stringLit:
+		return "string"
+	}
+	return strconv.Quote(p.lit)
+}
+
+func (p *parser) is(kind tokenKind, lit string) bool {
+	return p.kind == kind && p.lit == lit
+}
+
+// got advances to the next token and reports true if the current token is
+// the punctuator s.
+func (p *parser) got(s string) bool {
+	if p.is(punct, s) {
+		p.scan()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) {
+	if !p.got(s) {
+		p.errorf("expected %q, found %s", s, p.tok())
+	}
+}
+
+func (p *parser) name() string {
+	if p.kind != name {
+		p.errorf("expected name, found %s", p.tok())
+	}
+	s := p.lit
+	p.scan()
+	return s
+}
+
+// description parses an optional description.
+func (p *parser) description() string {
+	if p.kind != stringLit {
+		return ""
+	}
+	s := p.lit
+	p.scan()
+	return s
+}
+
+func (p *parser) definition() *definition {
+	desc := p.description()
+	d := &definition{pos: p.pos(), desc: desc}
+	if p.is(name, "extend") {
+		d.extend = true
+		p.scan()
+	}
+	if p.kind != name {
+		p.errorf("expected definition, found %s", p.tok())
+	}
+	d.kind = p.lit
+	switch d.kind {
+	case "schema":
+		p.scan()
+		p.directives()
+		if p.got("{") {
+			for !p.got("}") {
+				p.name()
+				p.expect(":")
+				p.name()
+			}
+		}
+		return nil
+
+	case "directive":
+		p.scan()
+		p.expect("@")
+		p.name()
+		p.arguments()
+		if p.is(name, "repeatable") {
+			p.scan()
+		}
+		if !p.is(name, "on") {
+			p.errorf(`expected "on", found %s`, p.tok())
+		}
+		p.scan()
+		p.got("|")
+		p.name()
+		for p.got("|") {
+			p.name()
+		}
+		return nil
+
+	case "type", "interface":
+		p.scan()
+		d.name = p.name()
+		if p.is(name, "implements") {
+			p.scan()
+			p.got("&")
+			d.interfaces = append(d.interfaces, p.name())
+			for p.got("&") {
+				d.interfaces = append(d.interfaces, p.name())
+			}
+		}
+		d.directives = p.directives()
+		d.fields = p.fields(false)
+
+	case "input":
+		p.scan()
+		d.name = p.name()
+		d.directives = p.directives()
+		d.fields = p.fields(true)
+
+	case "enum":
+		p.scan()
+		d.name = p.name()
+		d.directives = p.directives()
+		if p.got("{") {
+			for !p.got("}") {
+				v := &enumValue{desc: p.description()}
+				v.name = p.name()
+				v.directives = p.directives()
+				d.values = append(d.values, v)
+			}
+		}
+
+	case "union":
+		p.scan()
+		d.name = p.name()
+		d.directives = p.directives()
+		if p.got("=") {
+			p.got("|")
+			d.members = append(d.members, p.name())
+			for p.got("|") {
+				d.members = append(d.members, p.name())
+			}
+		}
+
+	case "scalar":
+		p.scan()
+		d.name = p.name()
+		d.directives = p.directives()
+
+	case "query", "mutation", "subscription", "fragment":
+		p.errorf("executable definition %q not allowed in schema", d.kind)
+
+	default:
+		p.errorf("unknown definition %q", d.kind)
+	}
+	return d
+}
+
+// fields parses an optional list of field definitions, or input value
+// definitions if input is true.
+func (p *parser) fields(input bool) (a []*field) {
+	if !p.got("{") {
+		return nil
+	}
+	for !p.got("}") {
+		f := &field{desc: p.description()}
+		f.name = p.name()
+		if !input {
+			p.arguments()
+		}
+		p.expect(":")
+		f.typ = p.typeRef()
+		if input && p.got("=") {
+			f.value = p.value()
+		}
+		f.directives = p.directives()
+		a = append(a, f)
+	}
+	return a
+}
+
+// arguments parses and discards optional argument definitions.
+func (p *parser) arguments() {
+	if !p.got("(") {
+		return
+	}
+	for !p.got(")") {
+		p.description()
+		p.name()
+		p.expect(":")
+		p.typeRef()
+		if p.got("=") {
+			p.value()
+		}
+		p.directives()
+	}
+}
+
+func (p *parser) typeRef() *typeRef {
+	t := &typeRef{}
+	if p.got("[") {
+		t.elem = p.typeRef()
+		p.expect("]")
+	} else {
+		t.name = p.name()
+	}
+	t.nonNull = p.got("!")
+	return t
+}
+
+func (p *parser) directives() (a []*directive) {
+	for p.got("@") {
+		d := &directive{name: p.name()}
+		if p.got("(") {
+			for !p.got(")") {
+				arg := &argument{name: p.name()}
+				p.expect(":")
+				arg.value = p.value()
+				d.args = append(d.args, arg)
+			}
+		}
+		a = append(a, d)
+	}
+	return a
+}
+
+// value parses a constant value.
+func (p *parser) value() ast.Expr {
+	lit := p.lit
+	switch p.kind {
+	case intLit:
+		p.scan()
+		return ast.NewLit(token.INT, lit)
+	case floatLit:
+		p.scan()
+		return ast.NewLit(token.FLOAT, lit)
+	case stringLit:
+		p.scan()
+		return ast.NewString(lit)
+	case name:
+		p.scan()
+		switch lit {
+		case "true", "false":
+			return ast.NewBool(lit == "true")
+		case "null":
+			return ast.NewNull()
+		}
+		// Enum values are represented as strings.
+		return ast.NewString(lit)
+	}
+	switch {
+	case p.got("["):
+		list := ast.NewList()
+		for !p.got("]") {
+			list.Elts = append(list.Elts, p.value())
+		}
+		return list
+	case p.got("{"):
+		s := ast.NewStruct()
+		for !p.got("}") {
+			f := &ast.Field{Label: label(p.name())}
+			p.expect(":")
+			f.Value = p.value()
+			s.Elts = append(s.Elts, f)
+		}
+		return s
+	case p.is(punct, "$"):
+		p.errorf("variables not allowed in constant values")
+	}
+	p.errorf("expected value, found %s", p.tok())
+	return nil
+}
+
+// scan advances to the next token, skipping white space, commas and
+// comments.
+func (p *parser) scan() {
+	src := p.src
+	i := p.next
+skip:
+	for i < len(src) {
+		switch src[i] {
+		case ' ', '\t', '\n', '\r', ',':
+			i++
+		case '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		default:
+			if !strings.HasPrefix(string(src[i:]), "\uFEFF") {
+				break skip
+			}
+			i += len("\uFEFF") // byte order mark
+		}
+	}
+	p.off = i
+	if i >= len(src) {
+		p.kind, p.lit, p.next = eof, "", i
+		return
+	}
+
+	c := src[i]
+	switch {
+	case isNameStart(c):
+		j := i + 1
+		for j < len(src) && (isNameStart(src[j]) || isDigit(src[j])) {
+			j++
+		}
+		p.kind, p.lit, p.next = name, string(src[i:j]), j
+
+	case isDigit(c) || c == '-':
+		p.number(i)
+
+	case c == '"':
+		p.string(i)
+
+	case strings.HasPrefix(string(src[i:]), "..."):
+		p.kind, p.lit, p.next = punct, "...", i+3
+
+	case strings.IndexByte("!$&():=@[]{|}", c) >= 0:
+		p.kind, p.lit, p.next = punct, string(c), i+1
+
+	default:
+		r, _ := utf8.DecodeRune(src[i:])
+		p.errorf("unexpected character %q", r)
+	}
+}
+
+func (p *parser) number(i int) {
+	src := p.src
+	j := i
+	if src[j] == '-' {
+		j++
+	}
+	start := j
+	for j < len(src) && isDigit(src[j]) {
+		j++
+	}
+	if j == start || (src[start] == '0' && j-start > 1) {
+		p.errorf("invalid number")
+	}
+	p.kind = intLit
+	if j < len(src) && src[j] == '.' {
+		p.kind = floatLit
+		j++
+		start = j
+		for j < len(src) && isDigit(src[j]) {
+			j++
+		}
+		if j == start {
+			p.errorf("invalid number")
+		}
+	}
+	if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+		p.kind = floatLit
+		j++
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		start = j
+		for j < len(src) && isDigit(src[j]) {
+			j++
+		}
+		if j == start {
+			p.errorf("invalid number")
+		}
+	}
+	if j < len(src) && (isNameStart(src[j]) || src[j] == '.') {
+		p.errorf("invalid number")
+	}
+	p.lit, p.next = string(src[i:j]), j
+}
+
+func (p *parser) string(i int) {
+	src := p.src
+	if strings.HasPrefix(string(src[i:]), `"""`) {
+		var b strings.Builder
+		j := i + 3
+		for {
+			if j >= len(src) {
+				p.errorf("unterminated string")
+			}
+			if strings.HasPrefix(string(src[j:]), `\"""`) {
+				b.WriteString(`"""`)
+				j += 4
+				continue
+			}
+			if strings.HasPrefix(string(src[j:]), `"""`) {
+				break
+			}
+			b.WriteByte(src[j])
+			j++
+		}
+		p.kind, p.lit, p.next = stringLit, blockString(b.String()), j+3
+		return
+	}
+
+	var b strings.Builder
+	j := i + 1
+	for ; ; j++ {
+		if j >= len(src) || src[j] == '\n' || src[j] == '\r' {
+			p.off = j
+			p.errorf("unterminated string")
+		}
+		c := src[j]
+		if c == '"' {
+			break
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		j++
+		if j >= len(src) {
+			continue
+		}
+		switch c := src[j]; c {
+		case '"', '\\', '/':
+			b.WriteByte(c)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if j+5 > len(src) {
+				p.errorf("invalid escape sequence")
+			}
+			r, err := strconv.ParseUint(string(src[j+1:j+5]), 16, 32)
+			if err != nil {
+				p.errorf("invalid escape sequence")
+			}
+			b.WriteRune(rune(r))
+			j += 4
+		default:
+			p.errorf("invalid escape sequence")
+		}
+	}
+	p.kind, p.lit, p.next = stringLit, b.String(), j+1
+}
+
+// blockString returns the value of a block string with raw contents s, as
+// defined in https://spec.graphql.org/October2021/#BlockStringValue().
+func blockString(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(strings.ReplaceAll(s, "\r", "\n"), "\n")
+
+	indent := -1
+	for _, line := range lines[1:] {
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if n < len(line) && (indent < 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i, line := range lines[1:] {
+			if len(line) < indent {
+				lines[i+1] = ""
+			} else {
+				lines[i+1] = line[indent:]
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/internal"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "empty",
+		in:   "",
+		out:  "",
+	}, {
+		name: "attributes",
+		in: `
+str = "a\tbé"
+int = 42
+neg = -17
+float = 3.14
+exp = 1e10
+t = true
+n = null
+list = [1, "two", [3]]
+obj = { a = 1, "b c": 2 }
+multi = {
+  x = 1
+  y = 2
+}
+`,
+		out: `
+str:   "a\tbé"
+int:   42
+neg:   -17
+float: 3.14
+exp:   1e10
+t:     true
+n:     null
+list: [1, "two", [3]]
+obj: {
+	a:     1
+	"b c": 2
+}
+multi: {
+	x: 1
+	y: 2
+}
+`,
+	}, {
+		name: "expressions",
+		in: `
+sum = 1 + 2 * 3
+group = (1 + 2) * 3
+ref = var.region
+call = lookup(var.amis, "us-east-1", "ami-0")
+cond = var.enabled ? 1 : 0
+index = aws_instance.web[0].id
+splat = aws_instance.web[*].id
+for = [for s in var.list : upper(s) if s != ""]
+for_obj = {for k, v in var.map : k => v...}
+mixed = [1, var.x]
+template = "Hello, ${var.name}!"
+escaped = "$${literal}"
+mod = 5 % 2
+`,
+		out: `
+sum:     1 + (2 * 3)
+group:   (1 + 2) * 3
+ref:     "${var.region}"
+call:    "${lookup(var.amis, \"us-east-1\", \"ami-0\")}"
+cond:    "${var.enabled ? 1 : 0}"
+index:   "${aws_instance.web[0].id}"
+splat:   "${aws_instance.web[*].id}"
+for:     "${[for s in var.list : upper(s) if s != \"\"]}"
+for_obj: "${{for k, v in var.map : k => v...}}"
+mixed: [1, "${var.x}"]
+template: "Hello, ${var.name}!"
+escaped:  "$${literal}"
+mod:      "${5 % 2}"
+`,
+	}, {
+		name: "heredoc",
+		in: `
+a = <<EOT
+line 1
+  line 2
+EOT
+b = <<-EOT
+    indented ${var.x}
+      more
+    EOT
+`,
+		out: `
+a: "line 1\n  line 2\n"
+b: "indented ${var.x}\n  more\n"
+`,
+	}, {
+		name: "blocks",
+		in: `
+# The provider.
+provider "aws" {
+  region = "us-east-1" # line comment
+}
+
+/* Inline */ resource "aws_instance" "web" {
+  ami = "ami-123"
+  tags = {
+    Name = "web"
+  }
+
+  ebs_block_device {
+    device_name = "/dev/sda1"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+}
+
+resource "aws_instance" "db" { ami = "ami-456" }
+
+// Unattached comment.
+
+terraform {}
+`,
+		out: `
+// The provider.
+provider: aws: {
+	region: "us-east-1" // line comment
+}
+
+resource: aws_instance: {
+	web: {
+		ami: "ami-123"
+		tags: {
+			Name: "web"
+		}
+
+		ebs_block_device: [
+			{
+				device_name: "/dev/sda1"
+			},
+			{
+				device_name: "/dev/sdb"
+			},
+		]
+	}
+
+	db: {
+		ami: "ami-456"
+	}
+}
+
+// Unattached comment.
+
+terraform: {}
+`,
+	}, {
+		name: "duplicate attribute",
+		in:   "a = 1\na = 2",
+		err:  `test.tf:2:1: "a" is already defined`,
+	}, {
+		name: "attribute and block",
+		in:   "a = 1\na \"b\" {}",
+		err:  `test.tf:2:1: "a" is already defined`,
+	}, {
+		name: "missing newline",
+		in:   "a = 1 b = 2",
+		err:  `test.tf:1:7: expected newline, found 'b'`,
+	}, {
+		name: "unclosed block",
+		in:   "a {\n",
+		err:  `test.tf:1:5: expected '}', found end of file`,
+	}, {
+		name: "template label",
+		in:   `a "${x}" {}`,
+		err:  `test.tf:1:3: block labels must be literal strings`,
+	}, {
+		name: "unterminated string",
+		in:   "a = \"abc\n",
+		err:  `test.tf:1:5: unterminated string`,
+	}, {
+		name: "invalid escape",
+		in:   `a = "\x"`,
+		err:  `test.tf:1:6: invalid escape sequence`,
+	}, {
+		name: "unterminated heredoc",
+		in:   "a = <<EOT\nfoo\n",
+		err:  `test.tf:1:5: heredoc not terminated: missing EOT`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDecoder("test.tf", strings.NewReader(tc.in))
+			expr, err := d.Decode()
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				got := fmt.Sprintf("%v: %v", err.(errors.Error).Position(), err)
+				if got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(internal.ToFile(expr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSpace(string(b)), strings.TrimSpace(tc.out); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := d.Decode(); err != io.EOF {
+				t.Errorf("second Decode: got %v; want io.EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hcl converts files in the HCL2 native syntax, such as Terraform
+// configurations, to CUE.
+//
+// The conversion follows the conventions of the JSON syntax of HCL.
+// Attributes map to fields. A block maps to a field named after the block
+// type, with a nested field for each of its labels. For instance,
+//
+//	resource "aws_instance" "web" { ami = "ami-123" }
+//
+// maps to
+//
+//	resource: aws_instance: web: {ami: "ami-123"}
+//
+// Blocks with the same type and labels in the same body map to a list.
+//
+// Expressions that can be resolved statically, such as literals, tuples,
+// objects, and arithmetic on them, are converted to CUE. Other expressions,
+// such as references to variables and function calls, are converted to a
+// string holding the expression in template syntax, as in "${var.region}".
+// Strings are converted to their literal value; template sequences, such as
+// "${var.name}" and "%{if true}", and their escapes are retained as written.
+//
+// Comments preceding an attribute or block are retained as doc comments.
+package hcl
+
+import (
+	"io"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// A Decoder reads an HCL file and converts it to CUE.
+type Decoder struct {
+	filename string
+	r        io.Reader
+	done     bool
+}
+
+// NewDecoder returns a decoder that reads an HCL file from r. The filename
+// is used for position information.
+func NewDecoder(filename string, r io.Reader) *Decoder {
+	return &Decoder{filename: filename, r: r}
+}
+
+// Decode parses the HCL file and returns it as a CUE struct literal. An HCL
+// file holds a single body, so any subsequent call returns io.EOF.
+func (d *Decoder) Decode() (ast.Expr, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	d.done = true
+	src, err := io.ReadAll(d.r)
+	if err != nil {
+		return nil, err
+	}
+	f := token.NewFile(d.filename, -1, len(src))
+	f.SetLinesForContent(src)
+	return parse(f, src)
+}
@@ -0,0 +1,991 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+type parser struct {
+	file *token.File
+	src  []byte
+	off  int
+
+	// nest is the bracket nesting depth. Newlines are only significant
+	// outside of brackets.
+	nest int
+
+	// comments holds the comment lines preceding the next attribute or
+	// block.
+	comments []string
+
+	// section reports whether the next attribute or block is preceded by a
+	// blank line.
+	section bool
+}
+
+// bailout is used to abort parsing on the first error.
+type bailout struct{ err errors.Error }
+
+func parse(f *token.File, src []byte) (expr ast.Expr, err error) {
+	p := &parser{file: f, src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(bailout)
+			if !ok {
+				panic(r)
+			}
+			expr, err = nil, b.err
+		}
+	}()
+	o := newObject(token.NoPos)
+	p.parseBody(o, 0)
+	return o.lit, nil
+}
+
+func (p *parser) pos(off int) token.Pos {
+	return p.file.Pos(off, token.NoRelPos)
+}
+
+func (p *parser) errf(off int, format string, args ...interface{}) {
+	panic(bailout{errors.Newf(p.pos(off), format, args...)})
+}
+
+func (p *parser) eof() bool { return p.off >= len(p.src) }
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.off]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(p.src[p.off:]), s)
+}
+
+func (p *parser) found() string {
+	if p.eof() {
+		return "end of file"
+	}
+	if p.peek() == '\n' {
+		return "newline"
+	}
+	r, _ := utf8.DecodeRune(p.src[p.off:])
+	return strconv.QuoteRune(r)
+}
+
+func (p *parser) expect(c byte) {
+	if p.peek() != c {
+		p.errf(p.off, "expected %q, found %s", c, p.found())
+	}
+	p.off++
+}
+
+// skipSpace skips white space and inline comments. Newlines and line comments
+// are skipped as well within brackets.
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.off++
+		case p.hasPrefix("/*"):
+			end := strings.Index(string(p.src[p.off+2:]), "*/")
+			if end < 0 {
+				p.errf(p.off, "comment not terminated")
+			}
+			p.off += end + 4
+		case p.nest > 0 && c == '\n':
+			p.off++
+		case p.nest > 0 && (c == '#' || p.hasPrefix("//")):
+			p.lineComment()
+		default:
+			return
+		}
+	}
+}
+
+// lineComment reads a comment up to, but not including, the end of the line
+// and returns its text.
+func (p *parser) lineComment() string {
+	start := p.off
+	for !p.eof() && p.peek() != '\n' {
+		p.off++
+	}
+	text := string(p.src[start:p.off])
+	if strings.HasPrefix(text, "#") {
+		text = text[1:]
+	} else {
+		text = text[2:]
+	}
+	return strings.TrimSpace(strings.TrimRight(text, "\r"))
+}
+
+// parseBody parses the attributes and blocks of a body into o, up to the
+// end of file, if end is 0, or the given closing character.
+func (p *parser) parseBody(o *object, end byte) {
+	blank := end == 0 // the current line holds only white space
+	for {
+		p.skipSpace()
+		switch c := p.peek(); {
+		case p.eof():
+			if end != 0 {
+				p.errf(p.off, "expected %q, found end of file", end)
+			}
+			p.flushComments(o)
+			return
+
+		case c == end:
+			p.flushComments(o)
+			return
+
+		case c == '\n':
+			p.off++
+			if blank {
+				p.flushComments(o)
+				p.section = true
+			}
+			blank = true
+
+		case c == '#' || p.hasPrefix("//"):
+			p.comments = append(p.comments, p.lineComment())
+			blank = false
+
+		case isIdentStart(rune(c)):
+			p.parseItem(o, end)
+			blank = false
+
+		default:
+			p.errf(p.off, "expected attribute or block, found %s", p.found())
+		}
+	}
+}
+
+// flushComments adds pending comments that are not directly followed by an
+// attribute or block to o.
+func (p *parser) flushComments(o *object) {
+	if len(p.comments) == 0 {
+		return
+	}
+	cg := &ast.CommentGroup{}
+	for _, c := range p.comments {
+		cg.List = append(cg.List, comment(c))
+	}
+	ast.SetRelPos(cg, token.NewSection)
+	p.comments = nil
+	p.section = false
+	o.lit.Elts = append(o.lit.Elts, cg)
+}
+
+// parseItem parses an attribute or block definition.
+func (p *parser) parseItem(o *object, end byte) {
+	comments, section := p.comments, p.section
+	p.comments, p.section = nil, false
+
+	start := p.off
+	name := p.ident()
+	p.skipSpace()
+
+	if p.peek() == '=' && !p.hasPrefix("==") {
+		p.off++
+		p.skipSpace()
+		v := p.parseExpr()
+		f := &ast.Field{Label: label(name, p.pos(start)), Value: p.value(v)}
+		addDoc(f, comments)
+		p.section = section
+		o.addAttr(p, start, name, f)
+		p.skipSpace()
+		if c := p.peek(); c == '#' || p.hasPrefix("//") {
+			ast.AddComment(f.Value, &ast.CommentGroup{
+				Line:     true,
+				Position: 4,
+				List:     []*ast.Comment{comment(p.lineComment())},
+			})
+		}
+		p.endItem(end)
+		return
+	}
+
+	var labels []string
+	var positions []token.Pos
+	for {
+		lstart := p.off
+		switch c := p.peek(); {
+		case c == '"':
+			s, ok := p.parseString()
+			if !ok {
+				p.errf(lstart, "block labels must be literal strings")
+			}
+			labels = append(labels, s)
+		case isIdentStart(rune(c)):
+			labels = append(labels, p.ident())
+		case c == '{':
+			p.off++
+			body := newObject(p.pos(lstart))
+			p.parseBody(body, '}')
+			p.off++
+			body.lit.Rbrace = p.pos(p.off - 1)
+			p.section = section
+			o.addBlock(p, start, name, labels, positions, body.lit, comments)
+			p.skipSpace()
+			p.endItem(end)
+			return
+		default:
+			p.errf(p.off, "expected block label or '{', found %s", p.found())
+		}
+		positions = append(positions, p.pos(lstart))
+		p.skipSpace()
+	}
+}
+
+// endItem verifies that an attribute or block definition is followed by a
+// newline, a line comment, or the end of the enclosing body.
+func (p *parser) endItem(end byte) {
+	switch c := p.peek(); {
+	case p.eof(), c == '\n', c == '#', p.hasPrefix("//"):
+	case end != 0 && c == end:
+	default:
+		p.errf(p.off, "expected newline, found %s", p.found())
+	}
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentChar(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (p *parser) ident() string {
+	start := p.off
+	for !p.eof() {
+		r, size := utf8.DecodeRune(p.src[p.off:])
+		if p.off == start && !isIdentStart(r) || !isIdentChar(r) {
+			break
+		}
+		p.off += size
+	}
+	if p.off == start {
+		p.errf(p.off, "expected identifier, found %s", p.found())
+	}
+	return string(p.src[start:p.off])
+}
+
+// A value is a parsed expression.
+type value struct {
+	// expr is the CUE representation of the expression, or nil if the
+	// expression cannot be resolved statically.
+	expr ast.Expr
+
+	start, end int
+}
+
+// value returns the CUE representation of v. Expressions that cannot be
+// resolved statically are represented as a template holding the expression.
+func (p *parser) value(v value) ast.Expr {
+	if v.expr != nil {
+		return v.expr
+	}
+	return newString(p.pos(v.start), "${"+string(p.src[v.start:v.end])+"}")
+}
+
+func (p *parser) parseExpr() value {
+	cond := p.parseBinary(0)
+	p.skipSpace()
+	if p.peek() != '?' {
+		return cond
+	}
+	p.off++
+	p.skipSpace()
+	p.parseExpr()
+	p.skipSpace()
+	p.expect(':')
+	p.skipSpace()
+	v := p.parseExpr()
+	return value{start: cond.start, end: v.end}
+}
+
+// binaryOps lists the binary operators by increasing precedence.
+var binaryOps = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// cueOps maps the HCL operators that have the same meaning in CUE.
+var cueOps = map[string]token.Token{
+	"||": token.LOR,
+	"&&": token.LAND,
+	"==": token.EQL,
+	"!=": token.NEQ,
+	"<=": token.LEQ,
+	">=": token.GEQ,
+	"<":  token.LSS,
+	">":  token.GTR,
+	"+":  token.ADD,
+	"-":  token.SUB,
+	"*":  token.MUL,
+	"/":  token.QUO,
+}
+
+func (p *parser) parseBinary(level int) value {
+	if level == len(binaryOps) {
+		return p.parseUnary()
+	}
+	x := p.parseBinary(level + 1)
+	for {
+		save := p.off
+		p.skipSpace()
+		op := ""
+		for _, s := range binaryOps[level] {
+			if p.hasPrefix(s) {
+				op = s
+				break
+			}
+		}
+		// Exclude heredocs and the "=>" of for expressions.
+		if op == "" || op == "<" && p.hasPrefix("<<") {
+			p.off = save
+			return x
+		}
+		opPos := p.pos(p.off)
+		p.off += len(op)
+		p.skipSpace()
+		y := p.parseBinary(level + 1)
+		v := value{start: x.start, end: y.end}
+		if tok, ok := cueOps[op]; ok && x.expr != nil && y.expr != nil {
+			v.expr = &ast.BinaryExpr{X: paren(x.expr), OpPos: opPos, Op: tok, Y: paren(y.expr)}
+		}
+		x = v
+	}
+}
+
+// paren wraps binary expressions in parentheses to retain the evaluation
+// order of HCL.
+func paren(x ast.Expr) ast.Expr {
+	if _, ok := x.(*ast.BinaryExpr); ok {
+		return &ast.ParenExpr{X: x}
+	}
+	return x
+}
+
+func (p *parser) parseUnary() value {
+	start := p.off
+	var op token.Token
+	switch p.peek() {
+	case '-':
+		op = token.SUB
+	case '!':
+		op = token.NOT
+	default:
+		return p.parsePostfix()
+	}
+	p.off++
+	p.skipSpace()
+	x := p.parseUnary()
+	v := value{start: start, end: x.end}
+	if x.expr != nil {
+		v.expr = &ast.UnaryExpr{OpPos: p.pos(start), Op: op, X: paren(x.expr)}
+	}
+	return v
+}
+
+// parsePostfix parses an expression term followed by any number of index,
+// attribute access, and splat operators.
+func (p *parser) parsePostfix() value {
+	x := p.parseTerm()
+	for {
+		switch {
+		case p.hasPrefix("[*]") || p.hasPrefix(".*"):
+			p.off += len(".*")
+			if p.peek() == ']' {
+				p.off++
+			}
+		case p.peek() == '[':
+			p.off++
+			p.nest++
+			p.skipSpace()
+			p.parseExpr()
+			p.skipSpace()
+			p.expect(']')
+			p.nest--
+		case p.peek() == '.' && !p.hasPrefix("..."):
+			p.off++
+			if c := p.peek(); c >= '0' && c <= '9' {
+				p.parseNumber()
+			} else {
+				p.ident()
+			}
+		default:
+			return x
+		}
+		x = value{start: x.start, end: p.off}
+	}
+}
+
+func (p *parser) parseTerm() value {
+	start := p.off
+	switch c := p.peek(); {
+	case c >= '0' && c <= '9':
+		return value{expr: p.parseNumber(), start: start, end: p.off}
+
+	case c == '"':
+		s, _ := p.parseString()
+		return value{expr: newString(p.pos(start), s), start: start, end: p.off}
+
+	case p.hasPrefix("<<"):
+		s := p.parseHeredoc()
+		return value{expr: newString(p.pos(start), s), start: start, end: p.off}
+
+	case c == '[':
+		return p.parseTuple()
+
+	case c == '{':
+		return p.parseObject()
+
+	case c == '(':
+		p.off++
+		p.nest++
+		p.skipSpace()
+		x := p.parseExpr()
+		p.skipSpace()
+		p.expect(')')
+		p.nest--
+		v := value{start: start, end: p.off}
+		if x.expr != nil {
+			v.expr = &ast.ParenExpr{Lparen: p.pos(start), X: x.expr, Rparen: p.pos(p.off - 1)}
+		}
+		return v
+
+	case isIdentStart(rune(c)):
+		name := p.ident()
+		save := p.off
+		p.skipSpace()
+		if p.peek() == '(' {
+			p.parseCall()
+			return value{start: start, end: p.off}
+		}
+		p.off = save
+		switch name {
+		case "true", "false":
+			return value{expr: ast.NewBool(name == "true"), start: start, end: p.off}
+		case "null":
+			return value{expr: ast.NewNull(), start: start, end: p.off}
+		}
+		// A variable reference.
+		return value{start: start, end: p.off}
+	}
+	p.errf(p.off, "expected expression, found %s", p.found())
+	panic("unreachable")
+}
+
+func (p *parser) parseNumber() ast.Expr {
+	start := p.off
+	digits := func() {
+		n := p.off
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.off++
+		}
+		if p.off == n {
+			p.errf(p.off, "expected digit, found %s", p.found())
+		}
+	}
+	kind := token.INT
+	digits()
+	if p.peek() == '.' && p.off+1 < len(p.src) && p.src[p.off+1] >= '0' && p.src[p.off+1] <= '9' {
+		p.off++
+		digits()
+		kind = token.FLOAT
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.off++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.off++
+		}
+		digits()
+		kind = token.FLOAT
+	}
+	return &ast.BasicLit{ValuePos: p.pos(start), Kind: kind, Value: string(p.src[start:p.off])}
+}
+
+// parseString parses a quoted template. It returns the literal value of the
+// string and whether the string contained no template sequences. For
+// templates, the value retains the template sequences as written.
+func (p *parser) parseString() (s string, literal bool) {
+	start := p.off
+	p.off++
+	var b strings.Builder
+	literal = true
+	for {
+		if p.eof() || p.peek() == '\n' {
+			p.errf(start, "unterminated string")
+		}
+		switch c := p.peek(); {
+		case c == '"':
+			p.off++
+			return b.String(), literal
+
+		case c == '\\':
+			p.parseEscape(&b)
+
+		case p.hasPrefix("$${") || p.hasPrefix("%%{"):
+			// Escaped template sequences are retained, as the result is
+			// interpreted as a template.
+			b.WriteString(string(p.src[p.off : p.off+3]))
+			p.off += 3
+
+		case p.hasPrefix("${") || p.hasPrefix("%{"):
+			n := p.off
+			p.skipTemplate()
+			b.Write(p.src[n:p.off])
+			literal = false
+
+		default:
+			r, size := utf8.DecodeRune(p.src[p.off:])
+			b.WriteRune(r)
+			p.off += size
+		}
+	}
+}
+
+func (p *parser) parseEscape(b *strings.Builder) {
+	start := p.off
+	p.off++
+	c := p.peek()
+	p.off++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.off+n > len(p.src) {
+			p.errf(start, "invalid escape sequence")
+		}
+		r, err := strconv.ParseUint(string(p.src[p.off:p.off+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			p.errf(start, "invalid escape sequence")
+		}
+		b.WriteRune(rune(r))
+		p.off += n
+	default:
+		p.errf(start, "invalid escape sequence")
+	}
+}
+
+// skipTemplate skips a template interpolation or directive.
+func (p *parser) skipTemplate() {
+	start := p.off
+	interpolation := p.peek() == '$'
+	p.off += 2
+	p.nest++
+	if p.peek() == '~' {
+		p.off++
+	}
+	p.skipSpace()
+	if interpolation {
+		p.parseExpr()
+	} else {
+		// Directives, such as %{ if cond } and %{ for x in list }, hold
+		// keywords and expressions.
+		for p.peek() != '~' && p.peek() != '}' {
+			if p.eof() {
+				p.errf(start, "unterminated template sequence")
+			}
+			p.parseExpr()
+			p.skipSpace()
+			if p.peek() == ',' {
+				p.off++
+			}
+			p.skipSpace()
+		}
+	}
+	p.skipSpace()
+	if p.peek() == '~' {
+		p.off++
+	}
+	p.expect('}')
+	p.nest--
+}
+
+// parseHeredoc parses a heredoc template. Template sequences are retained as
+// written.
+func (p *parser) parseHeredoc() string {
+	start := p.off
+	p.off += 2
+	indented := p.peek() == '-'
+	if indented {
+		p.off++
+	}
+	marker := p.ident()
+	if p.hasPrefix("\r\n") {
+		p.off++
+	}
+	if p.peek() != '\n' {
+		p.errf(p.off, "expected newline after heredoc marker, found %s", p.found())
+	}
+	p.off++
+
+	var lines []string
+	for {
+		if p.eof() {
+			p.errf(start, "heredoc not terminated: missing %s", marker)
+		}
+		n := p.off
+		for !p.eof() && p.peek() != '\n' {
+			p.off++
+		}
+		line := strings.TrimSuffix(string(p.src[n:p.off]), "\r")
+		if strings.TrimSpace(line) == marker {
+			break
+		}
+		lines = append(lines, line)
+		if !p.eof() {
+			p.off++
+		}
+	}
+
+	if indented {
+		trim := -1
+		for _, l := range lines {
+			if strings.TrimSpace(l) == "" {
+				continue
+			}
+			n := len(l) - len(strings.TrimLeft(l, " \t"))
+			if trim < 0 || n < trim {
+				trim = n
+			}
+		}
+		for i, l := range lines {
+			if len(l) >= trim && trim > 0 {
+				lines[i] = l[trim:]
+			} else {
+				lines[i] = strings.TrimLeft(l, " \t")
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// parseCall parses the arguments of a function call.
+func (p *parser) parseCall() {
+	p.expect('(')
+	p.nest++
+	for {
+		p.skipSpace()
+		if p.peek() == ')' {
+			break
+		}
+		p.parseExpr()
+		p.skipSpace()
+		if p.hasPrefix("...") {
+			p.off += 3
+			p.skipSpace()
+		}
+		if p.peek() != ',' {
+			break
+		}
+		p.off++
+	}
+	p.expect(')')
+	p.nest--
+}
+
+// parseFor parses the remainder of a for expression, after the opening
+// bracket, up to and including the closing bracket.
+func (p *parser) parseFor(closing byte) {
+	p.off += len("for")
+	p.skipSpace()
+	p.ident()
+	p.skipSpace()
+	if p.peek() == ',' {
+		p.off++
+		p.skipSpace()
+		p.ident()
+		p.skipSpace()
+	}
+	if !p.hasPrefix("in") {
+		p.errf(p.off, "expected \"in\", found %s", p.found())
+	}
+	p.off += len("in")
+	p.skipSpace()
+	p.parseExpr()
+	p.skipSpace()
+	p.expect(':')
+	p.skipSpace()
+	p.parseExpr()
+	p.skipSpace()
+	if closing == '}' {
+		if !p.hasPrefix("=>") {
+			p.errf(p.off, "expected \"=>\", found %s", p.found())
+		}
+		p.off += 2
+		p.skipSpace()
+		p.parseExpr()
+		p.skipSpace()
+		if p.hasPrefix("...") {
+			p.off += 3
+			p.skipSpace()
+		}
+	}
+	if p.isKeyword("if") {
+		p.off += len("if")
+		p.skipSpace()
+		p.parseExpr()
+		p.skipSpace()
+	}
+	p.expect(closing)
+}
+
+// isKeyword reports whether the input starts with the given keyword.
+func (p *parser) isKeyword(kw string) bool {
+	if !p.hasPrefix(kw) {
+		return false
+	}
+	r, _ := utf8.DecodeRune(p.src[p.off+len(kw):])
+	return p.off+len(kw) == len(p.src) || !isIdentChar(r)
+}
+
+func (p *parser) parseTuple() value {
+	start := p.off
+	p.off++
+	p.nest++
+	defer func() { p.nest-- }()
+	p.skipSpace()
+	if p.isKeyword("for") {
+		p.parseFor(']')
+		return value{start: start, end: p.off}
+	}
+	list := &ast.ListLit{Lbrack: p.pos(start)}
+	for {
+		p.skipSpace()
+		if p.peek() == ']' {
+			break
+		}
+		list.Elts = append(list.Elts, p.value(p.parseExpr()))
+		p.skipSpace()
+		if p.peek() != ',' {
+			break
+		}
+		p.off++
+	}
+	p.expect(']')
+	list.Rbrack = p.pos(p.off - 1)
+	return value{expr: list, start: start, end: p.off}
+}
+
+func (p *parser) parseObject() value {
+	start := p.off
+	p.off++
+	p.nest++
+	defer func() { p.nest-- }()
+	p.skipSpace()
+	if p.isKeyword("for") {
+		p.parseFor('}')
+		return value{start: start, end: p.off}
+	}
+	obj := &ast.StructLit{Lbrace: p.pos(start)}
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			break
+		}
+		kstart := p.off
+		var key string
+		if isIdentStart(rune(p.peek())) {
+			key = p.ident()
+		} else {
+			k := p.parseExpr()
+			if lit, ok := k.expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				key, _ = strconv.Unquote(lit.Value)
+			} else {
+				key = "${" + string(p.src[k.start:k.end]) + "}"
+			}
+		}
+		p.skipSpace()
+		if c := p.peek(); c != '=' && c != ':' {
+			p.errf(p.off, "expected '=' or ':', found %s", p.found())
+		}
+		p.off++
+		p.skipSpace()
+		f := &ast.Field{Label: label(key, p.pos(kstart)), Value: p.value(p.parseExpr())}
+		if len(obj.Elts) > 0 && p.file.Line(p.pos(kstart)) != p.file.Line(obj.Lbrace) {
+			ast.SetRelPos(f, token.Newline)
+		}
+		obj.Elts = append(obj.Elts, f)
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.off++
+		}
+	}
+	p.expect('}')
+	obj.Rbrace = p.pos(p.off - 1)
+	return value{expr: obj, start: start, end: p.off}
+}
+
+// An object holds the fields of a struct literal that is built from
+// attributes and blocks.
+type object struct {
+	lit     *ast.StructLit
+	entries map[string]*entry
+}
+
+type entry struct {
+	field *ast.Field
+
+	// attr reports whether the field was defined by an attribute.
+	attr bool
+
+	// obj is the object holding the nested labels of blocks, if the field is
+	// a block type or a label that is not the last.
+	obj *object
+
+	// list holds the bodies of repeated blocks.
+	list *ast.ListLit
+}
+
+func newObject(pos token.Pos) *object {
+	return &object{
+		lit:     &ast.StructLit{Lbrace: pos},
+		entries: map[string]*entry{},
+	}
+}
+
+func (o *object) add(p *parser, name string, e *entry) {
+	o.entries[name] = e
+	switch {
+	case len(o.lit.Elts) == 0:
+	case p.section:
+		ast.SetRelPos(e.field, token.NewSection)
+		// The blank line precedes the doc comment, if any.
+		for _, cg := range ast.Comments(e.field) {
+			if cg.Doc {
+				ast.SetRelPos(cg, token.NewSection)
+			}
+		}
+	default:
+		ast.SetRelPos(e.field, token.Newline)
+	}
+	p.section = false
+	o.lit.Elts = append(o.lit.Elts, e.field)
+}
+
+func (o *object) addAttr(p *parser, off int, name string, f *ast.Field) {
+	if o.entries[name] != nil {
+		p.errf(off, "%q is already defined", name)
+	}
+	o.add(p, name, &entry{field: f, attr: true})
+}
+
+func (o *object) addBlock(p *parser, off int, typ string, labels []string, positions []token.Pos, body *ast.StructLit, comments []string) {
+	names := append([]string{typ}, labels...)
+	positions = append([]token.Pos{p.pos(off)}, positions...)
+	last := len(names) - 1
+	for i, name := range names[:last] {
+		e := o.entries[name]
+		switch {
+		case e == nil:
+			// Omit the position of the brace, so that the formatter can
+			// combine labels on a single line.
+			e = &entry{obj: newObject(token.NoPos)}
+			e.field = &ast.Field{Label: label(name, positions[i]), Value: e.obj.lit}
+			addDoc(e.field, comments)
+			comments = nil
+			o.add(p, name, e)
+		case e.obj == nil:
+			p.errf(off, "%q is already defined", strings.Join(names[:i+1], "."))
+		}
+		o = e.obj
+	}
+
+	name := names[last]
+	e := o.entries[name]
+	switch {
+	case e == nil:
+		e = &entry{field: &ast.Field{Label: label(name, positions[last]), Value: body}}
+		addDoc(e.field, comments)
+		o.add(p, name, e)
+		return
+	case e.attr || e.obj != nil:
+		p.errf(off, "%q is already defined", strings.Join(names, "."))
+	}
+	if e.list == nil {
+		first := e.field.Value
+		e.list = &ast.ListLit{Lbrack: first.Pos(), Elts: []ast.Expr{first}}
+		ast.SetRelPos(first, token.Newline)
+		e.field.Value = e.list
+	}
+	ast.SetRelPos(body, token.Newline)
+	addDoc(body, comments)
+	p.section = false
+	e.list.Elts = append(e.list.Elts, body)
+	e.list.Rbrack = body.Rbrace.WithRel(token.Newline)
+}
+
+func addDoc(n ast.Node, comments []string) {
+	if len(comments) == 0 {
+		return
+	}
+	cg := &ast.CommentGroup{Doc: true}
+	for _, c := range comments {
+		cg.List = append(cg.List, comment(c))
+	}
+	ast.AddComment(n, cg)
+}
+
+func comment(text string) *ast.Comment {
+	if text == "" {
+		return &ast.Comment{Text: "//"}
+	}
+	return &ast.Comment{Text: "// " + text}
+}
+
+func label(name string, pos token.Pos) ast.Label {
+	if ast.IsValidIdent(name) &&
+		!strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") {
+		return &ast.Ident{NamePos: pos, Name: name}
+	}
+	return newString(pos, name)
+}
+
+func newString(pos token.Pos, s string) *ast.BasicLit {
+	lit := ast.NewString(s)
+	lit.ValuePos = pos
+	return lit
+}
@@ -0,0 +1,361 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ini converts INI files to CUE.
+//
+// Keys before the first section map to top-level fields, and each section maps
+// to a field holding the keys of the section. A section with a quoted
+// subsection, as in Git configuration files, maps to a nested field:
+//
+//	[remote "origin"]
+//	url = https://example.com/repo.git
+//
+// maps to
+//
+//	remote: origin: url: "https://example.com/repo.git"
+//
+// Keys are separated from their values by "=" or ":". Values are strings,
+// except for unquoted values that are the literals true or false, or that are
+// decimal integers or floating point numbers, which map to the corresponding
+// CUE values. A value may be enclosed in single or double quotes to preserve
+// surrounding white space or to force it to be a string; double quoted values
+// may contain the escape sequences \", \\, \n and \t. A key without a value
+// maps to true. Indented lines following a key continue its value, with the
+// lines joined by newlines.
+//
+// Lines starting with ";" or "#" are comments; comments preceding a key or
+// section are retained as doc comments. A ";" or "#" preceded by white space
+// starts a comment in an unquoted value.
+//
+// Sections that occur more than once are merged, but a key may occur only
+// once in a section.
+package ini
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+)
+
+// A Decoder reads an INI file and converts it to CUE.
+type Decoder struct {
+	filename string
+	r        io.Reader
+	done     bool
+}
+
+// NewDecoder returns a decoder that reads an INI file from r. The filename
+// is used for position information.
+func NewDecoder(filename string, r io.Reader) *Decoder {
+	return &Decoder{filename: filename, r: r}
+}
+
+// Decode parses the INI file and returns it as a CUE struct literal. An INI
+// file holds a single value, so any subsequent call returns io.EOF.
+func (d *Decoder) Decode() (ast.Expr, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	d.done = true
+	src, err := io.ReadAll(d.r)
+	if err != nil {
+		return nil, err
+	}
+	f := token.NewFile(d.filename, -1, len(src))
+	f.SetLinesForContent(src)
+	return parse(f, src)
+}
+
+type parser struct {
+	file     *token.File
+	root     *ast.StructLit
+	sections map[string]*ast.StructLit
+	doc      []string
+
+	// last is the field of the last key, which may be continued.
+	last    *ast.Field
+	lastVal string
+}
+
+func parse(f *token.File, src []byte) (ast.Expr, error) {
+	p := &parser{
+		file:     f,
+		root:     ast.NewStruct(),
+		sections: map[string]*ast.StructLit{},
+	}
+	cur := p.root
+
+	off := 0
+	for _, line := range bytes.SplitAfter(src, []byte("\n")) {
+		pos := off
+		off += len(line)
+		s := strings.TrimRight(string(line), "\r\n")
+		trimmed := strings.TrimSpace(s)
+
+		switch {
+		case trimmed == "":
+			p.last = nil
+			p.doc = nil
+			continue
+
+		case trimmed[0] == ';' || trimmed[0] == '#':
+			p.doc = append(p.doc, strings.TrimSpace(trimmed[1:]))
+			continue
+
+		case p.last != nil && (s[0] == ' ' || s[0] == '\t'):
+			// Continuation of the previous value.
+			p.lastVal += "\n" + stripComment(trimmed)
+			x := ast.NewString(p.lastVal)
+			ast.SetPos(x, p.last.Value.Pos())
+			p.last.Value = x
+			continue
+		}
+
+		start := p.file.Pos(pos+len(s)-len(strings.TrimLeft(s, " \t")), token.NoRelPos)
+		if trimmed[0] == '[' {
+			sec, err := p.section(start, trimmed)
+			if err != nil {
+				return nil, err
+			}
+			cur = sec
+			p.last = nil
+			continue
+		}
+		if err := p.key(cur, start, trimmed); err != nil {
+			return nil, err
+		}
+	}
+	return p.root, nil
+}
+
+// section parses the section header line and returns the section it
+// declares.
+func (p *parser) section(pos token.Pos, line string) (*ast.StructLit, error) {
+	end := strings.IndexByte(line, ']')
+	if end < 0 {
+		return nil, errors.Newf(pos, "unterminated section header")
+	}
+	if rest := strings.TrimSpace(line[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+		return nil, errors.Newf(pos, "unexpected text after section header")
+	}
+	header := strings.TrimSpace(line[1:end])
+
+	labels := []string{header}
+	if i := strings.IndexByte(header, '"'); i >= 0 {
+		sub, err := strconv.Unquote(strings.TrimSpace(header[i:]))
+		if err != nil {
+			return nil, errors.Newf(pos, "invalid subsection name %s", header[i:])
+		}
+		labels = []string{strings.TrimSpace(header[:i]), sub}
+	}
+	if labels[0] == "" {
+		return nil, errors.Newf(pos, "empty section name")
+	}
+
+	key := strings.Join(labels, "\x00")
+	if sec, ok := p.sections[key]; ok {
+		p.doc = nil
+		return sec, nil
+	}
+	parent := p.root
+	for i, name := range labels {
+		f := lookup(parent, name)
+		if f == nil {
+			f = &ast.Field{Label: label(name), Value: ast.NewStruct()}
+			ast.SetPos(f.Label, pos)
+			parent.Elts = append(parent.Elts, f)
+		}
+		s, ok := f.Value.(*ast.StructLit)
+		if !ok {
+			return nil, errors.Newf(pos, "section %q conflicts with key of the same name", name)
+		}
+		if i == len(labels)-1 {
+			p.addDoc(f)
+		}
+		parent = s
+	}
+	p.sections[key] = parent
+	return parent, nil
+}
+
+// key parses the key-value line and adds it to sec.
+func (p *parser) key(sec *ast.StructLit, pos token.Pos, line string) error {
+	name, value, hasValue := line, "", false
+	valOff := 0
+	if i := strings.IndexAny(line, "=:"); i >= 0 {
+		name, value, hasValue = line[:i], line[i+1:], true
+		valOff = i + 1 + len(value) - len(strings.TrimLeft(value, " \t"))
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.Newf(pos, "missing key")
+	}
+	if lookup(sec, name) != nil {
+		return errors.Newf(pos, "duplicate key %q", name)
+	}
+
+	f := &ast.Field{Label: label(name)}
+	ast.SetPos(f.Label, pos)
+	p.last = nil
+	if !hasValue {
+		f.Value = ast.NewBool(true)
+	} else {
+		x, s, quoted, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return errors.Newf(pos, "%v", err)
+		}
+		ast.SetPos(x, p.file.Pos(pos.Offset()+valOff, token.NoRelPos))
+		f.Value = x
+		if !quoted {
+			p.last, p.lastVal = f, s
+		}
+	}
+	p.addDoc(f)
+	sec.Elts = append(sec.Elts, f)
+	return nil
+}
+
+// parseValue parses a value and returns its CUE expression, its string
+// value and whether it was quoted.
+func parseValue(s string) (x ast.Expr, str string, quoted bool, err error) {
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		q := s[0]
+		end := -1
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' && q == '"' {
+				i++
+				continue
+			}
+			if s[i] == q {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil, "", false, errors.New("unterminated quoted value")
+		}
+		if rest := strings.TrimSpace(s[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+			return nil, "", false, errors.New("unexpected text after quoted value")
+		}
+		str = s[1:end]
+		if q == '"' {
+			str, err = unescape(str)
+			if err != nil {
+				return nil, "", false, err
+			}
+		}
+		return ast.NewString(str), str, true, nil
+	}
+
+	s = stripComment(s)
+	switch s {
+	case "true", "false":
+		return ast.NewBool(s == "true"), s, false, nil
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil && validNumber(s) {
+		return ast.NewLit(token.INT, s), s, false, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && validNumber(s) {
+		return ast.NewLit(token.FLOAT, s), s, false, nil
+	}
+	return ast.NewString(s), s, false, nil
+}
+
+// validNumber reports whether s, which is accepted by strconv, is a number
+// in a form that is also a valid CUE literal, excluding forms like "+1",
+// "Inf" or "01".
+func validNumber(s string) bool {
+	t := strings.TrimPrefix(s, "-")
+	if t == "" || t[0] < '0' || '9' < t[0] {
+		return false
+	}
+	if len(t) > 1 && t[0] == '0' && t[1] >= '0' && t[1] <= '9' {
+		return false
+	}
+	return !strings.ContainsAny(t, "_xXoObBpP") && t[len(t)-1] != '.'
+}
+
+// stripComment removes a trailing comment from the unquoted value s.
+func stripComment(s string) string {
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", errors.New("invalid escape sequence")
+		}
+		switch s[i] {
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			return "", errors.New("invalid escape sequence")
+		}
+	}
+	return b.String(), nil
+}
+
+func (p *parser) addDoc(n ast.Node) {
+	if len(p.doc) > 0 {
+		ast.SetComments(n, []*ast.CommentGroup{
+			internal.NewComment(true, strings.Join(p.doc, "\n")),
+		})
+	}
+	p.doc = nil
+}
+
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !internal.IsDefOrHidden(name) {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+// lookup returns the field with the given name in s, or nil if it does not
+// exist.
+func lookup(s *ast.StructLit, name string) *ast.Field {
+	for _, e := range s.Elts {
+		if f, ok := e.(*ast.Field); ok {
+			if l, _, _ := ast.LabelName(f.Label); l == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
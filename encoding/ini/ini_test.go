@@ -0,0 +1,185 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ini
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/internal"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "empty",
+		in:   "",
+		out:  "",
+	}, {
+		name: "sections",
+		in: `
+; Global settings.
+name = app
+debug
+
+[server]
+# The port to listen on.
+port = 8080
+host: 0.0.0.0 ; all interfaces
+ratio = 0.75
+enabled = true
+quoted = "  8080 # not a comment  "
+raw = 'C:\path'
+escaped = "a\tb\"c"
+Content-Type = text/plain
+_hidden = 1
+
+[remote "origin"]
+url = https://example.com/repo.git
+
+[server]
+timeout = 30s
+`,
+		out: `
+// Global settings.
+name:  "app"
+debug: true
+server: {
+	// The port to listen on.
+	port:           8080
+	host:           "0.0.0.0"
+	ratio:          0.75
+	enabled:        true
+	quoted:         "  8080 # not a comment  "
+	raw:            "C:\\path"
+	escaped:        "a\tb\"c"
+	"Content-Type": "text/plain"
+	"_hidden":      1
+	timeout:        "30s"
+}
+remote: {
+	origin: {
+		url: "https://example.com/repo.git"
+	}
+}
+`,
+	}, {
+		name: "numbers",
+		in: `
+a = -1
+b = 1e3
+c = 010
+d = +1
+e = 1_000
+f = 0x10
+g = Inf
+h = 1.
+`,
+		out: `
+a: -1
+b: 1e3
+c: "010"
+d: "+1"
+e: "1_000"
+f: "0x10"
+g: "Inf"
+h: "1."
+`,
+	}, {
+		name: "continuation",
+		in: `
+[message]
+text = first line
+  second line
+	third line
+next = 1
+`,
+		out: `
+message: {
+	text: "first line\nsecond line\nthird line"
+	next: 1
+}
+`,
+	}, {
+		name: "duplicate key",
+		in:   "[a]\nb = 1\nb = 2\n",
+		err:  `test.ini:3:1: duplicate key "b"`,
+	}, {
+		name: "section conflicts with key",
+		in:   "a = 1\n[a]\n",
+		err:  `test.ini:2:1: section "a" conflicts with key of the same name`,
+	}, {
+		name: "unterminated section",
+		in:   "  [a\n",
+		err:  `test.ini:1:3: unterminated section header`,
+	}, {
+		name: "text after section",
+		in:   "[a] b\n",
+		err:  `test.ini:1:1: unexpected text after section header`,
+	}, {
+		name: "empty section name",
+		in:   "[]\n",
+		err:  `test.ini:1:1: empty section name`,
+	}, {
+		name: "missing key",
+		in:   "= 1\n",
+		err:  `test.ini:1:1: missing key`,
+	}, {
+		name: "unterminated quote",
+		in:   "a = \"abc\n",
+		err:  `test.ini:1:1: unterminated quoted value`,
+	}, {
+		name: "invalid escape",
+		in:   `a = "\x"`,
+		err:  `test.ini:1:1: invalid escape sequence`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDecoder("test.ini", strings.NewReader(tc.in))
+			expr, err := d.Decode()
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				got := fmt.Sprintf("%v: %v", err.(errors.Error).Position(), err)
+				if got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(internal.ToFile(expr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSpace(string(b)), strings.TrimSpace(tc.out); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := d.Decode(); err != io.EOF {
+				t.Errorf("second Decode: got %v; want io.EOF", err)
+			}
+		})
+	}
+}
@@ -230,7 +230,7 @@ func patchExpr(n ast.Node) {
 
 				// TODO(legacy): remove checking for '_' prefix once hidden
 				// fields are removed.
-				if !ast.IsValidIdent(u) || strings.HasPrefix(u, "_") {
+				if !ast.IsValidIdent(u) || strings.HasPrefix(u, "_") || strings.HasPrefix(u, "#") {
 					break // keep string
 				}
 
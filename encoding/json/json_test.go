@@ -113,6 +113,10 @@ func TestExtract(t *testing.T) {
 		name: "legacy: hidden fields",
 		in:   `{"_legacy": 1}`,
 		out:  `{"_legacy": 1}`,
+	}, {
+		name: "definition-like keys",
+		in:   `{"#def": 1}`,
+		out:  `{"#def": 1}`,
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
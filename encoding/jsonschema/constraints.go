@@ -45,6 +45,13 @@ type constraint struct {
 	fn    constraintFunc
 }
 
+// Drafts 2019-09 and 2020-12 are numbered after draft-07 for the purpose of
+// comparing versions.
+const (
+	draft2019_09 = 8
+	draft2020_12 = 9
+)
+
 // A constraintFunc converts a given JSON Schema constraint (specified in n)
 // to a CUE constraint recorded in state.
 type constraintFunc func(n cue.Value, s *state)
@@ -61,6 +68,18 @@ func p1(name string, f constraintFunc) *constraint {
 	return &constraint{key: name, phase: 1, fn: f}
 }
 
+func p0d(name string, draft int, f constraintFunc) *constraint {
+	return &constraint{key: name, draft: draft, fn: f}
+}
+
+func p2d(name string, draft int, f constraintFunc) *constraint {
+	return &constraint{key: name, phase: 2, draft: draft, fn: f}
+}
+
+func p3d(name string, draft int, f constraintFunc) *constraint {
+	return &constraint{key: name, phase: 3, draft: draft, fn: f}
+}
+
 func p2(name string, f constraintFunc) *constraint {
 	return &constraint{key: name, phase: 2, fn: f}
 }
@@ -119,7 +138,6 @@ var constraints = []*constraint{
 
 	p0("$schema", func(n cue.Value, s *state) {
 		// Identifies this as a JSON schema and specifies its version.
-		// TODO: extract version.
 		s.jsonschema, _ = s.strValue(n)
 	}),
 
@@ -220,6 +238,29 @@ var constraints = []*constraint{
 		s.nullable = null
 	}),
 
+	p1("x-kubernetes-int-or-string", func(n cue.Value, s *state) {
+		if s.cfg.Dialect != Kubernetes {
+			if s.cfg.Strict {
+				s.warnf(n.Pos(), "unsupported constraint %q", "x-kubernetes-int-or-string")
+			}
+			return
+		}
+		if !s.boolValue(n) {
+			return
+		}
+		// Structural schemas commonly spell out the alternatives with anyOf,
+		// in which case there is no need to add them again.
+		for _, key := range []string{"anyOf", "oneOf"} {
+			if s.pos.LookupPath(cue.MakePath(cue.Str(key))).Exists() {
+				return
+			}
+		}
+		s.allowedTypes &= cue.IntKind | cue.StringKind
+		s.setTypeUsed(n, stringType)
+		s.setTypeUsed(n, numType)
+		s.add(n, numType, ast.NewIdent("int"))
+	}),
+
 	p1d("const", 6, func(n cue.Value, s *state) {
 		s.all.add(n, s.value(n))
 	}),
@@ -263,25 +304,18 @@ var constraints = []*constraint{
 
 	p1("$defs", addDefinitions),
 	p1("definitions", addDefinitions),
-	p1("$ref", func(n cue.Value, s *state) {
-		s.usedTypes = allTypes
+	p1("$ref", addRef),
 
-		u := s.resolveURI(n)
-
-		if u.Fragment != "" && !path.IsAbs(u.Fragment) {
-			s.addErr(errors.Newf(n.Pos(), "anchors (%s) not supported", u.Fragment))
-			// TODO: support anchors
-			return
-		}
-
-		expr := s.makeCUERef(n, u)
+	// Anchors are resolved by the references that use them. See
+	// decoder.anchors.
+	p0d("$anchor", draft2019_09, func(n cue.Value, s *state) {}),
+	p0d("$dynamicAnchor", draft2020_12, func(n cue.Value, s *state) {}),
 
-		if expr == nil {
-			expr = &ast.BadExpr{From: n.Pos()}
-		}
-
-		s.all.add(n, expr)
-	}),
+	// A dynamic reference resolves to the outermost schema in the dynamic
+	// scope that declares the anchor. As the scope is limited to the schema
+	// being converted, in which anchors are unique, this is the schema that
+	// declares the anchor, making it equivalent to a static reference.
+	p1d("$dynamicRef", draft2020_12, addRef),
 
 	// Combinators
 
@@ -321,13 +355,23 @@ var constraints = []*constraint{
 	p2("anyOf", func(n cue.Value, s *state) {
 		var types cue.Kind
 		var a []ast.Expr
+		hasSome := false
 		for _, v := range s.listItems("anyOf", n, false) {
 			x, sub := s.schemaState(v, s.allowedTypes, nil, true)
 			types |= sub.allowedTypes
+			if sub.hasConstraints() {
+				hasSome = true
+			}
 			a = append(a, x)
 		}
 		s.allowedTypes &= types
 		if len(a) > 0 {
+			// As with oneOf, the alternatives fully describe the allowed
+			// types, so they should not be added again as a separate
+			// disjunction.
+			if hasSome {
+				s.usedTypes = allTypes
+			}
 			s.all.add(n, ast.NewBinExpr(token.OR, a...))
 		}
 	}),
@@ -361,6 +405,29 @@ var constraints = []*constraint{
 
 	// String constraints
 
+	// format is processed after type to replace the int constraint added
+	// for type "integer".
+	p2("format", func(n cue.Value, s *state) {
+		// Formats are annotations by default and unknown formats must be
+		// ignored. Only the OpenAPI integer formats map directly to CUE.
+		str, ok := s.strValue(n)
+		if !ok || !s.cfg.Dialect.isOpenAPI() {
+			return
+		}
+		switch str {
+		case "int32", "int64":
+			c := &s.types[numType]
+			a := c.constraints[:0]
+			for _, x := range c.constraints {
+				if id, ok := x.(*ast.Ident); !ok || id.Name != "int" {
+					a = append(a, x)
+				}
+			}
+			c.constraints = a
+			s.add(n, numType, ast.NewIdent(str))
+		}
+	}),
+
 	p1("pattern", func(n cue.Value, s *state) {
 		str, _ := n.String()
 		if _, err := regexp.Compile(str); err != nil {
@@ -570,6 +637,29 @@ var constraints = []*constraint{
 		*/
 	}),
 
+	p2d("dependentSchemas", draft2019_09, func(n cue.Value, s *state) {
+		if n.Kind() != cue.StructKind {
+			s.errf(n, `value of "dependentSchemas" must be an object, found %v`, n.Kind())
+			return
+		}
+		s.usedTypes |= cue.StructKind
+		s.processMap(n, func(key string, n cue.Value) {
+			// {key?: _|_, ...} | {key: _, ...} & schema
+			absent := ast.NewStruct(&ast.Field{
+				Label:    ast.NewString(key),
+				Optional: token.Blank.Pos(),
+				Value:    &ast.BottomLit{},
+			}, &ast.Ellipsis{})
+			present := ast.NewStruct(&ast.Field{
+				Label: ast.NewString(key),
+				Value: ast.NewIdent("_"),
+			}, &ast.Ellipsis{})
+			x := ast.NewBinExpr(token.OR, absent,
+				ast.NewBinExpr(token.AND, present, s.schema(n)))
+			s.add(n, objectType, &ast.ParenExpr{X: x})
+		})
+	}),
+
 	p2("patternProperties", func(n cue.Value, s *state) {
 		s.usedTypes |= cue.StructKind
 		if n.Kind() != cue.StructKind {
@@ -592,33 +682,17 @@ var constraints = []*constraint{
 		})
 	}),
 
-	p3("additionalProperties", func(n cue.Value, s *state) {
-		switch n.Kind() {
-		case cue.BoolKind:
-			s.closeStruct = !s.boolValue(n)
-
-		case cue.StructKind:
-			s.usedTypes |= cue.StructKind
-			s.closeStruct = true
-			obj := s.object(n)
-			if len(obj.Elts) == 0 {
-				obj.Elts = append(obj.Elts, &ast.Field{
-					Label: ast.NewList(ast.NewIdent("string")),
-					Value: s.schema(n),
-				})
-				return
-			}
-			// [!~(properties|patternProperties)]: schema
-			existing := append(s.patterns, excludeFields(obj.Elts))
-			f := internal.EmbedStruct(ast.NewStruct(&ast.Field{
-				Label: ast.NewList(ast.NewBinExpr(token.AND, existing...)),
-				Value: s.schema(n),
-			}))
-			obj.Elts = append(obj.Elts, f)
+	p3("additionalProperties", additionalProperties("additionalProperties")),
 
-		default:
-			s.errf(n, `value of "additionalProperties" must be an object or boolean`)
+	// Properties that are evaluated by applicators, such as allOf or $ref,
+	// are not visible in the struct of the current schema. The constraint is
+	// therefore only applied in the absence of applicators, erring on the
+	// side of accepting too much.
+	p3d("unevaluatedProperties", draft2019_09, func(n cue.Value, s *state) {
+		if hasApplicator(s.pos) || s.has("additionalProperties") {
+			return
 		}
+		additionalProperties("unevaluatedProperties")(n, s)
 	}),
 
 	// Array constraints.
@@ -627,11 +701,18 @@ var constraints = []*constraint{
 		s.usedTypes |= cue.ListKind
 		switch n.Kind() {
 		case cue.StructKind:
+			if s.has("prefixItems") {
+				// The schema applies to the items following the prefix.
+				return
+			}
 			elem := s.schema(n)
 			ast.SetRelPos(elem, token.NoRelPos)
 			s.add(n, arrayType, ast.NewList(&ast.Ellipsis{Type: elem}))
 
 		case cue.ListKind:
+			if s.schemaVersion() >= draft2020_12 && s.cfg.Strict {
+				s.warnf(n.Pos(), `value of "items" must be an object in draft 2020-12; use "prefixItems" for tuples`)
+			}
 			var a []ast.Expr
 			for _, n := range s.listItems("items", n, true) {
 				v := s.schema(n) // TODO: label with number literal.
@@ -641,8 +722,68 @@ var constraints = []*constraint{
 			s.list = ast.NewList(a...)
 			s.add(n, arrayType, s.list)
 
+		case cue.BoolKind:
+			if !s.boolValue(n) && !s.has("prefixItems") {
+				s.add(n, arrayType, ast.NewList())
+			}
+
+		default:
+			s.errf(n, `value of "items" must be an object, array, or boolean`)
+		}
+	}),
+
+	p1d("prefixItems", draft2020_12, func(n cue.Value, s *state) {
+		s.usedTypes |= cue.ListKind
+		var a []ast.Expr
+		for _, n := range s.listItems("prefixItems", n, true) {
+			v := s.schema(n)
+			ast.SetRelPos(v, token.NoRelPos)
+			a = append(a, v)
+		}
+
+		// Items following the prefix are allowed unless restricted by
+		// "items" or, in the absence of applicators, "unevaluatedItems".
+		rest := s.pos.LookupPath(cue.MakePath(cue.Str("items")))
+		if !rest.Exists() && !hasApplicator(s.pos) {
+			rest = s.pos.LookupPath(cue.MakePath(cue.Str("unevaluatedItems")))
+		}
+		switch rest.Kind() {
+		case cue.StructKind:
+			elem := s.schema(rest)
+			ast.SetRelPos(elem, token.NoRelPos)
+			a = append(a, &ast.Ellipsis{Type: elem})
+		case cue.BoolKind:
+			if s.boolValue(rest) {
+				a = append(a, &ast.Ellipsis{})
+			}
+		default:
+			a = append(a, &ast.Ellipsis{})
+		}
+		s.list = ast.NewList(a...)
+		s.add(n, arrayType, s.list)
+	}),
+
+	// As with unevaluatedProperties, unevaluatedItems is only applied in the
+	// absence of applicators. Restrictions on items following a prefix are
+	// handled by prefixItems.
+	p1d("unevaluatedItems", draft2019_09, func(n cue.Value, s *state) {
+		if hasApplicator(s.pos) || s.has("items") || s.has("prefixItems") {
+			return
+		}
+		s.usedTypes |= cue.ListKind
+		switch n.Kind() {
+		case cue.StructKind:
+			elem := s.schema(n)
+			ast.SetRelPos(elem, token.NoRelPos)
+			s.add(n, arrayType, ast.NewList(&ast.Ellipsis{Type: elem}))
+
+		case cue.BoolKind:
+			if !s.boolValue(n) {
+				s.add(n, arrayType, ast.NewList())
+			}
+
 		default:
-			s.errf(n, `value of "items" must be an object or array`)
+			s.errf(n, `value of "unevaluatedItems" must be an object or boolean`)
 		}
 	}),
 
@@ -709,6 +850,86 @@ var constraints = []*constraint{
 	}),
 }
 
+// addRef converts a $ref or $dynamicRef to a reference to the CUE value for
+// the referred schema.
+func addRef(n cue.Value, s *state) {
+	s.usedTypes = allTypes
+
+	u := s.resolveURI(n)
+	if u == nil {
+		return
+	}
+
+	if u.Fragment != "" && !path.IsAbs(u.Fragment) {
+		ptr, ok := s.anchors[u.Fragment]
+		if !ok {
+			s.addErr(errors.Newf(n.Pos(), "unknown anchor %q", u.Fragment))
+			return
+		}
+		u.Fragment = ptr
+	}
+
+	expr := s.makeCUERef(n, u)
+
+	if expr == nil {
+		expr = &ast.BadExpr{From: n.Pos()}
+	}
+
+	s.all.add(n, expr)
+}
+
+// additionalProperties returns the constraint for the schema of properties
+// not matched by "properties" or "patternProperties". The key is used in
+// error messages.
+func additionalProperties(key string) constraintFunc {
+	return func(n cue.Value, s *state) {
+		switch n.Kind() {
+		case cue.BoolKind:
+			s.closeStruct = !s.boolValue(n)
+
+		case cue.StructKind:
+			s.usedTypes |= cue.StructKind
+			s.closeStruct = true
+			obj := s.object(n)
+			if len(obj.Elts) == 0 {
+				obj.Elts = append(obj.Elts, &ast.Field{
+					Label: ast.NewList(ast.NewIdent("string")),
+					Value: s.schema(n),
+				})
+				return
+			}
+			// [!~(properties|patternProperties)]: schema
+			existing := append(s.patterns, excludeFields(obj.Elts))
+			f := internal.EmbedStruct(ast.NewStruct(&ast.Field{
+				Label: ast.NewList(ast.NewBinExpr(token.AND, existing...)),
+				Value: s.schema(n),
+			}))
+			obj.Elts = append(obj.Elts, f)
+
+		default:
+			s.errf(n, `value of %q must be an object or boolean`, key)
+		}
+	}
+}
+
+// applicators lists the keywords that apply subschemas to the same instance
+// location. Their evaluation results are not visible to the schema in which
+// they appear.
+var applicators = []string{
+	"allOf", "anyOf", "oneOf", "if", "then", "else",
+	"$ref", "$dynamicRef", "dependentSchemas",
+}
+
+// hasApplicator reports whether the schema n contains an applicator.
+func hasApplicator(n cue.Value) bool {
+	for _, key := range applicators {
+		if n.LookupPath(cue.MakePath(cue.Str(key))).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
 func clearPos(e ast.Expr) ast.Expr {
 	ast.SetRelPos(e, token.NoRelPos)
 	return e
@@ -43,6 +43,10 @@ type decoder struct {
 	cfg   *Config
 	errs  errors.Error
 	numID int // for creating unique numbers: increment on each use
+
+	// anchors maps the names of $anchor and $dynamicAnchor declarations to
+	// the JSON pointer of the schema declaring them.
+	anchors map[string]string
 }
 
 // addImport registers
@@ -69,6 +73,9 @@ func (d *decoder) decode(v cue.Value) *ast.File {
 
 	var a []ast.Decl
 
+	d.anchors = map[string]string{}
+	d.collectAnchors(v, "", true)
+
 	if d.cfg.Root == "" {
 		a = append(a, d.schema(nil, v)...)
 	} else {
@@ -362,6 +369,28 @@ type refs struct {
 	refs  []*ast.Ident
 }
 
+// has reports whether the schema of s defines the given keyword.
+func (s *state) has(key string) bool {
+	return s.pos.LookupPath(cue.MakePath(cue.Str(key))).Exists()
+}
+
+// schemaURI returns the $schema URI in scope for s, or "" if none is
+// specified.
+func (s *state) schemaURI() string {
+	for ; s != nil; s = s.up {
+		if s.jsonschema != "" {
+			return s.jsonschema
+		}
+	}
+	return ""
+}
+
+// schemaVersion returns the draft number of the $schema in scope for s, or 0
+// if it is not specified or not known.
+func (s *state) schemaVersion() int {
+	return draftVersion(s.schemaURI())
+}
+
 func (s *state) object(n cue.Value) *ast.StructLit {
 	if s.obj == nil {
 		s.obj = &ast.StructLit{}
@@ -563,6 +592,10 @@ func (s *state) schemaState(n cue.Value, types cue.Kind, idRef []label, isLogica
 			// Convert each constraint into a either a value or a functor.
 			c := constraintMap[key]
 			if c == nil {
+				if s.cfg.Dialect.isOpenAPI() && strings.HasPrefix(key, "x-") {
+					// Specification extensions may be ignored.
+					return
+				}
 				if pass == 0 && s.cfg.Strict {
 					// TODO: value is not the correct position, albeit close. Fix this.
 					s.warnf(value.Pos(), "unsupported constraint %q", key)
@@ -572,6 +605,13 @@ func (s *state) schemaState(n cue.Value, types cue.Kind, idRef []label, isLogica
 			if c.phase == pass {
 				c.fn(value, state)
 			}
+			// Check the version on the last pass, when $schema is known.
+			if pass == 3 && s.cfg.Strict && c.draft > 0 {
+				if v := state.schemaVersion(); v > 0 && c.draft > v {
+					s.warnf(value.Pos(),
+						"constraint %q is not supported by %s", key, state.schemaURI())
+				}
+			}
 		})
 	}
 
@@ -61,12 +61,19 @@ func TestDecode(t *testing.T) {
 			cfg := &Config{ID: fullpath}
 
 			if bytes.Contains(a.Comment, []byte("openapi")) {
+				cfg.Dialect = OpenAPI
 				cfg.Root = "#/components/schemas/"
 				cfg.Map = func(p token.Pos, a []string) ([]ast.Label, error) {
 					// Just for testing: does not validate the path.
 					return []ast.Label{ast.NewIdent("#" + a[len(a)-1])}, nil
 				}
 			}
+			if bytes.Contains(a.Comment, []byte("kubernetes")) {
+				cfg.Dialect = Kubernetes
+			}
+			if bytes.Contains(a.Comment, []byte("strict")) {
+				cfg.Strict = true
+			}
 
 			r := &cue.Runtime{}
 			var in *cue.Instance
@@ -0,0 +1,588 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/json"
+	"cuelang.org/go/internal/value"
+)
+
+// Generate generates a JSON Schema document from the definitions in v.
+//
+// Each definition of v is converted to a schema in the definitions section
+// of the document, named $defs or definitions depending on cfg.Version.
+// References to definitions are converted to a $ref to the corresponding
+// schema. If cfg.RootPath is set, the value at that path is converted to the
+// root schema of the document.
+//
+// Constraints that cannot be represented in JSON Schema, such as arbitrary
+// expressions and most validators, are omitted, resulting in a schema that
+// accepts more than the CUE value. In strict mode they are reported as an
+// error instead.
+func Generate(v cue.Value, cfg *Config) (*ast.File, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	g := &generator{
+		cfg:   cfg,
+		root:  v,
+		names: map[string]string{},
+		defs:  map[string]ast.Expr{},
+	}
+
+	version := cfg.Version
+	if version == "" {
+		version = "https://json-schema.org/draft/2020-12/schema"
+	}
+	switch g.version = draftVersion(version); g.version {
+	case 7, draft2019_09, draft2020_12:
+	default:
+		return nil, errors.Newf(token.NoPos, "unsupported JSON Schema version %q", version)
+	}
+	g.defsKey = "$defs"
+	if g.version < draft2019_09 {
+		g.defsKey = "definitions"
+	}
+
+	doc := ast.NewStruct("$schema", ast.NewString(version))
+	if cfg.ID != "" {
+		g.set(doc, "$id", ast.NewString(cfg.ID))
+	}
+
+	iter, err := v.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		if sel := iter.Selector(); sel.IsDefinition() {
+			g.ref(cue.MakePath(sel))
+		}
+	}
+
+	if p := cfg.RootPath; len(p.Selectors()) > 0 {
+		x := v.LookupPath(p)
+		if !x.Exists() {
+			return nil, errors.Newf(token.NoPos, "no value at root path %s", p)
+		}
+		var root ast.Expr
+		if isDefinitionPath(p) {
+			root = g.ref(p)
+			// Prior to 2019-09, keywords next to $ref are ignored.
+			if root != nil && g.version < draft2019_09 {
+				root = ast.NewStruct("allOf", ast.NewList(root))
+			}
+		}
+		if root == nil {
+			root = g.schema(x)
+		}
+		doc.Elts = append(doc.Elts, root.(*ast.StructLit).Elts...)
+	}
+
+	if len(g.defs) > 0 {
+		var names []string
+		for name := range g.defs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		defs := ast.NewStruct()
+		for _, name := range names {
+			g.set(defs, name, g.defs[name])
+		}
+		g.set(doc, g.defsKey, defs)
+	}
+
+	if g.errs != nil {
+		return nil, g.errs
+	}
+	return &ast.File{Decls: doc.Elts}, nil
+}
+
+type generator struct {
+	cfg     *Config
+	version int
+	defsKey string
+	root    cue.Value
+	errs    errors.Error
+
+	// names maps the paths of definitions to their name in the definitions
+	// section, or "" if they are expanded in place.
+	names map[string]string
+	defs  map[string]ast.Expr
+
+	// expanding holds the definitions that are being expanded in place, for
+	// detecting cycles.
+	expanding []string
+}
+
+func (g *generator) set(s *ast.StructLit, key string, x ast.Expr) {
+	s.Elts = append(s.Elts, &ast.Field{Label: ast.NewString(key), Value: x})
+}
+
+// unsupported reports a CUE constraint that cannot be represented in JSON
+// Schema. It is an error in strict mode only.
+func (g *generator) unsupported(v cue.Value, what string) {
+	if g.cfg.Strict {
+		g.errs = errors.Append(g.errs, errors.Newf(v.Pos(),
+			"cannot represent %s in JSON Schema: %v", what, v))
+	}
+}
+
+// name returns the name of the definition at path p in the definitions
+// section, or "" if it must be expanded in place.
+func (g *generator) name(p cue.Path) string {
+	if g.cfg.NameFunc != nil {
+		return g.cfg.NameFunc(p)
+	}
+	var a []string
+	for _, sel := range p.Selectors() {
+		a = append(a, strings.TrimPrefix(sel.String(), "#"))
+	}
+	return strings.Join(a, ".")
+}
+
+// ref returns a reference to the schema for the definition at path p of the
+// root value and generates the schema if needed. It returns nil if the
+// definition must be expanded in place.
+func (g *generator) ref(p cue.Path) ast.Expr {
+	key := p.String()
+	name, ok := g.names[key]
+	if !ok {
+		name = g.name(p)
+		g.names[key] = name
+		if name != "" {
+			if _, dup := g.defs[name]; dup {
+				g.errs = errors.Append(g.errs, errors.Newf(token.NoPos,
+					"definitions %s and another definition map to the same name %q", p, name))
+			}
+			g.defs[name] = ast.NewStruct() // placeholder for recursive references
+			g.defs[name] = g.schema(g.root.LookupPath(p))
+		}
+	}
+	if name == "" {
+		return nil
+	}
+	return ast.NewStruct("$ref", ast.NewString("#/"+g.defsKey+"/"+name))
+}
+
+// reference returns a reference to the schema of the definition v refers to,
+// or nil if v is not such a reference.
+func (g *generator) reference(v cue.Value) ast.Expr {
+	root, p := v.ReferencePath()
+	if !isDefinitionPath(p) || !sameValue(root, g.root) {
+		return nil
+	}
+	if ref := g.ref(p); ref != nil {
+		return ref
+	}
+	key := p.String()
+	for _, x := range g.expanding {
+		if x == key {
+			g.errs = errors.Append(g.errs, errors.Newf(v.Pos(),
+				"cannot expand recursive definition %s", p))
+			return ast.NewStruct()
+		}
+	}
+	g.expanding = append(g.expanding, key)
+	defer func() { g.expanding = g.expanding[:len(g.expanding)-1] }()
+	return g.schema(g.root.LookupPath(p))
+}
+
+// schema converts v to a JSON Schema.
+func (g *generator) schema(v cue.Value) *ast.StructLit {
+	s := ast.NewStruct()
+
+	var doc []string
+	for _, cg := range v.Doc() {
+		doc = append(doc, strings.TrimSpace(cg.Text()))
+	}
+	if len(doc) > 0 {
+		g.set(s, "description", ast.NewString(strings.Join(doc, "\n\n")))
+	}
+
+	if ref := g.reference(v); ref != nil {
+		return g.merge(s, ref)
+	}
+
+	x := v
+	op, a := v.Expr()
+	hasDefault := op == cue.OrOp
+	if op == cue.NoOp && len(a) == 1 {
+		// The value without its default.
+		x = a[0]
+		op, a = x.Expr()
+		hasDefault = true
+	}
+	if op == cue.OrOp {
+		g.disjunction(s, a)
+	} else {
+		g.conjunction(s, x)
+	}
+
+	if d, ok := v.Default(); ok && hasDefault && isDefault(d) {
+		if x := g.value(d); x != nil {
+			g.set(s, "default", x)
+		}
+	}
+	return s
+}
+
+// merge adds the fields of the schema x to s.
+func (g *generator) merge(s *ast.StructLit, x ast.Expr) *ast.StructLit {
+	if st, ok := x.(*ast.StructLit); ok {
+		s.Elts = append(s.Elts, st.Elts...)
+	}
+	return s
+}
+
+// disjunction converts the disjuncts a to an enum for the concrete values and
+// an anyOf for the remaining schemas.
+func (g *generator) disjunction(s *ast.StructLit, a []cue.Value) {
+	var enum, schemas []ast.Expr
+	for _, d := range a {
+		if isScalar(d) {
+			if x := g.value(d); x != nil {
+				enum = append(enum, x)
+			}
+			continue
+		}
+		schemas = append(schemas, g.schema(d))
+	}
+	switch {
+	case len(schemas) == 0:
+		g.set(s, "enum", ast.NewList(enum...))
+	default:
+		if len(enum) > 0 {
+			schemas = append([]ast.Expr{ast.NewStruct("enum", ast.NewList(enum...))}, schemas...)
+		}
+		g.set(s, "anyOf", ast.NewList(schemas...))
+	}
+
+}
+
+// conjunction converts v, which is not a disjunction, by converting the type
+// of v and each of its conjuncts.
+func (g *generator) conjunction(s *ast.StructLit, v cue.Value) {
+	if isScalar(v) {
+		if x := g.value(v); x != nil {
+			g.set(s, "const", x)
+		}
+		return
+	}
+
+	k := v.IncompleteKind()
+	if t := jsonType(k); t != nil {
+		g.set(s, "type", t)
+	}
+
+	for _, c := range splitConjuncts(nil, v) {
+		g.constraint(s, c)
+	}
+
+	switch {
+	case k == cue.StructKind:
+		g.object(s, v)
+	case k == cue.ListKind:
+		g.array(s, v)
+	}
+}
+
+// constraint converts a single conjunct c.
+func (g *generator) constraint(s *ast.StructLit, c cue.Value) {
+	op, a := c.Expr()
+	switch op {
+	case cue.NoOp, cue.AndOp:
+		// Types and structural values are handled by the caller.
+
+	case cue.SelectorOp:
+		// A validator used without arguments, such as time.Time.
+		if _, ok := validators[validatorName(c)]; ok {
+			g.validator(s, c, []cue.Value{c})
+		}
+
+	case cue.LessThanOp, cue.LessThanEqualOp, cue.GreaterThanOp, cue.GreaterThanEqualOp:
+		if a[0].IncompleteKind()&cue.NumberKind == 0 {
+			g.unsupported(c, "bound")
+			return
+		}
+		x := g.value(a[0])
+		switch op {
+		case cue.LessThanOp:
+			g.set(s, "exclusiveMaximum", x)
+		case cue.LessThanEqualOp:
+			g.set(s, "maximum", x)
+		case cue.GreaterThanOp:
+			g.set(s, "exclusiveMinimum", x)
+		case cue.GreaterThanEqualOp:
+			g.set(s, "minimum", x)
+		}
+
+	case cue.NotEqualOp:
+		if x := g.value(a[0]); x != nil {
+			g.set(s, "not", ast.NewStruct("const", x))
+		}
+
+	case cue.RegexMatchOp, cue.NotRegexMatchOp:
+		re, err := a[0].String()
+		if err != nil {
+			g.unsupported(c, "regular expression")
+			return
+		}
+		if op == cue.RegexMatchOp {
+			g.set(s, "pattern", ast.NewString(re))
+		} else {
+			g.set(s, "not", ast.NewStruct("pattern", ast.NewString(re)))
+		}
+
+	case cue.CallOp:
+		g.validator(s, c, a)
+
+	default:
+		g.unsupported(c, "expression")
+	}
+}
+
+// validators maps builtin validators to the corresponding JSON Schema
+// keywords. Validators without arguments map to the value true, and those of
+// the form "format=name" map to the given format.
+var validators = map[string]string{
+	"strings.MinRunes": "minLength",
+	"strings.MaxRunes": "maxLength",
+	"list.MinItems":    "minItems",
+	"list.MaxItems":    "maxItems",
+	"list.UniqueItems": "uniqueItems",
+	"struct.MinFields": "minProperties",
+	"struct.MaxFields": "maxProperties",
+	"math.MultipleOf":  "multipleOf",
+	"time.Time":        "format=date-time",
+	"net.IPv4":         "format=ipv4",
+	"net.IPv6":         "format=ipv6",
+	"net.FQDN":         "format=hostname",
+	"net.AbsURL":       "format=uri",
+	"net.URL":          "format=uri-reference",
+}
+
+// validatorName returns the qualified name of the builtin validator v, such
+// as "strings.MinRunes".
+func validatorName(v cue.Value) string {
+	return strings.TrimSuffix(fmt.Sprint(v), "()")
+}
+
+func (g *generator) validator(s *ast.StructLit, c cue.Value, a []cue.Value) {
+	key := validators[validatorName(a[0])]
+	switch {
+	case key == "":
+		g.unsupported(c, "validator")
+
+	case strings.HasPrefix(key, "format="):
+		g.set(s, "format", ast.NewString(strings.TrimPrefix(key, "format=")))
+
+	case len(a) == 1:
+		g.set(s, key, ast.NewBool(true))
+
+	case len(a) == 2:
+		if x := g.value(a[1]); x != nil {
+			g.set(s, key, x)
+		}
+
+	default:
+		g.unsupported(c, "validator")
+	}
+}
+
+// object converts the fields of the struct v.
+func (g *generator) object(s *ast.StructLit, v cue.Value) {
+	props := ast.NewStruct()
+	var required []ast.Expr
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		g.errs = errors.Append(g.errs, errors.Promote(err, ""))
+		return
+	}
+	for iter.Next() {
+		name := iter.Selector().Unquoted()
+		g.set(props, name, g.schema(iter.Value()))
+		if !iter.IsOptional() {
+			required = append(required, ast.NewString(name))
+		}
+	}
+	if len(props.Elts) > 0 {
+		g.set(s, "properties", props)
+	}
+	if len(required) > 0 {
+		g.set(s, "required", ast.NewList(required...))
+	}
+
+	if elem, ok := v.Elem(); ok {
+		g.set(s, "additionalProperties", g.schema(elem))
+	} else if !v.Allows(cue.AnyString) {
+		g.set(s, "additionalProperties", ast.NewBool(false))
+	}
+}
+
+// array converts the elements of the list v.
+func (g *generator) array(s *ast.StructLit, v cue.Value) {
+	var prefix []ast.Expr
+	iter, err := v.List()
+	if err != nil {
+		g.errs = errors.Append(g.errs, errors.Promote(err, ""))
+		return
+	}
+	for iter.Next() {
+		prefix = append(prefix, g.schema(iter.Value()))
+	}
+
+	var rest ast.Expr
+	if elem, ok := v.Elem(); ok {
+		rest = g.schema(elem)
+	} else {
+		rest = ast.NewBool(false)
+	}
+
+	switch {
+	case len(prefix) == 0:
+		if b, ok := rest.(*ast.BasicLit); ok && b.Kind == token.FALSE {
+			g.set(s, "maxItems", ast.NewLit(token.INT, "0"))
+		} else {
+			g.set(s, "items", rest)
+		}
+
+	case g.version >= draft2020_12:
+		g.set(s, "prefixItems", ast.NewList(prefix...))
+		g.set(s, "items", rest)
+
+	default:
+		g.set(s, "items", ast.NewList(prefix...))
+		g.set(s, "additionalItems", rest)
+	}
+	if len(prefix) > 0 {
+		g.set(s, "minItems", ast.NewLit(token.INT, fmt.Sprint(len(prefix))))
+	}
+}
+
+// value converts the concrete value v to JSON.
+func (g *generator) value(v cue.Value) ast.Expr {
+	if v.Kind() == cue.BytesKind {
+		g.unsupported(v, "bytes value")
+		return nil
+	}
+	b, err := v.MarshalJSON()
+	if err != nil {
+		g.errs = errors.Append(g.errs, errors.Promote(err, ""))
+		return nil
+	}
+	x, err := json.Extract("", b)
+	if err != nil {
+		g.errs = errors.Append(g.errs, errors.Promote(err, ""))
+		return nil
+	}
+	return x
+}
+
+// sameValue reports whether a and b are the same value.
+func sameValue(a, b cue.Value) bool {
+	_, x := value.ToInternal(a)
+	_, y := value.ToInternal(b)
+	return x == y
+}
+
+// isDefault reports whether d is a default value to include in a schema. As
+// open lists default to the empty list, empty lists are excluded to avoid
+// noise.
+func isDefault(d cue.Value) bool {
+	if d.Validate(cue.Concrete(true)) != nil {
+		return false
+	}
+	if d.Kind() == cue.ListKind {
+		n, _ := d.Len().Int64()
+		return n > 0
+	}
+	return true
+}
+
+// isDefinitionPath reports whether p is a non-empty path of definitions.
+func isDefinitionPath(p cue.Path) bool {
+	sels := p.Selectors()
+	for _, sel := range sels {
+		if !sel.IsDefinition() {
+			return false
+		}
+	}
+	return len(sels) > 0
+}
+
+// isScalar reports whether v is a concrete value other than a struct or
+// list.
+func isScalar(v cue.Value) bool {
+	if !v.IsConcrete() {
+		return false
+	}
+	switch v.Kind() {
+	case cue.StructKind, cue.ListKind:
+		return false
+	}
+	return true
+}
+
+// jsonType returns the JSON Schema type for the kind k, or nil if k allows
+// any type.
+func jsonType(k cue.Kind) ast.Expr {
+	if k&cue.NumberKind == cue.NumberKind {
+		k &^= cue.IntKind
+	}
+	var types []ast.Expr
+	for _, t := range []struct {
+		kind cue.Kind
+		name string
+	}{
+		{cue.NullKind, "null"},
+		{cue.BoolKind, "boolean"},
+		{cue.IntKind, "integer"},
+		{cue.FloatKind, "number"},
+		{cue.StringKind | cue.BytesKind, "string"},
+		{cue.StructKind, "object"},
+		{cue.ListKind, "array"},
+	} {
+		if k&t.kind != 0 {
+			types = append(types, ast.NewString(t.name))
+		}
+	}
+	switch len(types) {
+	case 0, 7:
+		return nil
+	case 1:
+		return types[0]
+	}
+	return ast.NewList(types...)
+}
+
+// splitConjuncts appends the conjuncts of v to a.
+func splitConjuncts(a []cue.Value, v cue.Value) []cue.Value {
+	op, args := v.Expr()
+	if op != cue.AndOp {
+		return append(a, v)
+	}
+	for _, x := range args {
+		a = splitConjuncts(a, x)
+	}
+	return a
+}
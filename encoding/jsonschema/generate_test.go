@@ -0,0 +1,295 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		cfg  *Config
+		out  string
+		err  string
+	}{{
+		name: "definitions",
+		in: `
+		import "strings"
+
+		// A Person is a human or robot.
+		#Person: {
+			// Name is the full name.
+			name:  string & strings.MinRunes(1)
+			age?:  int & >=0 & <150
+			kind:  *"human" | "robot"
+			tags?: [...string]
+			pos?:  [number, number]
+			parent?: #Person
+			labels?: [string]: string
+			nick?: string | null
+		}
+		`,
+		out: `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"$defs": {
+		"Person": {
+			"description": "A Person is a human or robot.",
+			"type": "object",
+			"properties": {
+				"name": {
+					"description": "Name is the full name.",
+					"type": "string",
+					"minLength": 1
+				},
+				"age": {
+					"type": "integer",
+					"minimum": 0,
+					"exclusiveMaximum": 150
+				},
+				"kind": {
+					"enum": [
+						"human",
+						"robot"
+					],
+					"default": "human"
+				},
+				"tags": {
+					"type": "array",
+					"items": {
+						"type": "string"
+					}
+				},
+				"pos": {
+					"type": "array",
+					"prefixItems": [
+						{
+							"type": "number"
+						},
+						{
+							"type": "number"
+						}
+					],
+					"items": false,
+					"minItems": 2
+				},
+				"parent": {
+					"$ref": "#/$defs/Person"
+				},
+				"labels": {
+					"type": "object",
+					"additionalProperties": {
+						"type": "string"
+					}
+				},
+				"nick": {
+					"anyOf": [
+						{
+							"enum": [
+								null
+							]
+						},
+						{
+							"type": "string"
+						}
+					]
+				}
+			},
+			"required": [
+				"name",
+				"kind"
+			],
+			"additionalProperties": false
+		}
+	}
+}`,
+	}, {
+		name: "draft07Root",
+		in: `
+		#Point: [number, number, ...number]
+		#Shape: {
+			kind: "circle" | "square"
+			center: #Point
+			radius: *1 | number & >0
+		}
+		`,
+		cfg: &Config{
+			Version:  "http://json-schema.org/draft-07/schema#",
+			ID:       "https://example.com/shape.json",
+			RootPath: cue.ParsePath("#Shape"),
+		},
+		out: `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"$id": "https://example.com/shape.json",
+	"allOf": [
+		{
+			"$ref": "#/definitions/Shape"
+		}
+	],
+	"definitions": {
+		"Point": {
+			"type": "array",
+			"items": [
+				{
+					"type": "number"
+				},
+				{
+					"type": "number"
+				}
+			],
+			"additionalItems": {
+				"type": "number"
+			},
+			"minItems": 2
+		},
+		"Shape": {
+			"type": "object",
+			"properties": {
+				"kind": {
+					"enum": [
+						"circle",
+						"square"
+					]
+				},
+				"center": {
+					"$ref": "#/definitions/Point"
+				},
+				"radius": {
+					"type": "number",
+					"exclusiveMinimum": 0,
+					"default": 1
+				}
+			},
+			"required": [
+				"kind",
+				"center",
+				"radius"
+			],
+			"additionalProperties": false
+		}
+	}
+}`,
+	}, {
+		name: "expand",
+		in: `
+		#ID: string & =~"^[a-z]+$"
+		#Item: {
+			id: #ID
+			count: uint
+		}
+		`,
+		cfg: &Config{
+			RootPath: cue.ParsePath("#Item"),
+			NameFunc: func(p cue.Path) string { return "" },
+		},
+		out: `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"id": {
+			"type": "string",
+			"pattern": "^[a-z]+$"
+		},
+		"count": {
+			"type": "integer",
+			"minimum": 0
+		}
+	},
+	"required": [
+		"id",
+		"count"
+	],
+	"additionalProperties": false
+}`,
+	}, {
+		name: "omitUnsupported",
+		in: `
+		#A: {
+			a: string & >"b"
+			b?: int
+		}
+		`,
+		out: `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"$defs": {
+		"A": {
+			"type": "object",
+			"properties": {
+				"a": {
+					"type": "string"
+				},
+				"b": {
+					"type": "integer"
+				}
+			},
+			"required": [
+				"a"
+			],
+			"additionalProperties": false
+		}
+	}
+}`,
+	}, {
+		name: "strict",
+		in: `
+		#A: a: string & >"b"
+		`,
+		cfg: &Config{Strict: true},
+		err: `cannot represent bound in JSON Schema: >"b"`,
+	}, {
+		name: "recursiveExpansion",
+		in: `
+		#List: next?: #List
+		`,
+		cfg: &Config{NameFunc: func(p cue.Path) string { return "" }, RootPath: cue.ParsePath("#List")},
+		err: "cannot expand recursive definition #List",
+	}, {
+		name: "version",
+		in:   `#A: int`,
+		cfg:  &Config{Version: "http://json-schema.org/draft-04/schema#"},
+		err:  `unsupported JSON Schema version "http://json-schema.org/draft-04/schema#"`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := cuecontext.New()
+			v := ctx.CompileString(tc.in)
+			qt.Assert(t, qt.IsNil(v.Err()))
+
+			f, err := Generate(v, tc.cfg)
+			if tc.err != "" {
+				qt.Assert(t, qt.IsNotNil(err))
+				qt.Assert(t, qt.StringContains(errors.Details(err, nil), tc.err))
+				return
+			}
+			qt.Assert(t, qt.IsNil(err))
+
+			b, err := ctx.BuildFile(f).MarshalJSON()
+			qt.Assert(t, qt.IsNil(err))
+			var buf bytes.Buffer
+			qt.Assert(t, qt.IsNil(stdjson.Indent(&buf, b, "", "\t")))
+			qt.Assert(t, qt.Equals(buf.String(), strings.TrimSpace(tc.out)))
+		})
+	}
+}
@@ -81,9 +81,57 @@ type Config struct {
 	// - selection and definition of formats
 	// - documentation hooks.
 
+	// Dialect selects the flavor of JSON Schema to interpret. The default is
+	// standard JSON Schema.
+	Dialect Dialect
+
 	// Strict reports an error for unsupported features, rather than ignoring
 	// them.
 	Strict bool
 
+	// The following fields only apply to Generate.
+
+	// Version is the URI of the JSON Schema draft to generate, as used for
+	// $schema. Draft-07, 2019-09, and 2020-12 are supported. The default is
+	// 2020-12.
+	Version string
+
+	// RootPath is the path of the value, typically a definition, that is
+	// converted to the root schema of the document. By default, the document
+	// only holds definitions.
+	RootPath cue.Path
+
+	// NameFunc determines the name of the definition at the given path
+	// within the definitions section, and thus of the references to it. If
+	// it returns the empty string, the definition is expanded in place
+	// wherever it is referenced. The default name is the path without the
+	// leading "#" of each definition, such as "A.B" for #A.#B.
+	NameFunc func(path cue.Path) string
+
 	_ struct{} // prohibit casting from different type.
 }
+
+// A Dialect identifies a variant of JSON Schema.
+type Dialect int
+
+const (
+	// JSONSchema is standard JSON Schema.
+	JSONSchema Dialect = iota
+
+	// OpenAPI is the subset and extension of JSON Schema used by schema
+	// objects in OpenAPI 3.0. It maps the int32 and int64 formats to the
+	// corresponding CUE types and ignores specification extensions, fields
+	// starting with "x-", even in strict mode.
+	OpenAPI
+
+	// Kubernetes is the OpenAPI dialect used for structural schemas of
+	// Kubernetes CustomResourceDefinitions. In addition to what is supported
+	// for OpenAPI, it interprets x-kubernetes-int-or-string as allowing both
+	// integers and strings.
+	Kubernetes
+)
+
+// isOpenAPI reports whether d is OpenAPI or a dialect derived from it.
+func (d Dialect) isOpenAPI() bool {
+	return d == OpenAPI || d == Kubernetes
+}
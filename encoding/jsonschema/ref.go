@@ -438,3 +438,64 @@ func jsonSchemaRef(p token.Pos, a []string) ([]ast.Label, error) {
 	}
 	return []ast.Label{ast.NewIdent(rootDefs), ast.NewString(name)}, nil
 }
+
+// draftVersion returns the draft number for the given $schema URI, or 0 if
+// it is not known. Drafts after draft-07 are numbered in sequence.
+func draftVersion(uri string) int {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return 0
+	}
+	switch strings.TrimSuffix(u.Host+u.Path, "/") {
+	case "json-schema.org/draft-04/schema":
+		return 4
+	case "json-schema.org/draft-06/schema":
+		return 6
+	case "json-schema.org/draft-07/schema":
+		return 7
+	case "json-schema.org/draft/2019-09/schema":
+		return draft2019_09
+	case "json-schema.org/draft/2020-12/schema":
+		return draft2020_12
+	}
+	return 0
+}
+
+// collectAnchors records the $anchor and $dynamicAnchor declarations within
+// the schema v, located at the JSON pointer ptr. Subschemas that define an
+// $id start a new resource with its own anchors and are skipped.
+func (d *decoder) collectAnchors(v cue.Value, ptr string, root bool) {
+	switch v.Kind() {
+	case cue.StructKind:
+		if !root && v.LookupPath(cue.MakePath(cue.Str("$id"))).Exists() {
+			return
+		}
+		for _, key := range []string{"$anchor", "$dynamicAnchor"} {
+			name, err := v.LookupPath(cue.MakePath(cue.Str(key))).String()
+			if err != nil {
+				continue
+			}
+			p := ptr
+			if p == "" {
+				p = "/"
+			}
+			d.anchors[name] = p
+		}
+		iter, _ := v.Fields()
+		for iter.Next() {
+			key := iter.Selector().Unquoted()
+			switch key {
+			case "const", "default", "enum", "examples":
+				// Not schemas.
+				continue
+			}
+			d.collectAnchors(iter.Value(), ptr+"/"+key, false)
+		}
+
+	case cue.ListKind:
+		iter, _ := v.List()
+		for i := 0; iter.Next(); i++ {
+			d.collectAnchors(iter.Value(), ptr+"/"+strconv.Itoa(i), false)
+		}
+	}
+}
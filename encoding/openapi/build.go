@@ -35,12 +35,14 @@ type buildContext struct {
 	inst      cue.Value
 	instExt   cue.Value
 	refPrefix string
+	fileRefs  bool
 	path      []cue.Selector
 	errs      errors.Error
 
 	expandRefs    bool
 	structural    bool
 	exclusiveBool bool
+	jsonSchema    bool // use JSON Schema semantics, as in OpenAPI 3.1
 	nameFunc      func(inst cue.Value, path cue.Path) string
 	descFunc      func(v cue.Value) string
 	fieldFilter   *regexp.Regexp
@@ -76,6 +78,13 @@ type oaSchema = OrderedMap
 type typeFunc func(b *builder, a cue.Value)
 
 func schemas(g *Generator, inst cue.InstanceOrValue) (schemas *ast.StructLit, err error) {
+	return generateSchemas(g, inst, false)
+}
+
+// generateSchemas generates the schemas for all top-level definitions of inst.
+// If fileRefs is true, references between schemas refer to sibling files
+// named after the schema instead of to a location within the same document.
+func generateSchemas(g *Generator, inst cue.InstanceOrValue, fileRefs bool) (schemas *ast.StructLit, err error) {
 	val := inst.Value()
 	_, isInstance := inst.(*cue.Instance)
 	var fieldFilter *regexp.Regexp
@@ -103,6 +112,7 @@ func schemas(g *Generator, inst cue.InstanceOrValue) (schemas *ast.StructLit, er
 		inst:         val,
 		instExt:      val,
 		refPrefix:    "components/schemas",
+		fileRefs:     fileRefs,
 		expandRefs:   g.ExpandReferences,
 		structural:   g.ExpandReferences,
 		nameFunc:     g.NameFunc,
@@ -147,6 +157,7 @@ func schemas(g *Generator, inst cue.InstanceOrValue) (schemas *ast.StructLit, er
 	case "3.0.0":
 		c.exclusiveBool = true
 	case "3.1.0":
+		c.jsonSchema = true
 	default:
 		return nil, errors.Newf(token.NoPos, "unsupported version %s", g.Version)
 	}
@@ -540,12 +551,11 @@ func isConcrete(v cue.Value) bool {
 func (b *builder) disjunction(a []cue.Value, f typeFunc) {
 	disjuncts := []cue.Value{}
 	enums := []ast.Expr{} // TODO: unique the enums
-	nullable := false     // Only supported in OpenAPI, not JSON schema
+	nullable := false
 
 	for _, v := range a {
 		switch {
 		case v.Null() == nil:
-			// TODO: for JSON schema, we need to fall through.
 			nullable = true
 
 		case isConcrete(v):
@@ -565,7 +575,7 @@ func (b *builder) disjunction(a []cue.Value, f typeFunc) {
 			b.set("enum", ast.NewList(enums...))
 		}
 		if nullable {
-			b.setSingle("nullable", ast.NewBool(true), true) // allowed in Structural
+			b.setNullable() // allowed in Structural
 		}
 		return
 	}
@@ -576,7 +586,7 @@ func (b *builder) disjunction(a []cue.Value, f typeFunc) {
 	}
 
 	if nullable {
-		b.setSingle("nullable", ast.NewBool(true), true)
+		b.setNullable()
 	}
 
 	schemas := make([]*ast.StructLit, len(disjuncts))
@@ -661,9 +671,7 @@ func (b *builder) dispatch(f typeFunc, v cue.Value) {
 
 	switch v.IncompleteKind() {
 	case cue.NullKind:
-		// TODO: for JSON schema we would set the type here. For OpenAPI,
-		// it must be nullable.
-		b.setSingle("nullable", ast.NewBool(true), true)
+		b.setNullable()
 
 	case cue.BoolKind:
 		b.setType("boolean", "")
@@ -799,7 +807,13 @@ func (b *builder) object(v cue.Value) {
 		(b.core == nil || b.core.items == nil) && b.checkCycle(t) {
 		schema := b.schema(nil, cue.AnyString, t)
 		if len(schema.Elts) > 0 {
-			b.setSingle("additionalProperties", schema, true) // Not allowed in structural.
+			// In JSON Schema, unevaluatedProperties also takes into account
+			// the properties of embedded references.
+			key := "additionalProperties"
+			if b.ctx.jsonSchema {
+				key = "unevaluatedProperties"
+			}
+			b.setSingle(key, schema, true) // Not allowed in structural.
 		}
 	}
 
@@ -985,6 +999,13 @@ func (b *builder) number(v cue.Value) {
 			return
 		}
 
+	case cue.AndOp:
+		// The conjuncts of a value with a default, such as *1 | int & >=0,
+		// are not split by value.
+		for _, x := range a {
+			b.number(x)
+		}
+
 	case cue.NoOp:
 		// TODO: extract format from specific type.
 
@@ -1114,6 +1135,7 @@ type builder struct {
 	current      *oaSchema
 	allOf        []*ast.StructLit
 	deprecated   bool
+	nullable     bool
 
 	// Building structural schema
 	core       *builder
@@ -1251,10 +1273,92 @@ func (b *builder) finish() *ast.StructLit {
 		t.Set("deprecated", ast.NewBool(true))
 	}
 	setType(t, b)
+	if b.nullable {
+		t = addNull(t)
+	}
 	sortSchema((*ast.StructLit)(t))
 	return (*ast.StructLit)(t)
 }
 
+// setNullable marks the schema as allowing null. OpenAPI 3.0 uses the
+// nullable keyword for this, whereas OpenAPI 3.1 follows JSON Schema in
+// adding null to the type, which is done by finish.
+func (b *builder) setNullable() {
+	if b.ctx.jsonSchema {
+		b.nullable = true
+		return
+	}
+	b.setSingle("nullable", ast.NewBool(true), true)
+}
+
+// addNull modifies t to also allow null. It adds null to the type and enum
+// of t, if present, unless t applies subschemas that would disallow null.
+// Otherwise it allows null as an alternative to t.
+func addNull(t *oaSchema) *oaSchema {
+	var typ *ast.BasicLit
+	var enum *ast.ListLit
+	for _, key := range []string{"$ref", "allOf", "anyOf", "oneOf", "not"} {
+		if t.exists(key) {
+			return nullAlternative(t)
+		}
+	}
+	if f := t.find("type"); f != nil {
+		typ, _ = f.Value.(*ast.BasicLit)
+	}
+	if f := t.find("enum"); f != nil {
+		enum, _ = f.Value.(*ast.ListLit)
+	}
+	if typ == nil && enum == nil {
+		if t.len() > 0 {
+			return nullAlternative(t)
+		}
+		t.Set("type", ast.NewString("null"))
+		return t
+	}
+	if typ != nil {
+		t.Set("type", ast.NewList(typ, ast.NewString("null")))
+	}
+	if enum != nil && !hasNull(enum) {
+		enum.Elts = append(enum.Elts, ast.NewNull())
+	}
+	return t
+}
+
+// nullAlternative returns a schema that allows null or values matching t.
+// The description of t, if any, is moved to the new schema.
+func nullAlternative(t *oaSchema) *oaSchema {
+	s := &OrderedMap{}
+	if f := t.find("description"); f != nil {
+		s.Set("description", f.Value)
+		t.Elts = removeField(t.Elts, f)
+	}
+	s.Set("anyOf", ast.NewList(
+		(*ast.StructLit)(t),
+		ast.NewStruct("type", ast.NewString("null")),
+	))
+	return s
+}
+
+func hasNull(l *ast.ListLit) bool {
+	for _, e := range l.Elts {
+		if lit, ok := e.(*ast.BasicLit); ok && lit.Kind == token.NULL {
+			return true
+		}
+	}
+	return false
+}
+
+func removeField(a []ast.Decl, f *ast.Field) []ast.Decl {
+	k := 0
+	for _, d := range a {
+		if d != f {
+			a[k] = d
+			k++
+		}
+	}
+	return a[:k]
+}
+
 func (b *builder) add(t *ast.StructLit) {
 	b.allOf = append(b.allOf, t)
 }
@@ -1267,10 +1371,14 @@ func (b *builder) addConjunct(f func(*builder)) {
 
 func (b *builder) addRef(v cue.Value, inst cue.Value, ref cue.Path) {
 	name := b.ctx.makeRef(inst, ref)
+	target := path.Join("#", b.ctx.refPrefix, name)
+	if b.ctx.fileRefs {
+		target = schemaFile(name)
+	}
 	b.addConjunct(func(b *builder) {
 		b.allOf = append(b.allOf, ast.NewStruct(
 			"$ref",
-			ast.NewString(path.Join("#", b.ctx.refPrefix, name)),
+			ast.NewString(target),
 		))
 	})
 
@@ -44,8 +44,12 @@ package openapi
 //
 
 import (
+	"strings"
+
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
 )
 
 // newCoreBuilder returns a builder that represents a structural schema.
@@ -179,3 +183,359 @@ func (b *builder) buildCoreStruct(v cue.Value) {
 		sub.buildCore(i.Value())
 	}
 }
+
+// GenerateCRDs generates a CustomResourceDefinition manifest for each group
+// and kind declared by the definitions of inst. A definition declares a
+// version of a custom resource with a @crd attribute, as in
+//
+//	#FooV1: {
+//		apiVersion: "example.com/v1"
+//		kind:       "Foo"
+//		spec: {...}
+//	} @crd(group="example.com", kind=Foo, version=v1)
+//
+// The attribute supports the following arguments:
+//
+//	group       the API group of the resource (required)
+//	kind        the kind of the resource (required)
+//	version     the name of the version (required)
+//	plural      the plural name; the default is the lowercase kind plus "s"
+//	singular    the singular name; the default is the lowercase kind
+//	listKind    the kind of lists; the default is the kind plus "List"
+//	shortName   a short name; it may be repeated
+//	scope       Namespaced (the default) or Cluster
+//	storage     marks the storage version, which is required if there is
+//	            more than one version
+//	served=false  marks a version that is not served
+//	status      enables the status subresource
+//
+// The definitions of all versions of a kind are converted to a structural
+// schema in the versions section of a single CRD, ordered as the definitions.
+// References are expanded, as CRDs do not allow them, and the schema of the
+// metadata field is reduced to the object type, as Kubernetes requires.
+//
+// Constraints that cannot be represented in a structural schema are mapped to
+// the corresponding x-kubernetes extensions: a value that allows both
+// integers and strings is marked with x-kubernetes-int-or-string, and structs
+// that allow any field and values of any type are marked with
+// x-kubernetes-preserve-unknown-fields. Other extensions are set with @crd
+// attributes on the fields of a definition:
+//
+//	listType=x          x-kubernetes-list-type: atomic, set, or map
+//	listMapKey=x        an element of x-kubernetes-list-map-keys; it may be
+//	                    repeated
+//	mapType=x           x-kubernetes-map-type: granular or atomic
+//	embeddedResource    x-kubernetes-embedded-resource
+//	preserveUnknownFields  x-kubernetes-preserve-unknown-fields
+//	intOrString         x-kubernetes-int-or-string
+//	rule=x, message=y   an element of x-kubernetes-validations with a CEL
+//	                    rule and an optional message
+//
+// The ExpandReferences and Version fields of c are ignored.
+func GenerateCRDs(inst cue.InstanceOrValue, c *Config) ([]*ast.File, error) {
+	cfg := Config{}
+	if c != nil {
+		cfg = *c
+	}
+	cfg.ExpandReferences = true
+	cfg.Version = "3.0.0"
+
+	val := inst.Value()
+	all, err := schemas(&cfg, inst)
+	if err != nil {
+		return nil, err
+	}
+	schemas := (*OrderedMap)(all)
+
+	var crds []*crd
+	byKind := map[string]*crd{}
+	var errs errors.Error
+
+	iter, err := val.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		v := iter.Value()
+		attr := v.Attribute("crd")
+		if !sel.IsDefinition() || attr.Err() != nil {
+			continue
+		}
+		args, err := parseCRDAttr(attr)
+		if err != nil {
+			errs = errors.Append(errs, errors.Newf(v.Pos(), "%s: %v", sel, err))
+			continue
+		}
+		name := selectorLabel(sel)
+		if cfg.NameFunc != nil {
+			name = cfg.NameFunc(val, cue.MakePath(sel))
+		}
+		f := schemas.find(name)
+		if f == nil {
+			errs = errors.Append(errs, errors.Newf(v.Pos(), "%s: no schema generated", sel))
+			continue
+		}
+		schema := f.Value.(*ast.StructLit)
+		kubernetesSchema(schema, v)
+		if m := (*OrderedMap)(schema).getMap("properties"); m != nil && m.exists("metadata") {
+			m.setExpr("metadata", ast.NewStruct("type", ast.NewString("object")))
+		}
+
+		key := args.group + "/" + args.kind
+		x := byKind[key]
+		if x == nil {
+			x = &crd{crdAttr: args}
+			byKind[key] = x
+			crds = append(crds, x)
+		}
+		x.versions = append(x.versions, crdVersion{args, schema})
+	}
+
+	var files []*ast.File
+	for _, x := range crds {
+		f, err := x.manifest()
+		if err != nil {
+			errs = errors.Append(errs, errors.Promote(err, x.group+"/"+x.kind))
+			continue
+		}
+		files = append(files, f)
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	if len(files) == 0 {
+		return nil, errors.Newf(token.NoPos, "no definitions with @crd attribute")
+	}
+	return files, nil
+}
+
+// crdAttr holds the arguments of a @crd attribute of a definition.
+type crdAttr struct {
+	group      string
+	kind       string
+	version    string
+	plural     string
+	singular   string
+	listKind   string
+	shortNames []string
+	scope      string
+	storage    bool
+	served     bool
+	status     bool
+}
+
+func parseCRDAttr(a cue.Attribute) (crdAttr, error) {
+	x := crdAttr{served: true}
+	for i := 0; i < a.NumArgs(); i++ {
+		key, value := a.Arg(i)
+		switch key {
+		case "group":
+			x.group = value
+		case "kind":
+			x.kind = value
+		case "version":
+			x.version = value
+		case "plural":
+			x.plural = value
+		case "singular":
+			x.singular = value
+		case "listKind":
+			x.listKind = value
+		case "shortName":
+			x.shortNames = append(x.shortNames, value)
+		case "scope":
+			if value != "Namespaced" && value != "Cluster" {
+				return x, errors.Newf(token.NoPos, "invalid scope %q", value)
+			}
+			x.scope = value
+		case "storage":
+			x.storage = value != "false"
+		case "served":
+			x.served = value != "false"
+		case "status":
+			x.status = value != "false"
+		default:
+			return x, errors.Newf(token.NoPos, "unknown @crd argument %q", key)
+		}
+	}
+	switch {
+	case x.group == "":
+		return x, errors.Newf(token.NoPos, "missing group in @crd attribute")
+	case x.kind == "":
+		return x, errors.Newf(token.NoPos, "missing kind in @crd attribute")
+	case x.version == "":
+		return x, errors.Newf(token.NoPos, "missing version in @crd attribute")
+	}
+	return x, nil
+}
+
+// A crd collects the versions of a custom resource.
+type crd struct {
+	crdAttr
+	versions []crdVersion
+}
+
+type crdVersion struct {
+	crdAttr
+	schema *ast.StructLit
+}
+
+// manifest returns the CustomResourceDefinition for x.
+func (x *crd) manifest() (*ast.File, error) {
+	lower := strings.ToLower(x.kind)
+	names := &OrderedMap{}
+	names.Set("kind", x.kind)
+	names.Set("listKind", orDefault(x.listKind, x.kind+"List"))
+	names.Set("plural", orDefault(x.plural, lower+"s"))
+	names.Set("singular", orDefault(x.singular, lower))
+	var shortNames []ast.Expr
+	for _, v := range x.versions {
+		for _, s := range v.shortNames {
+			shortNames = append(shortNames, ast.NewString(s))
+		}
+	}
+	if len(shortNames) > 0 {
+		names.setExpr("shortNames", ast.NewList(shortNames...))
+	}
+
+	scope := ""
+	storage := 0
+	var versions []ast.Expr
+	for i, v := range x.versions {
+		if v.scope != "" {
+			if scope != "" && scope != v.scope {
+				return nil, errors.Newf(token.NoPos, "conflicting scopes %q and %q", scope, v.scope)
+			}
+			scope = v.scope
+		}
+		for _, w := range x.versions[:i] {
+			if w.version == v.version {
+				return nil, errors.Newf(token.NoPos, "duplicate version %q", v.version)
+			}
+		}
+		isStorage := v.storage || len(x.versions) == 1
+		if isStorage {
+			storage++
+		}
+		m := &OrderedMap{}
+		m.Set("name", v.version)
+		m.setExpr("served", ast.NewBool(v.served))
+		m.setExpr("storage", ast.NewBool(isStorage))
+		m.setExpr("schema", ast.NewStruct("openAPIV3Schema", v.schema))
+		if v.status {
+			m.setExpr("subresources", ast.NewStruct("status", ast.NewStruct()))
+		}
+		versions = append(versions, (*ast.StructLit)(m))
+	}
+	if storage != 1 {
+		return nil, errors.Newf(token.NoPos,
+			"exactly one version must be marked as storage version, found %d", storage)
+	}
+
+	spec := &OrderedMap{}
+	spec.Set("group", x.group)
+	spec.Set("names", names)
+	spec.Set("scope", orDefault(scope, "Namespaced"))
+	spec.setExpr("versions", ast.NewList(versions...))
+
+	m := &OrderedMap{}
+	m.Set("apiVersion", "apiextensions.k8s.io/v1")
+	m.Set("kind", "CustomResourceDefinition")
+	m.setExpr("metadata", ast.NewStruct(
+		"name", ast.NewString(orDefault(x.plural, lower+"s")+"."+x.group)))
+	m.Set("spec", spec)
+	return &ast.File{Decls: m.Elts}, nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// kubernetesSchema adds the x-kubernetes extensions for v to the structural
+// schema s.
+func kubernetesSchema(s *ast.StructLit, v cue.Value) {
+	m := (*OrderedMap)(s)
+	attr := v.Attribute("crd")
+	flag := func(key string) bool {
+		ok, _ := attr.Flag(0, key)
+		return ok
+	}
+
+	kind := v.IncompleteKind() &^ cue.NullKind
+	switch {
+	case kind == cue.IntKind|cue.StringKind || flag("intOrString"):
+		for _, key := range []string{"type", "format", "oneOf", "anyOf"} {
+			m.remove(key)
+		}
+		m.setExpr("x-kubernetes-int-or-string", ast.NewBool(true))
+
+	case kind == cue.TopKind&^cue.NullKind:
+		m.setExpr("x-kubernetes-preserve-unknown-fields", ast.NewBool(true))
+	}
+
+	if props := m.getMap("properties"); props != nil {
+		for _, e := range props.Elts {
+			f := e.(*ast.Field)
+			name, _, _ := ast.LabelName(f.Label)
+			if x, ok := f.Value.(*ast.StructLit); ok {
+				f := v.LookupPath(cue.MakePath(cue.Str(name)))
+				if !f.Exists() {
+					f = v.LookupPath(cue.MakePath(cue.Str(name).Optional()))
+				}
+				kubernetesSchema(x, f)
+			}
+		}
+	}
+	if f := m.find("additionalProperties"); f != nil {
+		if x, ok := f.Value.(*ast.StructLit); ok {
+			if len(x.Elts) == 0 {
+				// Any field of any type is allowed.
+				m.remove("additionalProperties")
+				m.setExpr("x-kubernetes-preserve-unknown-fields", ast.NewBool(true))
+			} else {
+				kubernetesSchema(x, v.LookupPath(cue.MakePath(cue.AnyString)))
+			}
+		}
+	}
+	if f := m.find("items"); f != nil {
+		if x, ok := f.Value.(*ast.StructLit); ok {
+			kubernetesSchema(x, v.LookupPath(cue.MakePath(cue.AnyIndex)))
+		}
+	}
+
+	if flag("preserveUnknownFields") {
+		m.setExpr("x-kubernetes-preserve-unknown-fields", ast.NewBool(true))
+	}
+	if flag("embeddedResource") {
+		m.setExpr("x-kubernetes-embedded-resource", ast.NewBool(true))
+	}
+	var keys []ast.Expr
+	var rules []ast.Expr
+	for i := 0; i < attr.NumArgs(); i++ {
+		switch key, value := attr.Arg(i); key {
+		case "listType":
+			m.Set("x-kubernetes-list-type", value)
+		case "mapType":
+			m.Set("x-kubernetes-map-type", value)
+		case "listMapKey":
+			keys = append(keys, ast.NewString(value))
+		case "rule":
+			rules = append(rules, ast.NewStruct("rule", ast.NewString(value)))
+		case "message":
+			if len(rules) > 0 {
+				r := (*OrderedMap)(rules[len(rules)-1].(*ast.StructLit))
+				r.Set("message", value)
+			}
+		}
+	}
+	if len(keys) > 0 {
+		m.setExpr("x-kubernetes-list-map-keys", ast.NewList(keys...))
+	}
+	if len(rules) > 0 {
+		m.setExpr("x-kubernetes-validations", ast.NewList(rules...))
+	}
+}
@@ -27,8 +27,13 @@ import (
 
 // Extract converts OpenAPI definitions to an equivalent CUE representation.
 //
-// It currently only converts entries in #/components/schema and extracts some
-// meta data.
+// The entries in #/components/schemas are converted to CUE definitions. The
+// other parts of the API definition, such as paths, their operations,
+// parameters, request and response bodies, and security schemes, are
+// included as data in fields of the same name. References to schemas within
+// those parts are retained as is. As Generate includes these fields in the
+// generated document, this allows regenerating the API definition from the
+// CUE representation.
 func Extract(data cue.InstanceOrValue, c *Config) (*ast.File, error) {
 	// TODO: find a good OpenAPI validator. Both go-openapi and kin-openapi
 	// seem outdated. The k8s one might be good, but avoid pulling in massive
@@ -42,8 +47,9 @@ func Extract(data cue.InstanceOrValue, c *Config) (*ast.File, error) {
 	}
 
 	js, err := jsonschema.Extract(data, &jsonschema.Config{
-		Root: oapiSchemas,
-		Map:  openAPIMapping,
+		Root:    oapiSchemas,
+		Map:     openAPIMapping,
+		Dialect: jsonschema.OpenAPI,
 	})
 	if err != nil {
 		return nil, err
@@ -111,6 +117,27 @@ func Extract(data cue.InstanceOrValue, c *Config) (*ast.File, error) {
 		}
 	}
 
+	for _, name := range topLevelFields {
+		x := v.Lookup(name)
+		if !x.Exists() {
+			continue
+		}
+		expr, ok := x.Syntax().(ast.Expr)
+		switch name {
+		case "components":
+			expr, ok = componentsWithoutSchemas(expr)
+		case "paths":
+			// Generate adds empty paths by default.
+			if st, isStruct := expr.(*ast.StructLit); isStruct && len(st.Elts) == 0 {
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		add(&ast.Field{Label: ast.NewIdent(name), Value: expr})
+	}
+
 	if len(body) > 0 {
 		ast.SetRelPos(body[0], token.NewSection)
 		f.Decls = append(f.Decls, body...)
@@ -121,6 +148,42 @@ func Extract(data cue.InstanceOrValue, c *Config) (*ast.File, error) {
 
 const oapiSchemas = "#/components/schemas/"
 
+// topLevelFields lists the fields of an OpenAPI document, other than info
+// and the component schemas, that are retained as data by Extract and
+// included in the document by Generate.
+var topLevelFields = []string{
+	"servers",
+	"paths",
+	"webhooks",
+	"components",
+	"security",
+	"tags",
+	"externalDocs",
+}
+
+// componentsWithoutSchemas returns the components object x without the
+// schemas, which are converted to definitions. It reports false if there are
+// no other components.
+func componentsWithoutSchemas(x ast.Expr) (ast.Expr, bool) {
+	st, ok := x.(*ast.StructLit)
+	if !ok {
+		return nil, false
+	}
+	decls := []ast.Decl{}
+	for _, d := range st.Elts {
+		if f, ok := d.(*ast.Field); ok {
+			if name, _, _ := ast.LabelName(f.Label); name == "schemas" {
+				continue
+			}
+		}
+		decls = append(decls, d)
+	}
+	if len(decls) == 0 {
+		return nil, false
+	}
+	return &ast.StructLit{Elts: decls}, true
+}
+
 // rootDefs is the fallback for schemas that are not valid identifiers.
 // TODO: find something more principled.
 const rootDefs = "#SchemaMap"
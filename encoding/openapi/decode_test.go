@@ -16,6 +16,7 @@ package openapi_test
 
 import (
 	"bytes"
+	stdjson "encoding/json"
 	"io/fs"
 	"os"
 	"path"
@@ -28,6 +29,7 @@ import (
 	"golang.org/x/tools/txtar"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/encoding/json"
@@ -124,3 +126,31 @@ func TestDecode(t *testing.T) {
 	})
 	qt.Assert(t, qt.IsNil(err))
 }
+
+func TestRoundTrip(t *testing.T) {
+	a, err := txtar.ParseFile("testdata/script/paths.txtar")
+	qt.Assert(t, qt.IsNil(err))
+	r := &cue.Runtime{}
+	in, err := yaml.Decode(r, a.Files[0].Name, a.Files[0].Data)
+	qt.Assert(t, qt.IsNil(err))
+
+	f, err := openapi.Extract(in, &openapi.Config{})
+	qt.Assert(t, qt.IsNil(err))
+	v := cuecontext.New().BuildFile(f)
+	qt.Assert(t, qt.IsNil(v.Err()))
+
+	b, err := openapi.Gen(v, nil)
+	qt.Assert(t, qt.IsNil(err))
+	var got, want map[string]interface{}
+	qt.Assert(t, qt.IsNil(stdjson.Unmarshal(b, &got)))
+	qt.Assert(t, qt.IsNil(in.Value().Decode(&want)))
+
+	for _, key := range []string{"openapi", "servers", "paths", "security"} {
+		qt.Check(t, qt.DeepEquals(got[key], want[key]), qt.Commentf("%s", key))
+	}
+	gotComponents := got["components"].(map[string]interface{})
+	wantComponents := want["components"].(map[string]interface{})
+	for _, key := range []string{"parameters", "securitySchemes"} {
+		qt.Check(t, qt.DeepEquals(gotComponents[key], wantComponents[key]), qt.Commentf("components.%s", key))
+	}
+}
@@ -16,6 +16,7 @@ package openapi
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"cuelang.org/go/cue"
@@ -66,7 +67,9 @@ type Config struct {
 	// in this document.
 	SelfContained bool
 
-	// OpenAPI version to use. Supported as of v3.0.0.
+	// OpenAPI version to use. Supported versions are 3.0.0, the default, and
+	// 3.1.0. Version 3.1.0 uses JSON Schema semantics, for instance for null
+	// values, and allows webhooks and license identifiers.
 	Version string
 
 	// FieldFilter defines a regular expression of all fields to omit from the
@@ -114,6 +117,47 @@ func Generate(inst cue.InstanceOrValue, c *Config) (*ast.File, error) {
 	return &ast.File{Decls: top.Elts}, nil
 }
 
+// GenerateFiles is like Generate, but places each schema in a file of its own
+// instead of generating a single document. The first file is the root
+// document, named openapi.json, which refers to each of the schemas, named
+// components/schemas/<name>.json. References between schemas are relative to
+// the directory containing the schemas.
+//
+// The Filename of each file is set to its path relative to the root document.
+func GenerateFiles(inst cue.InstanceOrValue, c *Config) ([]*ast.File, error) {
+	if c == nil {
+		c = defaultConfig
+	}
+	all, err := generateSchemas(c, inst, true)
+	if err != nil {
+		return nil, err
+	}
+	refs := &OrderedMap{}
+	files := []*ast.File{nil}
+	for _, e := range all.Elts {
+		f := e.(*ast.Field)
+		name, _, _ := ast.LabelName(f.Label)
+		file := path.Join("components/schemas", schemaFile(name))
+		refs.Set(name, ast.NewStruct("$ref", ast.NewString("./"+file)))
+		files = append(files, &ast.File{
+			Filename: file,
+			Decls:    f.Value.(*ast.StructLit).Elts,
+		})
+	}
+	top, err := c.compose(inst, (*ast.StructLit)(refs))
+	if err != nil {
+		return nil, err
+	}
+	files[0] = &ast.File{Filename: "openapi.json", Decls: top.Elts}
+	return files, nil
+}
+
+// schemaFile returns the name of the file holding the schema with the given
+// name when generating multiple files.
+func schemaFile(name string) string {
+	return name + ".json"
+}
+
 // All generates an OpenAPI definition from the given instance.
 //
 // Note: only a limited number of top-level types are supported so far.
@@ -146,6 +190,7 @@ func (c *Config) compose(inst cue.InstanceOrValue, schemas *ast.StructLit) (x *a
 
 	var title, version string
 	var info *ast.StructLit
+	fields := map[string]ast.Expr{}
 
 	for i, _ := val.Fields(cue.Definitions(true)); i.Next(); {
 		if i.IsDefinition() {
@@ -168,6 +213,23 @@ func (c *Config) compose(inst cue.InstanceOrValue, schemas *ast.StructLit) (x *a
 			title, _ = i.Value().Lookup("title").String()
 			version, _ = i.Value().Lookup("version").String()
 
+		case "webhooks":
+			if c.Version < "3.1.0" {
+				errs = errors.Append(errs, errors.Newf(i.Value().Pos(),
+					"openapi: webhooks require version 3.1.0 or later"))
+				break
+			}
+			fallthrough
+
+		case "servers", "paths", "components", "security", "tags", "externalDocs":
+			x, _ := i.Value().Syntax(cue.Concrete(true)).(ast.Expr)
+			if x == nil {
+				errs = errors.Append(errs, errors.Newf(i.Value().Pos(),
+					"openapi: invalid %s", label))
+				break
+			}
+			fields[label] = x
+
 		default:
 			errs = errors.Append(errs, errors.Newf(i.Value().Pos(),
 				"openapi: unsupported top-level field %q", label))
@@ -222,12 +284,60 @@ func (c *Config) compose(inst cue.InstanceOrValue, schemas *ast.StructLit) (x *a
 		}
 	}
 
-	return ast.NewStruct(
-		"openapi", ast.NewString(c.Version),
-		"info", info,
-		"paths", ast.NewStruct(),
-		"components", ast.NewStruct("schemas", schemas),
-	), errs
+	if info != nil {
+		errs = errors.Append(errs, c.checkLicense(info))
+	}
+
+	components := &OrderedMap{}
+	components.Set("schemas", schemas)
+	if x, ok := fields["components"].(*ast.StructLit); ok {
+		for _, d := range x.Elts {
+			if f, ok := d.(*ast.Field); ok {
+				if name, _, _ := ast.LabelName(f.Label); name != "schemas" {
+					components.Set(name, f.Value)
+				}
+			}
+		}
+	}
+	if fields["paths"] == nil {
+		fields["paths"] = ast.NewStruct()
+	}
+	fields["components"] = (*ast.StructLit)(components)
+
+	top := &OrderedMap{}
+	top.Set("openapi", ast.NewString(c.Version))
+	top.Set("info", info)
+	for _, name := range topLevelFields {
+		if x := fields[name]; x != nil {
+			top.Set(name, x)
+		}
+	}
+	return (*ast.StructLit)(top), errs
+}
+
+// checkLicense checks the license object of the info section, if any. The
+// identifier field, an SPDX license expression, was introduced in OpenAPI
+// 3.1.0 as an alternative to the url field.
+func (c *Config) checkLicense(info *ast.StructLit) errors.Error {
+	f := (*OrderedMap)(info).find("license")
+	if f == nil {
+		return nil
+	}
+	license, ok := f.Value.(*ast.StructLit)
+	if !ok {
+		return nil
+	}
+	m := (*OrderedMap)(license)
+	switch {
+	case !m.exists("identifier"):
+	case c.Version < "3.1.0":
+		return errors.Newf(f.Pos(),
+			"openapi: license identifier requires version 3.1.0 or later")
+	case m.exists("url"):
+		return errors.Newf(f.Pos(),
+			"openapi: license identifier and url are mutually exclusive")
+	}
+	return nil
 }
 
 // Schemas extracts component/schemas from the CUE top-level types.
@@ -30,6 +30,7 @@ import (
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/load"
 	"cuelang.org/go/encoding/openapi"
+	"cuelang.org/go/encoding/yaml"
 	"cuelang.org/go/internal/cuetest"
 )
 
@@ -111,6 +112,14 @@ func TestParseDefinitions(t *testing.T) {
 		in:     "nums.cue",
 		out:    "nums-v3.1.0.json",
 		config: &openapi.Config{Info: info, Version: "3.1.0"},
+	}, {
+		in:     "nullable.cue",
+		out:    "nullable-v3.1.0.json",
+		config: &openapi.Config{Version: "3.1.0"},
+	}, {
+		in:     "nullable.cue",
+		config: defaultConfig,
+		err:    "webhooks require version 3.1.0",
 	}, {
 		in:     "builtins.cue",
 		out:    "builtins.json",
@@ -308,6 +317,113 @@ func walk(om *openapi.OrderedMap) {
 	}
 }
 
+func TestGenerateFiles(t *testing.T) {
+	ctx := cuecontext.New()
+	v := ctx.CompileString(`
+	$version: "v1"
+
+	#Type: {
+		a?: string
+		b:  #BaseType
+	}
+	#BaseType: {
+		c: int
+	}
+	`)
+	files, err := openapi.GenerateFiles(v, nil)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	got := map[string]string{}
+	for _, f := range files {
+		b, err := ctx.BuildFile(f).MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Filename] = string(b)
+	}
+	want := map[string]string{
+		"openapi.json": `{"openapi":"3.0.0","info":{"title":"Generated by cue.","version":"v1"},"paths":{},` +
+			`"components":{"schemas":{` +
+			`"BaseType":{"$ref":"./components/schemas/BaseType.json"},` +
+			`"Type":{"$ref":"./components/schemas/Type.json"}}}}`,
+		"components/schemas/BaseType.json": `{"type":"object","required":["c"],"properties":{"c":{"type":"integer"}}}`,
+		"components/schemas/Type.json":     `{"type":"object","required":["b"],"properties":{"a":{"type":"string"},"b":{"$ref":"BaseType.json"}}}`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+	if files[0].Filename != "openapi.json" {
+		t.Errorf("first file is %s; want openapi.json", files[0].Filename)
+	}
+}
+
+func TestGenerateCRDs(t *testing.T) {
+	inst := load.Instances([]string{"crd.cue"}, &load.Config{
+		Dir: "./testdata",
+	})[0]
+	ctx := cuecontext.New()
+	v := ctx.BuildInstance(inst)
+	if err := v.Err(); err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	files, err := openapi.GenerateCRDs(v, nil)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	var out bytes.Buffer
+	for _, f := range files {
+		b, err := yaml.Encode(ctx.BuildFile(f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out.WriteString("---\n")
+		out.Write(b)
+	}
+
+	wantFile := filepath.Join("testdata", "crd.yaml")
+	if cuetest.UpdateGoldenFiles {
+		_ = os.WriteFile(wantFile, out.Bytes(), 0644)
+		return
+	}
+	b, err := os.ReadFile(wantFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(string(b), out.String()); d != "" {
+		t.Errorf("files differ:\n%v", d)
+	}
+}
+
+func TestGenerateCRDsErrors(t *testing.T) {
+	testCases := []struct {
+		in  string
+		err string
+	}{{
+		in:  `#A: {} @crd(kind=A, version=v1)`,
+		err: "missing group",
+	}, {
+		in: `
+		#A1: {} @crd(group=g, kind=A, version=v1)
+		#A2: {} @crd(group=g, kind=A, version=v2)
+		`,
+		err: "exactly one version must be marked as storage version, found 0",
+	}, {
+		in:  `#A: {} @crd(group=g, kind=A, version=v1, scope=Global)`,
+		err: `invalid scope "Global"`,
+	}, {
+		in:  `#A: {}`,
+		err: "no definitions with @crd attribute",
+	}}
+	for _, tc := range testCases {
+		v := cuecontext.New().CompileString(tc.in)
+		_, err := openapi.GenerateCRDs(v, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %v; want %q", tc.in, err, tc.err)
+		}
+	}
+}
+
 // TODO: move OpenAPI testing to txtar and allow errors.
 func TestIssue1234(t *testing.T) {
 	var r cue.Runtime
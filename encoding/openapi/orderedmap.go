@@ -159,6 +159,13 @@ func (m *OrderedMap) SetAll(kvs []KeyValue) {
 	m.Elts = a
 }
 
+// remove removes the key-value pair for the given key, if it exists.
+func (m *OrderedMap) remove(key string) {
+	if f := m.find(key); f != nil {
+		m.Elts = removeField(m.Elts, f)
+	}
+}
+
 // exists reports whether a key-value pair exists for the given key.
 func (m *OrderedMap) exists(key string) bool {
 	return m.find(key) != nil
@@ -0,0 +1,40 @@
+package crd
+
+#Spec: {
+	// Replicas is the number of desired pods.
+	replicas: *1 | int
+	port:     int | string
+	labels: [string]: string
+	extra: {...}
+	config: _
+	containers: [...{
+		name:  string
+		image: string
+	}] @crd(listType=map,listMapKey=name)
+	template?: {...} @crd(embeddedResource)
+}
+
+// A Foo runs containers.
+#FooV1: {
+	apiVersion: "example.com/v1"
+	kind:       "Foo"
+	metadata: {
+		name: string
+		...
+	}
+	spec: #Spec & {
+		replicas: >=0 @crd(rule="self >= 0", message="must not be negative")
+	}
+	status?: ready: bool
+} @crd(group="example.com", kind=Foo, version=v1, storage, status, shortName=fo)
+
+#FooV1beta1: {
+	apiVersion: "example.com/v1beta1"
+	kind:       "Foo"
+	metadata: {...}
+	spec: replicas: int
+} @crd(group="example.com", kind=Foo, version=v1beta1, served=false)
+
+#Bar: {
+	spec: size: int
+} @crd(group="example.com", kind=Bar, version=v1alpha1, scope=Cluster, plural=barren)
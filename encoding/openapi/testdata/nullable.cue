@@ -0,0 +1,29 @@
+info: {
+	title:   "Nullable values"
+	version: "v1"
+	license: {
+		name:       "Apache 2.0"
+		identifier: "Apache-2.0"
+	}
+}
+
+webhooks: newPet: post: {
+	requestBody: description: "Information about a new pet."
+	responses: "200": description: "Return a 200 status to acknowledge receipt."
+}
+
+#Null: null
+
+#Int: int | null
+
+#Enum: "a" | "b" | null
+
+// A pet, if any.
+#Ref: #Pet | null
+
+#Pet: {
+	name: string
+	tags: [string]: string
+}
+
+#Pets: [string]: #Pet | null
@@ -0,0 +1,864 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/protobuf/pbinternal"
+	"cuelang.org/go/internal"
+)
+
+// Option defines options for the decoder.
+// There are currently no options.
+type Option func(*options)
+
+type options struct {
+}
+
+// NewDecoder returns a new Decoder.
+func NewDecoder(option ...Option) *Decoder {
+	return &Decoder{}
+}
+
+// A Decoder converts binary protocol buffer messages to CUE.
+type Decoder struct {
+}
+
+type decoder struct {
+	file *token.File
+	errs errors.Error
+}
+
+// Parse decodes the binary protocol buffer message b and converts it to a CUE
+// expression, using schema to determine the names and types of its fields:
+//
+//   - fields are identified by the field number of their @protobuf
+//     attribute; fields without such an attribute and fields of the message
+//     that have no corresponding field in schema are ignored,
+//   - enum values are converted to the name of the value, using the
+//     #enumValue of the values of the enum, or to an integer if the
+//     schema represents the enum as an integer,
+//   - the well-known types Timestamp, Duration, the wrapper types, Struct,
+//     Value and ListValue are converted following the protobuf JSON mapping,
+//     where Timestamp and Duration map to strings,
+//   - a message field that occurs more than once is merged, and for other
+//     non-repeated fields the last value is used.
+//
+// The filename is used for associating position information, where the
+// column of a position is the byte offset of a value plus one.
+func (d *Decoder) Parse(schema cue.Value, filename string, b []byte) (ast.Expr, error) {
+	if !schema.Exists() {
+		return nil, errors.Newf(token.NoPos, "binpb: a schema is needed to decode %s", filename)
+	}
+	// The end position of a literal is computed from its length, which may
+	// be up to six times the length of its encoding for quoted strings and
+	// bytes, or a constant length for numbers and times. Size the file such
+	// that these positions stay within bounds.
+	dec := &decoder{file: token.NewFile(filename, -1, 7*len(b)+64)}
+
+	m := dec.mapping(schema)
+	if dec.errs != nil {
+		return nil, dec.errs
+	}
+	x := dec.decodeMsg(m, []chunk{{b: b}})
+	if dec.errs != nil {
+		return nil, dec.errs
+	}
+	return x, nil
+}
+
+func (d *decoder) addErr(err error) {
+	d.errs = errors.Append(d.errs, errors.Promote(err, "binpb"))
+}
+
+func (d *decoder) errf(off int, format string, args ...interface{}) error {
+	return errors.Newf(d.pos(off), "binpb: "+format, args...)
+}
+
+func (d *decoder) addErrf(off int, format string, args ...interface{}) {
+	d.errs = errors.Append(d.errs, d.errf(off, format, args...).(errors.Error))
+}
+
+func (d *decoder) pos(off int) token.Pos {
+	return d.file.Pos(off, token.NoRelPos)
+}
+
+// A mapping holds the fields of a message by field number.
+type mapping struct {
+	fields []*fieldInfo // in schema order
+	byNum  map[int]*fieldInfo
+}
+
+type fieldInfo struct {
+	pbinternal.Info
+
+	num int
+	typ string // the protobuf type of the field, list element or map value
+
+	msg   *mapping         // the fields of a message type, computed lazily
+	enums map[int64]string // the names of enum values, computed lazily
+}
+
+// mapping returns the fields of the message with the given schema.
+func (d *decoder) mapping(schema cue.Value) *mapping {
+	m := &mapping{byNum: map[int]*fieldInfo{}}
+	d.addFields(m, schema)
+	return m
+}
+
+// addFields adds the fields of v to m. The fields of oneofs are part of
+// disjunctions embedded in v, so the operands of conjunctions and
+// disjunctions are visited as well.
+func (d *decoder) addFields(m *mapping, v cue.Value) {
+	switch op, args := v.Expr(); op {
+	case cue.AndOp, cue.OrOp:
+		for _, a := range args {
+			d.addFields(m, a)
+		}
+		return
+	}
+	if v.IncompleteKind() != cue.StructKind {
+		return
+	}
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		d.addErr(err)
+		return
+	}
+	for iter.Next() {
+		info, err := pbinternal.FromIter(iter)
+		if err != nil {
+			d.addErr(err)
+			continue
+		}
+		if info.Attr.Err() != nil {
+			continue // no field number
+		}
+		n, err := info.Attr.Int(0)
+		if err != nil {
+			d.addErr(err)
+			continue
+		}
+		num := int(n)
+		if m.byNum[num] != nil {
+			continue
+		}
+		typ := info.Type
+		if info.CompositeType == pbinternal.Map {
+			typ = strings.TrimSpace(typ[strings.IndexByte(typ, ']')+1:])
+		}
+		f := &fieldInfo{Info: info, num: num, typ: typ}
+		m.fields = append(m.fields, f)
+		m.byNum[num] = f
+	}
+}
+
+// message returns the fields of the message type of f.
+func (d *decoder) message(f *fieldInfo) *mapping {
+	if f.msg == nil {
+		f.msg = d.mapping(f.Value)
+	}
+	return f.msg
+}
+
+var enumValuePath = cue.ParsePath("#enumValue")
+
+// enum returns the name of the value n of the enum type of f.
+func (d *decoder) enum(f *fieldInfo, n int64) (string, bool) {
+	if f.enums == nil {
+		f.enums = map[int64]string{}
+		// The field refers to the definition of the enum, which holds the
+		// numeric values.
+		v := cue.Dereference(f.Value)
+		op, values := v.Expr()
+		if op != cue.OrOp {
+			values = []cue.Value{v}
+		}
+		for _, v := range values {
+			i, err := v.LookupPath(enumValuePath).Int64()
+			if err != nil {
+				continue
+			}
+			if s, err := v.String(); err == nil {
+				f.enums[i] = s
+			}
+		}
+	}
+	s, ok := f.enums[n]
+	return s, ok
+}
+
+// A chunk is an encoded message and its offset in the file.
+type chunk struct {
+	off int
+	b   []byte
+}
+
+func (d *decoder) decodeMsg(m *mapping, chunks []chunk) *ast.StructLit {
+	values := map[*fieldInfo]ast.Expr{}
+	maps := map[*fieldInfo]map[string]*ast.Field{}
+	messages := map[*fieldInfo][]chunk{}
+
+	for _, c := range chunks {
+		r := &reader{b: c.b, off: c.off}
+		for !r.done() {
+			num, v, err := d.field(r)
+			if err != nil {
+				d.addErr(err)
+				return ast.NewStruct()
+			}
+			f := m.byNum[num]
+			if f == nil {
+				continue // ignore unknown fields
+			}
+
+			switch f.CompositeType {
+			case pbinternal.List:
+				list, _ := values[f].(*ast.ListLit)
+				if list == nil {
+					list = &ast.ListLit{}
+					values[f] = list
+				}
+				list.Elts = append(list.Elts, d.repeated(f, v)...)
+
+			case pbinternal.Map:
+				s, _ := values[f].(*ast.StructLit)
+				if s == nil {
+					s = ast.NewStruct()
+					values[f] = s
+					maps[f] = map[string]*ast.Field{}
+				}
+				d.mapEntry(s, maps[f], f, v)
+
+			default:
+				if isMessage(f, f.typ) {
+					// Occurrences of a message field are merged.
+					if d.checkWire(f, v, lenWire) {
+						messages[f] = append(messages[f], chunk{v.off, v.b})
+						values[f] = nil
+					}
+					continue
+				}
+				if x := d.value(f, f.typ, v); x != nil {
+					values[f] = x
+				}
+			}
+		}
+	}
+	for f, chunks := range messages {
+		values[f] = d.decodeMsg(d.message(f), chunks)
+	}
+
+	s := ast.NewStruct()
+	for _, f := range m.fields {
+		if x, ok := values[f]; ok && x != nil {
+			s.Elts = append(s.Elts, &ast.Field{Label: label(f.CUEName), Value: x})
+		}
+	}
+	return s
+}
+
+// repeated decodes a value of the repeated field f, which may hold multiple
+// packed values.
+func (d *decoder) repeated(f *fieldInfo, v rawValue) []ast.Expr {
+	if wt, ok := packedWire(f, f.typ); ok && v.wt == lenWire {
+		var a []ast.Expr
+		r := &reader{b: v.b, off: v.off}
+		for !r.done() {
+			x := rawValue{off: r.pos(), wt: wt}
+			var ok bool
+			switch wt {
+			case varint:
+				x.u, ok = r.varint()
+			case i32:
+				x.u, ok = r.fixed(4)
+			case i64:
+				x.u, ok = r.fixed(8)
+			}
+			if !ok {
+				d.addErrf(x.off, "truncated packed value of field %s", f.Name)
+				break
+			}
+			if e := d.value(f, f.typ, x); e != nil {
+				a = append(a, e)
+			}
+		}
+		return a
+	}
+	if x := d.value(f, f.typ, v); x != nil {
+		return []ast.Expr{x}
+	}
+	return nil
+}
+
+// mapEntry decodes the map entry v of the map field f and adds it to s. The
+// entries of s are indexed by key in entries.
+func (d *decoder) mapEntry(s *ast.StructLit, entries map[string]*ast.Field, f *fieldInfo, v rawValue) {
+	if !d.checkWire(f, v, lenWire) {
+		return
+	}
+	var (
+		key interface{}
+		val ast.Expr
+	)
+	r := &reader{b: v.b, off: v.off}
+	for !r.done() {
+		num, x, err := d.field(r)
+		if err != nil {
+			d.addErr(err)
+			return
+		}
+		switch num {
+		case 1:
+			if !d.checkWire(f, x, scalarWire[f.KeyTypeString]) {
+				return
+			}
+			key = d.scalar(f.KeyTypeString, x)
+		case 2:
+			val = d.value(f, f.typ, x)
+		}
+	}
+	if key == nil {
+		key = d.scalar(f.KeyTypeString, rawValue{wt: scalarWire[f.KeyTypeString]})
+	}
+	if val == nil {
+		// A missing value is the zero value of its type.
+		val = d.value(f, f.typ, rawValue{off: v.off, wt: wireType(f, f.typ)})
+	}
+	if key == nil || val == nil {
+		return
+	}
+	k := fmt.Sprint(key)
+	if e := entries[k]; e != nil {
+		e.Value = val
+		return
+	}
+	e := &ast.Field{Label: label(k), Value: val}
+	entries[k] = e
+	s.Elts = append(s.Elts, e)
+}
+
+// value decodes v as a value of the protobuf type typ of field f.
+func (d *decoder) value(f *fieldInfo, typ string, v rawValue) ast.Expr {
+	if !d.checkWire(f, v, wireType(f, typ)) {
+		return nil
+	}
+	var x ast.Expr
+	switch {
+	case isMessage(f, typ):
+		return d.decodeMsg(d.message(f), []chunk{{v.off, v.b}})
+
+	case strings.HasPrefix(typ, "google.protobuf."):
+		x = d.wellKnown(typ, v)
+
+	case isScalar(typ):
+		x = toExpr(d.scalar(typ, v))
+
+	default:
+		// An enum.
+		n := int64(int32(v.u))
+		if f.Value.IncompleteKind()&cue.StringKind == 0 {
+			x = ast.NewLit(token.INT, strconv.FormatInt(n, 10))
+			break
+		}
+		s, ok := d.enum(f, n)
+		if !ok {
+			d.addErrf(v.off, "unknown value %d for enum %s of field %s", n, typ, f.Name)
+			return nil
+		}
+		x = ast.NewString(s)
+	}
+	if x != nil {
+		ast.SetPos(x, d.pos(v.off))
+	}
+	return x
+}
+
+// scalar returns the Go value of v, which has the scalar protobuf type typ,
+// or nil if v is not valid.
+func (d *decoder) scalar(typ string, v rawValue) interface{} {
+	switch typ {
+	case "int32":
+		return int64(int32(v.u))
+	case "int64":
+		return int64(v.u)
+	case "uint32":
+		return uint64(uint32(v.u))
+	case "uint64", "fixed64":
+		return v.u
+	case "sint32":
+		x := uint32(v.u)
+		return int64(int32(x>>1) ^ -int32(x&1))
+	case "sint64":
+		return int64(v.u>>1) ^ -int64(v.u&1)
+	case "bool":
+		return v.u != 0
+	case "fixed32":
+		return uint64(uint32(v.u))
+	case "sfixed32":
+		return int64(int32(uint32(v.u)))
+	case "sfixed64":
+		return int64(v.u)
+	case "float":
+		return d.float(float64(math.Float32frombits(uint32(v.u))), 32, v.off)
+	case "double":
+		return d.float(math.Float64frombits(v.u), 64, v.off)
+	case "string":
+		if !utf8.Valid(v.b) {
+			d.addErrf(v.off, "invalid UTF-8 in string")
+			return nil
+		}
+		return string(v.b)
+	case "bytes":
+		return v.b
+	}
+	return nil
+}
+
+// A float is a floating point number formatted as a CUE float literal.
+type float string
+
+func (d *decoder) float(f float64, bitSize, off int) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		d.addErrf(off, "cannot represent %v in CUE", f)
+		return nil
+	}
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return float(s)
+}
+
+func toExpr(x interface{}) ast.Expr {
+	switch x := x.(type) {
+	case int64:
+		return ast.NewLit(token.INT, strconv.FormatInt(x, 10))
+	case uint64:
+		return ast.NewLit(token.INT, strconv.FormatUint(x, 10))
+	case bool:
+		return ast.NewBool(x)
+	case float:
+		return ast.NewLit(token.FLOAT, string(x))
+	case string:
+		return ast.NewString(x)
+	case []byte:
+		return ast.NewLit(token.STRING, literal.Bytes.Quote(string(x)))
+	}
+	return nil
+}
+
+// wrappers maps the wrapper types to the types of the values they wrap.
+var wrappers = map[string]string{
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int64Value":  "int64",
+	"google.protobuf.UInt64Value": "uint64",
+	"google.protobuf.Int32Value":  "int32",
+	"google.protobuf.UInt32Value": "uint32",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.BytesValue":  "bytes",
+}
+
+// wellKnown decodes v as a value of the well-known type typ.
+func (d *decoder) wellKnown(typ string, v rawValue) ast.Expr {
+	if typ == "google.protobuf.NullValue" {
+		return ast.NewNull()
+	}
+	fields, err := d.fields(v)
+	if err != nil {
+		d.addErr(err)
+		return nil
+	}
+	last := func(num int, wt wire) (rawValue, bool) {
+		a := fields[num]
+		if len(a) == 0 {
+			return rawValue{off: v.off, wt: wt}, true
+		}
+		x := a[len(a)-1]
+		if x.wt != wt {
+			d.addErrf(x.off, "invalid wire type %d in %s", x.wt, typ)
+			return x, false
+		}
+		return x, true
+	}
+
+	if t, ok := wrappers[typ]; ok {
+		x, ok := last(1, scalarWire[t])
+		if !ok {
+			return nil
+		}
+		return toExpr(d.scalar(t, x))
+	}
+
+	switch typ {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration":
+		s, ok1 := last(1, varint)
+		n, ok2 := last(2, varint)
+		if !ok1 || !ok2 {
+			return nil
+		}
+		secs, nanos := int64(s.u), int64(int32(n.u))
+		if typ == "google.protobuf.Duration" {
+			return ast.NewString(formatDuration(secs, nanos))
+		}
+		if nanos < 0 || nanos >= 1e9 {
+			d.addErrf(v.off, "invalid nanos %d in %s", nanos, typ)
+			return nil
+		}
+		t := time.Unix(secs, nanos).UTC()
+		return ast.NewString(t.Format(time.RFC3339Nano))
+
+	case "google.protobuf.Struct":
+		s := ast.NewStruct()
+		entries := map[string]*ast.Field{}
+		for _, x := range fields[1] {
+			d.structEntry(s, entries, x)
+		}
+		return s
+
+	case "google.protobuf.ListValue":
+		list := &ast.ListLit{}
+		for _, x := range fields[1] {
+			if e := d.wellKnownMsg("google.protobuf.Value", x); e != nil {
+				list.Elts = append(list.Elts, e)
+			}
+		}
+		return list
+
+	case "google.protobuf.Value":
+		kinds := map[int]string{
+			1: "google.protobuf.NullValue",
+			2: "double",
+			3: "string",
+			4: "bool",
+			5: "google.protobuf.Struct",
+			6: "google.protobuf.ListValue",
+		}
+		// The last field of the oneof that is set is used.
+		var kind rawValue
+		num := 0
+		for n, a := range fields {
+			if x := a[len(a)-1]; kinds[n] != "" && (num == 0 || x.off > kind.off) {
+				num, kind = n, x
+			}
+		}
+		if num == 0 {
+			d.addErrf(v.off, "no value set in %s", typ)
+			return nil
+		}
+		t := kinds[num]
+		switch num {
+		case 1:
+			return ast.NewNull()
+		case 5, 6:
+			return d.wellKnownMsg(t, kind)
+		}
+		if kind.wt != scalarWire[t] {
+			d.addErrf(kind.off, "invalid wire type %d in %s", kind.wt, typ)
+			return nil
+		}
+		return toExpr(d.scalar(t, kind))
+	}
+
+	d.addErrf(v.off, "unsupported type %s", typ)
+	return nil
+}
+
+// wellKnownMsg decodes v as a value of the well-known message type typ.
+func (d *decoder) wellKnownMsg(typ string, v rawValue) ast.Expr {
+	if v.wt != lenWire {
+		d.addErrf(v.off, "invalid wire type %d for %s", v.wt, typ)
+		return nil
+	}
+	x := d.wellKnown(typ, v)
+	if x != nil {
+		ast.SetPos(x, d.pos(v.off))
+	}
+	return x
+}
+
+// structEntry decodes v as an entry of a google.protobuf.Struct and adds it
+// to s.
+func (d *decoder) structEntry(s *ast.StructLit, entries map[string]*ast.Field, v rawValue) {
+	if v.wt != lenWire {
+		d.addErrf(v.off, "invalid wire type %d for google.protobuf.Struct", v.wt)
+		return
+	}
+	fields, err := d.fields(v)
+	if err != nil {
+		d.addErr(err)
+		return
+	}
+	var key string
+	if a := fields[1]; len(a) > 0 {
+		k, ok := d.scalar("string", a[len(a)-1]).(string)
+		if !ok {
+			return
+		}
+		key = k
+	}
+	var val ast.Expr = ast.NewNull()
+	if a := fields[2]; len(a) > 0 {
+		if val = d.wellKnownMsg("google.protobuf.Value", a[len(a)-1]); val == nil {
+			return
+		}
+	}
+	if e := entries[key]; e != nil {
+		e.Value = val
+		return
+	}
+	e := &ast.Field{Label: label(key), Value: val}
+	entries[key] = e
+	s.Elts = append(s.Elts, e)
+}
+
+// fields returns the values of the fields of the message v by field number.
+func (d *decoder) fields(v rawValue) (map[int][]rawValue, error) {
+	fields := map[int][]rawValue{}
+	r := &reader{b: v.b, off: v.off}
+	for !r.done() {
+		num, x, err := d.field(r)
+		if err != nil {
+			return nil, err
+		}
+		fields[num] = append(fields[num], x)
+	}
+	return fields, nil
+}
+
+// formatDuration formats a duration like the protobuf JSON mapping.
+func formatDuration(secs, nanos int64) string {
+	sign := ""
+	if secs < 0 || nanos < 0 {
+		sign, secs, nanos = "-", -secs, -nanos
+	}
+	s := fmt.Sprintf("%s%d", sign, secs)
+	if nanos != 0 {
+		frac := strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")
+		s += "." + frac
+	}
+	return s + "s"
+}
+
+// isMessage reports whether typ is a message type that is decoded using the
+// fields of the schema of f.
+func isMessage(f *fieldInfo, typ string) bool {
+	if strings.HasPrefix(typ, "google.protobuf.") {
+		// Only Empty is represented as a regular message.
+		return typ == "google.protobuf.Empty"
+	}
+	return f.ValueType == pbinternal.Message
+}
+
+// wireType returns the wire type of the values of the protobuf type typ of
+// field f.
+func wireType(f *fieldInfo, typ string) wire {
+	if wt, ok := scalarWire[typ]; ok {
+		return wt
+	}
+	if isMessage(f, typ) || strings.HasPrefix(typ, "google.protobuf.") &&
+		typ != "google.protobuf.NullValue" {
+		return lenWire
+	}
+	return varint // an enum
+}
+
+// packedWire returns the wire type of the values of the repeated field f if
+// its values may be packed.
+func packedWire(f *fieldInfo, typ string) (wire, bool) {
+	switch wt := wireType(f, typ); {
+	case wt != lenWire:
+		return wt, true
+	}
+	return 0, false
+}
+
+func (d *decoder) checkWire(f *fieldInfo, v rawValue, want wire) bool {
+	if v.wt != want {
+		d.addErrf(v.off, "invalid wire type %d for field %s of type %s", v.wt, f.Name, f.Type)
+		return false
+	}
+	return true
+}
+
+func isScalar(typ string) bool {
+	_, ok := scalarWire[typ]
+	return ok
+}
+
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !internal.IsDefOrHidden(name) {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+// A wire is a protobuf wire type.
+type wire uint8
+
+const (
+	varint   wire = 0
+	i64      wire = 1
+	lenWire  wire = 2
+	sgroup   wire = 3
+	egroup   wire = 4
+	i32      wire = 5
+	maxField      = 1<<29 - 1
+)
+
+// scalarWire maps scalar protobuf types to their wire type.
+var scalarWire = map[string]wire{
+	"int32":    varint,
+	"int64":    varint,
+	"uint32":   varint,
+	"uint64":   varint,
+	"sint32":   varint,
+	"sint64":   varint,
+	"bool":     varint,
+	"fixed32":  i32,
+	"sfixed32": i32,
+	"float":    i32,
+	"fixed64":  i64,
+	"sfixed64": i64,
+	"double":   i64,
+	"string":   lenWire,
+	"bytes":    lenWire,
+}
+
+// A rawValue is a value read from the wire.
+type rawValue struct {
+	off int  // the offset of the value in the file
+	wt  wire // the wire type
+	u   uint64
+	b   []byte
+}
+
+type reader struct {
+	b   []byte
+	off int // the offset of b in the file
+	i   int
+}
+
+func (r *reader) done() bool { return r.i >= len(r.b) }
+
+func (r *reader) pos() int { return r.off + r.i }
+
+func (r *reader) varint() (uint64, bool) {
+	var x uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if r.done() {
+			return 0, false
+		}
+		c := r.b[r.i]
+		r.i++
+		x |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			return x, true
+		}
+	}
+	return 0, false
+}
+
+func (r *reader) fixed(n int) (uint64, bool) {
+	if len(r.b)-r.i < n {
+		return 0, false
+	}
+	b := r.b[r.i : r.i+n]
+	r.i += n
+	if n == 4 {
+		return uint64(binary.LittleEndian.Uint32(b)), true
+	}
+	return binary.LittleEndian.Uint64(b), true
+}
+
+// field reads the next field of a message from r and returns its number and
+// value. Groups are skipped and reported with their start group wire type.
+func (d *decoder) field(r *reader) (num int, v rawValue, err error) {
+	start := r.pos()
+	num, v, err = d.next(r)
+	if err == nil && v.wt == egroup {
+		return 0, v, d.errf(start, "unexpected end of group for field %d", num)
+	}
+	return num, v, err
+}
+
+// next reads the next field key and value from r.
+func (d *decoder) next(r *reader) (num int, v rawValue, err error) {
+	start := r.pos()
+	key, ok := r.varint()
+	if !ok {
+		return 0, v, d.errf(start, "invalid field key")
+	}
+	n := key >> 3
+	if n == 0 || n > maxField {
+		return 0, v, d.errf(start, "invalid field number %d", n)
+	}
+	num = int(n)
+	v.wt = wire(key & 7)
+	v.off = r.pos()
+	switch v.wt {
+	case varint:
+		v.u, ok = r.varint()
+	case i64:
+		v.u, ok = r.fixed(8)
+	case i32:
+		v.u, ok = r.fixed(4)
+	case lenWire:
+		var l uint64
+		l, ok = r.varint()
+		if ok && l <= uint64(len(r.b)-r.i) {
+			v.off = r.pos()
+			v.b = r.b[r.i : r.i+int(l)]
+			r.i += int(l)
+		} else {
+			ok = false
+		}
+	case sgroup:
+		for {
+			if r.done() {
+				return 0, v, d.errf(start, "unterminated group for field %d", num)
+			}
+			n, x, err := d.next(r)
+			if err != nil {
+				return 0, v, err
+			}
+			if x.wt == egroup && n == num {
+				return num, v, nil
+			}
+		}
+	case egroup:
+		// The end of a group is handled by the caller.
+	default:
+		return 0, v, d.errf(start, "invalid wire type %d for field %d", v.wt, num)
+	}
+	if !ok {
+		return 0, v, d.errf(start, "truncated value for field %d", num)
+	}
+	return num, v, nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binpb_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/encoding/protobuf"
+	"cuelang.org/go/encoding/protobuf/binpb"
+	"cuelang.org/go/internal"
+)
+
+const schema = `
+syntax = "proto3";
+package example;
+
+import "google/protobuf/duration.proto";
+import "google/protobuf/struct.proto";
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+
+message Person {
+  string name = 1;
+  int32 id = 2;
+  repeated string emails = 3;
+  enum Kind {
+    UNKNOWN = 0;
+    HUMAN = 1;
+    ROBOT = 2;
+  }
+  Kind kind = 4;
+  message Phone {
+    string number = 1;
+    sint64 ext = 2;
+  }
+  repeated Phone phones = 5;
+  map<string, int32> scores = 6;
+  oneof contact {
+    string email = 7;
+    Phone phone = 8;
+  }
+  google.protobuf.Timestamp born = 9;
+  repeated int32 lucky = 10;
+  double height = 11;
+  bytes avatar = 12;
+  bool active = 13;
+  fixed32 f32 = 14;
+  sfixed64 sf64 = 15;
+  float ratio = 16;
+  uint64 big = 17;
+  google.protobuf.Duration uptime = 18;
+  google.protobuf.Int32Value age = 19;
+  google.protobuf.Struct extra = 20;
+  map<int32, Phone> directory = 21;
+  repeated Kind kinds = 22;
+}
+`
+
+// Helpers for encoding the wire format.
+
+func key(num, wt int) []byte { return varint(uint64(num)<<3 | uint64(wt)) }
+
+func varint(v uint64) []byte { return binary.AppendUvarint(nil, v) }
+
+func vfield(num int, v uint64) []byte { return cat(key(num, 0), varint(v)) }
+
+func lfield(num int, b ...[]byte) []byte {
+	m := cat(b...)
+	return cat(key(num, 2), varint(uint64(len(m))), m)
+}
+
+func sfield(num int, s string) []byte { return lfield(num, []byte(s)) }
+
+func f32field(num int, v uint32) []byte {
+	return cat(key(num, 5), binary.LittleEndian.AppendUint32(nil, v))
+}
+
+func f64field(num int, v uint64) []byte {
+	return cat(key(num, 1), binary.LittleEndian.AppendUint64(nil, v))
+}
+
+func zigzag(v int64) uint64 { return uint64(v<<1) ^ uint64(v>>63) }
+
+func cat(b ...[]byte) []byte {
+	var out []byte
+	for _, x := range b {
+		out = append(out, x...)
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	f, err := protobuf.Extract("person.proto", schema, &protobuf.Config{
+		Paths: []string{"../testdata"},
+	})
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	v := cuecontext.New().BuildFile(f)
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+	person := v.LookupPath(cue.ParsePath("#Person"))
+
+	testCases := []struct {
+		name string
+		in   []byte
+		out  string
+		err  string
+	}{{
+		name: "empty",
+		in:   nil,
+		out:  "",
+	}, {
+		name: "scalars",
+		in: cat(
+			sfield(1, "Ada"),
+			vfield(2, math.MaxUint64), // -1 encoded as 10 bytes
+			sfield(3, "a@example.com"),
+			sfield(3, "b@example.com"),
+			vfield(4, 2),
+			f64field(11, math.Float64bits(1.5)),
+			lfield(12, []byte{0, 1, 0xff}),
+			vfield(13, 1),
+			f32field(14, 7),
+			f64field(15, uint64(math.MaxUint64-1)), // -2
+			f32field(16, math.Float32bits(2)),
+			vfield(17, math.MaxUint64),
+			vfield(99, 5), // unknown field
+		),
+		out: `
+name: "Ada"
+id:   -1
+emails: ["a@example.com", "b@example.com"]
+kind:   "ROBOT"
+height: 1.5
+avatar: '\x00\x01\xff'
+active: true
+f32:    7
+sf64:   -2
+ratio:  2.0
+big:    18446744073709551615
+`,
+	}, {
+		name: "messages",
+		in: cat(
+			lfield(5, sfield(1, "123"), vfield(2, zigzag(-42))),
+			lfield(5, sfield(1, "456")),
+			// Occurrences of a message field are merged.
+			lfield(8, sfield(1, "789")),
+			lfield(8, vfield(2, zigzag(7))),
+			lfield(21, vfield(1, 3), lfield(2, sfield(1, "000"))),
+			lfield(21, vfield(1, 4)),
+		),
+		out: `
+phone: {
+	number: "789"
+	ext:    7
+}
+phones: [{
+	number: "123"
+	ext:    -42
+}, {
+	number: "456"
+}]
+directory: {
+	"3": {
+		number: "000"
+	}
+	"4": {}
+}
+`,
+	}, {
+		name: "maps",
+		in: cat(
+			lfield(6, sfield(1, "math"), vfield(2, 90)),
+			lfield(6, sfield(1, "art"), vfield(2, 80)),
+			lfield(6, sfield(1, "math"), vfield(2, 95)),
+			lfield(6, sfield(1, "none")),
+		),
+		out: `
+scores: {
+	math: 95
+	art:  80
+	none: 0
+}
+`,
+	}, {
+		name: "packed",
+		in: cat(
+			lfield(10, varint(1), varint(300), varint(math.MaxUint64)),
+			vfield(10, 4),
+			lfield(22, varint(0), varint(1)),
+		),
+		out: `
+lucky: [1, 300, -1, 4]
+kinds: ["UNKNOWN", "HUMAN"]
+`,
+	}, {
+		name: "well-known types",
+		in: cat(
+			lfield(9, vfield(1, 1700000000), vfield(2, 500)),
+			lfield(18, vfield(1, 90), vfield(2, 500000000)),
+			lfield(19, vfield(1, 42)),
+			lfield(20,
+				lfield(1, sfield(1, "n"), lfield(2, f64field(2, math.Float64bits(3)))),
+				lfield(1, sfield(1, "s"), lfield(2, sfield(3, "x"))),
+				lfield(1, sfield(1, "z"), lfield(2, vfield(1, 0))),
+				lfield(1, sfield(1, "l"), lfield(2, lfield(6,
+					lfield(1, vfield(4, 1)),
+					lfield(1, lfield(5, lfield(1, sfield(1, "b"), lfield(2, vfield(4, 0))))),
+				))),
+			),
+		),
+		out: `
+born:   "2023-11-14T22:13:20.0000005Z"
+uptime: "90.5s"
+age:    42
+extra: {
+	n: 3.0
+	s: "x"
+	z: null
+	l: [true, {
+		b: false
+	}]
+}
+`,
+	}, {
+		name: "negative duration",
+		in:   lfield(18, vfield(1, uint64(math.MaxUint64)), vfield(2, uint64(1<<64-250000000))),
+		out:  `uptime: "-1.25s"`,
+	}, {
+		name: "unknown enum value",
+		in:   vfield(4, 7),
+		err:  "person.binpb:1:2: binpb: unknown value 7 for enum Kind of field kind",
+	}, {
+		name: "wrong wire type",
+		in:   vfield(1, 3),
+		err:  "person.binpb:1:2: binpb: invalid wire type 0 for field name of type string",
+	}, {
+		name: "truncated",
+		in:   cat(vfield(2, 1), key(1, 2), varint(10), []byte("abc")),
+		err:  "person.binpb:1:3: binpb: truncated value for field 1",
+	}, {
+		name: "invalid field number",
+		in:   key(0, 0),
+		err:  "person.binpb:1:1: binpb: invalid field number 0",
+	}, {
+		name: "skip groups",
+		in:   cat(key(30, 3), vfield(1, 1), key(31, 3), key(31, 4), key(30, 4), sfield(1, "x")),
+		out:  `name: "x"`,
+	}, {
+		name: "unterminated group",
+		in:   cat(key(30, 3), vfield(1, 1)),
+		err:  "person.binpb:1:1: binpb: unterminated group for field 30",
+	}, {
+		name: "invalid UTF-8",
+		in:   sfield(1, "\xff"),
+		err:  "person.binpb:1:3: binpb: invalid UTF-8 in string",
+	}, {
+		name: "NaN",
+		in:   f64field(11, math.Float64bits(math.NaN())),
+		err:  "person.binpb:1:2: binpb: cannot represent NaN in CUE",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			x, err := binpb.NewDecoder().Parse(person, "person.binpb", tc.in)
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				e := errors.Errors(err)[0]
+				if got := fmt.Sprintf("%v: %v", e.Position(), e); got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(internal.ToFile(x))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSpace(string(b)), strings.TrimSpace(tc.out); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseNoSchema(t *testing.T) {
+	_, err := binpb.NewDecoder().Parse(cue.Value{}, "x.binpb", nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
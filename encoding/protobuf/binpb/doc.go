@@ -0,0 +1,25 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package binpb converts messages in the protocol buffer binary wire format
+// to CUE.
+//
+// The wire format does not record field names or types, so a message can
+// only be decoded with a schema. The schema is the CUE definition of the
+// message as obtained by converting a .proto file with the protobuf package,
+// which records the field number and protobuf type of each field in a
+// @protobuf attribute.
+//
+// API Status: DRAFT: API may change without notice.
+package binpb
@@ -0,0 +1,783 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/emicklei/proto"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A GenerateConfig specifies how to generate a proto definition file from
+// CUE definitions.
+type GenerateConfig struct {
+	// Package is the proto package of the generated file.
+	Package string
+
+	// GoPackage, if set, is the value of the go_package option of the
+	// generated file.
+	GoPackage string
+
+	// Previous holds the contents of a .proto file previously generated
+	// from the same definitions. If set, fields and enum values keep the
+	// numbers they were assigned in Previous, and the numbers and names of
+	// fields that were removed since are reserved.
+	Previous []byte
+}
+
+// Generate generates a proto3 definition file from the definitions in v.
+// It is the inverse of Extract: the CUE generated by Extract converts back
+// to an equivalent .proto file.
+//
+// Each definition of a struct is converted to a message, and each
+// definition of a disjunction of strings is converted to an enum. Nested
+// definitions are converted to nested messages and enums. Fields are
+// converted as follows:
+//
+//	CUE type                 Proto type
+//	bool                     bool
+//	string                   string
+//	bytes                    bytes
+//	int32, uint32            int32, uint32
+//	int64, uint64            int64, uint64
+//	int, uint                int64, uint64
+//	float32                  float
+//	float64, float, number   double
+//	time.Time                google.protobuf.Timestamp
+//	time.Duration            google.protobuf.Duration
+//	[...T]                   repeated T
+//	{[string]: T}            map<string, T>
+//	#Def                     the message or enum for #Def
+//
+// A field of an anonymous struct or disjunction of strings is converted to
+// a nested message or enum named after the field. Field names are converted
+// to snake_case.
+//
+// A field may be annotated with a protobuf attribute, as generated by
+// Extract, to set its number and, optionally, its proto type and name:
+//
+//	maxRetry?: uint32 @protobuf(2,fixed32,name=max_retry)
+//
+// Fields without a number are assigned the lowest number not otherwise in
+// use, in the order in which they are declared. Enum values are numbered
+// from zero in the order in which they are declared, unless a number is
+// given by a #enumValue field or a #<Enum>_value map, as generated by
+// Extract. To keep numbers stable as definitions evolve, pass the
+// previously generated file in GenerateConfig.Previous.
+func Generate(v cue.Value, c *GenerateConfig) ([]byte, error) {
+	if c == nil {
+		c = &GenerateConfig{}
+	}
+	g := &generator{
+		root:    v,
+		imports: map[string]bool{},
+		prev:    map[string]*prevDecl{},
+	}
+	if c.Previous != nil {
+		p, err := proto.NewParser(bytes.NewReader(c.Previous)).Parse()
+		if err != nil {
+			return nil, errors.Newf(token.NoPos, "invalid previous proto file: %v", err)
+		}
+		g.addPrevious("", p.Elements)
+	}
+
+	decls := g.decls("", v)
+	if g.errs != nil {
+		return nil, g.errs
+	}
+
+	w := &bytes.Buffer{}
+	w.WriteString("syntax = \"proto3\";\n")
+	if c.Package != "" {
+		fmt.Fprintf(w, "\npackage %s;\n", c.Package)
+	}
+	if len(g.imports) > 0 {
+		var imports []string
+		for path := range g.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		w.WriteString("\n")
+		for _, path := range imports {
+			fmt.Fprintf(w, "import %q;\n", path)
+		}
+	}
+	if c.GoPackage != "" {
+		fmt.Fprintf(w, "\noption go_package = %q;\n", c.GoPackage)
+	}
+	for _, d := range decls {
+		w.WriteString("\n")
+		d.print(w, "")
+	}
+	return w.Bytes(), nil
+}
+
+type generator struct {
+	root    cue.Value
+	imports map[string]bool
+	errs    errors.Error
+
+	// prev holds the messages and enums of the previously generated file
+	// by their name relative to the package.
+	prev map[string]*prevDecl
+}
+
+// A prevDecl records the numbers of a message or enum of a previously
+// generated file.
+type prevDecl struct {
+	numbers       map[string]int
+	reserved      []proto.Range
+	reservedNames []string
+}
+
+func (g *generator) addErr(v cue.Value, format string, args ...interface{}) {
+	g.errs = errors.Append(g.errs, errors.Newf(v.Pos(), format, args...))
+}
+
+func (g *generator) addPrevious(scope string, elems []proto.Visitee) {
+	for _, e := range elems {
+		switch x := e.(type) {
+		case *proto.Message:
+			if x.IsExtend {
+				continue
+			}
+			name := scope + x.Name
+			d := &prevDecl{numbers: map[string]int{}}
+			g.prev[name] = d
+			for _, e := range x.Elements {
+				switch f := e.(type) {
+				case *proto.NormalField:
+					d.numbers[f.Name] = f.Sequence
+				case *proto.MapField:
+					d.numbers[f.Name] = f.Sequence
+				case *proto.Oneof:
+					for _, e := range f.Elements {
+						if f, ok := e.(*proto.OneOfField); ok {
+							d.numbers[f.Name] = f.Sequence
+						}
+					}
+				case *proto.Reserved:
+					d.reserved = append(d.reserved, f.Ranges...)
+					d.reservedNames = append(d.reservedNames, f.FieldNames...)
+				}
+			}
+			g.addPrevious(name+".", x.Elements)
+
+		case *proto.Enum:
+			d := &prevDecl{numbers: map[string]int{}}
+			g.prev[scope+x.Name] = d
+			for _, e := range x.Elements {
+				if f, ok := e.(*proto.EnumField); ok {
+					d.numbers[f.Name] = f.Integer
+				}
+			}
+		}
+	}
+}
+
+// A decl is a message or enum declaration.
+type decl interface {
+	print(w *bytes.Buffer, indent string)
+}
+
+type message struct {
+	doc      string
+	name     string
+	fields   []*field
+	nested   []decl
+	reserved []int
+	names    []string // reserved names
+}
+
+type field struct {
+	doc    string
+	label  string // "repeated" or ""
+	typ    string
+	name   string
+	number int
+}
+
+type enum struct {
+	doc    string
+	name   string
+	values []*enumValue
+}
+
+type enumValue struct {
+	doc    string
+	name   string
+	number int
+}
+
+// decls converts the definitions of v, which are nested in the message
+// with the given name, if any.
+func (g *generator) decls(scope string, v cue.Value) []decl {
+	iter, err := v.Fields(cue.Definitions(true))
+	if err != nil {
+		g.errs = errors.Append(g.errs, errors.Promote(err, ""))
+		return nil
+	}
+	var decls []decl
+	for iter.Next() {
+		sel := iter.Selector()
+		if !sel.IsDefinition() {
+			continue
+		}
+		name := strings.TrimPrefix(sel.String(), "#")
+		x := iter.Value()
+		switch {
+		case strings.HasSuffix(name, "_value") &&
+			isEnum(v.LookupPath(cue.MakePath(cue.Def(strings.TrimSuffix(name, "_value"))))):
+			// The numeric values of an enum, as generated by Extract.
+
+		case isEnum(x):
+			decls = append(decls, g.enum(scope+name, name, v, x))
+
+		case x.IncompleteKind() == cue.StructKind:
+			decls = append(decls, g.message(scope+name, name, x))
+		}
+	}
+	return decls
+}
+
+// isEnum reports whether v is a string or disjunction of strings.
+func isEnum(v cue.Value) bool {
+	if !v.Exists() {
+		return false
+	}
+	for _, x := range disjuncts(v) {
+		if x.Kind() != cue.StringKind {
+			return false
+		}
+	}
+	return true
+}
+
+func disjuncts(v cue.Value) []cue.Value {
+	if op, a := v.Expr(); op == cue.OrOp {
+		return a
+	}
+	return []cue.Value{v}
+}
+
+// message converts the struct v to a message, where fullName is the name
+// of the message relative to the package.
+func (g *generator) message(fullName, name string, v cue.Value) *message {
+	m := &message{doc: docText(v), name: name}
+	m.nested = g.decls(fullName+".", v)
+
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		g.errs = errors.Append(g.errs, errors.Promote(err, ""))
+		return m
+	}
+	var values []cue.Value
+	for iter.Next() {
+		f := &field{
+			doc:  docText(iter.Value()),
+			name: snakeCase(iter.Selector().Unquoted()),
+		}
+		x := iter.Value()
+		a := x.Attribute("protobuf")
+		if a.Err() == nil {
+			if n, err := a.Int(0); err == nil {
+				f.number = int(n)
+			}
+			if s, ok, _ := a.Lookup(1, "name"); ok {
+				f.name = s
+			}
+			if a.NumArgs() > 1 {
+				if s, _ := a.String(1); !strings.Contains(s, "=") {
+					f.typ = s
+				}
+			}
+		}
+		g.fieldType(fullName, m, f, x)
+		m.fields = append(m.fields, f)
+		values = append(values, x)
+	}
+
+	// Assign numbers to fields without one.
+	used := map[int]bool{}
+	for _, f := range m.fields {
+		if f.number > 0 {
+			used[f.number] = true
+		}
+	}
+	prev := g.prev[fullName]
+	if prev != nil {
+		for _, r := range prev.reserved {
+			to := r.To
+			if r.Max {
+				to = maxFieldNumber
+			}
+			for i := r.From; i <= to && i < firstReserved; i++ {
+				used[i] = true
+			}
+		}
+		for _, f := range m.fields {
+			if n, ok := prev.numbers[f.name]; ok && f.number == 0 && !used[n] {
+				f.number = n
+			}
+		}
+		// Do not reuse the numbers of removed fields.
+		for _, n := range prev.numbers {
+			used[n] = true
+		}
+	}
+	next := 1
+	for _, f := range m.fields {
+		if f.number > 0 {
+			continue
+		}
+		for used[next] || next >= firstReserved && next <= lastReserved {
+			next++
+		}
+		f.number = next
+		used[next] = true
+	}
+	seen := map[int]string{}
+	for i, f := range m.fields {
+		if other, ok := seen[f.number]; ok {
+			g.addErr(values[i], "fields %s and %s of message %s have the same number %d",
+				other, f.name, fullName, f.number)
+		}
+		seen[f.number] = f.name
+	}
+
+	// Reserve the numbers and names of removed fields so that they are not
+	// reused by future fields.
+	if prev != nil {
+		m.names = append(m.names, prev.reservedNames...)
+		for _, r := range prev.reserved {
+			to := r.To
+			if r.Max {
+				to = maxFieldNumber
+			}
+			for i := r.From; i <= to && i < firstReserved; i++ {
+				m.reserved = append(m.reserved, i)
+			}
+		}
+		for name, n := range prev.numbers {
+			if _, ok := seen[n]; !ok {
+				m.reserved = append(m.reserved, n)
+			}
+			found := false
+			for _, f := range m.fields {
+				found = found || f.name == name
+			}
+			if !found {
+				m.names = append(m.names, name)
+			}
+		}
+		m.reserved = uniqueInts(m.reserved)
+		m.names = uniqueStrings(m.names)
+	}
+	return m
+}
+
+const (
+	firstReserved  = 19000
+	lastReserved   = 19999
+	maxFieldNumber = 1<<29 - 1
+)
+
+// fieldType sets the type and label of field f of message m, converted
+// from the value v.
+func (g *generator) fieldType(scope string, m *message, f *field, v cue.Value) {
+	switch {
+	case strings.HasPrefix(f.typ, "map["):
+		f.typ = "map<" + strings.Replace(f.typ[len("map["):], "]", ", ", 1) + ">"
+		g.importType(f.typ)
+		return
+
+	case f.typ != "":
+		if v.IncompleteKind() == cue.ListKind && f.typ != "google.protobuf.ListValue" {
+			f.label = "repeated"
+		}
+		g.importType(f.typ)
+		return
+	}
+
+	if v.IncompleteKind() == cue.ListKind {
+		elem := v.LookupPath(cue.MakePath(cue.AnyIndex))
+		if !elem.Exists() || elem.IncompleteKind() == cue.ListKind {
+			g.addErr(v, "cannot represent list %v in proto", v)
+			return
+		}
+		f.label = "repeated"
+		v = elem
+	} else if elem := v.LookupPath(cue.MakePath(cue.AnyString)); elem.Exists() &&
+		v.IncompleteKind() == cue.StructKind && !hasFields(v) {
+		key := &field{}
+		g.typ(scope, m, key, f.name, v.Context().CompileString("string"))
+		val := &field{}
+		g.typ(scope, m, val, f.name, elem)
+		f.typ = fmt.Sprintf("map<%s, %s>", key.typ, val.typ)
+		return
+	}
+	g.typ(scope, m, f, f.name, v)
+}
+
+// typ sets the type of f to the proto type of v. Anonymous messages and
+// enums are added to m, named after the field name.
+func (g *generator) typ(scope string, m *message, f *field, name string, v cue.Value) {
+	if _, p := v.ReferencePath(); isDefinitionPath(p) {
+		var a []string
+		for _, sel := range p.Selectors() {
+			a = append(a, strings.TrimPrefix(sel.String(), "#"))
+		}
+		f.typ = strings.Join(a, ".")
+		return
+	}
+
+	if t := timeType(v); t != "" {
+		f.typ = t
+		g.importType(t)
+		return
+	}
+
+	if v.IncompleteKind() == cue.StringKind {
+		if len(disjuncts(v)) > 1 && isEnum(v) {
+			name := camelCase(name)
+			m.nested = append(m.nested, g.enum(scope+"."+name, name, cue.Value{}, v))
+			f.typ = name
+			return
+		}
+	}
+
+	switch k := v.IncompleteKind(); k {
+	case cue.BoolKind:
+		f.typ = "bool"
+	case cue.StringKind:
+		f.typ = "string"
+	case cue.BytesKind:
+		f.typ = "bytes"
+	case cue.IntKind:
+		f.typ = "int64"
+		for _, t := range []struct{ cue, proto string }{
+			{"int32", "int32"},
+			{"uint32", "uint32"},
+			{"int64", "int64"},
+			{"uint64", "uint64"},
+			{"uint", "uint64"},
+		} {
+			if subsumes(t.cue, v) {
+				f.typ = t.proto
+				break
+			}
+		}
+	case cue.FloatKind, cue.NumberKind:
+		f.typ = "double"
+		if subsumes("float32", v) {
+			f.typ = "float"
+		}
+	case cue.StructKind:
+		name := camelCase(name)
+		m.nested = append(m.nested, g.message(scope+"."+name, name, v))
+		f.typ = name
+	default:
+		g.addErr(v, "cannot represent %v in proto", v)
+	}
+}
+
+// subsumes reports whether the predeclared type t subsumes v.
+func subsumes(t string, v cue.Value) bool {
+	ctx := v.Context()
+	// Unify v with top, as the values of optional fields cannot be
+	// compared directly.
+	return ctx.CompileString(t).Subsume(ctx.CompileString("_").Unify(v)) == nil
+}
+
+// timeType returns the well-known proto type for a time.Time or
+// time.Duration value v, or "" if v is neither.
+func timeType(v cue.Value) string {
+	for _, x := range splitConjuncts([]cue.Value{v}, v) {
+		switch strings.TrimSuffix(fmt.Sprint(x), "()") {
+		case "time.Time":
+			return "google.protobuf.Timestamp"
+		case "time.Duration":
+			return "google.protobuf.Duration"
+		}
+	}
+	return ""
+}
+
+// wellKnownTypes maps well-known types to the file that defines them.
+var wellKnownTypes = map[string]string{
+	"google.protobuf.Any":         "google/protobuf/any.proto",
+	"google.protobuf.Duration":    "google/protobuf/duration.proto",
+	"google.protobuf.Empty":       "google/protobuf/empty.proto",
+	"google.protobuf.FieldMask":   "google/protobuf/field_mask.proto",
+	"google.protobuf.ListValue":   "google/protobuf/struct.proto",
+	"google.protobuf.NullValue":   "google/protobuf/struct.proto",
+	"google.protobuf.Struct":      "google/protobuf/struct.proto",
+	"google.protobuf.Timestamp":   "google/protobuf/timestamp.proto",
+	"google.protobuf.Value":       "google/protobuf/struct.proto",
+	"google.protobuf.BoolValue":   "google/protobuf/wrappers.proto",
+	"google.protobuf.BytesValue":  "google/protobuf/wrappers.proto",
+	"google.protobuf.DoubleValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.FloatValue":  "google/protobuf/wrappers.proto",
+	"google.protobuf.Int32Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.Int64Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.StringValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.UInt32Value": "google/protobuf/wrappers.proto",
+	"google.protobuf.UInt64Value": "google/protobuf/wrappers.proto",
+}
+
+// importType adds an import for the well-known types used in the type t.
+func (g *generator) importType(t string) {
+	for _, s := range strings.FieldsFunc(t, func(r rune) bool {
+		return r == '<' || r == '>' || r == ',' || r == ' '
+	}) {
+		if path, ok := wellKnownTypes[s]; ok {
+			g.imports[path] = true
+		}
+	}
+}
+
+// enum converts the disjunction of strings v to an enum. If parent exists,
+// it is the struct holding the definition of v, which may define the
+// numeric values of the enum as generated by Extract.
+func (g *generator) enum(fullName, name string, parent, v cue.Value) *enum {
+	e := &enum{doc: docText(v), name: name}
+	var numbers cue.Value
+	if parent.Exists() {
+		numbers = parent.LookupPath(cue.MakePath(cue.Def(name + "_value")))
+	}
+	prev := g.prev[fullName]
+	used := map[int]bool{}
+	var values []cue.Value
+	for _, x := range disjuncts(v) {
+		s, err := x.String()
+		if err != nil || !isIdent(s) {
+			g.addErr(x, "cannot represent %v as an enum value in proto", x)
+			continue
+		}
+		ev := &enumValue{doc: docText(x), name: s, number: -1}
+		if n, err := x.LookupPath(cue.MakePath(cue.Def("enumValue"))).Int64(); err == nil {
+			ev.number = int(n)
+		} else if n, err := numbers.LookupPath(cue.MakePath(cue.Str(s))).Int64(); err == nil {
+			ev.number = int(n)
+		}
+		if ev.number >= 0 {
+			used[ev.number] = true
+		}
+		e.values = append(e.values, ev)
+		values = append(values, x)
+	}
+	if prev != nil {
+		for _, ev := range e.values {
+			if n, ok := prev.numbers[ev.name]; ok && ev.number < 0 && !used[n] {
+				ev.number = n
+				used[n] = true
+			}
+		}
+	}
+	next := 0
+	for _, ev := range e.values {
+		if ev.number >= 0 {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		ev.number = next
+		used[next] = true
+	}
+	sort.SliceStable(e.values, func(i, j int) bool {
+		return e.values[i].number < e.values[j].number
+	})
+	if len(e.values) > 0 && e.values[0].number != 0 {
+		g.addErr(v, "enum %s has no value with number 0", fullName)
+	}
+	return e
+}
+
+func (m *message) print(w *bytes.Buffer, indent string) {
+	printDoc(w, indent, m.doc)
+	fmt.Fprintf(w, "%smessage %s {\n", indent, m.name)
+	in := indent + "  "
+	for i, d := range m.nested {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+		d.print(w, in)
+	}
+	if len(m.reserved) > 0 || len(m.names) > 0 {
+		if len(m.nested) > 0 {
+			w.WriteString("\n")
+		}
+		if len(m.reserved) > 0 {
+			var a []string
+			for _, n := range m.reserved {
+				a = append(a, strconv.Itoa(n))
+			}
+			fmt.Fprintf(w, "%sreserved %s;\n", in, strings.Join(a, ", "))
+		}
+		if len(m.names) > 0 {
+			var a []string
+			for _, s := range m.names {
+				a = append(a, strconv.Quote(s))
+			}
+			fmt.Fprintf(w, "%sreserved %s;\n", in, strings.Join(a, ", "))
+		}
+	}
+	for i, f := range m.fields {
+		if i > 0 && f.doc != "" || i == 0 && (len(m.nested) > 0 || len(m.reserved) > 0 || len(m.names) > 0) {
+			w.WriteString("\n")
+		}
+		printDoc(w, in, f.doc)
+		label := ""
+		if f.label != "" {
+			label = f.label + " "
+		}
+		fmt.Fprintf(w, "%s%s%s %s = %d;\n", in, label, f.typ, f.name, f.number)
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+func (e *enum) print(w *bytes.Buffer, indent string) {
+	printDoc(w, indent, e.doc)
+	fmt.Fprintf(w, "%senum %s {\n", indent, e.name)
+	for _, v := range e.values {
+		printDoc(w, indent+"  ", v.doc)
+		fmt.Fprintf(w, "%s  %s = %d;\n", indent, v.name, v.number)
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+func printDoc(w *bytes.Buffer, indent, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(doc, "\n"), "\n") {
+		if line == "" {
+			fmt.Fprintf(w, "%s//\n", indent)
+		} else {
+			fmt.Fprintf(w, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+func docText(v cue.Value) string {
+	var a []string
+	for _, cg := range v.Doc() {
+		a = append(a, cg.Text())
+	}
+	return strings.Join(a, "\n")
+}
+
+func hasFields(v cue.Value) bool {
+	iter, err := v.Fields(cue.Optional(true))
+	return err == nil && iter.Next()
+}
+
+// isDefinitionPath reports whether p is a non-empty path of definitions.
+func isDefinitionPath(p cue.Path) bool {
+	sels := p.Selectors()
+	for _, sel := range sels {
+		if !sel.IsDefinition() {
+			return false
+		}
+	}
+	return len(sels) > 0
+}
+
+// splitConjuncts appends the conjuncts of v to a.
+func splitConjuncts(a []cue.Value, v cue.Value) []cue.Value {
+	op, args := v.Expr()
+	if op != cue.AndOp {
+		return append(a, v)
+	}
+	for _, x := range args {
+		a = splitConjuncts(a, x)
+	}
+	return a
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// snakeCase converts a lowerCamelCase field name to snake_case.
+func snakeCase(s string) string {
+	var b strings.Builder
+	r := []rune(s)
+	for i, c := range r {
+		if unicode.IsUpper(c) {
+			if i > 0 && (!unicode.IsUpper(r[i-1]) || i+1 < len(r) && unicode.IsLower(r[i+1])) && r[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// camelCase converts a field name to UpperCamelCase.
+func camelCase(s string) string {
+	var b strings.Builder
+	up := true
+	for _, c := range s {
+		if c == '_' {
+			up = true
+			continue
+		}
+		if up {
+			c = unicode.ToUpper(c)
+			up = false
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func uniqueInts(a []int) []int {
+	sort.Ints(a)
+	var b []int
+	for i, n := range a {
+		if i == 0 || n != a[i-1] {
+			b = append(b, n)
+		}
+	}
+	return b
+}
+
+func uniqueStrings(a []string) []string {
+	sort.Strings(a)
+	var b []string
+	for i, s := range a {
+		if i == 0 || s != a[i-1] {
+			b = append(b, s)
+		}
+	}
+	return b
+}
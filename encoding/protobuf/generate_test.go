@@ -0,0 +1,52 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf_test
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/encoding/protobuf"
+	"cuelang.org/go/internal/cuetxtar"
+)
+
+func TestGenerate(t *testing.T) {
+	test := cuetxtar.TxTarTest{
+		Root: "./testdata/generate",
+		Name: "generate",
+	}
+
+	test.Run(t, func(t *cuetxtar.Test) {
+		v := cuecontext.New().BuildInstance(t.Instance())
+		if err := v.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		c := &protobuf.GenerateConfig{Package: "example"}
+		for _, f := range t.Archive.Files {
+			if f.Name == "previous.proto" {
+				c.Previous = f.Data
+			}
+		}
+
+		b, err := protobuf.Generate(v, c)
+		if err != nil {
+			t.WriteErrors(errors.Promote(err, "generate"))
+			return
+		}
+		_, _ = t.Write(b)
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/scanner"
@@ -52,7 +53,12 @@ func (s *Extractor) parse(filename string, src interface{}) (p *protoConverter,
 		return nil, err
 	}
 
-	parser := proto.NewParser(bytes.NewReader(b))
+	// The proto parser does not support editions. Rewrite the edition
+	// declaration as a syntax declaration of the same length, so that
+	// positions are retained.
+	text, edition := rewriteEdition(b)
+
+	parser := proto.NewParser(bytes.NewReader(text))
 	if filename != "" {
 		parser.Filename(filename)
 	}
@@ -70,6 +76,7 @@ func (s *Extractor) parse(filename string, src interface{}) (p *protoConverter,
 		tfile:    tfile,
 		imported: map[string]bool{},
 		symbols:  map[string]bool{},
+		edition:  edition,
 	}
 
 	defer func() {
@@ -96,6 +103,9 @@ func (s *Extractor) parse(filename string, src interface{}) (p *protoConverter,
 		case *proto.Package:
 			p.protoPkg = x.Name
 		case *proto.Option:
+			if x.Name == featureFieldPresence {
+				p.fieldPresence = x.Constant.Source
+			}
 			if x.Name == "go_package" {
 				str, err := strconv.Unquote(x.Constant.SourceRepresentation())
 				if err != nil {
@@ -156,6 +166,13 @@ type protoConverter struct {
 
 	proto3 bool
 
+	// edition is the edition of a file using editions, such as "2023".
+	edition string
+
+	// fieldPresence is the default value of the field_presence feature
+	// in the current scope for files using editions.
+	fieldPresence string
+
 	id           string
 	protoPkg     string
 	shortPkgName string
@@ -408,7 +425,7 @@ func (p *protoConverter) addTag(f *ast.Field, body string) {
 func (p *protoConverter) topElement(v proto.Visitee) {
 	switch x := v.(type) {
 	case *proto.Syntax:
-		p.proto3 = x.Value == "proto3"
+		p.proto3 = x.Value == "proto3" && p.edition == ""
 
 	case *proto.Comment:
 		addComments(p.file, 0, x, nil)
@@ -472,6 +489,13 @@ func (p *protoConverter) message(v *proto.Message) {
 	}(p.current)
 	p.current = s
 
+	defer func(saved string) { p.fieldPresence = saved }(p.fieldPresence)
+	for _, e := range v.Elements {
+		if x, ok := e.(*proto.Option); ok && x.Name == featureFieldPresence {
+			p.fieldPresence = x.Constant.Source
+		}
+	}
+
 	for i, e := range v.Elements {
 		p.messageField(s, i, e)
 	}
@@ -749,7 +773,14 @@ func (p *protoConverter) parseField(s *ast.StructLit, i int, x *proto.Field) *as
 	f.Value = typ
 	s.Elts = append(s.Elts, f)
 
-	o := optionParser{message: s, field: f}
+	// Fields are optional, unless they have the LEGACY_REQUIRED presence in
+	// editions. Fields with the proto3 optional label have explicit
+	// presence, which also maps to an optional field.
+	o := optionParser{
+		message:  s,
+		field:    f,
+		required: p.fieldPresence == "LEGACY_REQUIRED",
+	}
 
 	// body of @protobuf tag: sequence,type[,name=<name>][,...]
 	o.tags += fmt.Sprintf("%v,%s", x.Sequence, x.Type)
@@ -765,6 +796,26 @@ func (p *protoConverter) parseField(s *ast.StructLit, i int, x *proto.Field) *as
 	return f
 }
 
+// featureFieldPresence is the option for the field presence feature of
+// editions.
+const featureFieldPresence = "features.field_presence"
+
+// editionRE matches an edition declaration, preceded by comments and
+// white space, at the start of a file.
+var editionRE = regexp.MustCompile(`^(?:\s|//[^\n]*\n|/\*(?s:.*?)\*/)*(edition)\s*=\s*"([^"]*)"`)
+
+// rewriteEdition replaces an edition declaration at the start of b, if any,
+// with a syntax declaration for the edition, and returns the edition.
+func rewriteEdition(b []byte) (text []byte, edition string) {
+	m := editionRE.FindSubmatchIndex(b)
+	if m == nil {
+		return b, ""
+	}
+	text = append([]byte(nil), b...)
+	copy(text[m[2]:m[3]], "syntax ")
+	return text, string(b[m[4]:m[5]])
+}
+
 type optionParser struct {
 	message  *ast.StructLit
 	field    *ast.Field
@@ -783,6 +834,9 @@ func (p *optionParser) parse(options []*proto.Option) {
 			p.required = true
 			// TODO: Dropping comments. Maybe add a dummy tag?
 
+		case featureFieldPresence:
+			p.required = o.Constant.Source == "LEGACY_REQUIRED"
+
 		case "(cue.val)":
 			// TODO: set filename and base offset.
 			expr, err := parser.ParseExpr("", o.Constant.Source)
@@ -64,6 +64,13 @@
 //	Timestamp      time.Time        See struct.proto.
 //	Duration       time.Duration    See struct.proto.
 //
+// # Field Presence
+//
+// Fields map to optional CUE fields, including fields with the optional
+// label in proto3 and fields with explicit or implicit presence in files
+// using editions. Fields with the LEGACY_REQUIRED presence feature in
+// editions map to regular fields.
+//
 // Protobuf definitions can be annotated with CUE constraints that are included
 // in the generated CUE:
 //
@@ -74,6 +81,13 @@
 //	(cue.opt)     FieldOptions
 //	   required   bool          Defines the field is required. Use with
 //	                            caution.
+//
+// # Generating Proto Definitions
+//
+// Generate converts CUE definitions back to a .proto file, allowing schemas
+// to be authored in CUE and published as protos for other languages. Field
+// numbers are taken from protobuf attributes or assigned deterministically,
+// and can be preserved across runs by passing the previously generated file.
 package protobuf
 
 // TODO mappings:
@@ -406,9 +420,6 @@ func Extract(filename string, src interface{}, c *Config) (f *ast.File, err erro
 	return p.file, b.Err()
 }
 
-// TODO
-// func GenDefinition
-
 // func MarshalText(cue.Value) (string, error) {
 // 	return "", nil
 // }
@@ -37,6 +37,8 @@ func TestExtractDefinitions(t *testing.T) {
 		"mixer/v1/attributes.proto",
 		"mixer/v1/config/client/client_config.proto",
 		"other/trailcomment.proto",
+		"other/optional.proto",
+		"other/editions.proto",
 	}
 	for _, file := range testCases {
 		t.Run(file, func(t *testing.T) {
@@ -0,0 +1,13 @@
+package foo
+
+#Editions: {
+	name?:  string @protobuf(1,string)
+	id:     string @protobuf(2,string)
+	count?: int32  @protobuf(3,int32)
+
+	#Nested: {
+		@protobuf(option features.field_presence=LEGACY_REQUIRED)
+		key:    string @protobuf(1,string)
+		value?: string @protobuf(2,string)
+	}
+}
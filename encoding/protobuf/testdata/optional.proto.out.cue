@@ -0,0 +1,8 @@
+package foo
+
+#Optional: {
+	// Fields with explicit presence.
+	name?:  string @protobuf(1,string)
+	count?: int32  @protobuf(2,int32)
+	plain?: string @protobuf(3,string)
+}
@@ -0,0 +1,300 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package roundtrip verifies that CUE values survive a conversion to another
+// encoding and back.
+//
+// Encodings such as JSON or TOML cannot represent everything a CUE value
+// holds. For instance, JSON has no bytes type and no attributes, and YAML
+// may not preserve the precision of numbers. Check reports such losses, so
+// that tests can assert that the values of a pipeline convert losslessly:
+//
+//	findings, err := roundtrip.Check(v, build.YAML)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	for _, f := range findings {
+//		t.Error(f)
+//	}
+package roundtrip
+
+import (
+	"bytes"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/json"
+	"cuelang.org/go/encoding/protobuf/textproto"
+	"cuelang.org/go/encoding/toml"
+	"cuelang.org/go/encoding/yaml"
+)
+
+// A Kind classifies a fidelity loss.
+type Kind int
+
+const (
+	// Dropped indicates that a field or list element is missing after the
+	// round trip.
+	Dropped Kind = iota + 1
+
+	// Added indicates that a field or list element appears only after the
+	// round trip.
+	Added
+
+	// TypeChanged indicates that a value was converted to another type,
+	// such as bytes to a string or an integer to a float.
+	TypeChanged
+
+	// PrecisionChanged indicates that a number has another value after the
+	// round trip, for instance because it was rounded.
+	PrecisionChanged
+
+	// ValueChanged indicates that a value other than a number has another
+	// value after the round trip.
+	ValueChanged
+
+	// AttributeDropped indicates that a field attribute is missing after
+	// the round trip.
+	AttributeDropped
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Dropped:
+		return "dropped"
+	case Added:
+		return "added"
+	case TypeChanged:
+		return "type changed"
+	case PrecisionChanged:
+		return "precision changed"
+	case ValueChanged:
+		return "value changed"
+	case AttributeDropped:
+		return "attribute dropped"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// A Finding describes a fidelity loss at a single path.
+type Finding struct {
+	// Path is the path of the affected value relative to the checked value.
+	Path cue.Path
+
+	Kind Kind
+
+	// Before and After are the values before and after the round trip.
+	// Before does not exist for Added findings, and After does not exist
+	// for Dropped findings.
+	Before cue.Value
+	After  cue.Value
+
+	// Message describes the loss.
+	Message string
+}
+
+func (f Finding) String() string {
+	path := f.Path.String()
+	if path == "" {
+		path = "."
+	}
+	return fmt.Sprintf("%s: %s", path, f.Message)
+}
+
+// An Option configures Check.
+type Option func(c *config)
+
+type config struct {
+	schema cue.Value
+}
+
+// Schema sets the schema used to decode encodings that need one, which is
+// currently only build.TextProto. By default the checked value itself is
+// used as the schema.
+func Schema(v cue.Value) Option {
+	return func(c *config) { c.schema = v }
+}
+
+// Check encodes v, which must be concrete, with the given encoding, decodes
+// the result, and reports how the decoded value differs from v. The
+// supported encodings are build.JSON, build.YAML, build.TOML, and
+// build.TextProto.
+//
+// Only regular fields are compared, as definitions, hidden fields, and
+// optional fields are never encoded. Defaults are resolved before
+// comparing. Check returns an error if v cannot be encoded or its encoding
+// cannot be decoded.
+func Check(v cue.Value, enc build.Encoding, opts ...Option) ([]Finding, error) {
+	c := &config{schema: v}
+	for _, o := range opts {
+		o(c)
+	}
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return nil, err
+	}
+
+	after, err := roundTrip(v, enc, c)
+	if err != nil {
+		return nil, err
+	}
+	if err := after.Err(); err != nil {
+		return nil, err
+	}
+
+	var d differ
+	d.compare(nil, v, after)
+	return d.findings, nil
+}
+
+func roundTrip(v cue.Value, enc build.Encoding, c *config) (cue.Value, error) {
+	ctx := v.Context()
+	filename := "roundtrip." + string(enc)
+	switch enc {
+	case build.JSON:
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return cue.Value{}, err
+		}
+		expr, err := json.Extract(filename, b)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		return ctx.BuildExpr(expr), nil
+
+	case build.YAML:
+		b, err := yaml.Encode(v)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		f, err := yaml.Extract(filename, b)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		return ctx.BuildFile(f), nil
+
+	case build.TOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+			return cue.Value{}, err
+		}
+		expr, err := toml.NewDecoder(filename, &buf).Decode()
+		if err != nil {
+			return cue.Value{}, err
+		}
+		return ctx.BuildExpr(expr), nil
+
+	case build.TextProto:
+		b, err := textproto.NewEncoder().Encode(v)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		expr, err := textproto.NewDecoder().Parse(c.schema, filename, b)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		return ctx.BuildExpr(expr), nil
+	}
+	return cue.Value{}, errors.Newf(token.NoPos, "roundtrip: unsupported encoding %q", enc)
+}
+
+type differ struct {
+	findings []Finding
+}
+
+func (d *differ) add(sels []cue.Selector, kind Kind, before, after cue.Value, format string, args ...interface{}) {
+	d.findings = append(d.findings, Finding{
+		Path:    cue.MakePath(sels...),
+		Kind:    kind,
+		Before:  before,
+		After:   after,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) compare(sels []cue.Selector, before, after cue.Value) {
+	before, _ = before.Default()
+	after, _ = after.Default()
+
+	d.compareAttrs(sels, before, after)
+
+	bk, ak := before.Kind(), after.Kind()
+	if bk != ak {
+		d.add(sels, TypeChanged, before, after, "type changed from %v to %v", bk, ak)
+		return
+	}
+
+	switch bk {
+	case cue.StructKind:
+		iter, _ := before.Fields()
+		for iter.Next() {
+			sel := iter.Selector()
+			path := append(sels[:len(sels):len(sels)], sel)
+			a := after.LookupPath(cue.MakePath(sel))
+			if !a.Exists() {
+				d.add(path, Dropped, iter.Value(), a, "field dropped")
+				continue
+			}
+			d.compare(path, iter.Value(), a)
+		}
+		iter, _ = after.Fields()
+		for iter.Next() {
+			sel := iter.Selector()
+			if !before.LookupPath(cue.MakePath(sel)).Exists() {
+				path := append(sels[:len(sels):len(sels)], sel)
+				d.add(path, Added, cue.Value{}, iter.Value(), "field added")
+			}
+		}
+
+	case cue.ListKind:
+		bi, _ := before.List()
+		ai, _ := after.List()
+		for i := 0; ; i++ {
+			bok, aok := bi.Next(), ai.Next()
+			if !bok && !aok {
+				break
+			}
+			path := append(sels[:len(sels):len(sels)], cue.Index(i))
+			switch {
+			case !aok:
+				d.add(path, Dropped, bi.Value(), cue.Value{}, "element dropped")
+			case !bok:
+				d.add(path, Added, cue.Value{}, ai.Value(), "element added")
+			default:
+				d.compare(path, bi.Value(), ai.Value())
+			}
+		}
+
+	default:
+		if before.Equals(after) {
+			return
+		}
+		kind := ValueChanged
+		if bk&cue.NumberKind != 0 {
+			kind = PrecisionChanged
+		}
+		d.add(sels, kind, before, after, "%s from %v to %v", kind, before, after)
+	}
+}
+
+func (d *differ) compareAttrs(sels []cue.Selector, before, after cue.Value) {
+	for _, a := range before.Attributes(cue.FieldAttr) {
+		if x := after.Attribute(a.Name()); x.Err() == nil {
+			continue
+		}
+		d.add(sels, AttributeDropped, before, after, "attribute @%s(%s) dropped", a.Name(), a.Contents())
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package roundtrip_test
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/encoding/roundtrip"
+)
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name string
+		enc  build.Encoding
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "lossless",
+		enc:  build.JSON,
+		in: `
+		a: 1
+		b: [1.5, "x", {c: true}]
+		d: *"def" | string
+		#D: 1
+		_h: 2
+		o?: 3
+		`,
+	}, {
+		name: "attributes and bytes",
+		enc:  build.JSON,
+		in: `
+		a: 1 @go(A)
+		b: 'bytes'
+		`,
+		out: `a: attribute @go(A) dropped (attribute dropped)
+b: type changed from bytes to string (type changed)`,
+	}, {
+		name: "yaml",
+		enc:  build.YAML,
+		in: `
+		a: 0.1234567890123456789012345
+		b: 12345678901234567890
+		c: "true"
+		`,
+	}, {
+		name: "toml precision",
+		enc:  build.TOML,
+		in: `
+		a: 0.1234567890123456789012345
+		b: [{c: 1}]
+		`,
+		out: `a: precision changed from 0.1234567890123456789012345 to 0.12345678901234568 (precision changed)`,
+	}, {
+		name: "toml null",
+		enc:  build.TOML,
+		in:   `a: null`,
+		err:  "toml: cannot encode null at a",
+	}, {
+		name: "textproto",
+		enc:  build.TextProto,
+		in: `
+		a: 1
+		b: "x"
+		`,
+	}, {
+		name: "incomplete",
+		enc:  build.JSON,
+		in:   `a: int`,
+		err:  "a: incomplete value int",
+	}, {
+		name: "unsupported",
+		enc:  build.XML,
+		in:   `a: 1`,
+		err:  `roundtrip: unsupported encoding "xml"`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := cuecontext.New().CompileString(tc.in)
+			findings, err := roundtrip.Check(v, tc.enc)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("got error %v; want %s", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var a []string
+			for _, f := range findings {
+				a = append(a, f.String()+" ("+f.Kind.String()+")")
+			}
+			if got := strings.Join(a, "\n"); got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
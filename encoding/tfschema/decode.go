@@ -0,0 +1,456 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfschema
+
+import (
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal"
+)
+
+// helpers holds the definitions that may be referred to by the generated
+// schemas, in the order in which they are emitted.
+var helpers = []struct {
+	name string
+	doc  string
+	src  string
+}{{
+	name: "#ProviderMeta",
+	doc:  "#ProviderMeta holds the meta-arguments of provider configurations.",
+	src:  `{alias?: string}`,
+}, {
+	name: "#ResourceMeta",
+	doc:  "#ResourceMeta holds the meta-arguments of resources.",
+	src: `{
+		count?:       int | #Expr
+		for_each?:    _
+		depends_on?:  [...string]
+		provider?:    string
+		lifecycle?:   {...}
+		provisioner?: _
+		connection?:  _
+	}`,
+}, {
+	name: "#DataMeta",
+	doc:  "#DataMeta holds the meta-arguments of data sources.",
+	src: `{
+		count?:      int | #Expr
+		for_each?:   _
+		depends_on?: [...string]
+		provider?:   string
+		lifecycle?:  {...}
+	}`,
+}, {
+	name: "#Expr",
+	doc: "#Expr is a string with template interpolations, which Terraform " +
+		"accepts in place of any value.",
+	src: `=~"\\$\\{"`,
+}}
+
+// A decoder converts Terraform provider schemas to CUE.
+type decoder struct {
+	cfg  *Config
+	errs errors.Error
+
+	// used records the helper definitions referred to by the generated
+	// schemas.
+	used map[string]bool
+}
+
+func (d *decoder) errf(v cue.Value, format string, args ...interface{}) ast.Expr {
+	d.errs = errors.Append(d.errs, errors.Newf(v.Pos(), format, args...))
+	return &ast.BottomLit{}
+}
+
+func lookup(v cue.Value, key string) cue.Value {
+	return v.LookupPath(cue.MakePath(cue.Str(key)))
+}
+
+// each calls fn for each field of the object v, if it exists.
+func (d *decoder) each(v cue.Value, fn func(name string, v cue.Value)) {
+	if !v.Exists() {
+		return
+	}
+	iter, err := v.Fields()
+	if err != nil {
+		d.errf(v, "expected object: %v", err)
+		return
+	}
+	for iter.Next() {
+		fn(iter.Selector().Unquoted(), iter.Value())
+	}
+}
+
+func (d *decoder) decode(v cue.Value) *ast.File {
+	f := &ast.File{}
+	if pkgName := d.cfg.PkgName; pkgName != "" {
+		f.Decls = append(f.Decls, &ast.Package{Name: ast.NewIdent(pkgName)})
+	}
+
+	schemas := lookup(v, "provider_schemas")
+	if !schemas.Exists() {
+		d.errf(v, "not a Terraform provider schema: missing provider_schemas")
+		return f
+	}
+
+	providers := newStruct()
+	resources := newStruct()
+	dataSources := newStruct()
+	addresses := map[string]string{}
+	d.each(schemas, func(addr string, ps cue.Value) {
+		name := localName(addr)
+		if prev, ok := addresses[name]; ok {
+			d.errf(ps, "providers %q and %q have the same local name %q", prev, addr, name)
+			return
+		}
+		addresses[name] = addr
+		if p := lookup(ps, "provider"); p.Exists() {
+			d.schema(providers, name, p, "#ProviderMeta")
+		}
+		d.each(lookup(ps, "resource_schemas"), func(name string, v cue.Value) {
+			d.schema(resources, name, v, "#ResourceMeta")
+		})
+		d.each(lookup(ps, "data_source_schemas"), func(name string, v cue.Value) {
+			d.schema(dataSources, name, v, "#DataMeta")
+		})
+	})
+
+	config := newStruct()
+	if len(providers.Elts) > 0 {
+		s := newStruct()
+		for _, name := range labels(providers) {
+			ref := selector("#Provider", name)
+			s.Elts = append(s.Elts, optional(name, ast.NewBinExpr(token.OR,
+				ref, ast.NewList(&ast.Ellipsis{Type: selector("#Provider", name)}))))
+		}
+		config.Elts = append(config.Elts, optional("provider", s))
+	}
+	for _, x := range []struct {
+		label, def string
+		s          *ast.StructLit
+	}{
+		{"resource", "#Resource", resources},
+		{"data", "#Data", dataSources},
+	} {
+		if len(x.s.Elts) == 0 {
+			continue
+		}
+		s := newStruct()
+		for _, name := range labels(x.s) {
+			s.Elts = append(s.Elts, optional(name, ast.NewStruct(&ast.Field{
+				Label: ast.NewList(ast.NewIdent("string")),
+				Value: selector(x.def, name),
+			})))
+		}
+		config.Elts = append(config.Elts, optional(x.label, s))
+	}
+	config.Elts = append(config.Elts, &ast.Ellipsis{})
+
+	addDef(f, "#Config", "#Config is a Terraform configuration in the JSON syntax "+
+		"using the imported providers.", config)
+	if len(providers.Elts) > 0 {
+		addDef(f, "#Provider", "#Provider holds the configurations of providers by local name.", providers)
+	}
+	if len(resources.Elts) > 0 {
+		addDef(f, "#Resource", "#Resource holds the schemas of resources by type.", resources)
+	}
+	if len(dataSources.Elts) > 0 {
+		addDef(f, "#Data", "#Data holds the schemas of data sources by type.", dataSources)
+	}
+	if d.used["#ResourceMeta"] || d.used["#DataMeta"] {
+		d.used["#Expr"] = true
+	}
+	for _, h := range helpers {
+		if !d.used[h.name] {
+			continue
+		}
+		x, err := parser.ParseExpr("tfschema", h.src)
+		if err != nil {
+			panic(err)
+		}
+		addDef(f, h.name, h.doc, x)
+	}
+
+	_ = astutil.Sanitize(f)
+	return f
+}
+
+func addDef(f *ast.File, name, doc string, x ast.Expr) {
+	field := &ast.Field{Label: ast.NewIdent(name), Value: x}
+	ast.SetComments(field, []*ast.CommentGroup{internal.NewComment(true, doc)})
+	f.Decls = append(f.Decls, field)
+}
+
+// localName returns the local name of the provider with the given address,
+// such as aws for registry.terraform.io/hashicorp/aws.
+func localName(addr string) string {
+	return addr[strings.LastIndexByte(addr, '/')+1:]
+}
+
+// labels returns the names of the fields of s.
+func labels(s *ast.StructLit) []string {
+	var names []string
+	for _, elt := range s.Elts {
+		if f, ok := elt.(*ast.Field); ok {
+			name, _, _ := ast.LabelName(f.Label)
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// schema adds a field for the schema v of a provider, resource or data
+// source to s. Its fields include the meta-arguments of the given helper
+// definition.
+func (d *decoder) schema(s *ast.StructLit, name string, v cue.Value, meta string) {
+	b := lookup(v, "block")
+	x := d.block(b)
+	d.used[meta] = true
+	x.Elts = append([]ast.Decl{&ast.EmbedDecl{Expr: ast.NewIdent(meta)}}, x.Elts...)
+	f := &ast.Field{Label: label(name), Value: x}
+	ast.SetRelPos(f, token.Newline)
+	d.addMeta(f, b)
+	s.Elts = append(s.Elts, f)
+}
+
+// block converts the block schema v to a struct.
+func (d *decoder) block(v cue.Value) *ast.StructLit {
+	s := newStruct()
+	d.attributes(s, lookup(v, "attributes"))
+	d.each(lookup(v, "block_types"), func(name string, bt cue.Value) {
+		b := lookup(bt, "block")
+		inner := d.block(b)
+		min, max := intField(bt, "min_items"), intField(bt, "max_items")
+		var x ast.Expr
+		switch mode, _ := lookup(bt, "nesting_mode").String(); mode {
+		case "single", "group":
+			x = inner
+		case "list", "set":
+			x = d.list(bt, inner, min, max)
+		case "map":
+			x = mapOf(inner)
+		default:
+			x = d.errf(bt, "unsupported nesting mode %q of block %q", mode, name)
+		}
+		f := field(name, x, min > 0)
+		d.addMeta(f, b)
+		s.Elts = append(s.Elts, f)
+	})
+	return s
+}
+
+// attributes adds a field to s for each of the attributes v that may be
+// set in a configuration.
+func (d *decoder) attributes(s *ast.StructLit, v cue.Value) {
+	d.each(v, func(name string, a cue.Value) {
+		required := boolField(a, "required")
+		if boolField(a, "computed") && !required && !boolField(a, "optional") {
+			// The attribute is set by the provider.
+			return
+		}
+		var x ast.Expr
+		if nt := lookup(a, "nested_type"); nt.Exists() {
+			x = d.nestedType(nt)
+		} else if t := lookup(a, "type"); t.Exists() {
+			x = d.ctyType(t)
+		} else {
+			x = d.errf(a, "attribute %q has no type", name)
+		}
+		f := field(name, x, required)
+		d.addMeta(f, a)
+		s.Elts = append(s.Elts, f)
+	})
+}
+
+// nestedType converts the nested type of an attribute, as used by providers
+// of protocol version 6.
+func (d *decoder) nestedType(v cue.Value) ast.Expr {
+	s := newStruct()
+	d.attributes(s, lookup(v, "attributes"))
+	var x ast.Expr
+	switch mode, _ := lookup(v, "nesting_mode").String(); mode {
+	case "single":
+		x = s
+	case "list", "set":
+		x = d.list(v, s, intField(v, "min_items"), intField(v, "max_items"))
+	case "map":
+		x = mapOf(s)
+	default:
+		return d.errf(v, "unsupported nesting mode %q", mode)
+	}
+	return d.expr(x)
+}
+
+// ctyType converts a type in the JSON encoding of types of Terraform.
+func (d *decoder) ctyType(v cue.Value) ast.Expr {
+	if s, err := v.String(); err == nil {
+		switch s {
+		case "string":
+			return ast.NewIdent("string")
+		case "number", "bool":
+			return d.expr(ast.NewIdent(s))
+		case "dynamic":
+			return ast.NewIdent("_")
+		}
+		return d.errf(v, "unsupported type %q", s)
+	}
+
+	var elems []cue.Value
+	if iter, err := v.List(); err == nil {
+		for iter.Next() {
+			elems = append(elems, iter.Value())
+		}
+	}
+	if len(elems) < 2 {
+		return d.errf(v, "invalid type %v", v)
+	}
+	switch kind, _ := elems[0].String(); kind {
+	case "list", "set":
+		return d.expr(ast.NewList(&ast.Ellipsis{Type: d.ctyType(elems[1])}))
+
+	case "map":
+		return d.expr(mapOf(d.ctyType(elems[1])))
+
+	case "object":
+		optional := map[string]bool{}
+		if len(elems) > 2 {
+			iter, _ := elems[2].List()
+			for iter.Next() {
+				name, _ := iter.Value().String()
+				optional[name] = true
+			}
+		}
+		s := newStruct()
+		d.each(elems[1], func(name string, t cue.Value) {
+			s.Elts = append(s.Elts, field(name, d.ctyType(t), !optional[name]))
+		})
+		return d.expr(s)
+
+	case "tuple":
+		var types []ast.Expr
+		iter, _ := elems[1].List()
+		for iter.Next() {
+			types = append(types, d.ctyType(iter.Value()))
+		}
+		return d.expr(ast.NewList(types...))
+	}
+	return d.errf(v, "invalid type %v", v)
+}
+
+// expr allows x to be given as a string with template interpolations.
+func (d *decoder) expr(x ast.Expr) ast.Expr {
+	d.used["#Expr"] = true
+	return ast.NewBinExpr(token.OR, x, ast.NewIdent("#Expr"))
+}
+
+// list returns a list of elem with the given bounds, where a bound of 0
+// means no bound.
+func (d *decoder) list(v cue.Value, elem ast.Expr, min, max int64) ast.Expr {
+	var x ast.Expr = ast.NewList(&ast.Ellipsis{Type: elem})
+	if min > 0 {
+		list := d.addImport(v, "list")
+		x = ast.NewBinExpr(token.AND, x, ast.NewCall(ast.NewSel(list, "MinItems"), ast.NewLit(token.INT, strconv.FormatInt(min, 10))))
+	}
+	if max > 0 {
+		list := d.addImport(v, "list")
+		x = ast.NewBinExpr(token.AND, x, ast.NewCall(ast.NewSel(list, "MaxItems"), ast.NewLit(token.INT, strconv.FormatInt(max, 10))))
+	}
+	return x
+}
+
+func (d *decoder) addImport(v cue.Value, pkg string) *ast.Ident {
+	spec := ast.NewImport(nil, pkg)
+	info, err := astutil.ParseImportSpec(spec)
+	if err != nil {
+		d.errf(v, "invalid import %q", pkg)
+	}
+	ident := ast.NewIdent(info.Ident)
+	ident.Node = spec
+	return ident
+}
+
+// addMeta adds the description of v as the doc comment of f and marks f as
+// deprecated if v is.
+func (d *decoder) addMeta(f *ast.Field, v cue.Value) {
+	if desc, err := lookup(v, "description").String(); err == nil && strings.TrimSpace(desc) != "" {
+		ast.SetComments(f, []*ast.CommentGroup{internal.NewComment(true, desc)})
+	}
+	if boolField(v, "deprecated") {
+		f.Attrs = append(f.Attrs, &ast.Attribute{Text: "@deprecated()"})
+	}
+}
+
+// newStruct returns an empty struct that is formatted with each field on a
+// line of its own.
+func newStruct() *ast.StructLit {
+	s := ast.NewStruct()
+	s.Rbrace = token.Newline.Pos()
+	return s
+}
+
+func mapOf(x ast.Expr) ast.Expr {
+	return ast.NewStruct(&ast.Field{
+		Label: ast.NewList(ast.NewIdent("string")),
+		Value: x,
+	})
+}
+
+// field returns a required or optional field.
+func field(name string, x ast.Expr, required bool) *ast.Field {
+	f := &ast.Field{Label: label(name), Value: x, Constraint: token.OPTION}
+	if required {
+		f.Constraint = token.NOT
+	}
+	// Put each field on its own line.
+	ast.SetRelPos(f, token.Newline)
+	return f
+}
+
+func optional(name string, x ast.Expr) *ast.Field {
+	return field(name, x, false)
+}
+
+// selector returns a reference to the field name of the definition def.
+func selector(def, name string) ast.Expr {
+	if l, ok := label(name).(*ast.Ident); ok {
+		return ast.NewSel(ast.NewIdent(def), l.Name)
+	}
+	return &ast.IndexExpr{X: ast.NewIdent(def), Index: ast.NewString(name)}
+}
+
+// label returns the label for the given name.
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !internal.IsDefOrHidden(name) {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+func boolField(v cue.Value, key string) bool {
+	b, _ := lookup(v, key).Bool()
+	return b
+}
+
+func intField(v cue.Value, key string) int64 {
+	i, _ := lookup(v, key).Int64()
+	return i
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tfschema converts Terraform provider schemas to CUE.
+//
+// The schemas are those printed by
+//
+//	terraform providers schema -json
+//
+// The result describes Terraform configurations in the JSON syntax, so that
+// they can be generated and validated from CUE. The schemas of providers,
+// resources and data sources are mapped to the fields of the definitions
+// #Provider, #Resource and #Data, named after the local name of the provider
+// and the type of the resource or data source, respectively. The definition
+// #Config describes a configuration using these:
+//
+//	#Config: {
+//		provider?: aws?: #Provider.aws | [...#Provider.aws]
+//		resource?: aws_instance?: [string]: #Resource.aws_instance
+//		data?: aws_ami?: [string]: #Data.aws_ami
+//		...
+//	}
+//
+// Required attributes and blocks are mapped to required fields and the
+// others to optional fields. Attributes that are computed by the provider and
+// cannot be set are omitted. Attribute types map to CUE as follows:
+//
+//	Terraform                 CUE
+//	string                    string
+//	number                    number | #Expr
+//	bool                      bool | #Expr
+//	list(T), set(T)           [...T] | #Expr
+//	map(T)                    {[string]: T} | #Expr
+//	object({a = T})           {a!: T} | #Expr
+//	tuple([T1, T2])           [T1, T2] | #Expr
+//	dynamic                   _
+//
+// where #Expr matches strings with template interpolations, such as
+// "${var.size}", which Terraform accepts in place of any value.
+//
+// Descriptions are converted to doc comments and deprecated attributes and
+// blocks are marked with a @deprecated attribute.
+package tfschema
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+)
+
+// Config configures the conversion of Terraform provider schemas.
+type Config struct {
+	// PkgName is the package name of the generated CUE file. If it is empty,
+	// no package clause is generated.
+	PkgName string
+}
+
+// Extract converts the provider schemas data, typically the JSON output of
+// terraform providers schema -json, to a CUE file.
+func Extract(data cue.InstanceOrValue, cfg *Config) (*ast.File, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	d := &decoder{cfg: cfg, used: map[string]bool{}}
+	f := d.decode(data.Value())
+	if d.errs != nil {
+		return f, d.errs
+	}
+
+	// Reparse the formatted result so that positions reflect a canonical
+	// layout, as with the files generated from protobuf definitions.
+	b, err := format.Node(f)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseFile("tfschema.cue", b, parser.ParseComments)
+}
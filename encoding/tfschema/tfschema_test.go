@@ -0,0 +1,271 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/encoding/tfschema"
+)
+
+const schema = `{
+	"format_version": "1.0",
+	"provider_schemas": {
+		"registry.terraform.io/example/cloud": {
+			"provider": {
+				"version": 0,
+				"block": {
+					"attributes": {
+						"region": {"type": "string", "description": "The default region.", "optional": true}
+					}
+				}
+			},
+			"resource_schemas": {
+				"cloud_server": {
+					"version": 1,
+					"block": {
+						"attributes": {
+							"id": {"type": "string", "computed": true},
+							"name": {"type": "string", "required": true},
+							"size": {"type": "number", "optional": true, "computed": true},
+							"tags": {"type": ["map", "string"], "optional": true},
+							"ports": {"type": ["set", "number"], "optional": true},
+							"image": {"type": "string", "optional": true, "deprecated": true},
+							"network": {"type": ["object", {"cidr": "string", "ipv6": "bool"}, ["ipv6"]], "optional": true},
+							"disks": {
+								"nested_type": {
+									"attributes": {"size": {"type": "number", "required": true}},
+									"nesting_mode": "list"
+								},
+								"optional": true
+							}
+						},
+						"block_types": {
+							"timeouts": {
+								"nesting_mode": "single",
+								"block": {"attributes": {"create": {"type": "string", "optional": true}}}
+							},
+							"volume": {
+								"nesting_mode": "list",
+								"block": {
+									"attributes": {"path": {"type": "string", "required": true}},
+									"description": "A volume attached to the server."
+								},
+								"min_items": 1,
+								"max_items": 4
+							}
+						},
+						"description": "A virtual server."
+					}
+				}
+			},
+			"data_source_schemas": {
+				"cloud_image": {
+					"version": 0,
+					"block": {
+						"attributes": {
+							"name": {"type": "string", "required": true},
+							"labels": {"type": "dynamic", "computed": true}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+const want = `package cloud
+
+import "list"
+
+// #Config is a Terraform configuration in the JSON syntax using
+// the imported providers.
+#Config: {
+	provider?: {
+		cloud?: #Provider.cloud | [...#Provider.cloud]
+	}
+	resource?: {
+		cloud_server?: {
+			[string]: #Resource.cloud_server
+		}
+	}
+	data?: {
+		cloud_image?: {
+			[string]: #Data.cloud_image
+		}
+	}
+	...
+}
+
+// #Provider holds the configurations of providers by local name.
+#Provider: {
+	cloud: {
+		#ProviderMeta
+
+		// The default region.
+		region?: string
+	}
+}
+
+// #Resource holds the schemas of resources by type.
+#Resource: {
+	// A virtual server.
+	cloud_server: {
+		#ResourceMeta
+		name!: string
+		size?: number | #Expr
+		tags?: {
+			[string]: string
+		} | #Expr
+		ports?:   [...number | #Expr] | #Expr
+		image?:   string @deprecated()
+		network?: {
+			cidr!: string
+			ipv6?: bool | #Expr
+		} | #Expr
+		disks?: [...{
+			size!: number | #Expr
+		}] | #Expr
+		timeouts?: {
+			create?: string
+		}
+		// A volume attached to the server.
+		volume!: [...{
+			path!: string
+		}] & list.MinItems(1) & list.MaxItems(4)
+	}
+}
+
+// #Data holds the schemas of data sources by type.
+#Data: {
+	cloud_image: {
+		#DataMeta
+		name!: string
+	}
+}
+
+// #ProviderMeta holds the meta-arguments of provider
+// configurations.
+#ProviderMeta: {alias?: string}
+
+// #ResourceMeta holds the meta-arguments of resources.
+#ResourceMeta: {
+	count?:    int | #Expr
+	for_each?: _
+	depends_on?: [...string]
+	provider?: string
+	lifecycle?: {...}
+	provisioner?: _
+	connection?:  _
+}
+
+// #DataMeta holds the meta-arguments of data sources.
+#DataMeta: {
+	count?:    int | #Expr
+	for_each?: _
+	depends_on?: [...string]
+	provider?: string
+	lifecycle?: {...}
+}
+
+// #Expr is a string with template interpolations, which Terraform
+// accepts in place of any value.
+#Expr: =~"\\$\\{"
+`
+
+func TestExtract(t *testing.T) {
+	ctx := cuecontext.New()
+	f, err := tfschema.Extract(ctx.CompileString(schema), &tfschema.Config{PkgName: "cloud"})
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	b, err := format.Node(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The result validates configurations.
+	defs := ctx.BuildFile(f)
+	if err := defs.Err(); err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	config := defs.LookupPath(cue.ParsePath("#Config"))
+	testCases := []struct {
+		config string
+		err    string
+	}{{
+		config: `{
+			provider: cloud: region: "eu"
+			resource: cloud_server: web: {
+				name:   "web"
+				size:   "${var.size}"
+				count:  2
+				volume: [{path: "/data"}]
+			}
+			data: cloud_image: base: name: "ubuntu"
+			variable: size: default: 2
+		}`,
+	}, {
+		config: `resource: cloud_server: web: {name: "web", volume: [{path: "/data"}], id: "x"}`,
+		err:    `#Config.resource.cloud_server.web.id: field not allowed`,
+	}, {
+		config: `resource: cloud_server: web: {name: "web", volume: [], size: "large"}`,
+		err:    `#Config.resource.cloud_server.web.volume: invalid value [] (does not satisfy list.MinItems(1))`,
+	}}
+	for _, tc := range testCases {
+		v := config.Unify(ctx.CompileString(tc.config))
+		err := v.Validate(cue.Concrete(true))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", tc.config, errors.Details(err, nil))
+		case tc.err != "" && (err == nil || !strings.Contains(errors.Details(err, nil), tc.err)):
+			t.Errorf("got error %v for %s; want %s", err, tc.config, tc.err)
+		}
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	testCases := []struct {
+		schema string
+		err    string
+	}{{
+		schema: `{"format_version": "1.0"}`,
+		err:    `not a Terraform provider schema: missing provider_schemas`,
+	}, {
+		schema: `{"provider_schemas": {"a/x": {}, "b/x": {}}}`,
+		err:    `providers "a/x" and "b/x" have the same local name "x"`,
+	}, {
+		schema: `{"provider_schemas": {"x": {"resource_schemas": {"r": {"block": {"attributes": {"a": {"type": "text", "optional": true}}}}}}}}`,
+		err:    `unsupported type "text"`,
+	}, {
+		schema: `{"provider_schemas": {"x": {"resource_schemas": {"r": {"block": {"block_types": {"b": {"nesting_mode": "tree", "block": {}}}}}}}}}`,
+		err:    `unsupported nesting mode "tree" of block "b"`,
+	}}
+	for _, tc := range testCases {
+		v := cuecontext.New().CompileString(tc.schema)
+		_, err := tfschema.Extract(v, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("got error %v; want %s", err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,939 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toml converts TOML to and from CUE. When converting to CUE,
+// comments and position information are retained.
+//
+// TOML date and time values are converted to strings. TOML does not support
+// null or bytes values; CUE values of these kinds cannot be encoded.
+package toml
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// A Decoder reads a TOML document and converts it to CUE.
+type Decoder struct {
+	filename string
+	r        io.Reader
+	done     bool
+}
+
+// NewDecoder returns a decoder that reads a TOML document from r. The
+// filename is used for position information.
+func NewDecoder(filename string, r io.Reader) *Decoder {
+	return &Decoder{filename: filename, r: r}
+}
+
+// Decode parses the TOML document and returns it as a CUE struct literal.
+// A TOML input holds a single document, so any subsequent call returns
+// io.EOF.
+func (d *Decoder) Decode() (ast.Expr, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	d.done = true
+	src, err := io.ReadAll(d.r)
+	if err != nil {
+		return nil, err
+	}
+	return parse(d.filename, src)
+}
+
+// tableKind indicates how a table was defined. It determines whether
+// a table may be extended later on in the document.
+type tableKind int
+
+const (
+	// implicitTable is a table created as a parent of a table header.
+	implicitTable tableKind = iota
+	// headerTable is a table defined by a [table] header.
+	headerTable
+	// dottedTable is a table defined by a dotted key.
+	dottedTable
+	// inlineTable is a table defined by an inline table. It is closed.
+	inlineTable
+	// arrayTable is an element of an array of tables.
+	arrayTable
+)
+
+type table struct {
+	kind tableKind
+	lit  *ast.StructLit
+	keys map[string]*node
+}
+
+func newTable(kind tableKind) *table {
+	return &table{
+		kind: kind,
+		lit:  &ast.StructLit{},
+		keys: map[string]*node{},
+	}
+}
+
+// A node records a key defined in a table.
+type node struct {
+	field *ast.Field
+
+	// table is set if the value of the field is a table.
+	table *table
+
+	// list and tables are set if the value of the field is an array of
+	// tables defined with [[array]] headers.
+	list   *ast.ListLit
+	tables []*table
+
+	// dotted reports whether the table was defined by a dotted key. Only
+	// such tables may be extended by other dotted keys.
+	dotted bool
+}
+
+type key struct {
+	name string
+	pos  token.Pos
+}
+
+type parser struct {
+	file *token.File
+	src  []byte
+	off  int
+
+	root    *table
+	current *table
+
+	// comments holds the comment lines preceding the next definition.
+	comments *ast.CommentGroup
+
+	// blank reports whether a blank line precedes the next definition.
+	blank bool
+	// rel is the relative position for the next field added to a table
+	// that is not an inline table.
+	rel token.RelPos
+}
+
+// bailout is used to abort parsing on the first error.
+type bailout struct{ err errors.Error }
+
+func parse(filename string, src []byte) (expr ast.Expr, err error) {
+	f := token.NewFile(filename, -1, len(src))
+	f.SetLinesForContent(src)
+	p := &parser{
+		file: f,
+		src:  src,
+		root: newTable(implicitTable),
+	}
+	p.current = p.root
+
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(bailout)
+			if !ok {
+				panic(r)
+			}
+			expr, err = nil, b.err
+		}
+	}()
+
+	p.parseDocument()
+	return p.root.lit, nil
+}
+
+func (p *parser) pos(off int) token.Pos {
+	return p.file.Pos(off, token.NoRelPos)
+}
+
+func (p *parser) errf(off int, format string, args ...interface{}) {
+	panic(bailout{errors.Newf(p.pos(off), format, args...)})
+}
+
+func (p *parser) eof() bool { return p.off >= len(p.src) }
+
+// peek returns the current character or 0 at the end of the input.
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.off]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(p.src[p.off:]), s)
+}
+
+func (p *parser) expect(c byte) {
+	if p.peek() != c {
+		p.errf(p.off, "expected %q, found %s", c, p.describe())
+	}
+	p.off++
+}
+
+func (p *parser) describe() string {
+	switch c := p.peek(); {
+	case p.eof():
+		return "end of file"
+	case c == '\n' || c == '\r':
+		return "newline"
+	default:
+		r, _ := utf8.DecodeRune(p.src[p.off:])
+		return fmt.Sprintf("%q", r)
+	}
+}
+
+func (p *parser) skipSpace() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.off++
+	}
+}
+
+// newline consumes a newline, if present.
+func (p *parser) newline() bool {
+	switch {
+	case p.hasPrefix("\n"):
+		p.off++
+	case p.hasPrefix("\r\n"):
+		p.off += 2
+	default:
+		return false
+	}
+	return true
+}
+
+// comment reads a comment, if present, and returns it in CUE syntax.
+func (p *parser) comment() *ast.Comment {
+	if p.peek() != '#' {
+		return nil
+	}
+	start := p.off
+	p.off++
+	for !p.eof() && p.src[p.off] != '\n' && !p.hasPrefix("\r\n") {
+		if c := p.src[p.off]; c < 0x20 && c != '\t' || c == 0x7f {
+			p.errf(p.off, "invalid control character in comment")
+		}
+		p.off++
+	}
+	text := strings.TrimRight(string(p.src[start+1:p.off]), " \t")
+	return &ast.Comment{Slash: p.pos(start), Text: "//" + text}
+}
+
+// endLine consumes the remainder of a line after a definition and attaches
+// any comment on that line to n.
+func (p *parser) endLine(n ast.Node) {
+	p.skipSpace()
+	if c := p.comment(); c != nil {
+		ast.AddComment(n, &ast.CommentGroup{
+			Line:     true,
+			Position: 4,
+			List:     []*ast.Comment{c},
+		})
+	}
+	if !p.eof() && !p.newline() {
+		p.errf(p.off, "expected newline or end of file, found %s", p.describe())
+	}
+}
+
+// flushComments adds pending comments that are not associated with
+// a definition to the current table.
+func (p *parser) flushComments() {
+	if p.comments != nil {
+		p.current.lit.Elts = append(p.current.lit.Elts, p.comments)
+		p.comments = nil
+	}
+}
+
+// attachComments associates pending comments with n.
+func (p *parser) attachComments(n ast.Node) {
+	if p.comments != nil {
+		p.comments.Doc = true
+		ast.AddComment(n, p.comments)
+		p.comments = nil
+	}
+}
+
+func (p *parser) parseDocument() {
+	for {
+		p.skipSpace()
+		switch {
+		case p.eof():
+			p.flushComments()
+			return
+
+		case p.newline():
+			// A blank line separates comments from the next definition.
+			p.flushComments()
+			p.blank = true
+
+		case p.peek() == '#':
+			c := p.comment()
+			if p.comments == nil {
+				ast.SetRelPos(c, p.nextRelPos())
+				p.comments = &ast.CommentGroup{}
+			}
+			p.comments.List = append(p.comments.List, c)
+			if !p.eof() && !p.newline() {
+				p.errf(p.off, "expected newline after comment")
+			}
+
+		case p.peek() == '[':
+			p.rel = p.nextRelPos()
+			p.parseHeader()
+
+		default:
+			p.rel = p.nextRelPos()
+			f := p.parseKeyValue(p.current)
+			p.endLine(f)
+		}
+	}
+}
+
+// nextRelPos returns the relative position of the next definition in
+// the document, preserving blank lines.
+func (p *parser) nextRelPos() token.RelPos {
+	rel := token.Newline
+	if p.blank {
+		rel = token.NewSection
+	}
+	p.blank = false
+	return rel
+}
+
+// add adds a field to t. See table.add.
+func (p *parser) add(t *table, k key, v interface{}) *node {
+	first := len(t.keys) == 0
+	n := t.add(k, v)
+	// The first field of a table is left to the formatter, which keeps
+	// it on the same line as its parent if it is the only field.
+	if !first && t.kind != inlineTable && p.rel != token.NoRelPos {
+		ast.SetRelPos(n.field, p.rel)
+		p.rel = token.NoRelPos
+	}
+	return n
+}
+
+// parseHeader parses a [table] or [[array of tables]] header.
+func (p *parser) parseHeader() {
+	start := p.off
+	isArray := p.hasPrefix("[[")
+	p.off++
+	if isArray {
+		p.off++
+	}
+	p.skipSpace()
+	keys := p.parseKey()
+	p.skipSpace()
+	p.expect(']')
+	if isArray {
+		p.expect(']')
+	}
+
+	t := p.root
+	for _, k := range keys[:len(keys)-1] {
+		n := t.keys[k.name]
+		switch {
+		case n == nil:
+			n = p.add(t, k, newTable(implicitTable))
+		case n.table != nil && n.table.kind != inlineTable:
+		case n.tables != nil:
+			t = n.tables[len(n.tables)-1]
+			continue
+		default:
+			p.errf(k.pos.Offset(), "key %q is already defined as a non-table value", k.name)
+		}
+		t = n.table
+	}
+
+	k := keys[len(keys)-1]
+	n := t.keys[k.name]
+	var doc ast.Node
+	if isArray {
+		switch {
+		case n == nil:
+			list := &ast.ListLit{}
+			n = p.add(t, k, list)
+			n.list = list
+			doc = n.field
+		case n.list == nil:
+			p.errf(start, "cannot define array of tables %q: key is already defined", k.name)
+		}
+		elem := newTable(arrayTable)
+		elem.lit.Lbrace = p.file.Pos(start, token.Newline)
+		n.list.Elts = append(n.list.Elts, elem.lit)
+		n.list.Rbrack = p.file.Pos(start, token.Newline)
+		n.tables = append(n.tables, elem)
+		p.current = elem
+		if doc == nil {
+			doc = elem.lit
+		}
+	} else {
+		switch {
+		case n == nil:
+			n = p.add(t, k, newTable(headerTable))
+		case n.table != nil && n.table.kind == implicitTable:
+			n.table.kind = headerTable
+		default:
+			p.errf(start, "table %q is already defined", k.name)
+		}
+		p.current = n.table
+		doc = n.field
+	}
+	p.attachComments(doc)
+	p.endLine(doc)
+}
+
+// parseKeyValue parses a key/value pair, adds it to t, and returns the field
+// holding the value.
+func (p *parser) parseKeyValue(t *table) *ast.Field {
+	keys := p.parseKey()
+	p.skipSpace()
+	p.expect('=')
+	p.skipSpace()
+
+	for _, k := range keys[:len(keys)-1] {
+		n := t.keys[k.name]
+		switch {
+		case n == nil:
+			kind := dottedTable
+			if t.kind == inlineTable {
+				kind = inlineTable
+			}
+			n = p.add(t, k, newTable(kind))
+			n.dotted = true
+		case n.dotted:
+		default:
+			p.errf(k.pos.Offset(), "cannot extend key %q with a dotted key", k.name)
+		}
+		t = n.table
+	}
+
+	k := keys[len(keys)-1]
+	if t.keys[k.name] != nil {
+		p.errf(k.pos.Offset(), "key %q is already defined", k.name)
+	}
+	n := p.add(t, k, p.parseValue())
+	p.attachComments(n.field)
+	return n.field
+}
+
+// add adds a field with the given key to t. The value must be either
+// an ast.Expr or a table.
+func (t *table) add(k key, v interface{}) *node {
+	n := &node{}
+	var value ast.Expr
+	switch x := v.(type) {
+	case *table:
+		n.table = x
+		value = x.lit
+	case ast.Expr:
+		value = x
+	}
+	n.field = &ast.Field{Label: label(k), Value: value}
+	t.keys[k.name] = n
+	t.lit.Elts = append(t.lit.Elts, n.field)
+	return n
+}
+
+func label(k key) ast.Label {
+	if ast.IsValidIdent(k.name) &&
+		!strings.HasPrefix(k.name, "_") && !strings.HasPrefix(k.name, "#") {
+		return &ast.Ident{NamePos: k.pos, Name: k.name}
+	}
+	s := ast.NewString(k.name)
+	s.ValuePos = k.pos
+	return s
+}
+
+// parseKey parses a possibly dotted key.
+func (p *parser) parseKey() []key {
+	var keys []key
+	for {
+		start := p.off
+		var name string
+		switch c := p.peek(); {
+		case c == '"':
+			if p.hasPrefix(`"""`) {
+				p.errf(start, "multi-line strings are not allowed as keys")
+			}
+			name = p.parseBasicString()
+		case c == '\'':
+			if p.hasPrefix(`'''`) {
+				p.errf(start, "multi-line strings are not allowed as keys")
+			}
+			name = p.parseLiteralString()
+		default:
+			for !p.eof() && isBareKeyChar(p.src[p.off]) {
+				p.off++
+			}
+			if p.off == start {
+				p.errf(start, "expected key, found %s", p.describe())
+			}
+			name = string(p.src[start:p.off])
+		}
+		keys = append(keys, key{name: name, pos: p.pos(start)})
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys
+		}
+		p.off++
+		p.skipSpace()
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *parser) parseValue() ast.Expr {
+	start := p.off
+	pos := p.pos(start)
+	switch c := p.peek(); {
+	case c == '"':
+		var s string
+		if p.hasPrefix(`"""`) {
+			s = p.parseMultilineBasicString()
+		} else {
+			s = p.parseBasicString()
+		}
+		return newString(pos, s)
+
+	case c == '\'':
+		var s string
+		if p.hasPrefix(`'''`) {
+			s = p.parseMultilineLiteralString()
+		} else {
+			s = p.parseLiteralString()
+		}
+		return newString(pos, s)
+
+	case c == '[':
+		return p.parseArray()
+
+	case c == '{':
+		return p.parseInlineTable()
+
+	case p.hasPrefix("true"):
+		p.off += len("true")
+		p.checkEndOfValue()
+		return &ast.BasicLit{ValuePos: pos, Kind: token.TRUE, Value: "true"}
+
+	case p.hasPrefix("false"):
+		p.off += len("false")
+		p.checkEndOfValue()
+		return &ast.BasicLit{ValuePos: pos, Kind: token.FALSE, Value: "false"}
+	}
+	return p.parseNumberOrDate()
+}
+
+func newString(pos token.Pos, s string) *ast.BasicLit {
+	lit := ast.NewString(s)
+	lit.ValuePos = pos
+	return lit
+}
+
+func (p *parser) checkEndOfValue() {
+	if !p.eof() && !isValueDelimiter(p.src[p.off]) {
+		p.errf(p.off, "unexpected %s after value", p.describe())
+	}
+}
+
+func isValueDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', ',', ']', '}', '#':
+		return true
+	}
+	return false
+}
+
+// skipArraySpace skips whitespace, newlines, and comments within arrays.
+func (p *parser) skipArraySpace() {
+	for {
+		p.skipSpace()
+		if p.comment() == nil && !p.newline() {
+			return
+		}
+	}
+}
+
+func (p *parser) parseArray() ast.Expr {
+	list := &ast.ListLit{Lbrack: p.pos(p.off)}
+	p.expect('[')
+	for {
+		p.skipArraySpace()
+		if p.peek() == ']' {
+			break
+		}
+		list.Elts = append(list.Elts, p.parseValue())
+		p.skipArraySpace()
+		if p.peek() != ',' {
+			break
+		}
+		p.off++
+	}
+	list.Rbrack = p.pos(p.off)
+	p.expect(']')
+	return list
+}
+
+func (p *parser) parseInlineTable() ast.Expr {
+	t := newTable(inlineTable)
+	t.lit.Lbrace = p.pos(p.off)
+	p.expect('{')
+	p.skipSpace()
+	if p.peek() != '}' {
+		for {
+			p.parseKeyValue(t)
+			p.skipSpace()
+			if p.peek() != ',' {
+				break
+			}
+			p.off++
+			p.skipSpace()
+		}
+	}
+	t.lit.Rbrace = p.pos(p.off)
+	p.expect('}')
+	return t.lit
+}
+
+func (p *parser) parseBasicString() string {
+	start := p.off
+	p.expect('"')
+	var b strings.Builder
+	for {
+		switch c := p.peek(); {
+		case p.eof() || c == '\n' || c == '\r':
+			p.errf(start, "unterminated string")
+		case c == '"':
+			p.off++
+			return b.String()
+		case c == '\\':
+			p.parseEscape(&b)
+		default:
+			p.stringChar(&b)
+		}
+	}
+}
+
+func (p *parser) parseMultilineBasicString() string {
+	start := p.off
+	p.off += 3
+	p.newline() // A newline directly after the delimiter is trimmed.
+	var b strings.Builder
+	for {
+		switch c := p.peek(); {
+		case p.eof():
+			p.errf(start, "unterminated string")
+		case p.hasPrefix(`"""`):
+			p.closeMultiline(&b, '"')
+			return b.String()
+		case c == '\\' && p.isLineEndingBackslash():
+			p.off++
+			for p.skipSpace(); p.newline(); p.skipSpace() {
+			}
+		case c == '\\':
+			p.parseEscape(&b)
+		case p.newline():
+			b.WriteByte('\n')
+		default:
+			p.stringChar(&b)
+		}
+	}
+}
+
+// isLineEndingBackslash reports whether the backslash at the current
+// position is only followed by whitespace on the current line.
+func (p *parser) isLineEndingBackslash() bool {
+	i := p.off + 1
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t') {
+		i++
+	}
+	return i < len(p.src) && (p.src[i] == '\n' || p.src[i] == '\r')
+}
+
+// closeMultiline consumes the closing delimiter of a multi-line string,
+// which may be preceded by up to two quotes that are part of the string.
+func (p *parser) closeMultiline(b *strings.Builder, quote byte) {
+	n := 0
+	for p.peek() == quote {
+		n++
+		p.off++
+	}
+	if n > 5 {
+		p.errf(p.off-n+5, "too many quotes at end of multi-line string")
+	}
+	for ; n > 3; n-- {
+		b.WriteByte(quote)
+	}
+}
+
+func (p *parser) parseLiteralString() string {
+	start := p.off
+	p.expect('\'')
+	for {
+		switch c := p.peek(); {
+		case p.eof() || c == '\n' || c == '\r':
+			p.errf(start, "unterminated string")
+		case c == '\'':
+			s := string(p.src[start+1 : p.off])
+			p.off++
+			return s
+		default:
+			p.stringChar(nil)
+		}
+	}
+}
+
+func (p *parser) parseMultilineLiteralString() string {
+	start := p.off
+	p.off += 3
+	p.newline()
+	var b strings.Builder
+	for {
+		switch {
+		case p.eof():
+			p.errf(start, "unterminated string")
+		case p.hasPrefix(`'''`):
+			p.closeMultiline(&b, '\'')
+			return b.String()
+		case p.newline():
+			b.WriteByte('\n')
+		default:
+			p.stringChar(&b)
+		}
+	}
+}
+
+// stringChar validates the character at the current position and writes it
+// to b, if b is not nil.
+func (p *parser) stringChar(b *strings.Builder) {
+	c := p.src[p.off]
+	if c < 0x20 && c != '\t' || c == 0x7f {
+		p.errf(p.off, "invalid control character in string")
+	}
+	r, size := utf8.DecodeRune(p.src[p.off:])
+	if r == utf8.RuneError && size == 1 {
+		p.errf(p.off, "invalid UTF-8 encoding")
+	}
+	if b != nil {
+		b.WriteRune(r)
+	}
+	p.off += size
+}
+
+func (p *parser) parseEscape(b *strings.Builder) {
+	start := p.off
+	p.off++ // backslash
+	c := p.peek()
+	p.off++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.off+n > len(p.src) {
+			p.errf(start, "invalid unicode escape")
+		}
+		var r rune
+		for _, d := range p.src[p.off : p.off+n] {
+			v, ok := hexValue(d)
+			if !ok {
+				p.errf(start, "invalid unicode escape")
+			}
+			r = r<<4 | rune(v)
+		}
+		if !utf8.ValidRune(r) {
+			p.errf(start, "invalid unicode code point in escape")
+		}
+		p.off += n
+		b.WriteRune(r)
+	default:
+		p.errf(start, "invalid escape sequence")
+	}
+}
+
+func hexValue(c byte) (int, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0'), true
+	case 'a' <= c && c <= 'f':
+		return int(c-'a') + 10, true
+	case 'A' <= c && c <= 'F':
+		return int(c-'A') + 10, true
+	}
+	return 0, false
+}
+
+var (
+	dateRE    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	timeRE    = regexp.MustCompile(`^\d{2}:\d{2}`)
+	decimalRE = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)$`)
+	floatRE   = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+// dateTimeLayouts lists the layouts of the TOML date and time types.
+// Fractional seconds are accepted by time.Parse even if not included in
+// the layout.
+var dateTimeLayouts = []string{
+	time.RFC3339,          // offset date-time
+	"2006-01-02T15:04:05", // local date-time
+	"2006-01-02",          // local date
+	"15:04:05",            // local time
+}
+
+func (p *parser) parseNumberOrDate() ast.Expr {
+	start := p.off
+	for !p.eof() && !isValueDelimiter(p.src[p.off]) {
+		p.off++
+	}
+	s := string(p.src[start:p.off])
+	// A date and time may be separated by a space.
+	if dateRE.MatchString(s) && p.off+3 < len(p.src) && p.src[p.off] == ' ' &&
+		timeRE.Match(p.src[p.off+1:]) {
+		p.off++
+		for !p.eof() && !isValueDelimiter(p.src[p.off]) {
+			p.off++
+		}
+		s = string(p.src[start:p.off])
+	}
+	pos := p.pos(start)
+
+	if s == "" {
+		p.errf(start, "expected value, found %s", p.describe())
+	}
+	if len(s) >= 10 && dateRE.MatchString(s[:10]) || timeRE.MatchString(s) {
+		return p.dateTime(start, s)
+	}
+
+	switch strings.TrimLeft(s, "+-") {
+	case "inf", "nan":
+		p.errf(start, "%s is not supported in CUE", s)
+	}
+
+	if len(s) > 2 && s[0] == '0' {
+		base := 0
+		switch s[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 0 {
+			digits := p.removeUnderscores(start+2, s[2:], isHexDigit)
+			i, ok := new(big.Int).SetString(digits, base)
+			if !ok || strings.ContainsAny(digits, "+-") {
+				p.errf(start, "invalid integer %s", s)
+			}
+			return &ast.BasicLit{ValuePos: pos, Kind: token.INT, Value: i.String()}
+		}
+	}
+
+	num := p.removeUnderscores(start, s, isDigit)
+	var kind token.Token
+	switch {
+	case decimalRE.MatchString(num):
+		kind = token.INT
+	case floatRE.MatchString(num):
+		kind = token.FLOAT
+	default:
+		p.errf(start, "invalid value %s", s)
+	}
+	return newNumber(pos, kind, num)
+}
+
+// newNumber returns a number literal, using a unary expression for negative
+// numbers as the CUE parser would.
+func newNumber(pos token.Pos, kind token.Token, s string) ast.Expr {
+	switch s[0] {
+	case '+':
+		s = s[1:]
+	case '-':
+		return &ast.UnaryExpr{
+			OpPos: pos,
+			Op:    token.SUB,
+			X:     &ast.BasicLit{ValuePos: pos, Kind: kind, Value: s[1:]},
+		}
+	}
+	return &ast.BasicLit{ValuePos: pos, Kind: kind, Value: s}
+}
+
+// removeUnderscores removes the underscores from a number, each of which
+// must be surrounded by digits.
+func (p *parser) removeUnderscores(start int, s string, isDigit func(byte) bool) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(s)-1 || !isDigit(s[i-1]) || !isDigit(s[i+1]) {
+			p.errf(start+i, "underscores in numbers must be surrounded by digits")
+		}
+	}
+	return strings.ReplaceAll(s, "_", "")
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isHexDigit(c byte) bool {
+	_, ok := hexValue(c)
+	return ok
+}
+
+func (p *parser) dateTime(start int, s string) ast.Expr {
+	// Normalize to RFC 3339 so that the result can be validated with
+	// time.Time and the like.
+	b := []byte(s)
+	if len(b) > 10 && (b[10] == ' ' || b[10] == 't') {
+		b[10] = 'T'
+	}
+	if n := len(b); n > 0 && b[n-1] == 'z' {
+		b[n-1] = 'Z'
+	}
+	norm := string(b)
+	for _, layout := range dateTimeLayouts {
+		if _, err := time.Parse(layout, norm); err == nil {
+			return newString(p.pos(start), norm)
+		}
+	}
+	p.errf(start, "invalid date or time %s", s)
+	return nil
+}
@@ -0,0 +1,257 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/internal"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "empty",
+		in:   "",
+		out:  "",
+	}, {
+		name: "scalars",
+		in: `
+str = "a\tb\u00e9"
+lit = 'C:\path'
+int = +1_000
+neg = -17
+hex = 0xdead_beef
+oct = 0o755
+bin = 0b1101
+float = 3.14
+exp = -5e+22
+t = true
+f = false
+`,
+		out: `
+str:   "a\tbé"
+lit:   "C:\\path"
+int:   1000
+neg:   -17
+hex:   3735928559
+oct:   493
+bin:   13
+float: 3.14
+exp:   -5e+22
+t:     true
+f:     false
+`,
+	}, {
+		name: "dates",
+		in: `
+odt = 1979-05-27 07:32:00.999z
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+lt = 07:32:00
+`,
+		out: `
+odt: "1979-05-27T07:32:00.999Z"
+ldt: "1979-05-27T07:32:00"
+ld:  "1979-05-27"
+lt:  "07:32:00"
+`,
+	}, {
+		name: "multiline strings",
+		in: `
+a = """
+Roses \
+  are red
+"Violets" ""are"" blue"""""
+b = '''
+C:\path
+'''
+`,
+		out: `
+a: "Roses are red\n\"Violets\" \"\"are\"\" blue\"\""
+b: "C:\\path\n"
+`,
+	}, {
+		name: "keys",
+		in: `
+"quoted key" = 1
+'_hidden' = 2
+a.b . "c.d" = 3
+a.e = 4
+`,
+		out: `
+"quoted key": 1
+"_hidden":    2
+a: {
+	b: "c.d": 3
+	e: 4
+}
+`,
+	}, {
+		name: "arrays and inline tables",
+		in: `
+a = [
+  1, # one
+  2,
+]
+b = { x = 1, y.z = [{ w = 2 }] }
+c = {}
+`,
+		out: `
+a: [1, 2]
+b: {
+	x: 1
+	y: z: [{
+		w: 2
+	}]
+}
+c: {}
+`,
+	}, {
+		name: "tables",
+		in: `
+# Header comment.
+
+# Doc of a.
+a = 1 # line comment
+
+[x.y]
+b = 2
+
+[x]
+c = 3
+
+# Products.
+[[products]]
+name = "Hammer"
+
+[[products]]
+
+[[products.parts]]
+name = "Nail"
+`,
+		out: `
+// Header comment.
+
+// Doc of a.
+a: 1 // line comment
+
+x: {
+	y: b: 2
+	c: 3
+}
+
+// Products.
+products: [
+	{
+		name: "Hammer"
+	},
+	{
+		parts: [
+			{
+				name: "Nail"
+			},
+		]
+	},
+]
+`,
+	}, {
+		name: "duplicate key",
+		in:   "a = 1\na = 2",
+		err:  `test.toml:2:1: key "a" is already defined`,
+	}, {
+		name: "duplicate table",
+		in:   "[a]\n[a]",
+		err:  `test.toml:2:1: table "a" is already defined`,
+	}, {
+		name: "table defined by dotted key",
+		in:   "a.b = 1\n[a]",
+		err:  `test.toml:2:1: table "a" is already defined`,
+	}, {
+		name: "extend inline table",
+		in:   "a = {}\na.b = 1",
+		err:  `test.toml:2:1: cannot extend key "a" with a dotted key`,
+	}, {
+		name: "extend static array",
+		in:   "a = []\n[[a]]",
+		err:  `test.toml:2:1: cannot define array of tables "a": key is already defined`,
+	}, {
+		name: "missing newline",
+		in:   "a = 1 b = 2",
+		err:  `test.toml:1:7: expected newline or end of file, found 'b'`,
+	}, {
+		name: "leading zero",
+		in:   "a = 01",
+		err:  `test.toml:1:5: invalid value 01`,
+	}, {
+		name: "underscore",
+		in:   "a = 1__0",
+		err:  `test.toml:1:6: underscores in numbers must be surrounded by digits`,
+	}, {
+		name: "nan",
+		in:   "a = nan",
+		err:  `test.toml:1:5: nan is not supported in CUE`,
+	}, {
+		name: "invalid date",
+		in:   "a = 1979-13-27",
+		err:  `test.toml:1:5: invalid date or time 1979-13-27`,
+	}, {
+		name: "unterminated string",
+		in:   "a = \"abc\n",
+		err:  `test.toml:1:5: unterminated string`,
+	}, {
+		name: "invalid escape",
+		in:   `a = "\x"`,
+		err:  `test.toml:1:6: invalid escape sequence`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDecoder("test.toml", strings.NewReader(tc.in))
+			expr, err := d.Decode()
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				got := fmt.Sprintf("%v: %v", err.(errors.Error).Position(), err)
+				if got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(internal.ToFile(expr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSpace(string(b)), strings.TrimSpace(tc.out); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := d.Decode(); err != io.EOF {
+				t.Errorf("second Decode: got %v; want io.EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+// An Encoder converts CUE values to TOML.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder that writes TOML to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the TOML encoding of v to the stream. The value must be
+// a concrete struct. Doc comments of fields are written as TOML comments.
+//
+// Structs are encoded as tables and lists of structs as arrays of tables,
+// except within lists, where inline tables are used.
+func (e *Encoder) Encode(v cue.Value) error {
+	v, _ = v.Default()
+	if err := v.Err(); err != nil {
+		return err
+	}
+	if v.Kind() != cue.StructKind {
+		return errors.Newf(v.Pos(), "toml: top-level value must be a struct, found %v", v.IncompleteKind())
+	}
+	enc := &encoder{}
+	if err := enc.encodeTable(nil, v, false); err != nil {
+		return err
+	}
+	_, err := e.w.Write(enc.buf.Bytes())
+	return err
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+type field struct {
+	key   string
+	value cue.Value
+}
+
+// encodeTable writes the table v at path. If isElem is true, the table is
+// an element of an array of tables.
+func (e *encoder) encodeTable(path []string, v cue.Value, isElem bool) error {
+	iter, err := v.Fields()
+	if err != nil {
+		return err
+	}
+	var values, tables []field
+	for iter.Next() {
+		f := field{key: iter.Selector().Unquoted(), value: iter.Value()}
+		f.value, _ = f.value.Default()
+		if isTable(f.value) || isArrayOfTables(f.value) {
+			tables = append(tables, f)
+		} else {
+			values = append(values, f)
+		}
+	}
+
+	// A header is only needed if the table has values of its own or would
+	// otherwise not appear in the output.
+	switch {
+	case isElem:
+		e.separate()
+		e.writeDoc(v)
+		fmt.Fprintf(&e.buf, "[[%s]]\n", formatPath(path))
+	case len(path) > 0 && (len(values) > 0 || len(tables) == 0 || len(v.Doc()) > 0):
+		e.separate()
+		e.writeDoc(v)
+		fmt.Fprintf(&e.buf, "[%s]\n", formatPath(path))
+	}
+
+	for _, f := range values {
+		s, err := formatValue(f.value)
+		if err != nil {
+			return err
+		}
+		e.writeDoc(f.value)
+		fmt.Fprintf(&e.buf, "%s = %s\n", formatKey(f.key), s)
+	}
+
+	for _, f := range tables {
+		p := append(path[:len(path):len(path)], f.key)
+		if isTable(f.value) {
+			if err := e.encodeTable(p, f.value, false); err != nil {
+				return err
+			}
+			continue
+		}
+		list, _ := f.value.List()
+		for list.Next() {
+			elem, _ := list.Value().Default()
+			if err := e.encodeTable(p, elem, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// separate writes a blank line between a table header and preceding output.
+func (e *encoder) separate() {
+	if e.buf.Len() > 0 {
+		e.buf.WriteByte('\n')
+	}
+}
+
+func (e *encoder) writeDoc(v cue.Value) {
+	for _, cg := range v.Doc() {
+		text := strings.TrimSuffix(cg.Text(), "\n")
+		for _, line := range strings.Split(text, "\n") {
+			if line == "" {
+				e.buf.WriteString("#\n")
+			} else {
+				fmt.Fprintf(&e.buf, "# %s\n", line)
+			}
+		}
+	}
+}
+
+func isTable(v cue.Value) bool {
+	return v.Kind() == cue.StructKind
+}
+
+func isArrayOfTables(v cue.Value) bool {
+	if v.Kind() != cue.ListKind {
+		return false
+	}
+	list, _ := v.List()
+	n := 0
+	for ; list.Next(); n++ {
+		if elem, _ := list.Value().Default(); !isTable(elem) {
+			return false
+		}
+	}
+	return n > 0
+}
+
+// formatValue returns the inline TOML representation of v.
+func formatValue(v cue.Value) (string, error) {
+	v, _ = v.Default()
+	switch v.Kind() {
+	case cue.BoolKind:
+		b, err := v.Bool()
+		return strconv.FormatBool(b), err
+
+	case cue.IntKind:
+		i, err := v.Int64()
+		if err != nil {
+			return "", errors.Wrapf(err, v.Pos(), "toml: integer %v out of range", v)
+		}
+		return strconv.FormatInt(i, 10), nil
+
+	case cue.FloatKind:
+		f, err := v.Float64()
+		if err != nil || math.IsInf(f, 0) {
+			return "", errors.Wrapf(err, v.Pos(), "toml: float %v out of range", v)
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s, nil
+
+	case cue.StringKind:
+		s, err := v.String()
+		return quote(s, true), err
+
+	case cue.ListKind:
+		list, err := v.List()
+		if err != nil {
+			return "", err
+		}
+		var elems []string
+		for list.Next() {
+			s, err := formatValue(list.Value())
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, s)
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+
+	case cue.StructKind:
+		iter, err := v.Fields()
+		if err != nil {
+			return "", err
+		}
+		var elems []string
+		for iter.Next() {
+			s, err := formatValue(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, formatKey(iter.Selector().Unquoted())+" = "+s)
+		}
+		if len(elems) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(elems, ", ") + " }", nil
+
+	case cue.NullKind:
+		return "", errors.Newf(v.Pos(), "toml: cannot encode null at %v", v.Path())
+
+	case cue.BytesKind:
+		return "", errors.Newf(v.Pos(), "toml: cannot encode bytes at %v", v.Path())
+	}
+	if err := v.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.Newf(v.Pos(), "toml: cannot encode incomplete value %v at %v", v, v.Path())
+}
+
+func formatPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, k := range path {
+		keys[i] = formatKey(k)
+	}
+	return strings.Join(keys, ".")
+}
+
+func formatKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for i := 0; i < len(k); i++ {
+		if !isBareKeyChar(k[i]) {
+			return quote(k, false)
+		}
+	}
+	return k
+}
+
+// quote returns s as a TOML basic string. If allowMultiline is true,
+// a multi-line basic string is used for strings with newlines.
+func quote(s string, allowMultiline bool) string {
+	multiline := allowMultiline && strings.Contains(s, "\n")
+	var b strings.Builder
+	if multiline {
+		b.WriteString("\"\"\"\n")
+	} else {
+		b.WriteByte('"')
+	}
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\n':
+			if multiline {
+				b.WriteByte('\n')
+			} else {
+				b.WriteString(`\n`)
+			}
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	if multiline {
+		b.WriteString(`"""`)
+	} else {
+		b.WriteByte('"')
+	}
+	return b.String()
+}
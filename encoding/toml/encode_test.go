@@ -0,0 +1,149 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "scalars",
+		in: `
+			str:   "a\tb\"c"
+			text:  "line 1\nline 2"
+			int:   1
+			float: 1.0
+			exp:   5e+22
+			bool:  true
+			"a b": "key"
+			`,
+		out: `
+str = "a\tb\"c"
+text = """
+line 1
+line 2"""
+int = 1
+float = 1.0
+exp = 5e+22
+bool = true
+"a b" = "key"
+`,
+	}, {
+		name: "tables",
+		in: `
+			// Doc of a.
+			a: 1
+			// Doc of x.
+			x: {
+				y: z: 2
+				b: 3
+			}
+			e: {}
+			l: [1, {a: 1}, []]
+			products: [{name: "Hammer"}, {}, {parts: [{name: "Nail"}]}]
+			`,
+		out: `
+# Doc of a.
+a = 1
+l = [1, { a = 1 }, []]
+
+# Doc of x.
+[x]
+b = 3
+
+[x.y]
+z = 2
+
+[e]
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+
+[[products]]
+
+[[products.parts]]
+name = "Nail"
+`,
+	}, {
+		name: "null",
+		in:   "a: b: null",
+		err:  "toml: cannot encode null at a.b",
+	}, {
+		name: "bytes",
+		in:   "a: 'foo'",
+		err:  "toml: cannot encode bytes at a",
+	}, {
+		name: "large int",
+		in:   "a: 1_000_000_000_000_000_000_000",
+		err:  "toml: integer 1000000000000000000000 out of range: value was rounded down",
+	}, {
+		name: "incomplete",
+		in:   "a: int",
+		err:  "toml: cannot encode incomplete value int at a",
+	}, {
+		name: "not a struct",
+		in:   "[1]",
+		err:  "toml: top-level value must be a struct, found list",
+	}}
+	ctx := cuecontext.New()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := ctx.CompileString(tc.in)
+			if err := v.Err(); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			err := NewEncoder(&buf).Encode(v)
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				if got := err.Error(); got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			if got, want := buf.String(), strings.TrimLeft(tc.out, "\n"); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+
+			// The output should decode to the original value.
+			expr, err := NewDecoder("test.toml", &buf).Decode()
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			w := ctx.BuildExpr(expr)
+			if !w.Equals(v) {
+				t.Errorf("round trip: got %v; want %v", w, v)
+			}
+		})
+	}
+}
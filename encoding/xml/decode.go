@@ -0,0 +1,272 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xml converts XML to and from CUE.
+//
+// XML documents are mapped to CUE using the following conventions:
+//
+//   - A document is a struct with a single field, named after the root
+//     element.
+//   - An element without attributes or child elements maps to a string
+//     holding its text content.
+//   - Any other element maps to a struct. Attributes are fields whose name is
+//     the attribute name prefixed with "$". Text content, with leading and
+//     trailing white space removed, is held in the field "$$", if not empty.
+//     Child elements are fields named after the element.
+//   - Sibling elements with the same name map to a list, in document order.
+//   - Names are used as written, including their namespace prefix, as in
+//     "soap:Body". Namespace declarations are attributes, as in
+//     "$xmlns:soap", so that prefixes can be resolved after a round trip.
+//   - Comments preceding an element are retained as doc comments.
+//
+// All values decoded from XML are strings. The relative order of text and
+// child elements of mixed content, as well as processing instructions and
+// directives other than the XML declaration, are not retained.
+//
+// When encoding CUE as XML, the same conventions apply in reverse. Numbers and
+// booleans are written in their CUE representation; null values map to
+// empty elements.
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+const (
+	// attrPrefix is the prefix of fields representing attributes.
+	attrPrefix = "$"
+
+	// textField is the name of the field holding the text content of an
+	// element with attributes or child elements.
+	textField = "$$"
+)
+
+// A Decoder reads an XML document and converts it to CUE.
+type Decoder struct {
+	filename string
+	r        io.Reader
+	done     bool
+}
+
+// NewDecoder returns a decoder that reads an XML document from r. The
+// filename is used for position information.
+func NewDecoder(filename string, r io.Reader) *Decoder {
+	return &Decoder{filename: filename, r: r}
+}
+
+// Decode parses the XML document and returns it as a CUE struct literal.
+// An XML input holds a single document, so any subsequent call returns
+// io.EOF.
+func (d *Decoder) Decode() (ast.Expr, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	d.done = true
+	src, err := io.ReadAll(d.r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := token.NewFile(d.filename, -1, len(src))
+	f.SetLinesForContent(src)
+	root, err := parse(f, src)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.StructLit{Elts: []ast.Decl{root.field()}}, nil
+}
+
+type element struct {
+	name     string
+	pos      token.Pos
+	attrs    []xml.Attr
+	children []*element
+	text     strings.Builder
+
+	// comments holds the comments preceding the element.
+	comments []string
+}
+
+func parse(f *token.File, src []byte) (*element, error) {
+	d := xml.NewDecoder(bytes.NewReader(src))
+	var (
+		root     *element
+		stack    []*element
+		comments []string
+	)
+	for {
+		off := int(d.InputOffset())
+		pos := f.Pos(off, token.NoRelPos)
+		// RawToken does not translate namespace prefixes, which are retained
+		// as written. It does not verify that start and end elements match,
+		// which is done below.
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, pos, "invalid XML")
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{
+				name:     qualifiedName(t.Name),
+				pos:      pos,
+				attrs:    t.Attr,
+				comments: comments,
+			}
+			comments = nil
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, errors.Newf(pos, "multiple root elements")
+				}
+				root = e
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			}
+			stack = append(stack, e)
+
+		case xml.EndElement:
+			name := qualifiedName(t.Name)
+			if len(stack) == 0 || stack[len(stack)-1].name != name {
+				return nil, errors.Newf(pos, "unexpected end element </%s>", name)
+			}
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if len(stack) == 0 {
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, errors.Newf(pos, "text outside of root element")
+				}
+				continue
+			}
+			e := stack[len(stack)-1]
+			e.text.Write(t)
+
+		case xml.Comment:
+			comments = append(comments, string(t))
+		}
+	}
+	switch {
+	case len(stack) > 0:
+		return nil, errors.Newf(stack[len(stack)-1].pos,
+			"element <%s> not closed", stack[len(stack)-1].name)
+	case root == nil:
+		return nil, errors.Newf(f.Pos(0, token.NoRelPos), "missing root element")
+	}
+	return root, nil
+}
+
+func qualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// field returns the CUE field representing e.
+func (e *element) field() *ast.Field {
+	f := &ast.Field{Label: label(e.name, e.pos), Value: e.value()}
+	addComments(f, e.comments)
+	return f
+}
+
+func (e *element) value() ast.Expr {
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		return newString(e.pos, e.text.String())
+	}
+
+	s := &ast.StructLit{Lbrace: e.pos}
+	for _, a := range e.attrs {
+		name := attrPrefix + qualifiedName(a.Name)
+		s.Elts = append(s.Elts, &ast.Field{
+			Label: label(name, e.pos),
+			Value: newString(e.pos, a.Value),
+		})
+	}
+	if text := strings.TrimSpace(e.text.String()); text != "" {
+		s.Elts = append(s.Elts, &ast.Field{
+			Label: label(textField, e.pos),
+			Value: newString(e.pos, text),
+		})
+	}
+
+	// Group elements with the same name in a list at the position of the
+	// first element.
+	lists := map[string]*ast.ListLit{}
+	count := map[string]int{}
+	for _, c := range e.children {
+		count[c.name]++
+	}
+	for _, c := range e.children {
+		if count[c.name] == 1 {
+			s.Elts = append(s.Elts, c.field())
+			continue
+		}
+		v := c.value()
+		ast.SetRelPos(v, token.Newline)
+		addComments(v, c.comments)
+		list := lists[c.name]
+		if list == nil {
+			list = &ast.ListLit{Lbrack: c.pos}
+			lists[c.name] = list
+			s.Elts = append(s.Elts, &ast.Field{Label: label(c.name, c.pos), Value: list})
+		}
+		list.Elts = append(list.Elts, v)
+		list.Rbrack = c.pos.WithRel(token.Newline)
+	}
+	return s
+}
+
+func addComments(n ast.Node, comments []string) {
+	if len(comments) == 0 {
+		return
+	}
+	cg := &ast.CommentGroup{Doc: true}
+	for _, c := range comments {
+		for _, line := range strings.Split(strings.TrimSpace(c), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				cg.List = append(cg.List, &ast.Comment{Text: "//"})
+			} else {
+				cg.List = append(cg.List, &ast.Comment{Text: "// " + line})
+			}
+		}
+	}
+	ast.AddComment(n, cg)
+}
+
+func label(name string, pos token.Pos) ast.Label {
+	if ast.IsValidIdent(name) &&
+		!strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") &&
+		!strings.HasPrefix(name, attrPrefix) {
+		return &ast.Ident{NamePos: pos, Name: name}
+	}
+	return newString(pos, name)
+}
+
+func newString(pos token.Pos, s string) *ast.BasicLit {
+	lit := ast.NewString(s)
+	lit.ValuePos = pos
+	return lit
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/internal"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "text",
+		in:   `<a>hello &amp; bye</a>`,
+		out:  `a: "hello & bye"`,
+	}, {
+		name: "empty",
+		in:   `<?xml version="1.0"?><a/>`,
+		out:  `a: ""`,
+	}, {
+		name: "attributes and text",
+		in:   `<a id="1" x-y="2"> text </a>`,
+		out: `
+a: {
+	"$id":  "1"
+	"$x-y": "2"
+	"$$":   "text"
+}`,
+	}, {
+		name: "children",
+		in: `
+<!-- The root. -->
+<a>
+  <b>1</b>
+  <c><d/></c>
+  <!-- First. -->
+  <b>2</b>
+  mixed
+</a>`,
+		out: `
+// The root.
+a: {
+	"$$": "mixed"
+	b: [
+		"1",
+		// First.
+		"2",
+	]
+	c: {
+		d: ""
+	}
+}`,
+	}, {
+		name: "namespaces",
+		in: `
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns="urn:x">
+  <soap:Body><m>x</m></soap:Body>
+</soap:Envelope>`,
+		out: `
+"soap:Envelope": {
+	"$xmlns:soap": "http://www.w3.org/2003/05/soap-envelope"
+	"$xmlns":      "urn:x"
+	"soap:Body": {
+		m: "x"
+	}
+}`,
+	}, {
+		name: "mismatched end",
+		in:   "<a>\n<b></a>",
+		err:  "test.xml:2:4: unexpected end element </a>",
+	}, {
+		name: "not closed",
+		in:   "<a>\n<b></b>",
+		err:  "test.xml:1:1: element <a> not closed",
+	}, {
+		name: "multiple roots",
+		in:   "<a/><b/>",
+		err:  "test.xml:1:5: multiple root elements",
+	}, {
+		name: "no root",
+		in:   "<!-- nothing -->",
+		err:  "test.xml:1:1: missing root element",
+	}, {
+		name: "syntax error",
+		in:   "<a><</a>",
+		err:  "test.xml:1:4: invalid XML: XML syntax error on line 1: expected element name after <",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDecoder("test.xml", strings.NewReader(tc.in))
+			expr, err := d.Decode()
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				got := fmt.Sprintf("%v: %v", err.(errors.Error).Position(), err)
+				if got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			b, err := format.Node(internal.ToFile(expr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSpace(string(b)), strings.TrimSpace(tc.out); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := d.Decode(); err != io.EOF {
+				t.Errorf("second Decode: got %v; want io.EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+// An Encoder converts CUE values to XML.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder that writes XML to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the XML encoding of v to the stream, following the
+// conventions described in the package documentation. The value must be
+// a concrete struct with a single field, which represents the root element.
+func (e *Encoder) Encode(v cue.Value) error {
+	v, _ = v.Default()
+	if err := v.Err(); err != nil {
+		return err
+	}
+	if v.Kind() != cue.StructKind {
+		return errors.Newf(v.Pos(), "xml: top-level value must be a struct, found %v", v.IncompleteKind())
+	}
+	iter, err := v.Fields()
+	if err != nil {
+		return err
+	}
+	var root []field
+	for iter.Next() {
+		root = append(root, field{iter.Selector().Unquoted(), iter.Value()})
+	}
+	if len(root) != 1 {
+		return errors.Newf(v.Pos(), "xml: top-level struct must have exactly one field, found %d", len(root))
+	}
+
+	enc := &encoder{}
+	enc.buf.WriteString(xml.Header)
+	if err := enc.encodeField(root[0].name, root[0].value, 0); err != nil {
+		return err
+	}
+	_, err = e.w.Write(enc.buf.Bytes())
+	return err
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+type field struct {
+	name  string
+	value cue.Value
+}
+
+const indent = "    "
+
+// encodeField writes the elements for the field with the given name and
+// value at the given nesting depth.
+func (e *encoder) encodeField(name string, v cue.Value, depth int) error {
+	if !isName(name) {
+		return errors.Newf(v.Pos(), "xml: invalid element name %q", name)
+	}
+	v, _ = v.Default()
+	if v.Kind() != cue.ListKind {
+		return e.encodeElement(name, v, depth)
+	}
+	list, err := v.List()
+	if err != nil {
+		return err
+	}
+	for list.Next() {
+		elem, _ := list.Value().Default()
+		if elem.Kind() == cue.ListKind {
+			return errors.Newf(elem.Pos(), "xml: cannot encode nested list at %v", elem.Path())
+		}
+		if err := e.encodeElement(name, elem, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeElement(name string, v cue.Value, depth int) error {
+	prefix := strings.Repeat(indent, depth)
+	e.writeDoc(v, prefix)
+
+	if v.Kind() != cue.StructKind {
+		text, err := scalar(v)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			fmt.Fprintf(&e.buf, "%s<%s/>\n", prefix, name)
+			return nil
+		}
+		fmt.Fprintf(&e.buf, "%s<%s>", prefix, name)
+		xml.EscapeText(&e.buf, []byte(text))
+		fmt.Fprintf(&e.buf, "</%s>\n", name)
+		return nil
+	}
+
+	iter, err := v.Fields()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&e.buf, "%s<%s", prefix, name)
+	var text *cue.Value
+	var children []field
+	for iter.Next() {
+		f := field{iter.Selector().Unquoted(), iter.Value()}
+		switch {
+		case f.name == textField:
+			text = &f.value
+		case strings.HasPrefix(f.name, attrPrefix):
+			attr := strings.TrimPrefix(f.name, attrPrefix)
+			if !isName(attr) {
+				return errors.Newf(f.value.Pos(), "xml: invalid attribute name %q", attr)
+			}
+			s, err := scalar(f.value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&e.buf, " %s=\"", attr)
+			xml.EscapeText(&e.buf, []byte(s))
+			e.buf.WriteByte('"')
+		default:
+			children = append(children, f)
+		}
+	}
+
+	switch {
+	case text == nil && len(children) == 0:
+		e.buf.WriteString("/>\n")
+		return nil
+
+	case len(children) == 0:
+		s, err := scalar(*text)
+		if err != nil {
+			return err
+		}
+		e.buf.WriteByte('>')
+		xml.EscapeText(&e.buf, []byte(s))
+		fmt.Fprintf(&e.buf, "</%s>\n", name)
+		return nil
+	}
+
+	e.buf.WriteString(">\n")
+	if text != nil {
+		s, err := scalar(*text)
+		if err != nil {
+			return err
+		}
+		e.buf.WriteString(prefix + indent)
+		xml.EscapeText(&e.buf, []byte(s))
+		e.buf.WriteByte('\n')
+	}
+	for _, c := range children {
+		if err := e.encodeField(c.name, c.value, depth+1); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(&e.buf, "%s</%s>\n", prefix, name)
+	return nil
+}
+
+func (e *encoder) writeDoc(v cue.Value, prefix string) {
+	for _, cg := range v.Doc() {
+		text := strings.TrimSpace(cg.Text())
+		// "--" is not allowed within XML comments.
+		text = strings.ReplaceAll(text, "--", "- -")
+		if strings.Contains(text, "\n") {
+			text = strings.ReplaceAll(text, "\n", "\n"+prefix+indent)
+			fmt.Fprintf(&e.buf, "%s<!--\n%s%s%s\n%s-->\n", prefix, prefix, indent, text, prefix)
+		} else {
+			fmt.Fprintf(&e.buf, "%s<!-- %s -->\n", prefix, text)
+		}
+	}
+}
+
+// scalar returns the text representation of a value that is not a struct
+// or list.
+func scalar(v cue.Value) (string, error) {
+	v, _ = v.Default()
+	switch v.Kind() {
+	case cue.StringKind:
+		return v.String()
+	case cue.IntKind, cue.FloatKind, cue.BoolKind:
+		return fmt.Sprint(v), nil
+	case cue.NullKind:
+		return "", nil
+	case cue.BytesKind:
+		return "", errors.Newf(v.Pos(), "xml: cannot encode bytes at %v", v.Path())
+	case cue.StructKind, cue.ListKind:
+		return "", errors.Newf(v.Pos(), "xml: cannot encode %v as text or attribute at %v", v.Kind(), v.Path())
+	}
+	if err := v.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.Newf(v.Pos(), "xml: cannot encode incomplete value %v at %v", v, v.Path())
+}
+
+// isName reports whether s is a valid XML name, optionally with a namespace
+// prefix.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || r == ':' ||
+			'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r >= 0xC0:
+		case i > 0 && (r == '-' || r == '.' || '0' <= r && r <= '9' || r == 0xB7):
+		default:
+			return false
+		}
+	}
+	return true
+}
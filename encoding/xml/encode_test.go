@@ -0,0 +1,117 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{{
+		name: "elements",
+		in: `
+			// The root.
+			"p:a": {
+				"$xmlns:p": "urn:p"
+				"$id":      1
+				s:          "x < y"
+				n:          1.5
+				b:          true
+				e:          null
+				l: ["1", {"$k": "v", "$$": "t"}]
+				c: {"$$": "text", d: {}}
+			}
+			`,
+		out: `
+<?xml version="1.0" encoding="UTF-8"?>
+<!-- The root. -->
+<p:a xmlns:p="urn:p" id="1">
+    <s>x &lt; y</s>
+    <n>1.5</n>
+    <b>true</b>
+    <e/>
+    <l>1</l>
+    <l k="v">t</l>
+    <c>
+        text
+        <d/>
+    </c>
+</p:a>
+`,
+	}, {
+		name: "multiple roots",
+		in:   "a: 1, b: 2",
+		err:  "xml: top-level struct must have exactly one field, found 2",
+	}, {
+		name: "invalid name",
+		in:   `a: "1b": 1`,
+		err:  `xml: invalid element name "1b"`,
+	}, {
+		name: "nested list",
+		in:   "a: b: [[1]]",
+		err:  "xml: cannot encode nested list at a.b[0]",
+	}, {
+		name: "struct attribute",
+		in:   `a: "$b": {}`,
+		err:  "xml: cannot encode struct as text or attribute at a.$b",
+	}, {
+		name: "bytes",
+		in:   "a: 'foo'",
+		err:  "xml: cannot encode bytes at a",
+	}, {
+		name: "incomplete",
+		in:   "a: int",
+		err:  "xml: cannot encode incomplete value int at a",
+	}}
+	ctx := cuecontext.New()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := ctx.CompileString(tc.in)
+			if err := v.Err(); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			err := NewEncoder(&buf).Encode(v)
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tc.err)
+				}
+				if got := err.Error(); got != tc.err {
+					t.Fatalf("got error %q; want %q", got, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(errors.Details(err, nil))
+			}
+			if got, want := buf.String(), strings.TrimLeft(tc.out, "\n"); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := NewDecoder("test.xml", &buf).Decode(); err != nil {
+				t.Errorf("output is not valid XML: %v", err)
+			}
+		})
+	}
+}
@@ -105,6 +105,92 @@ func EncodeStream(iter cue.Iterator) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// An Encoder writes the YAML encoding of CUE values to an output stream.
+//
+// Unlike Encode, an Encoder encodes the top-level fields of a struct and the
+// elements of a list one at a time, writing each to the output before
+// encoding the next. This bounds memory usage to that needed for the largest
+// such field or element, rather than the entire value.
+type Encoder struct {
+	w io.Writer
+	n int
+}
+
+// NewEncoder returns an Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the YAML encoding of v to the stream. Consecutive values are
+// separated with a `---`, as with EncodeStream. The output for each value is
+// identical to that of Encode.
+//
+// If an error is returned, the stream may have received part of the encoding.
+func (e *Encoder) Encode(v cue.Value) error {
+	if e.n > 0 {
+		if _, err := io.WriteString(e.w, "---\n"); err != nil {
+			return err
+		}
+	}
+	e.n++
+
+	v, _ = v.Default()
+	switch v.Kind() {
+	case cue.StructKind:
+		iter, err := v.Fields()
+		if err != nil {
+			break
+		}
+		i := 0
+		for ; iter.Next(); i++ {
+			n := iter.Value().Syntax(cue.Final())
+			x, ok := n.(ast.Expr)
+			if !ok {
+				return e.encodeNode(n)
+			}
+			f := &ast.StructLit{Elts: []ast.Decl{
+				&ast.Field{Label: ast.NewString(iter.Label()), Value: x},
+			}}
+			if err := e.encodeNode(f); err != nil {
+				return err
+			}
+		}
+		if i > 0 {
+			return nil
+		}
+
+	case cue.ListKind:
+		iter, err := v.List()
+		if err != nil {
+			break
+		}
+		i := 0
+		for ; iter.Next(); i++ {
+			n := iter.Value().Syntax(cue.Final())
+			x, ok := n.(ast.Expr)
+			if !ok {
+				return e.encodeNode(n)
+			}
+			if err := e.encodeNode(ast.NewList(x)); err != nil {
+				return err
+			}
+		}
+		if i > 0 {
+			return nil
+		}
+	}
+	return e.encodeNode(v.Syntax(cue.Final()))
+}
+
+func (e *Encoder) encodeNode(n ast.Node) error {
+	b, err := cueyaml.Encode(n)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
 // Validate validates the YAML and confirms it matches the constraints
 // specified by v. For YAML streams, all values must match v.
 func Validate(b []byte, v cue.Value) error {
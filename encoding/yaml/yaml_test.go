@@ -15,6 +15,8 @@
 package yaml
 
 import (
+	"bytes"
+	"io"
 	"strings"
 	"testing"
 
@@ -137,6 +139,14 @@ null
 				if got := strings.TrimSpace(string(b)); got != yamlOut {
 					t.Errorf("Encode:\ngot  %q\nwant %q", got, yamlOut)
 				}
+
+				var buf bytes.Buffer
+				if err := NewEncoder(&buf).Encode(inst.Value()); err != nil {
+					t.Error(err)
+				}
+				if got := buf.String(); got != string(b) {
+					t.Errorf("Encoder:\ngot  %q\nwant %q", got, b)
+				}
 			} else {
 				iter, _ := inst.Value().List()
 				b, err := EncodeStream(iter)
@@ -146,6 +156,17 @@ null
 				if got := string(b); got != yamlOut {
 					t.Errorf("EncodeStream:\ngot  %q\nwant %q", got, yamlOut)
 				}
+
+				var buf bytes.Buffer
+				enc := NewEncoder(&buf)
+				for iter, _ = inst.Value().List(); iter.Next(); {
+					if err := enc.Encode(iter.Value()); err != nil {
+						t.Error(err)
+					}
+				}
+				if got := buf.String(); got != yamlOut {
+					t.Errorf("Encoder:\ngot  %q\nwant %q", got, yamlOut)
+				}
 			}
 		})
 	}
@@ -245,3 +266,41 @@ func TestYAMLValues(t *testing.T) {
 		})
 	}
 }
+
+func TestEncoder(t *testing.T) {
+	testCases := []string{
+		`{}`,
+		`[]`,
+		`"a"`,
+		`a: 1, b: "two", "c-d": [1, {e: 3}]`,
+		`[{a: 1, b: [2, 3]}, [4, [5]], {}, []]`,
+		`a: """
+			multi
+			line
+			"""
+		b: c: d: true`,
+		`a: *1 | int, #b: 2, c?: 3, _d: 4`,
+	}
+	ctx := cuecontext.New()
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			v := ctx.CompileString(tc)
+			want, err := Encode(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := NewEncoder(&buf).Encode(v); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+
+	v := ctx.CompileString(`a: int`)
+	if err := NewEncoder(io.Discard).Encode(v); err == nil {
+		t.Errorf("expected error encoding incomplete value")
+	}
+}
@@ -0,0 +1,565 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package astcodec implements a compact binary encoding of parsed CUE files.
+//
+// The encoding is intended for caching the result of parsing so that it can
+// be shared between processes. It is not a stable format and must only be
+// decoded by the same version of CUE that encoded it; Version identifies the
+// format.
+//
+// Positions are encoded as offsets and are restored relative to a
+// token.File built from the original source, so the source must be
+// available when decoding. Identifier resolution is not encoded: it is
+// recomputed on decoding.
+package astcodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/token"
+)
+
+// Version identifies the encoding format. It must be changed whenever the
+// encoding or the AST types change in an incompatible way.
+const Version = "astcodec-v1"
+
+// nodeTypes lists all concrete node types that may be stored in an
+// interface-typed field. The index of a type in this list is used to
+// identify it in the encoding, so entries may only be appended.
+var nodeTypes = []reflect.Type{
+	reflect.TypeOf(ast.Comment{}),
+	reflect.TypeOf(ast.CommentGroup{}),
+	reflect.TypeOf(ast.Attribute{}),
+	reflect.TypeOf(ast.Field{}),
+	reflect.TypeOf(ast.Alias{}),
+	reflect.TypeOf(ast.Comprehension{}),
+	reflect.TypeOf(ast.BadExpr{}),
+	reflect.TypeOf(ast.BottomLit{}),
+	reflect.TypeOf(ast.Ident{}),
+	reflect.TypeOf(ast.BasicLit{}),
+	reflect.TypeOf(ast.Interpolation{}),
+	reflect.TypeOf(ast.Func{}),
+	reflect.TypeOf(ast.StructLit{}),
+	reflect.TypeOf(ast.ListLit{}),
+	reflect.TypeOf(ast.Ellipsis{}),
+	reflect.TypeOf(ast.ForClause{}),
+	reflect.TypeOf(ast.IfClause{}),
+	reflect.TypeOf(ast.LetClause{}),
+	reflect.TypeOf(ast.ParenExpr{}),
+	reflect.TypeOf(ast.SelectorExpr{}),
+	reflect.TypeOf(ast.IndexExpr{}),
+	reflect.TypeOf(ast.SliceExpr{}),
+	reflect.TypeOf(ast.CallExpr{}),
+	reflect.TypeOf(ast.UnaryExpr{}),
+	reflect.TypeOf(ast.BinaryExpr{}),
+	reflect.TypeOf(ast.ImportSpec{}),
+	reflect.TypeOf(ast.BadDecl{}),
+	reflect.TypeOf(ast.ImportDecl{}),
+	reflect.TypeOf(ast.EmbedDecl{}),
+	reflect.TypeOf(ast.File{}),
+	reflect.TypeOf(ast.Package{}),
+}
+
+var typeIndex = map[reflect.Type]int{}
+
+// plans holds the fields to encode for each struct type reachable from
+// nodeTypes, and isNode whether a pointer to such a type is an ast.Node.
+// They are computed once to avoid repeated reflection on types.
+var (
+	plans  = map[reflect.Type][]int{}
+	isNode = map[reflect.Type]bool{}
+)
+
+var (
+	posType   = reflect.TypeOf(token.Pos{})
+	identType = reflect.TypeOf(ast.Ident{})
+	fileType  = reflect.TypeOf(ast.File{})
+	nodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+func init() {
+	for i, t := range nodeTypes {
+		typeIndex[t] = i
+		addPlan(t)
+	}
+}
+
+func addPlan(t reflect.Type) {
+	if _, ok := plans[t]; ok || t == posType {
+		return
+	}
+	var fields []int
+	plans[t] = nil // guard against recursion
+	isNode[reflect.PtrTo(t)] = reflect.PtrTo(t).Implements(nodeType)
+	for i := 0; i < t.NumField(); i++ {
+		if skipField(t, i) {
+			continue
+		}
+		fields = append(fields, i)
+		ft := t.Field(i).Type
+		for ft.Kind() == reflect.Slice || ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			addPlan(ft)
+		}
+	}
+	plans[t] = fields
+}
+
+// skipField reports whether a field of a node type is not encoded, because it
+// is not exported or because it is recomputed on decoding.
+func skipField(t reflect.Type, i int) bool {
+	f := t.Field(i)
+	if !f.IsExported() {
+		return true
+	}
+	switch {
+	case t == identType:
+		return f.Name == "Scope" || f.Name == "Node"
+	case t == fileType:
+		return f.Name == "Unresolved"
+	}
+	return false
+}
+
+// Encode encodes the file f, which must have been produced by parsing a
+// single source. It returns an error if f contains nodes that cannot be
+// encoded, such as positions from other sources.
+func Encode(f *ast.File) (b []byte, err error) {
+	e := &encoder{ptrs: map[uintptr]int{}}
+	defer func() {
+		if r := recover(); r != nil {
+			if ee, ok := r.(encodeError); ok {
+				err = ee
+				return
+			}
+			panic(r)
+		}
+	}()
+	e.value(reflect.ValueOf(f).Elem())
+	e.comments(f)
+	return e.buf.Bytes(), nil
+}
+
+// EncodeStandalone is like Encode, but also records the size and line offset
+// table of the file from which f was parsed, so that it can be decoded with
+// DecodeStandalone without access to the original source. Position-altering
+// //line comments are not recorded.
+func EncodeStandalone(f *ast.File) ([]byte, error) {
+	body, err := Encode(f)
+	if err != nil {
+		return nil, err
+	}
+	e := &encoder{}
+	var size int
+	var lines []int
+	if file := findFile(f); file != nil {
+		size, lines = file.Size(), file.Lines()
+	}
+	e.uint(uint64(size))
+	e.uint(uint64(len(lines)))
+	last := 0
+	for _, l := range lines {
+		e.uint(uint64(l - last))
+		last = l
+	}
+	e.buf.Write(body)
+	return e.buf.Bytes(), nil
+}
+
+// findFile returns the token.File of the first position found in f.
+func findFile(f *ast.File) (file *token.File) {
+	ast.Walk(f, func(n ast.Node) bool {
+		if file == nil {
+			file = n.Pos().File()
+		}
+		return file == nil
+	}, nil)
+	return file
+}
+
+type encodeError struct{ error }
+
+type encoder struct {
+	buf  bytes.Buffer
+	file *token.File
+	ptrs map[uintptr]int
+	tmp  [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) errorf(format string, args ...interface{}) {
+	panic(encodeError{fmt.Errorf(format, args...)})
+}
+
+func (e *encoder) uint(x uint64) {
+	n := binary.PutUvarint(e.tmp[:], x)
+	e.buf.Write(e.tmp[:n])
+}
+
+func (e *encoder) int(x int64) {
+	n := binary.PutVarint(e.tmp[:], x)
+	e.buf.Write(e.tmp[:n])
+}
+
+func (e *encoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *encoder) pos(p token.Pos) {
+	switch f := p.File(); {
+	case f == nil:
+		e.uint(0)
+		e.uint(uint64(p.RelPos()))
+	case e.file == nil:
+		e.file = f
+		fallthrough
+	case f == e.file:
+		e.uint(1)
+		e.uint(uint64(p.RelPos()))
+		e.uint(uint64(p.Offset()))
+	default:
+		e.errorf("astcodec: position %v not in encoded file", p)
+	}
+}
+
+// ptr encodes a pointer to a node, preserving sharing of nodes.
+func (e *encoder) ptr(v reflect.Value) {
+	if v.IsNil() {
+		e.uint(0)
+		return
+	}
+	if id, ok := e.ptrs[v.Pointer()]; ok {
+		e.uint(uint64(id) + 2)
+		return
+	}
+	e.ptrs[v.Pointer()] = len(e.ptrs)
+	e.uint(1)
+	e.value(v.Elem())
+	if isNode[v.Type()] {
+		e.comments(v.Interface().(ast.Node))
+	}
+}
+
+func (e *encoder) comments(n ast.Node) {
+	cgs := ast.Comments(n)
+	e.uint(uint64(len(cgs)))
+	for _, cg := range cgs {
+		e.ptr(reflect.ValueOf(cg))
+	}
+}
+
+func (e *encoder) value(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.uint(1)
+		} else {
+			e.uint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.uint(0)
+			return
+		}
+		e.uint(uint64(v.Len()) + 1)
+		for i := 0; i < v.Len(); i++ {
+			e.value(v.Index(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			e.uint(0)
+			return
+		}
+		elem := v.Elem()
+		if elem.Kind() != reflect.Ptr {
+			e.errorf("astcodec: unsupported node type %v", elem.Type())
+		}
+		i, ok := typeIndex[elem.Type().Elem()]
+		if !ok {
+			e.errorf("astcodec: unsupported node type %v", elem.Type())
+		}
+		e.uint(uint64(i) + 1)
+		e.ptr(elem)
+	case reflect.Ptr:
+		e.ptr(v)
+	case reflect.Struct:
+		if v.Type() == posType {
+			e.pos(v.Interface().(token.Pos))
+			return
+		}
+		for _, i := range plans[v.Type()] {
+			e.value(v.Field(i))
+		}
+	default:
+		e.errorf("astcodec: unsupported kind %v", v.Kind())
+	}
+}
+
+// Decode decodes a file encoded with Encode. The filename and src must be
+// those of the file originally parsed; they are used to reconstruct
+// position information.
+func Decode(filename string, src []byte, data []byte) (f *ast.File, err error) {
+	// Strings are sliced from a single copy of data to avoid allocating
+	// each of them separately.
+	d := &decoder{data: string(data)}
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(decodeError); ok {
+				f, err = nil, de
+				return
+			}
+			panic(r)
+		}
+	}()
+	d.file = token.NewFile(filename, -1, len(src))
+	d.file.SetLinesForContent(src)
+	return d.decode(filename), nil
+}
+
+// DecodeStandalone decodes a file encoded with EncodeStandalone.
+func DecodeStandalone(filename string, data []byte) (f *ast.File, err error) {
+	d := &decoder{data: string(data)}
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(decodeError); ok {
+				f, err = nil, de
+				return
+			}
+			panic(r)
+		}
+	}()
+	size := d.uint()
+	n := d.uint()
+	if size > uint64(len(data))<<32 || n > size+1 {
+		d.errorf("astcodec: corrupt data: invalid line table")
+	}
+	lines := make([]int, n)
+	last := 0
+	for i := range lines {
+		last += int(d.uint())
+		lines[i] = last
+	}
+	d.file = token.NewFile(filename, -1, int(size))
+	if len(lines) > 0 && !d.file.SetLines(lines) {
+		d.errorf("astcodec: corrupt data: invalid line table")
+	}
+	return d.decode(filename), nil
+}
+
+func (d *decoder) decode(filename string) *ast.File {
+	f := &ast.File{}
+	d.value(reflect.ValueOf(f).Elem())
+	d.comments(f)
+	if n := len(d.data) - d.off; n != 0 {
+		d.errorf("astcodec: %d bytes of trailing data", n)
+	}
+	f.Filename = filename
+	astutil.Resolve(f, func(token.Pos, string, ...interface{}) {})
+	return f
+}
+
+type decodeError struct{ error }
+
+type decoder struct {
+	data string
+	off  int
+	file *token.File
+	ptrs []reflect.Value
+}
+
+func (d *decoder) errorf(format string, args ...interface{}) {
+	panic(decodeError{fmt.Errorf(format, args...)})
+}
+
+func (d *decoder) uint() uint64 {
+	var x uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if d.off >= len(d.data) {
+			break
+		}
+		b := d.data[d.off]
+		d.off++
+		x |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return x
+		}
+	}
+	d.errorf("astcodec: corrupt data: invalid varint")
+	return 0
+}
+
+func (d *decoder) int() int64 {
+	ux := d.uint()
+	x := int64(ux >> 1)
+	if ux&1 != 0 {
+		x = ^x
+	}
+	return x
+}
+
+func (d *decoder) string() string {
+	n := d.uint()
+	if n > uint64(len(d.data)-d.off) {
+		d.errorf("astcodec: corrupt data: string too long")
+	}
+	s := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return s
+}
+
+func (d *decoder) pos() token.Pos {
+	kind := d.uint()
+	rel := token.RelPos(d.uint())
+	if kind == 0 {
+		return token.NoPos.WithRel(rel)
+	}
+	offset := d.uint()
+	if offset > uint64(d.file.Size()) {
+		d.errorf("astcodec: corrupt data: offset out of range")
+	}
+	return d.file.Pos(int(offset), rel)
+}
+
+// ptr decodes a pointer to a node of type t, which must be a pointer type.
+func (d *decoder) ptr(t reflect.Type) reflect.Value {
+	switch x := d.uint(); x {
+	case 0:
+		return reflect.Zero(t)
+	case 1:
+		v := reflect.New(t.Elem())
+		d.ptrs = append(d.ptrs, v)
+		d.value(v.Elem())
+		if isNode[t] {
+			d.comments(v.Interface().(ast.Node))
+		}
+		return v
+	default:
+		id := x - 2
+		if id >= uint64(len(d.ptrs)) || d.ptrs[id].Type() != t {
+			d.errorf("astcodec: corrupt data: invalid node reference")
+		}
+		return d.ptrs[id]
+	}
+}
+
+func (d *decoder) comments(n ast.Node) {
+	for i := d.uint(); i > 0; i-- {
+		cg := d.ptr(reflect.TypeOf(&ast.CommentGroup{}))
+		ast.AddComment(n, cg.Interface().(*ast.CommentGroup))
+	}
+}
+
+// setNode assigns n to the interface-typed v. It avoids the cost of the
+// general reflection-based assignment for the common node interfaces.
+func (d *decoder) setNode(v reflect.Value, n ast.Node) {
+	var x interface{}
+	var ok bool
+	switch v.Type() {
+	case nodeType:
+		x, ok = &n, true
+	case exprType:
+		var e ast.Expr
+		e, ok = n.(ast.Expr)
+		x = &e
+	case declType:
+		var e ast.Decl
+		e, ok = n.(ast.Decl)
+		x = &e
+	case labelType:
+		var e ast.Label
+		e, ok = n.(ast.Label)
+		x = &e
+	case clauseType:
+		var e ast.Clause
+		e, ok = n.(ast.Clause)
+		x = &e
+	default:
+		p := reflect.ValueOf(n)
+		if !p.Type().AssignableTo(v.Type()) {
+			d.errorf("astcodec: corrupt data: %v is not a %v", p.Type(), v.Type())
+		}
+		v.Set(p)
+		return
+	}
+	if !ok {
+		d.errorf("astcodec: corrupt data: %T is not a %v", n, v.Type())
+	}
+	v.Set(reflect.ValueOf(x).Elem())
+}
+
+var (
+	exprType   = reflect.TypeOf((*ast.Expr)(nil)).Elem()
+	declType   = reflect.TypeOf((*ast.Decl)(nil)).Elem()
+	labelType  = reflect.TypeOf((*ast.Label)(nil)).Elem()
+	clauseType = reflect.TypeOf((*ast.Clause)(nil)).Elem()
+)
+
+func (d *decoder) value(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(d.uint() != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(d.int())
+	case reflect.String:
+		v.SetString(d.string())
+	case reflect.Slice:
+		n := d.uint()
+		if n == 0 {
+			return
+		}
+		n--
+		if n > uint64(len(d.data)-d.off) {
+			d.errorf("astcodec: corrupt data: slice too long")
+		}
+		s := reflect.MakeSlice(v.Type(), int(n), int(n))
+		for i := 0; i < int(n); i++ {
+			d.value(s.Index(i))
+		}
+		v.Set(s)
+	case reflect.Interface:
+		i := d.uint()
+		if i == 0 {
+			return
+		}
+		if i > uint64(len(nodeTypes)) {
+			d.errorf("astcodec: corrupt data: unknown node type")
+		}
+		p := d.ptr(reflect.PtrTo(nodeTypes[i-1]))
+		d.setNode(v, p.Interface().(ast.Node))
+	case reflect.Ptr:
+		v.Set(d.ptr(v.Type()))
+	case reflect.Struct:
+		if v.Type() == posType {
+			v.Set(reflect.ValueOf(d.pos()))
+			return
+		}
+		for _, i := range plans[v.Type()] {
+			d.value(v.Field(i))
+		}
+	default:
+		d.errorf("astcodec: unsupported kind %v", v.Kind())
+	}
+}
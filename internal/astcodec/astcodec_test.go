@@ -0,0 +1,135 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astcodec
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/astinternal"
+)
+
+// TestRoundTrip checks that all CUE files in the repository survive an
+// encoding round trip with their syntax, comments and positions intact.
+func TestRoundTrip(t *testing.T) {
+	n := 0
+	err := filepath.WalkDir("../..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".cue") {
+			return err
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		want, err := parser.ParseFile(path, src, parser.ParseComments)
+		if err != nil {
+			return nil // only valid files are cached
+		}
+		n++
+		t.Run(path, func(t *testing.T) {
+			data, err := Encode(want)
+			qt.Assert(t, qt.IsNil(err))
+			got, err := Decode(path, src, data)
+			qt.Assert(t, qt.IsNil(err))
+
+			qt.Assert(t, qt.Equals(astinternal.DebugStr(got), astinternal.DebugStr(want)))
+			qt.Assert(t, qt.DeepEquals(positions(got), positions(want)))
+			qt.Assert(t, qt.Equals(len(got.Unresolved), len(want.Unresolved)))
+
+			wantFmt, err := format.Node(want)
+			qt.Assert(t, qt.IsNil(err))
+			gotFmt, err := format.Node(got)
+			qt.Assert(t, qt.IsNil(err))
+			qt.Assert(t, qt.Equals(string(gotFmt), string(wantFmt)))
+
+			data, err = EncodeStandalone(want)
+			qt.Assert(t, qt.IsNil(err))
+			got, err = DecodeStandalone(path, data)
+			qt.Assert(t, qt.IsNil(err))
+			qt.Assert(t, qt.DeepEquals(positions(got), positions(want)))
+		})
+		return nil
+	})
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.IsTrue(n > 100))
+}
+
+func positions(f *ast.File) (a []string) {
+	ast.Walk(f, func(n ast.Node) bool {
+		a = append(a, n.Pos().String()+"-"+n.End().String()+":"+n.Pos().RelPos().String())
+		if id, ok := n.(*ast.Ident); ok && id.Node != nil {
+			a = append(a, "ref:"+id.Node.Pos().String())
+		}
+		return true
+	}, nil)
+	return a
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	src := []byte("a: 1\nb: a // comment\n")
+	f, err := parser.ParseFile("x.cue", src, parser.ParseComments)
+	qt.Assert(t, qt.IsNil(err))
+	data, err := Encode(f)
+	qt.Assert(t, qt.IsNil(err))
+	for i := 0; i < len(data); i++ {
+		_, err := Decode("x.cue", src, data[:i])
+		qt.Assert(t, qt.IsNotNil(err))
+	}
+}
+
+func benchmarkSource(b *testing.B) []byte {
+	src, err := os.ReadFile("../../pkg/tool/exec/exec.cue")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return src
+}
+
+func BenchmarkParse(b *testing.B) {
+	src := benchmarkSource(b)
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseFile("exec.cue", src, parser.ParseComments); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	src := benchmarkSource(b)
+	f, err := parser.ParseFile("exec.cue", src, parser.ParseComments)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, err := Encode(f)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode("exec.cue", src, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/literal"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal"
 )
@@ -62,6 +63,9 @@ func DebugStr(x interface{}) (out string) {
 		return "_|_"
 
 	case *ast.BasicLit:
+		if v.Raw != nil {
+			return literal.Bytes.Quote(string(v.Raw))
+		}
 		return v.Value
 
 	case *ast.Interpolation:
@@ -162,11 +162,19 @@ func BinOp(c *OpContext, op Op, left, right Value) Value {
 			return c.Add(c.Num(left, op), c.Num(right, op))
 
 		case leftKind == StringKind && rightKind == StringKind:
-			return c.NewString(c.StringValue(left) + c.StringValue(right))
+			sa := c.StringValue(left)
+			sb := c.StringValue(right)
+			if err := c.checkSize(len(sa)+len(sb), 1); err != nil {
+				return err
+			}
+			return c.NewString(sa + sb)
 
 		case leftKind == BytesKind && rightKind == BytesKind:
 			ba := c.bytesValue(left, op)
 			bb := c.bytesValue(right, op)
+			if err := c.checkSize(len(ba)+len(bb), 1); err != nil {
+				return err
+			}
 			b := make([]byte, len(ba)+len(bb))
 			copy(b, ba)
 			copy(b[len(ba):], bb)
@@ -222,19 +230,19 @@ func BinOp(c *OpContext, op Op, left, right Value) Value {
 
 		case leftKind == StringKind && rightKind == IntKind:
 			const as = "string multiplication"
-			return c.NewString(strings.Repeat(c.stringValue(left, as), int(c.uint64(right, as))))
+			return c.repeatString(c.stringValue(left, as), c.uint64(right, as))
 
 		case leftKind == IntKind && rightKind == StringKind:
 			const as = "string multiplication"
-			return c.NewString(strings.Repeat(c.stringValue(right, as), int(c.uint64(left, as))))
+			return c.repeatString(c.stringValue(right, as), c.uint64(left, as))
 
 		case leftKind == BytesKind && rightKind == IntKind:
 			const as = "bytes multiplication"
-			return c.newBytes(bytes.Repeat(c.bytesValue(left, as), int(c.uint64(right, as))))
+			return c.repeatBytes(c.bytesValue(left, as), c.uint64(right, as))
 
 		case leftKind == IntKind && rightKind == BytesKind:
 			const as = "bytes multiplication"
-			return c.newBytes(bytes.Repeat(c.bytesValue(right, as), int(c.uint64(left, as))))
+			return c.repeatBytes(c.bytesValue(right, as), c.uint64(left, as))
 
 		case leftKind == ListKind && rightKind == IntKind:
 			left, right = right, left
@@ -247,6 +255,9 @@ func BinOp(c *OpContext, op Op, left, right Value) Value {
 			x := MakeIdentLabel(c, "x", "")
 
 			for i := c.uint64(left, "list multiplier"); i > 0; i-- {
+				if err := c.spendIteration(); err != nil {
+					return err
+				}
 				st := &StructLit{Decls: []Decl{
 					&FieldReference{UpCount: 1, Label: x},
 				}}
@@ -320,3 +331,21 @@ func cmpTonode(c *OpContext, op Op, r int) Value {
 	}
 	return c.newBool(result)
 }
+
+// repeatString returns s repeated count times, unless the result would
+// exceed the size limit of c.
+func (c *OpContext) repeatString(s string, count uint64) Value {
+	if err := c.checkSize(len(s), count); err != nil {
+		return err
+	}
+	return c.NewString(strings.Repeat(s, int(count)))
+}
+
+// repeatBytes returns b repeated count times, unless the result would
+// exceed the size limit of c.
+func (c *OpContext) repeatBytes(b []byte, count uint64) Value {
+	if err := c.checkSize(len(b), count); err != nil {
+		return err
+	}
+	return c.newBytes(bytes.Repeat(b, int(count)))
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import (
+	"time"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// ErrBudgetExceeded is the cause of errors reported for evaluation that
+// exceeds its Budget.
+var ErrBudgetExceeded = errors.New("evaluation budget exceeded")
+
+// A Budget limits the amount of work done by a single evaluation, which
+// allows evaluating untrusted configurations. Evaluation that exceeds its
+// budget results in an error wrapping ErrBudgetExceeded.
+type Budget struct {
+	// MaxSteps limits the number of evaluation steps. Unifying a vertex,
+	// expanding a disjunct, iterating over a comprehension value and
+	// creating an element by list multiplication each count as a step.
+	// Zero means no limit.
+	MaxSteps int64
+
+	// MaxDuration limits the time spent on evaluation. Zero means no limit.
+	MaxDuration time.Duration
+
+	// Deadline, if not zero, is the time after which evaluation fails.
+	// Unlike MaxDuration, it is shared by all evaluations using the budget.
+	Deadline time.Time
+}
+
+// budgetCheckInterval is the number of steps after which the deadline of a
+// budget is checked, to amortize the cost of reading the clock.
+const budgetCheckInterval = 256
+
+// budgetError reports an exhausted budget, including the path of the vertex
+// that was being evaluated, if any, to help locate expensive parts of a
+// configuration.
+func (c *OpContext) budgetError(format string, args ...interface{}) *Bottom {
+	if c.vertex != nil {
+		if path := c.vertex.Path(); len(path) > 0 {
+			format += " while evaluating %s"
+			args = append(args, c.PathToString(c.Runtime, path))
+		}
+	}
+	return &Bottom{
+		Code: EvalError,
+		Err:  errors.Wrapf(ErrBudgetExceeded, token.NoPos, format, args...),
+	}
+}
@@ -117,11 +117,8 @@ func (e *Environment) evalCached(c *OpContext, x Expr) Value {
 		return v
 	}
 	key := cacheKey{x, nil}
-	v, ok := e.cache[key]
+	v, ok := e.lookupCache(c, key)
 	if !ok {
-		if e.cache == nil {
-			e.cache = map[cacheKey]Value{}
-		}
 		env, src := c.e, c.src
 		c.e, c.src = e, x.Source()
 		// Save and restore errors to ensure that only relevant errors are
@@ -131,7 +128,7 @@ func (e *Environment) evalCached(c *OpContext, x Expr) Value {
 		c.e, c.src = env, src
 		c.errs = err
 		if b, ok := v.(*Bottom); !ok || !b.IsIncomplete() {
-			e.cache[key] = v
+			e.storeCache(c, key, v)
 		}
 	}
 	return v
@@ -207,6 +204,10 @@ type Vertex struct {
 	// hasPendingArc is set if this Vertex has a void arc (e.g. for comprehensions)
 	hasPendingArc bool
 
+	// sharedSlices indicates that the backing arrays of Conjuncts and Structs
+	// may be shared with a clone of this Vertex. See ownSlices.
+	sharedSlices bool
+
 	// ArcType indicates the level of optionality of this arc.
 	ArcType ArcType
 
@@ -373,6 +374,66 @@ func (a ArcType) Suffix() string {
 	return ""
 }
 
+// The backing arrays of Conjuncts and Structs may be shared between a vertex
+// and its clones: see clone. Their elements must therefore only be modified
+// through the methods below, which first give v its own copies if needed.
+// Appending does not require this, as shared slices are limited to their
+// length: the first append to such a slice copies it.
+
+// ownSlices ensures that the backing arrays of Conjuncts and Structs are not
+// shared with a clone of v.
+func (v *Vertex) ownSlices() {
+	if !v.sharedSlices {
+		return
+	}
+	v.sharedSlices = false
+	if v.Conjuncts != nil {
+		v.Conjuncts = append(make([]Conjunct, 0, len(v.Conjuncts)), v.Conjuncts...)
+	}
+	if v.Structs != nil {
+		v.Structs = append(make([]*StructInfo, 0, len(v.Structs)), v.Structs...)
+	}
+}
+
+// setConjunctCyclic marks the conjunct at index i as cyclic.
+func (v *Vertex) setConjunctCyclic(i int) {
+	v.ownSlices()
+	v.Conjuncts[i].CloseInfo.IsCyclic = true
+}
+
+// addConjunctFieldTypes records that the conjunct at index i defines fields
+// of the types t.
+func (v *Vertex) addConjunctFieldTypes(i int, t OptionalType) {
+	if v.Conjuncts[i].CloseInfo.FieldTypes|t == v.Conjuncts[i].CloseInfo.FieldTypes {
+		return
+	}
+	v.ownSlices()
+	v.Conjuncts[i].CloseInfo.FieldTypes |= t
+}
+
+// removeUninitializedStructs removes the struct literals that were not
+// initialized from Structs.
+func (v *Vertex) removeUninitializedStructs() {
+	k := 0
+	for _, s := range v.Structs {
+		if s.initialized {
+			k++
+		}
+	}
+	if k == len(v.Structs) {
+		return
+	}
+	v.ownSlices()
+	k = 0
+	for _, s := range v.Structs {
+		if s.initialized {
+			v.Structs[k] = s
+			k++
+		}
+	}
+	v.Structs = v.Structs[:k]
+}
+
 func (v *Vertex) Clone() *Vertex {
 	c := *v
 	c.state = nil
@@ -0,0 +1,58 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import "testing"
+
+func TestCloneSharedSlices(t *testing.T) {
+	newVertex := func() *Vertex {
+		return &Vertex{
+			Conjuncts: []Conjunct{{}, {}},
+			Structs: []*StructInfo{
+				{StructLit: &StructLit{initialized: true}},
+				{StructLit: &StructLit{}},
+			},
+		}
+	}
+
+	v := newVertex()
+	w := clone(v)
+	if &w.Conjuncts[0] != &v.Conjuncts[0] || &w.Structs[0] != &v.Structs[0] {
+		t.Fatal("clone does not share the conjuncts and structs")
+	}
+
+	w.setConjunctCyclic(0)
+	w.addConjunctFieldTypes(1, HasField)
+	w.removeUninitializedStructs()
+
+	want := newVertex()
+	if v.Conjuncts[0].CloseInfo.IsCyclic != want.Conjuncts[0].CloseInfo.IsCyclic ||
+		v.Conjuncts[1].CloseInfo.FieldTypes != want.Conjuncts[1].CloseInfo.FieldTypes ||
+		len(v.Structs) != 2 || v.Structs[1].initialized {
+		t.Error("modifying the clone modified the original")
+	}
+	if !w.Conjuncts[0].CloseInfo.IsCyclic ||
+		w.Conjuncts[1].CloseInfo.FieldTypes != HasField ||
+		len(w.Structs) != 1 {
+		t.Error("clone was not modified")
+	}
+
+	// Modifying the original after it was cloned must not affect the clone.
+	u := clone(v)
+	v.setConjunctCyclic(1)
+	if u.Conjuncts[1].CloseInfo.IsCyclic {
+		t.Error("modifying the original modified the clone")
+	}
+}
@@ -186,14 +186,24 @@ func New(v *Vertex, cfg *Config) *OpContext {
 	if cfg.Runtime == nil {
 		panic("nil Runtime")
 	}
+	hooks := runtimeHooks(cfg.Runtime)
 	ctx := &OpContext{
 		Runtime: cfg.Runtime,
 		Format:  cfg.Format,
 		vertex:  v,
+		stats:   new(stats.Counts),
+		limits:  newLimitState(hooks),
+		tracer:  newTraceState(hooks),
+		prof:    newProfState(hooks),
+
+		cacheLock: runtimeCacheLock(cfg.Runtime),
 	}
 	if v != nil {
 		ctx.e = &Environment{Up: nil, Vertex: v}
 	}
+	if hooks != nil && hooks.Stats != nil {
+		ctx.statsRef = newStatsRef(hooks.Stats, ctx.stats)
+	}
 	return ctx
 }
 
@@ -219,9 +229,39 @@ type OpContext struct {
 
 	nest int
 
-	stats        stats.Counts
+	stats        *stats.Counts
 	freeListNode *nodeContext
 
+	// statsRef, if not nil, reports the counts of this context to a
+	// StatsRecorder once the context is released.
+	statsRef *statsRef
+
+	// limits, if not nil, bounds the work done and the resources used by
+	// this context.
+	limits *limitState
+
+	// tracer, if not nil, reports the evaluation of this context.
+	tracer *traceState
+
+	// prof, if not nil, records the evaluation of this context.
+	prof *profState
+
+	// parallel is the number of goroutines with which to evaluate the arcs
+	// of parallelRoot. See FinalizeParallel.
+	parallel     int
+	parallelRoot *Vertex
+
+	// sharedRoot is the root vertex whose arcs this context evaluates on
+	// behalf of completeArcsParallel. As other goroutines use it as well,
+	// it is only read, and its arcs are looked up in sharedArcs, rather
+	// than by inspecting arcs evaluated by other goroutines.
+	sharedRoot *Vertex
+	sharedArcs map[Feature]*Vertex
+
+	// cacheLock, if not nil, guards the caches of values and environments,
+	// which may be shared with the contexts of other goroutines.
+	cacheLock *CacheLock
+
 	e         *Environment
 	ci        CloseInfo
 	src       ast.Node
@@ -245,6 +285,9 @@ type OpContext struct {
 	closed     map[*closeInfo]*closeStats
 	todo       *closeStats
 
+	// shared holds the leaf disjuncts that are shared between disjunctions.
+	shared map[shareKey]*Vertex
+
 	// inDisjunct indicates that non-monotonic checks should be skipped.
 	// This is used if we want to do some extra work to eliminate disjunctions
 	// early. The result of unification should be thrown away if this check is
@@ -852,7 +895,14 @@ func (c *OpContext) lookup(x *Vertex, pos token.Pos, l Feature, state vertexStat
 		}
 	}
 
-	a := x.Lookup(l)
+	var a *Vertex
+	if x == c.sharedRoot {
+		if a = c.sharedArcs[l]; a != nil {
+			a = a.Indirect()
+		}
+	} else {
+		a = x.Lookup(l)
+	}
 
 	var hasCycle bool
 
@@ -1237,10 +1287,12 @@ func (c *OpContext) regexp(v Value) *regexp.Regexp {
 	}
 	switch x := v.(type) {
 	case *String:
+		if c.cacheLock.lock() {
+			defer c.cacheLock.mu.Unlock()
+		}
 		if x.RE != nil {
 			return x.RE
 		}
-		// TODO: synchronization
 		p, err := regexp.Compile(x.Str)
 		if err != nil {
 			// FatalError? How to cache error
@@ -1252,10 +1304,12 @@ func (c *OpContext) regexp(v Value) *regexp.Regexp {
 		return x.RE
 
 	case *Bytes:
+		if c.cacheLock.lock() {
+			defer c.cacheLock.mu.Unlock()
+		}
 		if x.RE != nil {
 			return x.RE
 		}
-		// TODO: synchronization
 		p, err := regexp.Compile(string(x.B))
 		if err != nil {
 			c.AddErrf("invalid regexp: %s", err)
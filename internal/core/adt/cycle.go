@@ -342,7 +342,7 @@ func (n *nodeContext) markCycle(arc *Vertex, env *Environment, x Resolver, ci Cl
 				// accurate. Maybe a pointer up to find the root and then
 				// "spread" downwards?
 				if r.Ref == x && r.Arc == rr.Arc {
-					n.node.Conjuncts[i].CloseInfo.IsCyclic = true
+					n.node.setConjunctCyclic(i)
 					break
 				}
 			}
@@ -469,6 +469,7 @@ outer:
 	}
 
 	n.hasCycle = true
+	n.traceCycle(x, nil)
 	if !n.hasNonCycle && env != nil {
 		v := Conjunct{env, x, ci}
 		n.cyclicConjuncts = append(n.cyclicConjuncts, cyclicConjunct{v, arc})
@@ -499,14 +500,14 @@ func assertStructuralCycle(n *nodeContext) bool {
 }
 
 func (n *nodeContext) reportCycleError() {
-	n.node.BaseValue = CombineErrors(nil,
-		n.node.Value(),
-		&Bottom{
-			Code:  StructuralCycleError,
-			Err:   n.ctx.Newf("structural cycle"),
-			Value: n.node.Value(),
-			// TODO: probably, this should have the referenced arc.
-		})
+	err := &Bottom{
+		Code:  StructuralCycleError,
+		Err:   n.ctx.Newf("structural cycle"),
+		Value: n.node.Value(),
+		// TODO: probably, this should have the referenced arc.
+	}
+	n.traceCycle(nil, err)
+	n.node.BaseValue = CombineErrors(nil, n.node.Value(), err)
 	n.node.Arcs = nil
 }
 
@@ -31,15 +31,33 @@ func (a *Num) Cmp(b *Num) int {
 	return a.X.Cmp(&b.X)
 }
 
+// exactContext is used for the addition, subtraction and multiplication of
+// integers, which, unlike these operations on floats, are not rounded to the
+// precision of the base context.
+var exactContext = internal.BaseContext.WithPrecision(0)
+
+func isInt(a, b *Num) bool {
+	return a.K == IntKind && b.K == IntKind
+}
+
 func (c *OpContext) Add(a, b *Num) Value {
+	if isInt(a, b) {
+		return numOp(c, exactContext.Add, a, b)
+	}
 	return numOp(c, internal.BaseContext.Add, a, b)
 }
 
 func (c *OpContext) Sub(a, b *Num) Value {
+	if isInt(a, b) {
+		return numOp(c, exactContext.Sub, a, b)
+	}
 	return numOp(c, internal.BaseContext.Sub, a, b)
 }
 
 func (c *OpContext) Mul(a, b *Num) Value {
+	if isInt(a, b) {
+		return numOp(c, exactContext.Mul, a, b)
+	}
 	return numOp(c, internal.BaseContext.Mul, a, b)
 }
 
@@ -57,6 +57,11 @@ func (v *Vertex) Default() *Vertex {
 			return v
 		case 1:
 			w = ToVertex(Default(d.Values[0]))
+			if w.Conjuncts == nil {
+				// w may be shared with other disjunctions: do not modify it.
+				x := *w
+				w = &x
+			}
 		default:
 			x := *v
 			x.state = nil
@@ -125,6 +125,18 @@ func (n *nodeContext) expandDisjuncts(
 	recursive, last bool) {
 
 	n.ctx.stats.Disjuncts++
+	n.ctx.profileDisjuncts()
+
+	if err := n.ctx.spendBudget(); err != nil {
+		n.addBottom(err)
+		if recursive {
+			// Stop expanding: with all disjuncts failing, the parent
+			// disjunction is abandoned as well.
+			parent.disjunctErrs = append(parent.disjunctErrs, err)
+			n.free()
+			return
+		}
+	}
 
 	// refNode is used to collect cyclicReferences for all disjuncts to be
 	// passed up to the parent node. Note that because the node in the parent
@@ -145,7 +157,7 @@ func (n *nodeContext) expandDisjuncts(
 	// save nodeContext.
 
 	if recursive || len(n.disjunctions) > 0 {
-		n.snapshot = clone(*n.node)
+		n.snapshot = clone(n.node)
 	} else {
 		n.snapshot = *n.node
 	}
@@ -223,9 +235,9 @@ func (n *nodeContext) expandDisjuncts(
 			for _, dn := range a {
 				switch {
 				case d.expr != nil:
-					for _, v := range d.expr.Values {
+					for _, v := range uniqueDisjuncts(d.expr.Values) {
 						cn := dn.clone()
-						*cn.node = clone(dn.snapshot)
+						*cn.node = clone(&dn.snapshot)
 						cn.node.state = cn
 
 						c := MakeConjunct(d.env, v.Val, d.cloneID)
@@ -233,7 +245,11 @@ func (n *nodeContext) expandDisjuncts(
 
 						newMode := mode(d.hasDefaults, v.Default)
 
+						numDisjuncts, numErrs := len(n.disjuncts), len(n.disjunctErrs)
+						start := n.ctx.startDisjunct()
 						cn.expandDisjuncts(state, n, newMode, true, last)
+						n.ctx.endDisjunct(d.expr, start)
+						n.traceDisjunct(v.Val, numDisjuncts, numErrs)
 
 						// Record the cyclicReferences of the conjunct in the
 						// parent list.
@@ -250,14 +266,18 @@ func (n *nodeContext) expandDisjuncts(
 				case d.value != nil:
 					for i, v := range d.value.Values {
 						cn := dn.clone()
-						*cn.node = clone(dn.snapshot)
+						*cn.node = clone(&dn.snapshot)
 						cn.node.state = cn
 
 						cn.addValueConjunct(d.env, v, d.cloneID)
 
 						newMode := mode(d.hasDefaults, i < d.value.NumDefaults)
 
+						numDisjuncts, numErrs := len(n.disjuncts), len(n.disjunctErrs)
+						start := n.ctx.startDisjunct()
 						cn.expandDisjuncts(state, n, newMode, true, last)
+						n.ctx.endDisjunct(d.value, start)
+						n.traceDisjunct(v, numDisjuncts, numErrs)
 
 						// See comment above.
 						for r := n.node.cyclicReferences; r != nil; r = r.Next {
@@ -434,6 +454,68 @@ func (n *nodeContext) expandDisjuncts(
 	}
 }
 
+// uniqueDisjuncts returns values without disjuncts that are identical to an
+// earlier disjunct with the same default marker. Such duplicates would
+// evaluate to the same result, only to be removed after evaluation, and
+// multiply the number of combinations evaluated for subsequent disjunctions.
+//
+// Only references are compared, as this is where duplicates typically
+// arise, such as when schemas are generated from oneOf constructs that list
+// the same type more than once. The original slice is returned if there
+// are no duplicates.
+func uniqueDisjuncts(values []Disjunct) []Disjunct {
+	var a []Disjunct
+	for i, v := range values {
+		dup := false
+		if isDisjunctRef(v.Val) {
+			for _, w := range values[:i] {
+				if w.Default == v.Default && sameReference(w.Val, v.Val) {
+					dup = true
+					break
+				}
+			}
+		}
+		switch {
+		case dup && a == nil:
+			a = append(make([]Disjunct, 0, len(values)), values[:i]...)
+		case !dup && a != nil:
+			a = append(a, v)
+		}
+	}
+	if a == nil {
+		return values
+	}
+	return a
+}
+
+func isDisjunctRef(x Expr) bool {
+	switch x.(type) {
+	case *FieldReference, *SelectorExpr, *ImportReference:
+		return true
+	}
+	return false
+}
+
+// sameReference reports whether x and y are references that, when evaluated
+// in the same environment, always resolve to the same value.
+func sameReference(x, y Expr) bool {
+	if x == y {
+		return true
+	}
+	switch a := x.(type) {
+	case *FieldReference:
+		b, ok := y.(*FieldReference)
+		return ok && a.UpCount == b.UpCount && a.Label == b.Label
+	case *ImportReference:
+		b, ok := y.(*ImportReference)
+		return ok && a.ImportPath == b.ImportPath
+	case *SelectorExpr:
+		b, ok := y.(*SelectorExpr)
+		return ok && a.Sel == b.Sel && sameReference(a.X, b.X)
+	}
+	return false
+}
+
 func (n *nodeContext) makeError() {
 	code := IncompleteError
 
@@ -475,8 +557,15 @@ func mode(hasDefault, marked bool) defaultMode {
 // and can be used as is, or Structs is assumed to not yet be computed at the
 // time that a clone is needed and must be nil. Conjuncts no longer needed and
 // can become nil. All other fields can be copied shallowly.
-func clone(v Vertex) Vertex {
+//
+// The Conjuncts and Structs of the clone and its arcs share their backing
+// arrays with the originals until either modifies their elements: see
+// ownSlices. A large disjunction clones the same vertex for each of its
+// disjuncts, most of which do not modify these lists.
+func clone(x *Vertex) Vertex {
+	v := *x
 	v.state = nil
+	shareSlices(x, &v)
 	if a := v.Arcs; len(a) > 0 {
 		v.Arcs = make([]*Vertex, len(a))
 		for i, arc := range a {
@@ -487,28 +576,30 @@ func clone(v Vertex) Vertex {
 			case 0:
 				a := *arc
 				v.Arcs[i] = &a
-
-				a.Conjuncts = make([]Conjunct, len(arc.Conjuncts))
-				copy(a.Conjuncts, arc.Conjuncts)
+				shareSlices(arc, &a)
 
 			default:
 				a := *arc
+				shareSlices(arc, &a)
 				a.state = arc.state.clone()
 				a.state.node = &a
-				a.state.snapshot = clone(a)
+				a.state.snapshot = clone(&a)
 				v.Arcs[i] = &a
 			}
 		}
 	}
 
-	if a := v.Structs; len(a) > 0 {
-		v.Structs = make([]*StructInfo, len(a))
-		copy(v.Structs, a)
-	}
-
 	return v
 }
 
+// shareSlices lets the clone w of v share the Conjuncts and Structs of v.
+func shareSlices(v, w *Vertex) {
+	w.Conjuncts = v.Conjuncts[:len(v.Conjuncts):len(v.Conjuncts)]
+	w.Structs = v.Structs[:len(v.Structs):len(v.Structs)]
+	v.sharedSlices = true
+	w.sharedSlices = true
+}
+
 // Default rules from spec:
 //
 // U1: (v1, d1) & v2       => (v1&v2, d1&v2)
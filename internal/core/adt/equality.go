@@ -14,6 +14,8 @@
 
 package adt
 
+import "bytes"
+
 type Flag uint16
 
 const (
@@ -55,6 +57,14 @@ func equalVertex(ctx *OpContext, x *Vertex, v Value, flags Flag) bool {
 		return false
 	}
 
+	// Rule out different scalars early. This is a common case when
+	// deduplicating the results of disjunctions.
+	if v, ok := x.BaseValue.(Value); ok {
+		if w, ok := y.BaseValue.(Value); ok && scalarsDiffer(v, w) {
+			return false
+		}
+	}
+
 	maxArcType := ArcMember
 	if flags&CheckStructural != 0 {
 		// Do not ignore optional fields
@@ -116,6 +126,27 @@ loop2:
 	return equalTerminal(ctx, v, w, flags)
 }
 
+// scalarsDiffer reports whether v and w are concrete scalars of the same type
+// that are known to be different. It is cheaper than checking for equality
+// with equalTerminal.
+func scalarsDiffer(v, w Value) bool {
+	switch x := v.(type) {
+	case *String:
+		y, ok := w.(*String)
+		return ok && x.Str != y.Str
+	case *Bool:
+		y, ok := w.(*Bool)
+		return ok && x.B != y.B
+	case *Bytes:
+		y, ok := w.(*Bytes)
+		return ok && !bytes.Equal(x.B, y.B)
+	case *Num:
+		y, ok := w.(*Num)
+		return ok && x.X.Cmp(&y.X) != 0
+	}
+	return false
+}
+
 // equalClosed tests if x and y have the same set of close information.
 // TODO: the following refinements are possible:
 //   - unify optional fields and equate the optional fields
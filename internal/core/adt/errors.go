@@ -243,6 +243,7 @@ func newRequiredFieldInComprehensionError(ctx *OpContext, x *ForClause, v *Verte
 // A ValueError is returned as a result of evaluating a value.
 type ValueError struct {
 	r      Runtime
+	format func(Node) string
 	v      *Vertex
 	pos    token.Pos
 	auxpos []token.Pos
@@ -307,6 +308,15 @@ func appendNodePositions(a []token.Pos, n Node) []token.Pos {
 	return a
 }
 
+// isScalar reports whether x is an immutable scalar value.
+func isScalar(x Node) bool {
+	switch x.(type) {
+	case *Null, *Bool, *Num, *String, *Bytes, *Top, *BasicType:
+		return true
+	}
+	return false
+}
+
 func (c *OpContext) NewPosf(p token.Pos, format string, args ...interface{}) *ValueError {
 	var a []token.Pos
 	if len(c.positions) > 0 {
@@ -319,7 +329,12 @@ func (c *OpContext) NewPosf(p token.Pos, format string, args ...interface{}) *Va
 		switch x := arg.(type) {
 		case Node:
 			a = appendNodePositions(a, x)
-			args[i] = c.Str(x)
+			// Scalar values are immutable, so they are kept and only
+			// formatted by Msg. This matters for disjunctions, where errors
+			// are created for every failed disjunct, but are mostly discarded.
+			if !isScalar(x) || c.Format == nil {
+				args[i] = c.Str(x)
+			}
 		case ast.Node:
 			b, _ := cueformat.Node(x)
 			if p := x.Pos(); p != token.NoPos {
@@ -332,6 +347,7 @@ func (c *OpContext) NewPosf(p token.Pos, format string, args ...interface{}) *Va
 	}
 	return &ValueError{
 		r:       c.Runtime,
+		format:  c.Format,
 		v:       c.errNode(),
 		pos:     p,
 		auxpos:  a,
@@ -343,6 +359,25 @@ func (e *ValueError) Error() string {
 	return errors.String(e)
 }
 
+// Msg returns the format string and arguments of the error message, in which
+// node arguments are formatted as strings.
+func (e *ValueError) Msg() (format string, args []interface{}) {
+	format, args = e.Message.Msg()
+	copied := false
+	for i, arg := range args {
+		n, ok := arg.(Node)
+		if !ok {
+			continue
+		}
+		if !copied {
+			args = append([]interface{}(nil), args...)
+			copied = true
+		}
+		args[i] = e.format(n)
+	}
+	return format, args
+}
+
 func (e *ValueError) Position() token.Pos {
 	return e.pos
 }
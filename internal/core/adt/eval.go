@@ -43,7 +43,7 @@ import (
 //
 
 func (c *OpContext) Stats() *stats.Counts {
-	return &c.stats
+	return c.stats
 }
 
 // TODO: Note: NewContext takes essentially a cue.Value. By making this
@@ -161,11 +161,38 @@ func (c *OpContext) unify(v *Vertex, state vertexStatus) {
 		}()
 	}
 
+	if v == c.sharedRoot {
+		// The root is evaluating its arcs, so unification would be a no-op.
+		return
+	}
+
 	// Ensure a node will always have a nodeContext after calling Unify if it is
 	// not yet Finalized.
 	n := v.getNodeContext(c, 1)
 	defer v.freeNode(n)
 
+	if c.tracer != nil && n != nil {
+		c.traceEnter(v)
+		defer c.traceExit(v, v.status)
+	}
+
+	if n != nil {
+		tracked, err := c.enterDepth(v)
+		if tracked {
+			defer c.exitDepth()
+		}
+		if err != nil {
+			// Do not evaluate v any further, as doing so may recurse
+			// indefinitely.
+			if v.status == unprocessed {
+				v.SetValue(c, err)
+			} else {
+				n.addBottom(err)
+			}
+			return
+		}
+	}
+
 	// TODO(cycle): verify this happens in all cases when we need it.
 	if n != nil && v.Parent != nil && v.Parent.state != nil {
 		n.depth = v.Parent.state.depth + 1
@@ -213,6 +240,15 @@ func (c *OpContext) unify(v *Vertex, state vertexStatus) {
 
 		c.stats.Unifications++
 
+		if c.prof != nil {
+			c.enterVertex(v)
+			defer c.exitVertex()
+		}
+
+		if err := c.spendBudget(); err != nil {
+			n.addBottom(err)
+		}
+
 		// Set the cache to a cycle error to ensure a cyclic reference will result
 		// in an error if applicable. A cyclic error may be ignored for
 		// non-expression references. The cycle error may also be removed as soon
@@ -403,9 +439,11 @@ func (n *nodeContext) insertConjuncts(state vertexStatus) bool {
 
 		// Record the OptionalTypes for all structs that were inferred by this
 		// Conjunct. This information can be used by algorithms such as trim.
-		for i := nInfos; i < len(n.node.Structs); i++ {
-			n.node.Conjuncts[p.index].CloseInfo.FieldTypes |= n.node.Structs[i].types
+		var t OptionalType
+		for _, s := range n.node.Structs[nInfos:] {
+			t |= s.types
 		}
+		n.node.addConjunctFieldTypes(p.index, t)
 	}
 	return false
 }
@@ -783,6 +821,11 @@ func (n *nodeContext) completeArcs(state vertexStatus) {
 	ctx := n.ctx
 
 	if !assertStructuralCycle(n) {
+		if ctx.parallelRoot == n.node {
+			ctx.parallelRoot = nil
+			n.completeArcsParallel()
+		}
+
 		k := 0
 		// Visit arcs recursively to validate and compute error.
 		for _, a := range n.node.Arcs {
@@ -884,14 +927,7 @@ func (n *nodeContext) completeArcs(state vertexStatus) {
 	//
 	// TODO(perf): we could keep track if any such structs exist and only
 	// do this removal if there is a change of shrinking the list.
-	k := 0
-	for _, s := range n.node.Structs {
-		if s.initialized {
-			n.node.Structs[k] = s
-			k++
-		}
-	}
-	n.node.Structs = n.node.Structs[:k]
+	n.node.removeUninitializedStructs()
 
 	n.node.updateStatus(finalized)
 }
@@ -915,6 +951,7 @@ func (n *nodeContext) createDisjunct() *Disjunction {
 		v := new(Vertex)
 		*v = x.result
 		v.state = nil
+		v = n.ctx.shareDisjunct(v)
 		switch x.defaultMode {
 		case isDefault:
 			a[i] = a[p]
@@ -1479,6 +1516,10 @@ func (n *nodeContext) addExprConjunct(v Conjunct, state vertexStatus) {
 	env := v.Env
 	id := v.CloseInfo
 
+	if x, ok := v.Elem().(*BinaryExpr); !ok || x.Op != AndOp {
+		n.traceConjunct(v.Elem())
+	}
+
 	switch x := v.Elem().(type) {
 	case *Vertex:
 		if x.IsData() {
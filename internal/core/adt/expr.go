@@ -904,16 +904,13 @@ func (x *LetReference) resolve(ctx *OpContext, state vertexStatus) *Vertex {
 	c := arc.Conjuncts[0]
 	expr := c.Expr()
 	key := cacheKey{expr, arc}
-	v, ok := e.cache[key]
+	v, ok := e.lookupCache(ctx, key)
 	if !ok {
 		// Link in the right environment to ensure comprehension context is not
 		// lost. Use a Vertex to piggyback on cycle processing.
 		c.Env = e
 		c.x = expr
 
-		if e.cache == nil {
-			e.cache = map[cacheKey]Value{}
-		}
 		n := &Vertex{
 			Parent:    arc.Parent,
 			Label:     x.Label,
@@ -921,7 +918,7 @@ func (x *LetReference) resolve(ctx *OpContext, state vertexStatus) *Vertex {
 			Conjuncts: []Conjunct{c},
 		}
 		v = n
-		e.cache[key] = n
+		e.storeCache(ctx, key, n)
 		nc := n.getNodeContext(ctx, 0)
 		nc.hasNonCycle = true // Allow a first cycle to be skipped.
 
@@ -1144,10 +1141,16 @@ func (x *Interpolation) evaluate(c *OpContext, state vertexStatus) Value {
 		// return nil
 		return err
 	}
+	var v Value
 	if x.K == BytesKind {
-		return &Bytes{x.Src, buf.Bytes(), nil}
+		v = &Bytes{x.Src, buf.Bytes(), nil}
+	} else {
+		v = &String{x.Src, buf.String(), nil}
+	}
+	if err := c.checkValueSize(v); err != nil {
+		return err
 	}
-	return &String{x.Src, buf.String(), nil}
+	return v
 }
 
 // UnaryExpr is a unary expression.
@@ -1607,6 +1610,9 @@ func (x *Builtin) call(c *OpContext, p token.Pos, validate bool, args []Value) E
 	ret := x.Func(c, args)
 	c.IsValidator = saved
 
+	if err := c.checkValueSize(ret); err != nil {
+		return err
+	}
 	return ret
 }
 
@@ -1866,6 +1872,10 @@ func (x *ForClause) yield(s *compState) {
 		if !a.Label.IsRegular() {
 			continue
 		}
+		if err := c.spendIteration(); err != nil {
+			c.AddBottom(err)
+			return
+		}
 		if !a.isDefined() {
 			a.Finalize(c)
 			switch a.ArcType {
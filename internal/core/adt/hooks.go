@@ -0,0 +1,66 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import "cuelang.org/go/cue/stats"
+
+// Hooks configure how the OpContexts created for a Runtime account for,
+// bound and report their evaluation. A nil field disables the respective
+// hook.
+type Hooks struct {
+	// Budget limits the work done by each evaluation.
+	Budget *Budget
+
+	// Limits bound the resources used by each evaluation.
+	Limits *Limits
+
+	// Stats is notified of the statistics of each evaluation.
+	Stats StatsRecorder
+
+	// Profile records where evaluation time and allocations go.
+	Profile *Profile
+
+	// Tracer receives the events of each evaluation.
+	Tracer Tracer
+}
+
+// A HooksRuntime is a Runtime that installs Hooks in each OpContext created
+// for it. The hooks are read once when an OpContext is created.
+type HooksRuntime interface {
+	Runtime
+
+	// Hooks returns the hooks for evaluation or nil if there are none.
+	Hooks() *Hooks
+}
+
+// A StatsRecorder is notified of the statistics of each OpContext, allowing
+// it to aggregate the statistics of all operations.
+type StatsRecorder interface {
+	// AddStats is called with the counts of each newly created OpContext.
+	// The counts are updated for as long as the OpContext is in use.
+	AddStats(counts *stats.Counts)
+
+	// ReleaseStats is called with counts passed to AddStats once the
+	// OpContext that updates them is no longer reachable. The counts no
+	// longer change after this point.
+	ReleaseStats(counts *stats.Counts)
+}
+
+func runtimeHooks(r Runtime) *Hooks {
+	if hr, ok := r.(HooksRuntime); ok {
+		return hr.Hooks()
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import (
+	"time"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// ErrLimitExceeded is the cause of errors reported for evaluation that
+// exceeds one of its Limits.
+var ErrLimitExceeded = errors.New("evaluation limit exceeded")
+
+// Limits bound the resources used by a single evaluation, which protects
+// against configurations that would otherwise exhaust the stack or memory.
+// Evaluation that exceeds a limit results in an error wrapping a
+// *LimitError. A zero value means there is no limit for the respective
+// measure.
+type Limits struct {
+	// MaxDepth limits the number of vertices that are being evaluated
+	// within one another, as is the case for nested fields and for
+	// references that recursively refer to new structure.
+	MaxDepth int
+
+	// MaxIterations limits the number of values that comprehensions iterate
+	// over, and the number of elements created by list multiplication.
+	MaxIterations int64
+
+	// MaxSize limits the length in bytes of strings and bytes values
+	// computed by operators, interpolations and builtins.
+	MaxSize int
+}
+
+// A LimitKind identifies one of the Limits.
+type LimitKind int
+
+const (
+	DepthLimit LimitKind = iota + 1
+	IterationLimit
+	SizeLimit
+)
+
+func (k LimitKind) String() string {
+	switch k {
+	case DepthLimit:
+		return "nesting depth"
+	case IterationLimit:
+		return "comprehension iterations"
+	case SizeLimit:
+		return "value size"
+	}
+	return "unknown limit"
+}
+
+// A LimitError describes the limit exceeded by an evaluation. It wraps
+// ErrLimitExceeded.
+type LimitError struct {
+	// Kind is the limit that was exceeded.
+	Kind LimitKind
+
+	// Max is the value of the exceeded limit.
+	Max int64
+
+	// Path is the path of the vertex that was being evaluated, or the empty
+	// string for the root vertex.
+	Path string
+}
+
+func (e *LimitError) Error() string { return ErrLimitExceeded.Error() }
+
+func (e *LimitError) Unwrap() error { return ErrLimitExceeded }
+
+// limitState tracks the Budget and Limits of an OpContext. A zero field of
+// either means there is no limit for the respective measure.
+type limitState struct {
+	budget Budget
+	limits Limits
+
+	steps      int64
+	iterations int64
+	depth      int
+	deadline   time.Time
+
+	// err is set once the budget is exhausted.
+	err *Bottom
+}
+
+func newLimitState(h *Hooks) *limitState {
+	if h == nil || (h.Budget == nil && h.Limits == nil) {
+		return nil
+	}
+	s := &limitState{}
+	if b := h.Budget; b != nil {
+		s.budget = *b
+		s.deadline = b.Deadline
+		if b.MaxDuration > 0 {
+			d := time.Now().Add(b.MaxDuration)
+			if s.deadline.IsZero() || d.Before(s.deadline) {
+				s.deadline = d
+			}
+		}
+	}
+	if h.Limits != nil {
+		s.limits = *h.Limits
+	}
+	return s
+}
+
+// spendBudget accounts for a single evaluation step. It returns an error if
+// the budget of the evaluation is exhausted. Once exhausted, all subsequent
+// calls return this error.
+func (c *OpContext) spendBudget() *Bottom {
+	return c.spend(false)
+}
+
+// spendIteration accounts for a single iteration of a comprehension or
+// element created by list multiplication, which also counts as an
+// evaluation step. It returns an error if the budget is exhausted or the
+// iteration limit is exceeded.
+func (c *OpContext) spendIteration() *Bottom {
+	return c.spend(true)
+}
+
+func (c *OpContext) spend(iteration bool) *Bottom {
+	s := c.limits
+	if s == nil {
+		return nil
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.steps++
+	if iteration {
+		s.iterations++
+	}
+	// The deadline is also checked at the first step, so that evaluations
+	// started after a shared deadline fail right away.
+	checkTime := s.steps == 1 || s.steps%budgetCheckInterval == 0
+	b, l := &s.budget, &s.limits
+	switch {
+	case b.MaxSteps > 0 && s.steps > b.MaxSteps:
+		s.err = c.budgetError("more than %d steps", b.MaxSteps)
+	case checkTime && !s.deadline.IsZero() && time.Now().After(s.deadline):
+		if b.MaxDuration > 0 && b.Deadline.IsZero() {
+			s.err = c.budgetError("took longer than %v", b.MaxDuration)
+		} else {
+			s.err = c.budgetError("deadline exceeded")
+		}
+	case iteration && l.MaxIterations > 0 && s.iterations > l.MaxIterations:
+		return c.limitError(c.vertex, IterationLimit, l.MaxIterations,
+			"%v exceed limit of %d", IterationLimit, l.MaxIterations)
+	}
+	return s.err
+}
+
+// enterDepth accounts for starting the evaluation of v. It reports whether
+// the depth is tracked, in which case exitDepth must be called when the
+// evaluation of v ends, and returns an error if the depth limit is exceeded.
+func (c *OpContext) enterDepth(v *Vertex) (tracked bool, err *Bottom) {
+	s := c.limits
+	if s == nil || s.limits.MaxDepth <= 0 {
+		return false, nil
+	}
+	s.depth++
+	if max := s.limits.MaxDepth; s.depth > max {
+		err = c.limitError(v, DepthLimit, int64(max),
+			"%v exceeds limit of %d", DepthLimit, max)
+	}
+	return true, err
+}
+
+func (c *OpContext) exitDepth() {
+	c.limits.depth--
+}
+
+// checkSize returns an error if a string or bytes value of n bytes, or of
+// n bytes repeated count times, exceeds the size limit. It is called before
+// such values are created, to avoid allocating them.
+func (c *OpContext) checkSize(n int, count uint64) *Bottom {
+	s := c.limits
+	if s == nil || s.limits.MaxSize <= 0 || n == 0 {
+		return nil
+	}
+	max := s.limits.MaxSize
+	switch {
+	case count <= uint64(max/n):
+		return nil
+	case count == 1:
+		return c.limitError(c.vertex, SizeLimit, int64(max),
+			"result of %d bytes exceeds size limit of %d", n, max)
+	}
+	return c.limitError(c.vertex, SizeLimit, int64(max),
+		"result of %d bytes repeated %d times exceeds size limit of %d",
+		n, count, max)
+}
+
+// checkValueSize returns an error if v is a string or bytes value that
+// exceeds the size limit.
+func (c *OpContext) checkValueSize(v Expr) *Bottom {
+	switch x := v.(type) {
+	case *String:
+		return c.checkSize(len(x.Str), 1)
+	case *Bytes:
+		return c.checkSize(len(x.B), 1)
+	}
+	return nil
+}
+
+// limitError reports an exceeded limit for the evaluation of v, if not nil.
+func (c *OpContext) limitError(v *Vertex, kind LimitKind, max int64, format string, args ...interface{}) *Bottom {
+	err := &LimitError{Kind: kind, Max: max}
+	if v != nil {
+		if path := v.Path(); len(path) > 0 {
+			err.Path = c.PathToString(c.Runtime, path)
+			format += " while evaluating %s"
+			args = append(args, err.Path)
+		}
+	}
+	return &Bottom{
+		Code: EvalError,
+		Err:  errors.Wrap(errors.Newf(token.NoPos, format, args...), err),
+	}
+}
@@ -89,6 +89,21 @@ func (o *StructInfo) MatchAndInsert(c *OpContext, arc *Vertex) {
 	}
 }
 
+// MatchBulk reports whether the label f matches the pattern of the bulk
+// optional field p, evaluated in env.
+func MatchBulk(c *OpContext, env *Environment, p *BulkOptionalField, f Feature) bool {
+	if !f.IsRegular() {
+		return false
+	}
+	var label Value
+	if int64(f.Index()) == MaxIndex {
+		f = 0
+	} else if f.IsString() {
+		label = f.ToValue(c)
+	}
+	return matchBulk(c, env, p, f, label)
+}
+
 // matchBulk reports whether feature f matches the filter of x. It evaluation of
 // the filter is erroneous, it returns false and the error will  be set in c.
 func matchBulk(c *OpContext, env *Environment, p *BulkOptionalField, f Feature, label Value) bool {
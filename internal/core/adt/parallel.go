@@ -0,0 +1,434 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// FinalizeParallel is like Finalize, but evaluates the independent arcs of
+// the root vertex v on up to n goroutines, each with its own OpContext.
+//
+// Arcs are independent if they do not refer to each other by label,
+// directly or indirectly. Arcs that do are evaluated in order on the same
+// goroutine. The packages imported by v are evaluated before any of the
+// arcs, as they are shared by all goroutines.
+//
+// The arcs of v are evaluated sequentially if they cannot be analyzed
+// statically, as is the case if v has embeddings, comprehensions, pattern
+// constraints or dynamic fields, is a disjunction, or contains vertices
+// that are not finalized.
+func (v *Vertex) FinalizeParallel(c *OpContext, n int) {
+	if n > 1 && v.Parent == nil && v.status == unprocessed {
+		c.parallel, c.parallelRoot = n, v
+		defer func() { c.parallel, c.parallelRoot = 0, nil }()
+	}
+	v.Finalize(c)
+}
+
+// A CacheLock guards the caches that evaluation fills in values and
+// environments, such as those of let expressions, dynamic fields and
+// regular expressions. These may be shared by several goroutines if the
+// vertices of a Runtime, such as imported packages, are evaluated
+// concurrently. The lock only takes effect between Begin and End.
+type CacheLock struct {
+	mu     sync.Mutex
+	active int32
+}
+
+// Begin marks the start of an evaluation that may use the vertices of the
+// Runtime concurrently with other evaluations. It must be called before
+// these evaluations start.
+func (l *CacheLock) Begin() {
+	if l == nil {
+		return
+	}
+	atomic.AddInt32(&l.active, 1)
+}
+
+// End marks the end of an evaluation started with Begin.
+func (l *CacheLock) End() {
+	if l == nil {
+		return
+	}
+	atomic.AddInt32(&l.active, -1)
+}
+
+// lock locks l and reports whether it did so, which is only the case if an
+// evaluation started with Begin is active.
+func (l *CacheLock) lock() bool {
+	if l == nil || atomic.LoadInt32(&l.active) == 0 {
+		return false
+	}
+	l.mu.Lock()
+	return true
+}
+
+// A CacheLockRuntime is a Runtime that guards the caches of its values with
+// a CacheLock, allowing them to be evaluated concurrently.
+type CacheLockRuntime interface {
+	Runtime
+
+	// CacheLock returns the lock guarding the caches of the values of the
+	// Runtime.
+	CacheLock() *CacheLock
+}
+
+func runtimeCacheLock(r Runtime) *CacheLock {
+	if lr, ok := r.(CacheLockRuntime); ok {
+		return lr.CacheLock()
+	}
+	return nil
+}
+
+// lookupCache returns the value cached in e for key, if any.
+func (e *Environment) lookupCache(c *OpContext, key cacheKey) (Value, bool) {
+	if c.cacheLock.lock() {
+		defer c.cacheLock.mu.Unlock()
+	}
+	v, ok := e.cache[key]
+	return v, ok
+}
+
+// storeCache caches v in e for key.
+func (e *Environment) storeCache(c *OpContext, key cacheKey, v Value) {
+	if c.cacheLock.lock() {
+		defer c.cacheLock.mu.Unlock()
+	}
+	if e.cache == nil {
+		e.cache = map[cacheKey]Value{}
+	}
+	e.cache[key] = v
+}
+
+// completeArcsParallel evaluates the arcs of n, the root vertex passed to
+// FinalizeParallel, in independent groups on up to c.parallel goroutines.
+// Arcs that could not be evaluated this way are left to completeArcs.
+func (n *nodeContext) completeArcsParallel() {
+	c := n.ctx
+	groups, imports := n.arcGroups()
+	if len(groups) < 2 {
+		return
+	}
+
+	// Imports are finalized first, after which they are only read.
+	for _, v := range imports {
+		v.Finalize(c)
+	}
+
+	// Environments of vertices that were evaluated before, such as those
+	// of imported packages, may be used by several goroutines. Their caches
+	// are guarded by the lock of the Runtime, which is shared with any
+	// other evaluations using these vertices.
+	c.cacheLock.Begin()
+	defer c.cacheLock.End()
+
+	arcs := make(map[Feature]*Vertex, len(n.node.Arcs))
+	for _, a := range n.node.Arcs {
+		arcs[a.Label] = a
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		panicked interface{}
+		sem      = make(chan struct{}, c.parallel)
+	)
+	for _, g := range groups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(g []*Vertex) {
+			defer func() {
+				if r := recover(); r != nil {
+					mu.Lock()
+					panicked = r
+					mu.Unlock()
+				}
+				<-sem
+				wg.Done()
+			}()
+			ctx := New(nil, &Config{Runtime: c.Runtime, Format: c.Format})
+			ctx.Version = c.Version
+			ctx.sharedRoot, ctx.sharedArcs = n.node, arcs
+			for _, a := range g {
+				ctx.unify(a, finalized)
+			}
+			AddStats(ctx)
+		}(g)
+	}
+	wg.Wait()
+	if panicked != nil {
+		panic(panicked)
+	}
+}
+
+// arcGroups partitions the arcs of n into groups of arcs that refer to each
+// other and reports the imports referred to. It returns no groups if the
+// arcs cannot be analyzed.
+func (n *nodeContext) arcGroups() (groups [][]*Vertex, imports []*Vertex) {
+	v := n.node
+	for _, c := range v.Conjuncts {
+		if _, ok := c.x.(*StructLit); !ok {
+			return nil, nil
+		}
+	}
+	for _, s := range v.Structs {
+		for _, d := range s.Decls {
+			switch d.(type) {
+			case *Field, *LetField:
+			default:
+				return nil, nil
+			}
+		}
+	}
+
+	index := map[Feature]int{}
+	for i, a := range v.Arcs {
+		index[a.Label] = i
+	}
+	parent := make([]int, len(v.Arcs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	seen := map[*Vertex]bool{}
+	w := &refWalker{ctx: n.ctx, imports: seen}
+	for i, a := range v.Arcs {
+		if a.status != unprocessed {
+			return nil, nil
+		}
+		w.labels = w.labels[:0]
+		for _, c := range a.Conjuncts {
+			if !w.node(c.x) {
+				return nil, nil
+			}
+		}
+		for _, l := range w.labels {
+			if j, ok := index[l]; ok {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	// Imports of imports may be evaluated by references into the imports.
+	for k := 0; k < len(w.found); k++ {
+		for _, c := range w.found[k].Conjuncts {
+			if !w.node(c.x) {
+				return nil, nil
+			}
+		}
+	}
+
+	byRoot := map[int]int{}
+	for i, a := range v.Arcs {
+		r := find(i)
+		k, ok := byRoot[r]
+		if !ok {
+			k = len(groups)
+			byRoot[r] = k
+			groups = append(groups, nil)
+		}
+		groups[k] = append(groups[k], a)
+	}
+	return groups, w.found
+}
+
+// refWalker collects the labels referred to by expressions and the imports
+// they refer to.
+type refWalker struct {
+	ctx    *OpContext
+	labels []Feature
+
+	// imports holds the imports and other finalized vertices visited.
+	imports map[*Vertex]bool
+
+	// found holds the imports referred to, in order of discovery.
+	found []*Vertex
+}
+
+// node records the references in x. It reports false if x contains nodes
+// whose references cannot be determined.
+func (w *refWalker) node(x Node) bool {
+	switch x := x.(type) {
+	case nil:
+		return true
+
+	case *Vertex:
+		if x.status != finalized {
+			return false
+		}
+		// Conjuncts of finalized vertices may be evaluated again, for
+		// instance when unified with other values.
+		if !w.imports[x] {
+			w.imports[x] = true
+			for _, c := range x.Conjuncts {
+				if !w.node(c.x) {
+					return false
+				}
+			}
+		}
+		return true
+
+	case *NodeLink:
+		return x.Node == nil || x.Node.status == finalized
+
+	case *Conjunction:
+		return w.values(x.Values)
+
+	case *Disjunction:
+		return w.values(x.Values)
+
+	case *BuiltinValidator:
+		return w.values(x.Args)
+
+	case Value:
+		return true
+
+	case *StructLit:
+		// Struct literals are initialized on first use. Do so now for those
+		// that may be shared.
+		x.Init()
+		for _, d := range x.Decls {
+			if !w.node(d) {
+				return false
+			}
+		}
+		return true
+
+	case *ListLit:
+		for _, e := range x.Elems {
+			if !w.node(e) {
+				return false
+			}
+		}
+		return true
+
+	case *FieldReference:
+		w.labels = append(w.labels, x.Label)
+		return true
+
+	case *LetReference:
+		w.labels = append(w.labels, x.Label)
+		return true
+
+	case *ValueReference, *LabelReference, *DynamicReference:
+		// These refer to the label or value of an enclosing field, which
+		// is within the same arc, as the root has no dynamic fields.
+		return true
+
+	case *ImportReference:
+		path := x.ImportPath.StringValue(w.ctx)
+		v := w.ctx.Runtime.LoadImport(path)
+		if v != nil && !w.imports[v] {
+			w.imports[v] = true
+			w.found = append(w.found, v)
+		}
+		return true
+
+	case *SelectorExpr:
+		return w.node(x.X)
+
+	case *IndexExpr:
+		return w.node(x.X) && w.node(x.Index)
+
+	case *SliceExpr:
+		return w.node(x.X) && w.node(x.Lo) && w.node(x.Hi) && w.node(x.Stride)
+
+	case *Interpolation:
+		return w.exprs(x.Parts)
+
+	case *BoundExpr:
+		return w.node(x.Expr)
+
+	case *UnaryExpr:
+		return w.node(x.X)
+
+	case *BinaryExpr:
+		return w.node(x.X) && w.node(x.Y)
+
+	case *CallExpr:
+		return w.node(x.Fun) && w.exprs(x.Args)
+
+	case *DisjunctionExpr:
+		for _, d := range x.Values {
+			if !w.node(d.Val) {
+				return false
+			}
+		}
+		return true
+
+	case *Field:
+		return w.node(x.Value)
+
+	case *LetField:
+		return w.node(x.Value)
+
+	case *BulkOptionalField:
+		return w.node(x.Filter) && w.node(x.Value)
+
+	case *DynamicField:
+		return w.node(x.Key) && w.node(x.Value)
+
+	case *Ellipsis:
+		return w.node(x.Value)
+
+	case *Comprehension:
+		for _, c := range x.Clauses {
+			if !w.node(c) {
+				return false
+			}
+		}
+		return w.node(x.Value)
+
+	case *ForClause:
+		return w.node(x.Src)
+
+	case *IfClause:
+		return w.node(x.Condition)
+
+	case *LetClause:
+		return w.node(x.Expr)
+
+	case *ValueClause:
+		return true
+	}
+	return false
+}
+
+func (w *refWalker) exprs(a []Expr) bool {
+	for _, x := range a {
+		if !w.node(x) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *refWalker) values(a []Value) bool {
+	for _, x := range a {
+		if !w.node(x) {
+			return false
+		}
+	}
+	return true
+}
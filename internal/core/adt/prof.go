@@ -15,9 +15,14 @@
 package adt
 
 import (
+	"runtime"
+	"runtime/metrics"
+	"sort"
 	"sync"
+	"time"
 
 	"cuelang.org/go/cue/stats"
+	"cuelang.org/go/cue/token"
 )
 
 // This file contains stats and profiling functionality.
@@ -32,7 +37,7 @@ var (
 // counters.
 func AddStats(ctx *OpContext) {
 	countsMu.Lock()
-	counts.Add(ctx.stats)
+	counts.Add(*ctx.stats)
 	countsMu.Unlock()
 }
 
@@ -45,3 +50,258 @@ func TotalStats() stats.Counts {
 	countsMu.Unlock()
 	return s
 }
+
+// statsRef notifies a StatsRecorder when the OpContext referring to it is
+// released. It is referred to only by its OpContext, so that it becomes
+// unreachable together with the OpContext, even if the OpContext is part of
+// a reference cycle, which would prevent a finalizer set on the OpContext
+// itself from running.
+type statsRef struct {
+	r      StatsRecorder
+	counts *stats.Counts
+}
+
+func newStatsRef(r StatsRecorder, counts *stats.Counts) *statsRef {
+	r.AddStats(counts)
+	ref := &statsRef{r: r, counts: counts}
+	runtime.SetFinalizer(ref, func(ref *statsRef) {
+		ref.r.ReleaseStats(ref.counts)
+	})
+	return ref
+}
+
+// A Profile records where evaluation time and allocations go, attributing
+// them to the paths of the evaluated values and to the disjunctions that are
+// expanded. A Profile may be shared by concurrent evaluations.
+type Profile struct {
+	mu           sync.Mutex
+	fields       map[profileKey]*ProfileEntry
+	disjunctions map[token.Pos]*ProfileEntry
+}
+
+type profileKey struct {
+	root *Vertex
+	path string
+}
+
+// A ProfileEntry holds the cost attributed to a single path or disjunction.
+type ProfileEntry struct {
+	// Root is the root of the evaluated value, which identifies its package.
+	// It is nil for disjunctions.
+	Root *Vertex
+
+	// Path is the path of the value relative to Root. It is empty for
+	// disjunctions.
+	Path string
+
+	// Pos is the position of the first conjunct of the value or the
+	// position of the disjunction.
+	Pos token.Pos
+
+	// Unifications counts the number of times a value at Path was unified.
+	Unifications int64
+
+	// Disjuncts counts the number of disjuncts expanded.
+	Disjuncts int64
+
+	// Time is the time spent. For paths it excludes the time spent on
+	// evaluating values at other paths, for disjunctions it includes it.
+	Time time.Duration
+
+	// AllocBytes and AllocObjects approximate the heap allocations made,
+	// excluding or including those for other paths like Time.
+	AllocBytes   int64
+	AllocObjects int64
+}
+
+// Fields returns the entries for all evaluated paths, sorted by decreasing
+// time.
+func (p *Profile) Fields() []*ProfileEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return sortEntries(p.fields)
+}
+
+// Disjunctions returns the entries for all expanded disjunctions, sorted by
+// decreasing time.
+func (p *Profile) Disjunctions() []*ProfileEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return sortEntries(p.disjunctions)
+}
+
+func sortEntries[K comparable](m map[K]*ProfileEntry) []*ProfileEntry {
+	a := make([]*ProfileEntry, 0, len(m))
+	for _, e := range m {
+		x := *e
+		a = append(a, &x)
+	}
+	sort.Slice(a, func(i, j int) bool {
+		x, y := a[i], a[j]
+		if x.Time != y.Time {
+			return x.Time > y.Time
+		}
+		if x.Path != y.Path {
+			return x.Path < y.Path
+		}
+		return x.Pos.Before(y.Pos)
+	})
+	return a
+}
+
+// profState records the evaluation of an OpContext in a Profile.
+type profState struct {
+	*Profile
+	stack   []profFrame
+	samples []metrics.Sample
+}
+
+// A profFrame tracks the evaluation of a single vertex.
+type profFrame struct {
+	entry *ProfileEntry
+	start profSample
+	inner profSample // cost of nested frames
+}
+
+type profSample struct {
+	time    time.Duration
+	bytes   int64
+	objects int64
+}
+
+var profEpoch = time.Now()
+
+func newProfState(h *Hooks) *profState {
+	if h == nil || h.Profile == nil {
+		return nil
+	}
+	return &profState{
+		Profile: h.Profile,
+		samples: []metrics.Sample{
+			{Name: "/gc/heap/allocs:bytes"},
+			{Name: "/gc/heap/allocs:objects"},
+		},
+	}
+}
+
+func (s *profState) sample() profSample {
+	metrics.Read(s.samples)
+	x := profSample{time: time.Since(profEpoch)}
+	if v := s.samples[0].Value; v.Kind() == metrics.KindUint64 {
+		x.bytes = int64(v.Uint64())
+	}
+	if v := s.samples[1].Value; v.Kind() == metrics.KindUint64 {
+		x.objects = int64(v.Uint64())
+	}
+	return x
+}
+
+func (x profSample) sub(y profSample) profSample {
+	return profSample{x.time - y.time, x.bytes - y.bytes, x.objects - y.objects}
+}
+
+func (x profSample) add(y profSample) profSample {
+	return profSample{x.time + y.time, x.bytes + y.bytes, x.objects + y.objects}
+}
+
+// enterVertex starts recording the unification of v. It must be paired
+// with a call to exitVertex.
+func (c *OpContext) enterVertex(v *Vertex) {
+	s := c.prof
+	root := v
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	key := profileKey{root: root, path: c.PathToString(c.Runtime, v.Path())}
+
+	s.mu.Lock()
+	if s.fields == nil {
+		s.fields = map[profileKey]*ProfileEntry{}
+	}
+	e := s.fields[key]
+	if e == nil {
+		e = &ProfileEntry{Root: root, Path: key.path}
+		if len(v.Conjuncts) > 0 {
+			if src := v.Conjuncts[0].Source(); src != nil {
+				e.Pos = src.Pos()
+			}
+		}
+		s.fields[key] = e
+	}
+	e.Unifications++
+	s.mu.Unlock()
+
+	s.stack = append(s.stack, profFrame{entry: e, start: s.sample()})
+}
+
+// exitVertex ends recording the unification started by the last call to
+// enterVertex.
+func (c *OpContext) exitVertex() {
+	s := c.prof
+	f := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+
+	total := s.sample().sub(f.start)
+	self := total.sub(f.inner)
+	if n := len(s.stack); n > 0 {
+		s.stack[n-1].inner = s.stack[n-1].inner.add(total)
+	}
+
+	s.mu.Lock()
+	f.entry.Time += self.time
+	f.entry.AllocBytes += self.bytes
+	f.entry.AllocObjects += self.objects
+	s.mu.Unlock()
+}
+
+// profileDisjuncts attributes the expansion of a disjunct to the vertex that
+// is being unified.
+func (c *OpContext) profileDisjuncts() {
+	s := c.prof
+	if s == nil || len(s.stack) == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.stack[len(s.stack)-1].entry.Disjuncts++
+	s.mu.Unlock()
+}
+
+// startDisjunct returns the state needed to record the expansion of a
+// disjunct with endDisjunct.
+func (c *OpContext) startDisjunct() profSample {
+	if c.prof == nil {
+		return profSample{}
+	}
+	return c.prof.sample()
+}
+
+// endDisjunct records the expansion of a disjunct of the disjunction src,
+// which started with start.
+func (c *OpContext) endDisjunct(src Node, start profSample) {
+	s := c.prof
+	if s == nil {
+		return
+	}
+	total := s.sample().sub(start)
+	var pos token.Pos
+	if src != nil {
+		if x := src.Source(); x != nil {
+			pos = x.Pos()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.disjunctions == nil {
+		s.disjunctions = map[token.Pos]*ProfileEntry{}
+	}
+	e := s.disjunctions[pos]
+	if e == nil {
+		e = &ProfileEntry{Pos: pos}
+		s.disjunctions[pos] = e
+	}
+	e.Disjuncts++
+	e.Time += total.time
+	e.AllocBytes += total.bytes
+	e.AllocObjects += total.objects
+}
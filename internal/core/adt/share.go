@@ -0,0 +1,111 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import "cuelang.org/go/cue/ast"
+
+// Sharing of disjuncts
+//
+// A disjunction that remains unresolved after evaluation keeps a Vertex for
+// each of its disjuncts. When the same definition is instantiated many
+// times, for instance a template with a field like
+//
+//     proto: *"TCP" | "UDP"
+//
+// every instance ends up with its own copies of vertices that are
+// indistinguishable: they have no arcs and the same scalar value or scalar
+// constraint, such as int or >=0. Such leaf disjuncts are shared within an
+// OpContext instead.
+//
+// A shared vertex has no parent and no conjuncts, so that it does not refer
+// to the instance for which it was first created. It must not be modified;
+// see Vertex.Default.
+//
+// Vertices with arcs are not shared, as their arcs carry parent links and
+// closedness information that are specific to an instance.
+
+// shareKey identifies a leaf disjunct that may be shared.
+type shareKey struct {
+	label   Feature
+	value   shareValue
+	arcType ArcType
+	closed  bool
+	isData  bool
+	dynamic bool
+}
+
+// shareDisjunct returns a vertex with the value of v that is shared by all
+// disjunctions created within c, or v itself if v cannot be shared. The
+// returned vertex has no parent and no conjuncts.
+func (c *OpContext) shareDisjunct(v *Vertex) *Vertex {
+	if v.status != finalized ||
+		len(v.Arcs) > 0 ||
+		len(v.Structs) > 0 ||
+		v.ChildErrors != nil ||
+		v.PatternConstraints != nil ||
+		v.cyclicReferences != nil {
+		return v
+	}
+	x, ok := v.BaseValue.(Value)
+	if !ok {
+		return v
+	}
+	value, ok := makeShareValue(x)
+	if !ok {
+		return v
+	}
+	key := shareKey{
+		label:   v.Label,
+		value:   value,
+		arcType: v.ArcType,
+		closed:  v.Closed,
+		isData:  v.isData,
+		dynamic: v.IsDynamic,
+	}
+	if w, ok := c.shared[key]; ok {
+		return w
+	}
+	if c.shared == nil {
+		c.shared = map[shareKey]*Vertex{}
+	}
+	w := *v
+	w.Parent = nil
+	w.Conjuncts = nil
+	c.shared[key] = &w
+	return &w
+}
+
+// shareValue identifies a scalar value or constraint by the expression it
+// results from, as its evaluation creates a new Value each time.
+type shareValue struct {
+	value Value
+	src   ast.Node
+	op    Op
+	kind  Kind
+}
+
+func makeShareValue(x Value) (shareValue, bool) {
+	switch x := x.(type) {
+	case *BasicType:
+		return shareValue{src: x.Src, kind: x.K}, x.Src != nil
+	case *BoundValue:
+		return shareValue{src: x.Src, op: x.Op, value: x.Value},
+			x.Src != nil && IsConcrete(x.Value)
+	}
+	if x.Kind()&^ScalarKinds != 0 || !IsConcrete(x) {
+		return shareValue{}, false
+	}
+	return shareValue{value: x}, true
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt_test
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/compile"
+	"cuelang.org/go/internal/core/eval"
+	"cuelang.org/go/internal/core/runtime"
+)
+
+func TestShareDisjuncts(t *testing.T) {
+	f, err := parser.ParseFile("in.cue", `
+		#T: {
+			proto: *"TCP" | "UDP"
+			port:  *80 | int
+			min:   *0 | >=1
+		}
+		a: #T
+		b: #T
+		`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := runtime.New()
+	v, errs := compile.Files(nil, r, "", f)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	ctx := eval.NewContext(r, v)
+	v.Finalize(ctx)
+
+	disjuncts := func(name, field string) []adt.Value {
+		w := v.Lookup(adt.MakeIdentLabel(r, name, ""))
+		w = w.Lookup(adt.MakeIdentLabel(r, field, ""))
+		d, ok := w.BaseValue.(*adt.Disjunction)
+		if !ok {
+			t.Fatalf("%s.%s: got %T; want disjunction", name, field, w.BaseValue)
+		}
+		return d.Values
+	}
+	for _, field := range []string{"proto", "port", "min"} {
+		a, b := disjuncts("a", field), disjuncts("b", field)
+		if a[0] != b[0] || a[1] != b[1] {
+			t.Fatalf("disjuncts of %s are not shared", field)
+		}
+	}
+	a := disjuncts("a", "proto")
+	shared := a[0].(*adt.Vertex)
+	if shared.Parent != nil || shared.Conjuncts != nil {
+		t.Fatal("shared disjunct refers to the instance it was created for")
+	}
+
+	// Taking and modifying the default of one instance must not modify the
+	// disjuncts shared with the other.
+	w := v.Lookup(adt.MakeIdentLabel(r, "a", ""))
+	w = w.Lookup(adt.MakeIdentLabel(r, "proto", ""))
+	d := w.Default()
+	if d == shared {
+		t.Fatal("default is the shared disjunct")
+	}
+	d.AddConjunct(adt.MakeRootConjunct(nil, &adt.Top{}))
+	d.Parent = v
+	if shared.Parent != nil || shared.Conjuncts != nil {
+		t.Error("modifying the default of a modified the disjuncts of b")
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adt
+
+import (
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+// This file contains functionality for tracing evaluation.
+
+// A Tracer receives the events of an evaluation. Evaluations that run
+// concurrently, such as those of values built in parallel, report their
+// events concurrently.
+type Tracer interface {
+	Trace(e TraceEvent)
+}
+
+// A TraceKind identifies the kind of a TraceEvent.
+type TraceKind int
+
+const (
+	// VertexStart is reported when the evaluation of a value starts.
+	VertexStart TraceKind = iota + 1
+
+	// VertexDone is reported when a value is completely evaluated. Err is
+	// set if the value is an error.
+	VertexDone
+
+	// ConjunctAdded is reported when a conjunct is added to a value. Pos
+	// is the position of the conjunct.
+	ConjunctAdded
+
+	// DisjunctSelected is reported for a disjunct that unifies with a value.
+	// Pos is the position of the disjunct.
+	DisjunctSelected
+
+	// DisjunctDiscarded is reported for a disjunct that fails to unify with
+	// a value. Pos is the position of the disjunct and Err the reason it
+	// was discarded.
+	DisjunctDiscarded
+
+	// CycleDetected is reported when a reference cycle or structural cycle
+	// is found. Pos is the position of the reference, if known.
+	CycleDetected
+)
+
+func (k TraceKind) String() string {
+	switch k {
+	case VertexStart:
+		return "start"
+	case VertexDone:
+		return "done"
+	case ConjunctAdded:
+		return "conjunct"
+	case DisjunctSelected:
+		return "select"
+	case DisjunctDiscarded:
+		return "discard"
+	case CycleDetected:
+		return "cycle"
+	}
+	return "unknown"
+}
+
+// A TraceEvent describes a single step in the evaluation of a value.
+type TraceEvent struct {
+	Kind TraceKind
+
+	// Path is the path of the value the event applies to.
+	Path string
+
+	// Pos is the source position associated with the event. For VertexStart
+	// and VertexDone, it is the position of the first conjunct of the
+	// value.
+	Pos token.Pos
+
+	// Err holds the error associated with the event, if any.
+	Err errors.Error
+
+	// Depth is the number of values being evaluated within one another
+	// when the event occurred, which allows presenting events as a tree.
+	Depth int
+}
+
+// traceState reports the evaluation of an OpContext to a Tracer.
+type traceState struct {
+	Tracer
+	depth int
+}
+
+func newTraceState(h *Hooks) *traceState {
+	if h == nil || h.Tracer == nil {
+		return nil
+	}
+	return &traceState{Tracer: h.Tracer}
+}
+
+// trace reports an event for v to the tracer of c.
+func (c *OpContext) trace(kind TraceKind, v *Vertex, pos token.Pos, err *Bottom) {
+	e := TraceEvent{
+		Kind:  kind,
+		Path:  c.PathToString(c.Runtime, v.Path()),
+		Pos:   pos,
+		Depth: c.tracer.depth,
+	}
+	if err != nil {
+		e.Err = err.Err
+	}
+	c.tracer.Trace(e)
+}
+
+// traceEnter reports the start of the evaluation of v, if it was not
+// started before. It must be paired with a call to traceExit.
+func (c *OpContext) traceEnter(v *Vertex) {
+	if v.status == unprocessed {
+		c.trace(VertexStart, v, vertexPos(v), nil)
+	}
+	c.tracer.depth++
+}
+
+// traceExit ends the evaluation started by the last call to traceEnter. It
+// reports whether v is completely evaluated if it was not before.
+func (c *OpContext) traceExit(v *Vertex, status vertexStatus) {
+	c.tracer.depth--
+	if status != finalized && v.status == finalized {
+		b, _ := v.BaseValue.(*Bottom)
+		c.trace(VertexDone, v, vertexPos(v), b)
+	}
+}
+
+// traceConjunct reports the addition of x to the vertex of n.
+func (n *nodeContext) traceConjunct(x Node) {
+	if n.ctx.tracer == nil {
+		return
+	}
+	n.ctx.trace(ConjunctAdded, n.node, pos(x), nil)
+}
+
+// traceDisjunct reports whether the disjunct x was selected or discarded,
+// given the number of disjuncts and disjunct errors of n before x was
+// expanded.
+func (n *nodeContext) traceDisjunct(x Node, numDisjuncts, numErrs int) {
+	if n.ctx.tracer == nil {
+		return
+	}
+	if len(n.disjuncts) > numDisjuncts || len(n.disjunctErrs) == numErrs {
+		n.ctx.trace(DisjunctSelected, n.node, pos(x), nil)
+		return
+	}
+	n.ctx.trace(DisjunctDiscarded, n.node, pos(x), n.disjunctErrs[len(n.disjunctErrs)-1])
+}
+
+// traceCycle reports a cycle found while evaluating the vertex of n.
+func (n *nodeContext) traceCycle(x Node, err *Bottom) {
+	if n.ctx.tracer == nil {
+		return
+	}
+	var p token.Pos
+	if x != nil {
+		p = pos(x)
+	}
+	n.ctx.trace(CycleDetected, n.node, p, err)
+}
+
+func vertexPos(v *Vertex) token.Pos {
+	if len(v.Conjuncts) > 0 {
+		if src := v.Conjuncts[0].Source(); src != nil {
+			return src.Pos()
+		}
+	}
+	return token.NoPos
+}
@@ -1058,6 +1058,9 @@ func (c *compiler) addDisjunctionElem(d *adt.DisjunctionExpr, n ast.Expr, mark b
 // TODO(perf): validate that regexps are cached at the right time.
 
 func (c *compiler) parse(l *ast.BasicLit) (n adt.Expr) {
+	if l.Raw != nil {
+		return &adt.Bytes{Src: l, B: l.Raw}
+	}
 	s := l.Value
 	if s == "" {
 		return c.errf(l, "invalid literal %q", s)
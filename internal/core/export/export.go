@@ -21,6 +21,7 @@ import (
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/ast/astutil"
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/eval"
@@ -65,6 +66,11 @@ type Profile struct {
 
 	// InlineImports expands references to non-builtin packages.
 	InlineImports bool
+
+	// NumForm, if set, is used to write all integers, for instance to
+	// write them with multipliers such as Mi. Otherwise integers are written
+	// as in the source or without a multiplier.
+	NumForm *literal.NumForm
 }
 
 var Simplified = &Profile{
@@ -247,21 +247,24 @@ func (e *exporter) bool(n *adt.Bool) (b *ast.BasicLit) {
 func extractBasic(a []adt.Conjunct) *ast.BasicLit {
 	for _, v := range a {
 		if b, ok := v.Source().(*ast.BasicLit); ok {
-			return &ast.BasicLit{Kind: b.Kind, Value: b.Value}
+			return &ast.BasicLit{Kind: b.Kind, Value: b.Value, Raw: b.Raw}
 		}
 	}
 	return nil
 }
 
 func (e *exporter) num(n *adt.Num, orig []adt.Conjunct) *ast.BasicLit {
-	// TODO: take original formatting into account.
-	if b := extractBasic(orig); b != nil {
-		return b
-	}
 	kind := token.FLOAT
 	if n.K&adt.IntKind != 0 {
 		kind = token.INT
 	}
+	if kind == token.INT && e.cfg.NumForm != nil {
+		return &ast.BasicLit{Kind: kind, Value: e.cfg.NumForm.Format(&n.X)}
+	}
+	// TODO: take original formatting into account.
+	if b := extractBasic(orig); b != nil {
+		return b
+	}
 	s := n.X.String()
 	if kind == token.FLOAT && !strings.ContainsAny(s, "eE.") {
 		s += "."
@@ -35,18 +35,34 @@ type Config struct {
 
 	Counts *stats.Counts
 
+	// Parallel, if greater than one, is the number of goroutines with which
+	// to evaluate the independent top-level fields of the built value.
+	Parallel int
+
 	compile.Config
 }
 
 // Build builds b and all its transitive dependencies, insofar they have not
 // been build yet.
+//
+// Build may be called concurrently. An instance that is being built by
+// another goroutine, such as an import shared by several instances, is not
+// built again: Build waits for the other build to complete and returns its
+// result instead.
 func (x *Runtime) Build(cfg *Config, b *build.Instance) (v *adt.Vertex, errs errors.Error) {
+	done, wait := x.claimBuild(b)
+	if wait != nil {
+		<-wait
+	}
+	if done == nil {
+		// b was built by another call.
+		return x.getNodeFromInstance(b), b.Err
+	}
+	defer x.releaseBuild(b, done)
+
 	if err := b.Complete(); err != nil {
 		return nil, b.Err
 	}
-	if v := x.getNodeFromInstance(b); v != nil {
-		return v, b.Err
-	}
 	// TODO: clear cache of old implementation.
 	// if s := b.ImportPath; s != "" {
 	// 	// Use cached result, if available.
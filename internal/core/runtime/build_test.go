@@ -0,0 +1,86 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime_test
+
+import (
+	"sync"
+	"testing"
+
+	"golang.org/x/tools/txtar"
+
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/runtime"
+	"cuelang.org/go/internal/cuetxtar"
+)
+
+// TestBuildConcurrent checks that instances sharing an import can be built
+// concurrently and that the import is only built once. It is most useful
+// when run with the race detector.
+func TestBuildConcurrent(t *testing.T) {
+	a := txtar.Parse([]byte(`
+-- cue.mod/module.cue --
+module: "mod.test"
+-- a/a.cue --
+package a
+
+import "mod.test/shared"
+
+x: shared.#T & {n: 1}
+-- b/b.cue --
+package b
+
+import "mod.test/shared"
+
+x: shared.#T & {n: 2}
+-- shared/shared.cue --
+package shared
+
+#T: {
+	n: int
+	m: n * 2
+}
+`))
+	insts := cuetxtar.Load(a, t.TempDir(), "./a", "./b")
+	shared := insts[0].Imports[0]
+	if insts[1].Imports[0] != shared {
+		t.Fatal("instances do not share import")
+	}
+
+	// Build each instance twice.
+	insts = append(insts, insts...)
+
+	r := runtime.New()
+	vs := make([]*adt.Vertex, len(insts))
+	var wg sync.WaitGroup
+	for i := range insts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := r.Build(nil, insts[i])
+			if err != nil {
+				t.Error(err)
+			}
+			vs[i] = v
+		}(i)
+	}
+	wg.Wait()
+
+	if vs[0] == nil || vs[0] != vs[2] || vs[1] != vs[3] {
+		t.Error("instance built more than once")
+	}
+	if got := r.GetInstanceFromNode(r.LoadImport("mod.test/shared")); got != shared {
+		t.Errorf("import registered for %v; want %v", got, shared)
+	}
+}
@@ -16,6 +16,7 @@ package runtime
 
 import (
 	"path"
+	"sort"
 	"sync"
 
 	"cuelang.org/go/cue/build"
@@ -50,6 +51,17 @@ func (x *Runtime) BuiltinPackagePath(path string) string {
 	return x.index.shortBuiltinToPath(path)
 }
 
+// BuiltinPackagePaths returns the import paths of all builtin packages in
+// sorted order.
+func (x *Runtime) BuiltinPackagePaths() []string {
+	paths := make([]string, 0, len(x.index.builtinPaths))
+	for p := range x.index.builtinPaths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
 // sharedIndex is used for indexing builtins and any other labels common to
 // all instances.
 var sharedIndex = newIndex()
@@ -65,6 +77,10 @@ type index struct {
 	importsByPath  map[string]*adt.Vertex
 	importsByBuild map[*build.Instance]*adt.Vertex
 
+	// building holds, for each instance that is currently being built,
+	// a channel that is closed once the build completes.
+	building map[*build.Instance]chan struct{}
+
 	nextUniqueID uint64
 
 	// These are initialized during Go package initialization time and do not
@@ -89,6 +105,7 @@ func newIndex() *index {
 		imports:        map[*adt.Vertex]*build.Instance{},
 		importsByPath:  map[string]*adt.Vertex{},
 		importsByBuild: map[*build.Instance]*adt.Vertex{},
+		building:       map[*build.Instance]chan struct{}{},
 	}
 	return i
 }
@@ -115,6 +132,56 @@ func (r *Runtime) AddInst(path string, key *adt.Vertex, p *build.Instance) {
 	}
 }
 
+// RemoveInst discards the results of building the given instances, so that
+// they may be garbage collected and the instances built anew. Import
+// references to a removed instance by path no longer resolve to it, so
+// values of instances importing it should be discarded as well.
+func (r *Runtime) RemoveInst(a ...*build.Instance) {
+	r.index.lock.Lock()
+	defer r.index.lock.Unlock()
+
+	x := r.index
+	for _, p := range a {
+		key := x.importsByBuild[p]
+		if key == nil {
+			continue
+		}
+		delete(x.importsByBuild, p)
+		delete(x.imports, key)
+		if x.importsByPath[p.ImportPath] == key {
+			delete(x.importsByPath, p.ImportPath)
+		}
+	}
+}
+
+// claimBuild claims the right to build b. It returns a non-nil done channel,
+// which must be passed to releaseBuild, if b has not been built yet and is
+// not being built by another goroutine. Otherwise, it returns a channel that
+// is closed once the other build completes, if any.
+func (r *Runtime) claimBuild(b *build.Instance) (done, wait chan struct{}) {
+	r.index.lock.Lock()
+	defer r.index.lock.Unlock()
+
+	x := r.index
+	if x.importsByBuild[b] != nil {
+		return nil, nil
+	}
+	if c, ok := x.building[b]; ok {
+		return nil, c
+	}
+	done = make(chan struct{})
+	x.building[b] = done
+	return done, nil
+}
+
+// releaseBuild marks the build of b, claimed with claimBuild, as completed.
+func (r *Runtime) releaseBuild(b *build.Instance, done chan struct{}) {
+	r.index.lock.Lock()
+	delete(r.index.building, b)
+	r.index.lock.Unlock()
+	close(done)
+}
+
 func (r *Runtime) GetInstanceFromNode(key *adt.Vertex) *build.Instance {
 	r.index.lock.RLock()
 	defer r.index.lock.RUnlock()
@@ -16,6 +16,7 @@ package runtime
 
 import (
 	"cuelang.org/go/cue/build"
+	"cuelang.org/go/internal/core/adt"
 )
 
 // A Runtime maintains data structures for indexing and reuse for evaluation.
@@ -27,6 +28,25 @@ type Runtime struct {
 	// interpreters implement extern functionality. The map key corresponds to
 	// the kind in a file-level @extern(kind) attribute.
 	interpreters map[string]Interpreter
+
+	// hooks configure the accounting, bounding and reporting of each
+	// evaluation.
+	hooks adt.Hooks
+
+	// cacheLock guards the caches of values that are evaluated by several
+	// goroutines at once.
+	cacheLock adt.CacheLock
+}
+
+// Hooks implements adt.HooksRuntime. Changes to the returned hooks apply to
+// evaluations started afterwards.
+func (r *Runtime) Hooks() *adt.Hooks {
+	return &r.hooks
+}
+
+// CacheLock implements adt.CacheLockRuntime.
+func (r *Runtime) CacheLock() *adt.CacheLock {
+	return &r.cacheLock
 }
 
 func (r *Runtime) SetBuildData(b *build.Instance, x interface{}) {
@@ -0,0 +1,73 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"sync"
+
+	"cuelang.org/go/cue/stats"
+)
+
+// statsRecorder aggregates the statistics of all operation contexts created
+// for a runtime.
+type statsRecorder struct {
+	mu sync.Mutex
+
+	// released holds the aggregate counts of released contexts.
+	released stats.Counts
+
+	// live holds the counts of contexts that are still in use.
+	live map[*stats.Counts]bool
+}
+
+// RecordStats enables recording statistics of all evaluations using r.
+func (r *Runtime) RecordStats() {
+	if r.hooks.Stats == nil {
+		r.hooks.Stats = &statsRecorder{live: map[*stats.Counts]bool{}}
+	}
+}
+
+// AddStats implements adt.StatsRecorder.
+func (s *statsRecorder) AddStats(counts *stats.Counts) {
+	s.mu.Lock()
+	s.live[counts] = true
+	s.mu.Unlock()
+}
+
+// ReleaseStats implements adt.StatsRecorder.
+func (s *statsRecorder) ReleaseStats(counts *stats.Counts) {
+	s.mu.Lock()
+	if s.live[counts] {
+		delete(s.live, counts)
+		s.released.Add(*counts)
+	}
+	s.mu.Unlock()
+}
+
+// Stats reports the aggregate statistics of all evaluations since
+// RecordStats was called.
+func (r *Runtime) Stats() (counts stats.Counts) {
+	s, ok := r.hooks.Stats.(*statsRecorder)
+	if !ok {
+		return counts
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts = s.released
+	for c := range s.live {
+		counts.Add(*c)
+	}
+	return counts
+}
@@ -44,6 +44,11 @@ type Profile struct {
 	// IgnoreClosedness ignores closedness of structs and is used for comparing
 	// APIs.
 	IgnoreClosedness bool
+
+	// Explain continues checking fields after a field is found not to be
+	// subsumed, so that the returned error lists each offending field along
+	// with its path.
+	Explain bool
 }
 
 var Simplify = Profile{
@@ -102,6 +107,7 @@ type subsumer struct {
 	Profile
 
 	inexact bool // If true, the result could be a false negative.
+	trying  int  // > 0 when trying alternatives, of which only one must match
 	missing adt.Feature
 	gt      adt.Value
 	lt      adt.Value
@@ -112,6 +118,35 @@ func (s *subsumer) errf(msg string, args ...interface{}) {
 	s.errs = errors.Append(s.errs, b.Err)
 }
 
+// fail records an error for field f of n, or for n itself if f is 0. It
+// reports whether checking should stop, which is the case unless the profile
+// requests an explanation of all failures.
+func (s *subsumer) fail(n *adt.Vertex, f adt.Feature, msg string, args ...interface{}) (stop bool) {
+	if !s.explaining() {
+		s.errf(msg, args...)
+		return true
+	}
+	if f != 0 {
+		n = &adt.Vertex{Parent: n, Label: f}
+	}
+	defer s.ctx.PopArc(s.ctx.PushArc(n))
+	s.errf(msg, args...)
+	return false
+}
+
+// explaining reports whether errors are collected for all failing fields.
+func (s *subsumer) explaining() bool {
+	return s.Explain && s.trying == 0
+}
+
+// try reports whether a subsumes b, where a failure does not imply that
+// subsumption fails as a whole, as other alternatives may still match.
+func (s *subsumer) try(a, b adt.Value) bool {
+	s.trying++
+	defer func() { s.trying-- }()
+	return s.values(a, b)
+}
+
 func unifyValue(c *adt.OpContext, a, b adt.Value) adt.Value {
 	v := &adt.Vertex{}
 	v.AddConjunct(adt.MakeRootConjunct(c.Env(0), a))
@@ -123,7 +158,8 @@ func unifyValue(c *adt.OpContext, a, b adt.Value) adt.Value {
 func (s *subsumer) getError() (err errors.Error) {
 	c := s.ctx
 	// src := binSrc(token.NoPos, opUnify, gt, lt)
-	if s.gt != nil && s.lt != nil {
+	// With Explain, all failures have been reported already.
+	if s.gt != nil && s.lt != nil && !(s.Explain && s.errs != nil) {
 		// src := binSrc(token.NoPos, opUnify, s.gt, s.lt)
 		if s.missing != 0 {
 			s.errf("missing field %q", s.missing.SelectorString(c))
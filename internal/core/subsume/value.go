@@ -58,7 +58,7 @@ func (s *subsumer) values(a, b adt.Value) (result bool) {
 			break
 		}
 		for _, y := range b.Values {
-			if s.values(a, y) {
+			if s.try(a, y) {
 				return true
 			}
 		}
@@ -150,7 +150,7 @@ func (s *subsumer) values(a, b adt.Value) (result bool) {
 		outerC:
 			for _, a := range x.Values {
 				for _, b := range y.Values {
-					if s.values(a, b) {
+					if s.try(a, b) {
 						continue outerC
 					}
 				}
@@ -190,7 +190,7 @@ func (s *subsumer) values(a, b adt.Value) (result bool) {
 				// v is subsumed if any value in x subsumes v.
 				for j, a := range x.Values {
 					aDefault := j < x.NumDefaults
-					if (aDefault || !bDefault) && s.values(a, b) {
+					if (aDefault || !bDefault) && s.try(a, b) {
 						continue outerD
 					}
 				}
@@ -200,7 +200,7 @@ func (s *subsumer) values(a, b adt.Value) (result bool) {
 		}
 		// b is subsumed if any value in x subsumes b.
 		for _, a := range x.Values {
-			if s.values(a, b) {
+			if s.try(a, b) {
 				return true
 			}
 		}
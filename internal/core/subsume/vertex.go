@@ -17,6 +17,7 @@ package subsume
 import (
 	"fmt"
 
+	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/export"
 )
@@ -101,6 +102,10 @@ func (s *subsumer) vertices(x, y *adt.Vertex) bool {
 		return false
 	}
 
+	// ok is only set to false when explaining, as otherwise the first failure
+	// returns immediately.
+	ok := true
+
 	// All arcs in x must exist in y and its values must subsume.
 	xFeatures := export.VertexFeatures(s.ctx, x)
 	for _, f := range xFeatures {
@@ -145,8 +150,11 @@ func (s *subsumer) vertices(x, y *adt.Vertex) bool {
 		if b == nil {
 			// y.f is optional
 			if !aOpt {
-				s.errf("required field is optional in subsumed value: %v", f)
-				return false
+				if s.fail(y, f, "required field is optional in subsumed value: %v", f) {
+					return false
+				}
+				ok = false
+				continue
 			}
 
 			// If f is undefined for y and if y is closed, the field is
@@ -162,10 +170,20 @@ func (s *subsumer) vertices(x, y *adt.Vertex) bool {
 			b.Finalize(ctx)
 		}
 
+		numErrs := len(errors.Errors(s.errs))
 		if s.values(a, b) {
 			continue
 		}
 
+		if s.explaining() {
+			ok = false
+			if len(errors.Errors(s.errs)) == numErrs {
+				// No more specific error was reported.
+				s.fail(y, f, "%v does not subsume %v", a, b)
+			}
+			continue
+		}
+
 		s.missing = f
 		s.gt = a
 		s.lt = y
@@ -175,8 +193,10 @@ func (s *subsumer) vertices(x, y *adt.Vertex) bool {
 	}
 
 	if xClosed && !yClosed && !s.Final {
-		s.errf("closed struct does not subsume open struct")
-		return false
+		if s.fail(y, 0, "closed struct does not subsume open struct") {
+			return false
+		}
+		ok = false
 	}
 
 	yFeatures := export.VertexFeatures(s.ctx, y)
@@ -211,8 +231,11 @@ outer:
 			if s.Profile.IgnoreClosedness {
 				continue
 			}
-			s.errf("field not allowed in closed struct: %v", f)
-			return false
+			if s.fail(y, f, "field not allowed in closed struct: %v", f) {
+				return false
+			}
+			ok = false
+			continue
 		}
 
 		a := &adt.Vertex{Label: f}
@@ -225,12 +248,19 @@ outer:
 		a.Finalize(ctx)
 		b.Finalize(ctx)
 
+		numErrs := len(errors.Errors(s.errs))
 		if !s.vertices(a, b) {
-			return false
+			if !s.explaining() {
+				return false
+			}
+			ok = false
+			if len(errors.Errors(s.errs)) == numErrs {
+				s.fail(y, f, "%v does not subsume %v", a, b)
+			}
 		}
 	}
 
-	return true
+	return ok
 }
 
 func (s *subsumer) listVertices(x, y *adt.Vertex) bool {
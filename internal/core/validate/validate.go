@@ -16,7 +16,12 @@
 package validate
 
 import (
+	"strings"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/dep"
 )
 
 type Config struct {
@@ -32,6 +37,19 @@ type Config struct {
 	// AllErrors continues descending into a Vertex, even if errors are found.
 	AllErrors bool
 
+	// Exhaustive, if true, implies AllErrors and also descends into a Vertex
+	// that itself is an error, to report errors of its descendants.
+	Exhaustive bool
+
+	// MaxErrors, if positive, stops validation after this many errors were
+	// found.
+	MaxErrors int
+
+	// LateBound holds the vertices for which the Concrete check is skipped,
+	// including their descendants, as their values are only supplied later.
+	// Errors within these vertices are still reported.
+	LateBound map[*adt.Vertex]bool
+
 	// TODO: omitOptional, if this is becomes relevant.
 }
 
@@ -42,6 +60,9 @@ func Validate(ctx *adt.OpContext, v *adt.Vertex, cfg *Config) *adt.Bottom {
 		cfg = &Config{}
 	}
 	x := validator{Config: *cfg, ctx: ctx}
+	if x.Exhaustive {
+		x.AllErrors = true
+	}
 	x.validate(v)
 	return x.err
 }
@@ -50,26 +71,108 @@ type validator struct {
 	Config
 	ctx          *adt.OpContext
 	err          *adt.Bottom
+	numErrs      int
+	seen         map[string]bool
 	inDefinition int
+	inLateBound  int
+}
+
+// done reports whether the maximum number of errors has been reached.
+func (v *validator) done() bool {
+	return v.MaxErrors > 0 && v.numErrs >= v.MaxErrors
 }
 
 func (v *validator) checkConcrete() bool {
-	return v.Concrete && v.inDefinition == 0
+	return v.Concrete && v.inDefinition == 0 && v.inLateBound == 0
 }
 
 func (v *validator) add(b *adt.Bottom) {
+	if v.done() {
+		return
+	}
+	if v.Exhaustive {
+		v.addEach(b)
+		return
+	}
 	if !v.AllErrors {
 		v.err = adt.CombineErrors(nil, v.err, b)
+		v.numErrs++
 		return
 	}
 	if !b.ChildError {
 		v.err = adt.CombineErrors(nil, v.err, b)
+		v.numErrs++
+	}
+}
+
+// addEach adds each individual error of b that was not reported before.
+// Unlike CombineErrors, it retains incomplete errors alongside other errors,
+// as all of them are relevant in exhaustive mode.
+func (v *validator) addEach(b *adt.Bottom) {
+	if b.ChildError {
+		return
+	}
+	if v.seen == nil {
+		v.seen = map[string]bool{}
 	}
+	for _, e := range errors.Errors(b.Err) {
+		key := strings.Join(e.Path(), ".") + ": " + e.Error()
+		if v.seen[key] {
+			continue
+		}
+		v.seen[key] = true
+		if v.err == nil {
+			v.err = &adt.Bottom{Code: b.Code}
+		} else if b.Code < v.err.Code {
+			v.err.Code = b.Code
+		}
+		v.err.Err = errors.Append(v.err.Err, e)
+		v.numErrs++
+		if v.done() {
+			return
+		}
+	}
+}
+
+// errLateBound is used to stop visiting dependencies once a late-bound
+// dependency has been found.
+var errLateBound = errors.Newf(token.NoPos, "late-bound dependency")
+
+// dependsOnLateBound reports whether x refers, directly or indirectly, to a
+// late-bound vertex, in which case it is allowed to be incomplete.
+func (v *validator) dependsOnLateBound(x *adt.Vertex) bool {
+	if len(v.LateBound) == 0 {
+		return false
+	}
+	found := false
+	visited := map[*adt.Vertex]bool{}
+	dep.Visit(nil, v.ctx, x, func(d dep.Dependency) error {
+		for n := d.Node; n != nil; n = n.Parent {
+			if v.LateBound[n] {
+				found = true
+				return errLateBound
+			}
+		}
+		if !found && !visited[d.Node] {
+			visited[d.Node] = true
+			d.Recurse()
+		}
+		if found {
+			return errLateBound
+		}
+		return nil
+	})
+	return found
 }
 
 func (v *validator) validate(x *adt.Vertex) {
 	defer v.ctx.PopArc(v.ctx.PushArc(x))
 
+	if v.LateBound[x] {
+		v.inLateBound++
+		defer func() { v.inLateBound-- }()
+	}
+
 	if b, _ := x.BaseValue.(*adt.Bottom); b != nil {
 		switch b.Code {
 		case adt.CycleError:
@@ -78,20 +181,20 @@ func (v *validator) validate(x *adt.Vertex) {
 			}
 
 		case adt.IncompleteError:
-			if v.checkConcrete() {
+			if v.checkConcrete() && !v.dependsOnLateBound(x) {
 				v.add(b)
 			}
 
 		default:
 			v.add(b)
 		}
-		if !b.HasRecursive {
+		if !b.HasRecursive && !v.Exhaustive {
 			return
 		}
 
 	} else if v.checkConcrete() {
 		x = x.Default()
-		if !adt.IsConcrete(x) {
+		if !adt.IsConcrete(x) && !v.dependsOnLateBound(x) {
 			x := x.Value()
 			v.add(&adt.Bottom{
 				Code: adt.IncompleteError,
@@ -101,7 +204,8 @@ func (v *validator) validate(x *adt.Vertex) {
 	}
 
 	for _, a := range x.Arcs {
-		if a.ArcType == adt.ArcRequired && v.Final && v.inDefinition == 0 {
+		if a.ArcType == adt.ArcRequired && v.Final && v.inDefinition == 0 &&
+			v.inLateBound == 0 && !v.LateBound[a] {
 			v.add(adt.NewRequiredNotPresentError(v.ctx, a))
 			continue
 		}
@@ -109,7 +213,7 @@ func (v *validator) validate(x *adt.Vertex) {
 		if a.Label.IsLet() || !a.IsDefined(v.ctx) {
 			continue
 		}
-		if !v.AllErrors && v.err != nil {
+		if !v.AllErrors && v.err != nil || v.done() {
 			break
 		}
 		if a.Label.IsRegular() {
@@ -96,6 +96,27 @@ x: conflicting values 2 and 1:
 y: conflicting values 4 and 2:
     test:3:6
     test:3:10`,
+	}, {
+		name: "max errors",
+		cfg:  &Config{AllErrors: true, MaxErrors: 1},
+		in: `
+		x: 1 & 2
+		y: 2 & 4
+		`,
+		out: "eval\nx: conflicting values 2 and 1:\n    test:2:6\n    test:2:10",
+	}, {
+		name: "exhaustive",
+		cfg:  &Config{Exhaustive: true},
+		in: `
+		x: {a: 1 & 2, b: 3 & 4, if a == 1 {c: 1}}
+		`,
+		out: `eval
+x.a: conflicting values 2 and 1:
+    test:2:10
+    test:2:14
+x.b: conflicting values 4 and 3:
+    test:2:20
+    test:2:24`,
 	}, {
 		name: "incomplete",
 		cfg:  &Config{Concrete: true},
@@ -396,6 +396,24 @@ a: x: "hello"
 		x:       `{a: 1, _hidden1: 1, _hidden: 1}`,
 		y:       `{a: 1, _hidden2: 1, _hidden: 2}`,
 		profile: &Profile{SkipHidden: true, Concrete: true},
+	}, {
+		name: "no docs or optional fields in values",
+		x:    `{a: 1}`,
+		y: `{
+			a: {
+				// doc
+				b: 1
+				c?: 2
+			}
+		}`,
+		kind: Modified,
+		diff: `  {
+-     a: 1
++     a: {
++     	b: 1
++     }
+  }
+`,
 	}, {
 		name: "all errors are equal",
 		x:    `1 & 3`,
@@ -15,11 +15,15 @@
 package diff
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/internal/core/export"
+	"cuelang.org/go/internal/value"
 )
 
 // Print the differences between two structs represented by an edit script.
@@ -187,8 +191,12 @@ func (p *printer) printSkipped(n int) {
 
 func (p *printer) printValue(v cue.Value) {
 	// TODO: have indent option.
-	s := fmt.Sprintf("%+v", v)
-	io.WriteString(p, s)
+	// The evaluated form is printed without the doc comments and optional
+	// fields that %+v includes.
+	r, x := value.ToInternal(v)
+	n, _ := export.Final.Value(r, "", x)
+	b, _ := format.Node(n)
+	p.Write(bytes.Trim(b, "\n\r"))
 }
 
 func (p *printer) printFieldRun(es *EditScript, start, end int) {
@@ -22,6 +22,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
@@ -29,10 +30,15 @@ import (
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/avro"
+	"cuelang.org/go/encoding/jsonschema"
 	"cuelang.org/go/encoding/openapi"
 	"cuelang.org/go/encoding/protobuf/jsonpb"
 	"cuelang.org/go/encoding/protobuf/textproto"
+	"cuelang.org/go/encoding/toml"
+	"cuelang.org/go/encoding/xml"
 	"cuelang.org/go/internal"
+	cueyaml "cuelang.org/go/internal/encoding/yaml"
 	"cuelang.org/go/internal/filetypes"
 	"cuelang.org/go/pkg/encoding/yaml"
 )
@@ -77,6 +83,12 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 		close: close,
 	}
 
+	if cfg.Header != "" {
+		if err := writeHeader(w, f, cfg.Header); err != nil {
+			return nil, err
+		}
+	}
+
 	switch f.Interpretation {
 	case "":
 	case build.OpenAPI:
@@ -94,13 +106,17 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 			f := valueToFile(v)
 			return f, jsonpb.NewEncoder(v).RewriteFile(f)
 		}
-
-	// case build.JSONSchema:
-	// 	// TODO: get encoding options
-	// 	cfg := openapi.Config{}
-	// 	i.interpret = func(inst *cue.Instance) (*ast.File, error) {
-	// 		return jsonschmea.Generate(inst, cfg)
-	// 	}
+	case build.JSONSchema:
+		// TODO: get encoding options
+		cfg := &jsonschema.Config{}
+		e.interpret = func(v cue.Value) (*ast.File, error) {
+			return jsonschema.Generate(v, cfg)
+		}
+	case build.Avro:
+		cfg := &avro.Config{}
+		e.interpret = func(v cue.Value) (*ast.File, error) {
+			return avro.Generate(v, cfg)
+		}
 	default:
 		return nil, fmt.Errorf("unsupported interpretation %q", f.Interpretation)
 	}
@@ -161,7 +177,7 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 		}
 		e.encFile = func(f *ast.File) error { return format(f.Filename, f) }
 
-	case build.JSON, build.JSONL:
+	case build.JSON:
 		e.concrete = true
 		d := json.NewEncoder(w)
 		d.SetIndent("", "    ")
@@ -174,6 +190,31 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 			return err
 		}
 
+	case build.JSONL:
+		e.concrete = true
+		d := json.NewEncoder(w)
+		d.SetEscapeHTML(cfg.EscapeHTML)
+		encode := func(v cue.Value) error {
+			err := d.Encode(v)
+			if x, ok := err.(*json.MarshalerError); ok {
+				err = x.Err
+			}
+			return err
+		}
+		e.encValue = func(v cue.Value) error {
+			// Write the elements of a list as separate records.
+			iter, err := v.List()
+			if err != nil {
+				return encode(v)
+			}
+			for iter.Next() {
+				if err := encode(iter.Value()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
 	case build.YAML:
 		e.concrete = true
 		streamed := false
@@ -183,6 +224,20 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 			}
 			streamed = true
 
+			if cfg.PreserveYAML {
+				b, err := cueyaml.Encode(v.Syntax(
+					cue.Final(),
+					cue.Concrete(true),
+					cue.Docs(true),
+					cue.Attributes(true),
+				))
+				if err != nil {
+					return err
+				}
+				_, err = w.Write(b)
+				return err
+			}
+
 			str, err := yaml.Marshal(v)
 			if err != nil {
 				return err
@@ -191,6 +246,14 @@ func NewEncoder(f *build.File, cfg *Config) (*Encoder, error) {
 			return err
 		}
 
+	case build.TOML:
+		e.concrete = true
+		e.encValue = toml.NewEncoder(w).Encode
+
+	case build.XML:
+		e.concrete = true
+		e.encValue = xml.NewEncoder(w).Encode
+
 	case build.TextProto:
 		// TODO: verify that the schema is given. Otherwise err out.
 		e.concrete = true
@@ -290,6 +353,32 @@ func (e *Encoder) encodeFile(f *ast.File, interpret func(cue.Value) (*ast.File,
 	return e.encValue(v)
 }
 
+// writeHeader writes header as a comment followed by an empty line, using
+// the comment syntax of the encoding of f.
+func writeHeader(w io.Writer, f *build.File, header string) error {
+	var prefix string
+	switch f.Encoding {
+	case build.CUE:
+		prefix = "//"
+	case build.YAML, build.TOML, build.TextProto:
+		prefix = "#"
+	default:
+		return fmt.Errorf("cannot write header: %s does not support comments", f.Encoding)
+	}
+	b := &bytes.Buffer{}
+	for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+		b.WriteString(prefix)
+		if line != "" {
+			b.WriteString(" ")
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
 func writer(f *build.File, cfg *Config) (_ io.Writer, close func() error, err error) {
 	if cfg.Out != nil {
 		return cfg.Out, nil, nil
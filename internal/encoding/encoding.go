@@ -33,14 +33,24 @@ import (
 	"cuelang.org/go/cue/literal"
 	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/cue/token"
+	"cuelang.org/go/encoding/avro"
+	"cuelang.org/go/encoding/dotenv"
+	"cuelang.org/go/encoding/graphql"
+	"cuelang.org/go/encoding/hcl"
+	"cuelang.org/go/encoding/ini"
 	"cuelang.org/go/encoding/json"
 	"cuelang.org/go/encoding/jsonschema"
 	"cuelang.org/go/encoding/openapi"
 	"cuelang.org/go/encoding/protobuf"
+	"cuelang.org/go/encoding/protobuf/binpb"
 	"cuelang.org/go/encoding/protobuf/jsonpb"
 	"cuelang.org/go/encoding/protobuf/textproto"
+	"cuelang.org/go/encoding/tfschema"
+	"cuelang.org/go/encoding/toml"
+	"cuelang.org/go/encoding/xml"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/filetypes"
+	"cuelang.org/go/internal/source"
 	"cuelang.org/go/internal/third_party/yaml"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
@@ -159,9 +169,20 @@ type Config struct {
 
 	EscapeHTML    bool
 	InlineImports bool // expand references to non-core imports
+	PreserveYAML  bool // keep YAML comments, anchors, and aliases
 	ProtoPath     []string
 	Format        []format.Option
 	ParseFile     func(name string, src interface{}) (*ast.File, error)
+
+	// Header, if non-empty, is written as a comment at the start of the
+	// output. It is an error to set it for encodings without comments.
+	Header string
+
+	// Mappings, if not nil, is used to map large binary files into memory
+	// rather than reading them. The literals decoded from such files refer
+	// to the mapped bytes, so neither they nor the values built from them
+	// may be used after Mappings is closed.
+	Mappings *source.Mappings
 }
 
 // NewDecoder returns a stream of non-rooted data expressions. The encoding
@@ -186,6 +207,22 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 		return i
 	}
 
+	if f.Encoding == build.Binary && cfg.Mappings != nil && f.Source == nil && f.Filename != "-" {
+		i.closer = io.NopCloser(strings.NewReader(""))
+		b, mapped, err := cfg.Mappings.MapFile(f.Filename)
+		switch {
+		case err != nil:
+			i.err = err
+		case mapped:
+			// Refer to the mapped bytes rather than quoting them, which
+			// would load all of them into memory.
+			i.expr = ast.NewBytes(b)
+		default:
+			i.expr = bytesLit(b)
+		}
+		return i
+	}
+
 	rc, err := reader(f, cfg.Stdin)
 	i.closer = rc
 	i.err = err
@@ -193,13 +230,16 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 		return i
 	}
 
-	// For now we assume that all encodings require UTF-8. This will not be the
-	// case for some binary protocols. We need to exempt those explicitly here
-	// once we introduce them.
+	// All encodings but the binary ones require UTF-8.
 	// TODO: this code also allows UTF16, which is too permissive for some
 	// encodings. Switch to unicode.UTF8Sig once available.
-	t := unicode.BOMOverride(unicode.UTF8.NewDecoder())
-	r := transform.NewReader(rc, t)
+	var r io.Reader = rc
+	switch f.Encoding {
+	case build.Binary, build.BinaryProto:
+	default:
+		t := unicode.BOMOverride(unicode.UTF8.NewDecoder())
+		r = transform.NewReader(rc, t)
+	}
 
 	switch f.Interpretation {
 	case "":
@@ -224,6 +264,12 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 	case build.ProtobufJSON:
 		i.interpretation = build.ProtobufJSON
 		i.rewriteFunc = protobufJSONFunc(cfg, f)
+	case build.Avro:
+		i.interpretation = build.Avro
+		i.interpretFunc = avroFunc(cfg, f)
+	case build.TFSchema:
+		i.interpretation = build.TFSchema
+		i.interpretFunc = tfSchemaFunc(cfg, f)
 	default:
 		i.err = fmt.Errorf("unsupported interpretation %q", f.Interpretation)
 	}
@@ -246,8 +292,24 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 	case build.YAML:
 		d, err := yaml.NewDecoder(path, r)
 		i.err = err
+		d.SetAnchors(cfg.PreserveYAML)
 		i.next = d.Decode
 		i.Next()
+	case build.TOML:
+		i.next = toml.NewDecoder(path, r).Decode
+		i.Next()
+	case build.XML:
+		i.next = xml.NewDecoder(path, r).Decode
+		i.Next()
+	case build.HCL:
+		i.next = hcl.NewDecoder(path, r).Decode
+		i.Next()
+	case build.INI:
+		i.next = ini.NewDecoder(path, r).Decode
+		i.Next()
+	case build.DotEnv:
+		i.next = dotenv.NewDecoder(path, r).Decode
+		i.Next()
 	case build.Text:
 		b, err := io.ReadAll(r)
 		i.err = err
@@ -255,14 +317,17 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 	case build.Binary:
 		b, err := io.ReadAll(r)
 		i.err = err
-		s := literal.Bytes.WithTabIndent(1).Quote(string(b))
-		i.expr = ast.NewLit(token.STRING, s)
+		i.expr = bytesLit(b)
 	case build.Protobuf:
 		paths := &protobuf.Config{
 			Paths:   cfg.ProtoPath,
 			PkgName: cfg.PkgName,
 		}
 		i.file, i.err = protobuf.Extract(path, r, paths)
+	case build.GraphQL:
+		i.file, i.err = graphql.Extract(path, r, &graphql.Config{
+			PkgName: cfg.PkgName,
+		})
 	case build.TextProto:
 		b, err := io.ReadAll(r)
 		i.err = err
@@ -270,6 +335,13 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 			d := textproto.NewDecoder()
 			i.expr, i.err = d.Parse(cfg.Schema, path, b)
 		}
+	case build.BinaryProto:
+		b, err := io.ReadAll(r)
+		i.err = err
+		if err == nil {
+			d := binpb.NewDecoder()
+			i.expr, i.err = d.Parse(cfg.Schema, path, b)
+		}
 	default:
 		i.err = fmt.Errorf("unsupported encoding %q", f.Encoding)
 	}
@@ -277,6 +349,11 @@ func NewDecoder(f *build.File, cfg *Config) *Decoder {
 	return i
 }
 
+func bytesLit(b []byte) ast.Expr {
+	s := literal.Bytes.WithTabIndent(1).Quote(string(b))
+	return ast.NewLit(token.STRING, s)
+}
+
 func jsonSchemaFunc(cfg *Config, f *build.File) interpretFunc {
 	return func(i *cue.Instance) (file *ast.File, id string, err error) {
 		id = f.Tags["id"]
@@ -314,6 +391,22 @@ func openAPIFunc(c *Config, f *build.File) interpretFunc {
 	}
 }
 
+func avroFunc(c *Config, f *build.File) interpretFunc {
+	cfg := &avro.Config{PkgName: c.PkgName}
+	return func(i *cue.Instance) (file *ast.File, id string, err error) {
+		file, err = avro.Extract(i, cfg)
+		return file, "", err
+	}
+}
+
+func tfSchemaFunc(c *Config, f *build.File) interpretFunc {
+	cfg := &tfschema.Config{PkgName: c.PkgName}
+	return func(i *cue.Instance) (file *ast.File, id string, err error) {
+		file, err = tfschema.Extract(i, cfg)
+		return file, "", err
+	}
+}
+
 func protobufJSONFunc(cfg *Config, file *build.File) rewriteFunc {
 	return func(f *ast.File) (*ast.File, error) {
 		if !cfg.Schema.Exists() {
@@ -15,12 +15,19 @@
 package encoding
 
 import (
+	"bytes"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/source"
 )
 
 func TestValidate(t *testing.T) {
@@ -89,3 +96,52 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestBinary(t *testing.T) {
+	for _, size := range []int{10, 2 << 20} {
+		data := bytes.Repeat([]byte("a'\\\n\x00"), size/5)
+		filename := filepath.Join(t.TempDir(), "data.bin")
+		if err := os.WriteFile(filename, data, 0o666); err != nil {
+			t.Fatal(err)
+		}
+
+		mappings := &source.Mappings{}
+		d := NewDecoder(&build.File{
+			Filename: filename,
+			Encoding: build.Binary,
+		}, &Config{Mappings: mappings})
+		if err := d.Err(); err != nil {
+			t.Fatal(err)
+		}
+		f := d.File()
+
+		lit := f.Decls[0].(*ast.EmbedDecl).Expr.(*ast.BasicLit)
+		if large := size >= 1<<20; large != (lit.Raw != nil) || large == (lit.Value != "") {
+			t.Errorf("%d: got Raw set %v and Value set %v", size, lit.Raw != nil, lit.Value != "")
+		}
+
+		got, err := cuecontext.New().BuildFile(f).Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%d: value does not match file contents", size)
+		}
+
+		b, err := format.Node(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = cuecontext.New().CompileBytes(b).Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%d: formatted value does not match file contents", size)
+		}
+
+		if err := mappings.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -45,9 +45,16 @@ import (
 //	Field         must be regular; label must be a BasicLit or Ident
 //	CommentGroup
 //
-// TODO: support anchors through Ident.
+// Anchors and aliases are restored from @yaml attributes, as recorded when
+// decoding YAML with anchors enabled. A field attribute @yaml(anchor=name)
+// or a declaration attribute of the same form in a struct marks a value as
+// an anchor. A value marked with @yaml(alias=name) is encoded as an alias if
+// it is still equal to the value of the anchor preceding it; otherwise its
+// value is encoded in full. A declaration attribute @yaml(merge=name) in a
+// struct is encoded as a merge key referring to the anchored mapping, with
+// the fields that are equal to those of the merged mapping omitted.
 func Encode(n ast.Node) (b []byte, err error) {
-	y, err := encode(n)
+	y, err := newEncoder().encode(n)
 	if err != nil {
 		return nil, err
 	}
@@ -61,27 +68,44 @@ func Encode(n ast.Node) (b []byte, err error) {
 	return w.Bytes(), nil
 }
 
-func encode(n ast.Node) (y *yaml.Node, err error) {
+type encoder struct {
+	// anchors maps anchor names to the most recently encoded node defining
+	// them.
+	anchors map[string]*yaml.Node
+
+	// expanded maps a mapping node using merge keys to the mapping with the
+	// merged fields expanded.
+	expanded map[*yaml.Node]*yaml.Node
+}
+
+func newEncoder() *encoder {
+	return &encoder{
+		anchors:  map[string]*yaml.Node{},
+		expanded: map[*yaml.Node]*yaml.Node{},
+	}
+}
+
+func (e *encoder) encode(n ast.Node) (y *yaml.Node, err error) {
 	switch x := n.(type) {
 	case *ast.BasicLit:
 		y, err = encodeScalar(x)
 
 	case *ast.ListLit:
-		y, err = encodeExprs(x.Elts)
+		y, err = e.encodeExprs(x.Elts)
 		line := x.Lbrack.Line()
 		if err == nil && line > 0 && line == x.Rbrack.Line() {
 			y.Style = yaml.FlowStyle
 		}
 
 	case *ast.StructLit:
-		y, err = encodeDecls(x.Elts)
+		y, err = e.encodeDecls(x.Elts)
 		line := x.Lbrace.Line()
 		if err == nil && line > 0 && line == x.Rbrace.Line() {
 			y.Style = yaml.FlowStyle
 		}
 
 	case *ast.File:
-		y, err = encodeDecls(x.Decls)
+		y, err = e.encodeDecls(x.Decls)
 
 	case *ast.UnaryExpr:
 		b, ok := x.X.(*ast.BasicLit)
@@ -218,15 +242,15 @@ func setNum(n *yaml.Node, s string, x interface{}) error {
 	return nil
 }
 
-func encodeExprs(exprs []ast.Expr) (n *yaml.Node, err error) {
+func (e *encoder) encodeExprs(exprs []ast.Expr) (n *yaml.Node, err error) {
 	n = &yaml.Node{Kind: yaml.SequenceNode}
 
 	for _, elem := range exprs {
-		e, err := encode(elem)
+		y, err := e.encode(elem)
 		if err != nil {
 			return nil, err
 		}
-		n.Content = append(n.Content, e)
+		n.Content = append(n.Content, y)
 	}
 	return n, nil
 }
@@ -235,11 +259,12 @@ func encodeExprs(exprs []ast.Expr) (n *yaml.Node, err error) {
 // an embedded value, it will return this expression. This is more relaxed for
 // structs than is currently allowed for CUE, but the expectation is that this
 // will be allowed at some point. The input would still be illegal CUE.
-func encodeDecls(decls []ast.Decl) (n *yaml.Node, err error) {
+func (e *encoder) encodeDecls(decls []ast.Decl) (n *yaml.Node, err error) {
 	n = &yaml.Node{Kind: yaml.MappingNode}
 
 	docForNext := strings.Builder{}
 	var lastHead, lastFoot *yaml.Node
+	var attrs []*ast.Attribute
 	hasEmbed := false
 	for _, d := range decls {
 		switch x := d.(type) {
@@ -258,6 +283,7 @@ func encodeDecls(decls []ast.Decl) (n *yaml.Node, err error) {
 			continue
 
 		case *ast.Attribute:
+			attrs = append(attrs, x)
 			continue
 
 		case *ast.Field:
@@ -282,10 +308,11 @@ func encodeDecls(decls []ast.Decl) (n *yaml.Node, err error) {
 				label.Style = yaml.DoubleQuotedStyle
 			}
 
-			value, err := encode(x.Value)
+			value, err := e.encode(x.Value)
 			if err != nil {
 				return nil, err
 			}
+			value = e.applyAttrs(x.Attrs, value)
 			lastHead = label
 			lastFoot = value
 			addDocs(x, label, value)
@@ -297,14 +324,14 @@ func encodeDecls(decls []ast.Decl) (n *yaml.Node, err error) {
 				return nil, errors.Newf(x.Pos(), "yaml: multiple embedded values")
 			}
 			hasEmbed = true
-			e, err := encode(x.Expr)
+			y, err := e.encode(x.Expr)
 			if err != nil {
 				return nil, err
 			}
-			addDocs(x, e, e)
-			lastHead = e
-			lastFoot = e
-			n.Content = append(n.Content, e)
+			addDocs(x, y, y)
+			lastHead = y
+			lastFoot = y
+			n.Content = append(n.Content, y)
 		}
 		if docForNext.Len() > 0 {
 			docForNext.WriteString(lastHead.HeadComment)
@@ -325,7 +352,162 @@ func encodeDecls(decls []ast.Decl) (n *yaml.Node, err error) {
 		return n.Content[0], nil
 	}
 
-	return n, nil
+	n = e.merge(attrs, n)
+	return e.applyAttrs(attrs, n), nil
+}
+
+// yamlAttrs returns the key-value pairs of the @yaml attributes in attrs with
+// the given key.
+func yamlAttrs(attrs []*ast.Attribute, key string) (values []string) {
+	for _, a := range attrs {
+		k, body := a.Split()
+		if k != "yaml" {
+			continue
+		}
+		attr := internal.ParseAttrBody(a.Pos(), body)
+		if attr.Err != nil {
+			continue
+		}
+		for _, kv := range attr.Fields {
+			if kv.Key() == key {
+				values = append(values, kv.Value())
+			}
+		}
+	}
+	return values
+}
+
+// applyAttrs marks n as an anchor or replaces it with an alias according to
+// the @yaml attributes in attrs.
+func (e *encoder) applyAttrs(attrs []*ast.Attribute, n *yaml.Node) *yaml.Node {
+	for _, name := range yamlAttrs(attrs, "alias") {
+		a, ok := e.anchors[name]
+		if !ok || !e.equal(a, n) {
+			continue
+		}
+		return &yaml.Node{
+			Kind:        yaml.AliasNode,
+			Value:       name,
+			Alias:       a,
+			HeadComment: n.HeadComment,
+			LineComment: n.LineComment,
+			FootComment: n.FootComment,
+		}
+	}
+	for _, name := range yamlAttrs(attrs, "anchor") {
+		n.Anchor = name
+		e.anchors[name] = n
+	}
+	return n
+}
+
+// merge replaces the fields of the mapping n that are equal to those of the
+// anchored mappings named by @yaml(merge=name) attributes with a merge key.
+// It returns n unchanged if the merged mappings are not known or if not all
+// of their fields are defined in n.
+func (e *encoder) merge(attrs []*ast.Attribute, n *yaml.Node) *yaml.Node {
+	names := yamlAttrs(attrs, "merge")
+	if len(names) == 0 {
+		return n
+	}
+	merged := map[string]*yaml.Node{}
+	var aliases []*yaml.Node
+	for _, name := range names {
+		a, ok := e.anchors[name]
+		if !ok {
+			return n
+		}
+		m := e.expand(a)
+		if m.Kind != yaml.MappingNode {
+			return n
+		}
+		// Earlier mappings take precedence.
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			if _, ok := merged[m.Content[i].Value]; !ok {
+				merged[m.Content[i].Value] = m.Content[i+1]
+			}
+		}
+		aliases = append(aliases, &yaml.Node{
+			Kind:  yaml.AliasNode,
+			Value: name,
+			Alias: a,
+		})
+	}
+
+	var content []*yaml.Node
+	found := 0
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if m, ok := merged[key.Value]; ok {
+			found++
+			if e.equal(m, value) && !hasAnchor(value) && key.HeadComment == "" {
+				continue
+			}
+		}
+		content = append(content, key, value)
+	}
+	if found != len(merged) {
+		return n
+	}
+
+	value := aliases[0]
+	if len(aliases) > 1 {
+		value = &yaml.Node{
+			Kind:    yaml.SequenceNode,
+			Style:   yaml.FlowStyle,
+			Content: aliases,
+		}
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Value: "<<"}
+
+	m := *n
+	m.Content = append([]*yaml.Node{key, value}, content...)
+	e.expanded[&m] = n
+	return &m
+}
+
+// expand returns the node defining the value of n, resolving aliases and
+// expanding merge keys.
+func (e *encoder) expand(n *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case n.Kind == yaml.AliasNode:
+			n = n.Alias
+		case e.expanded[n] != nil:
+			n = e.expanded[n]
+		default:
+			return n
+		}
+	}
+}
+
+// equal reports whether a and b represent the same value. Comments, styles,
+// and anchors are not considered.
+func (e *encoder) equal(a, b *yaml.Node) bool {
+	a, b = e.expand(a), e.expand(b)
+	if a.Kind != b.Kind || a.Tag != b.Tag || a.Value != b.Value ||
+		len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i, x := range a.Content {
+		if !e.equal(x, b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAnchor reports whether n or any of its descendants defines an anchor.
+func hasAnchor(n *yaml.Node) bool {
+	if n.Anchor != "" {
+		return true
+	}
+	for _, c := range n.Content {
+		if hasAnchor(c) {
+			return true
+		}
+	}
+	return false
 }
 
 // addDocs prefixes head, replaces line and appends foot comments.
@@ -244,6 +244,82 @@ route:
   receiver: pager
   group_by: [alertname, cluster]
 		`,
+	}, {
+		name: "anchors",
+		in: `
+		a: {
+			x: 1
+		} @yaml(anchor=a)
+		b: {
+			x: 1
+		} @yaml(alias=a)
+		c: {
+			x: 2
+		} @yaml(alias=a)
+		d: [{
+			@yaml(anchor=e)
+			v: 1
+		}, {
+			@yaml(alias=e)
+			v: 1
+		}]
+		e: 1 @yaml(alias=unknown)
+		`,
+		out: `
+a: &a
+  x: 1
+b: *a
+c:
+  x: 2
+d:
+  - &e
+    v: 1
+  - *e
+e: 1
+		`,
+	}, {
+		name: "merge",
+		in: `
+		a: {
+			x: 1
+			v: 2
+		} @yaml(anchor=a)
+		b: {
+			z: 3
+		} @yaml(anchor=b)
+		c: {
+			@yaml(merge=a)
+			x: 1
+			v: 3
+			w: 4
+		}
+		d: {
+			@yaml(merge=a)
+			@yaml(merge=b)
+			x: 1
+			v: 2
+			z: 3
+		}
+		e: {
+			@yaml(merge=a)
+			x: 1
+		}
+		`,
+		out: `
+a: &a
+  x: 1
+  v: 2
+b: &b
+  z: 3
+c:
+  <<: *a
+  v: 3
+  w: 4
+d:
+  <<: [*a, *b]
+e:
+  x: 1
+		`,
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -310,7 +386,7 @@ true
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			n, err := encode(tc.in)
+			n, err := newEncoder().encode(tc.in)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -305,11 +305,21 @@ func parseType(s string, mode Mode) (inst, val cue.Value, err error) {
 }
 
 // fileExt is like filepath.Ext except we don't treat file names starting with "." as having an extension
-// unless there's also another . in the name.
+// unless there's also another . in the name or the name is listed in dotFiles.
 func fileExt(f string) string {
 	e := filepath.Ext(f)
-	if e == "" || e == filepath.Base(f) {
+	if e == "" {
+		return ""
+	}
+	if e == filepath.Base(f) && !dotFiles[e] {
 		return ""
 	}
 	return e
 }
+
+// dotFiles holds the names of files starting with "." that are treated as
+// if the entire name were their extension, as they are conventionally named
+// after their file type only.
+var dotFiles = map[string]bool{
+	".env": true,
+}
@@ -147,14 +147,24 @@ extensions: {
 	".ndjson":    tags.jsonl
 	".yaml":      tags.yaml
 	".yml":       tags.yaml
+	".toml":      tags.toml
+	".xml":       tags.xml
+	".hcl":       tags.hcl
+	".tf":        tags.hcl
+	".ini":       tags.ini
+	".env":       tags.dotenv
 	".txt":       tags.text
 	".go":        tags.go
 	".proto":     tags.proto
 	".textproto": tags.textproto
 	".textpb":    tags.textproto // perhaps also pbtxt
+	".avsc":      tags.avro
+	".graphql":   tags.graphql
+	".graphqls":  tags.graphql
+	".gql":       tags.graphql
+	".binpb":     tags.binpb
 
 	// TODO: jsonseq,
-	// ".pb":        tags.binpb // binarypb
 }
 
 // A Encoding indicates a file format for representing a program.
@@ -183,10 +193,18 @@ tags: {
 
 	json: encoding:      "json"
 	jsonl: encoding:     "jsonl"
+	ndjson: encoding:    "jsonl"
+	ldjson: encoding:    "jsonl"
 	yaml: encoding:      "yaml"
+	toml: encoding:      "toml"
+	xml: encoding:       "xml"
+	hcl: encoding:       "hcl"
+	ini: encoding:       "ini"
+	dotenv: encoding:    "dotenv"
 	proto: encoding:     "proto"
 	textproto: encoding: "textproto"
-	// "binpb":  encodings.binproto
+	graphql: encoding:   "graphql"
+	binpb: encoding:     "binarypb"
 
 	// pb is used either to indicate binary encoding, or to indicate
 	pb: *{
@@ -228,6 +246,14 @@ tags: {
 		interpretation: "openapi"
 		encoding:       *"json" | _
 	}
+	avro: {
+		interpretation: "avro"
+		encoding:       *"json" | _
+	}
+	tfschema: {
+		interpretation: "tfschema"
+		encoding:       *"json" | _
+	}
 }
 
 // forms defines schema for all forms. It does not include the form ID.
@@ -324,11 +350,36 @@ encodings: toml: {
 	stream: false
 }
 
+encodings: xml: {
+	forms.data
+	stream: false
+}
+
+encodings: hcl: {
+	forms.data
+	stream: false
+}
+
+encodings: ini: {
+	forms.data
+	stream: false
+}
+
+encodings: dotenv: {
+	forms.data
+	stream: false
+}
+
 encodings: proto: {
 	forms.schema
 	encoding: "proto"
 }
 
+encodings: graphql: {
+	forms.schema
+	encoding: "graphql"
+}
+
 encodings: textproto: {
 	forms.data
 	encoding: "textproto"
@@ -369,6 +420,16 @@ interpretations: openapi: {
 	encoding: *"json" | _
 }
 
+interpretations: avro: {
+	forms.schema
+	encoding: *"json" | _
+}
+
+interpretations: tfschema: {
+	forms.schema
+	encoding: *"json" | _
+}
+
 interpretations: pb: {
 	forms.data
 	stream: true
@@ -10,6 +10,13 @@ import (
 	_ "cuelang.org/go/pkg"
 )
 
+var cuegenvalFileInfo = cuegenMake("#FileInfo", &FileInfo{})
+
+// Validate validates x.
+func (x *FileInfo) Validate() error {
+	return cuegenCodec.Validate(cuegenvalFileInfo, x)
+}
+
 var cuegenCodec, cuegenInstance_, cuegenValue = func() (*gocodec.Codec, *cue.Instance, cue.Value) {
 	var r *cue.Runtime
 	r = &cue.Runtime{}
@@ -44,5 +51,5 @@ func cuegenMake(name string, x interface{}) cue.Value {
 	return v
 }
 
-// Data size: 1706 bytes.
-var cuegenInstanceData = []byte("\x01\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xc4X\xddo\xe4\xb6\x11\x97|W\xa0R\xd3>\xe6\xad\xc0\x9c\xae\bR\xe3*#\x1f\xe8\xc3\x02\u01a1\xe8\xdd\x15\xf7\xd2\x14E\xfa\x14\x04\v\xae4\xdae#\x91*I%6\xe2E\xdb4\xed\x9f\x1d\x17CR\xa2\xa8\x95\xed3\x90\xa2~\xf1\xee\xfc8\u00d9\xe1|\xee\xcfo\xff}\x96\x9e\xdd\xfe'Io\xff\x91$\xbf\xfd\xfb\x934}\x8f\vm\x98\xa8\xf0\x153\x8c\xc8\xe9\x93\xf4\u97e54\xe9Y\x92>\xfd\x133\x87\xf4\xbd$\xfd\xc9\x1b\u07a2No\xbfO\x92\u45f7\xff:K\xd3_|\xf1e5`\xd9\xf0\xd6s~\x9f\xa4\xb7\xdf%\u0247\xb7\xff|\x92\xa6?\r\xf4\xef\x92\xf4,}\xfaG\xd6!\tzj\x89y\x92$?\xbc\xff+R$M\xcf\xd243\xd7=\xea\xb2\x1a0\xfd\xe1\xfd\x9f\xf5\xac\xfa\x8a\xed\x11v\x03o\xeb<\xbf\xb8\x80\xdf\x01\xdd\x0f\x95T\nu/E\xad\xc1H`\xf0\a\xe9\x0e\x95\x04\x97\xf9s\xfa\xb7\x81o\xf3\x8c\xae\x17\xac\xc3\r\xf8?m\x14\x17\xfb<CQ\u025a\x8b\xfd\x04<\x7f\xed)y\u0185A\xd5+4\xccp)^n\xe0\xf9\u06c8\x92g\x8dT\xdd\u02c9\x95\xb8\xdfH\xd5\xe5\x99a{\xfd\xd2^\x9c}\xe1n\xfar3]y\u030f\u0588W\u0630\xa15\xc05\x98\x03\x02\xa9\b\x83\xc6\x1a\x1a\xa9@\x9b\x9a\v`\xa2\xa6Or0%|~@\xd0h\f\x17{\r5\xf6(j\x92\"E\xe0\xeedMV{\xc1\x1b\xb0\xf6\xc3\a\xb1\x03\u038b\xdf\x14p3js\x9c\xf9\xf3\xadh$\xd4\xd8p\x81\x1a\x0e\xf2\x1b`N,\xd7`\u0744\xb5Uhr\v\xd6\xde\xc5\xc4h\xad\xb5\xdf\xf2\xacf\x86\x05\xaf\x9c\x1b5 \xdc@\xc3Z\x8dy\xa6\xb0A\x85\xa2B\xbd9\x05\xab\xeb\xaau\xc0\n\xa7U\x8d\x93\xe7\xe9\xc4N\xca6\xcfdO\xdfY\xebX\x1c\xad\x92B\x1b\u01780\xe1\xdcW\x88\xbd\xf7\x8b\xdex\x1a\x17\x95\xec\xfa\x16\x8d\r\vO\xebz\xa9\u0328\x81\xa3i\xa3\x90u\xa3R\x8eV\xcbJ\a\x13\x1d\x8d\x19\xa3\xf8n0\xce\x00Ks\xee\xa5w\xd1\xf4x\xf4pN\a\xfb\xc85o\xac/\f\xc8\x1e\x15s\x96\xb8\xd3e~qA\xac\x9f\x1fP#\x18\xec\xfa\x96\x19\xd4\xc0\x14\xda\a\x10\xf4\x1aF\xc2\x0ea\x10\xbc\xe1H\xef\x02\xcc\xd8`PR\x1a\x90\r\x98\x03\xd7$\xa4\x92\xa2\xe1\xfb\xc1\xddP\xe6\xf6\x02\xfb^\\\xf4\x83qq\u06a2\x81+\xb8\xb4\x9f#\xeb\x16\x8f\x90Ef.\xc1c\x9ee!\xfe\xac\xac\x90a\xe7E5 \xc5\u0796\xe8eY\x8e\f!\x86\xae\xf2\xc0\xa0\xbd\x80j\xa0\xa8\xa5T\u04e5\xae\x0e\xd81/\x82x\xf1\u02a0\xd0.$\xec\xe9\xa2\xfc\xab\x96\xa2\xf0\xdf\x169L:\xb0\xc1\xc8I\x89\xa3c\xb9f]\xfbX\x96\xc7q\x1c)\xef3\xbc\xa2\xe8\x9a9|\xfb\u045a\u02fdS\xcfW]\xbe\x04\x1fp\xb9\xf5\xc6\xfd>\xdf~\xf4\x80\xd7)\x9f\x83\u03cfy&\x87\xdeD\x81\xb3\xfd\xf8\u01f1c\xae\xd5\u01cf\xd5\n\xbf\xa6:\x10t\xfa\xe4\x7f\xed\u06c7\xc3y\xfb\xc9\x03F4\x9cR~nE\x8d\xcd\u0708O\xff\xff9\xb9\xfd\xf4\x91Y9v\xb8\xd7crB\xc7z\xed\x9aIHX*_\xbe\x1c:\xa8WT\x06\r\xa7\xea\xb7\xc8\ub898w\xd9m\x9e\x154\x1cLD\xea\xb7D\xc8C\xfa\a:\x11F\xa0\xf5\xc8\x04\xb4\x84\xb4u`\x8a\x11q'\xe2KF\x90F\x84|*\f+\x80\xb921`\xf0\xca\x10\xb0\x97\xc1:\v\xec%\x91{%\x8d\x9c\xebk\tV\x12^\x99\x11\x9d$\xc5\xe8n\xa6s@\xf3\x8cZ\xcag\xaf>\xdb\x00\x19\xa2\xf1o/,\xa9(G\x86\x89i\xc7E\xbf\x83\x8b\v\xd8q\xc1\xd4u\xbf\x9bF\x85q@\x02.j^\xb9\xae\xe4\x1e\x90\xa2\x81\x19\xdb\xda\x14\xf6\n5\n\x1aW\x80\xd1\xd3\xee\x15\xeb\xca|\x1a\xaf6\xf0\xec\xb2(\x9cH\x01\xf1`\x055\x1aT\xddl\x0e\xa9P\x19\xc6\xc5(\a\xf4A\x0emM\xdd/\x9aF..\xe0\x8dT0\x8e\xb0/\xc0\u0588\x8e]/N\x02\xa3N\xac+\xc5wN?\x17\xc1/\xe0\x9b\x03\xaf\x0e\xc0\x8d\u01b6\xb1\x9d\x93\tb\xad\xa4\xf8\x1a\x95q-\x97\xc1\xef\xff\xf2\xdas\x94\xf9b&\x9c\xc6<;\t\u0383\xd6\xd3\x1b;\x92F#\xe38z-\x06\xb5\xa2\x91\xd2E\xb3\x1b4\x1dW\xe1..\xfcs\xd0[\xb9\xec\xaad\xd7\xd1x\xd6r\x81\x8el\xe4i^\x11`3\u0289q\xc9\xec\xa4O\x92)\x85\xf7\x8a\xf5\x87\b\xb5\x14\a\xd6l\x1fA5\u06cf\x80a\v\xc4x\x81\xb6^|\x9b\xcfk\x8f-=\x16$+OPo\xba\x87\xdbU\xbcu\a(\xc5Np\x9b\xa1\x16\xb6\xc1\x7f\x82\xbb\f\xb2\a\xa6\f99\x14R\x8d\x0e\xdad\xe9w4\x11\xdbI\x1d\xb99\xa0\"G\x8f\xb9\xe0\xd3\x05F\x11/@Fx\x9e\xf5\xbb\r\x9c\u01f7\xb8\xbfb\u0334\"?\x1d)\n\xba\x1fn`\x8d\xf1\xd9\xe5\xfd\xac\x96\xec\xad\\5\xb0\x98\x1e\xcc\xea\x11\x1e\u0349=\xe1q\xe4;\xb9\xf6'n\xf4\x06\xd2\x0eq\x97q\xd9\x14\x99Y\xd62{\u035e\x9c\xee\xbb\"\xb1\xfe(R\xc7-\xcc\u02e5A\xcd\xe1'\xecv\x86[\xb90\x9a\xa9|t\u03b3\xe9DP8\xf0.\xe2d\x8f\x82\xf5\xfc\x0eY\x1e}\aA\xae>\xd8\x06=-u\xbeQS\x81fm\xeb\xc0\x12\xde\x1a\xa8%j\x10\xd2\x00\x17U;\xd4\xe8vJ\xa9:x\xfb\xaa\xcc\xed9\xab\x90\xddhiw\xbf\x9c\xd6\u06a9~Y\xed\xa9Qo\u05ea\vLZzW\xc0\r\x14v\xfa\xb1\x9f\xc6\xea\xb2X\xb6\x96\x03Y\xbc\xb2-'\x9dxA\\\xa2\xf1\xaa\xf8a\x04\xff\x1a>XR\xf2l\xb1H.\xe5\xc5+\xe5\x12\x8d\x17\xc9\x05z\xa4:/\xc6iu>D\x9d\xf8\xcb\xfb\xe8\xe4\xbeu\xab\x82\xfc\x93\x02\x1e\x1e\xc0\xf9\x9a\xbcN\x85\xdb\xfd\xb7\xb9\xbbX\xdcI\xe7\x13\x9f\xaf\xfb\xfa^m\x16~\\\xf7\u07fa\u07c2=Q\xcf\u0465\xb5af\u06f3\xcb\x10B\xe3\x8f\bs\xe6y_\xa2\xd5a\xbf\xf4\u02f3K\xdf\xc6bmG\xb5\xa2_-&\xbb\xe6\xbfV\xac\x1a\xb0\xea\x97I\xafc\x1eO\xd5S\x8f\x1c\x93 X\x10:dX~\x16\xd9\xe2\x92\x04n\xc6w\x9b/\f\xa3\x1e\xf3=!\b\x0f\xed3vn\xa4\x06\xa5\xa1\x93\x1cw\xe4U}\xa6\x83\xa1\u7b1e\v:\xcc[\xcd\x03G\x8d\xec\xee\xbb;\x1c\x9c\xb5\xf4E\x8e\xbd\xd3\x18\x10I\xbfc&\x98\xbd\xc0\xd2\x16j\xf4\xf7\x89\x99\xf7\xec5)\xa1\xe5-\x94?1\xf4\x98\xc7}\xe2\x11\xb5\xda.S\xae\t\u01b7,\xbb\u069d\x0e\xbc\xb7\x7f\xbd3\u05ea\xb3\x96\xd1t\u0313\xe4\xbf\x01\x00\x00\xff\xff\x9d6Q(\xb3\x16\x00\x00")
+// Data size: 1880 bytes.
+var cuegenInstanceData = []byte("\x01\x1f\x8b\b\x00\x00\x00\x00\x00\x00\xff\xc4X_o\xe4\xb6\x11_\xf9\xae@\xa5\xa6}o\x81\x02s{@\x90\x1a\xd75\xf2\a}X\xc08\x14\xbd\xbb\xe2^\x92\xa2H\x9f\x82\xc0\xe0J\xa3]6\x12\xa9#)g\x8dx\xd16M\xfb\x81\xfb\x01\xe2bHI\x14)\xad\xd7\x06\xae\xa8_l\xcfof83\xe4\f\x7f\xe2\xcf\xef\xfeu\x96\x9c\xdd\xfd{\x91\xdc\xfd}\xb1\xf8\xdd\u07de$\xc9\a\\h\xc3D\x8e\xaf\x98a$N\x9e$O\xff,\xa5I\xce\x16\xc9\xd3?1\xb3K>X$?y\xc3+\xd4\xc9\xdd\x0f\x8b\xc5\xe2\xd7w\xff<K\x92_|\xf5u\xde\xe2\xaa\xe4Ug\xf9\xc3\"\xb9\xfb~\xb1\xf8\xe8\xee\x1fO\x92\xe4\xa7^\xfe\xfd\"9K\x9e~\xcej$GO\xad0[,\x16?\xfe\xeag\x14H\x92\x9c%Ijn\x1a\u052b\xbc\xc5\xe4\xc7_\xfe\xa7a\xf97l\x8b\xb0iyUd\xd9\xc5\x05\xfc\x1eh}\u0225R\xa8\x1b)\n\rF\x02\x83?J\xa7\xb4\"x\x95=\xa7_k\xf8.Kiy\xc1j\\C\xf7\xa3\x8d\xe2b\x9b\xa5(rYp\xb1\x1d\x80\xe7\xaf;I\x96raP5\n\r3\\\x8a\x97kx\xfe6\x90di)U\xfdr0%\xeb7R\xd5Yj\xd8V\xbf\xb4\v\xa7_\xb9\x95\xbe^\x0fK\x1e\xb2\x83M\xe2\x15\x96\xac\xad\fp\rf\x87@!B\xab\xb1\x80R*\u0426\xe0\x02\x98(\xe8/\u065a\x15|\xb9C\xd0h\f\x17[\r\x056(\n\xf2\"\x85\xb7\xaeeAYw\x8e\xd7`\xf3\x87\x0f\xc3\x02\x9c/\x7f\xbb\x84\xdb>\x9a\u00e8\x9eoE)\xa1\xc0\x92\v\u0530\x93\xdf\x02sn\xb9\x06[&,l@CY\xb0\xe8JL\x866[\xfb_\x96\x16\xcc0_\x95s\xa3Z\x84[(Y\xa51K\x15\x96\xa8P\xe4\xa8\xd7S0\xbf\xc9+\a\xccX\xda\xd08U\x9e46RVY*\x1b\xfa\x9fU\xce\xc4\xc9r)\xb4Q\x8c\v\xe3\xf5\xbeAl\xba\xba\xe8u'\xe3\"\x97uS\xa1\xb1\u01e2\x93\u054dT\xa6\x8f\xc0\u0274Q\xc8\xea>('+d\xae}\x8aN\u018cQ|\xd3\x1a\x97\x80\x95\xb9\xf2\u04beh\xda<\xda8\x17\x83\xdd\u4097\xb6\x16\x06d\x83\x8a\xb9L\x9c\xf6*\xbb\xb8 \xd3/w\xa8\x11\f\xd6M\xc5\fj`\n\xed\x06\b\xda\r#a\x83\xd0\n^r\xa4}\x01f\xecaPR\x1a\x90%\x98\x1d\xd7\xe4$\x97\xa2\xe4\xdb\u05ad\xb0\xca\xec\x02v\xbf\xb8hZ\xe3\xcei\x85\x06\xf6pi\xff\x0e\xb2\x8b6!\r\u048c\xc1C\x96\xa6\xfe\xfcY_\xbe\xc3\u0397y\x8bt\xf6\xaeH\xbeZ\xadz\x03\x7f\x86\xf6\x997\u041d\x83\xbc\xa5SK\xad\xa6W:\xdfa\xcd:\x17d\x8b{\x83B\xbb#a\xb5\x97\xab\xbfj)\x96\xdd\x7fQ\x0fS\f\xac5r\b\xe2\xe0LnX]=\xd6\xe4q\x16\a\xea\xfb\x14\xf7t\xbaF\x05\xbf\xfax\xae\xe4]Q\xcfgK\x1e\x83'Jn\xabq\x7f\u036f>>Qu\xeag_\xf3C\x96\u02b61\xc1\xc1\xb9\xfa\xe4\xfd\xe41\x8e\xea\x93\xc7F\x85\xd74\a|L\x9f\xfe\xafk{\xfa8_}z\"\x89\x92S\u02cf\xb3(\xb0\x1c'\xf1\xd9\xff\xbf'\xaf>{dW\xf67\xdc\xeb\xbe9\xa1f\x8dv\x97\x89oX\x1a_\xdd8tP\xa3h\f\x1aN\xd3/\xea\xeb\xe5r|\xcb^e\xe9\x92\xc8\xc1 \xa4\xfb\x96\x04\x99o\x7f/'A\x0fT\x1d2\x00\x15!U\xe1\x8dBD\x1cE\xba\x91\u1f51 \x1b\x06\xc3\f`ddA\x02\x02\xf6\xb1\xc5\xde\xc9wy$\xdf\xe5\xceQ\xe9\xab1\x96s\xc1C}.8\xc9Q\\\x87\xf2B\x1a\x14\xd7\xd6\xd5\u0784\x90\xc1\xbd!`+\xa35\xb6\x92\u010d\x92F\x8ekh\x05\xd6\x13\xeeM\x8f\x0e\x9eBt3\xaac\x80\xb2k\x9d\a\x95a\xd7\xca\x02[\u015a\xdd;W\x05\x17\x85\x13\x8c0M\xe0\x04{\x17Un\x84m\xb8\xe8#q\x98\x15dYJ\xb7\xed\x17\xaf\xbeX\x03\xed\xb1\xc6w/\x06r\xd4SB\xe0\xa2\u0e7b\x87\u0751\xa5\xf3\u03cc\xbd\xcc\x156\n5\n\"h\xc0\xe80o\x15\xabW\xd9@(\xd7\xf0\xecr\xb9t.\x05\x84T\x12\n4\xa8\xea\x11\xf3\xcaQ\x19\xc6E\xef\a\xf4N\xb6UA\xf7}\xc0\xbf..\xe0\x8dT\u0413\xf6\x17`\xa7b\xcdn\"M`\xc4=t\xae\xf8\xc6\xc5\xe7z\xf6\x05|\xbb\xe3\xf9\x0e\xb8\xd1X\x95\x96+0A\xa6\xb9\x14\u05e8\x8c#\x19\f\xfe\xf0\x97\u05dd\xc5*\x8bX\xf0@l-\xf7\x1d\xb7i'/-\t\x1f\xfd\f\xb3%\xa6\xa6\xcbRJ\u05ff\x8eZ;\xab\xa5[x\xd9m\a\ud65b'\xb9\xack\"\xa4\x15\x17\xe8\xc4FN'\t\x01v\x8687n|9\xef\x83g\x1aZ\xf6\x88\x04\xa8\x958\xb0`\xdb\x00*\u0636\a\f\x8b\x10\xd39\xb4\x13\xf2\xbbl<m\xed\xb0\xb5 e9A\xbb\xd4;\xb8\x9a\xc5+\xa7\xe0\x06\xd3}\x1a\xd5I\r\x1aL\x13\xdc\xce5\v\xd3x\x9a\xc0v\x88Yx?\x83\xee{p\x97OA\x1ag\x16\xe4\x82O@\x9a]\xae\xa0v6Mp'v*vnL4\xdc\xf0q\x91\xf7\xc3e\x1a\xfe0\xa5\xfc\x9e\xbf\x9b\x86\xda\xcf\x1d\xabd\xe7\xc3De\xc3\x05S7\xcd\xc6\xea\xd8\xe1\xd1l\xe8K\xc9~\xc1!7;Tt\x1c\xfb\x89\x01N\x1fz\x0f/@\x06x\x96\xd2\x1a\xe7\xe1\"\xeeg\xbc\u0504j.i}\xb8\x859\xc3g\x97\xf7\x9bv\xc1\xdbr\xcdVj9\x1ck\x1b\x87?\xda\xce\ud45a\x1c\xb5\xdaN\xf6\xa3K\x90\xbe-\x8f%\x97\x0e\xfd\x9b\xa6\x15s\xbbC\xbb\u05f1%2}/^\xfb\xaf\xf3\xce/\x11x\x87O\xcc-\xb7\x9fY0\xe0\xda]\x0f\x8fg\xce\u0111Wx\x88;\u0660`\r?\xe2\xabC\x1f\xe2\x88.\xd7c\xa9]\xab\a\xa5f\xca{\x13\xeb\xe1\a\xb8r3\xdd\xd2\xc8\xe1\u9863\x93t\xa9\xb2\xaar\xe0\n\xde\x1a($j\x10\xd2\x00\x17y\xd5\x16\xe8^>\xa4\xaa\xe1\xed\xabUf\xf5lD\xf6\xdd\xe5sV\xe3\xe5\xf0\xf82\xdc96~\xa2\x93Ws7\x02\fQv\x19\xc0-,-G\xb7\x7f\xf57B\xf4$\x10\x7f6\x84\x0f\v1\x1f\x0f\x9f1b4|\xd0\xf8(\x80\x7f\x03\x1f\u0192,\x8d\x9e;b\x7f\xe1\xc3G\x8c\x86\xcf\x1d\x11z\xa0\xbbY\xf4\xdfTc\xaa?\xa9WW\xa3\xc9z\xf3Yy\xff\x93K\xd7o\x80\xab5U\x9d.[\xf7\xdbN\x92\xe8y\x89b\x9e\xd4|\xbe\xd6\xf7F\x13\xd5q\xbe~\xf3u\xf3\xf9\x04<\xa1\xa3\x9d\xa3\u071e]\xfa#\xd4?u\x8d\x8d\xc7\\\x82>p\xb7q]\x9e]v\xd4#\x8c\xb6\x0f+x[\x1b\xf2\x1a\xbf\xa9\xcd&0[\x97!\xaeC\x16~\xfb\r\xbc\xa6o\x02\x9f\x81g\x1c\xfe\x13=\xea\x16\xd7$p\xdb\xef\xdb\xf8\xb3\xb6\x8fc\xfc5\xeb\x9d{\xba\x12\x167\b\x83\xda\xd0y\x0eY\xd4l<\x83\xa2\xbf\x01g\xf5|\f\xe3\x8b\uf12a\xa7O'\x14\xf7\x0f\xd4\x1b8\xd5\t\xbd\x81^\x9d\xd0\x1b3\xad\x13\xaa#2\x15\x8d\x81y\x02\x16\xf0\xaa\xe3\x16\x01\u034aH[\x10\xd1\x11\x067:X\xf1\x16\xf5\x8c\ud61b11\x9a\xf3\xe2yE\x14\xfe\xa48\x87,\xbc\xff\x1eq\x05\u0657\f\xc74\xc2Ub\xeap\xb4\x84\xf7\x92\x84\a[yF\xf0`\x93\x90\x01<\xd8lv[\xe2v<d\x8b\xc5\x7f\x03\x00\x00\xff\xff\x7f\xe2.\xbc\x9a\x1a\x00\x00")
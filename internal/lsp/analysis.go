@@ -0,0 +1,390 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/token"
+)
+
+// nodesAt returns the nodes of f that enclose the given offset, outermost
+// first.
+func nodesAt(f *ast.File, off int) []ast.Node {
+	var stack []ast.Node
+	ast.Walk(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.File:
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		default:
+			start, end := n.Pos(), n.End()
+			if !start.IsValid() || start.Offset() > off || end.Offset() < off {
+				return false
+			}
+		}
+		stack = append(stack, n)
+		return true
+	}, nil)
+	return stack
+}
+
+// innermost returns the innermost node of f enclosing off, other than f
+// itself, or nil if there is none.
+func innermost(f *ast.File, off int) ast.Node {
+	stack := nodesAt(f, off)
+	if len(stack) < 2 {
+		return nil
+	}
+	return stack[len(stack)-1]
+}
+
+// identAt returns the identifier at off and the nodes enclosing it, or nil
+// if there is no identifier at off.
+func identAt(f *ast.File, off int) (*ast.Ident, []ast.Node) {
+	stack := nodesAt(f, off)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if x, ok := stack[i].(*ast.Ident); ok {
+			return x, stack[:i]
+		}
+	}
+	return nil, nil
+}
+
+// ancestors returns the nodes of f enclosing n, outermost first, or nil if
+// n is not in f.
+func ancestors(f *ast.File, n ast.Node) []ast.Node {
+	var stack, found []ast.Node
+	ast.Walk(f, func(x ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		if x == n {
+			found = append([]ast.Node{}, stack...)
+			return false
+		}
+		stack = append(stack, x)
+		return true
+	}, func(x ast.Node) {
+		if len(stack) > 0 && stack[len(stack)-1] == x {
+			stack = stack[:len(stack)-1]
+		}
+	})
+	return found
+}
+
+// A decl is a declaration of an identifier.
+type decl struct {
+	file string
+
+	// node is the declaring node: an *ast.Field, *ast.LetClause,
+	// *ast.Alias, *ast.ImportSpec, or an *ast.Ident declared by a
+	// comprehension clause.
+	node ast.Node
+
+	// name is the node holding the declared name. It is nil for imports
+	// without an explicit name.
+	name ast.Node
+}
+
+// fieldName returns the label holding the name of a field.
+func fieldName(f *ast.Field) ast.Label {
+	if a, ok := f.Label.(*ast.Alias); ok {
+		l, _ := a.Expr.(ast.Label)
+		return l
+	}
+	return f.Label
+}
+
+// labelOf returns the field of which x is the label, if any, where stack
+// holds the nodes enclosing x.
+func labelOf(x *ast.Ident, stack []ast.Node) *ast.Field {
+	if len(stack) == 0 {
+		return nil
+	}
+	parent := stack[len(stack)-1]
+	if _, ok := parent.(*ast.Alias); ok && len(stack) > 1 {
+		// The label of a field with an aliased label, as in X=foo: 1.
+		parent = stack[len(stack)-2]
+	}
+	f, ok := parent.(*ast.Field)
+	if !ok || fieldName(f) != ast.Label(x) {
+		return nil
+	}
+	return f
+}
+
+// declaration returns the declaration that x, in the given file, refers
+// to, if any.
+func declaration(snap *snapshot, filename string, x *ast.Ident) *decl {
+	switch n := x.Node.(type) {
+	case nil:
+		return nil
+	case *ast.ImportSpec:
+		d := &decl{file: filename, node: n}
+		if n.Name != nil {
+			d.name = n.Name
+		}
+		return d
+	case *ast.LetClause:
+		return &decl{file: filename, node: n, name: n.Ident}
+	case *ast.Alias:
+		return &decl{file: filename, node: n, name: n.Ident}
+	case *ast.Ident:
+		return &decl{file: filename, node: n, name: n}
+	case *ast.Field:
+		// A reference to the alias of a label.
+		if a, ok := n.Label.(*ast.Alias); ok {
+			return &decl{file: filename, node: a, name: a.Ident}
+		}
+		return nil
+	}
+	// References to fields refer to their values. The loader resolves
+	// references to top-level fields across the files of a package, so
+	// look in the file of the reference first and then in the others.
+	files := []string{filename}
+	for _, name := range sortedFiles(snap) {
+		if name != filename {
+			files = append(files, name)
+		}
+	}
+	for _, name := range files {
+		if field := fieldWithValue(snap.files[name], x); field != nil {
+			return &decl{file: name, node: field, name: fieldName(field)}
+		}
+	}
+	return nil
+}
+
+// fieldWithValue returns the field named by x of which x.Node is the value.
+func fieldWithValue(f *ast.File, x *ast.Ident) *ast.Field {
+	var found *ast.Field
+	ast.Walk(f, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		field, ok := n.(*ast.Field)
+		if !ok {
+			return true
+		}
+		v := field.Value
+		if a, ok := v.(*ast.Alias); ok {
+			v = a.Expr
+		}
+		if v == x.Node {
+			if name, _, _ := ast.LabelName(fieldName(field)); name == x.Name {
+				found = field
+				return false
+			}
+		}
+		return true
+	}, nil)
+	return found
+}
+
+// topLevelFields returns the top-level fields with the given name in the
+// files of the package of snap.
+func topLevelFields(snap *snapshot, name string) []*decl {
+	var decls []*decl
+	for _, filename := range sortedFiles(snap) {
+		for _, d := range snap.files[filename].Decls {
+			f, ok := d.(*ast.Field)
+			if !ok {
+				continue
+			}
+			if n, _, _ := ast.LabelName(fieldName(f)); n == name {
+				decls = append(decls, &decl{file: filename, node: f, name: fieldName(f)})
+			}
+		}
+	}
+	return decls
+}
+
+func sortedFiles(snap *snapshot) []string {
+	names := make([]string, 0, len(snap.files))
+	for name := range snap.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve returns the declarations that the identifier x in the given file
+// refers to, where stack holds the nodes enclosing x. Fields declared in
+// multiple places, such as top-level fields declared in several files of a
+// package, have multiple declarations.
+func resolve(snap *snapshot, filename string, x *ast.Ident, stack []ast.Node) []*decl {
+	if field := labelOf(x, stack); field != nil {
+		if _, ok := stack[0].(*ast.File); ok && stack[1] == field {
+			// A top-level field.
+			return topLevelFields(snap, x.Name)
+		}
+		return []*decl{{file: filename, node: field, name: x}}
+	}
+	if len(stack) > 0 {
+		if sel, ok := stack[len(stack)-1].(*ast.SelectorExpr); ok && sel.Sel == ast.Label(x) {
+			return resolveSelector(snap, filename, sel, stack[:len(stack)-1])
+		}
+	}
+	if d := declaration(snap, filename, x); d != nil {
+		return []*decl{d}
+	}
+	if x.Node == nil {
+		// References to fields declared in other files of the package are
+		// not resolved by the parser.
+		return topLevelFields(snap, x.Name)
+	}
+	return nil
+}
+
+// resolveSelector returns the declarations of the field selected by sel.
+func resolveSelector(snap *snapshot, filename string, sel *ast.SelectorExpr, stack []ast.Node) []*decl {
+	name, _, err := ast.LabelName(sel.Sel)
+	if err != nil {
+		return nil
+	}
+	var decls []*decl
+	switch x := sel.X.(type) {
+	case *ast.Ident:
+		if spec, ok := x.Node.(*ast.ImportSpec); ok {
+			imp := importedInstance(snap, spec)
+			if imp == nil {
+				return nil
+			}
+			for _, f := range imp.Files {
+				for _, d := range f.Decls {
+					if field, ok := d.(*ast.Field); ok {
+						if n, _, _ := ast.LabelName(fieldName(field)); n == name {
+							decls = append(decls, &decl{file: f.Filename, node: field, name: fieldName(field)})
+						}
+					}
+				}
+			}
+			return decls
+		}
+		decls = resolve(snap, filename, x, append(stack, sel))
+	case *ast.SelectorExpr:
+		decls = resolveSelector(snap, filename, x, append(stack, sel))
+	default:
+		return nil
+	}
+
+	// Look up the field in the struct literals declaring the selected
+	// value.
+	var result []*decl
+	for _, d := range decls {
+		field, ok := d.node.(*ast.Field)
+		if !ok {
+			continue
+		}
+		for _, s := range structLits(field.Value) {
+			for _, elt := range s.Elts {
+				if f, ok := elt.(*ast.Field); ok {
+					if n, _, _ := ast.LabelName(fieldName(f)); n == name {
+						result = append(result, &decl{file: d.file, node: f, name: fieldName(f)})
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// structLits returns the struct literals unified in expression x.
+func structLits(x ast.Expr) []*ast.StructLit {
+	switch x := x.(type) {
+	case *ast.StructLit:
+		return []*ast.StructLit{x}
+	case *ast.Alias:
+		return structLits(x.Expr)
+	case *ast.ParenExpr:
+		return structLits(x.X)
+	case *ast.BinaryExpr:
+		if x.Op == token.AND {
+			return append(structLits(x.X), structLits(x.Y)...)
+		}
+	}
+	return nil
+}
+
+func importedInstance(snap *snapshot, spec *ast.ImportSpec) *build.Instance {
+	if snap.inst == nil {
+		return nil
+	}
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return nil
+	}
+	for _, imp := range snap.inst.Imports {
+		if imp.ImportPath == path {
+			return imp
+		}
+	}
+	return nil
+}
+
+// valuePath returns the path of the value of the given field, where stack
+// holds the nodes enclosing the field in its file. It reports false if the
+// path cannot be determined statically, for instance for fields within
+// comprehensions or lists.
+func valuePath(inst *build.Instance, stack []ast.Node, field *ast.Field) (cue.Path, bool) {
+	var sels []cue.Selector
+	for _, n := range append(stack, field) {
+		switch x := n.(type) {
+		case *ast.File, *ast.StructLit, *ast.EmbedDecl, *ast.ParenExpr, *ast.Alias:
+		case *ast.BinaryExpr:
+			if x.Op != token.AND {
+				return cue.Path{}, false
+			}
+		case *ast.Field:
+			sel, ok := labelSelector(inst, fieldName(x))
+			if !ok {
+				return cue.Path{}, false
+			}
+			if x.Constraint != token.ILLEGAL {
+				sel = sel.Optional()
+			}
+			sels = append(sels, sel)
+		default:
+			return cue.Path{}, false
+		}
+	}
+	return cue.MakePath(sels...), true
+}
+
+func labelSelector(inst *build.Instance, l ast.Label) (cue.Selector, bool) {
+	name, isIdent, err := ast.LabelName(l)
+	if err != nil {
+		return cue.Selector{}, false
+	}
+	switch {
+	case !isIdent:
+		return cue.Str(name), true
+	case strings.HasPrefix(name, "#"):
+		return cue.Def(name), true
+	case strings.HasPrefix(name, "_"):
+		pkg := "_"
+		if inst != nil && inst.ImportPath != "" {
+			pkg = inst.ImportPath
+		}
+		return cue.Hid(name, pkg), true
+	}
+	return cue.Str(name), true
+}
@@ -0,0 +1,553 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/runtime"
+	"cuelang.org/go/internal/mod/modfile"
+)
+
+// codeRequestFailed is the LSP error code for requests that are valid, but
+// cannot be performed.
+const codeRequestFailed = -32803
+
+// maxHoverLines limits the size of values shown on hover.
+const maxHoverLines = 30
+
+func (s *server) uri(path string) string {
+	if doc := s.docs[path]; doc != nil {
+		return doc.uri
+	}
+	return pathToURI(path)
+}
+
+// fileOf returns the syntax of the file with the given name and the instance
+// it belongs to, which is either the package of snap or one of its imports.
+func fileOf(snap *snapshot, filename string) (*build.Instance, *ast.File) {
+	if f := snap.files[filename]; f != nil {
+		return snap.inst, f
+	}
+	if snap.inst == nil {
+		return nil, nil
+	}
+	for _, imp := range snap.inst.Imports {
+		for _, f := range imp.Files {
+			if f.Filename == filename {
+				return imp, f
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *server) definition(doc *document, pos Position) []Location {
+	snap := s.snapshot(doc)
+	f := snap.files[doc.path]
+	if f == nil {
+		return nil
+	}
+	x, stack := identAt(f, offset(doc.text, pos))
+	if x == nil {
+		return nil
+	}
+	var locs []Location
+	for _, d := range resolve(snap, doc.path, x, stack) {
+		name := d.name
+		if name == nil {
+			name = d.node
+		}
+		locs = append(locs, Location{
+			URI:   s.uri(d.file),
+			Range: nodeRange(s.fileText(d.file), name),
+		})
+	}
+	return locs
+}
+
+func (s *server) hover(doc *document, pos Position) *Hover {
+	snap := s.snapshot(doc)
+	f := snap.files[doc.path]
+	if f == nil {
+		return nil
+	}
+	x, stack := identAt(f, offset(doc.text, pos))
+	if x == nil {
+		return nil
+	}
+	decls := resolve(snap, doc.path, x, stack)
+	if len(decls) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	switch n := decls[0].node.(type) {
+	case *ast.ImportSpec:
+		fmt.Fprintf(&b, "```cue\nimport %s\n```\n", n.Path.Value)
+
+	case *ast.Field:
+		name, _, _ := ast.LabelName(fieldName(n))
+		v, ok := fieldValue(snap, decls[0])
+		var src []byte
+		if ok {
+			src, _ = format.Node(v.Syntax(
+				cue.Definitions(true),
+				cue.Optional(true),
+				cue.Docs(false),
+				cue.Attributes(false),
+			))
+		} else {
+			src, _ = format.Node(n.Value)
+		}
+		fmt.Fprintf(&b, "```cue\n%s: %s\n```\n", name, truncateLines(string(src)))
+		docs := ast.Comments(n)
+		if ok {
+			docs = v.Doc()
+		}
+		writeDocs(&b, docs)
+
+	default:
+		src, _ := format.Node(n)
+		fmt.Fprintf(&b, "```cue\n%s\n```\n", truncateLines(string(src)))
+	}
+	r := nodeRange(doc.text, x)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: b.String()},
+		Range:    &r,
+	}
+}
+
+// fieldValue returns the evaluated value of the field declared by d.
+func fieldValue(snap *snapshot, d *decl) (cue.Value, bool) {
+	field, ok := d.node.(*ast.Field)
+	if !ok || !snap.value.Exists() {
+		return cue.Value{}, false
+	}
+	inst, f := fileOf(snap, d.file)
+	if f == nil {
+		return cue.Value{}, false
+	}
+	path, ok := valuePath(inst, ancestors(f, field), field)
+	if !ok {
+		return cue.Value{}, false
+	}
+	root := snap.value
+	if inst != snap.inst {
+		root = snap.value.Context().BuildInstance(inst)
+	}
+	v := root.LookupPath(path)
+	return v, v.Exists()
+}
+
+func writeDocs(b *strings.Builder, docs []*ast.CommentGroup) {
+	for _, cg := range docs {
+		if cg.Doc {
+			fmt.Fprintf(b, "\n%s", cg.Text())
+		}
+	}
+}
+
+func truncateLines(s string) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= maxHoverLines {
+		return s
+	}
+	return strings.Join(lines[:maxHoverLines], "\n") + "\n..."
+}
+
+var (
+	identChars = `[\p{L}\p{N}_$#]`
+
+	// importPrefix matches the start of an import path up to the cursor.
+	importPrefix = regexp.MustCompile(`^\s*(?:import\s+)?(?:` + identChars + `+\s+)?"([^"]*)$`)
+
+	// importLine matches lines that may precede an import path being typed.
+	importLine = regexp.MustCompile(`^\s*(?:$|//|package\s|import\b|\)|(?:` + identChars + `+\s+)?"[^"]*"\s*$)`)
+
+	// selectorPrefix matches selectors up to the cursor, as in a.b.c.
+	selectorPrefix = regexp.MustCompile(`((?:` + identChars + `+\.)+)` + identChars + `*$`)
+)
+
+func (s *server) completion(doc *document, pos Position) *CompletionList {
+	list := &CompletionList{Items: []CompletionItem{}}
+	off := offset(doc.text, pos)
+	lineStart := strings.LastIndexByte(doc.text[:off], '\n') + 1
+	prefix := doc.text[lineStart:off]
+
+	if m := importPrefix.FindStringSubmatch(prefix); m != nil && inImports(doc.text[:lineStart]) {
+		start := pos
+		start.Character -= utf16Len(m[1])
+		for _, path := range importPaths(doc.path) {
+			if strings.HasPrefix(path, m[1]) {
+				list.Items = append(list.Items, CompletionItem{
+					Label:    path,
+					Kind:     CompletionModule,
+					TextEdit: &TextEdit{Range: Range{Start: start, End: pos}, NewText: path},
+				})
+			}
+		}
+		return list
+	}
+
+	snap := s.snapshot(doc)
+	if !snap.value.Exists() {
+		// Use the last evaluated version of the document while it is
+		// being edited.
+		snap = s.lastGood[doc.path]
+		if snap == nil {
+			return list
+		}
+	}
+	f := snap.files[doc.path]
+	if f == nil {
+		return list
+	}
+	scopes := scopeValues(snap, f, off)
+
+	seen := map[string]bool{}
+	if m := selectorPrefix.FindStringSubmatch(prefix); m != nil {
+		names := strings.Split(strings.TrimSuffix(m[1], "."), ".")
+		addFields(list, seen, lookupScoped(snap, f, scopes, names))
+		return list
+	}
+	for _, v := range scopes {
+		addFields(list, seen, v)
+	}
+	for _, spec := range f.Imports {
+		info, err := astutil.ParseImportSpec(spec)
+		if err != nil || seen[info.Ident] {
+			continue
+		}
+		seen[info.Ident] = true
+		list.Items = append(list.Items, CompletionItem{
+			Label:  info.Ident,
+			Kind:   CompletionModule,
+			Detail: spec.Path.Value,
+		})
+	}
+	return list
+}
+
+// inImports reports whether text, the contents of a document before the
+// current line, only holds a package clause and imports.
+func inImports(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if !importLine.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// importPaths returns the import paths of the builtin packages and of the
+// packages of the module containing the given file.
+func importPaths(filename string) []string {
+	paths := runtime.SharedRuntime.BuiltinPackagePaths()
+
+	root := filepath.Dir(filename)
+	for {
+		if _, err := os.Stat(filepath.Join(root, "cue.mod", "module.cue")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return paths
+		}
+		root = parent
+	}
+	data, err := os.ReadFile(filepath.Join(root, "cue.mod", "module.cue"))
+	if err != nil {
+		return paths
+	}
+	mf, err := modfile.ParseNonStrict(data, "module.cue")
+	if err != nil || mf.Module == "" {
+		return paths
+	}
+	module, _, _ := strings.Cut(mf.Module, "@")
+
+	own := filepath.Dir(filename)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (name == "cue.mod" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		if path == own || !hasCUEFiles(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		if rel == "." {
+			paths = append(paths, module)
+		} else {
+			paths = append(paths, module+"/"+filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths
+}
+
+func hasCUEFiles(dir string) bool {
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".cue") {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeValues returns the values of the structs enclosing off in f,
+// innermost first.
+func scopeValues(snap *snapshot, f *ast.File, off int) []cue.Value {
+	var sels []cue.Selector
+	paths := [][]cue.Selector{nil}
+loop:
+	for _, n := range nodesAt(f, off) {
+		switch x := n.(type) {
+		case *ast.File, *ast.StructLit, *ast.EmbedDecl, *ast.ParenExpr:
+		case *ast.BinaryExpr:
+			if x.Op != token.AND {
+				break loop
+			}
+		case *ast.Field:
+			if x.Value == nil || off < x.Value.Pos().Offset() {
+				// Completing the label of the field.
+				break loop
+			}
+			sel, ok := labelSelector(snap.inst, fieldName(x))
+			if !ok {
+				break loop
+			}
+			sels = append(sels, sel)
+			paths = append(paths, append([]cue.Selector{}, sels...))
+		default:
+			break loop
+		}
+	}
+	values := make([]cue.Value, 0, len(paths))
+	for i := len(paths) - 1; i >= 0; i-- {
+		if v := snap.value.LookupPath(cue.MakePath(paths[i]...)); v.Exists() {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// lookupScoped looks up the selectors in names, where the first name refers
+// to an import of f or a field in one of the given scopes.
+func lookupScoped(snap *snapshot, f *ast.File, scopes []cue.Value, names []string) cue.Value {
+	var v cue.Value
+	for _, spec := range f.Imports {
+		if info, err := astutil.ParseImportSpec(spec); err == nil && info.Ident == names[0] {
+			if imp := importedInstance(snap, spec); imp != nil {
+				v = snap.value.Context().BuildInstance(imp)
+			}
+			break
+		}
+	}
+	if !v.Exists() {
+		sel, ok := identSelector(snap.inst, names[0])
+		if !ok {
+			return cue.Value{}
+		}
+		for _, scope := range scopes {
+			if w := scope.LookupPath(cue.MakePath(sel)); w.Exists() {
+				v = w
+				break
+			}
+		}
+	}
+	for _, name := range names[1:] {
+		sel, ok := identSelector(snap.inst, name)
+		if !ok {
+			return cue.Value{}
+		}
+		v = v.LookupPath(cue.MakePath(sel))
+	}
+	return v
+}
+
+func identSelector(inst *build.Instance, name string) (cue.Selector, bool) {
+	if !ast.IsValidIdent(name) {
+		return cue.Selector{}, false
+	}
+	return labelSelector(inst, ast.NewIdent(name))
+}
+
+// addFields adds the fields of v to list, skipping those in seen.
+func addFields(list *CompletionList, seen map[string]bool, v cue.Value) {
+	iter, err := v.Fields(cue.Optional(true), cue.Definitions(true))
+	if err != nil {
+		return
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		label := strings.TrimRight(sel.String(), "?!")
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		item := CompletionItem{
+			Label:  label,
+			Kind:   CompletionField,
+			Detail: iter.Value().IncompleteKind().String(),
+		}
+		if sel.IsDefinition() {
+			item.Kind = CompletionStruct
+		}
+		var b strings.Builder
+		writeDocs(&b, iter.Value().Doc())
+		item.Documentation = strings.TrimSpace(b.String())
+		list.Items = append(list.Items, item)
+	}
+}
+
+func (s *server) rename(doc *document, pos Position, newName string) (*WorkspaceEdit, error) {
+	fail := func(format string, args ...interface{}) (*WorkspaceEdit, error) {
+		return nil, &rpcError{Code: codeRequestFailed, Message: fmt.Sprintf(format, args...)}
+	}
+	snap := s.snapshot(doc)
+	f := snap.files[doc.path]
+	if f == nil {
+		return fail("cannot rename in a file with syntax errors")
+	}
+	x, stack := identAt(f, offset(doc.text, pos))
+	if x == nil {
+		return fail("no identifier at position")
+	}
+	oldName := x.Name
+	if !ast.IsValidIdent(newName) {
+		return fail("%q is not a valid identifier", newName)
+	}
+	if identKind(oldName) != identKind(newName) {
+		return fail("cannot rename %s to %s: both must be %s", oldName, newName, identKind(oldName))
+	}
+
+	decls := resolve(snap, doc.path, x, stack)
+	if len(decls) == 0 {
+		return fail("cannot find the declaration of %s", oldName)
+	}
+	topLevel := false
+	for _, d := range decls {
+		file := snap.files[d.file]
+		if file == nil {
+			return fail("cannot rename %s: declared in another package", oldName)
+		}
+		for _, decl := range file.Decls {
+			if decl == d.node {
+				topLevel = true
+			}
+		}
+	}
+	if topLevel {
+		// Top-level fields may be declared and referred to in all files of
+		// the package.
+		decls = topLevelFields(snap, oldName)
+	}
+
+	// targets holds the nodes that references to the renamed declarations
+	// resolve to.
+	targets := map[ast.Node]bool{}
+	edits := map[string][]TextEdit{}
+	add := func(filename string, r Range, text string) {
+		uri := s.uri(filename)
+		for _, e := range edits[uri] {
+			if e.Range == r {
+				return
+			}
+		}
+		edits[uri] = append(edits[uri], TextEdit{Range: r, NewText: text})
+	}
+	for _, d := range decls {
+		text := s.fileText(d.file)
+		switch n := d.node.(type) {
+		case *ast.Field:
+			v := n.Value
+			if a, ok := v.(*ast.Alias); ok {
+				v = a.Expr
+			}
+			targets[v] = true
+		case *ast.ImportSpec:
+			targets[n] = true
+			if d.name == nil {
+				// Give the import an explicit name.
+				p := position(text, n.Path.Pos())
+				add(d.file, Range{Start: p, End: p}, newName+" ")
+				continue
+			}
+		default:
+			targets[n] = true
+		}
+		add(d.file, nodeRange(text, d.name), newName)
+	}
+
+	for _, filename := range sortedFiles(snap) {
+		file := snap.files[filename]
+		text := s.fileText(filename)
+		ast.Walk(file, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == oldName && id.Node != nil && targets[id.Node] {
+				add(filename, nodeRange(text, id), newName)
+			}
+			return true
+		}, nil)
+		if topLevel {
+			for _, id := range file.Unresolved {
+				if id.Name == oldName {
+					add(filename, nodeRange(text, id), newName)
+				}
+			}
+		}
+	}
+	for _, e := range edits {
+		sort.Slice(e, func(i, j int) bool {
+			a, b := e[i].Range.Start, e[j].Range.Start
+			return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+		})
+	}
+	return &WorkspaceEdit{Changes: edits}, nil
+}
+
+// identKind describes the kind of an identifier, which renaming must not
+// change.
+func identKind(name string) string {
+	switch {
+	case strings.HasPrefix(name, "_#"):
+		return "hidden definitions"
+	case strings.HasPrefix(name, "#"):
+		return "definitions"
+	case strings.HasPrefix(name, "_"):
+		return "hidden"
+	}
+	return "regular identifiers"
+}
@@ -0,0 +1,357 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var testFiles = map[string]string{
+	"cue.mod/module.cue": `module: "example.com/m"
+`,
+	"a/a.cue": `package a
+
+import "example.com/m/b"
+
+// Port is the port to listen on.
+#Port: int & >=1024 | *8080
+
+server: {
+	port: #Port
+	host: b.host
+}
+`,
+	"a/other.cue": `package a
+
+replicas: server.port
+`,
+	"b/b.cue": `package b
+
+host: "localhost"
+`,
+}
+
+// A testClient sends requests to a server and records the diagnostics it
+// publishes.
+type testClient struct {
+	t      *testing.T
+	conn   *conn
+	frames chan []byte
+	nextID int
+	diags  map[string][]Diagnostic
+}
+
+func newTestClient(t *testing.T, r io.Reader, w io.Writer) *testClient {
+	c := &testClient{
+		t:      t,
+		conn:   newConn(r, w),
+		frames: make(chan []byte, 100),
+		diags:  map[string][]Diagnostic{},
+	}
+	// Read messages concurrently so that the server never blocks on
+	// writing notifications while the client is sending.
+	go func() {
+		defer close(c.frames)
+		for {
+			data, err := c.conn.readFrame()
+			if err != nil {
+				return
+			}
+			c.frames <- data
+		}
+	}()
+	return c
+}
+
+type testMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// call sends a request and decodes its result into result. It returns the
+// error of the response, if any.
+func (c *testClient) call(method string, params, result interface{}) *rpcError {
+	c.t.Helper()
+	c.nextID++
+	id, _ := json.Marshal(c.nextID)
+	err := c.conn.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		data, ok := <-c.frames
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		var msg testMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.t.Fatal(err)
+		}
+		if msg.Method == "textDocument/publishDiagnostics" {
+			var p PublishDiagnosticsParams
+			if err := json.Unmarshal(msg.Params, &p); err != nil {
+				c.t.Fatal(err)
+			}
+			c.diags[p.URI] = p.Diagnostics
+			continue
+		}
+		if string(msg.ID) != string(id) {
+			c.t.Fatalf("unexpected message %s", data)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return nil
+	}
+}
+
+// positionOf returns the position of the n-th occurrence of substr in the
+// given file, starting at 0.
+func positionOf(t *testing.T, file, substr string, n int) Position {
+	text := testFiles[file]
+	off := 0
+	for i := 0; ; i++ {
+		j := strings.Index(text[off:], substr)
+		if j < 0 {
+			t.Fatalf("%q not found in %s", substr, file)
+		}
+		if i == n {
+			off += j
+			break
+		}
+		off += j + len(substr)
+	}
+	return Position{
+		Line:      strings.Count(text[:off], "\n"),
+		Character: off - strings.LastIndexByte(text[:off], '\n') - 1,
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	for name, text := range testFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uri := func(name string) string {
+		return pathToURI(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+	doc := func(name string) TextDocumentIdentifier {
+		return TextDocumentIdentifier{URI: uri(name)}
+	}
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(context.Background(), serverR, serverW)
+		serverW.Close()
+	}()
+	c := newTestClient(t, clientR, clientW)
+
+	var init InitializeResult
+	if err := c.call("initialize", map[string]interface{}{}, &init); err != nil {
+		t.Fatal(err)
+	}
+	if !init.Capabilities.HoverProvider || !init.Capabilities.RenameProvider {
+		t.Errorf("unexpected capabilities %+v", init.Capabilities)
+	}
+	c.notify("initialized", struct{}{})
+	for _, name := range []string{"a/a.cue", "a/other.cue"} {
+		c.notify("textDocument/didOpen", &DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+			URI:        uri(name),
+			LanguageID: "cue",
+			Version:    1,
+			Text:       testFiles[name],
+		}})
+	}
+
+	t.Run("definition", func(t *testing.T) {
+		testCases := []struct {
+			file   string
+			at     string
+			n      int
+			toFile string
+			to     string
+			toN    int
+		}{
+			{"a/a.cue", "#Port", 1, "a/a.cue", "#Port", 0},
+			{"a/a.cue", "host", 1, "b/b.cue", "host", 0},
+			{"a/other.cue", "server", 0, "a/a.cue", "server", 0},
+			{"a/other.cue", "port", 0, "a/a.cue", "port", 2},
+		}
+		for _, tc := range testCases {
+			var locs []Location
+			err := c.call("textDocument/definition", &TextDocumentPositionParams{
+				TextDocument: doc(tc.file),
+				Position:     positionOf(t, tc.file, tc.at, tc.n),
+			}, &locs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Location{URI: uri(tc.toFile), Range: Range{Start: positionOf(t, tc.toFile, tc.to, tc.toN)}}
+			want.Range.End = want.Range.Start
+			want.Range.End.Character += len(tc.to)
+			if len(locs) != 1 || locs[0] != want {
+				t.Errorf("definition of %s in %s: got %+v; want %+v", tc.at, tc.file, locs, want)
+			}
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var h Hover
+		err := c.call("textDocument/hover", &TextDocumentPositionParams{
+			TextDocument: doc("a/a.cue"),
+			Position:     positionOf(t, "a/a.cue", "#Port", 1),
+		}, &h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"#Port: int & >=1024 | *8080", "Port is the port to listen on."} {
+			if !strings.Contains(h.Contents.Value, want) {
+				t.Errorf("hover does not contain %q:\n%s", want, h.Contents.Value)
+			}
+		}
+	})
+
+	completions := func(t *testing.T, file string, pos Position) []string {
+		var list CompletionList
+		err := c.call("textDocument/completion", &TextDocumentPositionParams{
+			TextDocument: doc(file),
+			Position:     pos,
+		}, &list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var labels []string
+		for _, item := range list.Items {
+			labels = append(labels, item.Label)
+		}
+		sort.Strings(labels)
+		return labels
+	}
+
+	t.Run("completion", func(t *testing.T) {
+		// Fields of the enclosing structs and imports.
+		pos := positionOf(t, "a/a.cue", "host", 0)
+		got := strings.Join(completions(t, "a/a.cue", pos), " ")
+		if want := "#Port b host port replicas server"; got != want {
+			t.Errorf("got %s; want %s", got, want)
+		}
+
+		// Fields of the selected value.
+		pos = positionOf(t, "a/a.cue", "host", 1)
+		got = strings.Join(completions(t, "a/a.cue", pos), " ")
+		if want := "host"; got != want {
+			t.Errorf("got %s; want %s", got, want)
+		}
+
+		// Import paths.
+		pos = positionOf(t, "a/a.cue", "example.com", 0)
+		labels := completions(t, "a/a.cue", pos)
+		for _, want := range []string{"strings", "example.com/m/b"} {
+			found := false
+			for _, l := range labels {
+				found = found || l == want
+			}
+			if !found {
+				t.Errorf("import completions %v do not include %s", labels, want)
+			}
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		var edit WorkspaceEdit
+		err := c.call("textDocument/rename", &RenameParams{
+			TextDocument: doc("a/other.cue"),
+			Position:     positionOf(t, "a/other.cue", "server", 0),
+			NewName:      "frontend",
+		}, &edit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for file, n := range map[string]int{"a/a.cue": 1, "a/other.cue": 1} {
+			if edits := edit.Changes[uri(file)]; len(edits) != n {
+				t.Errorf("got %d edits in %s; want %d", len(edits), file, n)
+			}
+		}
+		if len(edit.Changes) != 2 {
+			t.Errorf("unexpected edits %v", edit.Changes)
+		}
+
+		err = c.call("textDocument/rename", &RenameParams{
+			TextDocument: doc("a/a.cue"),
+			Position:     positionOf(t, "a/a.cue", "#Port", 0),
+			NewName:      "Port",
+		}, nil)
+		if err == nil || err.Code != codeRequestFailed {
+			t.Errorf("renaming a definition to a regular field: got %v; want error", err)
+		}
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		if d := c.diags[uri("a/a.cue")]; len(d) != 0 {
+			t.Errorf("unexpected diagnostics %+v", d)
+		}
+		text := strings.Replace(testFiles["a/a.cue"], "port: #Port", "port: #Port & 80", 1)
+		c.notify("textDocument/didChange", &DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{URI: uri("a/a.cue"), Version: 2},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: text}},
+		})
+		// Wait for the diagnostics to be published.
+		c.call("shutdown", nil, nil)
+		d := c.diags[uri("a/a.cue")]
+		if len(d) == 0 || !strings.Contains(d[0].Message, "server.port") || d[0].Range.Start.Line != 5 {
+			t.Errorf("unexpected diagnostics %+v", d)
+		}
+	})
+
+	c.notify("exit", nil)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,466 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lsp implements a language server for CUE, as run by cue lsp.
+//
+// The server speaks the Language Server Protocol over a single stream. It
+// keeps the documents opened by the client in memory and loads the packages
+// they belong to with these documents as overlays, so that diagnostics,
+// definitions, hover information, completions, and renames reflect unsaved
+// changes.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+)
+
+// Serve runs a language server that reads requests from r and writes
+// responses to w until the client sends the exit notification, r is
+// exhausted, or ctx is done.
+func Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s := &server{
+		conn:      newConn(r, w),
+		docs:      map[string]*document{},
+		snapshots: map[string]*snapshot{},
+		lastGood:  map[string]*snapshot{},
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if e, ok := err.(*rpcError); ok {
+			// The message could be framed, but not decoded.
+			if err := s.conn.reply(json.RawMessage("null"), nil, e); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit before shutdown")
+			}
+			return nil
+		}
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			// Notifications have no response.
+			continue
+		}
+		if err := s.conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+type server struct {
+	conn     *conn
+	shutdown bool
+
+	// docs holds the open documents by file name.
+	docs map[string]*document
+
+	// snapshots caches the analysis of open documents by file name. It is
+	// cleared whenever a document changes.
+	snapshots map[string]*snapshot
+
+	// lastGood holds the last snapshot of each open document that could be
+	// evaluated, for completion while the document is being edited.
+	lastGood map[string]*snapshot
+}
+
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    string
+}
+
+func (s *server) handle(msg *message) (interface{}, error) {
+	if s.shutdown {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+	switch msg.Method {
+	case "initialize":
+		return &InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:   TextDocumentSyncFull,
+				HoverProvider:      true,
+				DefinitionProvider: true,
+				CompletionProvider: &CompletionOptions{
+					TriggerCharacters: []string{".", "\"", "/"},
+				},
+				RenameProvider: true,
+			},
+			ServerInfo: &ServerInfo{Name: "cue"},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := decode(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		path, err := uriToPath(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		s.docs[path] = &document{
+			uri:     p.TextDocument.URI,
+			path:    path,
+			version: p.TextDocument.Version,
+			text:    p.TextDocument.Text,
+		}
+		return nil, s.changed()
+
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := decode(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range p.ContentChanges {
+			if c.Range == nil {
+				doc.text = c.Text
+				continue
+			}
+			start := offset(doc.text, c.Range.Start)
+			end := offset(doc.text, c.Range.End)
+			doc.text = doc.text[:start] + c.Text + doc.text[end:]
+		}
+		doc.version = p.TextDocument.Version
+		return nil, s.changed()
+
+	case "textDocument/didSave":
+		// Files that are not open may have changed as well.
+		return nil, s.changed()
+
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := decode(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		delete(s.docs, doc.path)
+		delete(s.lastGood, doc.path)
+		if err := s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         doc.uri,
+			Diagnostics: []Diagnostic{},
+		}); err != nil {
+			return nil, err
+		}
+		return nil, s.changed()
+
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		if err := decode(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.hover(doc, p.Position), nil
+
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		if err := decode(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.definition(doc, p.Position), nil
+
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		if err := decode(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.completion(doc, p.Position), nil
+
+	case "textDocument/rename":
+		var p RenameParams
+		if err := decode(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.rename(doc, p.Position, p.NewName)
+	}
+	if msg.ID == nil {
+		// Unknown notifications, such as initialized or $/cancelRequest,
+		// are ignored.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not supported: " + msg.Method}
+}
+
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *server) document(uri string) (*document, error) {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	doc := s.docs[path]
+	if doc == nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "document not open: " + uri}
+	}
+	return doc, nil
+}
+
+// changed invalidates all analysis and publishes new diagnostics for all
+// open documents, as a change to one document may affect the others.
+func (s *server) changed() error {
+	s.snapshots = map[string]*snapshot{}
+	paths := make([]string, 0, len(s.docs))
+	for path := range s.docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		doc := s.docs[path]
+		if err := s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         doc.uri,
+			Diagnostics: s.diagnostics(doc),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A snapshot holds the analysis of the package of a document.
+type snapshot struct {
+	// parseErr holds the syntax errors of the document.
+	parseErr error
+
+	// inst is the package of the document. It is nil if the document could
+	// not be parsed.
+	inst *build.Instance
+
+	// files holds the syntax of the files of the package by file name. It
+	// holds at least the document itself, if it could be parsed.
+	files map[string]*ast.File
+
+	// value is the evaluated package. It does not exist if the package
+	// could not be loaded.
+	value cue.Value
+}
+
+func (s *server) snapshot(doc *document) *snapshot {
+	if snap := s.snapshots[doc.path]; snap != nil {
+		return snap
+	}
+	snap := &snapshot{files: map[string]*ast.File{}}
+	s.snapshots[doc.path] = snap
+
+	f, err := parser.ParseFile(doc.path, doc.text, parser.ParseComments)
+	if err != nil {
+		snap.parseErr = err
+		return snap
+	}
+	snap.files[doc.path] = f
+
+	overlay := map[string]load.Source{}
+	for path, d := range s.docs {
+		overlay[path] = load.FromString(d.text)
+	}
+	cfg := &load.Config{
+		Dir:     filepath.Dir(doc.path),
+		Overlay: overlay,
+	}
+	args := []string{doc.path}
+	if name := f.PackageName(); name != "" && name != "_" {
+		cfg.Package = name
+		args = []string{"."}
+	}
+	insts := load.Instances(args, cfg)
+	if len(insts) != 1 {
+		return snap
+	}
+	snap.inst = insts[0]
+	if snap.inst.Err != nil {
+		return snap
+	}
+	for _, f := range snap.inst.Files {
+		snap.files[f.Filename] = f
+	}
+	snap.value = cuecontext.New().BuildInstance(snap.inst)
+	s.lastGood[doc.path] = snap
+	return snap
+}
+
+// diagnostics returns the errors of the package of doc that are positioned
+// in doc.
+func (s *server) diagnostics(doc *document) []Diagnostic {
+	snap := s.snapshot(doc)
+	err := snap.parseErr
+	if err == nil && snap.inst != nil {
+		err = snap.inst.Err
+		if err == nil {
+			err = snap.value.Validate()
+		}
+	}
+
+	diags := []Diagnostic{}
+	seen := map[Diagnostic]bool{}
+	for _, e := range errors.Errors(err) {
+		positions := append([]token.Pos{e.Position()}, e.InputPositions()...)
+		for _, pos := range positions {
+			if pos.Filename() != doc.path {
+				continue
+			}
+			start := position(doc.text, pos)
+			d := Diagnostic{
+				Range:    Range{Start: start, End: start},
+				Severity: SeverityError,
+				Source:   "cue",
+				Message:  e.Error(),
+			}
+			if f := snap.files[doc.path]; f != nil {
+				if n := innermost(f, pos.Offset()); n != nil {
+					d.Range = nodeRange(doc.text, n)
+				}
+			}
+			if !seen[d] {
+				seen[d] = true
+				diags = append(diags, d)
+			}
+		}
+	}
+	return diags
+}
+
+// fileText returns the contents of the file with the given name, which may
+// be an open document.
+func (s *server) fileText(path string) string {
+	if doc := s.docs[path]; doc != nil {
+		return doc.text
+	}
+	b, _ := os.ReadFile(path)
+	return string(b)
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", &rpcError{Code: codeInvalidParams, Message: "unsupported document URI: " + uri}
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths such as C:/dir.
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// position converts a position in text to an LSP position.
+func position(text string, pos token.Pos) Position {
+	off := pos.Offset()
+	if off > len(text) {
+		off = len(text)
+	}
+	line := strings.Count(text[:off], "\n")
+	start := strings.LastIndexByte(text[:off], '\n') + 1
+	return Position{Line: line, Character: utf16Len(text[start:off])}
+}
+
+// offset converts an LSP position in text to a byte offset.
+func offset(text string, p Position) int {
+	off := 0
+	for i := 0; i < p.Line; i++ {
+		j := strings.IndexByte(text[off:], '\n')
+		if j < 0 {
+			return len(text)
+		}
+		off += j + 1
+	}
+	for n := 0; n < p.Character && off < len(text) && text[off] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		n += runeLen16(r)
+		off += size
+	}
+	return off
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen16(r)
+	}
+	return n
+}
+
+// runeLen16 returns the number of UTF-16 code units encoding r.
+func runeLen16(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func nodeRange(text string, n ast.Node) Range {
+	return Range{Start: position(text, n.Pos()), End: position(text, n.End())}
+}
@@ -0,0 +1,281 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Doer is the interface implemented by *http.Client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// A Client is an HTTP client that authenticates requests to OCI registries.
+//
+// A request that is rejected with an authentication challenge is retried
+// with credentials: for Basic challenges, the credentials for the registry
+// are sent directly; for Bearer challenges, they are exchanged for a token
+// with the authorization service named in the challenge, as described in
+// https://distribution.github.io/distribution/spec/auth/token/. Tokens are
+// also obtained anonymously, as required by many public registries.
+//
+// The authorization that succeeded last for a host is sent with subsequent
+// requests to that host up front.
+type Client struct {
+	base        Doer
+	credentials func(host string) (Credentials, error)
+
+	mu   sync.Mutex
+	auth map[string]string // Authorization header by host
+}
+
+// NewClient returns a client that sends requests with base, obtaining the
+// credentials for a registry host by calling credentials. If base is nil,
+// http.DefaultClient is used.
+func NewClient(base Doer, credentials func(host string) (Credentials, error)) *Client {
+	if base == nil {
+		base = http.DefaultClient
+	}
+	return &Client{
+		base:        base,
+		credentials: credentials,
+		auth:        map[string]string{},
+	}
+}
+
+// Do implements ociclient.HTTPDoer.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	c.mu.Lock()
+	auth := c.auth[host]
+	c.mu.Unlock()
+
+	// Keep the original request intact, so that it can be retried.
+	if auth != "" && req.Header.Get("Authorization") == "" {
+		req1 := req.Clone(req.Context())
+		req1.Header.Set("Authorization", auth)
+		if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+			req1.Body, _ = req.GetBody()
+		}
+		req = req1
+	}
+	resp, err := c.base.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	ch, ok := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+	canRetry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if !canRetry {
+		return resp, nil
+	}
+	auth, err = c.authorize(req.Context(), host, ch)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("cannot authenticate to %s: %v", host, err)
+	}
+	if auth == "" {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	req1 := req.Clone(req.Context())
+	req1.Header.Set("Authorization", auth)
+	if req.GetBody != nil {
+		if req1.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	resp, err = c.base.Do(req1)
+	if err == nil && resp.StatusCode != http.StatusUnauthorized {
+		c.mu.Lock()
+		c.auth[host] = auth
+		c.mu.Unlock()
+	}
+	return resp, err
+}
+
+// authorize returns the value of the Authorization header that answers the
+// given challenge for host, or "" if no credentials are available.
+func (c *Client) authorize(ctx context.Context, host string, ch challenge) (string, error) {
+	var creds Credentials
+	if c.credentials != nil {
+		var err error
+		if creds, err = c.credentials(host); err != nil {
+			return "", err
+		}
+	}
+	switch ch.scheme {
+	case "basic":
+		if creds.Username == "" && creds.Password == "" {
+			return "", nil
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(creds.Username, creds.Password)
+		return req.Header.Get("Authorization"), nil
+
+	case "bearer":
+		token, err := c.fetchToken(ctx, ch.params, creds)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+	return "", nil
+}
+
+// fetchToken obtains a bearer token from the authorization service described
+// by the parameters of a Bearer challenge.
+func (c *Client) fetchToken(ctx context.Context, params map[string]string, creds Credentials) (string, error) {
+	realm := params["realm"]
+	u, err := url.Parse(realm)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", fmt.Errorf("invalid realm %q in authentication challenge", realm)
+	}
+	form := url.Values{}
+	if s := params["service"]; s != "" {
+		form.Set("service", s)
+	}
+	if s := params["scope"]; s != "" {
+		form.Set("scope", s)
+	}
+
+	var req *http.Request
+	if creds.RefreshToken != "" {
+		// Exchange the refresh token for an access token as in OAuth2.
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", creds.RefreshToken)
+		form.Set("client_id", "cue")
+		req, err = http.NewRequestWithContext(ctx, "POST", realm, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		q := u.Query()
+		for k, v := range form {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+		req, err = http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if creds.Username != "" || creds.Password != "" {
+			req.SetBasicAuth(creds.Username, creds.Password)
+		}
+	}
+
+	resp, err := c.base.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("authorization service %s responded with %s", u.Host, resp.Status)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("invalid response from authorization service %s: %v", u.Host, err)
+	}
+	if tok.Token == "" {
+		tok.Token = tok.AccessToken
+	}
+	if tok.Token == "" {
+		return "", fmt.Errorf("authorization service %s did not return a token", u.Host)
+	}
+	return tok.Token, nil
+}
+
+// A challenge is a parsed WWW-Authenticate header.
+type challenge struct {
+	scheme string // lower case
+	params map[string]string
+}
+
+// parseChallenge parses the first challenge in a WWW-Authenticate header,
+// such as
+//
+//	Bearer realm="https://auth.example.com/token",service="registry.example.com"
+func parseChallenge(s string) (challenge, bool) {
+	s = strings.TrimSpace(s)
+	scheme, rest, _ := strings.Cut(s, " ")
+	if scheme == "" {
+		return challenge{}, false
+	}
+	ch := challenge{
+		scheme: strings.ToLower(scheme),
+		params: map[string]string{},
+	}
+	for {
+		rest = strings.TrimLeft(rest, " ,")
+		if rest == "" {
+			break
+		}
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			// Another challenge or a token68 value.
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var value string
+		if strings.HasPrefix(after, `"`) {
+			value, rest = quotedString(after[1:])
+		} else {
+			value, rest, _ = strings.Cut(after, ",")
+			value = strings.TrimSpace(value)
+		}
+		ch.params[key] = value
+	}
+	return ch, true
+}
+
+// quotedString returns the contents of the quoted string that s starts
+// with, after its opening quote, and the remainder of s.
+func quotedString(s string) (value, rest string) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:]
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), ""
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modauth
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+)
+
+func TestClientBearer(t *testing.T) {
+	var tokenRequests []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			user, pass, _ := req.BasicAuth()
+			q := req.URL.Query()
+			tokenRequests = append(tokenRequests, fmt.Sprintf("%s:%s %s %s", user, pass, q.Get("service"), q.Get("scope")))
+			if user != "" && pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"token": "tok-%s"}`, user)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer tok-alice" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="registry",scope="repository:%s:pull"`,
+				srv.URL, strings.TrimPrefix(req.URL.Path, "/v2/")))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		fmt.Fprintf(w, "%s %s", req.URL.Path, body)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	c := NewClient(srv.Client(), func(h string) (Credentials, error) {
+		qt.Check(t, qt.Equals(h, host))
+		return Credentials{Username: "alice", Password: "secret"}, nil
+	})
+	get := func(path, body string) string {
+		req, err := http.NewRequest("PUT", srv.URL+path, strings.NewReader(body))
+		qt.Assert(t, qt.IsNil(err))
+		resp, err := c.Do(req)
+		qt.Assert(t, qt.IsNil(err))
+		defer resp.Body.Close()
+		qt.Assert(t, qt.Equals(resp.StatusCode, http.StatusOK))
+		data, err := io.ReadAll(resp.Body)
+		qt.Assert(t, qt.IsNil(err))
+		return string(data)
+	}
+
+	qt.Assert(t, qt.Equals(get("/v2/foo", "a"), "/v2/foo a"))
+	qt.Assert(t, qt.Equals(get("/v2/foo", "b"), "/v2/foo b"))
+	// The token is sent with later requests up front.
+	qt.Assert(t, qt.DeepEquals(tokenRequests, []string{"alice:secret registry repository:foo:pull"}))
+}
+
+func TestClientAnonymousBearer(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/token":
+			_, _, ok := req.BasicAuth()
+			qt.Check(t, qt.IsFalse(ok))
+			fmt.Fprint(w, `{"access_token": "anon"}`)
+		case req.Header.Get("Authorization") != "Bearer anon":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), nil)
+	resp, err := c.Do(mustRequest(t, srv.URL+"/v2/"))
+	qt.Assert(t, qt.IsNil(err))
+	resp.Body.Close()
+	qt.Assert(t, qt.Equals(resp.StatusCode, http.StatusOK))
+}
+
+func TestClientRefreshToken(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/token":
+			qt.Check(t, qt.Equals(req.Method, "POST"))
+			req.ParseForm()
+			qt.Check(t, qt.Equals(req.PostForm.Get("grant_type"), "refresh_token"))
+			fmt.Fprintf(w, `{"access_token": "access-%s"}`, req.PostForm.Get("refresh_token"))
+		case req.Header.Get("Authorization") != "Bearer access-r1":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="s"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), func(string) (Credentials, error) {
+		return Credentials{RefreshToken: "r1"}, nil
+	})
+	resp, err := c.Do(mustRequest(t, srv.URL+"/v2/"))
+	qt.Assert(t, qt.IsNil(err))
+	resp.Body.Close()
+	qt.Assert(t, qt.Equals(resp.StatusCode, http.StatusOK))
+}
+
+func TestClientBasic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, _ := req.BasicAuth(); user != "bob" || pass != "pw" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	// Without credentials, the challenge is passed on.
+	resp, err := NewClient(srv.Client(), nil).Do(mustRequest(t, srv.URL))
+	qt.Assert(t, qt.IsNil(err))
+	resp.Body.Close()
+	qt.Assert(t, qt.Equals(resp.StatusCode, http.StatusUnauthorized))
+
+	c := NewClient(srv.Client(), func(string) (Credentials, error) {
+		return Credentials{Username: "bob", Password: "pw"}, nil
+	})
+	resp, err = c.Do(mustRequest(t, srv.URL))
+	qt.Assert(t, qt.IsNil(err))
+	resp.Body.Close()
+	qt.Assert(t, qt.Equals(resp.StatusCode, http.StatusOK))
+}
+
+func TestClientCredentialsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), func(string) (Credentials, error) {
+		return Credentials{}, fmt.Errorf("no keychain")
+	})
+	_, err := c.Do(mustRequest(t, srv.URL))
+	qt.Assert(t, qt.ErrorMatches(err, `cannot authenticate to 127.0.0.1:\d+: no keychain`))
+}
+
+func mustRequest(t *testing.T, u string) *http.Request {
+	req, err := http.NewRequest("GET", u, nil)
+	qt.Assert(t, qt.IsNil(err))
+	return req
+}
+
+var parseChallengeTests = []struct {
+	in     string
+	scheme string
+	params map[string]string
+}{{
+	in:     `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:foo/bar:pull,push"`,
+	scheme: "bearer",
+	params: map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:foo/bar:pull,push",
+	},
+}, {
+	in:     `Basic realm="a \"quoted\" realm", charset=UTF-8`,
+	scheme: "basic",
+	params: map[string]string{
+		"realm":   `a "quoted" realm`,
+		"charset": "UTF-8",
+	},
+}, {
+	in:     `Negotiate`,
+	scheme: "negotiate",
+	params: map[string]string{},
+}}
+
+func TestParseChallenge(t *testing.T) {
+	for _, test := range parseChallengeTests {
+		t.Run(test.in, func(t *testing.T) {
+			ch, ok := parseChallenge(test.in)
+			qt.Assert(t, qt.IsTrue(ok))
+			qt.Assert(t, qt.Equals(ch.scheme, test.scheme))
+			qt.Assert(t, qt.DeepEquals(ch.params, test.params))
+		})
+	}
+	_, ok := parseChallenge("")
+	qt.Assert(t, qt.IsFalse(ok))
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modauth authenticates requests to OCI registries using the
+// credentials configured for Docker.
+package modauth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials holds the credentials for a registry. The zero value
+// represents anonymous access.
+type Credentials struct {
+	Username string
+	Password string
+
+	// RefreshToken holds an OAuth2 refresh token, also known as an identity
+	// token, which is exchanged for access tokens when set.
+	RefreshToken string
+}
+
+// A Config holds the registry credentials found in a Docker configuration
+// file.
+type Config struct {
+	Auths       map[string]authConfig `json:"auths"`
+	CredsStore  string                `json:"credsStore"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+
+	// runHelper runs the named credential helper; it is replaced in tests.
+	runHelper func(helper, host string) ([]byte, error)
+}
+
+type authConfig struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// Load loads the Docker configuration file, config.json, from the directory
+// named by $DOCKER_CONFIG or, if that is not set, from the .docker directory
+// in the user's home directory. The environment is looked up with getenv.
+//
+// A missing configuration file results in a Config without any credentials.
+func Load(getenv func(string) string) (*Config, error) {
+	dir := getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home := getenv("HOME")
+		if home == "" {
+			return &Config{}, nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	file := filepath.Join(dir, "config.json")
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(file, data)
+}
+
+// Parse parses the contents of a Docker configuration file.
+func Parse(filename string, data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid Docker configuration file %s: %v", filename, err)
+	}
+	return &c, nil
+}
+
+// Credentials returns the credentials for the registry at the given host,
+// which may include a port. Credential helpers specific to the host take
+// precedence over the default credential store, which in turn takes
+// precedence over credentials stored in the configuration file itself.
+func (c *Config) Credentials(host string) (Credentials, error) {
+	helper := c.CredHelpers[host]
+	if helper == "" {
+		helper = c.CredsStore
+	}
+	if helper != "" {
+		creds, ok, err := c.fromHelper(helper, host)
+		if err != nil || ok {
+			return creds, err
+		}
+	}
+	if a, ok := c.Auths[host]; ok {
+		return a.credentials(host)
+	}
+	for key, a := range c.Auths {
+		if normalizeHost(key) == host {
+			return a.credentials(key)
+		}
+	}
+	return Credentials{}, nil
+}
+
+func (a authConfig) credentials(key string) (Credentials, error) {
+	creds := Credentials{
+		Username:     a.Username,
+		Password:     a.Password,
+		RefreshToken: a.IdentityToken,
+	}
+	if a.Auth != "" {
+		b, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("invalid auth for %s in Docker configuration: %v", key, err)
+		}
+		user, pass, ok := strings.Cut(string(b), ":")
+		if !ok {
+			return Credentials{}, fmt.Errorf("invalid auth for %s in Docker configuration: missing colon", key)
+		}
+		creds.Username, creds.Password = user, pass
+	}
+	return creds, nil
+}
+
+// normalizeHost returns the host of an entry of the auths field, whose keys
+// may be URLs such as https://index.docker.io/v1/.
+func normalizeHost(key string) string {
+	if _, rest, ok := strings.Cut(key, "://"); ok {
+		key = rest
+	}
+	host, _, _ := strings.Cut(key, "/")
+	return host
+}
+
+// fromHelper returns the credentials reported by the given credential
+// helper. It reports false if the helper has no credentials for host.
+func (c *Config) fromHelper(helper, host string) (Credentials, bool, error) {
+	run := c.runHelper
+	if run == nil {
+		run = runHelper
+	}
+	out, err := run(helper, host)
+	if err != nil {
+		if bytes.Contains(out, []byte("credentials not found")) {
+			return Credentials{}, false, nil
+		}
+		return Credentials{}, false, fmt.Errorf("credential helper %s failed for %s: %v", helper, host, err)
+	}
+	var resp struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return Credentials{}, false, fmt.Errorf("invalid output from credential helper %s: %v", helper, err)
+	}
+	if resp.Username == "<token>" {
+		return Credentials{RefreshToken: resp.Secret}, true, nil
+	}
+	return Credentials{Username: resp.Username, Password: resp.Secret}, true, nil
+}
+
+// runHelper runs docker-credential-<helper> get, which reads the host from
+// standard input.
+func runHelper(helper, host string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return append(stdout.Bytes(), stderr.Bytes()...), err
+	}
+	return stdout.Bytes(), nil
+}
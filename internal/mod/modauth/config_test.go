@@ -0,0 +1,109 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modauth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+)
+
+const testConfig = `{
+	"auths": {
+		"plain.example.com": {"auth": "YWxpY2U6c2VjcmV0"},
+		"https://url.example.com/v1/": {"username": "bob", "password": "pw"},
+		"token.example.com": {"identitytoken": "refresh"},
+		"bad.example.com": {"auth": "!!"},
+		"store.example.com": {"auth": "ZmFsbGJhY2s6cHc="}
+	},
+	"credsStore": "store",
+	"credHelpers": {
+		"helper.example.com": "special"
+	}
+}`
+
+func TestCredentials(t *testing.T) {
+	c, err := Parse("config.json", []byte(testConfig))
+	qt.Assert(t, qt.IsNil(err))
+	c.runHelper = func(helper, host string) ([]byte, error) {
+		switch host {
+		case "helper.example.com":
+			qt.Check(t, qt.Equals(helper, "special"))
+			return []byte(`{"ServerURL": "helper.example.com", "Username": "<token>", "Secret": "id"}`), nil
+		case "keychain.example.com":
+			return []byte(`{"Username": "carol", "Secret": "pw"}`), nil
+		case "broken.example.com":
+			return []byte("boom"), fmt.Errorf("exit status 1")
+		}
+		return []byte("credentials not found in native keychain\n"), fmt.Errorf("exit status 1")
+	}
+
+	tests := []struct {
+		host string
+		want Credentials
+		err  string
+	}{
+		{host: "plain.example.com", want: Credentials{Username: "alice", Password: "secret"}},
+		{host: "url.example.com", want: Credentials{Username: "bob", Password: "pw"}},
+		{host: "token.example.com", want: Credentials{RefreshToken: "refresh"}},
+		{host: "helper.example.com", want: Credentials{RefreshToken: "id"}},
+		{host: "keychain.example.com", want: Credentials{Username: "carol", Password: "pw"}},
+		{host: "store.example.com", want: Credentials{Username: "fallback", Password: "pw"}},
+		{host: "other.example.com", want: Credentials{}},
+		{host: "bad.example.com", err: `invalid auth for bad.example.com in Docker configuration: .*`},
+		{host: "broken.example.com", err: `credential helper store failed for broken.example.com: exit status 1`},
+	}
+	for _, test := range tests {
+		t.Run(test.host, func(t *testing.T) {
+			got, err := c.Credentials(test.host)
+			if test.err != "" {
+				qt.Assert(t, qt.ErrorMatches(err, test.err))
+				return
+			}
+			qt.Assert(t, qt.IsNil(err))
+			qt.Assert(t, qt.Equals(got, test.want))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{"HOME": dir}
+	getenv := func(k string) string { return env[k] }
+
+	// No configuration file.
+	c, err := Load(getenv)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.HasLen(c.Auths, 0))
+
+	err = os.MkdirAll(filepath.Join(dir, ".docker"), 0o777)
+	qt.Assert(t, qt.IsNil(err))
+	err = os.WriteFile(filepath.Join(dir, ".docker", "config.json"), []byte(`{"auths": {"a": {}}}`), 0o666)
+	qt.Assert(t, qt.IsNil(err))
+	c, err = Load(getenv)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.HasLen(c.Auths, 1))
+
+	env["DOCKER_CONFIG"] = filepath.Join(dir, "other")
+	err = os.MkdirAll(env["DOCKER_CONFIG"], 0o777)
+	qt.Assert(t, qt.IsNil(err))
+	err = os.WriteFile(filepath.Join(env["DOCKER_CONFIG"], "config.json"), []byte(`{`), 0o666)
+	qt.Assert(t, qt.IsNil(err))
+	_, err = Load(getenv)
+	qt.Assert(t, qt.ErrorMatches(err, `invalid Docker configuration file .*config.json: .*`))
+}
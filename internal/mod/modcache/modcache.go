@@ -0,0 +1,323 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modcache implements storage for the contents of modules
+// downloaded from a registry.
+package modcache
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"cuelang.org/go/internal/mod/module"
+	modzip "cuelang.org/go/internal/mod/zip"
+)
+
+// A Cache stores the contents of modules. As module versions are immutable,
+// an entry never needs to be updated once it is stored.
+type Cache interface {
+	// Dir returns the directory holding the contents of the module m, and
+	// reports whether the module is in the cache.
+	Dir(m module.Version) (dir string, ok bool)
+
+	// Put stores the contents of the module m, read as a zip archive from r,
+	// and returns the directory holding them.
+	Put(m module.Version, r io.Reader) (dir string, err error)
+
+	// Verify checks that the stored contents of the module m are those of
+	// the zip archive with digest d.
+	Verify(m module.Version, d digest.Digest) error
+}
+
+// Disk is the default implementation of Cache. It stores modules in a
+// directory on disk, which may be shared by concurrent processes.
+//
+// Each module is stored as a zip archive, named after the module path and
+// version, and as a directory with its extracted contents.
+type Disk struct {
+	dir string
+
+	// MaxSize, if positive, is the size in bytes to which the cache is
+	// trimmed, as with GC, after storing a module.
+	MaxSize int64
+}
+
+var _ Cache = (*Disk)(nil)
+
+// NewDisk returns a cache that stores modules in the directory dir.
+func NewDisk(dir string) *Disk {
+	return &Disk{dir: dir}
+}
+
+// Root returns the directory holding the cache.
+func (c *Disk) Root() string {
+	return c.dir
+}
+
+func (c *Disk) path(m module.Version) string {
+	return filepath.Join(c.dir, filepath.FromSlash(m.String()))
+}
+
+// Dir implements Cache.Dir. It marks the module as used, so that it is
+// removed after modules that were used less recently.
+func (c *Disk) Dir(m module.Version) (string, bool) {
+	dir := c.path(m)
+	if _, err := os.Stat(dir); err != nil {
+		return "", false
+	}
+	now := time.Now()
+	os.Chtimes(dir+".zip", now, now)
+	return dir, true
+}
+
+// Put implements Cache.Put.
+func (c *Disk) Put(m module.Version, r io.Reader) (string, error) {
+	dir := c.path(m)
+	if err := os.MkdirAll(filepath.Dir(dir), 0o777); err != nil {
+		return "", fmt.Errorf("cannot create parent directory for module: %v", err)
+	}
+	// Write to temporary locations first and rename them into place, so
+	// that concurrent processes never observe partially written entries.
+	f, err := os.CreateTemp(filepath.Dir(dir), "tmp-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("cannot create zip file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot copy data to zip file %q: %v", f.Name(), err)
+	}
+	tmpDir := strings.TrimSuffix(f.Name(), ".zip")
+	if err := modzip.Unzip(tmpDir, m, f.Name()); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("cannot unzip %v: %v", m, err)
+	}
+	if err := os.Rename(f.Name(), dir+".zip"); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		os.RemoveAll(tmpDir)
+		if _, serr := os.Stat(dir); serr != nil {
+			return "", err
+		}
+		// Another process stored the module concurrently.
+	}
+	if c.MaxSize > 0 {
+		if err := c.gc(c.MaxSize, dir); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// Verify implements Cache.Verify. It checks both the zip archive and the
+// directory with its extracted contents, as the latter is used for
+// loading.
+func (c *Disk) Verify(m module.Version, d digest.Digest) error {
+	dir := c.path(m)
+	data, err := os.ReadFile(dir + ".zip")
+	if err != nil {
+		return fmt.Errorf("cannot verify cached module %v: %v", m, err)
+	}
+	if err := d.Validate(); err != nil {
+		return fmt.Errorf("cannot verify cached module %v: %v", m, err)
+	}
+	if d.Algorithm().FromBytes(data) != d {
+		return fmt.Errorf("cached module %v does not match digest %s", m, d)
+	}
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("cannot verify cached module %v: %v", m, err)
+	}
+	n := 0
+	for _, zf := range z.File {
+		if zf.Name == "" || strings.HasSuffix(zf.Name, "/") {
+			continue
+		}
+		n++
+		if !sameContents(zf, filepath.Join(dir, filepath.FromSlash(zf.Name))) {
+			return fmt.Errorf("cached file %s of module %v does not match digest %s", zf.Name, m, d)
+		}
+	}
+	count := 0
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot verify cached module %v: %v", m, err)
+	}
+	if count != n {
+		return fmt.Errorf("cached module %v has files not in digest %s", m, d)
+	}
+	return nil
+}
+
+// sameContents reports whether the file at path has the contents of zf.
+func sameContents(zf *zip.File, path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil || uint64(len(data)) != zf.UncompressedSize64 {
+		return false
+	}
+	r, err := zf.Open()
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+	want, err := io.ReadAll(r)
+	return err == nil && bytes.Equal(data, want)
+}
+
+// An entry describes a module stored in a Disk cache.
+type entry struct {
+	dir  string // directory with the extracted contents
+	size int64  // total size of the zip archive and its contents
+	used time.Time
+}
+
+// entries returns the modules in the cache.
+func (c *Disk) entries() ([]entry, error) {
+	var entries []entry
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == c.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() || !strings.Contains(d.Name(), "@") {
+			return nil
+		}
+		e := entry{dir: path}
+		if info, err := os.Stat(path + ".zip"); err == nil {
+			e.size = info.Size()
+			e.used = info.ModTime()
+		}
+		size, err := dirSize(path)
+		if err != nil {
+			return err
+		}
+		e.size += size
+		entries = append(entries, e)
+		return filepath.SkipDir
+	})
+	return entries, err
+}
+
+func dirSize(dir string) (size int64, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// Size returns the total size in bytes of the modules in the cache.
+func (c *Disk) Size() (int64, error) {
+	entries, err := c.entries()
+	var size int64
+	for _, e := range entries {
+		size += e.size
+	}
+	return size, err
+}
+
+// GC removes the least recently used modules from the cache until the size
+// of the remaining modules is at most maxSize bytes.
+func (c *Disk) GC(maxSize int64) error {
+	return c.gc(maxSize, "")
+}
+
+// gc is like GC, but never removes the module stored in keep.
+func (c *Disk) gc(maxSize int64, keep string) error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.size
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].used.Before(entries[j].used)
+	})
+	for _, e := range entries {
+		if size <= maxSize {
+			break
+		}
+		if e.dir == keep {
+			continue
+		}
+		if err := remove(e.dir); err != nil {
+			return err
+		}
+		size -= e.size
+	}
+	return nil
+}
+
+// Clean removes all modules from the cache.
+func (c *Disk) Clean() error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := remove(e.dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove removes the module stored in dir. The directory is moved out of
+// place first, so that concurrent processes never observe a partially
+// removed module.
+func remove(dir string) error {
+	if err := os.Remove(dir + ".zip"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "tmp-")
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(dir, filepath.Join(tmp, "module")); err != nil && !os.IsNotExist(err) {
+		os.Remove(tmp)
+		return err
+	}
+	return os.RemoveAll(tmp)
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modcache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-quicktest/qt"
+	"github.com/opencontainers/go-digest"
+
+	"cuelang.org/go/internal/mod/module"
+	"cuelang.org/go/internal/mod/zip"
+)
+
+func TestDisk(t *testing.T) {
+	c := NewDisk(t.TempDir())
+	a := module.MustNewVersion("example.com/a@v0", "v0.1.0")
+	b := module.MustNewVersion("example.com/b@v0", "v0.2.0")
+
+	_, ok := c.Dir(a)
+	qt.Assert(t, qt.IsFalse(ok))
+
+	dirA, err := c.Put(a, bytes.NewReader(moduleZip(t, a)))
+	qt.Assert(t, qt.IsNil(err))
+	data, err := os.ReadFile(filepath.Join(dirA, "x.cue"))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.Equals(string(data), "package x\n"))
+
+	dirB, err := c.Put(b, bytes.NewReader(moduleZip(t, b)))
+	qt.Assert(t, qt.IsNil(err))
+
+	dir, ok := c.Dir(a)
+	qt.Assert(t, qt.IsTrue(ok))
+	qt.Assert(t, qt.Equals(dir, dirA))
+
+	// Mark b as used less recently than a.
+	old := time.Now().Add(-time.Hour)
+	qt.Assert(t, qt.IsNil(os.Chtimes(dirB+".zip", old, old)))
+
+	size, err := c.Size()
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.IsNil(c.GC(size)))
+	qt.Assert(t, qt.IsTrue(exists(dirB)))
+
+	qt.Assert(t, qt.IsNil(c.GC(size-1)))
+	qt.Assert(t, qt.IsFalse(exists(dirB)))
+	qt.Assert(t, qt.IsFalse(exists(dirB+".zip")))
+	qt.Assert(t, qt.IsTrue(exists(dirA)))
+
+	qt.Assert(t, qt.IsNil(c.Clean()))
+	_, ok = c.Dir(a)
+	qt.Assert(t, qt.IsFalse(ok))
+	size, err = c.Size()
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.Equals(size, int64(0)))
+}
+
+func TestDiskMaxSize(t *testing.T) {
+	c := NewDisk(t.TempDir())
+	c.MaxSize = 1
+	a := module.MustNewVersion("example.com/a@v0", "v0.1.0")
+	b := module.MustNewVersion("example.com/b@v0", "v0.2.0")
+
+	_, err := c.Put(a, bytes.NewReader(moduleZip(t, a)))
+	qt.Assert(t, qt.IsNil(err))
+	// The module just stored is never removed.
+	_, ok := c.Dir(a)
+	qt.Assert(t, qt.IsTrue(ok))
+
+	_, err = c.Put(b, bytes.NewReader(moduleZip(t, b)))
+	qt.Assert(t, qt.IsNil(err))
+	_, ok = c.Dir(a)
+	qt.Assert(t, qt.IsFalse(ok))
+	_, ok = c.Dir(b)
+	qt.Assert(t, qt.IsTrue(ok))
+}
+
+func TestDiskVerify(t *testing.T) {
+	c := NewDisk(t.TempDir())
+	a := module.MustNewVersion("example.com/a@v0", "v0.1.0")
+	data := moduleZip(t, a)
+	d := digest.FromBytes(data)
+
+	dir, err := c.Put(a, bytes.NewReader(data))
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.IsNil(c.Verify(a, d)))
+	qt.Assert(t, qt.ErrorMatches(c.Verify(a, digest.FromString("other")),
+		`cached module example.com/a@v0.1.0 does not match digest sha256:.*`))
+
+	// The extracted contents are checked as well as the zip archive.
+	file := filepath.Join(dir, "x.cue")
+	qt.Assert(t, qt.IsNil(os.Chmod(file, 0o666)))
+	qt.Assert(t, qt.IsNil(os.WriteFile(file, []byte("package y\n"), 0o666)))
+	qt.Assert(t, qt.ErrorMatches(c.Verify(a, d),
+		`cached file x.cue of module example.com/a@v0.1.0 does not match digest sha256:.*`))
+
+	qt.Assert(t, qt.IsNil(os.WriteFile(file, []byte("package x\n"), 0o666)))
+	qt.Assert(t, qt.IsNil(c.Verify(a, d)))
+	qt.Assert(t, qt.IsNil(os.WriteFile(filepath.Join(dir, "y.cue"), nil, 0o666)))
+	qt.Assert(t, qt.ErrorMatches(c.Verify(a, d),
+		`cached module example.com/a@v0.1.0 has files not in digest sha256:.*`))
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func moduleZip(t *testing.T, m module.Version) []byte {
+	dir := t.TempDir()
+	files := map[string]string{
+		"cue.mod/module.cue": "module: \"" + m.Path() + "\"\n",
+		"x.cue":              "package x\n",
+	}
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		qt.Assert(t, qt.IsNil(os.MkdirAll(filepath.Dir(name), 0o777)))
+		qt.Assert(t, qt.IsNil(os.WriteFile(name, []byte(data), 0o666)))
+	}
+	var buf bytes.Buffer
+	qt.Assert(t, qt.IsNil(zip.CreateFromDir(&buf, m, dir)))
+	return buf.Bytes()
+}
@@ -36,6 +36,11 @@ type File struct {
 	Module   string          `json:"module"`
 	Language Language        `json:"language"`
 	Deps     map[string]*Dep `json:"deps,omitempty"`
+	Export   Export          `json:"export,omitempty"`
+	Fmt      Fmt             `json:"fmt,omitempty"`
+
+	Signatures Signatures `json:"signatures,omitempty"`
+
 	versions []module.Version
 }
 
@@ -43,6 +48,53 @@ type Language struct {
 	Version string `json:"version"`
 }
 
+// Export holds settings for files generated by "cue export".
+type Export struct {
+	// Header is a template for a comment written at the start of
+	// exported files.
+	Header string `json:"header,omitempty"`
+}
+
+// Fmt holds settings for "cue fmt".
+type Fmt struct {
+	// Indent is the number of spaces used for indentation, or zero to
+	// indent with tabs.
+	Indent int `json:"indent,omitempty"`
+
+	// Simplify is the level of simplification, as for
+	// format.SimplifyLevel.
+	Simplify int `json:"simplify,omitempty"`
+
+	// MaxBlankLines is the maximum number of consecutive blank lines,
+	// or nil for the default.
+	MaxBlankLines *int `json:"maxBlankLines,omitempty"`
+
+	// TrailingComma reports whether to write a comma after the last
+	// field of structs written on a single line.
+	TrailingComma bool `json:"trailingComma,omitempty"`
+
+	// LineWidth is the column width beyond which disjunctions and
+	// call arguments are wrapped, or zero to not wrap them.
+	LineWidth int `json:"lineWidth,omitempty"`
+
+	// Sort reports whether to sort imports and top-level fields.
+	Sort bool `json:"sort,omitempty"`
+
+	// FieldOrder holds the names of the fields that come first, in
+	// this order, when sorting fields.
+	FieldOrder []string `json:"fieldOrder,omitempty"`
+}
+
+// Signatures holds the policy for the signatures of modules downloaded
+// from a registry.
+type Signatures struct {
+	// Keys holds the public keys that are trusted to sign modules.
+	Keys []string `json:"keys,omitempty"`
+
+	// Required reports whether modules must be signed with one of Keys.
+	Required bool `json:"required,omitempty"`
+}
+
 type Dep struct {
 	Version string `json:"v"`
 	Default bool   `json:"default,omitempty"`
@@ -50,6 +102,8 @@ type Dep struct {
 
 type noDepsFile struct {
 	Module string `json:"module"`
+	Export Export `json:"export,omitempty"`
+	Fmt    Fmt    `json:"fmt,omitempty"`
 }
 
 var (
@@ -85,8 +139,8 @@ func Parse(modfile []byte, filename string) (*File, error) {
 }
 
 // ParseLegacy parses the legacy version of the module file
-// that only supports the single field "module" and ignores all other
-// fields.
+// that only supports the fields "module", "export" and "fmt" and
+// ignores all other fields.
 func ParseLegacy(modfile []byte, filename string) (*File, error) {
 	v := moduleSchema().Context().CompileBytes(modfile, cue.Filename(filename))
 	if err := v.Err(); err != nil {
@@ -98,6 +152,8 @@ func ParseLegacy(modfile []byte, filename string) (*File, error) {
 	}
 	return &File{
 		Module: f.Module,
+		Export: f.Export,
+		Fmt:    f.Fmt,
 	}, nil
 }
 
@@ -149,6 +149,92 @@ cue: lang: "xxx"
 	want: &File{
 		Module: "foo.com/bar",
 	},
+}, {
+	testName: "ExportHeader",
+	parse:    Parse,
+	data: `
+module: "foo.com/bar@v0"
+export: header: "Code generated by cue export; DO NOT EDIT."
+`,
+	want: &File{
+		Module: "foo.com/bar@v0",
+		Export: Export{
+			Header: "Code generated by cue export; DO NOT EDIT.",
+		},
+	},
+}, {
+	testName: "LegacyExportHeader",
+	parse:    ParseLegacy,
+	data: `
+module: "foo.com/bar"
+export: header: "generated"
+`,
+	want: &File{
+		Module: "foo.com/bar",
+		Export: Export{
+			Header: "generated",
+		},
+	},
+}, {
+	testName: "LegacyFmt",
+	parse:    ParseLegacy,
+	data: `
+module: "foo.com/bar"
+fmt: {
+	indent:        2
+	simplify:      1
+	maxBlankLines: 0
+	trailingComma: true
+	lineWidth:     100
+	sort:          true
+	fieldOrder: ["name", "kind"]
+}
+`,
+	want: &File{
+		Module: "foo.com/bar",
+		Fmt: Fmt{
+			Indent:        2,
+			Simplify:      1,
+			MaxBlankLines: new(int),
+			TrailingComma: true,
+			LineWidth:     100,
+			Sort:          true,
+			FieldOrder:    []string{"name", "kind"},
+		},
+	},
+}, {
+	testName: "Signatures",
+	parse:    Parse,
+	data: `
+module: "foo.com/bar@v0"
+signatures: {
+	keys: ["ed25519:MCowBQYDK2VwAyEA"]
+	required: true
+}
+`,
+	want: &File{
+		Module: "foo.com/bar@v0",
+		Signatures: Signatures{
+			Keys:     []string{"ed25519:MCowBQYDK2VwAyEA"},
+			Required: true,
+		},
+	},
+}, {
+	testName: "InvalidSignatureKey",
+	parse:    Parse,
+	data: `
+module: "foo.com/bar@v0"
+signatures: keys: ["rsa:AAAA"]
+`,
+	wantError: `signatures.keys.0: invalid value "rsa:AAAA" \(out of bound =~"\^ed25519:"\)(.|\n)*`,
+}, {
+	testName: "InvalidFmtSimplify",
+	parse:    Parse,
+	data: `
+module: "foo.com/bar@v0"
+fmt: simplify: 3
+`,
+	wantError: `fmt.simplify: 3 errors in empty disjunction:(.|\n)*`,
 }}
 
 func TestParse(t *testing.T) {
@@ -46,6 +46,60 @@ let unimplemented = 1&2
 	// deps holds dependency information for modules, keyed by module path.
 	deps?: [#Module]: #Dep
 
+	// export holds settings for files generated by "cue export".
+	export?: {
+		// header is a template for a comment written at the start
+		// of exported files. See "cue help export" for details.
+		header?: string
+	}
+
+	// fmt holds settings for "cue fmt". See "cue help fmt" for
+	// details.
+	fmt?: {
+		// indent is the number of spaces used for indentation,
+		// or 0 to indent with tabs.
+		indent?: int & >=0
+
+		// simplify is the level of simplification: 0 for none,
+		// 1 to remove unnecessary quotes from labels, and 2 to also
+		// collapse structs with a single field.
+		simplify?: 0 | 1 | 2
+
+		// maxBlankLines is the maximum number of consecutive
+		// blank lines.
+		maxBlankLines?: int & >=0
+
+		// trailingComma specifies whether to write a comma after
+		// the last field of structs written on a single line.
+		trailingComma?: bool
+
+		// lineWidth is the column width beyond which disjunctions
+		// and call arguments are wrapped, or 0 to not wrap them.
+		lineWidth?: int & >=0
+
+		// sort specifies whether to sort imports and top-level
+		// fields.
+		sort?: bool
+
+		// fieldOrder holds the names of the fields that come
+		// first, in this order, when sorting fields.
+		fieldOrder?: [...string]
+	}
+
+	// signatures specifies which signatures are accepted for
+	// modules downloaded from a registry. See "cue help mod publish"
+	// for details.
+	signatures?: {
+		// keys holds the public keys, such as "ed25519:...", that
+		// are trusted to sign modules. A module that is signed must
+		// have a valid signature by one of these keys.
+		keys?: [...=~"^ed25519:"]
+
+		// required specifies whether modules must be signed with
+		// one of keys.
+		required?: bool
+	}
+
 	#Dep: {
 		// TODO use the below when mustexist is implemented.
 		// replace and replaceAll are mutually exclusive.
@@ -0,0 +1,281 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modload maintains the dependencies of a module: it reconciles the
+// dependencies recorded in its cue.mod directory with the packages that the
+// module imports, and vendors them.
+package modload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cuelabs.dev/go/oci/ociregistry"
+
+	"cuelang.org/go/internal/mod/modcache"
+	"cuelang.org/go/internal/mod/modfile"
+	"cuelang.org/go/internal/mod/modlock"
+	"cuelang.org/go/internal/mod/modregistry"
+	"cuelang.org/go/internal/mod/modsign"
+	"cuelang.org/go/internal/mod/module"
+	"cuelang.org/go/internal/mod/mvs"
+	"cuelang.org/go/internal/mod/semver"
+)
+
+const (
+	modDir     = "cue.mod"
+	moduleFile = "module.cue"
+	vendorDir  = "vendor"
+)
+
+// A mainModule holds the module being worked on.
+type mainModule struct {
+	root     string // root directory of the module
+	filename string // path of the module file
+	data     []byte // contents of the module file
+	file     *modfile.File
+
+	// basePath holds the module path without its major version suffix.
+	basePath string
+}
+
+func readMainModule(root string) (*mainModule, error) {
+	filename := filepath.Join(root, modDir, moduleFile)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	mf, err := modfile.ParseNonStrict(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	if mf.Module == "" {
+		return nil, fmt.Errorf("no module path in %s", filename)
+	}
+	basePath, _, ok := module.SplitPathVersion(mf.Module)
+	if !ok {
+		basePath = mf.Module
+	}
+	return &mainModule{
+		root:     root,
+		filename: filename,
+		data:     data,
+		file:     mf,
+		basePath: basePath,
+	}, nil
+}
+
+// readLock reads the lock file of the module rooted at root.
+func readLock(root string) (*modlock.File, error) {
+	filename := filepath.Join(root, modDir, modlock.FileName)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return modlock.Parse(data, filename)
+}
+
+// registry fetches module information and contents from a registry,
+// storing contents in a cache.
+type registry struct {
+	client *modregistry.Client
+	cache  modcache.Cache
+
+	// policy determines the signatures accepted for downloaded modules.
+	policy *modsign.Policy
+
+	mu       sync.Mutex
+	modFiles map[module.Version]*modfile.File
+	verified map[module.Version]bool
+}
+
+func newRegistry(mm *mainModule, reg ociregistry.Interface, cache modcache.Cache) (*registry, error) {
+	client, err := modregistry.NewClient(reg)
+	if err != nil {
+		return nil, err
+	}
+	return &registry{
+		client: client,
+		cache:  cache,
+		policy: &modsign.Policy{
+			Keys:     mm.file.Signatures.Keys,
+			Required: mm.file.Signatures.Required,
+		},
+		modFiles: map[module.Version]*modfile.File{},
+		verified: map[module.Version]bool{},
+	}, nil
+}
+
+// modFile returns the module file of m.
+func (r *registry) modFile(ctx context.Context, m module.Version) (*modfile.File, error) {
+	r.mu.Lock()
+	mf, ok := r.modFiles[m]
+	r.mu.Unlock()
+	if ok {
+		return mf, nil
+	}
+	rm, err := r.client.GetModule(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rm.ModuleFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mf, err = modfile.Parse(data, path.Join(m.Path(), modDir, moduleFile))
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.modFiles[m] = mf
+	r.mu.Unlock()
+	return mf, nil
+}
+
+// contents returns the directory holding the contents of m, downloading
+// them into the cache if needed. The signatures of m are checked against
+// the policy of the main module, even if m is in the cache, as the cache
+// may be shared with modules that have a different policy. A cached module
+// must then match the signed digest.
+func (r *registry) contents(ctx context.Context, m module.Version) (string, error) {
+	r.mu.Lock()
+	verified := r.verified[m] || r.policy.AcceptsAll()
+	r.mu.Unlock()
+	dir, ok := r.cache.Dir(m)
+	if ok && verified {
+		return dir, nil
+	}
+	rm, err := r.client.GetModule(ctx, m)
+	if err != nil {
+		return "", err
+	}
+	if !verified {
+		if err := rm.VerifySignatures(ctx, r.policy); err != nil {
+			return "", err
+		}
+		if ok {
+			// The signatures only cover the digest of the module, so
+			// check that the cached module has that digest.
+			if err := r.cache.Verify(m, rm.ZipDigest()); err != nil {
+				return "", err
+			}
+		}
+		r.mu.Lock()
+		r.verified[m] = true
+		r.mu.Unlock()
+	}
+	if ok {
+		return dir, nil
+	}
+	zr, err := rm.GetZip(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	return r.cache.Put(m, zr)
+}
+
+// buildList returns the modules selected by minimal version selection for
+// the given direct dependencies of the main module, sorted by path.
+func (r *registry) buildList(ctx context.Context, mm *mainModule, deps []module.Version) ([]module.Version, error) {
+	if len(deps) == 0 {
+		return nil, nil
+	}
+	list, err := mvs.BuildList[module.Version](deps, &mvsReqs{
+		ctx:  ctx,
+		main: mm,
+		reg:  r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	module.Sort(list)
+	return list, nil
+}
+
+// mvsReqs implements mvs.Reqs by fetching module files from a registry.
+type mvsReqs struct {
+	module.Versions
+	ctx  context.Context
+	main *mainModule
+	reg  *registry
+}
+
+// Required implements mvs.Reqs.Required.
+func (reqs *mvsReqs) Required(m module.Version) ([]module.Version, error) {
+	if m.Path() == reqs.main.file.Module {
+		return reqs.main.file.DepVersions(), nil
+	}
+	mf, err := reqs.reg.modFile(reqs.ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	return mf.DepVersions(), nil
+}
+
+// pkgSubdir reports whether the module m may contain the package with the
+// given import path, without any package qualifier, and if so, returns the
+// slash-separated directory of the package within the module.
+func pkgSubdir(m module.Version, importPath string) (string, bool) {
+	return pathSubdir(m.BasePath(), semver.Major(m.Version()), importPath)
+}
+
+// pathSubdir is like pkgSubdir for a module with the given base path and
+// major version.
+func pathSubdir(modBase, modMajor, importPath string) (string, bool) {
+	pkgBase, pkgMajor, ok := module.SplitPathVersion(importPath)
+	if !ok {
+		pkgBase = importPath
+	} else if pkgMajor != modMajor {
+		return "", false
+	}
+	switch {
+	case pkgBase == modBase:
+		return ".", true
+	case strings.HasPrefix(pkgBase, modBase+"/"):
+		return pkgBase[len(modBase)+1:], true
+	}
+	return "", false
+}
+
+// hasPackage reports whether dir holds any CUE files.
+func hasPackage(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if isCUEFile(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCUEFile(e fs.DirEntry) bool {
+	name := e.Name()
+	return e.Type().IsRegular() && strings.HasSuffix(name, ".cue") &&
+		!strings.HasPrefix(name, ".") && !strings.HasPrefix(name, "_")
+}
+
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, ociregistry.ErrNameUnknown)
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modload
+
+import (
+	"testing"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/internal/mod/module"
+)
+
+func TestPkgSubdir(t *testing.T) {
+	tests := []struct {
+		mod        string
+		version    string
+		importPath string
+		subdir     string
+		ok         bool
+	}{
+		{"example.com@v0", "v0.0.1", "example.com", ".", true},
+		{"example.com@v0", "v0.0.1", "example.com/foo/bar", "foo/bar", true},
+		{"example.com@v0", "v0.0.1", "example.com/foo@v0", "foo", true},
+		{"example.com@v0", "v0.0.1", "example.com/foo@v1", "", false},
+		{"example.com@v0", "v0.0.1", "example.com.x/foo", "", false},
+		{"example.com/foo@v1", "v1.0.0", "example.com", "", false},
+	}
+	for _, test := range tests {
+		m := module.MustNewVersion(test.mod, test.version)
+		subdir, ok := pkgSubdir(m, test.importPath)
+		qt.Check(t, qt.Equals(subdir, test.subdir), qt.Commentf("%s in %v", test.importPath, m))
+		qt.Check(t, qt.Equals(ok, test.ok), qt.Commentf("%s in %v", test.importPath, m))
+	}
+}
+
+func TestLatest(t *testing.T) {
+	qt.Assert(t, qt.Equals(latest(nil), ""))
+	qt.Assert(t, qt.Equals(latest([]string{"v0.1.0", "v0.3.0", "v0.2.0"}), "v0.3.0"))
+	qt.Assert(t, qt.Equals(latest([]string{"v0.1.0", "v0.2.0-alpha"}), "v0.1.0"))
+	qt.Assert(t, qt.Equals(latest([]string{"v0.2.0-alpha", "v0.2.0-beta"}), "v0.2.0-beta"))
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modload
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cuelabs.dev/go/oci/ociregistry"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/internal/mod/modcache"
+	"cuelang.org/go/internal/mod/module"
+	"cuelang.org/go/internal/mod/semver"
+)
+
+// A Risk classifies the risk of upgrading a dependency.
+type Risk int
+
+const (
+	// RiskNone indicates that the schemas of every package of the current
+	// version are subsumed by those of the newer version, so that any
+	// value accepted before is still accepted.
+	RiskNone Risk = iota
+
+	// RiskBreaking indicates that the newer version removes a package or
+	// no longer accepts some values accepted by the current version.
+	RiskBreaking
+
+	// RiskUnknown indicates that the versions could not be compared, for
+	// instance because a package fails to build.
+	RiskUnknown
+)
+
+var riskStrings = map[Risk]string{
+	RiskNone:     "compatible",
+	RiskBreaking: "breaking",
+	RiskUnknown:  "unknown",
+}
+
+func (r Risk) String() string {
+	if s, ok := riskStrings[r]; ok {
+		return s
+	}
+	return fmt.Sprintf("Risk(%d)", int(r))
+}
+
+// An Update describes a newer version of a dependency.
+type Update struct {
+	Current module.Version
+	Latest  module.Version
+
+	Risk Risk
+
+	// Errors holds the reasons for the risk, if any. For a breaking
+	// upgrade, each error describes a package that was removed or a field
+	// that no longer accepts a value accepted by the current version.
+	Errors []error
+}
+
+// Outdated reports the dependencies of the module rooted at dir for which
+// the registry holds a newer version with the same major version, sorted
+// by module path. Pre-releases are only considered for dependencies that
+// use a pre-release.
+//
+// The packages of each newer version are compared with those of the
+// current version to classify the risk of upgrading.
+func Outdated(ctx context.Context, dir string, reg ociregistry.Interface, cache modcache.Cache) ([]Update, error) {
+	mm, err := readMainModule(dir)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRegistry(mm, reg, cache)
+	if err != nil {
+		return nil, err
+	}
+	deps := mm.file.DepVersions()
+	module.Sort(deps)
+
+	var updates []Update
+	for _, m := range deps {
+		versions, err := r.client.ModuleVersions(ctx, m.Path())
+		if err != nil && !isNotExist(err) {
+			return nil, err
+		}
+		if semver.Prerelease(m.Version()) == "" {
+			versions = releases(versions)
+		}
+		v := latest(versions)
+		if v == "" || semver.Compare(v, m.Version()) <= 0 {
+			continue
+		}
+		newer, err := module.NewVersion(m.Path(), v)
+		if err != nil {
+			return nil, err
+		}
+		u := Update{Current: m, Latest: newer}
+		u.Risk, u.Errors = r.compare(ctx, reg, m, newer)
+		updates = append(updates, u)
+	}
+	return updates, nil
+}
+
+func releases(versions []string) []string {
+	var a []string
+	for _, v := range versions {
+		if semver.Prerelease(v) == "" {
+			a = append(a, v)
+		}
+	}
+	return a
+}
+
+// compare classifies the risk of upgrading from module version old to
+// newer by checking that the schemas of each package of old are subsumed
+// by those of newer.
+func (r *registry) compare(ctx context.Context, reg ociregistry.Interface, old, newer module.Version) (Risk, []error) {
+	oldDir, err := r.contents(ctx, old)
+	if err != nil {
+		return RiskUnknown, []error{err}
+	}
+	newDir, err := r.contents(ctx, newer)
+	if err != nil {
+		return RiskUnknown, []error{err}
+	}
+	pkgs, err := packageDirs(oldDir)
+	if err != nil {
+		return RiskUnknown, []error{err}
+	}
+
+	cuectx := cuecontext.New()
+	risk := RiskNone
+	var errs []error
+	for _, pkg := range pkgs {
+		importPath := old.BasePath()
+		if pkg != "." {
+			importPath = path.Join(importPath, pkg)
+		}
+		if !hasPackage(filepath.Join(newDir, filepath.FromSlash(pkg))) {
+			risk = RiskBreaking
+			errs = append(errs, fmt.Errorf("package %s was removed", importPath))
+			continue
+		}
+		oldV, err := r.buildPackage(cuectx, reg, oldDir, pkg)
+		if err != nil {
+			return RiskUnknown, []error{fmt.Errorf("cannot build %s in %v: %v", importPath, old, err)}
+		}
+		newV, err := r.buildPackage(cuectx, reg, newDir, pkg)
+		if err != nil {
+			return RiskUnknown, []error{fmt.Errorf("cannot build %s in %v: %v", importPath, newer, err)}
+		}
+		if err := newV.Subsume(oldV, cue.AllErrors(0)); err != nil {
+			risk = RiskBreaking
+			for _, e := range errors.Errors(err) {
+				errs = append(errs, fmt.Errorf("%s: %v", importPath, e))
+			}
+		}
+	}
+	return risk, errs
+}
+
+// buildPackage builds the package in the directory pkg, relative to the
+// root of the module stored in dir.
+func (r *registry) buildPackage(ctx *cue.Context, reg ociregistry.Interface, dir, pkg string) (cue.Value, error) {
+	arg := "./" + pkg
+	if pkg == "." {
+		arg = "."
+	}
+	insts := load.Instances([]string{arg}, &load.Config{
+		Dir:      dir,
+		Registry: reg,
+		ModCache: r.cache,
+	})
+	if err := insts[0].Err; err != nil {
+		return cue.Value{}, err
+	}
+	v := ctx.BuildInstance(insts[0])
+	return v, v.Err()
+}
+
+// packageDirs returns the slash-separated directories, relative to the
+// module root dir, holding CUE packages.
+func packageDirs(dir string) ([]string, error) {
+	var pkgs []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir {
+			name := d.Name()
+			if name == modDir || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+		}
+		if hasPackage(p) {
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			pkgs = append(pkgs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return pkgs, err
+}
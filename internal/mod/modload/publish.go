@@ -0,0 +1,115 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"cuelabs.dev/go/oci/ociregistry"
+	"github.com/opencontainers/go-digest"
+
+	"cuelang.org/go/internal/mod/modcache"
+	"cuelang.org/go/internal/mod/modsign"
+	"cuelang.org/go/internal/mod/module"
+	"cuelang.org/go/internal/mod/semver"
+	modzip "cuelang.org/go/internal/mod/zip"
+)
+
+// Publish uploads the module rooted at dir to the registry as the given
+// version, which must be a canonical semantic version with the major
+// version of the module path, such as v1.2.3 for example.com/foo@v1. It
+// returns the published module version and the digest of its archive.
+//
+// The module must be tidy, as with Tidy, so that its module file lists
+// every module in the build. A version that is already published is not
+// replaced.
+//
+// If signingKey is not empty, the archive is signed with it, as with
+// modsign.Bundle.Sign, and the signature is stored alongside the module.
+func Publish(ctx context.Context, dir, version string, reg ociregistry.Interface, cache modcache.Cache, signingKey string) (module.Version, digest.Digest, error) {
+	mm, err := readMainModule(dir)
+	if err != nil {
+		return module.Version{}, "", err
+	}
+	if _, _, ok := module.SplitPathVersion(mm.file.Module); !ok {
+		return module.Version{}, "", fmt.Errorf("module path %q in %s has no major version suffix, such as %s@%s",
+			mm.file.Module, mm.filename, mm.file.Module, majorOf(version))
+	}
+	mv, err := module.NewVersion(mm.file.Module, version)
+	if err != nil {
+		return module.Version{}, "", err
+	}
+	r, err := newRegistry(mm, reg, cache)
+	if err != nil {
+		return module.Version{}, "", err
+	}
+
+	deps := mm.file.DepVersions()
+	module.Sort(deps)
+	list, err := r.tidyList(ctx, mm)
+	if err != nil {
+		return module.Version{}, "", err
+	}
+	if !equalVersions(list, deps) {
+		return module.Version{}, "", fmt.Errorf("dependencies in %s are not tidy; run 'cue mod tidy'", mm.filename)
+	}
+
+	versions, err := r.client.ModuleVersions(ctx, mv.Path())
+	if err != nil && !isNotExist(err) {
+		return module.Version{}, "", err
+	}
+	for _, v := range versions {
+		if v == version {
+			return module.Version{}, "", fmt.Errorf("%v is already published", mv)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := modzip.CreateFromDir(&buf, mv, mm.root); err != nil {
+		return module.Version{}, "", err
+	}
+	data := buf.Bytes()
+	zipDigest := digest.FromBytes(data)
+
+	// Sign the archive before uploading anything, so that an invalid key
+	// does not leave an unsigned module behind.
+	var sigs *modsign.Bundle
+	if signingKey != "" {
+		sigs = &modsign.Bundle{}
+		if err := sigs.Sign(mv, zipDigest, signingKey); err != nil {
+			return module.Version{}, "", err
+		}
+	}
+	if err := r.client.PutModule(ctx, mv, bytes.NewReader(data), int64(len(data))); err != nil {
+		return module.Version{}, "", err
+	}
+	if sigs != nil {
+		if err := r.client.PutSignatures(ctx, mv, sigs); err != nil {
+			return module.Version{}, "", err
+		}
+	}
+	return mv, zipDigest, nil
+}
+
+// majorOf returns the major version of version, or v0 if version is
+// invalid.
+func majorOf(version string) string {
+	if m := semver.Major(version); m != "" {
+		return m
+	}
+	return "v0"
+}
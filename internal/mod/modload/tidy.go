@@ -0,0 +1,358 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modload
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cuelabs.dev/go/oci/ociregistry"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/mod/modcache"
+	"cuelang.org/go/internal/mod/modlock"
+	"cuelang.org/go/internal/mod/module"
+	"cuelang.org/go/internal/mod/semver"
+)
+
+// Tidy updates the module file, cue.mod/module.cue, and the lock file,
+// cue.mod/module.lock, of the module rooted at dir, so that they record
+// exactly the modules needed to build the packages of the module.
+//
+// Packages imported by the module that are not provided by any of its
+// dependencies are resolved to the latest version of the module with the
+// longest path that provides them. Dependencies that provide none of the
+// imported packages, directly or indirectly, are removed. The module file
+// lists all modules in the resulting build list, and the lock file holds
+// the hashes of their contents.
+func Tidy(ctx context.Context, dir string, reg ociregistry.Interface, cache modcache.Cache) error {
+	mm, err := readMainModule(dir)
+	if err != nil {
+		return err
+	}
+	r, err := newRegistry(mm, reg, cache)
+	if err != nil {
+		return err
+	}
+	list, err := r.tidyList(ctx, mm)
+	if err != nil {
+		return err
+	}
+
+	var locked []modlock.Module
+	for _, m := range list {
+		dir, err := r.contents(ctx, m)
+		if err != nil {
+			return err
+		}
+		h, err := modlock.Hash(os.DirFS(dir))
+		if err != nil {
+			return fmt.Errorf("cannot hash %v: %v", m, err)
+		}
+		locked = append(locked, modlock.Module{Version: m, Hash: h})
+	}
+
+	data, err := mm.formatWithDeps(list)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(mm.filename, data, 0o666); err != nil {
+		return err
+	}
+	lockFile := filepath.Join(mm.root, modDir, modlock.FileName)
+	return os.WriteFile(lockFile, modlock.New(locked).Format(), 0o666)
+}
+
+// tidyList returns the build list that Tidy records for the main module,
+// sorted by module path.
+func (r *registry) tidyList(ctx context.Context, mm *mainModule) ([]module.Version, error) {
+	imports, err := moduleImports(mm)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := map[string]module.Version{}
+	for _, m := range mm.file.DepVersions() {
+		deps[m.Path()] = m
+	}
+	var list []module.Version
+	providers := map[string]module.Version{}
+	for {
+		list, err = r.buildList(ctx, mm, depList(deps))
+		if err != nil {
+			return nil, err
+		}
+		var missing []string
+		for _, p := range imports {
+			m, ok, err := r.provider(ctx, mm, list, p)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				missing = append(missing, p)
+				continue
+			}
+			providers[p] = m
+		}
+		if len(missing) == 0 {
+			break
+		}
+		for _, p := range missing {
+			m, err := r.latestProvider(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+			if cur, ok := deps[m.Path()]; ok && semver.Compare(cur.Version(), m.Version()) >= 0 {
+				return nil, fmt.Errorf("module %v does not provide package %s", cur, p)
+			}
+			deps[m.Path()] = m
+		}
+	}
+
+	// Drop the dependencies that are not needed for any imported package.
+	// Using the selected versions of the needed ones as direct dependencies
+	// keeps their versions as they are.
+	needed := map[string]module.Version{}
+	for _, m := range providers {
+		needed[m.Path()] = m
+	}
+	return r.buildList(ctx, mm, depList(needed))
+}
+
+func depList(deps map[string]module.Version) []module.Version {
+	list := make([]module.Version, 0, len(deps))
+	for _, m := range deps {
+		list = append(list, m)
+	}
+	module.Sort(list)
+	return list
+}
+
+// moduleImports returns the import paths of the packages outside the main
+// module and the standard library that the main module imports, without
+// their package qualifiers.
+func moduleImports(mm *mainModule) ([]string, error) {
+	seen := map[string]bool{}
+	err := filepath.WalkDir(mm.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p == mm.root {
+				return nil
+			}
+			name := d.Name()
+			if name == modDir || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, modDir)); err == nil {
+				// A nested module.
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isCUEFile(d) {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(p, data, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range f.Imports {
+			ip, err := literal.Unquote(spec.Path.Value)
+			if err != nil {
+				return fmt.Errorf("%s: invalid import path %s", p, spec.Path.Value)
+			}
+			if i := strings.LastIndexByte(ip, ':'); i >= 0 {
+				ip = ip[:i]
+			}
+			if err := module.CheckImportPath(ip); err != nil {
+				return fmt.Errorf("%s: %v", p, err)
+			}
+			if isStdlib(ip) {
+				continue
+			}
+			if _, ok := pathSubdir(mm.basePath, "", ip); ok {
+				continue
+			}
+			seen[ip] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	imports := make([]string, 0, len(seen))
+	for p := range seen {
+		imports = append(imports, p)
+	}
+	sort.Strings(imports)
+	return imports, nil
+}
+
+// isStdlib reports whether the import path refers to a package of the
+// standard library, whose first path element never contains a dot.
+func isStdlib(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// provider returns the module in list that provides the package with the
+// given import path, and reports whether there is one.
+func (r *registry) provider(ctx context.Context, mm *mainModule, list []module.Version, importPath string) (module.Version, bool, error) {
+	var found []module.Version
+	for _, m := range list {
+		sub, ok := pkgSubdir(m, importPath)
+		if !ok {
+			continue
+		}
+		dir, err := r.contents(ctx, m)
+		if err != nil {
+			return module.Version{}, false, err
+		}
+		if hasPackage(filepath.Join(dir, filepath.FromSlash(sub))) {
+			found = append(found, m)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return module.Version{}, false, nil
+	case 1:
+		return found[0], true, nil
+	}
+	// An import path without a major version may be provided by several
+	// major versions of a module, one of which must be the default.
+	for _, m := range found {
+		if dep := mm.file.Deps[m.Path()]; dep != nil && dep.Default {
+			return m, true, nil
+		}
+	}
+	return module.Version{}, false, fmt.Errorf("ambiguous import %s: provided by %v", importPath, found)
+}
+
+// latestProvider returns the latest version of the module with the longest
+// path that provides the package with the given import path.
+func (r *registry) latestProvider(ctx context.Context, importPath string) (module.Version, error) {
+	base, major, ok := module.SplitPathVersion(importPath)
+	if !ok {
+		base, major = importPath, ""
+	}
+	for prefix := base; prefix != "." && prefix != "/"; prefix = path.Dir(prefix) {
+		mpath := prefix
+		if major != "" {
+			mpath += "@" + major
+		}
+		versions, err := r.client.ModuleVersions(ctx, mpath)
+		if err != nil && !isNotExist(err) {
+			return module.Version{}, err
+		}
+		v := latest(versions)
+		if v == "" {
+			continue
+		}
+		m, err := module.NewVersion(prefix, v)
+		if err != nil {
+			return module.Version{}, err
+		}
+		dir, err := r.contents(ctx, m)
+		if err != nil {
+			return module.Version{}, err
+		}
+		sub, _ := pathSubdir(prefix, "", base)
+		if hasPackage(filepath.Join(dir, filepath.FromSlash(sub))) {
+			return m, nil
+		}
+	}
+	return module.Version{}, fmt.Errorf("cannot find module providing package %s", importPath)
+}
+
+// latest returns the latest of the given versions, preferring releases over
+// pre-releases, or "" if there are none.
+func latest(versions []string) string {
+	var max string
+	for _, v := range versions {
+		switch {
+		case max == "",
+			semver.Prerelease(max) != "" && semver.Prerelease(v) == "",
+			(semver.Prerelease(max) == "") == (semver.Prerelease(v) == "") && semver.Compare(v, max) > 0:
+			max = v
+		}
+	}
+	return max
+}
+
+// formatWithDeps returns the contents of the module file with its
+// dependencies replaced by the modules in list.
+func (mm *mainModule) formatWithDeps(list []module.Version) ([]byte, error) {
+	f, err := parser.ParseFile(mm.filename, mm.data, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var deps ast.Decl
+	if len(list) > 0 {
+		s := &ast.StructLit{}
+		for _, m := range list {
+			fields := []interface{}{ast.NewIdent("v"), ast.NewString(m.Version())}
+			if dep := mm.file.Deps[m.Path()]; dep != nil && dep.Default {
+				fields = append(fields, ast.NewIdent("default"), ast.NewBool(true))
+			}
+			field := &ast.Field{
+				Label: ast.NewString(m.Path()),
+				Value: ast.NewStruct(fields...),
+			}
+			ast.SetRelPos(field, token.Newline)
+			s.Elts = append(s.Elts, field)
+		}
+		s.Rbrace = token.Newline.Pos()
+		deps = &ast.Field{Label: ast.NewIdent("deps"), Value: s}
+	}
+
+	// Replace the first declaration of deps and remove all others.
+	var decls []ast.Decl
+	for _, d := range f.Decls {
+		if field, ok := d.(*ast.Field); ok {
+			if name, _, _ := ast.LabelName(field.Label); name == "deps" {
+				if deps != nil {
+					ast.SetRelPos(deps, token.NewSection)
+					ast.SetComments(deps, ast.Comments(field))
+					decls = append(decls, deps)
+					deps = nil
+				}
+				continue
+			}
+		}
+		decls = append(decls, d)
+	}
+	if deps != nil {
+		ast.SetRelPos(deps, token.NewSection)
+		decls = append(decls, deps)
+	}
+	f.Decls = decls
+	return format.Node(f)
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"cuelabs.dev/go/oci/ociregistry"
+
+	"cuelang.org/go/internal/mod/modcache"
+	"cuelang.org/go/internal/mod/modlock"
+	"cuelang.org/go/internal/mod/module"
+)
+
+// Vendor copies the contents of all dependencies of the module rooted at
+// dir into its cue.mod/vendor directory, replacing any previous contents.
+// Each dependency is stored in a directory named after its path and
+// version, such as cue.mod/vendor/example.com/foo@v0.1.2.
+//
+// The module must be tidy, as with Tidy, and the contents of each
+// dependency must match the hash recorded in the lock file.
+func Vendor(ctx context.Context, dir string, reg ociregistry.Interface, cache modcache.Cache) error {
+	mm, err := readMainModule(dir)
+	if err != nil {
+		return err
+	}
+	lock, err := readLock(mm.root)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("no %s file; run 'cue mod tidy'", modlock.FileName)
+		}
+		return err
+	}
+	r, err := newRegistry(mm, reg, cache)
+	if err != nil {
+		return err
+	}
+	deps := mm.file.DepVersions()
+	module.Sort(deps)
+	list, err := r.buildList(ctx, mm, deps)
+	if err != nil {
+		return err
+	}
+	if !equalVersions(list, deps) {
+		return fmt.Errorf("dependencies in %s are not tidy; run 'cue mod tidy'", mm.filename)
+	}
+
+	vendor := filepath.Join(mm.root, modDir, vendorDir)
+	tmp, err := os.MkdirTemp(filepath.Join(mm.root, modDir), vendorDir+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	for _, m := range list {
+		src, err := r.contents(ctx, m)
+		if err != nil {
+			return err
+		}
+		if err := lock.Verify(m, os.DirFS(src)); err != nil {
+			return err
+		}
+		if err := copyDir(filepath.Join(tmp, filepath.FromSlash(m.String())), src); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(vendor); err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return os.Rename(tmp, vendor)
+}
+
+func equalVersions(a, b []module.Version) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// copyDir copies the regular files in the directory src to dst, creating
+// dst as needed.
+func copyDir(dst, src string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o777)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(target, p)
+	})
+}
+
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modlock implements the module lock file, cue.mod/module.lock,
+// which records the exact version of every dependency of a module together
+// with a hash of its contents.
+//
+// Each line of a lock file holds a module path, including its major version
+// suffix, a version and a hash, separated by spaces:
+//
+//	example.com/foo@v0 v0.1.2 h1:cECPwQI3vAsnkjsG3Wppcnate5Mqzqx0Zn2lLyvXFxE=
+//
+// Blank lines and lines starting with # are ignored.
+package modlock
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"cuelang.org/go/internal/mod/module"
+	"cuelang.org/go/internal/mod/semver"
+)
+
+// FileName is the name of the lock file within the cue.mod directory.
+const FileName = "module.lock"
+
+// A File holds the contents of a lock file.
+type File struct {
+	// Modules holds the locked modules, sorted by path.
+	Modules []Module
+
+	filename string
+}
+
+// A Module is a locked module.
+type Module struct {
+	Version module.Version
+
+	// Hash holds the hash of the contents of the module, as computed by
+	// Hash.
+	Hash string
+}
+
+// Parse parses the contents of a lock file. The file name is used for
+// error messages.
+func Parse(data []byte, filename string) (*File, error) {
+	f := &File{filename: filename}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed line", filename, i+1)
+		}
+		v, err := module.NewVersion(fields[0], fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, i+1, err)
+		}
+		if v.Path() != fields[0] {
+			return nil, fmt.Errorf("%s:%d: no major version in %q", filename, i+1, fields[0])
+		}
+		if !strings.HasPrefix(fields[2], "h1:") {
+			return nil, fmt.Errorf("%s:%d: unknown hash %q", filename, i+1, fields[2])
+		}
+		f.Modules = append(f.Modules, Module{Version: v, Hash: fields[2]})
+	}
+	f.sort()
+	return f, nil
+}
+
+// New returns a lock file holding the given modules.
+func New(modules []Module) *File {
+	f := &File{
+		Modules:  append([]Module(nil), modules...),
+		filename: FileName,
+	}
+	f.sort()
+	return f
+}
+
+func (f *File) sort() {
+	sort.Slice(f.Modules, func(i, j int) bool {
+		a, b := f.Modules[i].Version, f.Modules[j].Version
+		if a.Path() != b.Path() {
+			return a.Path() < b.Path()
+		}
+		return semver.Compare(a.Version(), b.Version()) < 0
+	})
+}
+
+// Format returns the contents of f as written to a lock file.
+func (f *File) Format() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# This file is generated by \"cue mod tidy\". DO NOT EDIT.\n")
+	for _, m := range f.Modules {
+		fmt.Fprintf(&buf, "%s %s %s\n", m.Version.Path(), m.Version.Version(), m.Hash)
+	}
+	return buf.Bytes()
+}
+
+// Hash returns the recorded hash of the contents of module m and reports
+// whether f holds one.
+func (f *File) Hash(m module.Version) (string, bool) {
+	for _, e := range f.Modules {
+		if e.Version == m {
+			return e.Hash, true
+		}
+	}
+	return "", false
+}
+
+// Verify checks that the contents of module m, found in fsys, match the hash
+// recorded for m.
+func (f *File) Verify(m module.Version, fsys fs.FS) error {
+	want, ok := f.Hash(m)
+	if !ok {
+		return fmt.Errorf("%v is missing from %s", m, f.filename)
+	}
+	got, err := Hash(fsys)
+	if err != nil {
+		return fmt.Errorf("cannot hash %v: %v", m, err)
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %v:\n\tcontents: %s\n\t%s: %s", m, got, f.filename, want)
+	}
+	return nil
+}
+
+// Hash returns a hash of the regular files in fsys. The hash has the same
+// form as those in go.sum files, as computed by dirhash.Hash1.
+func Hash(fsys fs.FS) (string, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return fsys.Open(name)
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modlock
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-quicktest/qt"
+
+	"cuelang.org/go/internal/mod/module"
+)
+
+func TestParseFormat(t *testing.T) {
+	data := `# comment
+example.com/foo@v1 v1.2.3 h1:abc=
+
+bar.org@v0 v0.0.1 h1:def=
+`
+	f, err := Parse([]byte(data), "module.lock")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.DeepEquals(f.Modules, []Module{{
+		Version: module.MustNewVersion("bar.org@v0", "v0.0.1"),
+		Hash:    "h1:def=",
+	}, {
+		Version: module.MustNewVersion("example.com/foo@v1", "v1.2.3"),
+		Hash:    "h1:abc=",
+	}}))
+	qt.Assert(t, qt.Equals(string(f.Format()), `# This file is generated by "cue mod tidy". DO NOT EDIT.
+bar.org@v0 v0.0.1 h1:def=
+example.com/foo@v1 v1.2.3 h1:abc=
+`))
+
+	h, ok := f.Hash(module.MustNewVersion("bar.org@v0", "v0.0.1"))
+	qt.Assert(t, qt.IsTrue(ok))
+	qt.Assert(t, qt.Equals(h, "h1:def="))
+	_, ok = f.Hash(module.MustNewVersion("bar.org@v0", "v0.0.2"))
+	qt.Assert(t, qt.IsFalse(ok))
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{"foo.com@v0 v0.0.1", `module.lock:1: malformed line`},
+		{"\nfoo.com@v1 v0.0.1 h1:x=", `module.lock:2: mismatched major version suffix in "foo.com@v1" \(version v0.0.1\)`},
+		{"foo.com v0.0.1 h1:x=", `module.lock:1: no major version in "foo.com"`},
+		{"foo.com@v0 v0.0.1 h2:x=", `module.lock:1: unknown hash "h2:x="`},
+	}
+	for _, test := range tests {
+		_, err := Parse([]byte(test.data), "module.lock")
+		qt.Check(t, qt.ErrorMatches(err, test.err))
+	}
+}
+
+func TestHash(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cue.mod/module.cue": {Data: []byte(`module: "foo.com@v0"`)},
+		"x.cue":              {Data: []byte(`package x`)},
+	}
+	h, err := Hash(fsys)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.Equals(h, "h1:cECPwQI3vAsnkjsG3Wppcnate5Mqzqx0Zn2lLyvXFxE="))
+
+	m := module.MustNewVersion("foo.com@v0", "v0.0.1")
+	f := New([]Module{{Version: m, Hash: h}})
+	qt.Assert(t, qt.IsNil(f.Verify(m, fsys)))
+
+	fsys["x.cue"] = &fstest.MapFile{Data: []byte(`package y`)}
+	err = f.Verify(m, fsys)
+	qt.Assert(t, qt.ErrorMatches(err, `checksum mismatch for foo.com@v0.0.1:\n\tcontents: h1:.*\n\tmodule.lock: `+h))
+
+	err = f.Verify(module.MustNewVersion("foo.com@v0", "v0.0.2"), fsys)
+	qt.Assert(t, qt.ErrorMatches(err, `foo.com@v0.0.2 is missing from module.lock`))
+}
@@ -31,6 +31,7 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"cuelang.org/go/internal/mod/modfile"
+	"cuelang.org/go/internal/mod/modsign"
 	"cuelang.org/go/internal/mod/module"
 	modzip "cuelang.org/go/internal/mod/zip"
 )
@@ -87,21 +88,101 @@ func (c *Client) GetModule(ctx context.Context, m module.Version) (*Module, erro
 	return &Module{
 		client:   c,
 		repo:     repoName,
+		version:  m,
 		manifest: *manifest,
 	}, nil
 }
 
+// signatureTag returns the tag of the signatures of the given module
+// version. The tag is not a valid semantic version, so it is not reported
+// by ModuleVersions.
+func signatureTag(version string) string {
+	return version + ".sig"
+}
+
+// GetSignatures returns the signatures of the given module version.
+// It returns an error that satisfies errors.Is(ErrNotFound) if the
+// module version has no signatures.
+func (c *Client) GetSignatures(ctx context.Context, m module.Version) (*modsign.Bundle, error) {
+	repoName := c.repoName(m.Path())
+	desc, err := c.registry.ResolveTag(ctx, repoName, signatureTag(m.Version()))
+	if err != nil {
+		// Registry clients cannot tell an unknown tag from an unknown
+		// repository when resolving a tag over HTTP.
+		if errors.Is(err, ociregistry.ErrManifestUnknown) || errors.Is(err, ociregistry.ErrNameUnknown) {
+			return nil, fmt.Errorf("signatures of %v: %w", m, ErrNotFound)
+		}
+		return nil, fmt.Errorf("signatures of %v: %v", m, err)
+	}
+	manifest, err := fetchManifest(ctx, c.registry, repoName, desc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal manifest data: %v", err)
+	}
+	if manifest.Config.MediaType != modsign.MediaType || len(manifest.Layers) != 1 {
+		return nil, fmt.Errorf("signatures of %v do not resolve to a signature manifest", m)
+	}
+	r, err := c.getBlob(ctx, repoName, manifest.Layers[0])
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return modsign.Unmarshal(data)
+}
+
+// PutSignatures stores the signatures of the given module version,
+// replacing any signatures stored before.
+func (c *Client) PutSignatures(ctx context.Context, m module.Version, b *modsign.Bundle) error {
+	data, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+	repoName := c.repoName(m.Path())
+	configDesc, err := c.scratchConfig(ctx, repoName, modsign.MediaType)
+	if err != nil {
+		return fmt.Errorf("cannot make scratch config: %v", err)
+	}
+	manifest := &ocispec.Manifest{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers: []ocispec.Descriptor{{
+			Digest:    digest.FromBytes(data),
+			MediaType: modsign.MediaType,
+			Size:      int64(len(data)),
+		}},
+	}
+	if _, err := c.registry.PushBlob(ctx, repoName, manifest.Layers[0], bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("cannot push signatures: %v", err)
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("cannot marshal manifest: %v", err)
+	}
+	if _, err := c.registry.PushManifest(ctx, repoName, signatureTag(m.Version()), manifestData, ocispec.MediaTypeImageManifest); err != nil {
+		return fmt.Errorf("cannot tag signatures of %v: %v", m, err)
+	}
+	return nil
+}
+
 func (c *Client) repoName(modPath string) string {
-	path, _, _ := module.SplitPathVersion(modPath)
+	path, _, ok := module.SplitPathVersion(modPath)
+	if !ok {
+		return modPath
+	}
 	return path
 }
 
 // ModuleVersions returns all the versions for the module with the given path.
+// If the path has no major version suffix, the versions of all major
+// versions of the module are returned.
 func (c *Client) ModuleVersions(ctx context.Context, m string) ([]string, error) {
-	_, major, ok := module.SplitPathVersion(m)
-	if !ok {
-		return nil, fmt.Errorf("non-canonical module path %q", m)
-	}
+	_, major, _ := module.SplitPathVersion(m)
 	var tags []string
 	iter := c.registry.Tags(ctx, c.repoName(m))
 	for {
@@ -109,7 +190,7 @@ func (c *Client) ModuleVersions(ctx context.Context, m string) ([]string, error)
 		if !ok {
 			break
 		}
-		if semver.IsValid(tag) && semver.Major(tag) == major {
+		if semver.IsValid(tag) && (major == "" || semver.Major(tag) == major) {
 			tags = append(tags, tag)
 		}
 	}
@@ -283,12 +364,32 @@ func checkModFile(m module.Version, f *zip.File) ([]byte, *modfile.File, error)
 type Module struct {
 	client   *Client
 	repo     string
+	version  module.Version
 	manifest ocispec.Manifest
 }
 
+// ZipDigest returns the digest of the zip archive holding the module
+// files.
+func (m *Module) ZipDigest() digest.Digest {
+	return m.manifest.Layers[0].Digest
+}
+
+// VerifySignatures checks the signatures of the module against policy p.
+// The signatures are not fetched if p accepts all modules.
+func (m *Module) VerifySignatures(ctx context.Context, p *modsign.Policy) error {
+	if p.AcceptsAll() {
+		return nil
+	}
+	b, err := m.client.GetSignatures(ctx, m.version)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return p.Verify(m.version, m.ZipDigest(), b)
+}
+
 // ModuleFile returns the contents of the cue.mod/module.cue file.
 func (m *Module) ModuleFile(ctx context.Context) ([]byte, error) {
-	r, err := m.client.registry.GetBlob(ctx, m.repo, m.manifest.Layers[1].Digest)
+	r, err := m.client.getBlob(ctx, m.repo, m.manifest.Layers[1])
 	if err != nil {
 		return nil, err
 	}
@@ -300,8 +401,56 @@ func (m *Module) ModuleFile(ctx context.Context) ([]byte, error) {
 // archive containing the module files. The reader should be closed after use,
 // and the contents should not be assumed to be correct until the close
 // error has been checked.
+//
+// Reading fails if the contents do not match the digest and size recorded
+// for the archive in the module's manifest.
 func (m *Module) GetZip(ctx context.Context) (io.ReadCloser, error) {
-	return m.client.registry.GetBlob(ctx, m.repo, m.manifest.Layers[0].Digest)
+	return m.client.getBlob(ctx, m.repo, m.manifest.Layers[0])
+}
+
+// getBlob returns a reader for the blob with the given descriptor that fails
+// if the contents of the blob do not match the descriptor.
+func (c *Client) getBlob(ctx context.Context, repoName string, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid digest for blob in %s: %v", repoName, err)
+	}
+	r, err := c.registry.GetBlob(ctx, repoName, desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return &verifyingReader{
+		r:        r,
+		desc:     desc,
+		verifier: desc.Digest.Verifier(),
+	}, nil
+}
+
+// verifyingReader checks that the contents read from r match desc.
+type verifyingReader struct {
+	r        io.ReadCloser
+	desc     ocispec.Descriptor
+	verifier digest.Verifier
+	n        int64
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.verifier.Write(p[:n])
+	r.n += int64(n)
+	switch {
+	case r.n > r.desc.Size:
+		return n, fmt.Errorf("blob %s is larger than its expected size %d", r.desc.Digest, r.desc.Size)
+	case err != io.EOF:
+	case r.n < r.desc.Size:
+		return n, fmt.Errorf("blob %s is smaller than its expected size %d", r.desc.Digest, r.desc.Size)
+	case !r.verifier.Verified():
+		return n, fmt.Errorf("contents of blob %s do not match its digest", r.desc.Digest)
+	}
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.r.Close()
 }
 
 func fetchManifest(ctx context.Context, r ociregistry.Interface, repoName string, desc ocispec.Descriptor) (*ociregistry.Manifest, error) {
@@ -317,6 +466,9 @@ func fetchManifest(ctx context.Context, r ociregistry.Interface, repoName string
 	if err != nil {
 		return nil, err
 	}
+	if desc.Digest.Validate() != nil || desc.Digest.Algorithm().FromBytes(data) != desc.Digest {
+		return nil, fmt.Errorf("manifest for %s does not match its digest %s", repoName, desc.Digest)
+	}
 	var m ociregistry.Manifest
 	if err := json.Unmarshal(data, &m); err != nil {
 		return nil, fmt.Errorf("cannot decode %s content as manifest: %v", desc.MediaType, err)
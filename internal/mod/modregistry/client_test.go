@@ -17,6 +17,8 @@ package modregistry
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
 	"io"
 	"os"
 	"path"
@@ -27,8 +29,10 @@ import (
 
 	"golang.org/x/tools/txtar"
 
+	"cuelabs.dev/go/oci/ociregistry"
 	"cuelabs.dev/go/oci/ociregistry/ocimem"
 
+	"cuelang.org/go/internal/mod/modsign"
 	"cuelang.org/go/internal/mod/module"
 	modzip "cuelang.org/go/internal/mod/zip"
 )
@@ -64,6 +68,53 @@ x: 42
 	tags, err := c.ModuleVersions(ctx, mv.Path())
 	qt.Assert(t, qt.IsNil(err))
 	qt.Assert(t, qt.DeepEquals(tags, []string{"v1.2.3"}))
+
+	// Without a major version, versions of all major versions are returned.
+	tags, err = c.ModuleVersions(ctx, "example.com/module")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.DeepEquals(tags, []string{"v1.2.3"}))
+
+	tags, err = c.ModuleVersions(ctx, "example.com/module@v0")
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.HasLen(tags, 0))
+}
+
+func TestPutGetSignatures(t *testing.T) {
+	const testMod = `
+-- cue.mod/module.cue --
+module: "example.com/module@v1"
+
+-- x.cue --
+x: 42
+`
+	ctx := context.Background()
+	mv := module.MustParseVersion("example.com/module@v1.2.3")
+	c := newTestClient(t)
+	putModule(t, c, mv, testMod)
+	m, err := c.GetModule(ctx, mv)
+	qt.Assert(t, qt.IsNil(err))
+
+	pub, priv, err := modsign.GenerateKey(rand.Reader)
+	qt.Assert(t, qt.IsNil(err))
+	policy := &modsign.Policy{Keys: []string{pub}, Required: true}
+
+	_, err = c.GetSignatures(ctx, mv)
+	qt.Assert(t, qt.IsTrue(errors.Is(err, ErrNotFound)))
+	qt.Assert(t, qt.ErrorIs(m.VerifySignatures(ctx, policy), modsign.ErrUnsigned))
+	qt.Assert(t, qt.IsNil(m.VerifySignatures(ctx, &modsign.Policy{})))
+
+	var b modsign.Bundle
+	qt.Assert(t, qt.IsNil(b.Sign(mv, m.ZipDigest(), priv)))
+	qt.Assert(t, qt.IsNil(c.PutSignatures(ctx, mv, &b)))
+	got, err := c.GetSignatures(ctx, mv)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.DeepEquals(got, &b))
+	qt.Assert(t, qt.IsNil(m.VerifySignatures(ctx, policy)))
+
+	// The signature tag is not reported as a version.
+	tags, err := c.ModuleVersions(ctx, mv.Path())
+	qt.Assert(t, qt.IsNil(err))
+	qt.Assert(t, qt.DeepEquals(tags, []string{"v1.2.3"}))
 }
 
 func TestPutGetWithDependencies(t *testing.T) {
@@ -172,6 +223,62 @@ func TestCheckModule(t *testing.T) {
 	}
 }
 
+func TestGetCorruptModule(t *testing.T) {
+	const testMod = `
+-- cue.mod/module.cue --
+module: "example.com/module@v1"
+
+-- x.cue --
+x: 42
+`
+	ctx := context.Background()
+	mv := module.MustParseVersion("example.com/module@v1.2.3")
+	reg := ocimem.New()
+	c, err := NewClient(reg)
+	qt.Assert(t, qt.IsNil(err))
+	putModule(t, c, mv, testMod)
+
+	c, err = NewClient(corruptRegistry{reg})
+	qt.Assert(t, qt.IsNil(err))
+	m, err := c.GetModule(ctx, mv)
+	qt.Assert(t, qt.IsNil(err))
+
+	r, err := m.GetZip(ctx)
+	qt.Assert(t, qt.IsNil(err))
+	_, err = io.ReadAll(r)
+	qt.Assert(t, qt.ErrorMatches(err, `contents of blob sha256:[0-9a-f]+ do not match its digest`))
+
+	_, err = m.ModuleFile(ctx)
+	qt.Assert(t, qt.ErrorMatches(err, `contents of blob sha256:[0-9a-f]+ do not match its digest`))
+}
+
+// corruptRegistry flips the first byte of every blob it returns.
+type corruptRegistry struct {
+	ociregistry.Interface
+}
+
+func (r corruptRegistry) GetBlob(ctx context.Context, repo string, digest ociregistry.Digest) (ociregistry.BlobReader, error) {
+	br, err := r.Interface.GetBlob(ctx, repo, digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(br)
+	br.Close()
+	if err != nil {
+		return nil, err
+	}
+	data[0] ^= 0xff
+	return corruptBlob{bytes.NewReader(data), br.Descriptor()}, nil
+}
+
+type corruptBlob struct {
+	*bytes.Reader
+	desc ociregistry.Descriptor
+}
+
+func (b corruptBlob) Close() error                       { return nil }
+func (b corruptBlob) Descriptor() ociregistry.Descriptor { return b.desc }
+
 func putModule(t *testing.T, c *Client, mv module.Version, txtarData string) []byte {
 	zipData := createZip(t, mv, txtarData)
 	err := c.PutModule(context.Background(), mv, bytes.NewReader(zipData), int64(len(zipData)))
@@ -0,0 +1,202 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modsign signs module archives and verifies their signatures.
+//
+// A signature covers the module path, the version, and the digest of the
+// module's zip archive, so that it cannot be reused for another module or
+// version. Signatures are made with Ed25519 keys, which are written as
+// "ed25519:" followed by the base64 encoding of the public key or of the
+// private key seed.
+package modsign
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"cuelang.org/go/internal/mod/module"
+)
+
+// MediaType is the media type of an encoded signature bundle.
+const MediaType = "application/vnd.cue.module.signature.v1+json"
+
+const keyPrefix = "ed25519:"
+
+// ErrUnsigned is returned by Verify when a policy requires a signature and
+// there is none.
+var ErrUnsigned = errors.New("module is not signed")
+
+// A Bundle holds the signatures of a module archive.
+type Bundle struct {
+	Signatures []Signature `json:"signatures"`
+}
+
+// A Signature is a signature of a module archive made with a single key.
+type Signature struct {
+	// KeyID identifies the key that made the signature. See KeyID.
+	KeyID string `json:"keyid"`
+
+	// Sig holds the signature of the payload for the module version.
+	Sig []byte `json:"sig"`
+}
+
+// GenerateKey generates a key pair, using entropy from rand, and returns the
+// encoded public and private keys.
+func GenerateKey(rand io.Reader) (public, private string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeKey(pub), encodeKey(priv.Seed()), nil
+}
+
+// KeyID returns a short identifier for the encoded public key.
+func KeyID(public string) (string, error) {
+	pub, err := parsePublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	return keyID(pub), nil
+}
+
+// Sign signs the archive of module version mv, which has the given digest,
+// with the encoded private key and adds the signature to b.
+func (b *Bundle) Sign(mv module.Version, zipDigest digest.Digest, private string) error {
+	seed, err := decodeKey(private, ed25519.SeedSize)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %v", err)
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	b.Signatures = append(b.Signatures, Signature{
+		KeyID: keyID(priv.Public().(ed25519.PublicKey)),
+		Sig:   ed25519.Sign(priv, payload(mv, zipDigest)),
+	})
+	return nil
+}
+
+// Marshal returns the encoding of b.
+func (b *Bundle) Marshal() ([]byte, error) {
+	return json.Marshal(b)
+}
+
+// Unmarshal decodes a bundle encoded with Marshal.
+func Unmarshal(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid signature bundle: %v", err)
+	}
+	return &b, nil
+}
+
+// A Policy determines which signatures are accepted for a module.
+type Policy struct {
+	// Keys holds the encoded public keys that are trusted to sign the
+	// module.
+	Keys []string
+
+	// Required reports whether the module must be signed. If it is false,
+	// unsigned modules are accepted, but signatures that are present must
+	// still be valid.
+	Required bool
+}
+
+// AcceptsAll reports whether p accepts all modules, whether they are signed
+// or not.
+func (p *Policy) AcceptsAll() bool {
+	return len(p.Keys) == 0 && !p.Required
+}
+
+// Verify reports an error unless b, which may be nil for a module without
+// signatures, satisfies p for the archive of mv with the given digest. A
+// bundle satisfies a policy if it has a valid signature made with one of
+// the trusted keys. Signatures made with other keys are ignored.
+func (p *Policy) Verify(mv module.Version, zipDigest digest.Digest, b *Bundle) error {
+	if b == nil || len(b.Signatures) == 0 {
+		if p.Required {
+			return fmt.Errorf("%v: %w", mv, ErrUnsigned)
+		}
+		return nil
+	}
+	keys := make(map[string]ed25519.PublicKey, len(p.Keys))
+	for _, k := range p.Keys {
+		pub, err := parsePublicKey(k)
+		if err != nil {
+			return err
+		}
+		keys[keyID(pub)] = pub
+	}
+	msg := payload(mv, zipDigest)
+	trusted := false
+	for _, s := range b.Signatures {
+		pub, ok := keys[s.KeyID]
+		if !ok {
+			continue
+		}
+		if !ed25519.Verify(pub, msg, s.Sig) {
+			return fmt.Errorf("%v: invalid signature by key %s", mv, s.KeyID)
+		}
+		trusted = true
+	}
+	if !trusted && (p.Required || len(keys) > 0) {
+		return fmt.Errorf("%v: no signature by a trusted key", mv)
+	}
+	return nil
+}
+
+// payload returns the message that is signed for the archive of mv.
+func payload(mv module.Version, zipDigest digest.Digest) []byte {
+	return []byte(fmt.Sprintf("cue module signature v1\n%s\n%s\n%s\n",
+		mv.Path(), mv.Version(), zipDigest))
+}
+
+func keyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+func parsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := decodeKey(s, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %q: %v", s, err)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+func encodeKey(b []byte) string {
+	return keyPrefix + base64.StdEncoding.EncodeToString(b)
+}
+
+func decodeKey(s string, size int) ([]byte, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), keyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("key does not start with %q", keyPrefix)
+	}
+	b, err := base64.StdEncoding.DecodeString(rest)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("key has %d bytes; want %d", len(b), size)
+	}
+	return b, nil
+}
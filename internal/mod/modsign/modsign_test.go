@@ -0,0 +1,89 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modsign
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/go-quicktest/qt"
+	"github.com/opencontainers/go-digest"
+
+	"cuelang.org/go/internal/mod/module"
+)
+
+func TestSignVerify(t *testing.T) {
+	pub, priv, err := GenerateKey(rand.Reader)
+	qt.Assert(t, qt.IsNil(err))
+	otherPub, otherPriv, err := GenerateKey(rand.Reader)
+	qt.Assert(t, qt.IsNil(err))
+
+	mv := module.MustNewVersion("example.com/foo@v1", "v1.2.3")
+	d := digest.FromString("archive")
+
+	var b Bundle
+	qt.Assert(t, qt.IsNil(b.Sign(mv, d, priv)))
+	data, err := b.Marshal()
+	qt.Assert(t, qt.IsNil(err))
+	signed, err := Unmarshal(data)
+	qt.Assert(t, qt.IsNil(err))
+
+	trusted := &Policy{Keys: []string{pub}, Required: true}
+	qt.Check(t, qt.IsNil(trusted.Verify(mv, d, signed)))
+
+	// The signature does not apply to other archives or versions.
+	err = trusted.Verify(mv, digest.FromString("other"), signed)
+	qt.Check(t, qt.ErrorMatches(err, `example.com/foo@v1.2.3: invalid signature by key [0-9a-f]{16}`))
+	err = trusted.Verify(module.MustNewVersion("example.com/foo@v1", "v1.2.4"), d, signed)
+	qt.Check(t, qt.ErrorMatches(err, `example.com/foo@v1.2.4: invalid signature by key [0-9a-f]{16}`))
+
+	// Signatures by untrusted keys are not accepted.
+	other := &Policy{Keys: []string{otherPub}}
+	err = other.Verify(mv, d, signed)
+	qt.Check(t, qt.ErrorMatches(err, `example.com/foo@v1.2.3: no signature by a trusted key`))
+
+	// A bundle may have signatures by several keys.
+	qt.Assert(t, qt.IsNil(signed.Sign(mv, d, otherPriv)))
+	qt.Check(t, qt.IsNil(other.Verify(mv, d, signed)))
+	qt.Check(t, qt.IsNil(trusted.Verify(mv, d, signed)))
+
+	id, err := KeyID(pub)
+	qt.Assert(t, qt.IsNil(err))
+	qt.Check(t, qt.Equals(signed.Signatures[0].KeyID, id))
+}
+
+func TestVerifyUnsigned(t *testing.T) {
+	mv := module.MustNewVersion("example.com/foo@v1", "v1.0.0")
+	d := digest.FromString("archive")
+
+	optional := &Policy{}
+	qt.Check(t, qt.IsNil(optional.Verify(mv, d, nil)))
+
+	required := &Policy{Required: true}
+	qt.Check(t, qt.ErrorIs(required.Verify(mv, d, nil), ErrUnsigned))
+	qt.Check(t, qt.ErrorIs(required.Verify(mv, d, &Bundle{}), ErrUnsigned))
+}
+
+func TestInvalidKeys(t *testing.T) {
+	_, err := KeyID("rsa:AAAA")
+	qt.Check(t, qt.ErrorMatches(err, `invalid public key "rsa:AAAA": key does not start with "ed25519:"`))
+
+	_, err = KeyID("ed25519:AAAA")
+	qt.Check(t, qt.ErrorMatches(err, `invalid public key "ed25519:AAAA": key has 3 bytes; want 32`))
+
+	var b Bundle
+	err = b.Sign(module.MustNewVersion("example.com/foo@v1", "v1.0.0"), digest.FromString("x"), "ed25519:AAAA")
+	qt.Check(t, qt.ErrorMatches(err, `invalid private key: key has 3 bytes; want 32`))
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
@@ -33,6 +34,10 @@ import (
 // slashes in path have been replaced with underscores and should
 // contain a cue.mod/module.cue file holding the module info.
 //
+// If fsys contains a _registry_auth file holding user:password, the
+// registry requires requests to be authenticated with those credentials
+// using HTTP Basic authentication.
+//
 // The Registry should be closed after use.
 func New(fsys fs.FS, prefix string) (*Registry, error) {
 	r := ocimem.New()
@@ -48,7 +53,11 @@ func New(fsys fs.FS, prefix string) (*Registry, error) {
 	if err := pushContent(client, mods); err != nil {
 		return nil, fmt.Errorf("cannot push modules: %v", err)
 	}
-	srv := httptest.NewServer(ociserver.New(r, nil))
+	var h http.Handler = ociserver.New(r, nil)
+	if auth, err := fs.ReadFile(fsys, "_registry_auth"); err == nil {
+		h = basicAuth(h, strings.TrimSpace(string(auth)))
+	}
+	srv := httptest.NewServer(h)
 	u, err := url.Parse(srv.URL)
 	if err != nil {
 		return nil, err
@@ -59,6 +68,21 @@ func New(fsys fs.FS, prefix string) (*Registry, error) {
 	}, nil
 }
 
+// basicAuth returns a handler that rejects requests to h that are not
+// authenticated with the given user:password credentials.
+func basicAuth(h http.Handler, auth string) http.Handler {
+	wantUser, wantPassword, _ := strings.Cut(auth, ":")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, password, ok := req.BasicAuth()
+		if !ok || user != wantUser || password != wantPassword {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registrytest"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
 func pushContent(client *modregistry.Client, mods map[module.Version]*moduleContent) error {
 	pushed := make(map[module.Version]bool)
 	for v := range mods {
@@ -0,0 +1,89 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// mapThreshold is the minimum size of a file for it to be mapped into memory
+// by MapFile. Mapping smaller files is not worth the overhead.
+const mapThreshold = 1 << 20
+
+// Mappings holds files mapped into memory. Their pages are only loaded when
+// accessed and may be reclaimed by the operating system, so large files do
+// not count towards the memory of the process upfront.
+//
+// The mapped bytes remain valid until Close is called. Truncating a file
+// while it is mapped causes a fault when the missing pages are accessed, so
+// files that may change, such as those of a long-running process, should not
+// be mapped.
+//
+// The zero value is ready for use. A Mappings may be used by several
+// goroutines at once.
+type Mappings struct {
+	mu     sync.Mutex
+	maps   [][]byte
+	closed bool
+}
+
+// MapFile returns the contents of the named file and reports whether they
+// are mapped into memory. Where supported, regular files of at least 1MiB
+// are mapped rather than read. Mapped bytes are read-only and must not be
+// modified or used after m is closed.
+func (m *Mappings) MapFile(filename string) (b []byte, mapped bool, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if size := fi.Size(); fi.Mode().IsRegular() && size >= mapThreshold && int64(int(size)) == size {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.closed {
+			return nil, false, errors.New("source: mappings closed")
+		}
+		if b, err := mmap(f, int(size)); err == nil {
+			m.maps = append(m.maps, b)
+			return b, true, nil
+		}
+		// Fall back to reading the file.
+	}
+	b, err = io.ReadAll(f)
+	return b, false, err
+}
+
+// Close unmaps all files mapped by m. Bytes returned by MapFile must no
+// longer be used, including values that were created from them.
+func (m *Mappings) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var err error
+	for _, b := range m.maps {
+		if uerr := munmap(b); err == nil {
+			err = uerr
+		}
+	}
+	m.maps, m.closed = nil, true
+	return err
+}
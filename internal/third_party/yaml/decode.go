@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/literal"
@@ -34,6 +35,7 @@ type node struct {
 	tag      string
 	// For an alias node, alias holds the resolved alias.
 	alias    *node
+	anchor   string
 	value    string
 	implicit bool
 	children []*node
@@ -162,6 +164,7 @@ func (p *parser) fail() {
 
 func (p *parser) anchor(n *node, anchor []byte) {
 	if anchor != nil {
+		n.anchor = string(anchor)
 		p.doc.anchors[string(anchor)] = n
 	}
 }
@@ -266,6 +269,12 @@ type decoder struct {
 	prev         token.Pos
 	lastNode     ast.Node
 	forceNewline bool
+
+	// anchors indicates that anchors and aliases are recorded as attributes.
+	anchors bool
+	// expanding is non-zero while decoding the expansion of an alias or
+	// merge key, for which no attributes are recorded.
+	expanding int
 }
 
 var (
@@ -410,7 +419,9 @@ func (d *decoder) ident(n *node, name string) *ast.Ident {
 func (d *decoder) document(n *node) ast.Expr {
 	if len(n.children) == 1 {
 		d.doc = n
-		return d.unmarshal(n.children[0])
+		expr := d.unmarshal(n.children[0])
+		d.addDeclAttr(n.children[0], expr)
+		return expr
 	}
 	return &ast.BottomLit{} // TODO: more informatives
 }
@@ -421,11 +432,56 @@ func (d *decoder) alias(n *node) ast.Expr {
 		d.p.failf(n.startPos.line, "anchor '%s' value contains itself", n.value)
 	}
 	d.aliases[n] = true
+	d.expanding++
 	node := d.unmarshal(n.alias)
+	d.expanding--
 	delete(d.aliases, n)
 	return node
 }
 
+// recordAnchors reports whether anchors and aliases should be recorded for
+// the node currently being decoded.
+func (d *decoder) recordAnchors() bool {
+	return d.anchors && d.expanding == 0
+}
+
+// anchorAttr returns the attribute recording that n is an anchor or alias, or
+// nil if it is neither.
+func (d *decoder) anchorAttr(n *node) *ast.Attribute {
+	switch {
+	case !d.recordAnchors():
+		return nil
+	case n.kind == aliasNode:
+		return yamlAttr("alias", n.value)
+	case n.anchor != "":
+		return yamlAttr("anchor", n.anchor)
+	}
+	return nil
+}
+
+// addDeclAttr records the anchor or alias of n, if any, as a declaration
+// attribute of expr, which was decoded from n, if expr is a struct.
+func (d *decoder) addDeclAttr(n *node, expr ast.Expr) {
+	s, ok := expr.(*ast.StructLit)
+	if !ok {
+		return
+	}
+	if a := d.anchorAttr(n); a != nil {
+		s.Elts = append([]ast.Decl{a}, s.Elts...)
+	}
+}
+
+// yamlAttr returns the attribute @yaml(key=name).
+func yamlAttr(key, name string) *ast.Attribute {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.", r) {
+			name = literal.String.Quote(name)
+			break
+		}
+	}
+	return &ast.Attribute{Text: fmt.Sprintf("@yaml(%s=%s)", key, name)}
+}
+
 var zeroValue reflect.Value
 
 func (d *decoder) scalar(n *node) ast.Expr {
@@ -631,6 +687,7 @@ func (d *decoder) sequence(n *node) ast.Expr {
 	for _, c := range n.children {
 		d.forceNewline = !single
 		elem := d.unmarshal(c)
+		d.addDeclAttr(c, elem)
 		list.Elts = append(list.Elts, elem)
 		_, noNewline = elem.(*ast.StructLit)
 	}
@@ -674,7 +731,10 @@ outer:
 	for i := 0; i < l; i += 2 {
 		if isMerge(n.children[i]) {
 			merge = true
+			d.addMergeAttrs(n.children[i+1], m)
+			d.expanding++
 			d.merge(n.children[i+1], m)
+			d.expanding--
 			continue
 		}
 		switch n.children[i].kind {
@@ -694,7 +754,10 @@ outer:
 		if merge {
 			key := labelStr(label)
 			for _, decl := range m.Elts {
-				f := decl.(*ast.Field)
+				f, ok := decl.(*ast.Field)
+				if !ok {
+					continue
+				}
 				name, _, err := ast.LabelName(f.Label)
 				if err == nil && name == key {
 					f.Value = d.unmarshal(n.children[i+1])
@@ -705,6 +768,9 @@ outer:
 
 		value := d.unmarshal(n.children[i+1])
 		field.Value = value
+		if a := d.anchorAttr(n.children[i+1]); a != nil {
+			field.Attrs = append(field.Attrs, a)
+		}
 		d.attachDocComments(n.children[i+1].startPos, 0, value)
 		d.attachLineComment(n.children[i+1].endPos, 10, value)
 
@@ -727,6 +793,24 @@ func (d *decoder) failWantMap(n *node) {
 	d.p.failf(n.startPos.line, "map merge requires map or sequence of maps as the value")
 }
 
+// addMergeAttrs records the aliases of the merge key value n as declaration
+// attributes of m.
+func (d *decoder) addMergeAttrs(n *node, m *ast.StructLit) {
+	if !d.recordAnchors() {
+		return
+	}
+	switch n.kind {
+	case aliasNode:
+		m.Elts = append(m.Elts, yamlAttr("merge", n.value))
+	case sequenceNode:
+		for _, c := range n.children {
+			if c.kind == aliasNode {
+				m.Elts = append(m.Elts, yamlAttr("merge", c.value))
+			}
+		}
+	}
+}
+
 func (d *decoder) merge(n *node, m *ast.StructLit) {
 	switch n.kind {
 	case mappingNode:
@@ -849,6 +849,66 @@ func TestDecoder(t *testing.T) {
 	}
 }
 
+func TestDecoderAnchors(t *testing.T) {
+	const data = `
+a: &a
+  x: 1
+b: *a
+c:
+  <<: *a
+  y: 2
+d:
+  <<: [*a, *a]
+e:
+- &e {z: 1}
+- *e
+f: &f
+  g: &g 1
+h: *f
+`
+	const want = `a: {
+	x: 1
+} @yaml(anchor=a)
+b: {
+	x: 1
+} @yaml(alias=a)
+c: {
+	@yaml(merge=a)
+	x: 1
+
+	y: 2
+}
+d: {
+	@yaml(merge=a)
+	@yaml(merge=a)
+	x: 1
+}
+
+e: [{
+	@yaml(anchor=e)
+	z: 1
+}, {
+	@yaml(alias=e)
+	z: 1
+}]
+
+f: {
+	g: 1 @yaml(anchor=g)
+} @yaml(anchor=f)
+h: {
+	g: 1
+} @yaml(alias=f)`
+	dec := newDecoder(t, data)
+	dec.SetAnchors(true)
+	expr, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cueStr(expr); got != want {
+		t.Errorf("\n got: %v;\nwant: %v", got, want)
+	}
+}
+
 type errReader struct{}
 
 func (errReader) Read([]byte) (int, error) {
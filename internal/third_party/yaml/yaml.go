@@ -26,6 +26,7 @@ func Unmarshal(filename string, in []byte) (expr ast.Expr, err error) {
 // A Decoder reads and decodes YAML values from an input stream.
 type Decoder struct {
 	strict    bool
+	anchors   bool
 	firstDone bool
 	parser    *parser
 }
@@ -42,11 +43,26 @@ func NewDecoder(filename string, src interface{}) (*Decoder, error) {
 	return &Decoder{parser: d}, nil
 }
 
+// SetAnchors controls whether anchors, aliases, and merge keys are recorded
+// as @yaml attributes in the decoded values. Aliases are always expanded.
+//
+// An anchored or aliased value of a field is marked with a field attribute
+// @yaml(anchor=name) or @yaml(alias=name). For a mapping that is a list
+// element or document, these are recorded as a declaration attribute of the
+// resulting struct instead. An alias used as a merge key is recorded as a
+// declaration attribute @yaml(merge=name) of the struct into which it is
+// merged. Anchors of scalars and sequences that are not the value of a field
+// are not recorded.
+func (dec *Decoder) SetAnchors(on bool) {
+	dec.anchors = on
+}
+
 // Decode reads the next YAML-encoded value from its input and returns
 // it as CUE syntax. It returns io.EOF if there are no more value in the
 // stream.
 func (dec *Decoder) Decode() (expr ast.Expr, err error) {
 	d := newDecoder(dec.parser)
+	d.anchors = dec.anchors
 	defer handleErr(&err)
 	node := dec.parser.parse()
 	if node == nil {
@@ -0,0 +1,47 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package textproto implements the Protocol Buffer text format.
+//
+// The text format does not identify the type of fields. Conversions therefore
+// use a schema, typically a definition generated from a .proto file, whose
+// @protobuf attributes determine the names of fields and how maps and
+// repeated fields are encoded.
+package textproto
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/encoding/protobuf/textproto"
+)
+
+// Marshal returns the text format encoding of v. Field names and the
+// encoding of maps are taken from the @protobuf attributes of v, if any, so
+// v is typically a value unified with the schema of the message.
+func Marshal(v cue.Value) (string, error) {
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return "", err
+	}
+	b, err := textproto.NewEncoder().Encode(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Unmarshal parses the text format in data as a message described by
+// schema. Fields in data that are not defined in schema are ignored.
+func Unmarshal(data []byte, schema cue.Value) (ast.Expr, error) {
+	return textproto.NewDecoder().Parse(schema, "", data)
+}
@@ -0,0 +1,43 @@
+// Code generated by cuelang.org/go/pkg/gen. DO NOT EDIT.
+
+package textproto
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
+)
+
+func init() {
+	pkg.Register("encoding/textproto", p)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var p = &pkg.Package{
+	Native: []*pkg.Builtin{{
+		Name: "Marshal",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			v := c.Value(0)
+			if c.Do() {
+				c.Ret, c.Err = Marshal(v)
+			}
+		},
+	}, {
+		Name: "Unmarshal",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+			{Kind: adt.TopKind},
+		},
+		Result: adt.TopKind,
+		Func: func(c *pkg.CallCtxt) {
+			data, schema := c.Bytes(0), c.Value(1)
+			if c.Do() {
+				c.Ret, c.Err = Unmarshal(data, schema)
+			}
+		},
+	}},
+}
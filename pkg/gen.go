@@ -18,6 +18,10 @@
 //
 // It takes the list of packages from the packages.txt.
 //
+// The documentation of a package that defines CUE values may be given as the
+// doc comment of the package clause of the CUE file named after the package;
+// it is copied to the generated file, followed by the definitions in that file.
+//
 // Be sure to also update an entry in pkg/pkg.go, if so desired.
 package main
 
@@ -30,6 +34,7 @@ import (
 	"fmt"
 	"go/constant"
 	"go/format"
+	goparser "go/parser"
 	"go/token"
 	"go/types"
 	"log"
@@ -44,9 +49,11 @@ import (
 	"golang.org/x/tools/go/packages"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/errors"
 	cueformat "cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/internal"
 	"cuelang.org/go/internal/core/runtime"
 )
@@ -152,18 +159,12 @@ func generate(pkg *packages.Package) error {
 		params.CUEPkg = ""
 	}
 
-	if doc, err := os.ReadFile(filepath.Join(pkgDir, "doc.txt")); err == nil {
-		defs, err := os.ReadFile(filepath.Join(pkgDir, pkg.Name+".cue"))
-		if err != nil {
-			return err
-		}
-		i := bytes.Index(defs, []byte("package "+pkg.Name))
-		defs = defs[i+len("package "+pkg.Name)+1:]
-		defs = bytes.TrimRight(defs, "\n")
-		defs = bytes.ReplaceAll(defs, []byte("\n"), []byte("\n//\t"))
-		params.PackageDoc = string(doc)
-		params.PackageDefs = string(defs)
+	doc, defs, err := packageDoc(pkg, pkgDir)
+	if err != nil {
+		return err
 	}
+	params.PackageDoc = doc
+	params.PackageDefs = defs
 
 	if err := header.Execute(g.w, params); err != nil {
 		return err
@@ -196,6 +197,70 @@ func generate(pkg *packages.Package) error {
 	return nil
 }
 
+// packageDoc returns the package documentation, taken from the doc comment
+// of the package clause of the CUE file named after the package, along with
+// the CUE definitions following that clause, which are included in the
+// documentation.
+//
+// Packages without such a doc comment are documented in their Go sources
+// instead. It is an error to document a package in both.
+func packageDoc(pkg *packages.Package, pkgDir string) (doc, defs string, err error) {
+	if _, err := os.Stat(filepath.Join(pkgDir, "doc.txt")); err == nil {
+		return "", "", fmt.Errorf("doc.txt is no longer supported: document the package in %s.cue instead", pkg.Name)
+	}
+	filename := filepath.Join(pkgDir, pkg.Name+".cue")
+	src, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	f, err := parser.ParseFile(filename, src, parser.ParseComments)
+	if err != nil {
+		return "", "", err
+	}
+	var clause *ast.Package
+	for _, d := range f.Decls {
+		if p, ok := d.(*ast.Package); ok {
+			clause = p
+			break
+		}
+	}
+	if clause == nil {
+		return "", "", nil
+	}
+	var lines []string
+	for _, cg := range ast.Comments(clause) {
+		if cg.Doc {
+			for _, c := range cg.List {
+				lines = append(lines, c.Text)
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return "", "", nil
+	}
+
+	for _, goFile := range pkg.GoFiles {
+		if filepath.Base(goFile) == genFile {
+			continue
+		}
+		gf, err := goparser.ParseFile(token.NewFileSet(), goFile, nil, goparser.PackageClauseOnly|goparser.ParseComments)
+		if err != nil {
+			return "", "", err
+		}
+		if gf.Doc != nil {
+			return "", "", fmt.Errorf("package documented in both %s and %s", filename, goFile)
+		}
+	}
+
+	b := src[clause.End().Offset()+1:]
+	b = bytes.TrimRight(b, "\n")
+	b = bytes.ReplaceAll(b, []byte("\n"), []byte("\n//\t"))
+	return strings.Join(lines, "\n") + "\n", string(b), nil
+}
+
 func (g *generator) sep() {
 	if g.first {
 		g.first = false
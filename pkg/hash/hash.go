@@ -0,0 +1,108 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hash provides non-cryptographic hash functions.
+//
+// These functions are fast and deterministic, which makes them suitable for
+// bucketing, checksums and the generation of cache keys. They must not be
+// used where resistance against deliberate collisions is required; use the
+// crypto packages instead.
+package hash
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// FNV32 returns the 32-bit FNV-1 hash of data.
+func FNV32(data []byte) uint32 {
+	h := fnv.New32()
+	h.Write(data)
+	return h.Sum32()
+}
+
+// FNV32a returns the 32-bit FNV-1a hash of data.
+func FNV32a(data []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(data)
+	return h.Sum32()
+}
+
+// FNV64 returns the 64-bit FNV-1 hash of data.
+func FNV64(data []byte) uint64 {
+	h := fnv.New64()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// FNV64a returns the 64-bit FNV-1a hash of data.
+func FNV64a(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// CRC32 returns the CRC-32 checksum of data using the IEEE polynomial,
+// as used by, for instance, gzip and PNG.
+func CRC32(data []byte) uint32 {
+	return crc32.ChecksumIEEE(data)
+}
+
+// CRC32C returns the CRC-32 checksum of data using the Castagnoli
+// polynomial, as used by, for instance, iSCSI and ext4.
+func CRC32C(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoli)
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// XXHash64 returns the 64-bit xxHash (XXH64) of data with a seed of 0.
+func XXHash64(data []byte) uint64 {
+	return xxhash64(data, 0)
+}
+
+var hexFuncs = map[string]func([]byte) []byte{
+	"fnv32":    func(b []byte) []byte { return binary.BigEndian.AppendUint32(nil, FNV32(b)) },
+	"fnv32a":   func(b []byte) []byte { return binary.BigEndian.AppendUint32(nil, FNV32a(b)) },
+	"fnv64":    func(b []byte) []byte { return binary.BigEndian.AppendUint64(nil, FNV64(b)) },
+	"fnv64a":   func(b []byte) []byte { return binary.BigEndian.AppendUint64(nil, FNV64a(b)) },
+	"crc32":    func(b []byte) []byte { return binary.BigEndian.AppendUint32(nil, CRC32(b)) },
+	"crc32c":   func(b []byte) []byte { return binary.BigEndian.AppendUint32(nil, CRC32C(b)) },
+	"xxhash64": func(b []byte) []byte { return binary.BigEndian.AppendUint64(nil, XXHash64(b)) },
+}
+
+// Hex returns the hash of data computed with the given algorithm as a
+// zero-padded lowercase hexadecimal string, with as many digits as the
+// hash has bits divided by four.
+//
+// Valid algorithms are "fnv32", "fnv32a", "fnv64", "fnv64a", "crc32",
+// "crc32c", and "xxhash64".
+func Hex(algorithm string, data []byte) (string, error) {
+	f, ok := hexFuncs[algorithm]
+	if !ok {
+		names := make([]string, 0, len(hexFuncs))
+		for name := range hexFuncs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown hash algorithm %q; valid algorithms are %s",
+			algorithm, strings.Join(names, ", "))
+	}
+	return hex.EncodeToString(f(data)), nil
+}
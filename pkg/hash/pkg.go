@@ -0,0 +1,115 @@
+// Code generated by cuelang.org/go/pkg/gen. DO NOT EDIT.
+
+package hash
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
+)
+
+func init() {
+	pkg.Register("hash", p)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var p = &pkg.Package{
+	Native: []*pkg.Builtin{{
+		Name: "FNV32",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			data := c.Bytes(0)
+			if c.Do() {
+				c.Ret = FNV32(data)
+			}
+		},
+	}, {
+		Name: "FNV32a",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			data := c.Bytes(0)
+			if c.Do() {
+				c.Ret = FNV32a(data)
+			}
+		},
+	}, {
+		Name: "FNV64",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			data := c.Bytes(0)
+			if c.Do() {
+				c.Ret = FNV64(data)
+			}
+		},
+	}, {
+		Name: "FNV64a",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			data := c.Bytes(0)
+			if c.Do() {
+				c.Ret = FNV64a(data)
+			}
+		},
+	}, {
+		Name: "CRC32",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			data := c.Bytes(0)
+			if c.Do() {
+				c.Ret = CRC32(data)
+			}
+		},
+	}, {
+		Name: "CRC32C",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			data := c.Bytes(0)
+			if c.Do() {
+				c.Ret = CRC32C(data)
+			}
+		},
+	}, {
+		Name: "XXHash64",
+		Params: []pkg.Param{
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			data := c.Bytes(0)
+			if c.Do() {
+				c.Ret = XXHash64(data)
+			}
+		},
+	}, {
+		Name: "Hex",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			algorithm, data := c.String(0), c.Bytes(1)
+			if c.Do() {
+				c.Ret, c.Err = Hex(algorithm, data)
+			}
+		},
+	}},
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hash
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// This file implements XXH64 as described in
+// https://github.com/Cyan4973/xxHash/blob/dev/doc/xxhash_spec.md.
+
+const (
+	prime64_1 uint64 = 11400714785074694791
+	prime64_2 uint64 = 14029467366897019727
+	prime64_3 uint64 = 1609587929392839161
+	prime64_4 uint64 = 9650029242287828579
+	prime64_5 uint64 = 2870177450012600261
+)
+
+func xxhash64(b []byte, seed uint64) uint64 {
+	n := len(b)
+	var h uint64
+	if n >= 32 {
+		v1 := seed + prime64_1 + prime64_2
+		v2 := seed + prime64_2
+		v3 := seed
+		v4 := seed - prime64_1
+		for ; len(b) >= 32; b = b[32:] {
+			v1 = xxround(v1, binary.LittleEndian.Uint64(b[0:8]))
+			v2 = xxround(v2, binary.LittleEndian.Uint64(b[8:16]))
+			v3 = xxround(v3, binary.LittleEndian.Uint64(b[16:24]))
+			v4 = xxround(v4, binary.LittleEndian.Uint64(b[24:32]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) +
+			bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxmerge(h, v1)
+		h = xxmerge(h, v2)
+		h = xxmerge(h, v3)
+		h = xxmerge(h, v4)
+	} else {
+		h = seed + prime64_5
+	}
+	h += uint64(n)
+
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxround(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*prime64_1 + prime64_4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * prime64_1
+		h = bits.RotateLeft64(h, 23)*prime64_2 + prime64_3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * prime64_5
+		h = bits.RotateLeft64(h, 11) * prime64_1
+	}
+
+	h ^= h >> 33
+	h *= prime64_2
+	h ^= h >> 29
+	h *= prime64_3
+	h ^= h >> 32
+	return h
+}
+
+func xxround(acc, input uint64) uint64 {
+	acc += input * prime64_2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime64_1
+}
+
+func xxmerge(acc, v uint64) uint64 {
+	acc ^= xxround(0, v)
+	return acc*prime64_1 + prime64_4
+}
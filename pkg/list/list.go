@@ -21,9 +21,12 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/core/export"
 	"cuelang.org/go/internal/pkg"
+	"cuelang.org/go/internal/value"
 )
 
 // Drop reports the suffix of list x after the first n elements,
@@ -215,7 +218,10 @@ func Slice(x []cue.Value, i, j int) ([]cue.Value, error) {
 	return x[i:j], nil
 }
 
-// MinItems reports whether a has at least n items.
+// MinItems reports whether a has at least n items. The bound is inclusive: a
+// list with exactly n items is valid.
+//
+// MinItems maps to the minItems keyword of OpenAPI and JSON Schema.
 func MinItems(list pkg.List, n int) (bool, error) {
 	count := len(list.Elems())
 	if count >= n {
@@ -231,7 +237,10 @@ func MinItems(list pkg.List, n int) (bool, error) {
 	}}
 }
 
-// MaxItems reports whether a has at most n items.
+// MaxItems reports whether a has at most n items. The bound is inclusive: a
+// list with exactly n items is valid.
+//
+// MaxItems maps to the maxItems keyword of OpenAPI and JSON Schema.
 func MaxItems(list pkg.List, n int) (bool, error) {
 	count := len(list.Elems())
 	if count > n {
@@ -248,7 +257,7 @@ func MaxItems(list pkg.List, n int) (bool, error) {
 func UniqueItems(a []cue.Value) bool {
 	b := []string{}
 	for _, v := range a {
-		b = append(b, fmt.Sprintf("%+v", v))
+		b = append(b, uniqueKey(v))
 	}
 	sort.Strings(b)
 	for i := 1; i < len(b); i++ {
@@ -259,6 +268,15 @@ func UniqueItems(a []cue.Value) bool {
 	return true
 }
 
+// uniqueKey returns a canonical representation of the final value of v
+// that is used to compare list elements for equality.
+func uniqueKey(v cue.Value) string {
+	r, x := value.ToInternal(v)
+	n, _ := export.Final.Value(r, "", x)
+	b, _ := format.Node(n)
+	return string(b)
+}
+
 // Contains reports whether v is contained in a. The value must be a
 // comparable value.
 func Contains(a []cue.Value, v cue.Value) bool {
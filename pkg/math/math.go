@@ -254,6 +254,9 @@ var two = apd.New(2, 0)
 // Special cases are the same as Exp.
 func Exp2(x *internal.Decimal) (*internal.Decimal, error) {
 	var d internal.Decimal
+	if intPow(&d, two, x) {
+		return &d, nil
+	}
 	_, err := internal.BaseContext.Pow(&d, two, x)
 	return &d, err
 }
@@ -491,6 +494,9 @@ func Mod(x, y float64) float64 {
 //	Pow(x, y) = NaN for finite x < 0 and finite non-integer y
 func Pow(x, y *internal.Decimal) (*internal.Decimal, error) {
 	var d internal.Decimal
+	if intPow(&d, x, y) {
+		return &d, nil
+	}
 	_, err := internal.BaseContext.Pow(&d, x, y)
 	return &d, err
 }
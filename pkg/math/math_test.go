@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/cockroachdb/apd/v3"
+
 	"cuelang.org/go/pkg/internal/builtintest"
 	"cuelang.org/go/pkg/math"
 )
@@ -26,6 +28,29 @@ func TestBuiltin(t *testing.T) {
 	builtintest.Run("math", t)
 }
 
+func BenchmarkPow(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		x, y *apd.Decimal
+	}{
+		{"2^64", apd.New(2, 0), apd.New(64, 0)},
+		{"2^4096", apd.New(2, 0), apd.New(4096, 0)},
+		{"3^40", apd.New(3, 0), apd.New(40, 0)},
+		{"1000003^500", apd.New(1000003, 0), apd.New(500, 0)},
+		{"2.5^10", apd.New(25, -1), apd.New(10, 0)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := math.Pow(bm.x, bm.y); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func Example_constants() {
 	show := func(name string, value any) {
 		fmt.Printf("% 7s: %v\n", name, value)
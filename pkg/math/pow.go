@@ -0,0 +1,143 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package math
+
+import (
+	"sync"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"cuelang.org/go/internal"
+)
+
+// maxExactPowBits is the maximum size, in bits, of the result of an integer
+// power computed exactly. Larger results are computed with the default
+// decimal precision, like any other power.
+const maxExactPowBits = 1 << 16
+
+// maxCachedPowBits is the maximum size, in bits, of a cached integer power
+// and maxCachedPows the maximum number of cached powers.
+const (
+	maxCachedPowBits = 1 << 12
+	maxCachedPows    = 1024
+)
+
+type powKey struct {
+	base, exp int64
+}
+
+var powCache struct {
+	sync.Mutex
+	m map[powKey]*apd.BigInt
+}
+
+// intPow computes x**y exactly if x and y are integers, y is non-negative,
+// and the result is not too large. It reports whether it could do so.
+//
+// Unlike computing the power with a decimal context, the result is not
+// rounded to the context's precision. This keeps expressions like 2**100
+// integral and avoids the cost of the general algorithm.
+func intPow(z, x, y *internal.Decimal) bool {
+	if x.Form != apd.Finite || y.Form != apd.Finite ||
+		x.Exponent < 0 || y.Exponent < 0 || y.Negative {
+		return false
+	}
+	n, ok := smallInt(y)
+	if !ok {
+		return false
+	}
+	var b apd.BigInt
+	if x.Exponent == 0 {
+		b.Set(&x.Coeff)
+	} else {
+		if n > maxExactPowBits/int64(x.Exponent) {
+			return false
+		}
+		b.Exp(apd.NewBigInt(10), apd.NewBigInt(int64(x.Exponent)), nil)
+		b.Mul(&b, &x.Coeff)
+	}
+
+	switch bits := int64(b.BitLen()); {
+	case bits == 0:
+		// 0**0 == 1 and 0**n == 0.
+		z.SetInt64(0)
+		if n == 0 {
+			z.SetInt64(1)
+		}
+		return true
+
+	case bits > 1 && n > maxExactPowBits/(bits-1):
+		return false
+
+	case b.TrailingZeroBits() == uint(bits-1):
+		// b is a power of two: shift instead of multiplying.
+		z.Coeff.Lsh(apd.NewBigInt(1), uint(bits-1)*uint(n))
+
+	default:
+		cachedPow(&z.Coeff, &b, n)
+	}
+	z.Exponent = 0
+	z.Form = apd.Finite
+	z.Negative = x.Negative && n%2 == 1
+	return true
+}
+
+// cachedPow sets z to b**n, using or recording the result in a cache if b and
+// n are small.
+func cachedPow(z, b *apd.BigInt, n int64) {
+	if !b.IsInt64() || n > maxCachedPowBits/int64(b.BitLen()) {
+		z.Exp(b, apd.NewBigInt(n), nil)
+		return
+	}
+	key := powKey{b.Int64(), n}
+
+	powCache.Lock()
+	r, ok := powCache.m[key]
+	powCache.Unlock()
+	if ok {
+		z.Set(r)
+		return
+	}
+
+	z.Exp(b, apd.NewBigInt(n), nil)
+
+	powCache.Lock()
+	defer powCache.Unlock()
+	if powCache.m == nil {
+		powCache.m = map[powKey]*apd.BigInt{}
+	}
+	if len(powCache.m) < maxCachedPows {
+		powCache.m[key] = new(apd.BigInt).Set(z)
+	}
+}
+
+// smallInt returns the value of d if it is a non-negative integer that fits
+// in an int64.
+func smallInt(d *internal.Decimal) (int64, bool) {
+	if d.Exponent > 18 {
+		return 0, false
+	}
+	var i apd.BigInt
+	i.Set(&d.Coeff)
+	if d.Exponent > 0 {
+		var p apd.BigInt
+		p.Exp(apd.NewBigInt(10), apd.NewBigInt(int64(d.Exponent)), nil)
+		i.Mul(&i, &p)
+	}
+	if !i.IsInt64() {
+		return 0, false
+	}
+	return i.Int64(), true
+}
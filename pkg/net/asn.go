@@ -0,0 +1,94 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+)
+
+func netGetASN(asn cue.Value) (uint32, error) {
+	switch asn.Kind() {
+	case cue.IntKind:
+		n, err := asn.Int64()
+		if err != nil || n < 0 || math.MaxUint32 < n {
+			return 0, fmt.Errorf("invalid AS number %v", asn)
+		}
+		return uint32(n), nil
+
+	case cue.StringKind:
+		s, err := asn.String()
+		if err != nil {
+			return 0, err
+		}
+		return parseASN(s)
+
+	default:
+		return 0, fmt.Errorf("invalid AS number %v", asn)
+	}
+}
+
+// parseASN parses s in asplain ("64512") or asdot ("1.10") notation, as
+// defined in RFC 5396, optionally preceded by "AS".
+func parseASN(s string) (uint32, error) {
+	t := s
+	if len(t) > 2 && strings.EqualFold(t[:2], "AS") {
+		t = t[2:]
+	}
+	high, low, dot := strings.Cut(t, ".")
+	if !dot {
+		n, err := strconv.ParseUint(t, 10, 32)
+		if err != nil || (len(t) > 1 && t[0] == '0') {
+			return 0, fmt.Errorf("invalid AS number %q", s)
+		}
+		return uint32(n), nil
+	}
+	h, err1 := strconv.ParseUint(high, 10, 16)
+	l, err2 := strconv.ParseUint(low, 10, 16)
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("invalid AS number %q", s)
+	}
+	return uint32(h<<16 | l), nil
+}
+
+// ASN reports whether asn is a valid autonomous system number.
+//
+// The number may be an integer or a string in asplain ("64512") or asdot
+// ("1.10") notation, optionally preceded by "AS", as in "AS64512".
+func ASN(asn cue.Value) bool {
+	_, err := netGetASN(asn)
+	return err == nil
+}
+
+// ParseASN returns the value of the autonomous system number asn, which may
+// be an integer or a string in any of the forms accepted by ASN.
+func ParseASN(asn cue.Value) (int64, error) {
+	n, err := netGetASN(asn)
+	return int64(n), err
+}
+
+// PrivateASN reports whether asn is an autonomous system number reserved for
+// private use by RFC 6996.
+func PrivateASN(asn cue.Value) bool {
+	n, err := netGetASN(asn)
+	if err != nil {
+		return false
+	}
+	return (64512 <= n && n <= 65534) || (4200000000 <= n && n <= 4294967294)
+}
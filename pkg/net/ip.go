@@ -18,6 +18,7 @@ package net
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"cuelang.org/go/cue"
 )
@@ -229,3 +230,47 @@ func IPString(ip cue.Value) (string, error) {
 	}
 	return ipdata.String(), nil
 }
+
+// splitZone splits the scoped IPv6 address s into its address and zone,
+// returning nil if s is not a valid scoped address.
+func splitZone(s string) (ip net.IP, zone string) {
+	addr, zone, ok := strings.Cut(s, "%")
+	if !ok || zone == "" || strings.Contains(zone, "%") {
+		return nil, ""
+	}
+	ip = net.ParseIP(addr)
+	if ip == nil || ip.To4() != nil {
+		return nil, ""
+	}
+	return ip, zone
+}
+
+// ZonedIP reports whether s is an IPv6 address with a zone identifier, as
+// in "fe80::1%eth0", as used for link-local addresses.
+func ZonedIP(s string) bool {
+	ip, _ := splitZone(s)
+	return ip != nil
+}
+
+// SplitZone splits the scoped IPv6 address s, as in "fe80::1%eth0", into
+// its address, in canonical form, and its zone identifier.
+func SplitZone(s string) ([]string, error) {
+	ip, zone := splitZone(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid scoped IPv6 address %q", s)
+	}
+	return []string{ip.String(), zone}, nil
+}
+
+// JoinZone combines the IPv6 address ip, which may be a string or list of
+// bytes, and zone into a scoped address of the form "fe80::1%eth0".
+func JoinZone(ip cue.Value, zone string) (string, error) {
+	ipdata := netGetIP(ip)
+	if ipdata == nil || ipdata.To4() != nil {
+		return "", fmt.Errorf("invalid IPv6 address %s", ip)
+	}
+	if zone == "" || strings.Contains(zone, "%") {
+		return "", fmt.Errorf("invalid zone %q", zone)
+	}
+	return ipdata.String() + "%" + zone, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"fmt"
+	"net"
+
+	"cuelang.org/go/cue"
+)
+
+func netGetMAC(mac cue.Value) net.HardwareAddr {
+	switch mac.Kind() {
+	case cue.StringKind:
+		s, err := mac.String()
+		if err != nil {
+			return nil
+		}
+		return parseMAC(s)
+
+	case cue.ListKind:
+		iter, err := mac.List()
+		if err != nil {
+			return nil
+		}
+		var hw net.HardwareAddr
+		for iter.Next() {
+			v, err := iter.Value().Int64()
+			if err != nil || v < 0 || 255 < v {
+				return nil
+			}
+			hw = append(hw, byte(v))
+		}
+		if len(hw) != 6 && len(hw) != 8 {
+			return nil
+		}
+		return hw
+
+	default:
+		return nil
+	}
+}
+
+// parseMAC parses s as a MAC address, and returns nil if s is not a valid
+// 6 or 8-byte address.
+func parseMAC(s string) net.HardwareAddr {
+	hw, err := net.ParseMAC(s)
+	if err != nil || (len(hw) != 6 && len(hw) != 8) {
+		return nil
+	}
+	return hw
+}
+
+// MAC reports whether mac is a valid IEEE 802 MAC-48, EUI-48 or EUI-64
+// address.
+//
+// The address may be a list of bytes or a string in one of the forms
+// "00:00:5e:00:53:01", "00-00-5e-00-53-01" or "0000.5e00.5301".
+func MAC(mac cue.Value) bool {
+	return netGetMAC(mac) != nil
+}
+
+// ParseMAC parses s as an IEEE 802 MAC-48, EUI-48 or EUI-64 address and
+// returns its bytes.
+func ParseMAC(s string) ([]uint, error) {
+	hw := parseMAC(s)
+	if hw == nil {
+		return nil, fmt.Errorf("invalid MAC address %q", s)
+	}
+	return macToList(hw), nil
+}
+
+func macToList(hw net.HardwareAddr) []uint {
+	a := make([]uint, len(hw))
+	for i, p := range hw {
+		a[i] = uint(p)
+	}
+	return a
+}
+
+// MACString returns the canonical form of the MAC address mac, which uses
+// lower-case hexadecimal digits separated by colons, as in
+// "00:00:5e:00:53:01".
+//
+// The address may be a string or list of bytes.
+func MACString(mac cue.Value) (string, error) {
+	hw := netGetMAC(mac)
+	if hw == nil {
+		return "", fmt.Errorf("invalid MAC address %s", mac)
+	}
+	return hw.String(), nil
+}
+
+// EUI64 returns the IPv6 address formed from the network prefix and the
+// modified EUI-64 interface identifier derived from the MAC address mac, as
+// described in RFC 4291, Appendix A.
+//
+// The prefix is an IPv6 address in CIDR notation with a prefix length of at
+// most 64 bits, such as "2001:db8::/64". The MAC address may be a string or a
+// list of bytes.
+func EUI64(prefix string, mac cue.Value) (string, error) {
+	ip, ipnet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+	if ip.To4() != nil {
+		return "", fmt.Errorf("prefix %q is not an IPv6 prefix", prefix)
+	}
+	if ones, _ := ipnet.Mask.Size(); ones > 64 {
+		return "", fmt.Errorf("prefix %q is longer than 64 bits", prefix)
+	}
+	hw := netGetMAC(mac)
+	if hw == nil {
+		return "", fmt.Errorf("invalid MAC address %s", mac)
+	}
+
+	addr := make(net.IP, net.IPv6len)
+	copy(addr, ipnet.IP.To16()[:8])
+	if len(hw) == 6 {
+		copy(addr[8:], hw[:3])
+		addr[11], addr[12] = 0xff, 0xfe
+		copy(addr[13:], hw[3:])
+	} else {
+		copy(addr[8:], hw)
+	}
+	addr[8] ^= 0x02 // invert the universal/local bit
+	return addr.String(), nil
+}
@@ -15,6 +15,42 @@ var _ = adt.TopKind // in case the adt package isn't used
 
 var p = &pkg.Package{
 	Native: []*pkg.Builtin{{
+		Name: "ASN",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			asn := c.Value(0)
+			if c.Do() {
+				c.Ret = ASN(asn)
+			}
+		},
+	}, {
+		Name: "ParseASN",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			asn := c.Value(0)
+			if c.Do() {
+				c.Ret, c.Err = ParseASN(asn)
+			}
+		},
+	}, {
+		Name: "PrivateASN",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			asn := c.Value(0)
+			if c.Do() {
+				c.Ret = PrivateASN(asn)
+			}
+		},
+	}, {
 		Name: "SplitHostPort",
 		Params: []pkg.Param{
 			{Kind: adt.StringKind},
@@ -225,6 +261,92 @@ var p = &pkg.Package{
 				c.Ret, c.Err = IPString(ip)
 			}
 		},
+	}, {
+		Name: "ZonedIP",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			s := c.String(0)
+			if c.Do() {
+				c.Ret = ZonedIP(s)
+			}
+		},
+	}, {
+		Name: "SplitZone",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.ListKind,
+		Func: func(c *pkg.CallCtxt) {
+			s := c.String(0)
+			if c.Do() {
+				c.Ret, c.Err = SplitZone(s)
+			}
+		},
+	}, {
+		Name: "JoinZone",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			ip, zone := c.Value(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = JoinZone(ip, zone)
+			}
+		},
+	}, {
+		Name: "MAC",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			mac := c.Value(0)
+			if c.Do() {
+				c.Ret = MAC(mac)
+			}
+		},
+	}, {
+		Name: "ParseMAC",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.ListKind,
+		Func: func(c *pkg.CallCtxt) {
+			s := c.String(0)
+			if c.Do() {
+				c.Ret, c.Err = ParseMAC(s)
+			}
+		},
+	}, {
+		Name: "MACString",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			mac := c.Value(0)
+			if c.Do() {
+				c.Ret, c.Err = MACString(mac)
+			}
+		},
+	}, {
+		Name: "EUI64",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.TopKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			prefix, mac := c.String(0), c.Value(1)
+			if c.Do() {
+				c.Ret, c.Err = EUI64(prefix, mac)
+			}
+		},
 	}, {
 		Name: "PathEscape",
 		Params: []pkg.Param{
@@ -56,8 +56,15 @@ package regexp
 
 import (
 	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"sync"
 
 	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
 )
 
 var errNoMatch = errors.New("no match")
@@ -228,3 +235,94 @@ func Valid(pattern string) (bool, error) {
 	_, err := regexp.Compile(pattern)
 	return err == nil, err
 }
+
+// ValidPattern reports whether the given regular expression is valid. Unlike
+// Valid, an error reports the kind of problem along with the part of the
+// pattern at which it was found.
+func ValidPattern(pattern string) (bool, error) {
+	_, err := syntax.Parse(pattern, syntax.Perl)
+	if err == nil {
+		return true, nil
+	}
+	e, ok := err.(*syntax.Error)
+	if !ok {
+		return false, err
+	}
+	return false, pkg.ValidationError{B: &adt.Bottom{
+		Code: adt.EvalError,
+		Err:  errors.Newf(token.NoPos, "invalid pattern: %s: `%s`", e.Code, e.Expr),
+	}}
+}
+
+// maxSetCache is the maximum number of pattern sets kept by MatchAny.
+const maxSetCache = 64
+
+var setCache struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}
+
+// MatchAny reports whether the string s contains a match of any of the given
+// regular expressions. The patterns are compiled into a single regular
+// expression, so that s is scanned only once, regardless of the number of
+// patterns.
+func MatchAny(patterns []string, s string) (bool, error) {
+	re, err := compileSet(patterns)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+func compileSet(patterns []string) (*regexp.Regexp, error) {
+	// Prefix each pattern with its length, so that different lists of
+	// patterns map to different keys.
+	var kb strings.Builder
+	for _, p := range patterns {
+		kb.WriteString(strconv.Itoa(len(p)))
+		kb.WriteByte(':')
+		kb.WriteString(p)
+	}
+	key := kb.String()
+
+	setCache.Lock()
+	re, ok := setCache.m[key]
+	setCache.Unlock()
+	if ok {
+		return re, nil
+	}
+
+	var b strings.Builder
+	for i, p := range patterns {
+		// Compile each pattern separately to report which one is invalid.
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, errors.Wrapf(err, token.NoPos, "invalid pattern %d", i)
+		}
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		b.WriteString("(?:")
+		b.WriteString(p)
+		b.WriteString(")")
+	}
+	if len(patterns) == 0 {
+		// Match nothing.
+		b.WriteString(`[^\x00-\x{10FFFF}]`)
+	}
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, err
+	}
+
+	setCache.Lock()
+	if len(setCache.m) >= maxSetCache {
+		setCache.m = nil
+	}
+	if setCache.m == nil {
+		setCache.m = map[string]*regexp.Regexp{}
+	}
+	setCache.m[key] = re
+	setCache.Unlock()
+
+	return re, nil
+}
@@ -135,6 +135,31 @@ var p = &pkg.Package{
 				c.Ret, c.Err = Valid(pattern)
 			}
 		},
+	}, {
+		Name: "ValidPattern",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			pattern := c.String(0)
+			if c.Do() {
+				c.Ret, c.Err = ValidPattern(pattern)
+			}
+		},
+	}, {
+		Name: "MatchAny",
+		Params: []pkg.Param{
+			{Kind: adt.ListKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			patterns, s := c.StringList(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = MatchAny(patterns, s)
+			}
+		},
 	}, {
 		Name: "Match",
 		Params: []pkg.Param{
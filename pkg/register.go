@@ -25,7 +25,9 @@ import (
 	_ "cuelang.org/go/pkg/encoding/csv"
 	_ "cuelang.org/go/pkg/encoding/hex"
 	_ "cuelang.org/go/pkg/encoding/json"
+	_ "cuelang.org/go/pkg/encoding/textproto"
 	_ "cuelang.org/go/pkg/encoding/yaml"
+	_ "cuelang.org/go/pkg/hash"
 	_ "cuelang.org/go/pkg/html"
 
 	_ "cuelang.org/go/pkg/list"
@@ -34,6 +36,7 @@ import (
 	_ "cuelang.org/go/pkg/net"
 	_ "cuelang.org/go/pkg/path"
 	_ "cuelang.org/go/pkg/regexp"
+	_ "cuelang.org/go/pkg/semver"
 	_ "cuelang.org/go/pkg/strconv"
 	_ "cuelang.org/go/pkg/strings"
 	_ "cuelang.org/go/pkg/struct"
@@ -42,9 +45,14 @@ import (
 	_ "cuelang.org/go/pkg/time"
 	_ "cuelang.org/go/pkg/tool"
 	_ "cuelang.org/go/pkg/tool/cli"
+	_ "cuelang.org/go/pkg/tool/cloud"
 	_ "cuelang.org/go/pkg/tool/exec"
 	_ "cuelang.org/go/pkg/tool/file"
+	_ "cuelang.org/go/pkg/tool/git"
 	_ "cuelang.org/go/pkg/tool/http"
+	_ "cuelang.org/go/pkg/tool/oci"
 	_ "cuelang.org/go/pkg/tool/os"
+	_ "cuelang.org/go/pkg/tool/random"
+	_ "cuelang.org/go/pkg/tool/time"
 	_ "cuelang.org/go/pkg/uuid"
 )
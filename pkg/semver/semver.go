@@ -0,0 +1,223 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package semver implements parsing and comparison of semantic versions as
+// defined by Semantic Versioning 2.0.0 (see https://semver.org).
+//
+// Versions are of the form MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD] and may
+// optionally be prefixed with a "v".
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/internal/mod/semver"
+)
+
+// Parts holds the individual parts of a parsed semantic version.
+type Parts struct {
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	Prerelease string `json:"prerelease"`
+	Build      string `json:"build"`
+}
+
+// canonical returns v in the form accepted by the internal semver package,
+// which requires a leading "v". Unlike that package, the shorthand forms
+// vMAJOR and vMAJOR.MINOR are not accepted.
+func canonical(v string) (string, error) {
+	w := v
+	if !strings.HasPrefix(w, "v") {
+		w = "v" + w
+	}
+	if !semver.IsValid(w) || strings.Count(core(w), ".") != 2 {
+		return "", fmt.Errorf("invalid semantic version %q", v)
+	}
+	return w, nil
+}
+
+// Valid reports whether s is a valid semantic version.
+func Valid(s string) error {
+	_, err := canonical(s)
+	return err
+}
+
+// Parse parses a semantic version into its individual parts.
+// The prerelease and build fields are reported without their
+// leading "-" and "+" separators.
+func Parse(s string) (*Parts, error) {
+	v, err := canonical(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &Parts{
+		Prerelease: strings.TrimPrefix(semver.Prerelease(v), "-"),
+		Build:      strings.TrimPrefix(semver.Build(v), "+"),
+	}
+	nums := strings.Split(strings.TrimPrefix(core(v), "v"), ".")
+	for i, dst := range []*int{&p.Major, &p.Minor, &p.Patch} {
+		n, err := strconv.Atoi(nums[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic version %q: %v", s, err)
+		}
+		*dst = n
+	}
+	return p, nil
+}
+
+// Compare returns an integer comparing two versions according to
+// semantic version precedence.
+// The result will be 0 if a == b, -1 if a < b, or +1 if a > b.
+//
+// Build metadata is ignored, and a version with a prerelease
+// has lower precedence than the associated normal version.
+func Compare(a, b string) (int, error) {
+	va, err := canonical(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := canonical(b)
+	if err != nil {
+		return 0, err
+	}
+	return semver.Compare(va, vb), nil
+}
+
+// MatchesConstraint reports whether version v satisfies the given constraint.
+//
+// A constraint is a list of alternatives separated by "||", each of which is
+// a list of comparisons separated by whitespace or commas that must all hold.
+// A comparison is a version optionally preceded by one of the operators
+// =, !=, >, >=, <, <=, ^ (compatible with) and ~ (approximately), which may
+// be separated from the version by whitespace.
+//
+//	^1.2.3  matches >=1.2.3 <2.0.0
+//	^0.2.3  matches >=0.2.3 <0.3.0
+//	~1.2.3  matches >=1.2.3 <1.3.0
+//
+// As is customary, a version with a prerelease only matches a comparison if
+// the comparison's version has a prerelease for the same MAJOR.MINOR.PATCH.
+func MatchesConstraint(v, constraint string) (bool, error) {
+	cv, err := canonical(v)
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(constraint) == "" {
+		return false, fmt.Errorf("empty version constraint")
+	}
+	match := false
+	for _, alt := range strings.Split(constraint, "||") {
+		ok, err := matchAll(cv, alt)
+		if err != nil {
+			return false, fmt.Errorf("invalid version constraint %q: %v", constraint, err)
+		}
+		match = match || ok
+	}
+	return match, nil
+}
+
+func matchAll(v, alt string) (bool, error) {
+	terms := strings.FieldsFunc(alt, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(terms) == 0 {
+		return false, fmt.Errorf("empty alternative")
+	}
+	match := true
+	prereleaseAllowed := semver.Prerelease(v) == ""
+	for i := 0; i < len(terms); i++ {
+		op, ver := splitOp(terms[i])
+		if ver == "" {
+			// The operator is separated from its version, as in ">= 1.2.0".
+			if i++; i == len(terms) {
+				return false, fmt.Errorf("missing version after %q", op)
+			}
+			ver = terms[i]
+		}
+		w, err := canonical(ver)
+		if err != nil {
+			return false, err
+		}
+		if semver.Prerelease(w) != "" && sameCore(v, w) {
+			prereleaseAllowed = true
+		}
+		ok, err := compareOp(v, op, w)
+		if err != nil {
+			return false, err
+		}
+		match = match && ok
+	}
+	return match && prereleaseAllowed, nil
+}
+
+func splitOp(t string) (op, v string) {
+	for _, op := range []string{">=", "<=", "!=", "=", ">", "<", "^", "~"} {
+		if strings.HasPrefix(t, op) {
+			return op, t[len(op):]
+		}
+	}
+	return "=", t
+}
+
+func compareOp(v, op, w string) (bool, error) {
+	c := semver.Compare(v, w)
+	switch op {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case "^", "~":
+		p, err := Parse(w)
+		if err != nil {
+			return false, err
+		}
+		var upper string
+		switch {
+		case op == "~":
+			upper = fmt.Sprintf("v%d.%d.0-0", p.Major, p.Minor+1)
+		case p.Major > 0:
+			upper = fmt.Sprintf("v%d.0.0-0", p.Major+1)
+		case p.Minor > 0:
+			upper = fmt.Sprintf("v0.%d.0-0", p.Minor+1)
+		default:
+			upper = fmt.Sprintf("v0.0.%d-0", p.Patch+1)
+		}
+		return c >= 0 && semver.Compare(v, upper) < 0, nil
+	}
+	panic("unreachable")
+}
+
+// core returns v without its prerelease and build suffixes.
+func core(v string) string {
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		return v[:i]
+	}
+	return v
+}
+
+// sameCore reports whether v and w have the same MAJOR.MINOR.PATCH.
+func sameCore(v, w string) bool {
+	return core(v) == core(w)
+}
@@ -29,6 +29,12 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
 )
 
 // ByteAt reports the ith byte of the underlying strings or byte.
@@ -54,21 +60,39 @@ func Runes(s string) []rune {
 }
 
 // MinRunes reports whether the number of runes (Unicode codepoints) in a string
-// is at least a certain minimum. MinRunes can be used a field constraint to
-// except all strings for which this property holds.
-func MinRunes(s string, min int) bool {
+// is at least a certain minimum, inclusive. MinRunes can be used a field
+// constraint to except all strings for which this property holds.
+//
+// MinRunes maps to the minLength keyword of OpenAPI and JSON Schema.
+func MinRunes(s string, min int) (bool, error) {
 	// TODO: CUE strings cannot be invalid UTF-8. In case this changes, we need
 	// to use the following conversion to count properly:
 	// s, _ = unicodeenc.UTF8.NewDecoder().String(s)
-	return len([]rune(s)) >= min
+	count := utf8.RuneCountInString(s)
+	if count < min {
+		return false, pkg.ValidationError{B: &adt.Bottom{
+			Code: adt.EvalError,
+			Err:  errors.Newf(token.NoPos, "len(runes) < MinRunes(%[2]d) (%[1]d < %[2]d)", count, min),
+		}}
+	}
+	return true, nil
 }
 
 // MaxRunes reports whether the number of runes (Unicode codepoints) in a string
-// exceeds a certain maximum. MaxRunes can be used a field constraint to
-// except all strings for which this property holds
-func MaxRunes(s string, max int) bool {
+// is at most a certain maximum, inclusive. MaxRunes can be used a field
+// constraint to except all strings for which this property holds.
+//
+// MaxRunes maps to the maxLength keyword of OpenAPI and JSON Schema.
+func MaxRunes(s string, max int) (bool, error) {
 	// See comment in MinRunes implementation.
-	return len([]rune(s)) <= max
+	count := utf8.RuneCountInString(s)
+	if count > max {
+		return false, pkg.ValidationError{B: &adt.Bottom{
+			Code: adt.EvalError,
+			Err:  errors.Newf(token.NoPos, "len(runes) > MaxRunes(%[2]d) (%[1]d > %[2]d)", count, max),
+		}}
+	}
+	return true, nil
 }
 
 // ToTitle returns a copy of the string s with all Unicode letters that begin
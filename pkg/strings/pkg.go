@@ -63,7 +63,7 @@ var p = &pkg.Package{
 		Func: func(c *pkg.CallCtxt) {
 			s, min := c.String(0), c.Int(1)
 			if c.Do() {
-				c.Ret = MinRunes(s, min)
+				c.Ret, c.Err = MinRunes(s, min)
 			}
 		},
 	}, {
@@ -76,7 +76,7 @@ var p = &pkg.Package{
 		Func: func(c *pkg.CallCtxt) {
 			s, max := c.String(0), c.Int(1)
 			if c.Do() {
-				c.Ret = MaxRunes(s, max)
+				c.Ret, c.Err = MaxRunes(s, max)
 			}
 		},
 	}, {
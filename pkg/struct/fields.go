@@ -0,0 +1,145 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"regexp"
+
+	"cuelang.org/go/cue"
+)
+
+// FieldNames returns the names of the regular fields of object, in the order
+// in which they are defined. Hidden fields, optional fields, and definitions
+// are not included.
+//
+// For instance,
+//
+//	FieldNames({b: 1, a: 2})
+//
+// results in
+//
+//	["b", "a"]
+func FieldNames(object cue.Value) ([]string, error) {
+	return fieldNames(object, nil)
+}
+
+// FieldNamesMatching is like FieldNames, but only returns the names that
+// match the regular expression pattern.
+//
+// For instance,
+//
+//	FieldNamesMatching({"app.kubernetes.io/name": "a", team: "b"}, "^app\\.")
+//
+// results in
+//
+//	["app.kubernetes.io/name"]
+func FieldNamesMatching(object cue.Value, pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return fieldNames(object, re)
+}
+
+func fieldNames(object cue.Value, re *regexp.Regexp) ([]string, error) {
+	iter, err := object.Fields()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for iter.Next() {
+		name := iter.Selector().Unquoted()
+		if re == nil || re.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Values returns the values of the regular fields of object, in the order in
+// which they are defined.
+func Values(object cue.Value) ([]cue.Value, error) {
+	iter, err := object.Fields()
+	if err != nil {
+		return nil, err
+	}
+	values := []cue.Value{}
+	for iter.Next() {
+		values = append(values, iter.Value())
+	}
+	return values, nil
+}
+
+// ToList converts the regular fields of object to a list of structs with
+// fields key and value, in the order in which they are defined.
+//
+// For instance,
+//
+//	ToList({a: 1, b: 2})
+//
+// results in
+//
+//	[{key: "a", value: 1}, {key: "b", value: 2}]
+func ToList(object cue.Value) ([]map[string]interface{}, error) {
+	iter, err := object.Fields()
+	if err != nil {
+		return nil, err
+	}
+	list := []map[string]interface{}{}
+	for iter.Next() {
+		list = append(list, map[string]interface{}{
+			"key":   iter.Selector().Unquoted(),
+			"value": iter.Value(),
+		})
+	}
+	return list, nil
+}
+
+// FromList converts a list of structs with fields key and value, as
+// returned by ToList, to a struct. Values of elements with the same key are
+// unified.
+//
+// For instance,
+//
+//	FromList([{key: "a", value: 1}, {key: "b", value: 2}])
+//
+// results in
+//
+//	{a: 1, b: 2}
+func FromList(list []cue.Value) (interface{}, error) {
+	if len(list) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	var result cue.Value
+	for i, elem := range list {
+		key, err := elem.LookupPath(cue.MakePath(cue.Str("key"))).String()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key for element %d: %v", i, err)
+		}
+		value := elem.LookupPath(cue.MakePath(cue.Str("value")))
+		if !value.Exists() {
+			return nil, fmt.Errorf("missing value for element %d", i)
+		}
+		if i == 0 {
+			result = elem.Context().CompileString("{}")
+		}
+		result = result.FillPath(cue.MakePath(cue.Str(key)), value)
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
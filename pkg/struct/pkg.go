@@ -15,6 +15,67 @@ var _ = adt.TopKind // in case the adt package isn't used
 
 var p = &pkg.Package{
 	Native: []*pkg.Builtin{{
+		Name: "FieldNames",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.ListKind,
+		Func: func(c *pkg.CallCtxt) {
+			object := c.Value(0)
+			if c.Do() {
+				c.Ret, c.Err = FieldNames(object)
+			}
+		},
+	}, {
+		Name: "FieldNamesMatching",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+			{Kind: adt.StringKind},
+		},
+		Result: adt.ListKind,
+		Func: func(c *pkg.CallCtxt) {
+			object, pattern := c.Value(0), c.String(1)
+			if c.Do() {
+				c.Ret, c.Err = FieldNamesMatching(object, pattern)
+			}
+		},
+	}, {
+		Name: "Values",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.ListKind,
+		Func: func(c *pkg.CallCtxt) {
+			object := c.Value(0)
+			if c.Do() {
+				c.Ret, c.Err = Values(object)
+			}
+		},
+	}, {
+		Name: "ToList",
+		Params: []pkg.Param{
+			{Kind: adt.TopKind},
+		},
+		Result: adt.ListKind,
+		Func: func(c *pkg.CallCtxt) {
+			object := c.Value(0)
+			if c.Do() {
+				c.Ret, c.Err = ToList(object)
+			}
+		},
+	}, {
+		Name: "FromList",
+		Params: []pkg.Param{
+			{Kind: adt.ListKind},
+		},
+		Result: adt.TopKind,
+		Func: func(c *pkg.CallCtxt) {
+			list := c.List(0)
+			if c.Do() {
+				c.Ret, c.Err = FromList(list)
+			}
+		},
+	}, {
 		Name: "MinFields",
 		Params: []pkg.Param{
 			{Kind: adt.StructKind},
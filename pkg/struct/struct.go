@@ -23,10 +23,13 @@ import (
 )
 
 // MinFields validates the minimum number of fields that are part of a struct.
-// It can only be used as a validator, for instance `MinFields(3)`.
+// It can only be used as a validator, for instance `MinFields(3)`. The bound is
+// inclusive: a struct with exactly n fields is valid.
 //
 // Only fields that are part of the data model count. This excludes hidden
 // fields, optional fields, and definitions.
+//
+// MinFields maps to the minProperties keyword of OpenAPI and JSON Schema.
 func MinFields(object pkg.Struct, n int) (bool, error) {
 	count := object.Len()
 	code := adt.EvalError
@@ -43,10 +46,13 @@ func MinFields(object pkg.Struct, n int) (bool, error) {
 }
 
 // MaxFields validates the maximum number of fields that are part of a struct.
-// It can only be used as a validator, for instance `MaxFields(3)`.
+// It can only be used as a validator, for instance `MaxFields(3)`. The bound is
+// inclusive: a struct with exactly n fields is valid.
 //
 // Only fields that are part of the data model count. This excludes hidden
 // fields, optional fields, and definitions.
+//
+// MaxFields maps to the maxProperties keyword of OpenAPI and JSON Schema.
 func MaxFields(object pkg.Struct, n int) (bool, error) {
 	count := object.Len()
 	if count > n {
@@ -15,15 +15,61 @@
 package template
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"text/template"
 
+	"gopkg.in/yaml.v3"
+
 	"cuelang.org/go/cue"
 )
 
 // Execute executes a Go-style template.
 func Execute(templ string, data cue.Value) (string, error) {
-	t, err := template.New("").Parse(templ)
+	return execute(template.New(""), templ, data)
+}
+
+// options defines the configuration accepted by ExecuteWithOptions.
+// See #Options for a description of the fields.
+type options struct {
+	Delims  []string `json:"delims"`
+	Helpers bool     `json:"helpers"`
+}
+
+// ExecuteWithOptions executes a Go-style template using the given options,
+// which must be an instance of #Options.
+//
+// Setting delims allows a template to produce output that itself contains
+// Go template actions, as in Helm charts. Setting helpers makes the
+// following functions available in addition to the standard ones:
+//
+//	indent n s   prefixes each non-empty line of s with n spaces
+//	nindent n s  like indent, but preceded by a newline
+//	quote s      formats s as a double-quoted string
+//	toJson v     encodes v as JSON
+//	toYaml v     encodes v as YAML, without a trailing newline
+func ExecuteWithOptions(templ string, data, opts cue.Value) (string, error) {
+	var o options
+	if err := opts.Decode(&o); err != nil {
+		return "", err
+	}
+	t := template.New("")
+	switch len(o.Delims) {
+	case 0:
+	case 2:
+		t.Delims(o.Delims[0], o.Delims[1])
+	default:
+		return "", fmt.Errorf("delims must have two elements, found %d", len(o.Delims))
+	}
+	if o.Helpers {
+		t.Funcs(helpers)
+	}
+	return execute(t, templ, data)
+}
+
+func execute(t *template.Template, templ string, data cue.Value) (string, error) {
+	t, err := t.Parse(templ)
 	if err != nil {
 		return "", err
 	}
@@ -37,3 +83,28 @@ func Execute(templ string, data cue.Value) (string, error) {
 	}
 	return b.String(), nil
 }
+
+var helpers = template.FuncMap{
+	"indent":  indent,
+	"nindent": func(n int, s string) string { return "\n" + indent(n, s) },
+	"quote":   func(s string) string { return fmt.Sprintf("%q", s) },
+	"toJson": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"toYaml": func(v interface{}) (string, error) {
+		b, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(b), "\n"), err
+	},
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = pad + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
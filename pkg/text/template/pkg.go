@@ -27,6 +27,20 @@ var p = &pkg.Package{
 				c.Ret, c.Err = Execute(templ, data)
 			}
 		},
+	}, {
+		Name: "ExecuteWithOptions",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.TopKind},
+			{Kind: adt.TopKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			templ, data, opts := c.String(0), c.Value(1), c.Value(2)
+			if c.Do() {
+				c.Ret, c.Err = ExecuteWithOptions(templ, data, opts)
+			}
+		},
 	}, {
 		Name: "HTMLEscape",
 		Params: []pkg.Param{
@@ -52,4 +66,10 @@ var p = &pkg.Package{
 			}
 		},
 	}},
+	CUE: `{
+	#Options: {
+		delims?: [string, string]
+		helpers: *false | bool
+	}
+}`,
 }
@@ -0,0 +1,202 @@
+// Copyright 2023 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package time
+
+import (
+	"fmt"
+	"time"
+)
+
+// The functions in this file implement a simple business calendar in which
+// Saturdays, Sundays and an explicit list of holidays are non-working days.
+// Holidays are given as dates in RFC3339Date format ("2006-01-02"), which
+// allows calendars to be maintained as plain CUE data.
+//
+// Times may be passed either as a date in RFC3339Date format or as a
+// date-time in RFC3339 format, in which case only the date part, in the
+// time's own offset, is considered.
+
+type calendar map[civilDate]bool
+
+// maxWorkingDays bounds the number of working days that AddWorkingDays
+// steps through one by one.
+const maxWorkingDays = 100000
+
+type civilDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) civilDate {
+	y, m, d := t.Date()
+	return civilDate{y, m, d}
+}
+
+func newCalendar(holidays []string) (calendar, error) {
+	c := calendar{}
+	for _, h := range holidays {
+		t, err := time.Parse(RFC3339Date, h)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: must be of the form %s", h, RFC3339Date)
+		}
+		c[dateOf(t)] = true
+	}
+	return c, nil
+}
+
+// isWorkingDay reports whether t is a working day and, if not, why.
+func (c calendar) isWorkingDay(t time.Time) (ok bool, reason string) {
+	switch wd := t.Weekday(); {
+	case wd == time.Saturday || wd == time.Sunday:
+		return false, "a " + wd.String()
+	case c[dateOf(t)]:
+		return false, "a holiday"
+	}
+	return true, ""
+}
+
+// parseDay parses either an RFC3339Date or an RFC3339 time string. It reports
+// whether s was a plain date so that results can be formatted in kind.
+func parseDay(s string) (t time.Time, isDate bool, err error) {
+	if t, err := time.Parse(RFC3339Date, s); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return t, false, fmt.Errorf("invalid time %q", s)
+	}
+	return t, false, nil
+}
+
+// WorkingDay validates that the given date or time falls on a working day:
+// a weekday that is not listed in holidays.
+//
+// For example
+//
+//	cutoff: time.WorkingDay(["2023-12-25", "2023-12-26"])
+//
+// only accepts dates on weekdays other than Christmas and Boxing Day 2023.
+func WorkingDay(t string, holidays []string) (bool, error) {
+	c, err := newCalendar(holidays)
+	if err != nil {
+		return false, err
+	}
+	d, _, err := parseDay(t)
+	if err != nil {
+		return false, err
+	}
+	if ok, reason := c.isWorkingDay(d); !ok {
+		return false, fmt.Errorf("%s is not a working day: it is %s", t, reason)
+	}
+	return true, nil
+}
+
+// IsWorkingDay reports whether the given date or time falls on a working day:
+// a weekday that is not listed in holidays.
+func IsWorkingDay(t string, holidays []string) (bool, error) {
+	c, err := newCalendar(holidays)
+	if err != nil {
+		return false, err
+	}
+	d, _, err := parseDay(t)
+	if err != nil {
+		return false, err
+	}
+	ok, _ := c.isWorkingDay(d)
+	return ok, nil
+}
+
+// AddWorkingDays returns the date or time that lies n working days after t,
+// skipping weekends and the given holidays. A negative n counts backwards.
+// If n is zero and t is not a working day, t is moved forward to the next
+// working day.
+//
+// The result has the same format as t: a date for dates and an RFC3339 time
+// for times, in which case the time of day and offset are preserved.
+// It is an error for n to exceed 100000 working days, or about 380 years,
+// in either direction.
+func AddWorkingDays(t string, n int, holidays []string) (string, error) {
+	if n > maxWorkingDays || n < -maxWorkingDays {
+		return "", fmt.Errorf("number of working days %d out of range [-%d, %d]", n, maxWorkingDays, maxWorkingDays)
+	}
+	c, err := newCalendar(holidays)
+	if err != nil {
+		return "", err
+	}
+	d, isDate, err := parseDay(t)
+	if err != nil {
+		return "", err
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for ok, _ := c.isWorkingDay(d); n == 0 && !ok; ok, _ = c.isWorkingDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	for ; n > 0; n-- {
+		d = d.AddDate(0, 0, step)
+		for ok, _ := c.isWorkingDay(d); !ok; ok, _ = c.isWorkingDay(d) {
+			d = d.AddDate(0, 0, step)
+		}
+	}
+	if isDate {
+		return d.Format(RFC3339Date), nil
+	}
+	return d.Format(time.RFC3339Nano), nil
+}
+
+// WorkingDaysBetween reports the number of working days in the half-open
+// interval [start, end), skipping weekends and the given holidays. The result
+// is negative if end lies before start.
+func WorkingDaysBetween(start, end string, holidays []string) (int, error) {
+	c, err := newCalendar(holidays)
+	if err != nil {
+		return 0, err
+	}
+	s, _, err := parseDay(start)
+	if err != nil {
+		return 0, err
+	}
+	e, _, err := parseDay(end)
+	if err != nil {
+		return 0, err
+	}
+	sign := 1
+	from, to := dateOf(s), dateOf(e)
+	if dayBefore(to, from) {
+		from, to, sign = to, from, -1
+	}
+	n := 0
+	for d := from; dayBefore(d, to); {
+		t := time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC)
+		if ok, _ := c.isWorkingDay(t); ok {
+			n++
+		}
+		d = dateOf(t.AddDate(0, 0, 1))
+	}
+	return sign * n, nil
+}
+
+func dayBefore(a, b civilDate) bool {
+	if a.year != b.year {
+		return a.year < b.year
+	}
+	if a.month != b.month {
+		return a.month < b.month
+	}
+	return a.day < b.day
+}
@@ -15,6 +15,60 @@ var _ = adt.TopKind // in case the adt package isn't used
 
 var p = &pkg.Package{
 	Native: []*pkg.Builtin{{
+		Name: "WorkingDay",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.ListKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			t, holidays := c.String(0), c.StringList(1)
+			if c.Do() {
+				c.Ret, c.Err = WorkingDay(t, holidays)
+			}
+		},
+	}, {
+		Name: "IsWorkingDay",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.ListKind},
+		},
+		Result: adt.BoolKind,
+		Func: func(c *pkg.CallCtxt) {
+			t, holidays := c.String(0), c.StringList(1)
+			if c.Do() {
+				c.Ret, c.Err = IsWorkingDay(t, holidays)
+			}
+		},
+	}, {
+		Name: "AddWorkingDays",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.IntKind},
+			{Kind: adt.ListKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			t, n, holidays := c.String(0), c.Int(1), c.StringList(2)
+			if c.Do() {
+				c.Ret, c.Err = AddWorkingDays(t, n, holidays)
+			}
+		},
+	}, {
+		Name: "WorkingDaysBetween",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.StringKind},
+			{Kind: adt.ListKind},
+		},
+		Result: adt.IntKind,
+		Func: func(c *pkg.CallCtxt) {
+			start, end, holidays := c.String(0), c.String(1), c.StringList(2)
+			if c.Do() {
+				c.Ret, c.Err = WorkingDaysBetween(start, end, holidays)
+			}
+		},
+	}, {
 		Name:  "Nanosecond",
 		Const: "1",
 	}, {
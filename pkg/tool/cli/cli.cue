@@ -12,6 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package cli provides tasks dealing with a console.
+//
+// These are the supported tasks:
 package cli
 
 // Print sends text to the stdout of the current process.
@@ -0,0 +1,232 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+const awsMetadataEndpoint = "http://169.254.169.254"
+
+// now reports the time used to sign requests. It is replaced in tests.
+var now = time.Now
+
+// awsMetadata reads path from the EC2 instance metadata service. It first
+// obtains a session token, as required by IMDSv2.
+func awsMetadata(ctx context.Context, endpoint, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "PUT",
+		endpointURL(endpoint, awsMetadataEndpoint, "latest/api/token"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := do(req, plainText)
+	if err != nil {
+		return "", err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, "GET",
+		endpointURL(endpoint, awsMetadataEndpoint, "latest/meta-data/"+path), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	b, err := do(req, plainText)
+	return string(b), err
+}
+
+type awsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string `json:"Token"`
+}
+
+func awsStaticCredentials(v cue.Value) (c awsCredentials, err error) {
+	for _, f := range []struct {
+		name string
+		dst  *string
+	}{
+		{"accessKeyID", &c.AccessKeyID},
+		{"secretAccessKey", &c.SecretAccessKey},
+		{"sessionToken", &c.SessionToken},
+	} {
+		if *f.dst, err = optField(v, f.name); err != nil {
+			return c, err
+		}
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return c, errors.Newf(v.Pos(),
+			"credentials must set accessKeyID and secretAccessKey or fromMetadata for provider \"aws\"")
+	}
+	return c, nil
+}
+
+// awsMetadataCredentials obtains the credentials of the IAM role of the
+// instance from the instance metadata service.
+func awsMetadataCredentials(ctx context.Context, endpoint string) (c awsCredentials, err error) {
+	const path = "iam/security-credentials/"
+	role, err := awsMetadata(ctx, endpoint, path)
+	if err != nil {
+		return c, err
+	}
+	role, _, _ = strings.Cut(strings.TrimSpace(role), "\n")
+	if role == "" {
+		return c, fmt.Errorf("instance has no IAM role")
+	}
+	s, err := awsMetadata(ctx, endpoint, path+role)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal([]byte(s), &c); err != nil {
+		return c, fmt.Errorf("invalid credentials for role %s: %v", role, err)
+	}
+	return c, nil
+}
+
+// awsValue fetches the value of the secret from AWS Secrets Manager.
+func (s *secret) awsValue(ctx context.Context, key awsCredentials) (string, error) {
+	in := map[string]string{"SecretId": s.name}
+	if s.version != "" {
+		if _, err := uuid.Parse(s.version); err == nil {
+			in["VersionId"] = s.version
+		} else {
+			in["VersionStage"] = s.version
+		}
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+	u := endpointURL(s.endpoint, "https://secretsmanager."+s.region+".amazonaws.com", "")
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, body, key, s.region, "secretsmanager", now())
+
+	b, err := do(req, func(body []byte) string {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string
+		}
+		if json.Unmarshal(body, &e) != nil {
+			return ""
+		}
+		return strings.TrimLeft(e.Type+": "+e.Message, ": ")
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot get secret %s: %v", s.name, err)
+	}
+	var out struct {
+		SecretString *string
+		SecretBinary []byte
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return "", fmt.Errorf("cannot get secret %s: %v", s.name, err)
+	}
+	if out.SecretString != nil {
+		return *out.SecretString, nil
+	}
+	if !utf8.Valid(out.SecretBinary) {
+		return "", fmt.Errorf("secret %s is not valid UTF-8", s.name)
+	}
+	return string(out.SecretBinary), nil
+}
+
+// signV4 adds the headers to req that authenticate it with AWS Signature
+// Version 4, where body is the body of req and t the time of signing. All
+// headers set in req are signed.
+func signV4(req *http.Request, body []byte, key awsCredentials, region, service string, t time.Time) {
+	t = t.UTC()
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	if key.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", key.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.Join(v, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", k, strings.TrimSpace(headers[k]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// Values.Encode sorts by key, as required.
+	query := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		query,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		req.Header.Get("X-Amz-Date"),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	k := hmacSHA256([]byte("AWS4"+key.SecretAccessKey), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, service)
+	k = hmacSHA256(k, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(k, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		key.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
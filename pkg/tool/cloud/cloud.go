@@ -0,0 +1,221 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/cloud.Metadata", newMetadataCmd)
+	task.Register("tool/cloud.Secret", newSecretCmd)
+}
+
+// client is used for all requests. The timeout ensures that a command does
+// not hang when a metadata server is unreachable, such as when the command
+// does not run on a cloud instance.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+type metadataCmd struct{}
+
+func newMetadataCmd(v cue.Value) (task.Runner, error) {
+	return &metadataCmd{}, nil
+}
+
+func (c *metadataCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	provider := ctx.String("provider")
+	path := ctx.String("path")
+	endpoint := optString(ctx, "endpoint")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	var s string
+	switch provider {
+	case "aws":
+		s, err = awsMetadata(reqContext(ctx), endpoint, path)
+	case "gcp":
+		s, err = gcpMetadata(reqContext(ctx), endpoint, path)
+	default:
+		return nil, errors.Newf(ctx.Obj.Pos(), "unsupported provider %q", provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"value": s}, nil
+}
+
+type secretCmd struct{}
+
+func newSecretCmd(v cue.Value) (task.Runner, error) {
+	return &secretCmd{}, nil
+}
+
+func (c *secretCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	provider := ctx.String("provider")
+	s := secret{
+		name:     ctx.String("name"),
+		version:  optString(ctx, "version"),
+		endpoint: optString(ctx, "endpoint"),
+	}
+	creds := ctx.Obj.LookupPath(cue.ParsePath("credentials"))
+	fromMetadata, err := creds.LookupPath(cue.ParsePath("fromMetadata")).Bool()
+	if err != nil {
+		return nil, errors.Wrapf(err, creds.Pos(), "invalid fromMetadata")
+	}
+	metadataEndpoint, err := optField(creds, "metadataEndpoint")
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	var value string
+	switch provider {
+	case "aws":
+		if s.region, err = requiredField(ctx.Obj, "region", provider); err != nil {
+			return nil, err
+		}
+		var key awsCredentials
+		if fromMetadata {
+			key, err = awsMetadataCredentials(reqContext(ctx), metadataEndpoint)
+		} else {
+			key, err = awsStaticCredentials(creds)
+		}
+		if err != nil {
+			return nil, err
+		}
+		value, err = s.awsValue(reqContext(ctx), key)
+
+	case "gcp":
+		if s.project, err = requiredField(ctx.Obj, "project", provider); err != nil {
+			return nil, err
+		}
+		var token string
+		if fromMetadata {
+			token, err = gcpMetadataToken(reqContext(ctx), metadataEndpoint)
+		} else {
+			token, err = gcpStaticToken(creds)
+		}
+		if err != nil {
+			return nil, err
+		}
+		value, err = s.gcpValue(reqContext(ctx), token)
+
+	default:
+		return nil, errors.Newf(ctx.Obj.Pos(), "unsupported provider %q", provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"value": value}, nil
+}
+
+// A secret identifies the version of a secret to fetch.
+type secret struct {
+	name     string
+	version  string
+	region   string // AWS only
+	project  string // GCP only
+	endpoint string
+}
+
+func reqContext(ctx *task.Context) context.Context {
+	if ctx.Context != nil {
+		return ctx.Context
+	}
+	return context.Background()
+}
+
+// optString returns the value of the optional string field of the task,
+// or "" if it is not set.
+func optString(ctx *task.Context, field string) string {
+	if !ctx.Obj.Lookup(field).Exists() {
+		return ""
+	}
+	return ctx.String(field)
+}
+
+// optField returns the value of the optional string field of v, or "" if
+// it is not set.
+func optField(v cue.Value, field string) (string, error) {
+	f := v.LookupPath(cue.ParsePath(field))
+	if !f.Exists() {
+		return "", nil
+	}
+	s, err := f.String()
+	if err != nil {
+		return "", errors.Wrapf(err, f.Pos(), "invalid %s", field)
+	}
+	return s, nil
+}
+
+func requiredField(v cue.Value, field, provider string) (string, error) {
+	s, err := optField(v, field)
+	if err == nil && s == "" {
+		err = errors.Newf(v.Pos(), "%s must be set for provider %q", field, provider)
+	}
+	return s, err
+}
+
+// do performs a request and returns the body of a successful response.
+// The body of an unsuccessful response is passed to describe to obtain a
+// description of the error.
+func do(req *http.Request, describe func(body []byte) string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(describe(body))
+		if msg == "" {
+			msg = resp.Status
+		} else {
+			msg = resp.Status + ": " + msg
+		}
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), msg)
+	}
+	return body, nil
+}
+
+// endpointURL returns the URL for path on the given endpoint, or on def if
+// endpoint is empty.
+func endpointURL(endpoint, def, path string) string {
+	if endpoint == "" {
+		endpoint = def
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// plainText describes an error from a response body holding a plain text
+// message.
+func plainText(body []byte) string {
+	return string(body)
+}
@@ -0,0 +1,261 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/task"
+	"cuelang.org/go/internal/value"
+)
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite.
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	signV4(req, nil, key, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	got := req.Header.Get("Authorization")
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+// newServer returns a server for both metadata servers and both secret
+// services.
+func newServer(t *testing.T) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/latest/api/token":
+			if r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				http.Error(w, "missing ttl", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, "imds-token")
+
+		case strings.HasPrefix(r.URL.Path, "/latest/meta-data/"):
+			if r.Header.Get("X-aws-ec2-metadata-token") != "imds-token" {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			switch strings.TrimPrefix(r.URL.Path, "/latest/meta-data/") {
+			case "placement/region":
+				fmt.Fprint(w, "eu-west-1")
+			case "iam/security-credentials/":
+				fmt.Fprint(w, "web-role\n")
+			case "iam/security-credentials/web-role":
+				fmt.Fprint(w, `{"AccessKeyId": "ROLEKEY", "SecretAccessKey": "rolesecret", "Token": "roletoken"}`)
+			default:
+				http.NotFound(w, r)
+			}
+
+		case strings.HasPrefix(r.URL.Path, "/computeMetadata/v1/"):
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				http.Error(w, "", http.StatusForbidden)
+				return
+			}
+			switch strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/") {
+			case "instance/zone":
+				fmt.Fprint(w, "projects/1/zones/europe-west1-b")
+			case "instance/service-accounts/default/token":
+				fmt.Fprint(w, `{"access_token": "sa-token", "expires_in": 3599}`)
+			default:
+				http.NotFound(w, r)
+			}
+
+		case r.Method == "POST" && r.URL.Path == "/":
+			auth := r.Header.Get("Authorization")
+			if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+				!strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=") ||
+				!strings.Contains(auth, "/eu-west-1/secretsmanager/aws4_request") {
+				http.Error(w, "", http.StatusBadRequest)
+				return
+			}
+			if strings.Contains(auth, "ROLEKEY") && r.Header.Get("X-Amz-Security-Token") != "roletoken" {
+				http.Error(w, "", http.StatusForbidden)
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			var in map[string]string
+			json.Unmarshal(b, &in)
+			switch in["SecretId"] {
+			case "db-password":
+				json.NewEncoder(w).Encode(map[string]string{
+					"SecretString": "hunter2 " + in["VersionStage"] + in["VersionId"],
+				})
+			case "cert":
+				json.NewEncoder(w).Encode(map[string][]byte{"SecretBinary": []byte("binary")})
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type": "ResourceNotFoundException", "Message": "Secrets Manager can't find the specified secret."}`)
+			}
+
+		case strings.HasPrefix(r.URL.Path, "/v1/projects/"):
+			if r.Header.Get("Authorization") != "Bearer sa-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error": {"code": 401, "message": "Request had invalid authentication credentials."}}`)
+				return
+			}
+			if r.URL.Path != "/v1/projects/proj/secrets/db-password/versions/latest:access" {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"payload": map[string][]byte{"data": []byte("s3cret")},
+			})
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func run(t *testing.T, r task.Runner, kind, src string) (interface{}, error) {
+	x, err := parser.ParseExpr("test", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rt cue.Runtime
+	i, err := rt.CompileExpr(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := value.UnifyBuiltin(i.Value(), kind)
+	return r.Run(&task.Context{Obj: v})
+}
+
+func TestMetadata(t *testing.T) {
+	s := newServer(t)
+	testCases := []struct {
+		in  string
+		out string
+		err string
+	}{{
+		in:  `provider: "aws", path: "placement/region"`,
+		out: "eu-west-1",
+	}, {
+		in:  `provider: "gcp", path: "instance/zone"`,
+		out: "projects/1/zones/europe-west1-b",
+	}, {
+		in:  `provider: "gcp", path: "instance/missing"`,
+		err: "GET " + s.URL + "/computeMetadata/v1/instance/missing: 404 Not Found: 404 page not found",
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			got, err := run(t, &metadataCmd{}, "tool/cloud.Metadata",
+				fmt.Sprintf("{%s, endpoint: %q}", tc.in, s.URL))
+			checkResult(t, got, err, tc.out, tc.err)
+		})
+	}
+}
+
+func TestSecret(t *testing.T) {
+	s := newServer(t)
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	aws := `provider: "aws", region: "eu-west-1", `
+	static := `credentials: {accessKeyID: "AKID", secretAccessKey: "secret"}`
+	testCases := []struct {
+		in  string
+		out string
+		err string
+	}{{
+		in:  aws + `name: "db-password", ` + static,
+		out: "hunter2 ",
+	}, {
+		in:  aws + `name: "db-password", version: "AWSPREVIOUS", ` + static,
+		out: "hunter2 AWSPREVIOUS",
+	}, {
+		in:  aws + `name: "db-password", version: "8a3e6c7e-4b0d-4c2e-9a62-1f3f0f0a4e11", ` + static,
+		out: "hunter2 8a3e6c7e-4b0d-4c2e-9a62-1f3f0f0a4e11",
+	}, {
+		in:  aws + `name: "cert", ` + static,
+		out: "binary",
+	}, {
+		in:  aws + `name: "db-password", credentials: {fromMetadata: true, metadataEndpoint: "` + s.URL + `"}`,
+		out: "hunter2 ",
+	}, {
+		in: aws + `name: "missing", ` + static,
+		err: "cannot get secret missing: POST " + s.URL + "/: 400 Bad Request: " +
+			"ResourceNotFoundException: Secrets Manager can't find the specified secret.",
+	}, {
+		in:  `provider: "aws", name: "db-password", ` + static,
+		err: `region must be set for provider "aws"`,
+	}, {
+		in:  aws + `name: "db-password", credentials: accessKeyID: "AKID"`,
+		err: `credentials must set accessKeyID and secretAccessKey or fromMetadata for provider "aws"`,
+	}, {
+		in:  `provider: "gcp", project: "proj", name: "db-password", credentials: accessToken: "sa-token"`,
+		out: "s3cret",
+	}, {
+		in:  `provider: "gcp", project: "proj", name: "db-password", credentials: {fromMetadata: true, metadataEndpoint: "` + s.URL + `"}`,
+		out: "s3cret",
+	}, {
+		in: `provider: "gcp", project: "proj", name: "db-password", credentials: accessToken: "expired"`,
+		err: "cannot access secret db-password: GET " + s.URL +
+			"/v1/projects/proj/secrets/db-password/versions/latest:access: 401 Unauthorized: " +
+			"Request had invalid authentication credentials.",
+	}, {
+		in:  `provider: "gcp", project: "proj", name: "db-password"`,
+		err: `credentials must set accessToken or fromMetadata for provider "gcp"`,
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			got, err := run(t, &secretCmd{}, "tool/cloud.Secret",
+				fmt.Sprintf("{%s, endpoint: %q}", tc.in, s.URL))
+			checkResult(t, got, err, tc.out, tc.err)
+		})
+	}
+}
+
+func checkResult(t *testing.T, got interface{}, err error, out, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || err.Error() != wantErr {
+			t.Fatalf("error: got %v; want %v", err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"value": out}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+const (
+	gcpMetadataEndpoint      = "http://metadata.google.internal"
+	gcpSecretManagerEndpoint = "https://secretmanager.googleapis.com"
+)
+
+// gcpMetadata reads path from the Compute Engine metadata server.
+func gcpMetadata(ctx context.Context, endpoint, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		endpointURL(endpoint, gcpMetadataEndpoint, "computeMetadata/v1/"+path), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	b, err := do(req, plainText)
+	return string(b), err
+}
+
+func gcpStaticToken(v cue.Value) (string, error) {
+	token, err := optField(v, "accessToken")
+	if err == nil && token == "" {
+		err = errors.Newf(v.Pos(),
+			"credentials must set accessToken or fromMetadata for provider \"gcp\"")
+	}
+	return token, err
+}
+
+// gcpMetadataToken obtains an access token for the default service account
+// of the instance from the metadata server.
+func gcpMetadataToken(ctx context.Context, endpoint string) (string, error) {
+	s, err := gcpMetadata(ctx, endpoint, "instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal([]byte(s), &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid access token from metadata server")
+	}
+	return token.AccessToken, nil
+}
+
+// gcpValue fetches the value of the secret from GCP Secret Manager.
+func (s *secret) gcpValue(ctx context.Context, token string) (string, error) {
+	version := s.version
+	if version == "" {
+		version = "latest"
+	}
+	path := fmt.Sprintf("v1/projects/%s/secrets/%s/versions/%s:access",
+		url.PathEscape(s.project), url.PathEscape(s.name), url.PathEscape(version))
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		endpointURL(s.endpoint, gcpSecretManagerEndpoint, path), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	b, err := do(req, func(body []byte) string {
+		var e struct {
+			Error struct{ Message string }
+		}
+		if json.Unmarshal(body, &e) != nil {
+			return ""
+		}
+		return e.Error.Message
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot access secret %s: %v", s.name, err)
+	}
+	var out struct {
+		Payload struct{ Data []byte }
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return "", fmt.Errorf("cannot access secret %s: %v", s.name, err)
+	}
+	if !utf8.Valid(out.Payload.Data) {
+		return "", fmt.Errorf("secret %s is not valid UTF-8", s.name)
+	}
+	return string(out.Payload.Data), nil
+}
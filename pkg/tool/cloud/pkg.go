@@ -0,0 +1,137 @@
+// Code generated by cuelang.org/go/pkg/gen. DO NOT EDIT.
+
+// Package cloud provides tasks for reading instance metadata and secrets
+// from cloud providers.
+//
+// Credentials are never taken implicitly from the environment: tasks that
+// need them state where they come from.
+//
+// These are the supported tasks:
+//
+//	// Metadata reads a value from the metadata server of the cloud instance the
+//	// command runs on.
+//	//
+//	// Example:
+//	//     task: zone: cloud.Metadata & {
+//	//         provider: "gcp"
+//	//         path:     "instance/zone"
+//	//     }
+//	Metadata: {
+//		$id: "tool/cloud.Metadata"
+//
+//		// provider selects the metadata server: "aws" for the EC2 instance
+//		// metadata service, which is accessed with a session token (IMDSv2),
+//		// or "gcp" for the Compute Engine metadata server.
+//		provider: "aws" | "gcp"
+//
+//		// path is the path of the value relative to the root of the instance
+//		// metadata, such as "placement/region" for AWS or "instance/zone" for
+//		// GCP.
+//		path: !=""
+//
+//		// endpoint overrides the address of the metadata server.
+//		endpoint?: string
+//
+//		// value holds the value read.
+//		value: string
+//	}
+//
+//	// Secret fetches the value of a secret from AWS Secrets Manager or GCP
+//	// Secret Manager.
+//	//
+//	// Example:
+//	//     task: password: cloud.Secret & {
+//	//         provider: "aws"
+//	//         region:   "eu-west-1"
+//	//         name:     "db-password"
+//	//         credentials: fromMetadata: true
+//	//     }
+//	Secret: {
+//		$id: "tool/cloud.Secret"
+//
+//		provider: "aws" | "gcp"
+//
+//		// name is the name of the secret. For AWS, it may also be its ARN.
+//		name: !=""
+//
+//		// version selects the version of the secret, which defaults to the
+//		// current one. For AWS, it is a version ID or a staging label such as
+//		// "AWSPREVIOUS". For GCP, it is a version number or "latest".
+//		version?: string
+//
+//		// region is the AWS region holding the secret.
+//		region?: string
+//
+//		// project is the GCP project holding the secret.
+//		project?: string
+//
+//		// credentials configures how to authenticate with the provider. Exactly
+//		// one kind of credentials must be given.
+//		credentials: {
+//			// accessKeyID and secretAccessKey define an AWS access key, with
+//			// sessionToken for temporary credentials.
+//			accessKeyID?:     string
+//			secretAccessKey?: string
+//			sessionToken?:    string
+//
+//			// accessToken is a GCP OAuth 2.0 access token.
+//			accessToken?: string
+//
+//			// fromMetadata obtains the credentials of the role or service
+//			// account of the instance the command runs on from its metadata
+//			// server.
+//			fromMetadata: *false | bool
+//
+//			// metadataEndpoint overrides the address of the metadata server.
+//			metadataEndpoint?: string
+//		}
+//
+//		// endpoint overrides the address of the secret service.
+//		endpoint?: string
+//
+//		// value holds the value of the secret.
+//		value: string
+//	}
+package cloud
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
+)
+
+func init() {
+	pkg.Register("tool/cloud", p)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var p = &pkg.Package{
+	Native: []*pkg.Builtin{},
+	CUE: `{
+	Metadata: {
+		$id:       "tool/cloud.Metadata"
+		provider:  "aws" | "gcp"
+		path:      !=""
+		endpoint?: string
+		value:     string
+	}
+	Secret: {
+		$id:      "tool/cloud.Secret"
+		provider: "aws" | "gcp"
+		name:     !=""
+		version?: string
+		region?:  string
+		project?: string
+		credentials: {
+			accessKeyID?:      string
+			secretAccessKey?:  string
+			sessionToken?:     string
+			accessToken?:      string
+			fromMetadata:      *false | bool
+			metadataEndpoint?: string
+		}
+		endpoint?: string
+		value:     string
+	}
+}`,
+}
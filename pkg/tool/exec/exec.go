@@ -15,9 +15,14 @@
 package exec
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
@@ -38,12 +43,34 @@ func newExecCmd(v cue.Value) (task.Runner, error) {
 }
 
 func (c *execCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	var timeout time.Duration
+	if v := ctx.Obj.Lookup("timeout"); v.Exists() {
+		str, err := v.String()
+		if err != nil {
+			return nil, err
+		}
+		if timeout, err = time.ParseDuration(str); err != nil {
+			return nil, errors.Wrapf(err, v.Pos(), "invalid timeout")
+		}
+	}
+	timedOut := func() bool { return false }
+	if timeout > 0 {
+		goCtx, cancel := context.WithTimeout(ctx.Context, timeout)
+		defer cancel()
+		timedOut = func() bool { return goCtx.Err() == context.DeadlineExceeded }
+		c := *ctx
+		c.Context = goCtx
+		ctx = &c
+	}
+
 	cmd, doc, err := mkCommand(ctx)
 	if err != nil {
 		return cue.Value{}, err
 	}
+	// Do not wait indefinitely for the output of processes started by the
+	// command once it is killed.
+	cmd.WaitDelay = time.Second
 
-	// TODO: set environment variables, if defined.
 	stream := func(name string) (stream cue.Value, ok bool) {
 		c := ctx.Obj.Lookup(name)
 		// Although the schema defines a default versions, older implementations
@@ -62,31 +89,81 @@ func (c *execCmd) Run(ctx *task.Context) (res interface{}, err error) {
 	} else if cmd.Stdin, err = v.Reader(); err != nil {
 		return nil, errors.Wrapf(err, v.Pos(), "invalid input")
 	}
-	_, captureOut := stream("stdout")
-	if !captureOut {
-		cmd.Stdout = ctx.Stdout
+
+	tee := false
+	if v := ctx.Obj.Lookup("tee"); v.Exists() {
+		if tee, err = v.Bool(); err != nil {
+			return nil, err
+		}
 	}
-	_, captureErr := stream("stderr")
-	if !captureErr {
-		cmd.Stderr = ctx.Stderr
+
+	// output sets up the writer for the output stream with the given name,
+	// which writes to a buffer if the output is captured, to a file if one
+	// is given, and otherwise, or if tee is set, to w.
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			if cerr := f.Close(); err == nil && cerr != nil {
+				err = cerr
+			}
+		}
+	}()
+	output := func(name string, w io.Writer) (buf *bytes.Buffer, out io.Writer, err error) {
+		var writers []io.Writer
+		if _, ok := stream(name); ok {
+			buf = &bytes.Buffer{}
+			writers = append(writers, buf)
+		}
+		if v := ctx.Obj.Lookup(name + "File"); v.Exists() {
+			file, err := v.String()
+			if err != nil {
+				return nil, nil, err
+			}
+			f, err := os.Create(file)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, v.Pos(), "cannot create %s", name+"File")
+			}
+			files = append(files, f)
+			writers = append(writers, f)
+		}
+		if len(writers) == 0 || tee {
+			writers = append(writers, w)
+		}
+		if len(writers) == 1 {
+			return buf, writers[0], nil
+		}
+		return buf, io.MultiWriter(writers...), nil
 	}
+	stdout, w, err := output("stdout", ctx.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = w
+	stderr, w, err := output("stderr", ctx.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = w
+
+	err = cmd.Run()
 
 	update := map[string]interface{}{}
-	if captureOut {
-		var stdout []byte
-		stdout, err = cmd.Output()
-		update["stdout"] = string(stdout)
-	} else {
-		err = cmd.Run()
-	}
 	update["success"] = err == nil
+	if stdout != nil {
+		update["stdout"] = stdout.String()
+	}
+	if stderr != nil {
+		update["stderr"] = stderr.String()
+	}
 	if err != nil {
-		if exit := (*exec.ExitError)(nil); errors.As(err, &exit) && captureErr {
-			update["stderr"] = string(exit.Stderr)
-		} else {
+		if exit := (*exec.ExitError)(nil); !errors.As(err, &exit) {
 			update = nil
 		}
-		err = fmt.Errorf("command %q failed: %v", doc, err)
+		if timedOut() {
+			err = fmt.Errorf("command %q timed out after %v", doc, timeout)
+		} else {
+			err = fmt.Errorf("command %q failed: %v", doc, err)
+		}
 	}
 	return update, err
 }
@@ -136,6 +213,19 @@ func mkCommand(ctx *task.Context) (c *exec.Cmd, doc string, err error) {
 
 	cmd.Dir, _ = ctx.Obj.Lookup("dir").String()
 
+	if v := ctx.Obj.Lookup("inheritEnv"); v.Exists() {
+		inherit, err := v.Bool()
+		if err != nil {
+			return nil, "", err
+		}
+		if inherit {
+			cmd.Env = os.Environ()
+		} else {
+			// An empty, non-nil environment prevents inheriting it.
+			cmd.Env = []string{}
+		}
+	}
+
 	env := ctx.Obj.Lookup("env")
 
 	// List case.
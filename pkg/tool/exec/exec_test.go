@@ -16,12 +16,18 @@ package exec
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/internal/task"
+	"cuelang.org/go/internal/value"
 )
 
 func TestEnv(t *testing.T) {
@@ -69,3 +75,113 @@ func TestEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestInheritEnv(t *testing.T) {
+	t.Setenv("CUE_EXEC_TEST", "inherited")
+	testCases := []struct {
+		val  string
+		want []string
+	}{{
+		val:  `cmd: "env", inheritEnv: false`,
+		want: []string{},
+	}, {
+		val:  `cmd: "env", inheritEnv: false, env: FOO: "bar"`,
+		want: []string{"FOO=bar"},
+	}, {
+		val:  `cmd: "env", inheritEnv: true, env: FOO: "bar"`,
+		want: append(os.Environ(), "FOO=bar"),
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmd, _, err := mkCommand(&task.Context{
+				Context: context.Background(),
+				Obj:     inst.Value(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(cmd.Env, tc.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		desc   string
+		val    string
+		out    string // the results of the task or the error
+		stdout string // written to the stdout of the task Context
+		files  map[string]string
+	}{{
+		desc: "capture",
+		val:  `cmd: ["sh", "-c", "echo out; echo err >&2"], stdout: string, stderr: string`,
+		out: `map[stderr:err
+ stdout:out
+ success:true]`,
+	}, {
+		desc:   "terminal",
+		val:    `cmd: ["echo", "out"]`,
+		out:    `map[success:true]`,
+		stdout: "out\n",
+	}, {
+		desc:  "files",
+		val:   `cmd: ["sh", "-c", "echo out; echo err >&2"], stdoutFile: "DIR/out.txt", stderrFile: "DIR/err.txt"`,
+		out:   `map[success:true]`,
+		files: map[string]string{"out.txt": "out\n", "err.txt": "err\n"},
+	}, {
+		desc:   "tee",
+		val:    `cmd: ["echo", "out"], stdout: string, stdoutFile: "DIR/tee.txt", tee: true`,
+		out:    "map[stdout:out\n success:true]",
+		stdout: "out\n",
+		files:  map[string]string{"tee.txt": "out\n"},
+	}, {
+		desc: "timeout",
+		val:  `cmd: ["sleep", "10"], timeout: "50ms"`,
+		out:  `command "sleep 10" timed out after 50ms`,
+	}, {
+		desc: "invalidTimeout",
+		val:  `cmd: "true", timeout: "soon"`,
+		out:  `invalid timeout: time: invalid duration "soon"`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var r cue.Runtime
+			inst, err := r.Compile("test", strings.ReplaceAll(tc.val, "DIR", dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := value.UnifyBuiltin(inst.Value(), "tool/exec.Run")
+			stdout := &strings.Builder{}
+			res, err := (*execCmd).Run(nil, &task.Context{
+				Context: context.Background(),
+				Stdout:  stdout,
+				Stderr:  io.Discard,
+				Obj:     v,
+			})
+			got := fmt.Sprint(res)
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.out {
+				t.Errorf("got %q; want %q", got, tc.out)
+			}
+			if got := stdout.String(); got != tc.stdout {
+				t.Errorf("got stdout %q; want %q", got, tc.stdout)
+			}
+			for name, want := range tc.files {
+				b, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil || string(b) != want {
+					t.Errorf("%s: got %q, %v; want %q", name, b, err, want)
+				}
+			}
+		})
+	}
+}
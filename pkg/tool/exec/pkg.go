@@ -21,6 +21,15 @@
 //		// occurrances of the same key.
 //		env: [string]: string | [...=~"="]
 //
+//		// inheritEnv determines whether the command inherits the environment
+//		// of the current process, to which the variables in env are added.
+//		// If not set, the environment is inherited only if env is empty.
+//		inheritEnv?: bool
+//
+//		// timeout limits the time the command may run, as a duration such as
+//		// "30s". The command is killed once the timeout expires.
+//		timeout?: string
+//
 //		// stdout captures the output from stdout if it is of type bytes or string.
 //		// The default value of null indicates it is redirected to the stdout of the
 //		// current process.
@@ -29,6 +38,18 @@
 //		// stderr is like stdout, but for errors.
 //		stderr: *null | string | bytes
 //
+//		// stdoutFile and stderrFile are the names of files to which the
+//		// output of the command is written as it runs, instead of to the
+//		// stdout and stderr of the current process. They may be combined with
+//		// capturing the output in stdout and stderr.
+//		stdoutFile?: string
+//		stderrFile?: string
+//
+//		// tee causes output that is captured or written to a file to also be
+//		// written to the stdout and stderr of the current process as the
+//		// command runs, so that progress of long-running commands is visible.
+//		tee?: bool
+//
 //		// stdin specifies the input for the process. If stdin is null, the stdin
 //		// of the current process is redirected to this command (the default).
 //		// If it is of typ bytes or string, that input will be used instead.
@@ -62,10 +83,15 @@ var p = &pkg.Package{
 		env: {
 			[string]: string | [...=~"="]
 		}
-		stdout:  *null | string | bytes
-		stderr:  *null | string | bytes
-		stdin:   *null | string | bytes
-		success: bool
+		inheritEnv?: bool
+		timeout?:    string
+		stdout:      *null | string | bytes
+		stderr:      *null | string | bytes
+		stdoutFile?: string
+		stderrFile?: string
+		tee?:        bool
+		stdin:       *null | string | bytes
+		success:     bool
 	}
 }`,
 }
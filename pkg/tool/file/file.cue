@@ -12,6 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package file provides file operations for cue tasks.
+//
+// These are the supported tasks:
 package file
 
 // Read reads the contents of a file.
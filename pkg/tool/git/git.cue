@@ -0,0 +1,97 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package git provides tasks for working with git repositories.
+//
+// The tasks run the git command, which must be installed.
+//
+// These are the supported tasks:
+package git
+
+// Clone clones a repository into a new directory.
+//
+// Example:
+//     task: clone: git.Clone & {
+//         url: "https://github.com/cue-lang/cue"
+//         dir: "cue"
+//         ref: "v0.6.0"
+//         depth: 1
+//     }
+Clone: {
+	$id: "tool/git.Clone"
+
+	// url is the location of the repository.
+	url: !=""
+
+	// dir is the directory to clone into, which must not exist or be
+	// empty.
+	dir: !=""
+
+	// ref is a branch or tag to check out instead of the default branch.
+	ref?: string
+
+	// depth, if set, creates a shallow clone with the given number of
+	// commits.
+	depth?: int & >0
+
+	// commit holds the commit that was checked out.
+	commit: string
+}
+
+// RevParse resolves a revision, such as a branch, tag or HEAD, to the
+// commit it refers to.
+//
+// Example:
+//     task: head: git.RevParse & {dir: "cue"}
+RevParse: {
+	$id: "tool/git.RevParse"
+
+	// dir is a directory within the repository.
+	dir: *"." | string
+
+	// rev is the revision to resolve.
+	rev: *"HEAD" | string
+
+	// commit holds the full hash of the commit.
+	commit: string
+
+	// short holds the abbreviated hash of the commit.
+	short: string
+}
+
+// Tags lists the tags of a repository, sorted by name.
+//
+// Example:
+//     task: releases: git.Tags & {
+//         dir:     "cue"
+//         pattern: "v*"
+//     }
+Tags: {
+	$id: "tool/git.Tags"
+
+	// dir is a directory within the repository.
+	dir: *"." | string
+
+	// pattern, if set, limits the tags to those that match the given
+	// shell pattern.
+	pattern?: string
+
+	// tags holds the tags of the repository.
+	tags: [...{
+		name: string
+		// commit is the commit the tag refers to. For annotated tags this
+		// is the tagged commit, not the tag object.
+		commit: string
+	}]
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/git.Clone", newCloneCmd)
+	task.Register("tool/git.RevParse", newRevParseCmd)
+	task.Register("tool/git.Tags", newTagsCmd)
+}
+
+type cloneCmd struct{}
+
+func newCloneCmd(v cue.Value) (task.Runner, error) {
+	return &cloneCmd{}, nil
+}
+
+func (c *cloneCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	url := ctx.String("url")
+	dir := ctx.String("dir")
+	args := []string{"clone", "--quiet"}
+	if v := ctx.Obj.Lookup("ref"); v.Exists() {
+		args = append(args, "--branch", ctx.String("ref"))
+	}
+	if v := ctx.Obj.Lookup("depth"); v.Exists() {
+		args = append(args, "--depth", strconv.FormatInt(ctx.Int64("depth"), 10))
+	}
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	// Prevent options from being injected through the URL.
+	args = append(args, "--", url, dir)
+	if _, err := git(ctx, "", args...); err != nil {
+		return nil, err
+	}
+	commit, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"commit": commit}, nil
+}
+
+type revParseCmd struct{}
+
+func newRevParseCmd(v cue.Value) (task.Runner, error) {
+	return &revParseCmd{}, nil
+}
+
+func (c *revParseCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	dir := ctx.String("dir")
+	rev := ctx.String("rev")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	// Resolve to a commit, rather than to an annotated tag object.
+	out, err := git(ctx, dir, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	short, err := git(ctx, dir, "rev-parse", "--short", out)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"commit": out, "short": short}, nil
+}
+
+type tagsCmd struct{}
+
+func newTagsCmd(v cue.Value) (task.Runner, error) {
+	return &tagsCmd{}, nil
+}
+
+func (c *tagsCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	dir := ctx.String("dir")
+	args := []string{
+		"tag", "--list", "--sort=refname",
+		// For annotated tags, %(*objectname) is the tagged commit.
+		"--format=%(refname:strip=2)%00%(objectname)%00%(*objectname)",
+	}
+	if v := ctx.Obj.Lookup("pattern"); v.Exists() {
+		args = append(args, ctx.String("pattern"))
+	}
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	out, err := git(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	tags := []map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		f := strings.Split(line, "\x00")
+		if len(f) != 3 {
+			return nil, fmt.Errorf("unexpected output of git tag: %q", line)
+		}
+		commit := f[1]
+		if f[2] != "" {
+			commit = f[2]
+		}
+		tags = append(tags, map[string]string{"name": f[0], "commit": commit})
+	}
+	return map[string]interface{}{"tags": tags}, nil
+}
+
+// git runs git with the given arguments in dir and returns its trimmed
+// output.
+func git(ctx *task.Context, dir string, args ...string) (string, error) {
+	goCtx := ctx.Context
+	if goCtx == nil {
+		goCtx = context.Background()
+	}
+	cmd := exec.CommandContext(goCtx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", fmt.Errorf("git %s failed: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/task"
+	"cuelang.org/go/internal/value"
+)
+
+func parse(t *testing.T, kind, expr string) cue.Value {
+	t.Helper()
+
+	x, err := parser.ParseExpr("test", expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r cue.Runtime
+	i, err := r.CompileExpr(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value.UnifyBuiltin(i.Value(), kind)
+}
+
+// newRepo creates a repository with two commits, a lightweight tag v0.1.0
+// on the first and an annotated tag v0.2.0 on the second. It returns the
+// directory of the repository and the hashes of the commits.
+func newRepo(t *testing.T) (dir string, commits []string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir = t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(cmd.Environ(),
+			"GIT_CONFIG_GLOBAL=/dev/null",
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "--quiet")
+	run("commit", "--quiet", "--allow-empty", "-m", "first")
+	commits = append(commits, run("rev-parse", "HEAD"))
+	run("tag", "v0.1.0")
+	run("commit", "--quiet", "--allow-empty", "-m", "second")
+	commits = append(commits, run("rev-parse", "HEAD"))
+	run("tag", "-a", "-m", "release", "v0.2.0")
+	return dir, commits
+}
+
+func TestRevParse(t *testing.T) {
+	dir, commits := newRepo(t)
+
+	v := parse(t, "tool/git.RevParse", fmt.Sprintf(`{dir: %q}`, dir))
+	res, err := (*revParseCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := res.(map[string]interface{})
+	if m["commit"] != commits[1] {
+		t.Errorf("got commit %v; want %v", m["commit"], commits[1])
+	}
+	if short := m["short"].(string); !strings.HasPrefix(commits[1], short) {
+		t.Errorf("short hash %q is not a prefix of %q", short, commits[1])
+	}
+
+	// Annotated tags resolve to the tagged commit.
+	v = parse(t, "tool/git.RevParse", fmt.Sprintf(`{dir: %q, rev: "v0.2.0"}`, dir))
+	res, err = (*revParseCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(map[string]interface{})["commit"]; got != commits[1] {
+		t.Errorf("got commit %v; want %v", got, commits[1])
+	}
+
+	v = parse(t, "tool/git.RevParse", fmt.Sprintf(`{dir: %q, rev: "nonexistent"}`, dir))
+	if _, err := (*revParseCmd).Run(nil, &task.Context{Obj: v}); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+}
+
+func TestTags(t *testing.T) {
+	dir, commits := newRepo(t)
+
+	v := parse(t, "tool/git.Tags", fmt.Sprintf(`{dir: %q}`, dir))
+	res, err := (*tagsCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		{"name": "v0.1.0", "commit": commits[0]},
+		{"name": "v0.2.0", "commit": commits[1]},
+	}
+	if got := res.(map[string]interface{})["tags"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	v = parse(t, "tool/git.Tags", fmt.Sprintf(`{dir: %q, pattern: "v0.2.*"}`, dir))
+	res, err = (*tagsCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(map[string]interface{})["tags"]; !reflect.DeepEqual(got, want[1:]) {
+		t.Errorf("got %v; want %v", got, want[1:])
+	}
+}
+
+func TestClone(t *testing.T) {
+	src, commits := newRepo(t)
+	dst := filepath.Join(t.TempDir(), "clone")
+
+	v := parse(t, "tool/git.Clone", fmt.Sprintf(`{url: %q, dir: %q, ref: "v0.1.0"}`, src, dst))
+	res, err := (*cloneCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(map[string]interface{})["commit"]; got != commits[0] {
+		t.Errorf("got commit %v; want %v", got, commits[0])
+	}
+
+	// The destination must not exist.
+	if _, err := (*cloneCmd).Run(nil, &task.Context{Obj: v}); err == nil {
+		t.Error("expected error cloning into existing directory")
+	}
+}
@@ -0,0 +1,126 @@
+// Code generated by cuelang.org/go/pkg/gen. DO NOT EDIT.
+
+// Package git provides tasks for working with git repositories.
+//
+// The tasks run the git command, which must be installed.
+//
+// These are the supported tasks:
+//
+//	// Clone clones a repository into a new directory.
+//	//
+//	// Example:
+//	//     task: clone: git.Clone & {
+//	//         url: "https://github.com/cue-lang/cue"
+//	//         dir: "cue"
+//	//         ref: "v0.6.0"
+//	//         depth: 1
+//	//     }
+//	Clone: {
+//		$id: "tool/git.Clone"
+//
+//		// url is the location of the repository.
+//		url: !=""
+//
+//		// dir is the directory to clone into, which must not exist or be
+//		// empty.
+//		dir: !=""
+//
+//		// ref is a branch or tag to check out instead of the default branch.
+//		ref?: string
+//
+//		// depth, if set, creates a shallow clone with the given number of
+//		// commits.
+//		depth?: int & >0
+//
+//		// commit holds the commit that was checked out.
+//		commit: string
+//	}
+//
+//	// RevParse resolves a revision, such as a branch, tag or HEAD, to the
+//	// commit it refers to.
+//	//
+//	// Example:
+//	//     task: head: git.RevParse & {dir: "cue"}
+//	RevParse: {
+//		$id: "tool/git.RevParse"
+//
+//		// dir is a directory within the repository.
+//		dir: *"." | string
+//
+//		// rev is the revision to resolve.
+//		rev: *"HEAD" | string
+//
+//		// commit holds the full hash of the commit.
+//		commit: string
+//
+//		// short holds the abbreviated hash of the commit.
+//		short: string
+//	}
+//
+//	// Tags lists the tags of a repository, sorted by name.
+//	//
+//	// Example:
+//	//     task: releases: git.Tags & {
+//	//         dir:     "cue"
+//	//         pattern: "v*"
+//	//     }
+//	Tags: {
+//		$id: "tool/git.Tags"
+//
+//		// dir is a directory within the repository.
+//		dir: *"." | string
+//
+//		// pattern, if set, limits the tags to those that match the given
+//		// shell pattern.
+//		pattern?: string
+//
+//		// tags holds the tags of the repository.
+//		tags: [...{
+//			name: string
+//			// commit is the commit the tag refers to. For annotated tags this
+//			// is the tagged commit, not the tag object.
+//			commit: string
+//		}]
+//	}
+package git
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
+)
+
+func init() {
+	pkg.Register("tool/git", p)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var p = &pkg.Package{
+	Native: []*pkg.Builtin{},
+	CUE: `{
+	Clone: {
+		$id:    "tool/git.Clone"
+		url:    !=""
+		dir:    !=""
+		ref?:   string
+		depth?: >0 & int
+		commit: string
+	}
+	RevParse: {
+		$id:    "tool/git.RevParse"
+		dir:    *"." | string
+		rev:    *"HEAD" | string
+		commit: string
+		short:  string
+	}
+	Tags: {
+		$id:      "tool/git.Tags"
+		dir:      *"." | string
+		pattern?: string
+		tags: [...{
+			name:   string
+			commit: string
+		}]
+	}
+}`,
+}
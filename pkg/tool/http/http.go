@@ -16,11 +16,15 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
@@ -46,15 +50,24 @@ func (c *httpCmd) Run(ctx *task.Context) (res interface{}, err error) {
 		method = ctx.String("method")
 		u      = ctx.String("url")
 	)
-	var r io.Reader
+	var (
+		body     []byte
+		bodyFile string
+	)
 	if obj := ctx.Obj.Lookup("request"); obj.Exists() {
 		if v := obj.Lookup("body"); v.Exists() {
-			r, err = v.Reader()
+			body, err = v.Bytes()
 			if err != nil {
 				return nil, err
 			}
-		} else {
-			r = bytes.NewReader([]byte(""))
+		}
+		if v := obj.Lookup("file"); v.Exists() {
+			if body != nil {
+				return nil, errors.Newf(v.Pos(), "request.file may not be set together with request.body")
+			}
+			if bodyFile, err = v.String(); err != nil {
+				return nil, err
+			}
 		}
 		if header, err = parseHeaders(obj, "header"); err != nil {
 			return nil, err
@@ -64,6 +77,13 @@ func (c *httpCmd) Run(ctx *task.Context) (res interface{}, err error) {
 		}
 	}
 
+	var respFile string
+	if v := ctx.Obj.LookupPath(cue.ParsePath("response.file")); v.Exists() {
+		if respFile, err = v.String(); err != nil {
+			return nil, err
+		}
+	}
+
 	var caCert []byte
 	caCertValue := ctx.Obj.LookupPath(cue.ParsePath("tls.caCert"))
 	if caCertValue.Exists() {
@@ -82,6 +102,37 @@ func (c *httpCmd) Run(ctx *task.Context) (res interface{}, err error) {
 		}
 	}
 
+	clientCert, err := parseClientCert(ctx.Obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeout time.Duration
+	if v := ctx.Obj.Lookup("timeout"); v.Exists() {
+		if timeout, err = parseDuration(v); err != nil {
+			return nil, err
+		}
+	}
+
+	follow, maxRedirects := true, 10
+	if v := ctx.Obj.LookupPath(cue.ParsePath("redirect.follow")); v.Exists() {
+		if follow, err = v.Bool(); err != nil {
+			return nil, err
+		}
+	}
+	if v := ctx.Obj.LookupPath(cue.ParsePath("redirect.max")); v.Exists() {
+		n, err := v.Int64()
+		if err != nil {
+			return nil, err
+		}
+		maxRedirects = int(n)
+	}
+
+	retry, err := parseRetry(ctx.Obj)
+	if err != nil {
+		return nil, err
+	}
+
 	if ctx.Err != nil {
 		return nil, ctx.Err
 	}
@@ -110,36 +161,194 @@ func (c *httpCmd) Run(ctx *task.Context) (res interface{}, err error) {
 		}
 		transport.TLSClientConfig.RootCAs = pool
 	}
+	if clientCert != nil {
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*clientCert}
+	}
 
 	client := &http.Client{
 		Transport: transport,
-		// TODO: timeout
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !follow {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
 	}
 
-	req, err := http.NewRequest(method, u, r)
-	if err != nil {
-		return nil, err
+	goCtx := ctx.Context
+	if goCtx == nil {
+		goCtx = context.Background()
 	}
-	req.Header = header
-	req.Trailer = trailer
 
-	// TODO: retry logic
-	resp, err := client.Do(req)
+	var resp *http.Response
+	for attempt := 1; ; attempt++ {
+		var req *http.Request
+		req, err = newRequest(goCtx, method, u, body, bodyFile)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = header
+		req.Trailer = trailer
+
+		resp, err = client.Do(req)
+		if attempt > retry.attempts || (err == nil && !retry.statusCodes[resp.StatusCode]) {
+			break
+		}
+		if err == nil {
+			// Drain the body to allow the connection to be reused.
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-goCtx.Done():
+			return nil, goCtx.Err()
+		case <-time.After(retry.delay(attempt)):
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
-	// parse response body and headers
-	return map[string]interface{}{
-		"response": map[string]interface{}{
-			"status":     resp.Status,
-			"statusCode": resp.StatusCode,
-			"body":       string(b),
-			"header":     resp.Header,
-			"trailer":    resp.Trailer,
-		},
-	}, err
+
+	response := map[string]interface{}{
+		"status":     resp.Status,
+		"statusCode": resp.StatusCode,
+		"header":     resp.Header,
+	}
+	if respFile != "" {
+		err = writeFile(respFile, resp.Body)
+	} else {
+		var b []byte
+		b, err = io.ReadAll(resp.Body)
+		response["body"] = string(b)
+	}
+	// Trailers are only available once the body has been read.
+	response["trailer"] = resp.Trailer
+	return map[string]interface{}{"response": response}, err
+}
+
+// newRequest creates a request with the given body, or the body read from
+// file if it is set. A new request is created for each attempt, as a body
+// can only be read once.
+func newRequest(ctx context.Context, method, u string, body []byte, file string) (*http.Request, error) {
+	var r io.Reader = bytes.NewReader(body)
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		// The client closes the body.
+		r = f
+	}
+	return http.NewRequestWithContext(ctx, method, u, r)
+}
+
+func writeFile(name string, r io.Reader) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func parseClientCert(obj cue.Value) (*tls.Certificate, error) {
+	certValue := obj.LookupPath(cue.ParsePath("tls.clientCert"))
+	keyValue := obj.LookupPath(cue.ParsePath("tls.clientKey"))
+	if !certValue.Exists() && !keyValue.Exists() {
+		return nil, nil
+	}
+	if !certValue.Exists() || !keyValue.Exists() {
+		return nil, errors.Newf(obj.Pos(), "tls.clientCert and tls.clientKey must be set together")
+	}
+	cert, err := certValue.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, certValue.Pos(), "invalid bytes value")
+	}
+	key, err := keyValue.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, keyValue.Pos(), "invalid bytes value")
+	}
+	c, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, certValue.Pos(), "failed to parse client certificate")
+	}
+	return &c, nil
+}
+
+func parseDuration(v cue.Value) (time.Duration, error) {
+	s, err := v.String()
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, v.Pos(), "invalid duration")
+	}
+	return d, nil
+}
+
+type retryConfig struct {
+	attempts    int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	statusCodes map[int]bool
+}
+
+func parseRetry(obj cue.Value) (*retryConfig, error) {
+	r := &retryConfig{backoff: time.Second, statusCodes: map[int]bool{}}
+	v := obj.Lookup("retry")
+	if !v.Exists() {
+		return r, nil
+	}
+	var err error
+	if x := v.Lookup("attempts"); x.Exists() {
+		n, err := x.Int64()
+		if err != nil {
+			return nil, err
+		}
+		r.attempts = int(n)
+	}
+	if x := v.Lookup("backoff"); x.Exists() {
+		if r.backoff, err = parseDuration(x); err != nil {
+			return nil, err
+		}
+	}
+	if x := v.Lookup("maxBackoff"); x.Exists() {
+		if r.maxBackoff, err = parseDuration(x); err != nil {
+			return nil, err
+		}
+	}
+	if x := v.Lookup("statusCodes"); x.Exists() {
+		var codes []int
+		if err := x.Decode(&codes); err != nil {
+			return nil, err
+		}
+		for _, c := range codes {
+			r.statusCodes[c] = true
+		}
+	}
+	return r, nil
+}
+
+// delay reports the time to wait before the given retry, where 1 is the
+// first retry.
+func (r *retryConfig) delay(retry int) time.Duration {
+	d := r.backoff
+	for i := 1; i < retry && (r.maxBackoff == 0 || d < r.maxBackoff); i++ {
+		d *= 2
+	}
+	if r.maxBackoff > 0 && d > r.maxBackoff {
+		d = r.maxBackoff
+	}
+	return d
 }
 
 func parseHeaders(obj cue.Value, label string) (http.Header, error) {
@@ -15,12 +15,24 @@
 package http
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/parser"
@@ -158,3 +170,175 @@ func TestParseHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestRetry(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write(b)
+	}))
+	defer s.Close()
+
+	for _, tc := range []struct {
+		attempts int
+		want     string
+	}{
+		{attempts: 1, want: "503 ping"},
+		{attempts: 2, want: "200 ping"},
+	} {
+		atomic.StoreInt32(&calls, 0)
+		v := parse(t, "tool/http.Post", fmt.Sprintf(`{
+			url: %q
+			request: body: "ping"
+			retry: {attempts: %d, backoff: "1ms"}
+		}`, s.URL, tc.attempts))
+		res, err := (*httpCmd).Run(nil, &task.Context{Obj: v})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := res.(map[string]interface{})["response"].(map[string]interface{})
+		if got := fmt.Sprint(resp["statusCode"], " ", resp["body"]); got != tc.want {
+			t.Errorf("attempts %d: got %q; want %q", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestRedirectAndTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/redirect":
+			http.Redirect(w, r, "/", http.StatusFound)
+		}
+		w.Write([]byte("done"))
+	}))
+	defer s.Close()
+
+	testCases := []struct {
+		in   string
+		want string
+	}{{
+		in:   `url: "%s/redirect"`,
+		want: "200 done",
+	}, {
+		in:   `url: "%s/redirect", redirect: follow: false`,
+		want: "302 ",
+	}, {
+		in:   `url: "%s/redirect", redirect: max: 0`,
+		want: "stopped after 0 redirects",
+	}, {
+		in:   `url: "%s/slow", timeout: "10ms"`,
+		want: "Client.Timeout exceeded",
+	}, {
+		in:   `url: "%s/slow", timeout: "1 minute"`,
+		want: "invalid duration",
+	}}
+	for _, tc := range testCases {
+		v := parse(t, "tool/http.Get", "{"+fmt.Sprintf(tc.in, s.URL)+"}")
+		res, err := (*httpCmd).Run(nil, &task.Context{Obj: v})
+		got := fmt.Sprint(err)
+		if err == nil {
+			resp := res.(map[string]interface{})["response"].(map[string]interface{})
+			got = fmt.Sprint(resp["statusCode"], " ", resp["body"])
+		}
+		if !strings.Contains(got, tc.want) {
+			t.Errorf("%s: got %q; want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestStreamFiles(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer s.Close()
+
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	if err := os.WriteFile(in, []byte("streamed"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	v := parse(t, "tool/http.Put", fmt.Sprintf(`{
+		url: %q
+		request: file: %q
+		response: file: %q
+	}`, s.URL, in, out))
+	res, err := (*httpCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.(map[string]interface{})["response"].(map[string]interface{})["body"]; ok {
+		t.Error("body set when streaming to file")
+	}
+	if b, err := os.ReadFile(out); err != nil || string(b) != "streamed" {
+		t.Errorf("got %q, %v; want streamed", b, err)
+	}
+
+	v = parse(t, "tool/http.Put", fmt.Sprintf(`{
+		url: %q
+		request: {file: %q, body: "x"}
+	}`, s.URL, in))
+	if _, err := (*httpCmd).Run(nil, &task.Context{Obj: v}); err == nil {
+		t.Error("expected error for both request.file and request.body")
+	}
+}
+
+func TestClientCert(t *testing.T) {
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	s.StartTLS()
+	defer s.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cue-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	v := parse(t, "tool/http.Get", fmt.Sprintf(`{url: %q, tls: verify: false}`, s.URL))
+	if _, err := (*httpCmd).Run(nil, &task.Context{Obj: v}); err == nil {
+		t.Error("request without client certificate should have failed")
+	}
+
+	v = parse(t, "tool/http.Get", fmt.Sprintf(`{
+		url: %q
+		tls: {
+			verify:     false
+			clientCert: %q
+			clientKey:  %q
+		}
+	}`, s.URL, certPEM, keyPEM))
+	res, err := (*httpCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(map[string]interface{})["response"].(map[string]interface{})["body"]; got != "cue-client" {
+		t.Errorf("got body %q; want cue-client", got)
+	}
+
+	v = parse(t, "tool/http.Get", fmt.Sprintf(`{url: %q, tls: clientCert: %q}`, s.URL, certPEM))
+	if _, err := (*httpCmd).Run(nil, &task.Context{Obj: v}); err == nil {
+		t.Error("expected error for clientCert without clientKey")
+	}
+}
@@ -21,10 +21,45 @@
 //			// PEM encoded certificate(s) to validate the server certificate.
 //			// If not set the CA bundle of the system is used.
 //			caCert?: bytes | string
+//			// PEM encoded client certificate and private key, which are
+//			// presented to servers that require mutual TLS.
+//			clientCert?: bytes | string
+//			clientKey?:  bytes | string
+//		}
+//
+//		// timeout limits the time of each attempt of the request, including
+//		// reading the response body, as a duration such as "30s".
+//		timeout?: string
+//
+//		redirect: {
+//			// Whether redirects are followed. If not, the redirect response
+//			// is the response of the task.
+//			follow: *true | bool
+//			// The maximum number of redirects to follow.
+//			max: *10 | int & >=0
+//		}
+//
+//		// retry determines how often and when failed requests are retried.
+//		// A request fails if it cannot be sent or if the response has one
+//		// of the given status codes. The response of the last attempt is
+//		// the response of the task.
+//		retry: {
+//			// The maximum number of times the request is retried.
+//			attempts: *0 | int & >=0
+//			// The time to wait before the first retry, which doubles with
+//			// every retry.
+//			backoff: *"1s" | string
+//			// The maximum time to wait between retries.
+//			maxBackoff?: string
+//			// The status codes that cause a request to be retried.
+//			statusCodes: *[429, 502, 503, 504] | [...int]
 //		}
 //
 //		request: {
 //			body?: bytes | string
+//			// file is the name of a file from which the body is streamed.
+//			// It may not be set together with body.
+//			file?: string
 //			header: [string]:  string | [...string]
 //			trailer: [string]: string | [...string]
 //		}
@@ -32,7 +67,11 @@
 //			status:     string
 //			statusCode: int
 //
+//			// body is not set if the body is written to file.
 //			body: *bytes | string
+//			// file is the name of a file to which the body is streamed,
+//			// instead of storing it in body.
+//			file?: string
 //			header: [string]:  string | [...string]
 //			trailer: [string]: string | [...string]
 //		}
@@ -82,11 +121,25 @@ var p = &pkg.Package{
 		method: string
 		url:    string
 		tls: {
-			verify:  *true | bool
-			caCert?: bytes | string
+			verify:      *true | bool
+			caCert?:     bytes | string
+			clientCert?: bytes | string
+			clientKey?:  bytes | string
+		}
+		timeout?: string
+		redirect: {
+			follow: *true | bool
+			max:    *10 | int & >=0
+		}
+		retry: {
+			attempts:    *0 | int & >=0
+			backoff:     *"1s" | string
+			maxBackoff?: string
+			statusCodes: *[429, 502, 503, 504] | [...int]
 		}
 		request: {
 			body?: bytes | string
+			file?: string
 			header: {
 				[string]: string | [...string]
 			}
@@ -98,6 +151,7 @@ var p = &pkg.Package{
 			status:     string
 			statusCode: int
 			body:       *bytes | string
+			file?:      string
 			header: {
 				[string]: string | [...string]
 			}
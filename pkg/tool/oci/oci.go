@@ -0,0 +1,275 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"cuelabs.dev/go/oci/ociregistry"
+	"cuelabs.dev/go/oci/ociregistry/ociclient"
+	"cuelabs.dev/go/oci/ociregistry/ociref"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/internal/mod/modauth"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/oci.Resolve", newResolveCmd)
+	task.Register("tool/oci.Manifest", newManifestCmd)
+	task.Register("tool/oci.Push", newPushCmd)
+}
+
+type resolveCmd struct{}
+
+func newResolveCmd(v cue.Value) (task.Runner, error) {
+	return &resolveCmd{}, nil
+}
+
+func (c *resolveCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	r, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := r.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"digest":    string(desc.Digest),
+		"mediaType": desc.MediaType,
+		"size":      desc.Size,
+	}, nil
+}
+
+type manifestCmd struct{}
+
+func newManifestCmd(v cue.Value) (task.Runner, error) {
+	return &manifestCmd{}, nil
+}
+
+func (c *manifestCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	r, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var br ociregistry.BlobReader
+	if r.ref.Digest != "" {
+		br, err = r.reg.GetManifest(r.ctx, r.ref.Repository, r.ref.Digest)
+	} else {
+		br, err = r.reg.GetTag(r.ctx, r.ref.Repository, r.ref.Tag)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch manifest %s: %v", r.ref, err)
+	}
+	defer br.Close()
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch manifest %s: %v", r.ref, err)
+	}
+	desc := br.Descriptor()
+	if r.ref.Digest != "" && desc.Digest != r.ref.Digest {
+		return nil, fmt.Errorf("manifest %s has digest %s", r.ref, desc.Digest)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", r.ref, err)
+	}
+	return map[string]interface{}{
+		"digest":    string(desc.Digest),
+		"mediaType": desc.MediaType,
+		"manifest":  m,
+	}, nil
+}
+
+type pushCmd struct{}
+
+func newPushCmd(v cue.Value) (task.Runner, error) {
+	return &pushCmd{}, nil
+}
+
+func (c *pushCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	artifactType := ctx.String("artifactType")
+	annotations, err := stringMap(ctx.Obj.Lookup("annotations"))
+	if err != nil {
+		return nil, err
+	}
+	r, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.ref.Tag == "" || r.ref.Digest != "" {
+		return nil, errors.Newf(ctx.Obj.Lookup("ref").Pos(),
+			"reference %s must have a tag and no digest", r.ref)
+	}
+
+	m := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       ocispec.DescriptorEmptyJSON,
+		Layers:       []ocispec.Descriptor{},
+		Annotations:  annotations,
+	}
+	m.Config.Data = nil
+
+	// Read all layers before pushing anything, so that invalid layers do not
+	// leave partial uploads behind.
+	var contents [][]byte
+	layers, _ := ctx.Obj.Lookup("layers").List()
+	for layers.Next() {
+		desc, data, err := layer(layers.Value())
+		if err != nil {
+			return nil, err
+		}
+		m.Layers = append(m.Layers, desc)
+		contents = append(contents, data)
+	}
+
+	if err := r.pushBlob(m.Config, []byte("{}")); err != nil {
+		return nil, err
+	}
+	for i, desc := range m.Layers {
+		if err := r.pushBlob(desc, contents[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := r.reg.PushManifest(r.ctx, r.ref.Repository, r.ref.Tag, data, m.MediaType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot push manifest %s: %v", r.ref, err)
+	}
+	return map[string]interface{}{"digest": string(desc.Digest)}, nil
+}
+
+// layer returns the descriptor and contents of a layer of a Push task.
+func layer(v cue.Value) (desc ocispec.Descriptor, data []byte, err error) {
+	if desc.MediaType, err = v.Lookup("mediaType").String(); err != nil {
+		return desc, nil, err
+	}
+	contents, file := v.Lookup("contents"), v.Lookup("file")
+	switch {
+	case contents.Exists() == file.Exists():
+		return desc, nil, errors.Newf(v.Pos(), "layer must have exactly one of contents and file")
+	case contents.Exists():
+		data, err = contents.Bytes()
+	default:
+		var name string
+		if name, err = file.String(); err == nil {
+			data, err = os.ReadFile(name)
+		}
+	}
+	if err != nil {
+		return desc, nil, err
+	}
+	if desc.Annotations, err = stringMap(v.Lookup("annotations")); err != nil {
+		return desc, nil, err
+	}
+	desc.Digest = digest.FromBytes(data)
+	desc.Size = int64(len(data))
+	return desc, data, nil
+}
+
+// registry holds a client for the registry of the reference of a task.
+type registry struct {
+	ctx context.Context
+	reg ociregistry.Interface
+	ref ociref.Reference
+}
+
+// open parses the reference of the task of ctx and creates a client for its
+// registry.
+func open(ctx *task.Context) (*registry, error) {
+	refValue := ctx.Obj.Lookup("ref")
+	s := ctx.String("ref")
+	insecure, err := ctx.Obj.Lookup("insecure").Bool()
+	if err != nil {
+		insecure = false
+	}
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	ref, err := ociref.Parse(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, refValue.Pos(), "invalid reference %q", s)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	cfg, err := modauth.Load(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	reg, err := ociclient.New(ref.Host, &ociclient.Options{
+		Client:   modauth.NewClient(nil, cfg.Credentials),
+		Insecure: insecure,
+	})
+	if err != nil {
+		return nil, err
+	}
+	goCtx := ctx.Context
+	if goCtx == nil {
+		goCtx = context.Background()
+	}
+	return &registry{ctx: goCtx, reg: reg, ref: ref}, nil
+}
+
+func (r *registry) resolve() (ociregistry.Descriptor, error) {
+	var desc ociregistry.Descriptor
+	var err error
+	if r.ref.Digest != "" {
+		desc, err = r.reg.ResolveManifest(r.ctx, r.ref.Repository, r.ref.Digest)
+	} else {
+		desc, err = r.reg.ResolveTag(r.ctx, r.ref.Repository, r.ref.Tag)
+	}
+	if err != nil {
+		return desc, fmt.Errorf("cannot resolve %s: %v", r.ref, err)
+	}
+	return desc, nil
+}
+
+func (r *registry) pushBlob(desc ocispec.Descriptor, data []byte) error {
+	if _, err := r.reg.PushBlob(r.ctx, r.ref.Repository, desc, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("cannot push blob %s: %v", desc.Digest, err)
+	}
+	return nil
+}
+
+func stringMap(v cue.Value) (map[string]string, error) {
+	if !v.Exists() {
+		return nil, nil
+	}
+	var m map[string]string
+	if err := v.Decode(&m); err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
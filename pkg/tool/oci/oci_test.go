@@ -0,0 +1,143 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelabs.dev/go/oci/ociregistry/ocimem"
+	"cuelabs.dev/go/oci/ociregistry/ociserver"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/task"
+	"cuelang.org/go/internal/value"
+)
+
+func parse(t *testing.T, kind, expr string) cue.Value {
+	t.Helper()
+
+	x, err := parser.ParseExpr("test", expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r cue.Runtime
+	i, err := r.CompileExpr(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value.UnifyBuiltin(i.Value(), kind)
+}
+
+func TestPushResolveManifest(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	srv := httptest.NewServer(ociserver.New(ocimem.New(), nil))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	file := filepath.Join(t.TempDir(), "data.yaml")
+	if err := os.WriteFile(file, []byte("a: 1\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	v := parse(t, "tool/oci.Push", fmt.Sprintf(`{
+		ref:          "%s/test/artifact:v1"
+		insecure:     true
+		artifactType: "application/vnd.example.test"
+		layers: [{
+			contents: "hello"
+		}, {
+			mediaType: "application/yaml"
+			file:      %q
+			annotations: name: "data.yaml"
+		}]
+		annotations: source: "test"
+	}`, host, file))
+	res, err := (*pushCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := res.(map[string]interface{})["digest"].(string)
+	if !strings.HasPrefix(digest, "sha256:") {
+		t.Fatalf("unexpected digest %q", digest)
+	}
+
+	v = parse(t, "tool/oci.Resolve", fmt.Sprintf(`{ref: "%s/test/artifact:v1", insecure: true}`, host))
+	res, err = (*resolveCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(map[string]interface{})["digest"]; got != digest {
+		t.Errorf("resolved digest %v; want %v", got, digest)
+	}
+
+	v = parse(t, "tool/oci.Manifest", fmt.Sprintf(`{ref: "%s/test/artifact@%s", insecure: true}`, host, digest))
+	res, err = (*manifestCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := res.(map[string]interface{})["manifest"].(map[string]interface{})
+	if got := m["artifactType"]; got != "application/vnd.example.test" {
+		t.Errorf("got artifactType %v", got)
+	}
+	layers := m["layers"].([]interface{})
+	if len(layers) != 2 {
+		t.Fatalf("got %d layers; want 2", len(layers))
+	}
+	layer := layers[1].(map[string]interface{})
+	if layer["mediaType"] != "application/yaml" || layer["size"] != float64(5) {
+		t.Errorf("unexpected layer %v", layer)
+	}
+
+	v = parse(t, "tool/oci.Resolve", fmt.Sprintf(`{ref: "%s/test/artifact:missing", insecure: true}`, host))
+	if _, err := (*resolveCmd).Run(nil, &task.Context{Obj: v}); err == nil {
+		t.Error("expected error resolving missing tag")
+	}
+}
+
+func TestPushInvalid(t *testing.T) {
+	testCases := []struct {
+		desc, ref, layers, err string
+	}{{
+		desc:   "digest reference",
+		ref:    "localhost:5000/test@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		layers: "[]",
+		err:    "must have a tag",
+	}, {
+		desc:   "no host",
+		ref:    "test",
+		layers: "[]",
+		err:    "invalid reference",
+	}, {
+		desc:   "no contents",
+		ref:    "localhost:5000/test:v1",
+		layers: "[{}]",
+		err:    "exactly one of contents and file",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			v := parse(t, "tool/oci.Push", fmt.Sprintf(`{ref: %q, artifactType: "x", layers: %s}`, tc.ref, tc.layers))
+			_, err := (*pushCmd).Run(nil, &task.Context{Obj: v})
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("got error %v; want %q", err, tc.err)
+			}
+		})
+	}
+}
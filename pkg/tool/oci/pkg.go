@@ -0,0 +1,143 @@
+// Code generated by cuelang.org/go/pkg/gen. DO NOT EDIT.
+
+// Package oci provides tasks for working with OCI registries, such as
+// container registries.
+//
+// References have the form host/repository:tag or host/repository@digest.
+// Requests are authenticated with the credentials configured for the host
+// in the Docker configuration file, $DOCKER_CONFIG/config.json or
+// ~/.docker/config.json.
+//
+// These are the supported tasks:
+//
+//	// Resolve resolves a reference to the descriptor of its manifest.
+//	//
+//	// Example:
+//	//     task: resolve: oci.Resolve & {ref: "ghcr.io/cue-lang/cue:v0.6.0"}
+//	Resolve: {
+//		$id: "tool/oci.Resolve"
+//
+//		ref: !=""
+//
+//		// insecure uses HTTP instead of HTTPS to connect to the registry.
+//		insecure: *false | bool
+//
+//		// digest, mediaType and size describe the manifest.
+//		digest:    string
+//		mediaType: string
+//		size:      int
+//	}
+//
+//	// Manifest fetches a manifest.
+//	//
+//	// Example:
+//	//     task: manifest: oci.Manifest & {ref: "ghcr.io/cue-lang/cue:v0.6.0"}
+//	Manifest: {
+//		$id: "tool/oci.Manifest"
+//
+//		ref: !=""
+//
+//		// insecure uses HTTP instead of HTTPS to connect to the registry.
+//		insecure: *false | bool
+//
+//		// digest and mediaType describe the manifest.
+//		digest:    string
+//		mediaType: string
+//
+//		// manifest holds the decoded manifest.
+//		manifest: {...}
+//	}
+//
+//	// Push pushes an artifact as an OCI image manifest with the given layers
+//	// and an empty configuration.
+//	//
+//	// Example:
+//	//     task: push: oci.Push & {
+//	//         ref:          "registry.example.com/configs:v1"
+//	//         artifactType: "application/vnd.example.config"
+//	//         layers: [{
+//	//             mediaType: "application/yaml"
+//	//             file:      "deploy.yaml"
+//	//         }]
+//	//     }
+//	Push: {
+//		$id: "tool/oci.Push"
+//
+//		// ref is the reference to push to, which must have a tag and no digest.
+//		ref: !=""
+//
+//		// insecure uses HTTP instead of HTTPS to connect to the registry.
+//		insecure: *false | bool
+//
+//		// artifactType is the media type of the artifact.
+//		artifactType: !=""
+//
+//		// layers holds the blobs of the artifact. The contents of a layer are
+//		// given by either contents or the name of a file.
+//		layers: [...{
+//			mediaType: *"application/octet-stream" | string
+//			contents?: bytes | string
+//			file?:     string
+//			annotations: [string]: string
+//		}]
+//
+//		// annotations are added to the manifest.
+//		annotations: [string]: string
+//
+//		// digest holds the digest of the pushed manifest.
+//		digest: string
+//	}
+package oci
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
+)
+
+func init() {
+	pkg.Register("tool/oci", p)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var p = &pkg.Package{
+	Native: []*pkg.Builtin{},
+	CUE: `{
+	Resolve: {
+		$id:       "tool/oci.Resolve"
+		ref:       !=""
+		insecure:  *false | bool
+		digest:    string
+		mediaType: string
+		size:      int
+	}
+	Manifest: {
+		$id:       "tool/oci.Manifest"
+		ref:       !=""
+		insecure:  *false | bool
+		digest:    string
+		mediaType: string
+		manifest: {
+			...
+		}
+	}
+	Push: {
+		$id:          "tool/oci.Push"
+		ref:          !=""
+		insecure:     *false | bool
+		artifactType: !=""
+		layers: [...{
+			mediaType: *"application/octet-stream" | string
+			contents?: bytes | string
+			file?:     string
+			annotations: {
+				[string]: string
+			}
+		}]
+		annotations: {
+			[string]: string
+		}
+		digest: string
+	}
+}`,
+}
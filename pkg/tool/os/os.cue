@@ -12,6 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package os defines tasks for retrieving os-related information.
+//
+// CUE definitions:
 package os
 
 // A Value are all possible values allowed in flags.
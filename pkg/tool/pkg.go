@@ -53,6 +53,19 @@
 //		// $long is a longer description that spans multiple lines and
 //		// likely contain examples of usage of the command.
 //		$long?: string
+//
+//		// $flags declares the flags of the command. Each field declares a flag
+//		// of the same name, where the type of the field determines its type, a
+//		// default for the field its default, and the first line of its doc
+//		// comment its usage. The values given on the command line are unified
+//		// with the fields.
+//		$flags?: [string]: bool | number | string | [...string]
+//
+//		// $args declares the positional arguments of the command, in order,
+//		// in the same way as $flags. Arguments with a default are optional.
+//		// Only the last argument may be a list, which receives all remaining
+//		// arguments.
+//		$args?: [string]: bool | number | string | [...string]
 //	}
 //
 //	// TODO:
@@ -0,0 +1,71 @@
+// Code generated by cuelang.org/go/pkg/gen. DO NOT EDIT.
+
+// Package random provides tasks for generating random values.
+//
+// Random values are intentionally not provided as builtin functions, as these
+// are required to be pure. Defining them as tasks makes workflows that depend
+// on nondeterminism declare this explicitly.
+//
+// These are the supported tasks:
+//
+//	// Secret generates a cryptographically secure random secret.
+//	//
+//	// Example:
+//	//     task: token: random.Secret & {
+//	//         size: 16
+//	//     }
+//	Secret: {
+//		$id: "tool/random.Secret"
+//
+//		// size is the number of random bytes to generate.
+//		size: *32 | int & >0
+//
+//		// encoding specifies how the random bytes are encoded if value is a
+//		// string.
+//		encoding: *"hex" | "base64" | "base64url"
+//
+//		// value holds the generated secret. If it is declared as bytes, the raw
+//		// random bytes are returned.
+//		value: *string | bytes
+//	}
+//
+//	// Int generates a cryptographically secure random integer in the range
+//	// [0, max).
+//	Int: {
+//		$id: "tool/random.Int"
+//
+//		// max is the exclusive upper bound of the generated value.
+//		max: int & >0
+//
+//		// value holds the generated integer.
+//		value: int
+//	}
+package random
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
+)
+
+func init() {
+	pkg.Register("tool/random", p)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var p = &pkg.Package{
+	Native: []*pkg.Builtin{},
+	CUE: `{
+	Secret: {
+		$id:      "tool/random.Secret"
+		size:     *32 | int & >0
+		encoding: *"hex" | "base64" | "base64url"
+		value:    *string | bytes
+	}
+	Int: {
+		$id:   "tool/random.Int"
+		max:   >0 & int
+		value: int
+	}
+}`,
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package random provides tasks for generating random values.
+//
+// Random values are intentionally not provided as builtin functions, as these
+// are required to be pure. Defining them as tasks makes workflows that depend
+// on nondeterminism declare this explicitly.
+//
+// These are the supported tasks:
+package random
+
+// Secret generates a cryptographically secure random secret.
+//
+// Example:
+//     task: token: random.Secret & {
+//         size: 16
+//     }
+Secret: {
+	$id: "tool/random.Secret"
+
+	// size is the number of random bytes to generate.
+	size: *32 | int & >0
+
+	// encoding specifies how the random bytes are encoded if value is a
+	// string.
+	encoding: *"hex" | "base64" | "base64url"
+
+	// value holds the generated secret. If it is declared as bytes, the raw
+	// random bytes are returned.
+	value: *string | bytes
+}
+
+// Int generates a cryptographically secure random integer in the range
+// [0, max).
+Int: {
+	$id: "tool/random.Int"
+
+	// max is the exclusive upper bound of the generated value.
+	max: int & >0
+
+	// value holds the generated integer.
+	value: int
+}
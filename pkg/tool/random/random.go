@@ -0,0 +1,98 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package random
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/random.Secret", newSecretCmd)
+	task.Register("tool/random.Int", newIntCmd)
+}
+
+// reader is the source of randomness. It is replaced in tests.
+var reader io.Reader = rand.Reader
+
+type secretCmd struct{}
+
+func newSecretCmd(v cue.Value) (task.Runner, error) {
+	return &secretCmd{}, nil
+}
+
+func (c *secretCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	size := ctx.Int64("size")
+	encoding := ctx.String("encoding")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+
+	b := make([]byte, size)
+	if _, err := io.ReadFull(reader, b); err != nil {
+		return nil, err
+	}
+
+	update := map[string]interface{}{}
+	if v := ctx.Obj.LookupPath(cue.MakePath(cue.Str("value"))); v.IncompleteKind() == cue.BytesKind {
+		update["value"] = b
+		return update, nil
+	}
+
+	switch encoding {
+	case "hex":
+		update["value"] = hex.EncodeToString(b)
+	case "base64":
+		update["value"] = base64.StdEncoding.EncodeToString(b)
+	case "base64url":
+		update["value"] = base64.RawURLEncoding.EncodeToString(b)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+	return update, nil
+}
+
+type intCmd struct{}
+
+func newIntCmd(v cue.Value) (task.Runner, error) {
+	return &intCmd{}, nil
+}
+
+func (c *intCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	v := ctx.Lookup("max")
+	if ctx.Err != nil {
+		return nil, ctx.Err
+	}
+	var max big.Int
+	if _, err := v.Int(&max); err != nil {
+		return nil, err
+	}
+	if max.Sign() <= 0 {
+		return nil, fmt.Errorf("max must be positive, found %v", &max)
+	}
+
+	n, err := rand.Int(reader, &max)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"value": n}, nil
+}
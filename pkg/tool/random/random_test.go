@@ -0,0 +1,98 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package random
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/task"
+	"cuelang.org/go/internal/value"
+)
+
+func parse(t *testing.T, kind, expr string) cue.Value {
+	t.Helper()
+
+	x, err := parser.ParseExpr("test", expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r cue.Runtime
+	i, err := r.CompileExpr(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value.UnifyBuiltin(i.Value(), kind)
+}
+
+func TestSecret(t *testing.T) {
+	old := reader
+	defer func() { reader = old }()
+
+	testCases := []struct {
+		in   string
+		want interface{}
+	}{{
+		in:   `{size: 4}`,
+		want: "00010203",
+	}, {
+		in:   `{size: 4, encoding: "base64"}`,
+		want: "AAECAw==",
+	}, {
+		in:   `{size: 4, encoding: "base64url"}`,
+		want: "AAECAw",
+	}, {
+		in:   `{size: 4, value: bytes}`,
+		want: []byte{0, 1, 2, 3},
+	}, {
+		in:   `{}`,
+		want: "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			reader = bytes.NewReader([]byte{
+				0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+				16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
+			})
+			v := parse(t, "tool/random.Secret", tc.in)
+			got, err := (*secretCmd).Run(nil, &task.Context{Obj: v})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]interface{}{"value": tc.want}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestInt(t *testing.T) {
+	v := parse(t, "tool/random.Int", `{max: 10}`)
+	for i := 0; i < 10; i++ {
+		got, err := (*intCmd).Run(nil, &task.Context{Obj: v})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := got.(map[string]interface{})["value"].(*big.Int)
+		if n.Sign() < 0 || n.Cmp(big.NewInt(10)) >= 0 {
+			t.Errorf("value %v out of range [0, 10)", n)
+		}
+	}
+}
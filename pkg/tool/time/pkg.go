@@ -0,0 +1,51 @@
+// Code generated by cuelang.org/go/pkg/gen. DO NOT EDIT.
+
+// Package time provides tasks for observing the current time.
+//
+// The current time is intentionally not provided as a builtin function, as
+// these are required to be pure. Defining it as a task makes workflows that
+// depend on the current time declare this explicitly.
+//
+// These are the supported tasks:
+//
+//	// Now reports the current time.
+//	//
+//	// Example:
+//	//     task: now: time.Now
+//	//     task: print: cli.Print & {
+//	//         text: "generated at \(task.now.value)"
+//	//     }
+//	Now: {
+//		$id: "tool/time.Now"
+//
+//		// value holds the current time in UTC, formatted as RFC 3339 with
+//		// nanosecond precision. It can be passed to the builtin time package.
+//		value: string
+//
+//		// unix holds the current time as the number of seconds elapsed since
+//		// January 1, 1970 UTC.
+//		unix: int
+//	}
+package time
+
+import (
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/pkg"
+)
+
+func init() {
+	pkg.Register("tool/time", p)
+}
+
+var _ = adt.TopKind // in case the adt package isn't used
+
+var p = &pkg.Package{
+	Native: []*pkg.Builtin{},
+	CUE: `{
+	Now: {
+		$id:   "tool/time.Now"
+		value: string
+		unix:  int
+	}
+}`,
+}
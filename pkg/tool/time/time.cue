@@ -0,0 +1,41 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package time provides tasks for observing the current time.
+//
+// The current time is intentionally not provided as a builtin function, as
+// these are required to be pure. Defining it as a task makes workflows that
+// depend on the current time declare this explicitly.
+//
+// These are the supported tasks:
+package time
+
+// Now reports the current time.
+//
+// Example:
+//     task: now: time.Now
+//     task: print: cli.Print & {
+//         text: "generated at \(task.now.value)"
+//     }
+Now: {
+	$id: "tool/time.Now"
+
+	// value holds the current time in UTC, formatted as RFC 3339 with
+	// nanosecond precision. It can be passed to the builtin time package.
+	value: string
+
+	// unix holds the current time as the number of seconds elapsed since
+	// January 1, 1970 UTC.
+	unix: int
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package time
+
+import (
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/internal/task"
+)
+
+func init() {
+	task.Register("tool/time.Now", newNowCmd)
+}
+
+// now reports the current time. It is replaced in tests.
+var now = time.Now
+
+type nowCmd struct{}
+
+func newNowCmd(v cue.Value) (task.Runner, error) {
+	return &nowCmd{}, nil
+}
+
+func (c *nowCmd) Run(ctx *task.Context) (res interface{}, err error) {
+	t := now().UTC()
+	return map[string]interface{}{
+		"value": t.Format(time.RFC3339Nano),
+		"unix":  t.Unix(),
+	}, nil
+}
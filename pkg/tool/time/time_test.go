@@ -0,0 +1,57 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package time
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/internal/task"
+	"cuelang.org/go/internal/value"
+)
+
+func TestNow(t *testing.T) {
+	old := now
+	defer func() { now = old }()
+	now = func() time.Time {
+		return time.Date(2024, 3, 1, 12, 30, 0, 500, time.FixedZone("", 3600))
+	}
+
+	x, err := parser.ParseExpr("test", `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r cue.Runtime
+	i, err := r.CompileExpr(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := value.UnifyBuiltin(i.Value(), "tool/time.Now")
+
+	got, err := (*nowCmd).Run(nil, &task.Context{Obj: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"value": "2024-03-01T11:30:00.0000005Z",
+		"unix":  int64(1709292600),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
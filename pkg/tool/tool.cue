@@ -12,6 +12,24 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package tool defines stateful operation types for cue commands.
+//
+// This package is only visible in cue files with a _tool.cue or _tool_test.cue
+// ending.
+//
+// CUE configuration files are not influenced by and do not influence anything
+// outside the configuration itself: they are hermetic. Tools solve
+// two problems: allow outside values such as environment variables,
+// file or web contents, random generators etc. to influence configuration,
+// and allow configuration to be actionable from within the tooling itself.
+// Separating these concerns makes it clear to user when outside influences are
+// in play and the tool definition can be strict about what is allowed.
+//
+// Tools are defined in files ending with _tool.cue. These files have a
+// top-level map, "command", which defines all the tools made available through
+// the cue command.
+//
+// The following definitions are for defining commands in tool files:
 package tool
 
 // A Command specifies a user-defined command.
@@ -48,6 +66,19 @@ Command: {
 	// $long is a longer description that spans multiple lines and
 	// likely contain examples of usage of the command.
 	$long?: string
+
+	// $flags declares the flags of the command. Each field declares a flag
+	// of the same name, where the type of the field determines its type, a
+	// default for the field its default, and the first line of its doc
+	// comment its usage. The values given on the command line are unified
+	// with the fields.
+	$flags?: [string]: bool | number | string | [...string]
+
+	// $args declares the positional arguments of the command, in order,
+	// in the same way as $flags. Arguments with a default are optional.
+	// Only the last argument may be a list, which receives all remaining
+	// arguments.
+	$args?: [string]: bool | number | string | [...string]
 }
 
 // TODO:
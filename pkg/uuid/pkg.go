@@ -136,6 +136,56 @@ var p = &pkg.Package{
 				c.Ret, c.Err = MD5(space, data)
 			}
 		},
+	}, {
+		Name: "Inspect",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.TopKind,
+		Func: func(c *pkg.CallCtxt) {
+			s := c.String(0)
+			if c.Do() {
+				c.Ret, c.Err = Inspect(s)
+			}
+		},
+	}, {
+		Name: "ValidVersion",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.IntKind},
+		},
+		Result: adt.BottomKind,
+		Func: func(c *pkg.CallCtxt) {
+			s, version := c.String(0), c.Int(1)
+			if c.Do() {
+				c.Ret = ValidVersion(s, version)
+			}
+		},
+	}, {
+		Name: "V7",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+			{Kind: adt.BytesKind | adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			t, data := c.String(0), c.Bytes(1)
+			if c.Do() {
+				c.Ret, c.Err = V7(t, data)
+			}
+		},
+	}, {
+		Name: "Timestamp",
+		Params: []pkg.Param{
+			{Kind: adt.StringKind},
+		},
+		Result: adt.StringKind,
+		Func: func(c *pkg.CallCtxt) {
+			x := c.String(0)
+			if c.Do() {
+				c.Ret, c.Err = Timestamp(x)
+			}
+		},
 	}},
 	CUE: `{
 	ns: {
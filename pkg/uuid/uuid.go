@@ -14,13 +14,18 @@
 
 // Package uuid defines functionality for creating UUIDs as defined in RFC 4122.
 //
-// Currently only Version 5 (SHA1) and Version 3 (MD5) are supported.
+// UUIDs are generated deterministically from their inputs so that evaluation
+// stays hermetic. Version 5 (SHA1), Version 3 (MD5), and Version 7, from an
+// explicit timestamp, are supported.
 package uuid
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"math/big"
 	"regexp"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -118,3 +123,92 @@ func MD5(space string, data []byte) (string, error) {
 	}
 	return string(uuid.NewMD5(u, data).String()), nil
 }
+
+// Info holds the decoded properties of a UUID.
+type Info struct {
+	UUID    string `json:"uuid"`
+	URN     string `json:"urn"`
+	Version int    `json:"version"`
+	Variant int    `json:"variant"`
+}
+
+// Inspect decodes s, in any of the forms accepted by Parse, and reports its
+// canonical form, URN, version and variant.
+func Inspect(s string) (*Info, error) {
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{
+		UUID:    u.String(),
+		URN:     u.URN(),
+		Version: int(u.Version()),
+		Variant: int(u.Variant()),
+	}, nil
+}
+
+// ValidVersion reports whether s is a valid UUID in canonical form of the
+// given version with the RFC 4122 variant.
+//
+// It can be used as a validator, as in
+//
+//	id: uuid.ValidVersion(4)
+func ValidVersion(s string, version int) error {
+	if err := Valid(s); err != nil {
+		return err
+	}
+	u := uuid.MustParse(s)
+	if u.Variant() != uuid.RFC4122 {
+		return fmt.Errorf("UUID %q has variant %v; want RFC4122", s, u.Variant())
+	}
+	if int(u.Version()) != version {
+		return fmt.Errorf("UUID %q has version %d; want %d", s, u.Version(), version)
+	}
+	return nil
+}
+
+// V7 generates a version 7 UUID for the timestamp t, which must be in RFC3339
+// format. The bits that are normally random are derived from data instead, so
+// that the same inputs always yield the same UUID.
+//
+// UUIDs generated with later timestamps sort after those with earlier ones.
+func V7(t string, data []byte) (string, error) {
+	tm, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return "", err
+	}
+	ms := tm.UnixMilli()
+	if ms < 0 || ms >= 1<<48 {
+		return "", fmt.Errorf("timestamp %s out of range for UUID version 7", t)
+	}
+	sum := sha256.Sum256(data)
+
+	var u uuid.UUID
+	binary.BigEndian.PutUint64(u[:8], uint64(ms)<<16)
+	copy(u[6:], sum[:10])
+	u[6] = u[6]&0x0f | 0x70 // version 7
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	return u.String(), nil
+}
+
+// Timestamp reports the time embedded in a version 1 or version 7 UUID, in
+// RFC3339 format.
+func Timestamp(x string) (string, error) {
+	u, err := uuid.Parse(x)
+	if err != nil {
+		return "", err
+	}
+	var t time.Time
+	switch u.Version() {
+	case 1:
+		sec, nsec := u.Time().UnixTime()
+		t = time.Unix(sec, nsec)
+	case 7:
+		var b [8]byte
+		copy(b[2:], u[:6])
+		t = time.UnixMilli(int64(binary.BigEndian.Uint64(b[:])))
+	default:
+		return "", fmt.Errorf("UUID %q of version %d has no timestamp", x, u.Version())
+	}
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
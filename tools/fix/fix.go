@@ -17,6 +17,10 @@
 //
 // Note: the transformations that are supported in this package will change
 // over time.
+//
+// Additional user-defined rewrites can be applied with the Rules option, for
+// instance to rename a deprecated field across all files of a module using
+// Instances.
 package fix
 
 import (
@@ -31,6 +35,7 @@ type Option func(*options)
 
 type options struct {
 	simplify bool
+	rules    []*Rule
 }
 
 // Simplify enables fixes that simplify the code, but are not strictly
@@ -46,6 +51,10 @@ func File(f *ast.File, o ...Option) *ast.File {
 		f(&options)
 	}
 
+	if len(options.rules) > 0 {
+		f = applyRules(f, options.rules)
+	}
+
 	// Rewrite integer division operations to use builtins.
 	f = astutil.Apply(f, func(c astutil.Cursor) bool {
 		n := c.Node()
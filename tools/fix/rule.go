@@ -0,0 +1,270 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fix
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+)
+
+// A Rule rewrites all expressions matching Pattern to Replacement.
+//
+// Identifiers in Pattern that start with a $, such as $x, are metavariables.
+// A metavariable matches any expression; if it occurs more than once, all
+// occurrences must match the same expression. The expressions matched by
+// metavariables are substituted for the corresponding identifiers in
+// Replacement. All other nodes match structurally, ignoring positions and
+// comments. Note that this means identifiers such as $id cannot be matched
+// literally.
+//
+// A Pattern that consists of a single identifier also matches field labels
+// and selectors, so that a rule from oldName to newName renames a field
+// along with all references to it. Labels are only rewritten if Replacement
+// is a valid label.
+//
+// The matching is purely syntactic: a rule does not distinguish between
+// different fields or variables with the same name.
+type Rule struct {
+	Pattern     ast.Expr
+	Replacement ast.Expr
+}
+
+// ParseRule parses pattern and replacement as CUE expressions and returns the
+// corresponding rule.
+func ParseRule(pattern, replacement string) (*Rule, error) {
+	p, err := parser.ParseExpr("pattern", pattern)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parser.ParseExpr("replacement", replacement)
+	if err != nil {
+		return nil, err
+	}
+	rule := &Rule{Pattern: p, Replacement: r}
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Rules enables the given user-defined rules. The rules are applied before
+// any of the builtin fixes. For each expression, only the first matching
+// rule is applied.
+func Rules(rules ...*Rule) Option {
+	return func(o *options) { o.rules = append(o.rules, rules...) }
+}
+
+func (r *Rule) validate() error {
+	if isMetaVar(r.Pattern) {
+		return fmt.Errorf("pattern must not be a single metavariable")
+	}
+	vars := map[string]bool{}
+	ast.Walk(r.Pattern, func(n ast.Node) bool {
+		if x, ok := n.(*ast.Ident); ok && isMetaVar(x) {
+			vars[x.Name] = true
+		}
+		return true
+	}, nil)
+	var err error
+	ast.Walk(r.Replacement, func(n ast.Node) bool {
+		if x, ok := n.(*ast.Ident); ok && isMetaVar(x) && !vars[x.Name] && err == nil {
+			err = fmt.Errorf("metavariable %s in replacement does not occur in pattern", x.Name)
+		}
+		return err == nil
+	}, nil)
+	return err
+}
+
+// applyRules rewrites f according to rules.
+func applyRules(f *ast.File, rules []*Rule) *ast.File {
+	// Rewrite bottom-up, so that the result of a rewrite is not rewritten
+	// again.
+	return astutil.Apply(f, nil, func(c astutil.Cursor) bool {
+		x, ok := c.Node().(ast.Expr)
+		if !ok {
+			return true
+		}
+		for _, r := range rules {
+			vars := map[string]ast.Expr{}
+			if !match(reflect.ValueOf(r.Pattern), reflect.ValueOf(x), vars) {
+				continue
+			}
+			y := subst(reflect.ValueOf(r.Replacement), vars).Interface().(ast.Expr)
+			if !canReplace(c.Parent(), x, y) {
+				continue
+			}
+			astutil.CopyMeta(y, x)
+			c.Replace(y)
+			break
+		}
+		return true
+	}).(*ast.File)
+}
+
+// canReplace reports whether old can be replaced with new within its parent,
+// which is only the case if new is valid in the position of old.
+func canReplace(parent astutil.Cursor, old ast.Node, new ast.Expr) bool {
+	if parent == nil {
+		return true
+	}
+	_, isIdent := new.(*ast.Ident)
+	_, isLabel := new.(ast.Label)
+	switch p := parent.Node().(type) {
+	case *ast.Package, *ast.ImportSpec:
+		return false
+	case *ast.Field:
+		return ast.Node(p.Label) != old || isLabel
+	case *ast.SelectorExpr:
+		return ast.Node(p.Sel) != old || isLabel
+	case *ast.LetClause:
+		return ast.Node(p.Ident) != old || isIdent
+	case *ast.Alias:
+		return ast.Node(p.Ident) != old || isIdent
+	case *ast.ForClause:
+		return (ast.Node(p.Key) != old && ast.Node(p.Value) != old) || isIdent
+	}
+	return true
+}
+
+func isMetaVar(n ast.Node) bool {
+	x, ok := n.(*ast.Ident)
+	return ok && len(x.Name) > 1 && strings.HasPrefix(x.Name, "$")
+}
+
+var (
+	posType   = reflect.TypeOf(token.NoPos)
+	identType = reflect.TypeOf(ast.Ident{})
+)
+
+// skipField reports whether field i of struct type t should be ignored when
+// matching and copying nodes.
+func skipField(t reflect.Type, i int) bool {
+	f := t.Field(i)
+	switch {
+	case !f.IsExported(), f.Type == posType:
+		return true
+	case t == identType:
+		// Ignore references resolved by the parser.
+		return f.Name == "Scope" || f.Name == "Node"
+	}
+	return false
+}
+
+// match reports whether the node x matches the pattern p, recording the
+// expressions matched by metavariables in vars.
+func match(p, x reflect.Value, vars map[string]ast.Expr) bool {
+	if p.Kind() == reflect.Interface {
+		if p.IsNil() || x.IsNil() {
+			return p.IsNil() && x.IsNil()
+		}
+		p, x = p.Elem(), x.Elem()
+	}
+	if p.Type() != x.Type() && !(p.Kind() == reflect.Pointer && isMetaVar(p.Interface().(ast.Node))) {
+		return false
+	}
+	switch p.Kind() {
+	case reflect.Pointer:
+		if p.IsNil() || x.IsNil() {
+			return p.IsNil() && x.IsNil()
+		}
+		if n, ok := p.Interface().(*ast.Ident); ok && isMetaVar(n) {
+			e, ok := x.Interface().(ast.Expr)
+			if !ok {
+				return false
+			}
+			if prev, ok := vars[n.Name]; ok {
+				return match(reflect.ValueOf(prev), reflect.ValueOf(e), nil)
+			}
+			if vars == nil {
+				// Comparing two previously matched expressions.
+				return p.Type() == x.Type() && match(p.Elem(), x.Elem(), nil)
+			}
+			vars[n.Name] = e
+			return true
+		}
+		return match(p.Elem(), x.Elem(), vars)
+	case reflect.Slice:
+		if p.Len() != x.Len() {
+			return false
+		}
+		for i := 0; i < p.Len(); i++ {
+			if !match(p.Index(i), x.Index(i), vars) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			if skipField(p.Type(), i) {
+				continue
+			}
+			if !match(p.Field(i), x.Field(i), vars) {
+				return false
+			}
+		}
+		return true
+	}
+	return p.Interface() == x.Interface()
+}
+
+// subst returns a copy of the replacement node v with its metavariables
+// substituted by the expressions in vars. Copied nodes have no position or
+// comments, so that they are formatted according to their new context.
+func subst(v reflect.Value, vars map[string]ast.Expr) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		w := reflect.New(v.Type()).Elem()
+		w.Set(subst(v.Elem(), vars))
+		return w
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if n, ok := v.Interface().(*ast.Ident); ok && isMetaVar(n) {
+			return reflect.ValueOf(vars[n.Name])
+		}
+		w := reflect.New(v.Type().Elem())
+		w.Elem().Set(subst(v.Elem(), vars))
+		return w
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		w := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			w.Index(i).Set(subst(v.Index(i), vars))
+		}
+		return w
+	case reflect.Struct:
+		w := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if skipField(v.Type(), i) {
+				continue
+			}
+			w.Field(i).Set(subst(v.Field(i), vars))
+		}
+		return w
+	}
+	return v
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fix
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+)
+
+func TestRules(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rules [][2]string
+		in    string
+		out   string
+	}{{
+		name:  "rename field",
+		rules: [][2]string{{"oldName", "newName"}},
+		in: `package foo
+
+// Doc comment.
+oldName: 1 // line comment
+a:       oldName + 1
+b:       x.oldName
+c: {oldName: 2}
+`,
+		out: `package foo
+
+// Doc comment.
+newName: 1 // line comment
+a:       newName + 1
+b:       x.newName
+c: {newName: 2}
+`,
+	}, {
+		name:  "metavariables",
+		rules: [][2]string{{"strings.ToTitle($x)", "strings.ToUpper($x)"}},
+		in: `
+import "strings"
+
+a: strings.ToTitle("foo") // keep
+b: strings.ToTitle(strings.ToTitle(a))
+c: strings.ToLower("foo")
+`,
+		out: `import "strings"
+
+a: strings.ToUpper("foo") // keep
+b: strings.ToUpper(strings.ToUpper(a))
+c: strings.ToLower("foo")
+`,
+	}, {
+		name:  "repeated metavariable",
+		rules: [][2]string{{"$x & $x", "$x"}},
+		in: `
+a: b.c & b.c
+d: b.c & b.d
+`,
+		out: `a: b.c
+d: b.c & b.d
+`,
+	}, {
+		name:  "no relabeling with non-label",
+		rules: [][2]string{{"old", "new.field"}},
+		in: `
+old: 1
+a:   old
+`,
+		out: `old: 1
+a:   new.field
+`,
+	}, {
+		name: "first rule wins",
+		rules: [][2]string{
+			{"f($x)", "g($x)"},
+			{"f(1)", "h(1)"},
+		},
+		in: `a: f(1)
+`,
+		out: `a: g(1)
+`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var rules []*Rule
+			for _, r := range tc.rules {
+				rule, err := ParseRule(r[0], r[1])
+				if err != nil {
+					t.Fatal(err)
+				}
+				rules = append(rules, rule)
+			}
+			f, err := parser.ParseFile("", tc.in, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := format.Node(File(f, Rules(rules...)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tc.out {
+				t.Errorf("got %v; want %v", got, tc.out)
+			}
+		})
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	testCases := []struct {
+		pattern, replacement, err string
+	}{
+		{"$x", "y", "single metavariable"},
+		{"f($x)", "g($y)", "metavariable $y in replacement"},
+		{"f(", "g", "expected"},
+	}
+	for _, tc := range testCases {
+		_, err := ParseRule(tc.pattern, tc.replacement)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("ParseRule(%q, %q): got error %v; want %q", tc.pattern, tc.replacement, err, tc.err)
+		}
+	}
+}
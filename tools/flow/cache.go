@@ -0,0 +1,182 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+// This file contains the logic for caching the results of tasks.
+//
+// The key of a task is a hash of its value, as evaluated just before it is
+// run. As a task only runs once the tasks it depends on have completed, this
+// value includes all inputs of the task, including the results of other
+// tasks. The results of a task, as passed to Task.Fill, are stored as CUE.
+// A task for which an entry is found is not run; its cached results are
+// used instead, allowing downstream tasks to be skipped in turn if their
+// inputs are unchanged.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/internal/core/adt"
+	"cuelang.org/go/internal/value"
+)
+
+// cacheVersion is included in each key, so that changes to the format of
+// keys or entries invalidate existing caches.
+const cacheVersion = "cue.flow.cache v1"
+
+// A Cache stores the results of tasks, keyed by a hash of their inputs.
+//
+// Only tasks that have the cache flag set in their TaskAttr attribute, as in
+// @task(cache), are cached. Tasks with side effects, such as printing or
+// writing files, should not be cached, as they are skipped when their
+// results are found in the cache.
+//
+// A Cache must be safe for concurrent use.
+type Cache interface {
+	// Get returns the data stored for key and reports whether it was found.
+	Get(key string) (data []byte, ok bool, err error)
+
+	// Put stores data for key.
+	Put(key string, data []byte) error
+}
+
+// DirCache is a Cache that stores each entry as a file in a directory, which
+// is created as needed.
+type DirCache struct {
+	Dir string
+}
+
+// Get implements Cache.
+func (d DirCache) Get(key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(filepath.Join(d.Dir, key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Put implements Cache. Entries are written atomically, so that concurrent
+// runs never observe partially written entries.
+func (d DirCache) Put(key string, data []byte) error {
+	if err := os.MkdirAll(d.Dir, 0o777); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(d.Dir, key+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(d.Dir, key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// cacheKey computes the key of t from its current value.
+func cacheKey(t *Task) (string, error) {
+	b, err := format.Node(t.v.Syntax(
+		cue.Docs(false),
+		cue.Attributes(false),
+		cue.Optional(true),
+		cue.Definitions(true),
+		cue.Hidden(true),
+	))
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, cacheVersion)
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lookupCache sets the results of t from the cache if the Controller is
+// configured with a Cache, t is to be cached and an entry is found. It
+// reports whether this is the case.
+func (c *Controller) lookupCache(t *Task) (bool, error) {
+	if c.cfg.Cache == nil || !t.opts.cache {
+		return false, nil
+	}
+	key, err := cacheKey(t)
+	if err != nil {
+		// The task may still run, but its results cannot be cached.
+		return false, nil
+	}
+	t.cacheKey = key
+
+	data, ok, err := c.cfg.Cache.Get(key)
+	if err != nil {
+		return false, fmt.Errorf("cannot read cache: %v", err)
+	}
+	if !ok {
+		return false, nil
+	}
+	t.update = nil
+	if len(data) > 0 {
+		v := t.v.Context().CompileBytes(data)
+		if v.Err() != nil {
+			// Treat invalid entries as missing, so that they are replaced.
+			return false, nil
+		}
+		_, t.update = value.ToInternal(v)
+	}
+	t.cached = true
+	return true, nil
+}
+
+// storeCache stores the results of the completed task t if it was run and
+// is to be cached.
+func (c *Controller) storeCache(t *Task) error {
+	if c.cfg.Cache == nil || t.cached || t.cacheKey == "" {
+		return nil
+	}
+	var data []byte
+	if t.update != nil {
+		v := &adt.Vertex{Conjuncts: []adt.Conjunct{adt.MakeRootConjunct(c.env, t.update)}}
+		v.Finalize(c.opCtx)
+		b, err := format.Node(value.Make(c.opCtx, v).Syntax(cue.Final()))
+		if err != nil {
+			return fmt.Errorf("cannot encode results: %v", err)
+		}
+		data = b
+	}
+	if err := c.cfg.Cache.Put(t.cacheKey, data); err != nil {
+		return fmt.Errorf("cannot write cache: %v", err)
+	}
+	return nil
+}
+
+// Cached reports whether the results of the task were taken from the Cache
+// of the Controller, rather than by running the task.
+//
+// This method may currently only be called after a Task completed, or from
+// within a call to UpdateFunc.
+func (t *Task) Cached() bool {
+	return t.cached
+}
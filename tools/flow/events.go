@@ -0,0 +1,87 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import "time"
+
+// An EventKind indicates what happened to a task.
+type EventKind int
+
+const (
+	// TaskStarted indicates a task started running.
+	TaskStarted EventKind = iota
+
+	// TaskFinished indicates a task completed successfully.
+	TaskFinished
+
+	// TaskFailed indicates a task completed with an error.
+	TaskFailed
+)
+
+var eventKindStrings = map[EventKind]string{
+	TaskStarted:  "start",
+	TaskFinished: "finish",
+	TaskFailed:   "error",
+}
+
+// String reports a human readable string of kind k.
+func (k EventKind) String() string {
+	return eventKindStrings[k]
+}
+
+// An Event reports progress of a single task.
+type Event struct {
+	Kind EventKind
+	Task *Task
+
+	// Time is the time at which the event occurred.
+	Time time.Time
+
+	// Duration is the time elapsed since the task started. It is zero for
+	// TaskStarted events.
+	Duration time.Duration
+
+	// Err is the error with which the task failed for TaskFailed events.
+	Err error
+}
+
+// A Subscriber receives the events of a workflow.
+//
+// Events are delivered in the order in which they occur, from the goroutine
+// that calls Controller.Run. A Subscriber should therefore return quickly.
+// Within a call to Notify it is safe to call the methods of the Task, such
+// as Path, Attempts, and Cached, and those of the Controller, such as Graph.
+type Subscriber interface {
+	Notify(e Event)
+}
+
+// notify sends an event of the given kind for t to the Subscriber, if any.
+func (c *Controller) notify(kind EventKind, t *Task) {
+	now := time.Now()
+	if kind == TaskStarted {
+		t.start = now
+	}
+	if c.cfg.Subscriber == nil {
+		return
+	}
+	e := Event{Kind: kind, Task: t, Time: now}
+	if kind != TaskStarted {
+		e.Duration = now.Sub(t.start)
+	}
+	if kind == TaskFailed {
+		e.Err = t.err
+	}
+	c.cfg.Subscriber.Notify(e)
+}
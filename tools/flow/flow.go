@@ -68,10 +68,9 @@ package flow
 
 import (
 	"context"
-	"fmt"
 	"os"
-	"strings"
 	"sync"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
@@ -149,6 +148,32 @@ type Config struct {
 	// updated. This includes directly after initialization. The task may be
 	// nil if this call is not the result of a task completing.
 	UpdateFunc func(c *Controller, t *Task) error
+
+	// Tracer, if not nil, is used to create spans for each call to Run and
+	// for each task that is run.
+	Tracer Tracer
+
+	// Workspace, if not nil, causes a temporary directory to be created for
+	// each task before it runs. See Task.Workspace.
+	Workspace *Workspace
+
+	// MaxParallel limits the number of tasks that run concurrently. Zero
+	// means there is no limit. Limits for groups of tasks may be set with
+	// the TaskAttr attribute.
+	MaxParallel int
+
+	// Retry, if not nil, causes tasks that fail to be retried. Individual
+	// tasks may override it with the TaskAttr attribute.
+	Retry *Retry
+
+	// Cache, if not nil, is used to store the results of tasks and to skip
+	// tasks of which the inputs did not change since their results were
+	// stored. See Cache for which tasks are cached.
+	Cache Cache
+
+	// Subscriber, if not nil, receives an Event whenever a task starts or
+	// completes.
+	Subscriber Subscriber
 }
 
 // A Controller defines a set of Tasks to be executed.
@@ -252,6 +277,9 @@ func New(cfg *Config, inst cue.InstanceOrValue, f TaskFunc) *Controller {
 
 // Run runs the tasks of a workflow until completion.
 func (c *Controller) Run(ctx context.Context) error {
+	ctx, endSpan := c.startRunSpan(ctx)
+	defer endSpan()
+
 	c.context, c.cancelFunc = context.WithCancel(ctx)
 	defer c.cancelFunc()
 
@@ -286,24 +314,6 @@ func (c *Controller) Value() cue.Value {
 // We need to escape quotes in the path, per
 // https://mermaid-js.github.io/mermaid/#/flowchart?id=entity-codes-to-escape-characters
 // This also requires that we escape the quoting character #.
-var mermaidQuote = strings.NewReplacer("#", "#35;", `"`, "#quot;")
-
-// mermaidGraph generates a mermaid graph of the current state. This can be
-// pasted into https://mermaid-js.github.io/mermaid-live-editor/ for
-// visualization.
-func mermaidGraph(c *Controller) string {
-	w := &strings.Builder{}
-	fmt.Fprintln(w, "graph TD")
-	for i, t := range c.Tasks() {
-		path := mermaidQuote.Replace(t.Path().String())
-		fmt.Fprintf(w, "  t%d(\"%s [%s]\")\n", i, path, t.State())
-		for _, t := range t.Dependencies() {
-			fmt.Fprintf(w, "  t%d-->t%d\n", i, t.Index())
-		}
-	}
-	return w.String()
-}
-
 // A State indicates the state of a Task.
 //
 // The following state diagram indicates the possible state transitions:
@@ -361,6 +371,10 @@ type Task struct {
 	c    *Controller
 	ctxt *adt.OpContext
 	r    Runner
+	ctx  context.Context // set if the task is traced
+
+	workspace string
+	opts      taskOptions
 
 	index  int
 	path   cue.Path
@@ -378,6 +392,10 @@ type Task struct {
 	err         errors.Error
 	state       State
 	depTasks    []*Task
+	attempts    int
+	cacheKey    string
+	cached      bool
+	start       time.Time
 
 	stats stats.Counts
 }
@@ -392,8 +410,12 @@ func (t *Task) Stats() stats.Counts {
 	return t.stats
 }
 
-// Context reports the Controller's Context.
+// Context reports the Controller's Context. If the Controller is configured
+// with a Tracer, the context includes the span of the task once it runs.
 func (t *Task) Context() context.Context {
+	if t.ctx != nil {
+		return t.ctx
+	}
 	return t.c.context
 }
 
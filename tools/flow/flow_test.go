@@ -29,6 +29,7 @@ import (
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/stats"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/cuetxtar"
 	"cuelang.org/go/tools/flow"
 )
@@ -157,6 +158,487 @@ func TestFlowValuePanic(t *testing.T) {
 	t.Errorf("Value() did not panic")
 }
 
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+type spanKey struct{}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(ctx context.Context, name string, attrs ...flow.Attribute) (context.Context, flow.Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*testSpan)
+	s := &testSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+	tr.spans = append(tr.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), &testSpanEnder{tr, s}
+}
+
+type testSpanEnder struct {
+	tr *testTracer
+	s  *testSpan
+}
+
+func (e *testSpanEnder) End(err error, attrs ...flow.Attribute) {
+	e.tr.mu.Lock()
+	defer e.tr.mu.Unlock()
+	for _, a := range attrs {
+		e.s.attrs[a.Key] = a.Value
+	}
+	e.s.err = err
+	e.s.ended = true
+}
+
+func TestTracer(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {
+			$id: "valToOut"
+			val: "a"
+			out: string
+		}
+		b: {
+			$id: "failure"
+			val: a.out
+		}
+	}
+	`)
+	tr := &testTracer{}
+	cfg := &flow.Config{
+		Root:   cue.ParsePath("root"),
+		Tracer: tr,
+	}
+	var taskSpan *testSpan
+	c := flow.New(cfg, v, func(v cue.Value) (flow.Runner, error) {
+		id, err := v.LookupPath(cue.ParsePath("$id")).String()
+		if err != nil {
+			return nil, nil
+		}
+		return flow.RunnerFunc(func(t *flow.Task) error {
+			if id == "failure" {
+				taskSpan, _ = t.Context().Value(spanKey{}).(*testSpan)
+				return errors.New("failed")
+			}
+			return t.Fill(map[string]string{"out": "x"})
+		}), nil
+	})
+	if err := c.Run(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	want := []string{
+		"cue.flow.run root=root tasks=2 failed=true",
+		"cue.flow.task path=root.a index=0 failed=false parent=cue.flow.run",
+		"cue.flow.task path=root.b index=1 failed=true parent=cue.flow.run",
+	}
+	var got []string
+	for _, s := range tr.spans {
+		if !s.ended {
+			t.Errorf("span %s not ended", s.name)
+		}
+		str := s.name
+		if s.parent == nil {
+			str += fmt.Sprintf(" root=%v tasks=%v", s.attrs[flow.AttrRoot], s.attrs[flow.AttrTasks])
+		} else {
+			str += fmt.Sprintf(" path=%v index=%v", s.attrs[flow.AttrTaskPath], s.attrs[flow.AttrTaskIndex])
+		}
+		str += fmt.Sprintf(" failed=%v", s.err != nil)
+		if s.parent != nil {
+			str += " parent=" + s.parent.name
+		}
+		got = append(got, str)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got spans:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if taskSpan == nil || taskSpan.attrs[flow.AttrTaskPath] != "root.b" {
+		t.Errorf("task context does not contain task span")
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {
+			$id: "write"
+			val: "a"
+		}
+		b: {
+			$id: "write"
+			val: "b"
+		}
+		c: {
+			$id: "failure"
+			val: a.val + b.val
+		}
+	}
+	`)
+	for _, tc := range []struct {
+		retention flow.Retention
+		want      string
+	}{
+		{flow.RemoveWorkspaces, ""},
+		{flow.KeepOnFailure, "root.c"},
+		{flow.KeepWorkspaces, "root.a root.b root.c"},
+	} {
+		dir := t.TempDir()
+		cfg := &flow.Config{
+			Root:      cue.ParsePath("root"),
+			Workspace: &flow.Workspace{Dir: dir, Retention: tc.retention},
+		}
+		var mu sync.Mutex
+		workspaces := map[string]string{}
+		c := flow.New(cfg, v, func(v cue.Value) (flow.Runner, error) {
+			id, err := v.LookupPath(cue.ParsePath("$id")).String()
+			if err != nil {
+				return nil, nil
+			}
+			return flow.RunnerFunc(func(t *flow.Task) error {
+				ws := t.Workspace()
+				mu.Lock()
+				defer mu.Unlock()
+				for p, dir := range workspaces {
+					if dir == ws {
+						return fmt.Errorf("%s shares workspace with %s", t.Path(), p)
+					}
+				}
+				workspaces[t.Path().String()] = ws
+
+				if _, err := os.ReadDir(ws); err != nil {
+					return err
+				}
+				if id == "failure" {
+					return errors.New("failed")
+				}
+				return os.WriteFile(path.Join(ws, "out"), []byte("x"), 0o666)
+			}), nil
+		})
+		if err := c.Run(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+
+		var kept []string
+		for _, task := range c.Tasks() {
+			ws := task.Workspace()
+			if ws == "" || path.Dir(ws) != dir {
+				t.Errorf("%v: unexpected workspace %q", task.Path(), ws)
+			}
+			if _, err := os.Stat(ws); err == nil {
+				kept = append(kept, task.Path().String())
+			}
+		}
+		if got := strings.Join(kept, " "); got != tc.want {
+			t.Errorf("retention %d: got kept workspaces %q; want %q", tc.retention, got, tc.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		flaky: {
+			$id:  "flaky"
+			fail: 2
+			out:  string
+		} @task(retries=2, backoff=1ms)
+		broken: {
+			$id:  "flaky"
+			fail: 5
+			in:   flaky.out
+		}
+	}
+	`)
+	cfg := &flow.Config{
+		Root:  cue.ParsePath("root"),
+		Retry: &flow.Retry{Retries: 3, Backoff: time.Millisecond},
+	}
+	c := flow.New(cfg, v, func(v cue.Value) (flow.Runner, error) {
+		if !v.LookupPath(cue.ParsePath("$id")).Exists() {
+			return nil, nil
+		}
+		calls := 0
+		return flow.RunnerFunc(func(t *flow.Task) error {
+			// Results of failed attempts must be discarded.
+			t.Fill(map[string]string{"out": fmt.Sprint("attempt", calls)})
+			fail, err := t.Value().LookupPath(cue.ParsePath("fail")).Int64()
+			if err != nil {
+				return err
+			}
+			if calls++; int64(calls) <= fail {
+				return errors.Newf(token.NoPos, "attempt %d failed", calls)
+			}
+			return nil
+		}), nil
+	})
+	err := c.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "attempt 4 failed") {
+		t.Errorf("got error %v; want attempt 4 failed", err)
+	}
+
+	var got []string
+	for _, task := range c.Tasks() {
+		got = append(got, fmt.Sprintf("%v=%d", task.Path(), task.Attempts()))
+	}
+	if got, want := strings.Join(got, " "), "root.flaky=3 root.broken=4"; got != want {
+		t.Errorf("got attempts %q; want %q", got, want)
+	}
+	out, _ := c.Value().LookupPath(cue.ParsePath("root.flaky.out")).String()
+	if out != "attempt2" {
+		t.Errorf("got out %q; want attempt2", out)
+	}
+}
+
+func TestRetryInvalidAttr(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: a: {
+		$id: "valToOut"
+	} @task(retries=-1)
+	`)
+	c := flow.New(&flow.Config{Root: cue.ParsePath("root")}, v, taskFunc)
+	err := c.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid retries in @task attribute") {
+		t.Errorf("got error %v; want invalid retries", err)
+	}
+}
+
+func TestParallelLimits(t *testing.T) {
+	const src = `
+	root: {
+		for i in [0, 1, 2, 3, 4, 5] {
+			"a\(i)": {$id: "count"} @task(group=a, limit=2)
+			"b\(i)": {$id: "count"}
+		}
+	}
+	`
+	for _, tc := range []struct {
+		maxParallel int
+		wantA       int
+		wantAll     int
+	}{
+		{maxParallel: 0, wantA: 2, wantAll: 12},
+		{maxParallel: 3, wantA: 2, wantAll: 3},
+		{maxParallel: 1, wantA: 1, wantAll: 1},
+	} {
+		v := cuecontext.New().CompileString(src)
+		cfg := &flow.Config{
+			Root:        cue.ParsePath("root"),
+			MaxParallel: tc.maxParallel,
+		}
+		var (
+			mu                   sync.Mutex
+			all, a, maxAll, maxA int
+		)
+		c := flow.New(cfg, v, func(v cue.Value) (flow.Runner, error) {
+			if !v.LookupPath(cue.ParsePath("$id")).Exists() {
+				return nil, nil
+			}
+			return flow.RunnerFunc(func(t *flow.Task) error {
+				isA := strings.HasPrefix(t.Path().String(), "root.a")
+				mu.Lock()
+				all++
+				if all > maxAll {
+					maxAll = all
+				}
+				if isA {
+					a++
+					if a > maxA {
+						maxA = a
+					}
+				}
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				all--
+				if isA {
+					a--
+				}
+				mu.Unlock()
+				return nil
+			}), nil
+		})
+		if err := c.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if maxAll > tc.wantAll || maxA > tc.wantA {
+			t.Errorf("MaxParallel %d: got at most %d tasks and %d of group a; want %d and %d",
+				tc.maxParallel, maxAll, maxA, tc.wantAll, tc.wantA)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	const src = `
+	root: {
+		a: {
+			$id: "upper"
+			in:  %q
+			out: string
+		} @task(cache)
+		b: {
+			$id: "upper"
+			in:  a.out + "!"
+			out: string
+		} @task(cache)
+		c: {
+			$id: "upper"
+			in:  b.out
+		}
+	}
+	`
+	cache := flow.DirCache{Dir: t.TempDir()}
+	run := func(in string) (string, []string) {
+		var (
+			mu  sync.Mutex
+			ran []string
+		)
+		v := cuecontext.New().CompileString(fmt.Sprintf(src, in))
+		cfg := &flow.Config{Root: cue.ParsePath("root"), Cache: cache}
+		c := flow.New(cfg, v, func(v cue.Value) (flow.Runner, error) {
+			if !v.LookupPath(cue.ParsePath("$id")).Exists() {
+				return nil, nil
+			}
+			return flow.RunnerFunc(func(t *flow.Task) error {
+				mu.Lock()
+				ran = append(ran, t.Path().String())
+				mu.Unlock()
+				in, err := t.Value().LookupPath(cue.ParsePath("in")).String()
+				if err != nil {
+					return err
+				}
+				return t.Fill(map[string]string{"out": strings.ToUpper(in)})
+			}), nil
+		})
+		if err := c.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for _, task := range c.Tasks() {
+			if task.Cached() == contains(ran, task.Path().String()) {
+				t.Errorf("%v: got Cached() %v; ran %v", task.Path(), task.Cached(), ran)
+			}
+		}
+		out, _ := c.Value().LookupPath(cue.ParsePath("root.b.out")).String()
+		return out, ran
+	}
+
+	for _, tc := range []struct {
+		in      string
+		wantOut string
+		wantRan string
+	}{
+		{"foo", "FOO!", "root.a root.b root.c"},
+		{"foo", "FOO!", "root.c"},
+		{"bar", "BAR!", "root.a root.b root.c"},
+		// The result of a is different, but that of b is not.
+		{"Bar", "BAR!", "root.a root.c"},
+	} {
+		out, ran := run(tc.in)
+		if out != tc.wantOut {
+			t.Errorf("%s: got out %q; want %q", tc.in, out, tc.wantOut)
+		}
+		if got := strings.Join(ran, " "); got != tc.wantRan {
+			t.Errorf("%s: got ran %q; want %q", tc.in, got, tc.wantRan)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGraph(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {$id: "valToOut", val: "a", out: string}
+		"b\"c": {$id: "valToOut", val: a.out, out: string}
+		d: {$id: "valToOut", val: a.out + root["b\"c"].out}
+	}
+	`)
+	c := flow.New(&flow.Config{Root: cue.ParsePath("root")}, v, taskFunc)
+	g := c.Graph()
+	if got, want := fmt.Sprint(g.Edges), "[{1 0} {2 0} {2 1}]"; got != want {
+		t.Errorf("got edges %s; want %s", got, want)
+	}
+	want := `digraph tasks {
+	t0 [label="root.a [Waiting]"];
+	t1 [label="root.\"b\\\"c\" [Waiting]"];
+	t1 -> t0;
+	t2 [label="root.d [Waiting]"];
+	t2 -> t0;
+	t2 -> t1;
+}
+`
+	if got := g.DOT(); got != want {
+		t.Errorf("got DOT:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range c.Graph().Nodes {
+		if n.State != flow.Terminated {
+			t.Errorf("%v: got state %v; want Terminated", n.Path, n.State)
+		}
+	}
+}
+
+type testSubscriber struct {
+	events []string
+}
+
+func (s *testSubscriber) Notify(e flow.Event) {
+	str := fmt.Sprintf("%v %v", e.Kind, e.Task.Path())
+	if e.Err != nil {
+		str += " err=" + e.Err.Error()
+	}
+	if e.Kind == flow.TaskStarted && e.Duration != 0 || e.Duration < 0 || e.Time.IsZero() {
+		str += " invalid timing"
+	}
+	s.events = append(s.events, str)
+}
+
+func TestSubscriber(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+	root: {
+		a: {$id: "valToOut", val: "a", out: string}
+		b: {$id: "failure", val: a.out}
+		c: {$id: "valToOut", val: b.val}
+	}
+	`)
+	s := &testSubscriber{}
+	cfg := &flow.Config{Root: cue.ParsePath("root"), Subscriber: s}
+	c := flow.New(cfg, v, taskFunc)
+	if err := c.Run(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	want := []string{
+		"start root.a",
+		"finish root.a",
+		"start root.b",
+		"error root.b err=task failed: failure",
+	}
+	if got := strings.Join(s.events, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("got events:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
 func taskFunc(v cue.Value) (flow.Runner, error) {
 	switch name, err := v.Lookup("$id").String(); name {
 	default:
@@ -0,0 +1,109 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+)
+
+// A Graph is a snapshot of the tasks of a workflow and their dependencies.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// A Node is a task in a Graph.
+type Node struct {
+	// Index is the index of the task, as reported by Task.Index. It is also
+	// the index of the node in Graph.Nodes.
+	Index int
+
+	Path  cue.Path
+	State State
+}
+
+// An Edge indicates that the task at index From depends on the task at
+// index To.
+type Edge struct {
+	From, To int
+}
+
+// Graph reports the current task graph of the workflow.
+//
+// This may currently only be called before Run is called, after it
+// completed, or from within a call to UpdateFunc.
+func (c *Controller) Graph() *Graph {
+	g := &Graph{}
+	for i, t := range c.tasks {
+		g.Nodes = append(g.Nodes, Node{Index: i, Path: t.path, State: t.state})
+		for _, d := range t.depTasks {
+			g.Edges = append(g.Edges, Edge{From: i, To: d.index})
+		}
+	}
+	return g
+}
+
+var mermaidQuote = strings.NewReplacer("#", "#35;", `"`, "#quot;")
+
+// Mermaid returns the graph in the Mermaid flowchart syntax. The result can
+// be pasted into https://mermaid-js.github.io/mermaid-live-editor/ for
+// visualization.
+func (g *Graph) Mermaid() string {
+	w := &strings.Builder{}
+	fmt.Fprintln(w, "graph TD")
+	edges := g.edgesFrom()
+	for _, n := range g.Nodes {
+		path := mermaidQuote.Replace(n.Path.String())
+		fmt.Fprintf(w, "  t%d(\"%s [%s]\")\n", n.Index, path, n.State)
+		for _, to := range edges[n.Index] {
+			fmt.Fprintf(w, "  t%d-->t%d\n", n.Index, to)
+		}
+	}
+	return w.String()
+}
+
+// DOT returns the graph in the Graphviz DOT language.
+func (g *Graph) DOT() string {
+	w := &strings.Builder{}
+	fmt.Fprintln(w, "digraph tasks {")
+	edges := g.edgesFrom()
+	for _, n := range g.Nodes {
+		label := fmt.Sprintf("%s [%s]", n.Path, n.State)
+		fmt.Fprintf(w, "\tt%d [label=%s];\n", n.Index, strconv.Quote(label))
+		for _, to := range edges[n.Index] {
+			fmt.Fprintf(w, "\tt%d -> t%d;\n", n.Index, to)
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return w.String()
+}
+
+// edgesFrom returns the edges of g indexed by the node they start from.
+func (g *Graph) edgesFrom() map[int][]int {
+	m := map[int][]int{}
+	for _, e := range g.Edges {
+		m[e.From] = append(m[e.From], e.To)
+	}
+	return m
+}
+
+// mermaidGraph generates a mermaid graph of the current state.
+func mermaidGraph(c *Controller) string {
+	return c.Graph().Mermaid()
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+// This file contains the logic for limiting the number of tasks that run
+// concurrently and for retrying tasks that fail.
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+)
+
+// TaskAttr is the name of the attribute with which a task may configure how
+// it is scheduled, retried and cached. It may be specified as a field attribute of
+// the task or as a declaration attribute within the task, for instance
+//
+//	fetch: http.Get & {
+//		url: "https://example.com"
+//	} @task(retries=3, backoff=500ms, group=http, limit=2)
+//
+// The following arguments are supported:
+//
+//	retries     the maximum number of times the task is retried if it fails
+//	backoff     the time to wait before the first retry, as a Go duration
+//	maxBackoff  the maximum time to wait between retries
+//	group       the name of a group of tasks to which limit applies
+//	limit       the maximum number of tasks of the group that run concurrently
+//	cache       cache the results of the task if Config.Cache is set
+//
+// The retry arguments override the corresponding fields of Config.Retry.
+// Tasks with a limit that do not specify a group belong to the same
+// unnamed group.
+const TaskAttr = "task"
+
+// DefaultBackoff is the time to wait before the first retry of a task if no
+// backoff is configured.
+const DefaultBackoff = time.Second
+
+// A Retry configures how tasks that fail are retried. The time to wait
+// before a retry doubles with every attempt.
+//
+// Tasks that fail with ErrAbort and tasks of which the context is done are
+// not retried.
+type Retry struct {
+	// Retries is the maximum number of times a task is retried after it
+	// fails.
+	Retries int
+
+	// Backoff is the time to wait before the first retry. If zero,
+	// DefaultBackoff is used.
+	Backoff time.Duration
+
+	// MaxBackoff limits the time to wait between retries. Zero means there
+	// is no limit.
+	MaxBackoff time.Duration
+}
+
+// delay reports the time to wait before the given retry, where 1 is the
+// first retry.
+func (r *Retry) delay(retry int) time.Duration {
+	d := r.Backoff
+	if d <= 0 {
+		d = DefaultBackoff
+	}
+	for i := 1; i < retry; i++ {
+		if r.MaxBackoff > 0 && d >= r.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if r.MaxBackoff > 0 && d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+	return d
+}
+
+// taskOptions holds the scheduling options of a task.
+type taskOptions struct {
+	retry Retry
+	group string
+	limit int // zero means no limit
+	cache bool
+}
+
+// initOptions sets the scheduling options of t from the Config and any
+// TaskAttr attributes of its value.
+func (c *Controller) initOptions(t *Task) error {
+	t.opts = taskOptions{}
+	if c.cfg.Retry != nil {
+		t.opts.retry = *c.cfg.Retry
+	}
+	for _, a := range t.v.Attributes(cue.FieldAttr | cue.DeclAttr) {
+		if a.Name() != TaskAttr {
+			continue
+		}
+		if err := a.Err(); err != nil {
+			return err
+		}
+		for i := 0; i < a.NumArgs(); i++ {
+			key, val := a.Arg(i)
+			var err error
+			switch key {
+			case "retries":
+				t.opts.retry.Retries, err = parseCount(val)
+			case "backoff":
+				t.opts.retry.Backoff, err = time.ParseDuration(val)
+			case "maxBackoff":
+				t.opts.retry.MaxBackoff, err = time.ParseDuration(val)
+			case "group":
+				t.opts.group = val
+			case "cache":
+				if val != "" {
+					err = fmt.Errorf("must not have a value")
+				}
+				t.opts.cache = true
+			case "limit":
+				t.opts.limit, err = parseCount(val)
+				if err == nil && t.opts.limit == 0 {
+					err = fmt.Errorf("must be positive")
+				}
+			default:
+				return errors.Newf(t.v.Pos(), "unknown argument %q in @%s attribute", key, TaskAttr)
+			}
+			if err != nil {
+				return errors.Newf(t.v.Pos(), "invalid %s in @%s attribute: %v", key, TaskAttr, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseCount(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err == nil && n < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return n, err
+}
+
+// canStart reports whether t may start given the Config's MaxParallel and
+// the limit of the group of t.
+func (c *Controller) canStart(t *Task) bool {
+	running, inGroup := 0, 0
+	for _, x := range c.tasks {
+		if x.state != Running {
+			continue
+		}
+		running++
+		if x.opts.limit > 0 && x.opts.group == t.opts.group {
+			inGroup++
+		}
+	}
+	if c.cfg.MaxParallel > 0 && running >= c.cfg.MaxParallel {
+		return false
+	}
+	return t.opts.limit == 0 || inGroup < t.opts.limit
+}
+
+// runWithRetries runs t, retrying it as configured if it fails.
+func (c *Controller) runWithRetries(t *Task) error {
+	ctx := t.Context()
+	for {
+		t.attempts++
+		err := t.r.Run(t, nil)
+		if err == nil || err == ErrAbort || t.attempts > t.opts.retry.Retries {
+			return err
+		}
+		// Discard any results of the failed attempt.
+		t.update = nil
+
+		timer := time.NewTimer(t.opts.retry.delay(t.attempts))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Attempts reports the number of times the task was run, which is larger
+// than one if the task was retried.
+//
+// This method may currently only be called after a Task completed, or from
+// within a call to UpdateFunc.
+func (t *Task) Attempts() int {
+	return t.attempts
+}
@@ -59,17 +59,52 @@ func (c *Controller) runLoop() {
 				waiting = true
 
 			case Ready:
+				c.updateTaskValue(t)
+				if err := c.initOptions(t); err != nil {
+					c.addErr(err, "task options")
+					return
+				}
+				if !c.canStart(t) {
+					// The task is started once another task terminates.
+					break
+				}
+
 				running = true
 
 				t.state = Running
-				c.updateTaskValue(t)
 
 				t.ctxt = eval.NewContext(value.ToInternal(t.v))
 
+				span := c.startTaskSpan(t)
+				c.notify(TaskStarted, t)
+
+				if ok, err := c.lookupCache(t); err != nil {
+					c.addErr(err, "task cache")
+					return
+				} else if ok {
+					if span != nil {
+						span.End(nil, Attribute{Key: AttrTaskCached, Value: true})
+					}
+					go func(t *Task) { t.c.taskCh <- t }(t)
+					break
+				}
+
 				go func(t *Task) {
-					if err := t.r.Run(t, nil); err != nil {
+					if err := c.createWorkspace(t); err != nil {
+						t.err = errors.Promote(err, "task failed")
+					} else if err := c.runWithRetries(t); err != nil {
 						t.err = errors.Promote(err, "task failed")
 					}
+					if err := c.removeWorkspace(t); err != nil && t.err == nil {
+						t.err = errors.Promote(err, "task failed")
+					}
+					if span != nil {
+						var err error
+						if t.err != nil {
+							err = t.err
+						}
+						span.End(err, Attribute{Key: AttrTaskAttempts, Value: t.attempts})
+					}
 
 					t.c.taskCh <- t
 				}(t)
@@ -105,6 +140,11 @@ func (c *Controller) runLoop() {
 
 			switch t.err {
 			case nil:
+				if err := c.storeCache(t); err != nil {
+					c.addErr(err, "task cache")
+					return
+				}
+				c.notify(TaskFinished, t)
 				c.updateTaskResults(t)
 
 			case ErrAbort:
@@ -112,6 +152,7 @@ func (c *Controller) runLoop() {
 				fallthrough
 
 			default:
+				c.notify(TaskFailed, t)
 				c.addErr(t.err, "task failure")
 				return
 			}
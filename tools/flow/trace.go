@@ -0,0 +1,110 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"context"
+)
+
+// Names of the spans and attributes reported to a Tracer.
+const (
+	// RunSpan is the name of the span covering a call to Controller.Run.
+	RunSpan = "cue.flow.run"
+
+	// TaskSpan is the name of the span covering the execution of a task.
+	TaskSpan = "cue.flow.task"
+
+	// AttrRoot holds the root path of a workflow, as set in Config.Root.
+	AttrRoot = "cue.flow.root"
+
+	// AttrTasks holds the number of tasks in a workflow when it completes.
+	AttrTasks = "cue.flow.tasks"
+
+	// AttrTaskPath holds the path of a task.
+	AttrTaskPath = "cue.flow.task.path"
+
+	// AttrTaskIndex holds the index of a task.
+	AttrTaskIndex = "cue.flow.task.index"
+
+	// AttrTaskAttempts holds the number of times a task was run, including
+	// retries, when it terminates.
+	AttrTaskAttempts = "cue.flow.task.attempts"
+
+	// AttrTaskCached is set to true if the results of a task were taken
+	// from the Cache instead of running the task.
+	AttrTaskCached = "cue.flow.task.cached"
+)
+
+// A Tracer creates spans for workflow runs and the tasks they execute.
+//
+// The interface is modeled after the OpenTelemetry tracing API, so that an
+// adapter for an OpenTelemetry trace.Tracer only needs to convert attributes
+// and set the span status from the error passed to End. This avoids a
+// dependency of this package on any particular tracing implementation.
+//
+// A run span is started by Controller.Run and task spans are started as
+// children of the run span. The context returned for a task span is
+// available to its Runner through Task.Context, allowing runners to create
+// child spans of their own.
+type Tracer interface {
+	// Start starts a new span with the given name and attributes as a child
+	// of any span in ctx. It returns a context containing the new span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// A Span records a single operation started by a Tracer.
+type Span interface {
+	// End completes the span, adding the given attributes. If err is not nil,
+	// the operation failed with err.
+	End(err error, attrs ...Attribute)
+}
+
+// An Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key string
+
+	// Value is a string, bool, int, int64 or float64.
+	Value interface{}
+}
+
+// startRunSpan starts a run span if a Tracer is configured and returns a
+// function to end it.
+func (c *Controller) startRunSpan(ctx context.Context) (context.Context, func()) {
+	if c.cfg.Tracer == nil {
+		return ctx, func() {}
+	}
+	ctx, span := c.cfg.Tracer.Start(ctx, RunSpan,
+		Attribute{Key: AttrRoot, Value: c.cfg.Root.String()})
+	return ctx, func() {
+		var err error
+		if c.errs != nil {
+			err = c.errs
+		}
+		span.End(err, Attribute{Key: AttrTasks, Value: len(c.tasks)})
+	}
+}
+
+// startTaskSpan starts a span for t if a Tracer is configured and sets the
+// context of t accordingly. It returns the span or nil if there is no Tracer.
+func (c *Controller) startTaskSpan(t *Task) Span {
+	if c.cfg.Tracer == nil {
+		return nil
+	}
+	ctx, span := c.cfg.Tracer.Start(c.context, TaskSpan,
+		Attribute{Key: AttrTaskPath, Value: t.path.String()},
+		Attribute{Key: AttrTaskIndex, Value: t.index})
+	t.ctx = ctx
+	return span
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"fmt"
+	"os"
+)
+
+// A Workspace configures the temporary directories that are created for
+// tasks. Each task gets its own directory, so that tasks that run
+// concurrently, such as tasks that execute commands, do not clobber each
+// other's files.
+type Workspace struct {
+	// Dir is the directory in which the workspaces are created. The default
+	// is the directory returned by os.TempDir.
+	Dir string
+
+	// Retention determines which workspaces are kept after their task
+	// terminates.
+	Retention Retention
+}
+
+// A Retention determines whether the workspace of a task is removed after
+// the task terminates.
+type Retention int
+
+const (
+	// RemoveWorkspaces removes the workspace of each task when it terminates.
+	RemoveWorkspaces Retention = iota
+
+	// KeepOnFailure keeps the workspaces of tasks that fail, which allows
+	// them to be inspected, and removes all others.
+	KeepOnFailure
+
+	// KeepWorkspaces keeps the workspaces of all tasks. The user of this
+	// package is responsible for removing them.
+	KeepWorkspaces
+)
+
+// createWorkspace creates the workspace of t if the Controller is configured
+// to do so.
+func (c *Controller) createWorkspace(t *Task) error {
+	if c.cfg.Workspace == nil {
+		return nil
+	}
+	dir, err := os.MkdirTemp(c.cfg.Workspace.Dir, fmt.Sprintf("cue-task%d-", t.index))
+	if err != nil {
+		return fmt.Errorf("cannot create workspace: %v", err)
+	}
+	t.workspace = dir
+	return nil
+}
+
+// removeWorkspace removes the workspace of the terminated task t, unless it
+// is retained.
+func (c *Controller) removeWorkspace(t *Task) error {
+	if t.workspace == "" {
+		return nil
+	}
+	switch c.cfg.Workspace.Retention {
+	case KeepOnFailure:
+		if t.err != nil {
+			return nil
+		}
+	case KeepWorkspaces:
+		return nil
+	}
+	if err := os.RemoveAll(t.workspace); err != nil {
+		return fmt.Errorf("cannot remove workspace: %v", err)
+	}
+	return nil
+}
+
+// Workspace reports the directory created for this task if the Controller is
+// configured with a Workspace, or "" otherwise. The directory exists while
+// the task is running. Whether it exists after the task terminates is
+// determined by the Retention of the Workspace.
+func (t *Task) Workspace() string {
+	return t.workspace
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lint reports suspicious constructs in CUE packages, such as
+// unused imports or constraints that accept any value.
+//
+// The checks are implemented as rules. The package registers a set of
+// built-in rules; tools may register additional rules with Register:
+//
+//	func init() {
+//		lint.Register(&lint.Rule{
+//			Name: "no-todo",
+//			Doc:  "report TODO comments",
+//			Run: func(p *lint.Pass) {
+//				for _, f := range p.Files() {
+//					ast.Walk(f, func(n ast.Node) bool {
+//						if cg, ok := n.(*ast.CommentGroup); ok && strings.Contains(cg.Text(), "TODO") {
+//							p.Reportf(cg.Pos(), "TODO comment")
+//						}
+//						return true
+//					}, nil)
+//				}
+//			},
+//		})
+//	}
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/token"
+)
+
+// A Rule is a check for a class of suspicious constructs.
+type Rule struct {
+	// Name identifies the rule, for instance to select it on the command
+	// line. It must be unique.
+	Name string
+
+	// Doc is a one-line description of what the rule reports.
+	Doc string
+
+	// Run checks the package of the given pass and reports its findings
+	// with Pass.Reportf.
+	Run func(p *Pass)
+}
+
+// A Pass holds the package checked by a single rule.
+type Pass struct {
+	// Rule is the rule being run.
+	Rule *Rule
+
+	// Instance is the package being checked.
+	Instance *build.Instance
+
+	// Value is the evaluated package. It may be an error if the package
+	// does not evaluate, in which case rules that need it should not
+	// report anything.
+	Value cue.Value
+
+	diags *[]Diagnostic
+}
+
+// Files returns the parsed files of the package.
+func (p *Pass) Files() []*ast.File {
+	return p.Instance.Files
+}
+
+// Reportf reports a finding at the given position.
+func (p *Pass) Reportf(pos token.Pos, format string, args ...interface{}) {
+	*p.diags = append(*p.diags, Diagnostic{
+		Pos:     pos,
+		Rule:    p.Rule.Name,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// A Diagnostic is a finding reported by a rule.
+type Diagnostic struct {
+	Pos     token.Pos
+	Rule    string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Rule)
+}
+
+var (
+	mu    sync.Mutex
+	rules = map[string]*Rule{}
+)
+
+// Register makes a rule available by its name. It panics if the rule has no
+// name or a rule with the same name is already registered.
+func Register(r *Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r.Name == "" {
+		panic("lint: rule has no name")
+	}
+	if _, ok := rules[r.Name]; ok {
+		panic("lint: rule " + r.Name + " registered twice")
+	}
+	rules[r.Name] = r
+}
+
+// Rules returns all registered rules, sorted by name.
+func Rules() []*Rule {
+	mu.Lock()
+	defer mu.Unlock()
+	a := make([]*Rule, 0, len(rules))
+	for _, r := range rules {
+		a = append(a, r)
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].Name < a[j].Name })
+	return a
+}
+
+// Lookup returns the registered rule with the given name, or nil if there
+// is no such rule.
+func Lookup(name string) *Rule {
+	mu.Lock()
+	defer mu.Unlock()
+	return rules[name]
+}
+
+// Run checks a package with the given rules, or with all registered rules
+// if rules is empty. The value v is the evaluated package inst. Run returns
+// the diagnostics sorted by position.
+func Run(inst *build.Instance, v cue.Value, rules []*Rule) []Diagnostic {
+	if len(rules) == 0 {
+		rules = Rules()
+	}
+	var diags []Diagnostic
+	for _, r := range rules {
+		r.Run(&Pass{
+			Rule:     r,
+			Instance: inst,
+			Value:    v,
+			diags:    &diags,
+		})
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Pos, diags[j].Pos
+		if a.Filename() != b.Filename() {
+			return a.Filename() < b.Filename()
+		}
+		return a.Offset() < b.Offset()
+	})
+	return diags
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+	}{{
+		name: "unused import",
+		in: `
+import (
+	"strings"
+	"list"
+)
+
+a: strings.ToUpper("a")
+`,
+		out: `in.cue:4:2: "list" imported and not used (unused-import)`,
+	}, {
+		name: "shadowed field",
+		in: `
+name: "foo"
+metadata: name: name
+other: name: "bar"
+for k, v in {a: 1} {
+	list: [for v in [1] {v}]
+}
+`,
+		out: `in.cue:3:11: field name shadows the field name of an enclosing scope (shadowed-field)
+in.cue:6:13: variable v shadows the variable v of an enclosing scope (shadowed-field)`,
+	}, {
+		name: "always true",
+		in: `
+a: int | _
+b: *1 | _
+c: =~".*"
+d: =~"a"
+if 1 < 2 {
+	e: 1
+}
+if a > 2 {
+	f: 1
+}
+`,
+		out: `in.cue:2:10: disjunction with _ accepts any value (always-true)
+in.cue:4:4: =~".*" matches any string (always-true)
+in.cue:6:4: condition is always true (always-true)`,
+	}, {
+		name: "deprecated",
+		in: `
+#Config: {
+	old?: int @deprecated(use new)
+	new?: int
+}
+c: #Config & {
+	old: 1
+	new: 2
+}
+legacy: 1 @deprecated()
+ref: legacy
+`,
+		out: `in.cue:7:7: old is deprecated: use new (deprecated)
+in.cue:11:6: legacy is deprecated (deprecated)`,
+	}, {
+		name: "unreferenced definition",
+		in: `
+_#Used: int
+_#Unused: string
+#Exported: string
+a: _#Used
+`,
+		out: `in.cue:3:1: hidden definition _#Unused is never referenced (unreferenced-definition)`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inst := build.NewContext().NewInstance("", nil)
+			if err := inst.AddFile("in.cue", tc.in); err != nil {
+				t.Fatal(err)
+			}
+			v := cuecontext.New().BuildInstance(inst)
+			var a []string
+			for _, d := range Run(inst, v, nil) {
+				a = append(a, d.String())
+			}
+			if got := strings.Join(a, "\n"); got != tc.out {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.out)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := &Rule{
+		Name: "test-no-todo",
+		Doc:  "report TODO comments",
+		Run: func(p *Pass) {
+			for _, f := range p.Files() {
+				ast.Walk(f, func(n ast.Node) bool {
+					if cg, ok := n.(*ast.CommentGroup); ok && strings.Contains(cg.Text(), "TODO") {
+						p.Reportf(cg.Pos(), "TODO comment")
+					}
+					return true
+				}, nil)
+			}
+		},
+	}
+	Register(r)
+	defer func() {
+		mu.Lock()
+		delete(rules, r.Name)
+		mu.Unlock()
+	}()
+	if Lookup(r.Name) != r {
+		t.Errorf("Lookup(%q) did not return the registered rule", r.Name)
+	}
+
+	inst := build.NewContext().NewInstance("", nil)
+	if err := inst.AddFile("in.cue", "// TODO: fix\na: 1\n"); err != nil {
+		t.Fatal(err)
+	}
+	v := cuecontext.New().BuildInstance(inst)
+	diags := Run(inst, v, []*Rule{r})
+	if len(diags) != 1 || diags[0].String() != "in.cue:1:1: TODO comment (test-no-todo)" {
+		t.Errorf("unexpected diagnostics %v", diags)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a rule twice did not panic")
+		}
+	}()
+	Register(r)
+}
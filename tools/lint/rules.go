@@ -0,0 +1,507 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/token"
+)
+
+func init() {
+	Register(&Rule{
+		Name: "unused-import",
+		Doc:  "report imports that are not used",
+		Run:  unusedImports,
+	})
+	Register(&Rule{
+		Name: "shadowed-field",
+		Doc:  "report references that resolve to a declaration hiding another of the same name",
+		Run:  shadowedFields,
+	})
+	Register(&Rule{
+		Name: "always-true",
+		Doc:  "report constraints and conditions that hold for any value",
+		Run:  alwaysTrue,
+	})
+	Register(&Rule{
+		Name: "deprecated",
+		Doc:  "report uses of fields marked with a @deprecated attribute",
+		Run:  deprecated,
+	})
+	Register(&Rule{
+		Name: "unreferenced-definition",
+		Doc:  "report hidden definitions that are never referenced",
+		Run:  unreferencedDefinitions,
+	})
+}
+
+func unusedImports(p *Pass) {
+	for _, f := range p.Files() {
+		used := map[*ast.ImportSpec]bool{}
+		ast.Walk(f, func(n ast.Node) bool {
+			if x, ok := n.(*ast.Ident); ok {
+				if spec, ok := x.Node.(*ast.ImportSpec); ok {
+					used[spec] = true
+				}
+			}
+			return true
+		}, nil)
+		for _, spec := range f.Imports {
+			if spec.Name != nil && spec.Name.Name == "_" {
+				continue
+			}
+			if !used[spec] {
+				p.Reportf(spec.Pos(), "%s imported and not used", spec.Path.Value)
+			}
+		}
+	}
+}
+
+// shadowedFields reports declarations that hide a declaration of the same
+// name in an enclosing scope, if a reference resolves to them. Such
+// references are easily mistaken to refer to the outer declaration, as in
+//
+//	name: "foo"
+//	metadata: name: name // refers to itself
+func shadowedFields(p *Pass) {
+	c := &shadowChecker{p: p, reported: map[ast.Node]bool{}}
+	for _, f := range p.Files() {
+		c.walk(f)
+	}
+}
+
+type shadowChecker struct {
+	p        *Pass
+	scopes   []map[string]declaration
+	reported map[ast.Node]bool
+}
+
+type declaration struct {
+	kind string
+	node ast.Node
+}
+
+func (c *shadowChecker) push(decls []ast.Decl) {
+	s := map[string]declaration{}
+	add := func(name, kind string, n ast.Node) {
+		if _, ok := s[name]; !ok && name != "" && name != "_" {
+			s[name] = declaration{kind, n}
+		}
+	}
+	for _, d := range decls {
+		switch x := d.(type) {
+		case *ast.ImportDecl:
+			for _, spec := range x.Specs {
+				name := ""
+				if spec.Name != nil {
+					name = spec.Name.Name
+				} else if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+					name = path[strings.LastIndexByte(path, '/')+1:]
+					if i := strings.IndexByte(name, ':'); i >= 0 {
+						name = name[i+1:]
+					}
+				}
+				add(name, "import", spec)
+			}
+		case *ast.Field:
+			label := x.Label
+			if a, ok := label.(*ast.Alias); ok {
+				add(a.Ident.Name, "alias", a.Ident)
+				label, _ = a.Expr.(ast.Label)
+			}
+			if name, isIdent, _ := ast.LabelName(label); isIdent {
+				kind := "field"
+				if strings.HasPrefix(name, "#") || strings.HasPrefix(name, "_#") {
+					kind = "definition"
+				}
+				add(name, kind, label)
+			}
+		case *ast.LetClause:
+			add(x.Ident.Name, "let", x.Ident)
+		case *ast.Alias:
+			add(x.Ident.Name, "alias", x.Ident)
+		}
+	}
+	c.scopes = append(c.scopes, s)
+}
+
+func (c *shadowChecker) pushIdents(kind string, idents ...*ast.Ident) {
+	s := map[string]declaration{}
+	for _, x := range idents {
+		if x != nil && x.Name != "_" {
+			s[x.Name] = declaration{kind, x}
+		}
+	}
+	c.scopes = append(c.scopes, s)
+}
+
+func (c *shadowChecker) pop(n int) {
+	c.scopes = c.scopes[:len(c.scopes)-n]
+}
+
+func (c *shadowChecker) walk(n ast.Node) {
+	if n != nil {
+		ast.Walk(n, c.before, nil)
+	}
+}
+
+func (c *shadowChecker) before(n ast.Node) bool {
+	switch x := n.(type) {
+	case *ast.File:
+		c.push(x.Decls)
+		for _, d := range x.Decls {
+			c.walk(d)
+		}
+		c.pop(1)
+
+	case *ast.StructLit:
+		c.push(x.Elts)
+		for _, d := range x.Elts {
+			c.walk(d)
+		}
+		c.pop(1)
+
+	case *ast.Field:
+		var label ast.Node = x.Label
+		if a, ok := label.(*ast.Alias); ok {
+			label = a.Expr
+		}
+		scopes := 0
+		switch l := label.(type) {
+		case *ast.ParenExpr, *ast.Interpolation:
+			c.walk(l)
+		case *ast.ListLit:
+			for _, e := range l.Elts {
+				if a, ok := e.(*ast.Alias); ok {
+					c.pushIdents("alias", a.Ident)
+					scopes++
+					e = a.Expr
+				}
+				c.walk(e)
+			}
+		}
+		v := x.Value
+		if a, ok := v.(*ast.Alias); ok {
+			c.pushIdents("alias", a.Ident)
+			scopes++
+			v = a.Expr
+		}
+		c.walk(v)
+		c.pop(scopes)
+
+	case *ast.Comprehension:
+		scopes := 0
+		for _, cl := range x.Clauses {
+			switch y := cl.(type) {
+			case *ast.ForClause:
+				c.walk(y.Source)
+				c.pushIdents("variable", y.Key, y.Value)
+				scopes++
+			case *ast.LetClause:
+				c.walk(y.Expr)
+				c.pushIdents("let", y.Ident)
+				scopes++
+			case *ast.IfClause:
+				c.walk(y.Condition)
+			}
+		}
+		c.walk(x.Value)
+		c.pop(scopes)
+
+	case *ast.LetClause:
+		c.walk(x.Expr)
+
+	case *ast.Alias:
+		c.walk(x.Expr)
+
+	case *ast.SelectorExpr:
+		c.walk(x.X)
+
+	case *ast.Ident:
+		c.reference(x)
+
+	case *ast.ImportDecl, *ast.Package, *ast.Attribute:
+
+	default:
+		return true
+	}
+	return false
+}
+
+// reference reports the declaration to which x resolves if it shadows
+// another declaration.
+func (c *shadowChecker) reference(x *ast.Ident) {
+	inner := -1
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		d, ok := c.scopes[i][x.Name]
+		if !ok {
+			continue
+		}
+		if inner < 0 {
+			inner = i
+			continue
+		}
+		decl := c.scopes[inner][x.Name]
+		if !c.reported[decl.node] {
+			c.reported[decl.node] = true
+			c.p.Reportf(decl.node.Pos(), "%s %s shadows the %s %s of an enclosing scope",
+				decl.kind, x.Name, d.kind, x.Name)
+		}
+		return
+	}
+}
+
+// alwaysTrue reports disjunctions that accept any value, regular
+// expressions that match any string, and comprehension conditions that
+// always hold.
+func alwaysTrue(p *Pass) {
+	var ctx *cue.Context
+	for _, f := range p.Files() {
+		inner := map[*ast.BinaryExpr]bool{}
+		ast.Walk(f, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.BinaryExpr:
+				if x.Op != token.OR || inner[x] {
+					break
+				}
+				disjuncts := flattenOr(x, inner)
+				var top ast.Expr
+				for _, d := range disjuncts {
+					if u, ok := d.(*ast.UnaryExpr); ok && u.Op == token.MUL {
+						// A default makes _ a meaningful fallback.
+						top = nil
+						break
+					}
+					if id, ok := d.(*ast.Ident); ok && id.Name == "_" && top == nil {
+						top = d
+					}
+				}
+				if top != nil {
+					p.Reportf(top.Pos(), "disjunction with _ accepts any value")
+				}
+
+			case *ast.UnaryExpr:
+				if x.Op != token.MAT {
+					break
+				}
+				lit, ok := x.X.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					break
+				}
+				switch s, _ := strconv.Unquote(lit.Value); s {
+				case "", ".*", "^.*", ".*$", "^.*$":
+					p.Reportf(x.Pos(), "=~%s matches any string", lit.Value)
+				}
+
+			case *ast.IfClause:
+				if !isConstant(x.Condition) {
+					break
+				}
+				if ctx == nil {
+					ctx = cuecontext.New()
+				}
+				if b, err := ctx.BuildExpr(x.Condition).Bool(); err == nil && b {
+					p.Reportf(x.Condition.Pos(), "condition is always true")
+				}
+			}
+			return true
+		}, nil)
+	}
+}
+
+// flattenOr returns the disjuncts of x, marking nested disjunctions in
+// inner.
+func flattenOr(x ast.Expr, inner map[*ast.BinaryExpr]bool) []ast.Expr {
+	b, ok := x.(*ast.BinaryExpr)
+	if !ok || b.Op != token.OR {
+		return []ast.Expr{x}
+	}
+	inner[b] = true
+	return append(flattenOr(b.X, inner), flattenOr(b.Y, inner)...)
+}
+
+// isConstant reports whether x does not contain references.
+func isConstant(x ast.Expr) bool {
+	constant := true
+	ast.Walk(x, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.Ident, *ast.Comprehension:
+			constant = false
+		}
+		return constant
+	}, nil)
+	return constant
+}
+
+// deprecated reports fields of the package that unify with a field that has
+// a @deprecated attribute, and references to such fields, with the
+// contents of the attribute, if any, as an explanation.
+func deprecated(p *Pass) {
+	files := map[string]bool{}
+	for _, f := range p.Files() {
+		files[f.Filename] = true
+	}
+
+	// Collect the values of deprecated fields, which are declarations
+	// of the field that are not uses.
+	decls := map[token.Pos]bool{}
+	fields := map[ast.Node]*ast.Field{}
+	seenInst := map[*build.Instance]bool{}
+	var collect func(inst *build.Instance)
+	collect = func(inst *build.Instance) {
+		if inst == nil || seenInst[inst] {
+			return
+		}
+		seenInst[inst] = true
+		for _, f := range inst.Files {
+			ast.Walk(f, func(n ast.Node) bool {
+				if x, ok := n.(*ast.Field); ok && isDeprecated(x) {
+					v := x.Value
+					if a, ok := v.(*ast.Alias); ok {
+						v = a.Expr
+					}
+					decls[v.Pos()] = true
+					fields[v] = x
+				}
+				return true
+			}, nil)
+		}
+		for _, imp := range inst.Imports {
+			collect(imp)
+		}
+	}
+	collect(p.Instance)
+
+	seen := map[token.Pos]bool{}
+	report := func(pos token.Pos, name, reason string) {
+		if !pos.IsValid() || decls[pos] || !files[pos.Filename()] || seen[pos] {
+			return
+		}
+		seen[pos] = true
+		if reason != "" {
+			reason = ": " + reason
+		}
+		p.Reportf(pos, "%s is deprecated%s", name, reason)
+	}
+
+	for _, f := range p.Files() {
+		ast.Walk(f, func(n ast.Node) bool {
+			if x, ok := n.(*ast.Ident); ok {
+				if field, ok := fields[x.Node]; ok {
+					report(x.Pos(), x.Name, deprecation(field))
+				}
+			}
+			return true
+		}, nil)
+	}
+
+	if p.Value.Err() != nil {
+		return
+	}
+	var walk func(v cue.Value)
+	walk = func(v cue.Value) {
+		switch v.IncompleteKind() {
+		case cue.StructKind:
+			iter, err := v.Fields(cue.Definitions(true), cue.Hidden(true))
+			if err != nil {
+				return
+			}
+			for iter.Next() {
+				f := iter.Value()
+				if a := f.Attribute("deprecated"); a.Err() == nil {
+					for _, o := range f.Provenance() {
+						report(o.Pos, iter.Selector().String(), a.Contents())
+					}
+				}
+				walk(f)
+			}
+		case cue.ListKind:
+			iter, err := v.List()
+			if err != nil {
+				return
+			}
+			for iter.Next() {
+				walk(iter.Value())
+			}
+		}
+	}
+	walk(p.Value)
+}
+
+func isDeprecated(f *ast.Field) bool {
+	for _, a := range f.Attrs {
+		if key, _ := a.Split(); key == "deprecated" {
+			return true
+		}
+	}
+	return false
+}
+
+// deprecation returns the contents of the @deprecated attribute of f.
+func deprecation(f *ast.Field) string {
+	for _, a := range f.Attrs {
+		if key, body := a.Split(); key == "deprecated" {
+			return body
+		}
+	}
+	return ""
+}
+
+// unreferencedDefinitions reports hidden definitions that are not referenced
+// anywhere in the package. Other definitions may be used by importing
+// packages.
+func unreferencedDefinitions(p *Pass) {
+	labels := map[*ast.Ident]bool{}
+	var defs []*ast.Ident
+	for _, f := range p.Files() {
+		ast.Walk(f, func(n ast.Node) bool {
+			if x, ok := n.(*ast.Field); ok {
+				label := x.Label
+				if a, ok := label.(*ast.Alias); ok {
+					label, _ = a.Expr.(ast.Label)
+				}
+				if id, ok := label.(*ast.Ident); ok {
+					labels[id] = true
+					if strings.HasPrefix(id.Name, "_#") {
+						defs = append(defs, id)
+					}
+				}
+			}
+			return true
+		}, nil)
+	}
+	used := map[string]bool{}
+	for _, f := range p.Files() {
+		ast.Walk(f, func(n ast.Node) bool {
+			if x, ok := n.(*ast.Ident); ok && !labels[x] {
+				used[x.Name] = true
+			}
+			return true
+		}, nil)
+	}
+	reported := map[string]bool{}
+	for _, id := range defs {
+		if !used[id.Name] && !reported[id.Name] {
+			reported[id.Name] = true
+			p.Reportf(id.Pos(), "hidden definition %s is never referenced", id.Name)
+		}
+	}
+}
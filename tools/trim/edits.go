@@ -0,0 +1,93 @@
+// Copyright 2024 CUE Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trim
+
+import (
+	"bytes"
+
+	"github.com/rogpeppe/go-internal/diff"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+)
+
+// A FileEdit describes the changes that trimming makes to a single file.
+type FileEdit struct {
+	Filename string
+
+	// Original and Trimmed hold the formatted contents of the file before and
+	// after trimming.
+	Original []byte
+	Trimmed  []byte
+
+	// Removals holds the fields removed from the file.
+	Removals []Removal
+}
+
+// Diff returns the changes of e as a unified diff.
+func (e *FileEdit) Diff() []byte {
+	return diff.Diff(e.Filename, e.Original, e.Filename, e.Trimmed)
+}
+
+// Edits trims the given files like Files, but instead of leaving it to the
+// caller to write the modified files, it reports the proposed changes for
+// each file that is changed by trimming. This allows the changes to be
+// reviewed, or offered as suggestions, before they are applied.
+//
+// As with Files, the given files are modified in place. The files are
+// formatted with opts, both before and after trimming, so that Original and
+// Trimmed only differ in the removed fields, unless the original file was
+// not formatted consistently with opts.
+func Edits(files []*ast.File, inst cue.InstanceOrValue, cfg *Config, opts ...format.Option) ([]FileEdit, error) {
+	edits := make([]FileEdit, len(files))
+	index := map[string]int{}
+	for i, f := range files {
+		b, err := format.Node(f, opts...)
+		if err != nil {
+			return nil, err
+		}
+		edits[i] = FileEdit{Filename: f.Filename, Original: b}
+		index[f.Filename] = i
+	}
+
+	c := *cfg
+	c.Report = func(r Removal) {
+		if i, ok := index[r.Field.Pos().Filename()]; ok {
+			edits[i].Removals = append(edits[i].Removals, r)
+		}
+		if cfg.Report != nil {
+			cfg.Report(r)
+		}
+	}
+	if err := Files(files, inst, &c); err != nil {
+		return nil, err
+	}
+
+	changed := edits[:0]
+	for i, f := range files {
+		b, err := format.Node(f, opts...)
+		if err != nil {
+			return nil, err
+		}
+		e := edits[i]
+		if bytes.Equal(e.Original, b) {
+			continue
+		}
+		e.Trimmed = b
+		changed = append(changed, e)
+	}
+	return changed, nil
+}
@@ -50,10 +50,12 @@ package trim
 import (
 	"io"
 	"os"
+	"strings"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/internal/core/adt"
 	"cuelang.org/go/internal/core/debug"
 	"cuelang.org/go/internal/core/subsume"
@@ -64,6 +66,25 @@ import (
 // Config configures trim options.
 type Config struct {
 	Trace bool
+
+	// Report, if not nil, is called for each removed field.
+	Report func(r Removal)
+}
+
+// A Removal describes a field removed by Files.
+type Removal struct {
+	// Field is the removed field.
+	Field *ast.Field
+
+	// Path is the path of the removed value.
+	Path string
+
+	// Reason describes why the field could be removed, such as
+	// "implied by definition".
+	Reason string
+
+	// By is the position of a value implying the removed value, if known.
+	By token.Pos
 }
 
 // Files trims fields in the given files that can be implied from other fields,
@@ -76,7 +97,8 @@ func Files(files []*ast.File, inst cue.InstanceOrValue, cfg *Config) error {
 	t := &trimmer{
 		Config:  *cfg,
 		ctx:     adt.NewContext(r, v),
-		remove:  map[ast.Node]bool{},
+		remove:  map[ast.Node]*Removal{},
+		implied: map[ast.Node]*Removal{},
 		exclude: map[ast.Node]bool{},
 		debug:   Debug,
 		w:       os.Stderr,
@@ -109,12 +131,42 @@ func Files(files []*ast.File, inst cue.InstanceOrValue, cfg *Config) error {
 
 	// Remove subordinate values from files.
 	for _, f := range files {
+		// emptied holds the structs from which fields were removed.
+		emptied := map[ast.Node]bool{}
+		remove := func(c astutil.Cursor, f *ast.Field, r *Removal) {
+			c.Delete()
+			emptied[c.Parent().Node()] = true
+			if cfg.Report != nil {
+				r := *r
+				r.Field = f
+				cfg.Report(r)
+			}
+		}
 		astutil.Apply(f, func(c astutil.Cursor) bool {
-			if f, ok := c.Node().(*ast.Field); ok && t.remove[f.Value] && !t.exclude[f.Value] {
-				c.Delete()
+			f, ok := c.Node().(*ast.Field)
+			if !ok {
+				return true
+			}
+			if r := t.remove[f.Value]; r != nil && !t.exclude[f.Value] {
+				remove(c, f, r)
+				return false
+			}
+			return true
+		}, func(c astutil.Cursor) bool {
+			// Remove structs left empty by trimming if they exist regardless.
+			f, ok := c.Node().(*ast.Field)
+			if !ok {
+				return true
+			}
+			s, ok := f.Value.(*ast.StructLit)
+			if !ok || len(s.Elts) > 0 || !emptied[s] || t.exclude[s] {
+				return true
+			}
+			if r := t.implied[s]; r != nil {
+				remove(c, f, r)
 			}
 			return true
-		}, nil)
+		})
 		if err := astutil.Sanitize(f); err != nil {
 			return err
 		}
@@ -127,9 +179,14 @@ type trimmer struct {
 	Config
 
 	ctx     *adt.OpContext
-	remove  map[ast.Node]bool
+	remove  map[ast.Node]*Removal
 	exclude map[ast.Node]bool
 
+	// implied holds the structs defining values that exist regardless of
+	// these structs, as they are also generated by a comprehension or are
+	// required by a definition.
+	implied map[ast.Node]*Removal
+
 	debug  bool
 	indent int
 	w      io.Writer
@@ -137,9 +194,9 @@ type trimmer struct {
 
 var Debug bool = false
 
-func (t *trimmer) markRemove(c adt.Conjunct) {
+func (t *trimmer) markRemove(c adt.Conjunct, r *Removal) {
 	if src := c.Elem().Source(); src != nil {
-		t.remove[src] = true
+		t.remove[src] = r
 		if t.debug {
 			t.logf("removing %s", debug.NodeString(t.ctx, c.Elem(), nil))
 		}
@@ -280,9 +337,18 @@ func (t *trimmer) findSubordinates(doms, v *adt.Vertex, hasDisjunction bool) (re
 	doms, hasSubs, ambiguous, pickedDefault := t.addDominators(doms, v, hasDisjunction)
 
 	if ambiguous {
+		// Values that are entirely implied, such as incomplete values
+		// generated by a comprehension, need not be kept.
+		if !hasSubs {
+			return maybe
+		}
 		return no
 	}
 
+	if hasSubs {
+		t.markImplied(v)
+	}
+
 	// TODO(structure sharing): do not descend into vertices whose parent is not
 	// equal to the parent. This is not relevant at this time, but may be so in
 	// the future.
@@ -298,7 +364,12 @@ func (t *trimmer) findSubordinates(doms, v *adt.Vertex, hasDisjunction bool) (re
 		// this case we need to preserve the scalar to keep the type of the
 		// struct intact, which might as well be done by not removing the scalar
 		// type.
-		if match&yes == 0 || match&no != 0 {
+		if match&no != 0 {
+			return match
+		}
+		// A struct for which all fields are implied may itself only be
+		// removed if it was left empty, as from earlier trimming.
+		if match&yes == 0 && !onlyEmptyStructs(v) {
 			return match
 		}
 	}
@@ -337,12 +408,103 @@ func (t *trimmer) findSubordinates(doms, v *adt.Vertex, hasDisjunction bool) (re
 		}
 	}
 
+	var r *Removal
+	if t.Report != nil {
+		r = t.removal(v)
+	} else {
+		r = &Removal{}
+	}
 	for _, c := range v.Conjuncts {
 		_, allowRemove := isDominator(c)
 		if !allowRemove && removable(c, v) {
-			t.markRemove(c)
+			t.markRemove(c, r)
 		}
 	}
 
 	return yes
 }
+
+// markImplied records the struct literals defining v if v is a regular
+// field of a comprehension or definition, so that they may be removed if
+// trimming leaves them empty. Fields of pattern constraints are excluded, as
+// they only apply to fields that are defined elsewhere.
+func (t *trimmer) markImplied(v *adt.Vertex) {
+	implied := false
+	for _, c := range v.Conjuncts {
+		if !c.CloseInfo.IsInOneOf(adt.ComprehensionSpan|adt.DefinitionSpan) ||
+			c.CloseInfo.IsInOneOf(adt.ConstraintSpan) {
+			continue
+		}
+		if f, ok := c.Field().(*adt.Field); ok && f.ArcType == adt.ArcMember {
+			implied = true
+			break
+		}
+	}
+	if !implied {
+		return
+	}
+	var r *Removal
+	for _, c := range v.Conjuncts {
+		if isDom, _ := isDominator(c); isDom {
+			continue
+		}
+		if src, ok := c.Elem().Source().(*ast.StructLit); ok {
+			if r == nil {
+				r = &Removal{}
+				if t.Report != nil {
+					r = t.removal(v)
+					r.Reason = "left empty; " + r.Reason
+				}
+			}
+			t.implied[src] = r
+		}
+	}
+}
+
+// removal describes the removal of the subordinate values of v, naming the
+// kind of the first dominator value of v.
+func (t *trimmer) removal(v *adt.Vertex) *Removal {
+	var path []string
+	for _, f := range v.Path() {
+		path = append(path, f.SelectorString(t.ctx))
+	}
+	r := &Removal{Path: strings.Join(path, "."), Reason: "implied by other values"}
+	for _, c := range v.Conjuncts {
+		if isDom, _ := isDominator(c); !isDom {
+			continue
+		}
+		kind := "pattern constraint"
+		switch {
+		case c.CloseInfo.IsInOneOf(adt.ComprehensionSpan):
+			kind = "comprehension"
+		case c.CloseInfo.IsInOneOf(adt.DefinitionSpan):
+			kind = "definition"
+		}
+		r.Reason = "implied by " + kind
+		if x, ok := c.Elem().(*adt.DisjunctionExpr); ok && x.HasDefaults {
+			r.Reason = "matches default of " + kind
+		}
+		if src := c.Source(); src != nil {
+			r.By = src.Pos()
+		}
+		break
+	}
+	return r
+}
+
+// onlyEmptyStructs reports whether v has non-dominator conjuncts and all of
+// them are empty structs.
+func onlyEmptyStructs(v *adt.Vertex) bool {
+	n := 0
+	for _, c := range v.Conjuncts {
+		if isDom, _ := isDominator(c); isDom {
+			continue
+		}
+		s, ok := c.Elem().(*adt.StructLit)
+		if !ok || len(s.Decls) > 0 {
+			return false
+		}
+		n++
+	}
+	return n > 0
+}
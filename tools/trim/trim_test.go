@@ -15,10 +15,14 @@
 package trim
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/format"
@@ -247,6 +251,48 @@ group: {
 foo: entry: {
 	a: "insert"
 }
+`,
+	}, {
+		name: "remove structs generated by comprehension",
+		in: `
+foo: [string]: {
+	a: string
+	b: "--\(a)--"
+}
+foo: bar: a: "x"
+foo: baz: {}
+
+group: {
+	for k, v in foo {
+		comp: "\(k)": v
+	}
+	comp: bar: a: "x"
+	comp: baz: {}
+}
+		`,
+		out: `foo: [string]: {
+	a: string
+	b: "--\(a)--"
+}
+foo: bar: a: "x"
+foo: baz: {}
+
+group: {
+	for k, v in foo {
+		comp: "\(k)": v
+	}
+}
+`,
+	}, {
+		name: "keep structs of optional fields left empty",
+		in: `
+#D: {a?: b: {c: 1}}
+x: #D
+x: a: b: {c: 1}
+		`,
+		out: `#D: {a?: b: {c: 1}}
+x: #D
+x: a: {}
 `,
 	}}
 	for _, tc := range testCases {
@@ -273,6 +319,90 @@ foo: entry: {
 	}
 }
 
+func TestReport(t *testing.T) {
+	f, err := parser.ParseFile("test", `
+#D: {a: *1 | int, b: c: 2}
+x: #D
+x: a: 1
+x: b: c: 2
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := cuecontext.New().BuildFile(f)
+	var got []string
+	err = Files([]*ast.File{f}, v, &Config{
+		Report: func(r Removal) {
+			got = append(got, fmt.Sprintf("%v %s: %s (%v)", r.Field.Pos(), r.Path, r.Reason, r.By))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"test:4:4 x.a: matches default of definition (test:2:6)",
+		"test:5:4 x.b: implied by definition (test:2:19)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEdits(t *testing.T) {
+	parse := func(name, src string) *ast.File {
+		f, err := parser.ParseFile(name, src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	files := []*ast.File{
+		parse("a.cue", `package p
+
+#D: {a: *1 | int}
+`),
+		parse("b.cue", `package p
+
+x: #D
+x: a: 1 // removed
+`),
+	}
+	inst := build.NewContext().NewInstance("", nil)
+	for _, f := range files {
+		if err := inst.AddSyntax(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v := cuecontext.New().BuildInstance(inst)
+	edits, err := Edits(files, v, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 {
+		t.Fatalf("got %d edits; want 1", len(edits))
+	}
+	e := edits[0]
+	if e.Filename != "b.cue" {
+		t.Errorf("got file %s; want b.cue", e.Filename)
+	}
+	if len(e.Removals) != 1 || e.Removals[0].Path != "x.a" {
+		t.Errorf("unexpected removals %v", e.Removals)
+	}
+	want := `diff b.cue b.cue
+--- b.cue
++++ b.cue
+@@ -1,4 +1,4 @@
+ package p
+ 
+ x: #D
+-x: a: 1 // removed
++x: {} // removed
+`
+	if got := string(e.Diff()); got != want {
+		t.Errorf("got diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
 const trace = false
 
 func TestData(t *testing.T) {